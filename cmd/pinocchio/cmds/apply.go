@@ -0,0 +1,109 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/edits"
+)
+
+type ApplySettings struct {
+	Root   string `glazed:"root"`
+	Yes    bool   `glazed:"yes"`
+	DryRun bool   `glazed:"dry-run"`
+	Input  string `glazed:"input"`
+}
+
+type ApplyCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = &ApplyCommand{}
+
+func NewApplyCommand() (*ApplyCommand, error) {
+	return &ApplyCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"apply",
+			cmds.WithShort("Write the file edits of a model answer to the working tree"),
+			cmds.WithLong(`Find the edited files in a model answer and write them below --root.
+
+Whole files in catter's xml (<file name="...">) or begin-end
+(--- BEGIN FILE: ... ---) delimiters, repomap <file path="..."> blocks and
+unified diffs are recognized. Each file's diff is shown for review and
+can be accepted or rejected; the accepted files are then written together,
+or not at all. Paths outside of --root are refused.`),
+			cmds.WithFlags(
+				fields.New(
+					"root",
+					fields.TypeString,
+					fields.WithHelp("Working tree to write the edits to"),
+					fields.WithDefault("."),
+				),
+				fields.New(
+					"yes",
+					fields.TypeBool,
+					fields.WithHelp("Apply every edit without reviewing"),
+					fields.WithDefault(false),
+					fields.WithShortFlag("y"),
+				),
+				fields.New(
+					"dry-run",
+					fields.TypeBool,
+					fields.WithHelp("Print the diffs without writing"),
+					fields.WithDefault(false),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"input",
+					fields.TypeString,
+					fields.WithHelp("File holding the answer (- for stdin)"),
+					fields.WithDefault("-"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *ApplyCommand) Run(
+	ctx context.Context,
+	parsedLayers *values.Values,
+) error {
+	s := &ApplySettings{}
+	err := parsedLayers.DecodeSectionInto(values.DefaultSlug, s)
+	if err != nil {
+		return fmt.Errorf("error initializing settings: %w", err)
+	}
+
+	var in io.Reader = os.Stdin
+	if s.Input != "-" {
+		f, err := os.Open(s.Input)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+	text, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("error reading answer: %w", err)
+	}
+
+	records, err := edits.ApplyText(os.Stdout, string(text), edits.Options{
+		Root:   s.Root,
+		Review: !s.Yes,
+		DryRun: s.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		fmt.Println(edits.Summary(records))
+	}
+	return nil
+}
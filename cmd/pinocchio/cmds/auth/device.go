@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"rsc.io/qr"
+)
+
+const (
+	loginFlowAuto    = "auto"
+	loginFlowBrowser = "browser"
+	loginFlowDevice  = "device"
+)
+
+// selectLoginFlow picks the flow for --flow. auto prefers the browser flow and
+// falls back to the device flow when the profile has no authorization_url or
+// when an SSH session makes a local browser callback unreachable.
+func selectLoginFlow(flow string, profile *oauthprofiles.Profile, getenv func(string) string) (string, error) {
+	if profile == nil {
+		return "", errors.New("resolved OAuth profile is required")
+	}
+	switch strings.TrimSpace(flow) {
+	case "", loginFlowAuto:
+		if !profile.SupportsDeviceLogin() {
+			return loginFlowBrowser, nil
+		}
+		if !profile.SupportsBrowserLogin() || (getenv != nil && (getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "")) {
+			return loginFlowDevice, nil
+		}
+		return loginFlowBrowser, nil
+	case loginFlowBrowser:
+		if !profile.SupportsBrowserLogin() {
+			return "", errors.New("OAuth profile does not declare authorization_url; use --flow device")
+		}
+		return loginFlowBrowser, nil
+	case loginFlowDevice:
+		if !profile.SupportsDeviceLogin() {
+			return "", errors.New("OAuth profile does not declare device_authorization_url; use --flow browser")
+		}
+		return loginFlowDevice, nil
+	default:
+		return "", fmt.Errorf("unknown OAuth login flow %q", flow)
+	}
+}
+
+// runDeviceLogin runs the RFC 8628 device flow: it prints the user code and
+// verification URL, polls until the code is approved, and saves the
+// credential. The device code itself is never printed.
+func runDeviceLogin(parent context.Context, profile *profilebootstrap.ResolvedOAuthProfile, timeout time.Duration, showQR bool, deps loginDependencies) error {
+	if profile == nil || profile.Profile == nil {
+		return errors.New("resolved OAuth profile is required")
+	}
+	if timeout <= 0 {
+		return errors.New("OAuth login timeout must be positive")
+	}
+	if deps.prompt == nil {
+		return errors.New("OAuth login dependencies are incomplete")
+	}
+	client, err := profile.Profile.NewDeviceClient(deps.deviceOptions...)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	authorization, err := client.Authorize(ctx)
+	if err != nil {
+		return err
+	}
+	if err := writeDevicePrompt(deps.prompt, authorization, showQR); err != nil {
+		return err
+	}
+	credential, err := client.Poll(ctx, authorization)
+	if err != nil {
+		return err
+	}
+	if err := profile.Store.Save(ctx, profile.Request, credential); err != nil {
+		return errors.New("save OAuth credential")
+	}
+	return nil
+}
+
+func writeDevicePrompt(w io.Writer, authorization *oauthprofiles.DeviceAuthorization, showQR bool) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "To log in, open %s\nand enter the code: %s\n", authorization.VerificationURI, authorization.UserCode)
+	target := authorization.VerificationURI
+	if authorization.VerificationURIComplete != "" {
+		target = authorization.VerificationURIComplete
+		fmt.Fprintf(&b, "Or open %s directly.\n", target)
+	}
+	if showQR {
+		code, err := qr.Encode(target, qr.M)
+		if err != nil {
+			return fmt.Errorf("encode OAuth verification QR code: %w", err)
+		}
+		b.WriteString("\n")
+		b.WriteString(renderQR(code))
+	}
+	fmt.Fprintf(&b, "\nWaiting for approval (code expires at %s)...\n", authorization.ExpiresAt.Local().Format(time.Kitchen))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderQR draws a QR code with half-block characters, two modules per line,
+// inside the four-module quiet zone scanners expect. Dark modules are drawn
+// as spaces on a light background so the code also scans on dark terminals.
+func renderQR(code *qr.Code) string {
+	const quiet = 4
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < code.Size && y < code.Size && code.Black(x, y)
+	}
+	size := code.Size + 2*quiet
+	var b strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := dark(x, y), y+1 < size && dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString(" ")
+			case top:
+				b.WriteString("▄")
+			case bottom:
+				b.WriteString("▀")
+			default:
+				b.WriteString("█")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,98 @@
+//go:build !windows
+
+package auth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDeviceLoginPromptsPollsAndSavesCredential(t *testing.T) {
+	var tokenCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			_, _ = io.WriteString(w, `{"device_code":"device-secret","user_code":"WDJB-MJHT","verification_uri":"https://issuer.example.test/device","verification_uri_complete":"https://issuer.example.test/device?user_code=WDJB-MJHT","expires_in":600,"interval":1}`)
+		case "/token":
+			tokenCalls++
+			require.Equal(t, "device-secret", r.Form.Get("device_code"))
+			if tokenCalls == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"error":"authorization_pending"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"access_token":"device-access","refresh_token":"device-refresh","expires_in":3600}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	profile, store, request := loginProfileFixture(t, server.URL)
+	profile.Profile.DeviceAuthorizationURL = server.URL + "/device"
+	prompt := &bytes.Buffer{}
+	deps := defaultLoginDependencies()
+	deps.prompt = prompt
+	deps.deviceOptions = []oauthprofiles.DeviceClientOption{
+		oauthprofiles.WithDeviceWait(func(context.Context, time.Duration) error { return nil }),
+	}
+
+	require.NoError(t, runDeviceLogin(context.Background(), profile, time.Second, true, deps))
+	require.Equal(t, 2, tokenCalls)
+	require.Contains(t, prompt.String(), "https://issuer.example.test/device")
+	require.Contains(t, prompt.String(), "WDJB-MJHT")
+	require.Contains(t, prompt.String(), "▀")
+	require.NotContains(t, prompt.String(), "device-secret")
+
+	credential, err := store.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, "device-access", credential.AccessToken)
+	require.Equal(t, "device-refresh", credential.RefreshToken)
+}
+
+func TestSelectLoginFlow(t *testing.T) {
+	both := &oauthprofiles.Profile{AuthorizationURL: "https://issuer.example.test/authorize", DeviceAuthorizationURL: "https://issuer.example.test/device"}
+	browserOnly := &oauthprofiles.Profile{AuthorizationURL: "https://issuer.example.test/authorize"}
+	deviceOnly := &oauthprofiles.Profile{DeviceAuthorizationURL: "https://issuer.example.test/device"}
+	local := func(string) string { return "" }
+	ssh := func(key string) string {
+		if key == "SSH_CONNECTION" {
+			return "10.0.0.1 5000 10.0.0.2 22"
+		}
+		return ""
+	}
+
+	for name, tc := range map[string]struct {
+		flow     string
+		profile  *oauthprofiles.Profile
+		getenv   func(string) string
+		expected string
+	}{
+		"auto local":        {flow: loginFlowAuto, profile: both, getenv: local, expected: loginFlowBrowser},
+		"auto over ssh":     {flow: loginFlowAuto, profile: both, getenv: ssh, expected: loginFlowDevice},
+		"auto device only":  {flow: loginFlowAuto, profile: deviceOnly, getenv: local, expected: loginFlowDevice},
+		"auto browser only": {flow: loginFlowAuto, profile: browserOnly, getenv: ssh, expected: loginFlowBrowser},
+		"explicit device":   {flow: loginFlowDevice, profile: both, getenv: local, expected: loginFlowDevice},
+	} {
+		t.Run(name, func(t *testing.T) {
+			flow, err := selectLoginFlow(tc.flow, tc.profile, tc.getenv)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, flow)
+		})
+	}
+
+	_, err := selectLoginFlow(loginFlowDevice, browserOnly, local)
+	require.EqualError(t, err, "OAuth profile does not declare device_authorization_url; use --flow browser")
+	_, err = selectLoginFlow(loginFlowBrowser, deviceOnly, local)
+	require.EqualError(t, err, "OAuth profile does not declare authorization_url; use --flow device")
+}
@@ -19,7 +19,7 @@ func TestAuthLifecycleCommandsAreGlazedAndRegistered(t *testing.T) {
 
 	root, err := NewAuthCommand()
 	require.NoError(t, err)
-	for _, name := range []string{"login", "status", "logout"} {
+	for _, name := range []string{"login", "status", "logout", "migrate-store"} {
 		command, _, findErr := root.Find([]string{name})
 		require.NoError(t, findErr)
 		require.NotNil(t, command.Flags().Lookup("profile"))
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/go-go-golems/glazed/pkg/middlewares"
 	"github.com/go-go-golems/glazed/pkg/types"
 	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
 )
 
 const callbackPath = "/oauth/callback"
@@ -36,19 +38,26 @@ type LoginCommand struct {
 }
 
 type LoginSettings struct {
-	TimeoutSeconds int  `glazed:"timeout-seconds"`
-	OpenBrowser    bool `glazed:"open-browser"`
+	TimeoutSeconds int    `glazed:"timeout-seconds"`
+	OpenBrowser    bool   `glazed:"open-browser"`
+	Flow           string `glazed:"flow"`
+	QR             bool   `glazed:"qr"`
 }
 
 type loginDependencies struct {
-	listen      func(network, address string) (net.Listener, error)
-	openBrowser func(string) error
+	listen        func(network, address string) (net.Listener, error)
+	openBrowser   func(string) error
+	getenv        func(string) string
+	prompt        io.Writer
+	deviceOptions []oauthprofiles.DeviceClientOption
 }
 
 func defaultLoginDependencies() loginDependencies {
 	return loginDependencies{
 		listen:      net.Listen,
 		openBrowser: openBrowser,
+		getenv:      os.Getenv,
+		prompt:      os.Stderr,
 	}
 }
 
@@ -70,13 +79,28 @@ func NewLoginCommand() (*LoginCommand, error) {
 	return &LoginCommand{
 		CommandDescription: cmds.NewCommandDescription(
 			"login",
-			cmds.WithShort("Log in to an OAuth-backed profile through a local browser callback"),
-			cmds.WithLong(`Log in to the selected OAuth-backed profile using Authorization Code with PKCE.
+			cmds.WithShort("Log in to an OAuth-backed profile through a browser callback or a device code"),
+			cmds.WithLong(`Log in to the selected OAuth-backed profile.
 
-The profile must come from exactly one direct YAML profile registry with mode
-0600. The command binds an exact 127.0.0.1 callback before opening a browser,
-checks state once, exchanges the authorization code, and saves the resulting
-credential tuple without printing it.`),
+With the default registry credential store the profile must come from exactly
+one direct YAML profile registry with mode 0600; profiles that select another
+credential_store may come from any registry source. Two flows are supported:
+
+  browser  Authorization Code with PKCE. The command binds an exact 127.0.0.1
+           callback before opening a browser, checks state once and exchanges
+           the authorization code.
+  device   RFC 8628 device authorization, for SSH sessions and containers.
+           The command prints a verification URL, a user code and a QR code
+           on stderr, then polls the token endpoint until the code is
+           approved on another device.
+
+--flow auto uses the device flow when the profile declares only
+device_authorization_url, or declares it and the command runs over SSH.
+Either way the resulting credential tuple is saved without printing it.
+
+Examples:
+  pinocchio auth login --profile assistant
+  pinocchio auth login --profile assistant --flow device --qr=false`),
 			cmds.WithFlags(
 				fields.New("timeout-seconds", fields.TypeInteger,
 					fields.WithDefault(180),
@@ -84,7 +108,16 @@ credential tuple without printing it.`),
 				),
 				fields.New("open-browser", fields.TypeBool,
 					fields.WithDefault(true),
-					fields.WithHelp("Open the authorization URL in the system browser; must remain enabled"),
+					fields.WithHelp("Open the authorization URL in the system browser; must remain enabled for the browser flow"),
+				),
+				fields.New("flow", fields.TypeChoice,
+					fields.WithChoices(loginFlowAuto, loginFlowBrowser, loginFlowDevice),
+					fields.WithDefault(loginFlowAuto),
+					fields.WithHelp("Login flow: auto, browser or device"),
+				),
+				fields.New("qr", fields.TypeBool,
+					fields.WithDefault(true),
+					fields.WithHelp("Print a QR code of the verification URL in the device flow"),
 				),
 			),
 			cmds.WithSections(commandSettingsSection, profileSettingsSection),
@@ -94,7 +127,7 @@ credential tuple without printing it.`),
 }
 
 func (c *LoginCommand) RunIntoGlazeProcessor(ctx context.Context, parsed *values.Values, gp middlewares.Processor) error {
-	settings := &LoginSettings{TimeoutSeconds: 180, OpenBrowser: true}
+	settings := &LoginSettings{TimeoutSeconds: 180, OpenBrowser: true, Flow: loginFlowAuto, QR: true}
 	if parsed != nil {
 		if err := parsed.DecodeSectionInto(schema.DefaultSlug, settings); err != nil {
 			return fmt.Errorf("decode auth login settings: %w", err)
@@ -103,9 +136,6 @@ func (c *LoginCommand) RunIntoGlazeProcessor(ctx context.Context, parsed *values
 	if settings.TimeoutSeconds <= 0 {
 		return errors.New("OAuth login timeout-seconds must be positive")
 	}
-	if !settings.OpenBrowser {
-		return errors.New("OAuth login requires open-browser; manual authorization URL output is disabled")
-	}
 
 	commandSettings := &cli.CommandSettings{}
 	profileSettings := &profilebootstrap.ProfileSettings{}
@@ -139,12 +169,27 @@ func (c *LoginCommand) RunIntoGlazeProcessor(ctx context.Context, parsed *values
 	if oauthProfile == nil {
 		return errors.New("selected profile is not an OAuth profile")
 	}
-	if err := runLogin(ctx, oauthProfile, time.Duration(settings.TimeoutSeconds)*time.Second, c.deps); err != nil {
+	flow, err := selectLoginFlow(settings.Flow, oauthProfile.Profile, c.deps.getenv)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(settings.TimeoutSeconds) * time.Second
+	switch flow {
+	case loginFlowDevice:
+		err = runDeviceLogin(ctx, oauthProfile, timeout, settings.QR, c.deps)
+	default:
+		if !settings.OpenBrowser {
+			return errors.New("OAuth login requires open-browser; manual authorization URL output is disabled")
+		}
+		err = runLogin(ctx, oauthProfile, timeout, c.deps)
+	}
+	if err != nil {
 		return err
 	}
 	return gp.AddRow(ctx, types.NewRow(
 		types.MRP("profile", resolved.ResolvedEngineProfile.EngineProfileSlug.String()),
 		types.MRP("registry", resolved.ResolvedEngineProfile.RegistrySlug.String()),
+		types.MRP("flow", flow),
 		types.MRP("status", "completed"),
 	))
 }
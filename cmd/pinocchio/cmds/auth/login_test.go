@@ -185,7 +185,7 @@ func TestLoginCommandIsGlazedAndRegistersItsDeclaredFields(t *testing.T) {
 	require.NoError(t, err)
 	login, _, err := root.Find([]string{"login"})
 	require.NoError(t, err)
-	for _, name := range []string{"timeout-seconds", "open-browser", "profile", "profile-registries"} {
+	for _, name := range []string{"timeout-seconds", "open-browser", "flow", "qr", "profile", "profile-registries"} {
 		require.NotNil(t, login.Flags().Lookup(name))
 	}
 }
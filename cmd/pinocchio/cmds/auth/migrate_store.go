@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/profileedit"
+	"gopkg.in/yaml.v3"
+)
+
+// credentialStorePath is the profile-relative path of the store selection.
+var credentialStorePath = fmt.Sprintf("extensions[%q].credential_store", oauthprofiles.ExtensionKey)
+
+type MigrateStoreCommand struct {
+	*cmds.CommandDescription
+}
+
+type MigrateStoreSettings struct {
+	To         string   `glazed:"to"`
+	Path       string   `glazed:"path"`
+	Command    []string `glazed:"command"`
+	KeepSource bool     `glazed:"keep-source"`
+}
+
+var _ cmds.GlazeCommand = (*MigrateStoreCommand)(nil)
+
+func NewMigrateStoreCommand() (*MigrateStoreCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := profilebootstrap.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	return &MigrateStoreCommand{CommandDescription: cmds.NewCommandDescription(
+		"migrate-store",
+		cmds.WithShort("Move a profile's OAuth credentials to another credential store"),
+		cmds.WithLong(`Move the selected profile's OAuth credential tuple to another store.
+
+The tuple is copied into the new store, the profile's credential_store setting
+is rewritten in the file that defines the profile, and the tuple is removed
+from the old store unless --keep-source is given. Stores:
+
+  registry        next to the profile in its direct YAML registry (default)
+  file            an owner-only YAML file keyed by registry/profile
+  encrypted_file  a passphrase-encrypted file (PINOCCHIO_SECRETS_PASSPHRASE)
+  exec            a credential helper speaking the get/store/erase protocol
+
+No credential material is printed.
+
+Examples:
+  pinocchio auth migrate-store --profile workspace/assistant --to file
+  pinocchio auth migrate-store --profile workspace/assistant --to encrypted_file --path ~/.config/pinocchio/oauth.enc
+  pinocchio auth migrate-store --profile workspace/assistant --to exec --command pinocchio-pass-helper,--vault,work`),
+		cmds.WithFlags(
+			fields.New("to", fields.TypeChoice,
+				fields.WithChoices(oauthprofiles.StoreKindRegistry, oauthprofiles.StoreKindFile, oauthprofiles.StoreKindEncryptedFile, oauthprofiles.StoreKindExec),
+				fields.WithRequired(true),
+				fields.WithHelp("Credential store to move the tuple to"),
+			),
+			fields.New("path", fields.TypeString,
+				fields.WithDefault(""),
+				fields.WithHelp("File of the file or encrypted_file store; defaults to the Pinocchio config directory"),
+			),
+			fields.New("command", fields.TypeStringList,
+				fields.WithDefault([]string{}),
+				fields.WithHelp("Helper command and arguments of the exec store"),
+			),
+			fields.New("keep-source", fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Keep the tuple in the old store"),
+			),
+		),
+		cmds.WithSections(commandSettingsSection, profileSettingsSection),
+	)}, nil
+}
+
+func (c *MigrateStoreCommand) RunIntoGlazeProcessor(ctx context.Context, parsed *values.Values, gp middlewares.Processor) error {
+	settings := &MigrateStoreSettings{}
+	if parsed != nil {
+		if err := parsed.DecodeSectionInto(schema.DefaultSlug, settings); err != nil {
+			return fmt.Errorf("decode auth migrate-store settings: %w", err)
+		}
+	}
+	target := oauthprofiles.StoreConfig{Kind: settings.To, Path: strings.TrimSpace(settings.Path), Command: settings.Command}
+	if err := target.Validate(); err != nil {
+		return err
+	}
+
+	resolved, oauthProfile, err := resolveOAuthProfileForCommand(ctx, parsed)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if resolved.Close != nil {
+			resolved.Close()
+		}
+	}()
+	profile, registry, err := profileAndRegistryRow(resolved)
+	if err != nil {
+		return err
+	}
+	source := oauthProfile.Profile.CredentialStore
+	if sameStoreConfig(source, target) {
+		return fmt.Errorf("profile %s/%s already uses the %s credential store", registry, profile, target.EffectiveKind())
+	}
+	destination, err := profilebootstrap.NewOAuthCredentialStore(resolved, target, oauthProfile.Request)
+	if err != nil {
+		return err
+	}
+	if resolved.ProfileRuntime == nil {
+		return errors.New("selected OAuth profile has no editable profile source")
+	}
+	profileTarget, err := profileedit.Locate(resolved.ProfileRuntime.Documents, resolved.ProfileRuntime.ProfileSettings.ProfileRegistries, registry, profile)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := migrateCredentialStore(ctx, oauthProfile, destination, func() error {
+		return writeCredentialStoreConfig(profileTarget, profile, target)
+	}, settings.KeepSource)
+	if err != nil {
+		return err
+	}
+	return gp.AddRow(ctx, types.NewRow(
+		types.MRP("profile", profile),
+		types.MRP("registry", registry),
+		types.MRP("from", source.EffectiveKind()),
+		types.MRP("to", target.EffectiveKind()),
+		types.MRP("credential_moved", migrated),
+		types.MRP("source_kept", settings.KeepSource),
+	))
+}
+
+// migrateCredentialStore copies the tuple to destination, runs switchConfig
+// to point the profile at destination, and then deletes the tuple from the
+// old store. It reports whether there was a tuple to move.
+func migrateCredentialStore(ctx context.Context, profile *profilebootstrap.ResolvedOAuthProfile, destination oauthprofiles.CredentialStore, switchConfig func() error, keepSource bool) (bool, error) {
+	credential, err := profile.Store.Load(ctx, profile.Request)
+	if err != nil {
+		return false, fmt.Errorf("load OAuth credential from %s store: %w", profile.Store.Kind(), err)
+	}
+	moved := credential.AccessToken != "" || credential.RefreshToken != ""
+	if moved {
+		if err := destination.Save(ctx, profile.Request, credential); err != nil {
+			return false, fmt.Errorf("save OAuth credential to %s store: %w", destination.Kind(), err)
+		}
+	}
+	if err := switchConfig(); err != nil {
+		if moved {
+			return false, fmt.Errorf("update profile credential_store (the credential is now in both stores): %w", err)
+		}
+		return false, fmt.Errorf("update profile credential_store: %w", err)
+	}
+	if moved && !keepSource {
+		if err := profile.Store.Delete(ctx, profile.Request); err != nil {
+			return true, fmt.Errorf("remove OAuth credential from %s store: %w", profile.Store.Kind(), err)
+		}
+	}
+	return moved, nil
+}
+
+// writeCredentialStoreConfig points the profile at config. The registry store
+// is the default, so selecting it removes the setting.
+func writeCredentialStoreConfig(target profileedit.Target, profile string, config oauthprofiles.StoreConfig) error {
+	if config.EffectiveKind() == oauthprofiles.StoreKindRegistry {
+		return target.Update(profile, nil, []string{credentialStorePath})
+	}
+	value, err := yaml.Marshal(config.Map())
+	if err != nil {
+		return errors.New("encode OAuth credential_store")
+	}
+	return target.Update(profile, []profileedit.Assignment{{Path: credentialStorePath, Value: string(value)}}, nil)
+}
+
+func sameStoreConfig(a, b oauthprofiles.StoreConfig) bool {
+	return a.EffectiveKind() == b.EffectiveKind() && a.Path == b.Path && reflect.DeepEqual(a.Command, b.Command)
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateCredentialStoreMovesTupleAfterSwitchingConfig(t *testing.T) {
+	oauthProfile, source, request := loginProfileFixture(t, "https://issuer.example.test")
+	tuple := credentials.Credential{AccessToken: "registry-access", RefreshToken: "registry-refresh"}
+	require.NoError(t, source.Save(context.Background(), request, tuple))
+	destination := migrateTestFileStore(t, request)
+
+	switched := false
+	moved, err := migrateCredentialStore(context.Background(), oauthProfile, destination, func() error {
+		switched = true
+		return nil
+	}, false)
+	require.NoError(t, err)
+	require.True(t, moved)
+	require.True(t, switched)
+
+	loaded, err := destination.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, tuple, loaded)
+	loaded, err = source.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Empty(t, loaded.AccessToken)
+	require.Empty(t, loaded.RefreshToken)
+}
+
+func TestMigrateCredentialStoreKeepsSourceWhenConfigUpdateFails(t *testing.T) {
+	oauthProfile, source, request := loginProfileFixture(t, "https://issuer.example.test")
+	tuple := credentials.Credential{AccessToken: "registry-access", RefreshToken: "registry-refresh"}
+	require.NoError(t, source.Save(context.Background(), request, tuple))
+	destination := migrateTestFileStore(t, request)
+
+	_, err := migrateCredentialStore(context.Background(), oauthProfile, destination, func() error {
+		return errors.New("profile file is read-only")
+	}, false)
+	require.EqualError(t, err, "update profile credential_store (the credential is now in both stores): profile file is read-only")
+	loaded, err := source.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, tuple, loaded)
+}
+
+func TestSameStoreConfigTreatsEmptyKindAsRegistry(t *testing.T) {
+	require.True(t, sameStoreConfig(oauthprofiles.StoreConfig{}, oauthprofiles.StoreConfig{Kind: oauthprofiles.StoreKindRegistry}))
+	require.False(t, sameStoreConfig(
+		oauthprofiles.StoreConfig{Kind: oauthprofiles.StoreKindFile},
+		oauthprofiles.StoreConfig{Kind: oauthprofiles.StoreKindFile, Path: "/tmp/other.yaml"},
+	))
+}
+
+func migrateTestFileStore(t *testing.T, request credentials.Request) oauthprofiles.CredentialStore {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o700))
+	store, err := oauthprofiles.NewFileStore(filepath.Join(dir, "oauth.yaml"),
+		gepprofiles.MustRegistrySlug("workspace"), gepprofiles.MustEngineProfileSlug("assistant"), request)
+	require.NoError(t, err)
+	return store
+}
@@ -26,7 +26,11 @@ func NewAuthCommand() (*cobra.Command, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, command := range []cmds.GlazeCommand{login, status, logout} {
+	migrateStore, err := NewMigrateStoreCommand()
+	if err != nil {
+		return nil, err
+	}
+	for _, command := range []cmds.GlazeCommand{login, status, logout, migrateStore} {
 		cobraCommand, err := cli.BuildCobraCommand(command)
 		if err != nil {
 			return nil, err
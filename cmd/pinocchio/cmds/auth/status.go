@@ -35,9 +35,11 @@ func NewStatusCommand() (*StatusCommand, error) {
 		cmds.WithShort("Show secret-free local OAuth credential readiness for a profile"),
 		cmds.WithLong(`Show local OAuth credential readiness for the selected profile.
 
-The command reads only the owner direct-YAML profile registry. It does not call a
-provider, trigger refresh, or print access tokens, refresh tokens, expiry values,
-client secrets, or registry paths.`),
+The command reads only the profile's credential store: the owner direct-YAML
+profile registry, a separate credentials file, or a credential helper. The
+storage column names which one. It does not call a provider, trigger refresh,
+or print access tokens, refresh tokens, expiry values, client secrets, or
+registry paths.`),
 		cmds.WithSections(commandSettingsSection, profileSettingsSection),
 	)}, nil
 }
@@ -63,7 +65,7 @@ func (c *StatusCommand) RunIntoGlazeProcessor(ctx context.Context, parsed *value
 	return gp.AddRow(ctx, types.NewRow(
 		types.MRP("profile", profile),
 		types.MRP("registry", registry),
-		types.MRP("storage", "direct_yaml"),
+		types.MRP("storage", oauthProfile.Store.Kind()),
 		types.MRP("credential_state", credentialStatusState(status)),
 	))
 }
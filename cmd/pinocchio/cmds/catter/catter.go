@@ -22,6 +22,12 @@ func AddToRootCommand(rootCmd *cobra.Command) {
 	catterStatsCmd, err := cmds.NewCatterStatsCommand()
 	cobra.CheckErr(err)
 
+	catterServeCmd, err := cmds.NewCatterServeCommand()
+	cobra.CheckErr(err)
+
+	catterUnpackCmd, err := cmds.NewCatterUnpackCommand()
+	cobra.CheckErr(err)
+
 	catterCobraCmd, err := cli.BuildCobraCommand(catterPrintCommand,
 		cli.WithCobraMiddlewaresFunc(getMiddlewares),
 	)
@@ -32,8 +38,20 @@ func AddToRootCommand(rootCmd *cobra.Command) {
 	)
 	cobra.CheckErr(err)
 
+	catterServeCobraCmd, err := cli.BuildCobraCommand(catterServeCmd,
+		cli.WithCobraMiddlewaresFunc(getMiddlewares),
+	)
+	cobra.CheckErr(err)
+
+	catterUnpackCobraCmd, err := cli.BuildCobraCommand(catterUnpackCmd,
+		cli.WithCobraMiddlewaresFunc(getMiddlewares),
+	)
+	cobra.CheckErr(err)
+
 	catterCmd.AddCommand(catterCobraCmd)
 	catterCmd.AddCommand(catterStatsCobraCmd)
+	catterCmd.AddCommand(catterServeCobraCmd)
+	catterCmd.AddCommand(catterUnpackCobraCmd)
 	rootCmd.AddCommand(catterCmd)
 }
 
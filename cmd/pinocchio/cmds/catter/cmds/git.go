@@ -0,0 +1,60 @@
+package cmds
+
+import (
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg"
+
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+)
+
+// GitSettings are the flags selecting files from git, shared by print and
+// stats.
+type GitSettings struct {
+	GitDiff     string `glazed:"git-diff"`
+	GitWithDiff bool   `glazed:"git-with-diff"`
+	GitStaged   bool   `glazed:"git-staged"`
+	GitLog      int    `glazed:"git-log"`
+	At          string `glazed:"at"`
+}
+
+func (s *GitSettings) Options() pkg.GitOptions {
+	return pkg.GitOptions{
+		Diff:     s.GitDiff,
+		WithDiff: s.GitWithDiff,
+		Staged:   s.GitStaged,
+		Log:      s.GitLog,
+		At:       s.At,
+	}
+}
+
+func gitFlags() []*fields.Definition {
+	return []*fields.Definition{
+		fields.New(
+			"git-diff",
+			fields.TypeString,
+			fields.WithHelp("Only files changed between this git revision and --at, or the worktree (e.g., main)"),
+		),
+		fields.New(
+			"git-with-diff",
+			fields.TypeBool,
+			fields.WithHelp("Add the unified diff of each --git-diff or --git-staged file as <path>.diff"),
+			fields.WithDefault(false),
+		),
+		fields.New(
+			"git-staged",
+			fields.TypeBool,
+			fields.WithHelp("Only files with staged changes, as staged"),
+			fields.WithDefault(false),
+		),
+		fields.New(
+			"git-log",
+			fields.TypeInteger,
+			fields.WithHelp("Only files touched in the last N git commits (0 to disable)"),
+			fields.WithDefault(0),
+		),
+		fields.New(
+			"at",
+			fields.TypeString,
+			fields.WithHelp("Read file contents from this git revision instead of the worktree (alone, selects every file in it)"),
+		),
+	}
+}
@@ -19,6 +19,7 @@ type CatterPrintSettings struct {
 	MaxTotalSize  int64    `glazed:"max-total-size"`
 	List          bool     `glazed:"list"`
 	Delimiter     string   `glazed:"delimiter"`
+	OutputFormat  string   `glazed:"output-format"`
 	MaxLines      int      `glazed:"max-lines"`
 	MaxTokens     int      `glazed:"max-tokens"`
 	PrintFilters  bool     `glazed:"print-filters"`
@@ -27,7 +28,15 @@ type CatterPrintSettings struct {
 	Glazed        bool     `glazed:"glazed"`
 	ArchiveFile   string   `glazed:"archive-file"`
 	ArchivePrefix string   `glazed:"archive-prefix"`
+	Mode          string   `glazed:"mode"`
+	TokenBudget   int      `glazed:"token-budget"`
+	Strategy      []string `glazed:"budget-strategy"`
+	Priority      []string `glazed:"priority"`
+	Focus         string   `glazed:"focus"`
+	RecentCommits int      `glazed:"recent-commits"`
 	Paths         []string `glazed:"paths"`
+	GitSettings
+	TransformSettings
 }
 
 type CatterPrintCommand struct {
@@ -67,6 +76,13 @@ func NewCatterPrintCommand() (*CatterPrintCommand, error) {
 					fields.WithDefault("default"),
 					fields.WithShortFlag("d"),
 				),
+				fields.New(
+					"output-format",
+					fields.TypeChoice,
+					fields.WithChoices("text", pkg.FormatJSONL, pkg.FormatRepoMap, pkg.FormatMarkdown),
+					fields.WithHelp("Output format: text with --delimiter, or a bundle catter unpack reads back (jsonl, repomap, markdown)"),
+					fields.WithDefault("text"),
+				),
 				fields.New(
 					"max-lines",
 					fields.TypeInteger,
@@ -82,7 +98,7 @@ func NewCatterPrintCommand() (*CatterPrintCommand, error) {
 				fields.New(
 					"print-filters",
 					fields.TypeBool,
-					fields.WithHelp("Print configured filters"),
+					fields.WithHelp("Print configured filters and why each path is included or excluded"),
 					fields.WithDefault(false),
 				),
 				fields.New(
@@ -114,7 +130,44 @@ func NewCatterPrintCommand() (*CatterPrintCommand, error) {
 					fields.WithHelp("Directory prefix to add to files within the archive (e.g., 'myproject/')"),
 					fields.WithDefault(""),
 				),
+				fields.New(
+					"mode",
+					fields.TypeChoice,
+					fields.WithChoices(pkg.ModeFull, pkg.ModeOutline),
+					fields.WithHelp("Print files in full, or as outlines of their declarations and signatures"),
+					fields.WithDefault(pkg.ModeFull),
+				),
+				fields.New(
+					"token-budget",
+					fields.TypeInteger,
+					fields.WithHelp("Pack the best files into this many content tokens, outlining or dropping the rest (0 for no budget)"),
+					fields.WithDefault(0),
+				),
+				fields.New(
+					"budget-strategy",
+					fields.TypeStringList,
+					fields.WithHelp("How to rank files for --token-budget, most important first: priority, focus, recency, size"),
+					fields.WithDefault(pkg.DefaultBudgetStrategies),
+				),
+				fields.New(
+					"priority",
+					fields.TypeStringList,
+					fields.WithHelp("Gitignore-style globs ranked first by --token-budget, in order (e.g., pkg/auth/, '**/*.proto')"),
+				),
+				fields.New(
+					"focus",
+					fields.TypeString,
+					fields.WithHelp("Rank files by BM25 relevance to this query for --token-budget (e.g., 'auth refresh')"),
+				),
+				fields.New(
+					"recent-commits",
+					fields.TypeInteger,
+					fields.WithHelp("Rank files touched in the last N git commits first for --token-budget (0 to disable)"),
+					fields.WithDefault(20),
+				),
 			),
+			cmds.WithFlags(gitFlags()...),
+			cmds.WithFlags(transformFlags()...),
 			cmds.WithArguments(
 				fields.New(
 					"paths",
@@ -137,10 +190,23 @@ func (c *CatterPrintCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 		return fmt.Errorf("error initializing settings: %w", err)
 	}
 
-	outputFormat := "text"
+	outputFormat := s.OutputFormat
+	if outputFormat == "" {
+		outputFormat = "text"
+	}
 	outputFile := s.ArchiveFile
 	isArchiveOutput := outputFile != ""
 
+	if pkg.IsBundleFormat(outputFormat) {
+		if isArchiveOutput {
+			return fmt.Errorf("--output-format %s cannot be used with --archive-file", outputFormat)
+		}
+		if s.Glazed {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: --glazed is ignored with --output-format %s.\n", outputFormat)
+			s.Glazed = false
+		}
+	}
+
 	if isArchiveOutput {
 		if strings.HasSuffix(outputFile, ".zip") {
 			outputFormat = "zip"
@@ -168,19 +234,18 @@ func (c *CatterPrintCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 	if err != nil {
 		return err
 	}
+	transforms, err := s.Pipeline(s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return err
+	}
 
-	fileProcessorOptions := []pkg.FileProcessorOption{
-		pkg.WithMaxTotalSize(s.MaxTotalSize),
+	fileProcessorOptions := append(s.fileProcessorOptions(ff, transforms),
 		pkg.WithListOnly(s.List),
-		pkg.WithDelimiterType(s.Delimiter),
-		pkg.WithMaxLines(s.MaxLines),
-		pkg.WithMaxTokens(s.MaxTokens),
-		pkg.WithFileFilter(ff),
 		pkg.WithPrintFilters(s.PrintFilters),
 		pkg.WithOutputFormat(outputFormat),
 		pkg.WithOutputFile(outputFile),
 		pkg.WithArchivePrefix(archivePrefix),
-	}
+	)
 
 	if !isArchiveOutput && s.Glazed {
 		fileProcessorOptions = append(fileProcessorOptions, pkg.WithProcessor(gp))
@@ -195,6 +260,66 @@ func (c *CatterPrintCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 	return fp.ProcessPaths(s.Paths)
 }
 
+// CollectFiles selects and formats files like print does, and returns them
+// instead of writing them out.
+func (c *CatterPrintCommand) CollectFiles(parsedLayers *values.Values) ([]pkg.EmittedFile, error) {
+	s := &CatterPrintSettings{}
+	err := parsedLayers.DecodeSectionInto(values.DefaultSlug, s)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing settings: %w", err)
+	}
+	if s.ArchiveFile != "" || s.List || s.PrintFilters || s.Glazed {
+		return nil, fmt.Errorf("--archive-file, --list, --print-filters and --glazed only apply when printing")
+	}
+
+	ff, err := createFileFilter(parsedLayers, s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return nil, err
+	}
+	transforms, err := s.Pipeline(s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []pkg.EmittedFile
+	fp := pkg.NewFileProcessor(append(s.fileProcessorOptions(ff, transforms),
+		pkg.WithSink(func(f pkg.EmittedFile) error {
+			files = append(files, f)
+			return nil
+		}),
+	)...)
+
+	if len(s.Paths) < 1 {
+		s.Paths = append(s.Paths, ".")
+	}
+	if err := fp.ProcessPaths(s.Paths); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// fileProcessorOptions are the options that select and shape the files,
+// whatever the output.
+func (s *CatterPrintSettings) fileProcessorOptions(ff *filefilter.FileFilter, transforms *pkg.Pipeline) []pkg.FileProcessorOption {
+	return []pkg.FileProcessorOption{
+		pkg.WithMaxTotalSize(s.MaxTotalSize),
+		pkg.WithDelimiterType(s.Delimiter),
+		pkg.WithMaxLines(s.MaxLines),
+		pkg.WithMaxTokens(s.MaxTokens),
+		pkg.WithFileFilter(ff),
+		pkg.WithMode(s.Mode),
+		pkg.WithTokenBudget(pkg.BudgetOptions{
+			Tokens:        s.TokenBudget,
+			Strategies:    s.Strategy,
+			Priority:      s.Priority,
+			Focus:         s.Focus,
+			RecentCommits: s.RecentCommits,
+		}),
+		pkg.WithGit(s.Options()),
+		pkg.WithTransforms(transforms),
+	}
+}
+
 func createFileFilter(parsedLayers *values.Values, filterYAML, filterProfile string) (*filefilter.FileFilter, error) {
 	layer, ok := parsedLayers.Get(filefilter.FileFilterSlug)
 	if !ok {
@@ -211,8 +336,8 @@ func createFileFilter(parsedLayers *values.Values, filterYAML, filterProfile str
 			return nil, fmt.Errorf("error loading filter configuration from YAML: %w", err)
 		}
 	} else {
-		if _, err := os.Stat(".catter-filter.yaml"); err == nil {
-			ff, err = filefilter.LoadFromFile(".catter-filter.yaml", filterProfile)
+		if _, err := os.Stat(defaultFilterYAML); err == nil {
+			ff, err = filefilter.LoadFromFile(defaultFilterYAML, filterProfile)
 			if err != nil {
 				return nil, fmt.Errorf("error loading default filter configuration: %w", err)
 			}
@@ -0,0 +1,143 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg"
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+)
+
+type CatterServeSettings struct {
+	Address       string `glazed:"address"`
+	FilterYAML    string `glazed:"filter-yaml"`
+	FilterProfile string `glazed:"filter-profile"`
+	Root          string `glazed:"root"`
+	TransformSettings
+}
+
+type CatterServeCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*CatterServeCommand)(nil)
+
+func NewCatterServeCommand() (*CatterServeCommand, error) {
+	fileFilterLayer, err := filefilter.NewFileFilterParameterLayer()
+	if err != nil {
+		return nil, fmt.Errorf("could not create file filter parameter layer: %w", err)
+	}
+
+	return &CatterServeCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"serve",
+			cmds.WithShort("Serve a web UI to pick the files of a bundle"),
+			cmds.WithLong(`Start an HTTP server to browse the files below a directory with their
+token counts, include and exclude paths, preview the resulting bundle and
+download it as text, zip or tar.gz.
+
+Filters are edited in memory and can be saved as profiles of the filter YAML
+file (--filter-yaml, or .catter-filter.yaml).`),
+			cmds.WithFlags(
+				fields.New(
+					"address",
+					fields.TypeString,
+					fields.WithHelp("Address to listen on"),
+					fields.WithDefault("localhost:8090"),
+				),
+				fields.New(
+					"filter-yaml",
+					fields.TypeString,
+					fields.WithHelp("Path to YAML file containing filter configuration and profiles"),
+				),
+				fields.New(
+					"filter-profile",
+					fields.TypeString,
+					fields.WithHelp("Name of the filter profile to start with"),
+				),
+			),
+			cmds.WithFlags(transformFlags()...),
+			cmds.WithArguments(
+				fields.New(
+					"root",
+					fields.TypeString,
+					fields.WithHelp("Directory to serve"),
+					fields.WithDefault("."),
+				),
+			),
+			cmds.WithSections(
+				fileFilterLayer,
+			),
+		),
+	}, nil
+}
+
+func (c *CatterServeCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &CatterServeSettings{}
+	if err := parsedLayers.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return fmt.Errorf("error initializing settings: %w", err)
+	}
+
+	ff, err := createFileFilter(parsedLayers, s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return err
+	}
+
+	transforms, err := s.Pipeline(s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return err
+	}
+
+	filterFile := s.FilterYAML
+	if filterFile == "" {
+		filterFile = defaultFilterYAML
+	}
+	server, err := pkg.NewServer(s.Root, ff, filterFile)
+	if err != nil {
+		return err
+	}
+	server.Transforms = transforms
+	server.Cache = pkg.NewStatsCache(pkg.StatsCodec(transforms))
+	server.ProfileTransforms = func(profile string) (*pkg.Pipeline, error) {
+		return s.Pipeline(s.FilterYAML, profile)
+	}
+	server.Address = s.Address
+
+	httpSrv := &http.Server{
+		Addr:        s.Address,
+		Handler:     server.Handler(),
+		ReadTimeout: 10 * time.Second,
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "catter serving %s on http://%s\n", s.Root, s.Address)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpSrv.ListenAndServe()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return fmt.Errorf("server error: %w", err)
+	case <-sigCh:
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpSrv.Shutdown(shutdownCtx)
+}
@@ -3,8 +3,12 @@ package cmds
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg"
 	"github.com/go-go-golems/pinocchio/pkg/filefilter"
@@ -21,7 +25,14 @@ type CatterStatsSettings struct {
 	FilterYAML    string   `glazed:"filter-yaml"`
 	FilterProfile string   `glazed:"filter-profile"`
 	Glazed        bool     `glazed:"glazed"`
+	Cache         bool     `glazed:"cache"`
+	CacheDir      string   `glazed:"cache-dir"`
+	Workers       int      `glazed:"workers"`
+	Watch         bool     `glazed:"watch"`
+	WatchInterval int      `glazed:"watch-interval"`
 	Paths         []string `glazed:"paths"`
+	GitSettings
+	TransformSettings
 }
 
 type CatterStatsCommand struct {
@@ -51,7 +62,7 @@ func NewCatterStatsCommand() (*CatterStatsCommand, error) {
 				fields.New(
 					"print-filters",
 					fields.TypeBool,
-					fields.WithHelp("Print configured filters"),
+					fields.WithHelp("Print configured filters and why each path is included or excluded"),
 					fields.WithDefault(false),
 				),
 				fields.New(
@@ -70,7 +81,39 @@ func NewCatterStatsCommand() (*CatterStatsCommand, error) {
 					fields.WithHelp("Enable Glazed structured output"),
 					fields.WithDefault(true),
 				),
+				fields.New(
+					"cache",
+					fields.TypeBool,
+					fields.WithHelp("Keep the stats of unchanged files in a cache between runs"),
+					fields.WithDefault(false),
+				),
+				fields.New(
+					"cache-dir",
+					fields.TypeString,
+					fields.WithHelp("Directory of the stats cache"),
+					fields.WithDefault(pkg.DefaultCacheDir),
+				),
+				fields.New(
+					"workers",
+					fields.TypeInteger,
+					fields.WithHelp("Number of files to tokenize in parallel (0 for the number of CPUs)"),
+					fields.WithDefault(0),
+				),
+				fields.New(
+					"watch",
+					fields.TypeBool,
+					fields.WithHelp("Keep printing the stats as files change"),
+					fields.WithDefault(false),
+				),
+				fields.New(
+					"watch-interval",
+					fields.TypeInteger,
+					fields.WithHelp("Seconds between checks for changes in watch mode"),
+					fields.WithDefault(2),
+				),
 			),
+			cmds.WithFlags(gitFlags()...),
+			cmds.WithFlags(transformFlags()...),
 			cmds.WithArguments(
 				fields.New(
 					"paths",
@@ -102,11 +145,57 @@ func (c *CatterStatsCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 		s.Paths = append(s.Paths, ".")
 	}
 
-	stats := pkg.NewStats()
-	err = stats.ComputeStats(s.Paths, ff)
+	if s.PrintFilters {
+		pkg.PrintFilters(ff)
+		return pkg.PrintPathDecisions(os.Stdout, ff, s.Paths)
+	}
+
+	transforms, err := s.Pipeline(s.FilterYAML, s.FilterProfile)
+	if err != nil {
+		return err
+	}
+
+	var cache *pkg.StatsCache
+	if s.Cache {
+		cache, err = pkg.OpenStatsCache(s.CacheDir, pkg.StatsCodec(transforms))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := cache.Close(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Error closing stats cache: %v\n", err)
+			}
+		}()
+	} else if s.Watch {
+		cache = pkg.NewStatsCache(pkg.StatsCodec(transforms))
+	}
+
+	compute := func() (*pkg.Stats, error) {
+		stats := pkg.NewStats()
+		stats.Transforms = transforms
+		stats.Cache = cache
+		stats.Workers = s.Workers
+		var err error
+		if gitOptions := s.Options(); gitOptions.Enabled() {
+			var files []pkg.SourceFile
+			files, err = pkg.GitFiles(s.Paths, gitOptions, ff)
+			if err == nil {
+				err = stats.ComputeSourceStats(files)
+			}
+		} else {
+			err = stats.ComputeStats(s.Paths, ff)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error computing stats: %w", err)
+		}
+		return stats, nil
+	}
+
+	stats, err := compute()
 	if err != nil {
-		return fmt.Errorf("error computing stats: %w", err)
+		return err
 	}
+	transforms.WriteReport(os.Stderr)
 
 	config := pkg.Config{}
 	for _, statType := range s.Stats {
@@ -122,6 +211,10 @@ func (c *CatterStatsCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 		}
 	}
 
+	if s.Watch {
+		return watchStats(ctx, time.Duration(s.WatchInterval)*time.Second, config, stats, compute)
+	}
+
 	if !s.Glazed {
 		gp = nil
 	}
@@ -132,3 +225,46 @@ func (c *CatterStatsCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLa
 
 	return nil
 }
+
+// watchStats prints the stats as text, then recomputes them every interval
+// and prints them again when a file changed, until interrupted.
+func watchStats(ctx context.Context, interval time.Duration, config pkg.Config, stats *pkg.Stats, compute func() (*pkg.Stats, error)) error {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	show := func() {
+		// Clear the screen and move the cursor home.
+		fmt.Print("\033[H\033[2J")
+		if err := stats.PrintStats(config, nil); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error printing stats: %v\n", err)
+		}
+		fmt.Printf("\nWatching for changes every %s, press Ctrl-C to stop.\n", interval)
+	}
+	show()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+		next, err := compute()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+			continue
+		}
+		if maps.Equal(next.Files, stats.Files) {
+			continue
+		}
+		stats = next
+		show()
+	}
+}
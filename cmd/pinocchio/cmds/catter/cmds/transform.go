@@ -0,0 +1,68 @@
+package cmds
+
+import (
+	"fmt"
+
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg"
+
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+)
+
+// defaultFilterYAML is the filter file loaded when --filter-yaml is not set.
+const defaultFilterYAML = ".catter-filter.yaml"
+
+// TransformSettings are the flags adding transforms to the ones of the
+// filter YAML file, shared by print, stats and serve.
+type TransformSettings struct {
+	Transform              []string `glazed:"transform"`
+	AllowCommandTransforms bool     `glazed:"allow-command-transforms"`
+}
+
+// Pipeline builds the transforms of the filter YAML file, or its profile,
+// followed by the --transform ones.
+//
+// Command transforms run shell commands, so those of a .catter-filter.yaml
+// that was merely found in the working directory, such as one checked into
+// a cloned repository, are refused unless --allow-command-transforms is set.
+// A file passed with --filter-yaml is trusted.
+func (s *TransformSettings) Pipeline(filterYAML, filterProfile string) (*pkg.Pipeline, error) {
+	explicit := filterYAML != ""
+	if !explicit {
+		filterYAML = defaultFilterYAML
+	}
+	configs, err := pkg.LoadTransforms(filterYAML, filterProfile)
+	if err != nil {
+		return nil, err
+	}
+	if !explicit && !s.AllowCommandTransforms {
+		for _, config := range configs {
+			if config.Type == pkg.TransformCommand {
+				return nil, fmt.Errorf("%s lists command transforms, which run shell commands; pass --filter-yaml %s or --allow-command-transforms to run them", filterYAML, filterYAML)
+			}
+		}
+	}
+	for _, name := range s.Transform {
+		configs = append(configs, pkg.TransformConfig{Type: name})
+	}
+	pipeline, err := pkg.NewPipeline(configs)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transforms: %w", err)
+	}
+	return pipeline, nil
+}
+
+func transformFlags() []*fields.Definition {
+	return []*fields.Definition{
+		fields.New(
+			"transform",
+			fields.TypeStringList,
+			fields.WithHelp("Transforms to run over each file, after those of the filter YAML (redact-secrets, strip-license, strip-comments, strip-blank-lines, minify)"),
+		),
+		fields.New(
+			"allow-command-transforms",
+			fields.TypeBool,
+			fields.WithHelp("Run the command transforms of the "+defaultFilterYAML+" found in the working directory"),
+			fields.WithDefault(false),
+		),
+	}
+}
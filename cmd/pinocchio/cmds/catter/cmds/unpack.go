@@ -0,0 +1,116 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+)
+
+type CatterUnpackSettings struct {
+	Format    string `glazed:"format"`
+	OutputDir string `glazed:"output-dir"`
+	DryRun    bool   `glazed:"dry-run"`
+	Force     bool   `glazed:"force"`
+	Bundle    string `glazed:"bundle"`
+}
+
+type CatterUnpackCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*CatterUnpackCommand)(nil)
+
+func NewCatterUnpackCommand() (*CatterUnpackCommand, error) {
+	return &CatterUnpackCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"unpack",
+			cmds.WithShort("Write the files of a catter bundle back to disk"),
+			cmds.WithLong(`Read a bundle written by catter print --output-format jsonl, repomap or markdown,
+typically after a model edited it, and write its files below --output-dir.
+
+Files that were not edited in the bundle are not written over the files on
+disk, and outlines and redacted or truncated files are never written, even
+with --force.
+If a file was edited in the bundle and also changed on disk since, nothing
+is written unless --force is given.`),
+			cmds.WithFlags(
+				fields.New(
+					"format",
+					fields.TypeString,
+					fields.WithHelp("Format of the bundle: jsonl, repomap or markdown (detected if empty)"),
+				),
+				fields.New(
+					"output-dir",
+					fields.TypeString,
+					fields.WithHelp("Directory to write the files to"),
+					fields.WithDefault("."),
+					fields.WithShortFlag("o"),
+				),
+				fields.New(
+					"dry-run",
+					fields.TypeBool,
+					fields.WithHelp("Print what would be written without writing"),
+					fields.WithDefault(false),
+				),
+				fields.New(
+					"force",
+					fields.TypeBool,
+					fields.WithHelp("Write conflicting files over the files on disk; unedited, transformed and truncated files are still left alone"),
+					fields.WithDefault(false),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"bundle",
+					fields.TypeString,
+					fields.WithHelp("Bundle file to unpack (- for stdin)"),
+					fields.WithDefault("-"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *CatterUnpackCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &CatterUnpackSettings{}
+	if err := parsedLayers.DecodeSectionInto(values.DefaultSlug, s); err != nil {
+		return fmt.Errorf("error initializing settings: %w", err)
+	}
+
+	var in io.Reader = os.Stdin
+	if s.Bundle != "-" {
+		f, err := os.Open(s.Bundle)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	files, err := pkg.ReadBundle(in, s.Format)
+	if err != nil {
+		return fmt.Errorf("error reading bundle: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files found in %s", s.Bundle)
+	}
+
+	results, err := pkg.Unpack(s.OutputDir, files, pkg.UnpackOptions{DryRun: s.DryRun, Force: s.Force})
+	for _, r := range results {
+		if r.Status != pkg.UnpackUnchanged {
+			fmt.Printf("%-10s %s\n", r.Status, r.Path)
+		}
+	}
+	if errors.Is(err, pkg.ErrUnpackConflict) {
+		return fmt.Errorf("%w, nothing was written (use --force to overwrite)", err)
+	}
+	return err
+}
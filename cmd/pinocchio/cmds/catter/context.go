@@ -0,0 +1,54 @@
+package catter
+
+import (
+	"context"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/cmds"
+	pinocchiocmds "github.com/go-go-golems/pinocchio/pkg/cmds"
+	"github.com/pkg/errors"
+)
+
+// ContextSource is the "catter" source of --context-from. Its arguments are
+// the flags and paths of `catter print`; each file it would print becomes a
+// block of the turn.
+func ContextSource(_ context.Context, args []string) ([]pinocchiocmds.ContextFile, error) {
+	printCommand, err := cmds.NewCatterPrintCommand()
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := cli.NewCobraParserFromSections(printCommand.Schema, &cli.CobraParserConfig{
+		MiddlewaresFunc:            getMiddlewares,
+		SkipCommandSettingsSection: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cobraCmd := cli.NewCobraCommandFromCommandDescription(printCommand.Description())
+	if err := parser.AddToCobraCommand(cobraCmd); err != nil {
+		return nil, err
+	}
+	if err := cobraCmd.ParseFlags(args); err != nil {
+		return nil, errors.Wrap(err, "invalid catter arguments")
+	}
+	parsedLayers, err := parser.Parse(cobraCmd, cobraCmd.Flags().Args())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid catter arguments")
+	}
+
+	files, err := printCommand.CollectFiles(parsedLayers)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]pinocchiocmds.ContextFile, 0, len(files))
+	for _, f := range files {
+		ret = append(ret, pinocchiocmds.ContextFile{
+			Path:    f.Path,
+			Content: f.Content,
+			Tokens:  f.Tokens,
+			Outline: f.Outline,
+		})
+	}
+	return ret, nil
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// BM25 scores each document against query with Okapi BM25. Terms are
+// lowercased words; identifiers are split on case changes, so "refreshToken"
+// matches "refresh token".
+func BM25(query string, docs []string) []float64 {
+	terms := uniqueTerms(tokenizeTerms(query))
+	scores := make([]float64, len(docs))
+	if len(terms) == 0 || len(docs) == 0 {
+		return scores
+	}
+
+	freqs := make([]map[string]int, len(docs))
+	lengths := make([]int, len(docs))
+	docFreq := map[string]int{}
+	total := 0
+	for i, doc := range docs {
+		tokens := tokenizeTerms(doc)
+		lengths[i] = len(tokens)
+		total += len(tokens)
+		freqs[i] = map[string]int{}
+		for _, t := range tokens {
+			freqs[i][t]++
+		}
+		for _, t := range terms {
+			if freqs[i][t] > 0 {
+				docFreq[t]++
+			}
+		}
+	}
+	avgLen := float64(total) / float64(len(docs))
+	if avgLen == 0 {
+		return scores
+	}
+
+	n := float64(len(docs))
+	for i := range docs {
+		for _, t := range terms {
+			tf := float64(freqs[i][t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLen)
+			scores[i] += idf * tf * (bm25K1 + 1) / norm
+		}
+	}
+	return scores
+}
+
+func tokenizeTerms(text string) []string {
+	var terms []string
+	var word []rune
+	flush := func() {
+		if len(word) > 1 {
+			terms = append(terms, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	var prev rune
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if unicode.IsUpper(r) && unicode.IsLower(prev) {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+		prev = r
+	}
+	flush()
+	return terms
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	var ret []string
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
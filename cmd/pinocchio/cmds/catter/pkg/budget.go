@@ -0,0 +1,264 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+// Strategies rank the files competing for a token budget. Earlier
+// strategies win; later ones break ties.
+const (
+	// StrategyPriority ranks files by the first --priority glob they match.
+	StrategyPriority = "priority"
+	// StrategyFocus ranks files by BM25 relevance of path and content to --focus.
+	StrategyFocus = "focus"
+	// StrategyRecency ranks files by the newest of the last --recent-commits
+	// commits that touched them.
+	StrategyRecency = "recency"
+	// StrategySize ranks smaller files first.
+	StrategySize = "size"
+)
+
+var DefaultBudgetStrategies = []string{StrategyPriority, StrategyFocus, StrategyRecency, StrategySize}
+
+type BudgetOptions struct {
+	// Tokens is the total number of content tokens to pack.
+	Tokens        int
+	Strategies    []string
+	Priority      []string
+	Focus         string
+	RecentCommits int
+}
+
+// BudgetFile is a file competing for the budget. Content has the per-file
+// limits applied already.
+type BudgetFile struct {
+	Path    string
+	Content string
+	Tokens  int
+}
+
+type PackStatus string
+
+const (
+	PackFull    PackStatus = "full"
+	PackOutline PackStatus = "outline"
+	PackDropped PackStatus = "dropped"
+)
+
+type PackedFile struct {
+	BudgetFile
+	Status PackStatus
+	// Outline is set for outlined files, including the header.
+	Outline       string
+	OutlineTokens int
+	// Rank is the 1-based position in the ranking.
+	Rank   int
+	Reason string
+}
+
+type PackResult struct {
+	Budget int
+	Used   int
+	// Files are in the order they were given.
+	Files []PackedFile
+}
+
+type rankInfo struct {
+	keys []float64
+	desc []string
+}
+
+// Pack ranks files with opts.Strategies and fills the budget greedily. A
+// file that does not fit is replaced by its outline when that fits, and
+// dropped otherwise.
+func Pack(files []BudgetFile, opts BudgetOptions, countTokens func(string) int) (*PackResult, error) {
+	strategies := opts.Strategies
+	if len(strategies) == 0 {
+		strategies = DefaultBudgetStrategies
+	}
+	ranks, err := rankFiles(files, strategies, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ka, kb := ranks[order[a]].keys, ranks[order[b]].keys
+		for i := range ka {
+			if ka[i] != kb[i] {
+				return ka[i] < kb[i]
+			}
+		}
+		return false
+	})
+
+	result := &PackResult{Budget: opts.Tokens, Files: make([]PackedFile, len(files))}
+	for rank, i := range order {
+		f := files[i]
+		p := PackedFile{BudgetFile: f, Rank: rank + 1}
+		left := opts.Tokens - result.Used
+		rankDesc := fmt.Sprintf("rank %d of %d", rank+1, len(files))
+		if d := strings.Join(ranks[i].desc, ", "); d != "" {
+			rankDesc += ": " + d
+		}
+
+		if f.Tokens <= left {
+			p.Status = PackFull
+			p.Reason = rankDesc
+			result.Used += f.Tokens
+		} else {
+			p.Outline = fmt.Sprintf("[outline of %s: the full file is %d tokens]\n%s", f.Path, f.Tokens, Outline(f.Path, []byte(f.Content)))
+			p.OutlineTokens = countTokens(p.Outline)
+			if p.OutlineTokens <= left {
+				p.Status = PackOutline
+				p.Reason = fmt.Sprintf("%d tokens did not fit in the %d left; %s", f.Tokens, left, rankDesc)
+				result.Used += p.OutlineTokens
+			} else {
+				p.Status = PackDropped
+				p.Reason = fmt.Sprintf("%d tokens (outline %d) did not fit in the %d left; %s", f.Tokens, p.OutlineTokens, left, rankDesc)
+			}
+		}
+		result.Files[i] = p
+	}
+	return result, nil
+}
+
+func rankFiles(files []BudgetFile, strategies []string, opts BudgetOptions) ([]rankInfo, error) {
+	ranks := make([]rankInfo, len(files))
+	wd, _ := os.Getwd()
+	rels := make([]string, len(files))
+	abs := make([]string, len(files))
+	for i, f := range files {
+		abs[i], _ = filepath.Abs(f.Path)
+		rels[i] = filepath.ToSlash(f.Path)
+		if rel, err := filepath.Rel(wd, abs[i]); err == nil && !strings.HasPrefix(rel, "..") {
+			rels[i] = filepath.ToSlash(rel)
+		}
+	}
+
+	for _, strategy := range strategies {
+		switch strategy {
+		case StrategyPriority:
+			if len(opts.Priority) == 0 {
+				continue
+			}
+			var globs []*filefilter.Pattern
+			for _, g := range opts.Priority {
+				p, err := filefilter.ParsePattern(g)
+				if err != nil {
+					return nil, fmt.Errorf("invalid --priority glob: %w", err)
+				}
+				if p != nil {
+					globs = append(globs, p)
+				}
+			}
+			for i := range files {
+				idx := len(globs)
+				for j, g := range globs {
+					if matchesLevels(g, rels[i]) {
+						idx = j
+						break
+					}
+				}
+				ranks[i].keys = append(ranks[i].keys, float64(idx))
+				if idx < len(globs) {
+					ranks[i].desc = append(ranks[i].desc, "priority "+globs[idx].Pattern)
+				}
+			}
+
+		case StrategyFocus:
+			if strings.TrimSpace(opts.Focus) == "" {
+				continue
+			}
+			docs := make([]string, len(files))
+			for i, f := range files {
+				docs[i] = rels[i] + "\n" + f.Content
+			}
+			for i, score := range BM25(opts.Focus, docs) {
+				ranks[i].keys = append(ranks[i].keys, -score)
+				ranks[i].desc = append(ranks[i].desc, fmt.Sprintf("focus %.2f", score))
+			}
+
+		case StrategyRecency:
+			if opts.RecentCommits <= 0 {
+				continue
+			}
+			recent, err := RecentFiles(wd, opts.RecentCommits)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Warning: not ranking by git recency: %v\n", err)
+				continue
+			}
+			for i := range files {
+				commit, ok := recent[abs[i]]
+				if !ok {
+					ranks[i].keys = append(ranks[i].keys, float64(opts.RecentCommits))
+					continue
+				}
+				ranks[i].keys = append(ranks[i].keys, float64(commit))
+				ranks[i].desc = append(ranks[i].desc, fmt.Sprintf("touched %d commits ago", commit))
+			}
+
+		case StrategySize:
+			for i, f := range files {
+				ranks[i].keys = append(ranks[i].keys, float64(f.Tokens))
+			}
+
+		default:
+			return nil, fmt.Errorf("unknown budget strategy %q (use %s)", strategy, strings.Join(DefaultBudgetStrategies, ", "))
+		}
+	}
+	return ranks, nil
+}
+
+// matchesLevels reports whether p matches rel or one of its parent
+// directories, so "pkg/auth/" selects every file below it.
+func matchesLevels(p *filefilter.Pattern, rel string) bool {
+	segments := strings.Split(rel, "/")
+	for i := range segments {
+		if p.Match(strings.Join(segments[:i+1], "/"), i < len(segments)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteReport summarizes the packing and lists outlined and dropped files
+// with the reason.
+func (r *PackResult) WriteReport(w io.Writer) {
+	var full, outlined, dropped []PackedFile
+	for _, f := range r.Files {
+		switch f.Status {
+		case PackFull:
+			full = append(full, f)
+		case PackOutline:
+			outlined = append(outlined, f)
+		case PackDropped:
+			dropped = append(dropped, f)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "Token budget: %d of %d tokens used; %d files in full, %d as outlines, %d dropped\n",
+		r.Used, r.Budget, len(full), len(outlined), len(dropped))
+	writePacked := func(title string, files []PackedFile) {
+		if len(files) == 0 {
+			return
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Rank < files[j].Rank })
+		_, _ = fmt.Fprintf(w, "%s:\n", title)
+		for _, f := range files {
+			_, _ = fmt.Fprintf(w, "  %s: %s\n", f.Path, f.Reason)
+		}
+	}
+	writePacked("Outlined", outlined)
+	writePacked("Dropped", dropped)
+}
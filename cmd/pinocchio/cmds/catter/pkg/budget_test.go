@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func countWords(s string) int {
+	return len(strings.Fields(s))
+}
+
+func budgetFile(path string, content string) BudgetFile {
+	return BudgetFile{Path: path, Content: content, Tokens: countWords(content)}
+}
+
+func TestPackOutlinesAndDropsWhatDoesNotFit(t *testing.T) {
+	big := "package big\n\n// Run does the work.\nfunc Run() error {\n" + strings.Repeat("\tx := 1\n\t_ = x\n", 50) + "\treturn nil\n}\n"
+	files := []BudgetFile{
+		budgetFile("big.go", big),
+		budgetFile("a.go", "package a one two three"),
+		budgetFile("huge.txt", strings.Repeat("word ", 500)),
+	}
+
+	r, err := Pack(files, BudgetOptions{Tokens: 40, Strategies: []string{StrategySize}}, countWords)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if got := []PackStatus{r.Files[0].Status, r.Files[1].Status, r.Files[2].Status}; got[0] != PackOutline || got[1] != PackFull || got[2] != PackDropped {
+		t.Fatalf("statuses = %v", got)
+	}
+	if !strings.Contains(r.Files[0].Outline, "func Run() error") || strings.Contains(r.Files[0].Outline, "x := 1") {
+		t.Fatalf("unexpected outline:\n%s", r.Files[0].Outline)
+	}
+	if r.Used > 40 || r.Used != r.Files[1].Tokens+r.Files[0].OutlineTokens {
+		t.Fatalf("used = %d", r.Used)
+	}
+
+	var buf bytes.Buffer
+	r.WriteReport(&buf)
+	for _, want := range []string{"1 files in full, 1 as outlines, 1 dropped", "Dropped:\n  huge.txt: 500 tokens"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPackRanksByPriorityThenFocus(t *testing.T) {
+	files := []BudgetFile{
+		budgetFile("docs/readme.md", "general notes about the project"),
+		budgetFile("pkg/auth/token.go", "func refreshToken() { refresh the oauth token }"),
+		budgetFile("pkg/auth/login.go", "func login() { check password }"),
+		budgetFile("pkg/db/db.go", "func open() { connect }"),
+	}
+	r, err := Pack(files, BudgetOptions{
+		Tokens:     100,
+		Strategies: []string{StrategyPriority, StrategyFocus},
+		Priority:   []string{"pkg/auth/"},
+		Focus:      "refresh token",
+	}, countWords)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	ranks := map[string]int{}
+	for _, f := range r.Files {
+		ranks[f.Path] = f.Rank
+	}
+	if ranks["pkg/auth/token.go"] != 1 || ranks["pkg/auth/login.go"] != 2 {
+		t.Fatalf("ranks = %v", ranks)
+	}
+	if !strings.Contains(r.Files[1].Reason, "priority pkg/auth/, focus ") {
+		t.Fatalf("reason = %q", r.Files[1].Reason)
+	}
+
+	if _, err := Pack(files, BudgetOptions{Tokens: 10, Strategies: []string{"random"}}, countWords); err == nil {
+		t.Fatalf("expected unknown strategy error")
+	}
+}
+
+func TestBM25PrefersMatchingDocuments(t *testing.T) {
+	scores := BM25("refresh token", []string{
+		"func refreshToken() {}",
+		"func login() {}",
+		"token token token",
+	})
+	if scores[1] != 0 || scores[0] <= scores[2] || scores[2] <= 0 {
+		t.Fatalf("scores = %v", scores)
+	}
+}
@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Bundle formats, written as a whole after every file is selected. Unlike
+// the text delimiters they can be read back by ReadBundle.
+const (
+	FormatJSONL    = "jsonl"
+	FormatRepoMap  = "repomap"
+	FormatMarkdown = "markdown"
+)
+
+// BundleFormats are the formats WriteBundle and ReadBundle support.
+var BundleFormats = []string{FormatJSONL, FormatRepoMap, FormatMarkdown}
+
+// IsBundleFormat reports whether format is one of BundleFormats.
+func IsBundleFormat(format string) bool {
+	for _, f := range BundleFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// BundleFile is a file of a bundle. It is also the object of each line of a
+// JSONL bundle.
+type BundleFile struct {
+	// Path is slash-separated and relative to the working directory.
+	Path     string `json:"path"`
+	Language string `json:"language,omitempty"`
+	// Hash is the hex SHA-256 of Content as it was written, so unpack can
+	// tell which files were edited.
+	Hash string `json:"hash,omitempty"`
+	// SourceHash is the hex SHA-256 of the file on disk when the bundle was
+	// written, so unpack can tell which files changed since. It differs
+	// from Hash when Content was transformed or truncated.
+	SourceHash string `json:"source_hash,omitempty"`
+	Tokens     int    `json:"tokens,omitempty"`
+	// Outline is set when Content is an outline, which unpack never writes
+	// back.
+	Outline bool   `json:"outline,omitempty"`
+	Content string `json:"content"`
+}
+
+// NewBundleFile describes an emitted file for a bundle.
+func NewBundleFile(f EmittedFile) BundleFile {
+	return BundleFile{
+		Path:       path.Clean(filepath.ToSlash(getArchivePath(f.Path))),
+		Language:   Language(f.Path),
+		Hash:       contentHash([]byte(f.Content)),
+		SourceHash: f.SourceHash,
+		Tokens:     f.Tokens,
+		Outline:    f.Outline,
+		Content:    f.Content,
+	}
+}
+
+// Transformed reports whether the bundled content is not the file on disk,
+// because transforms or limits changed it. Unpack never writes such files.
+func (f BundleFile) Transformed() bool {
+	return f.SourceHash != "" && f.Hash != "" && f.SourceHash != f.Hash
+}
+
+var languages = map[string]string{
+	".go": "go", ".py": "python", ".pyi": "python", ".rb": "ruby", ".php": "php", ".pl": "perl", ".lua": "lua",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "jsx",
+	".ts": "typescript", ".mts": "typescript", ".cts": "typescript", ".tsx": "tsx",
+	".java": "java", ".kt": "kotlin", ".scala": "scala", ".swift": "swift", ".rs": "rust", ".cs": "csharp",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".hs": "haskell", ".r": "r",
+	".sh": "bash", ".bash": "bash", ".zsh": "zsh", ".sql": "sql", ".proto": "protobuf",
+	".html": "html", ".htm": "html", ".css": "css", ".scss": "scss", ".less": "less", ".xml": "xml", ".svg": "xml",
+	".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".md": "markdown", ".mk": "makefile",
+}
+
+// Language returns the name of the language of path, as used in Markdown
+// code fences, or "" if it is unknown.
+func Language(p string) string {
+	switch filepath.Base(p) {
+	case "Makefile":
+		return "makefile"
+	case "Dockerfile":
+		return "dockerfile"
+	}
+	return languages[strings.ToLower(filepath.Ext(p))]
+}
+
+// WriteBundle writes files to w in one of BundleFormats.
+func WriteBundle(w io.Writer, format string, files []BundleFile) error {
+	switch format {
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, f := range files {
+			if err := enc.Encode(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatRepoMap:
+		return writeRepoMap(w, files)
+	case FormatMarkdown:
+		return writeMarkdown(w, files)
+	}
+	return fmt.Errorf("unknown bundle format %q (available: %s)", format, strings.Join(BundleFormats, ", "))
+}
+
+// writeRepoMap writes a <repository> document: the directory tree of the
+// files, then each file with its content in CDATA sections.
+func writeRepoMap(w io.Writer, files []BundleFile) error {
+	var b strings.Builder
+	b.WriteString("<repository>\n<tree>\n")
+	b.WriteString(escapeXML(directoryTree(files)))
+	b.WriteString("</tree>\n<files>\n")
+	for _, f := range files {
+		fmt.Fprintf(&b, "<file path=\"%s\"", escapeXML(f.Path))
+		if f.Language != "" {
+			fmt.Fprintf(&b, " language=\"%s\"", f.Language)
+		}
+		if f.Hash != "" {
+			fmt.Fprintf(&b, " hash=\"%s\"", f.Hash)
+		}
+		if f.SourceHash != "" {
+			fmt.Fprintf(&b, " source-hash=\"%s\"", f.SourceHash)
+		}
+		fmt.Fprintf(&b, " tokens=\"%d\"", f.Tokens)
+		if f.Outline {
+			b.WriteString(" outline=\"true\"")
+		}
+		b.WriteString(">\n<![CDATA[")
+		// A CDATA section cannot contain its terminator, so split it.
+		b.WriteString(strings.ReplaceAll(f.Content, "]]>", "]]]]><![CDATA[>"))
+		b.WriteString("]]>\n</file>\n")
+	}
+	b.WriteString("</files>\n</repository>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// directoryTree renders the paths of files as an indented tree, directories
+// first, with a trailing slash.
+func directoryTree(files []BundleFile) string {
+	type dir struct {
+		dirs  map[string]*dir
+		files []string
+	}
+	newDir := func() *dir { return &dir{dirs: map[string]*dir{}} }
+	root := newDir()
+	for _, f := range files {
+		d := root
+		parts := strings.Split(path.Clean(f.Path), "/")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := d.dirs[part]
+			if !ok {
+				child = newDir()
+				d.dirs[part] = child
+			}
+			d = child
+		}
+		d.files = append(d.files, parts[len(parts)-1])
+	}
+
+	var b strings.Builder
+	var render func(d *dir, indent string)
+	render = func(d *dir, indent string) {
+		names := make([]string, 0, len(d.dirs))
+		for name := range d.dirs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s%s/\n", indent, name)
+			render(d.dirs[name], indent+"  ")
+		}
+		sort.Strings(d.files)
+		for _, name := range d.files {
+			fmt.Fprintf(&b, "%s%s\n", indent, name)
+		}
+	}
+	render(root, "")
+	return b.String()
+}
+
+// writeMarkdown writes a table of contents linking to an anchored section
+// per file. Contents are fenced with more backticks than they contain, and
+// end with a newline. The anchors carry the hashes.
+func writeMarkdown(w io.Writer, files []BundleFile) error {
+	anchors := make([]string, len(files))
+	used := map[string]int{}
+	for i, f := range files {
+		anchor := "file-" + strings.Trim(nonAnchorChars.ReplaceAllString(strings.ToLower(f.Path), "-"), "-")
+		used[anchor]++
+		if n := used[anchor]; n > 1 {
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		}
+		anchors[i] = anchor
+	}
+
+	var b strings.Builder
+	b.WriteString("# Files\n\n")
+	for i, f := range files {
+		fmt.Fprintf(&b, "- [%s](#%s) (%d tokens", f.Path, anchors[i], f.Tokens)
+		if f.Outline {
+			b.WriteString(", outline")
+		}
+		b.WriteString(")\n")
+	}
+	for i, f := range files {
+		heading := f.Path
+		if f.Outline {
+			heading += markdownOutlineSuffix
+		}
+		fence := strings.Repeat("`", max(3, longestBacktickRun(f.Content)+1))
+		content := f.Content
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		sourceHash := ""
+		if f.SourceHash != "" {
+			sourceHash = fmt.Sprintf(" data-source-hash=\"%s\"", f.SourceHash)
+		}
+		fmt.Fprintf(&b, "\n<a id=\"%s\" data-hash=\"%s\"%s></a>\n## %s\n\n%s%s\n%s%s\n", anchors[i], f.Hash, sourceHash, heading, fence, f.Language, content, fence)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+const markdownOutlineSuffix = " (outline)"
+
+var nonAnchorChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func longestBacktickRun(s string) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
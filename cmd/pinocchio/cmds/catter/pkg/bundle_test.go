@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func bundleFiles() []BundleFile {
+	var files []BundleFile
+	for _, f := range []EmittedFile{
+		{Path: "pkg/a.go", Content: "package a\n\n// ## not a heading\nvar s = \"]]> ```\"\n", Tokens: 12},
+		{Path: "cmd/main.go", Content: "package main", Tokens: 2},
+		{Path: "README.md", Content: "# Title\n\n```go\nx := 1\n```\n", Tokens: 8},
+		{Path: "pkg/b.go", Content: "func B()\n", Tokens: 3, Outline: true, SourceHash: contentHash([]byte("func B() {}\n"))},
+	} {
+		files = append(files, NewBundleFile(f))
+	}
+	return files
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	files := bundleFiles()
+	for _, format := range BundleFormats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteBundle(&buf, format, files); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadBundle(bytes.NewReader(buf.Bytes()), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(files) {
+				t.Fatalf("read %d files from:\n%s", len(got), buf.String())
+			}
+			for i, f := range files {
+				if got[i].Path != f.Path || got[i].Content != f.Content || got[i].Hash != f.Hash || got[i].SourceHash != f.SourceHash || got[i].Outline != f.Outline {
+					t.Errorf("file %d = %+v, want %+v", i, got[i], f)
+				}
+			}
+		})
+	}
+}
+
+func TestRepoMapTree(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, FormatRepoMap, bundleFiles()); err != nil {
+		t.Fatal(err)
+	}
+	want := "<repository>\n<tree>\ncmd/\n  main.go\npkg/\n  a.go\n  b.go\nREADME.md\n</tree>\n"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Fatalf("repomap:\n%s", buf.String())
+	}
+}
+
+func TestReadRepoMapWithoutCDATA(t *testing.T) {
+	files, err := ReadBundle(strings.NewReader("<file path=\"a &amp; b.go\">\nif a < b {}\n</file>\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BundleFile{{Path: "a & b.go", Content: "if a < b {}\n"}}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("files = %+v", files)
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	dir := t.TempDir()
+	original := map[string]string{
+		"edited.go":    "package a\n",
+		"redacted.go":  "key = secret\n",
+		"conflict.go":  "package c\n",
+		"unchanged.go": "package u\n",
+		"outline.go":   "package o\n\nfunc O() {}\n",
+	}
+	writeFiles(t, dir, original)
+
+	bundled := func(path, content string) BundleFile {
+		return BundleFile{Path: path, Content: content, Hash: contentHash([]byte(content))}
+	}
+	files := []BundleFile{
+		bundled("edited.go", "package a\n"),
+		bundled("redacted.go", "key = [REDACTED]\n"),
+		bundled("conflict.go", "package c\n"),
+		bundled("unchanged.go", "package u\n"),
+		{Path: "outline.go", Content: "package o\n\nfunc O()\n", Outline: true},
+		{Path: "new/file.go", Content: "package new\n"},
+	}
+	files[0].Content = "package a // edited\n"
+	files[2].Content = "package c // edited\n"
+	writeFiles(t, dir, map[string]string{"conflict.go": "package c // changed on disk\n"})
+
+	_, err := Unpack(dir, files, UnpackOptions{})
+	if !errors.Is(err, ErrUnpackConflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new", "file.go")); err == nil {
+		t.Fatal("files were written despite the conflict")
+	}
+
+	files = append(files[:2], files[3:]...)
+	results, err := Unpack(dir, files, UnpackOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.Path+" "+r.Status)
+	}
+	want := []string{"edited.go updated", "redacted.go not edited", "unchanged.go unchanged", "outline.go outline", "new/file.go created"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %q", statuses)
+	}
+	for path, content := range map[string]string{
+		"edited.go":   "package a // edited\n",
+		"redacted.go": original["redacted.go"],
+		"outline.go":  original["outline.go"],
+		"new/file.go": "package new\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v", path, data, err)
+		}
+	}
+
+	if _, err := Unpack(dir, []BundleFile{{Path: "../escape.go"}}, UnpackOptions{}); err == nil {
+		t.Fatal("expected an error for a path outside the directory")
+	}
+
+	// Force overwrites conflicts but never writes back a redacted file.
+	results, err = Unpack(dir, []BundleFile{bundled("redacted.go", "key = [REDACTED]\n")}, UnpackOptions{Force: true})
+	if err != nil || results[0].Status != UnpackNotEdited {
+		t.Fatalf("forced redacted file = %+v, %v", results, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Fatalf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestUnpackRefusesSymlinksOutOfTheDirectory(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, outside, map[string]string{"target.go": "package outside\n"})
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "target.go"), filepath.Join(dir, "file.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"link/target.go", "link/new.go", "file.go"} {
+		if _, err := Unpack(dir, []BundleFile{{Path: path, Content: "package pwned\n"}}, UnpackOptions{Force: true}); err == nil {
+			t.Errorf("%s: expected an error for a path through a symlink out of the directory", path)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(outside, "target.go"))
+	if err != nil || string(data) != "package outside\n" {
+		t.Fatalf("file outside the directory = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.go")); err == nil {
+		t.Fatal("file created outside the directory")
+	}
+}
+
+func TestUnpackNeverWritesTransformedFiles(t *testing.T) {
+	dir := t.TempDir()
+	original := map[string]string{
+		"redacted.go":  "key = secret\n",
+		"truncated.go": "package t\n\nfunc T() {}\n",
+		"plain.go":     "package p\n",
+	}
+	writeFiles(t, dir, original)
+
+	var files []BundleFile
+	for _, f := range []EmittedFile{
+		{Path: "redacted.go", Content: "key = [REDACTED]\n"},
+		{Path: "truncated.go", Content: "package t\n"},
+		{Path: "plain.go", Content: "package p\n"},
+	} {
+		f.SourceHash = contentHash([]byte(original[f.Path]))
+		files = append(files, NewBundleFile(f))
+	}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, FormatRepoMap, files); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ReadBundle(strings.NewReader(buf.String()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[0].Content = "key = [REDACTED] // edited\n"
+	files[1].Content = "package t // edited\n"
+	files[2].Content = "package p // edited\n"
+
+	// Edits of files that did not change on disk are not conflicts, even
+	// though their bundled content differs from the disk.
+	results, err := Unpack(dir, files, UnpackOptions{Force: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.Path+" "+r.Status)
+	}
+	want := []string{"redacted.go transformed", "truncated.go transformed", "plain.go updated"}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %q", statuses)
+	}
+	for path, content := range map[string]string{
+		"redacted.go":  original["redacted.go"],
+		"truncated.go": original["truncated.go"],
+		"plain.go":     "package p // edited\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v", path, data, err)
+		}
+	}
+
+	// The source hash, not the bundled content, tells whether the file
+	// changed on disk since.
+	writeFiles(t, dir, map[string]string{"plain.go": "package p // changed on disk\n"})
+	files[2].Content = "package p // edited again\n"
+	if _, err := Unpack(dir, files[2:], UnpackOptions{}); !errors.Is(err, ErrUnpackConflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/go-go-golems/glazed/pkg/middlewares"
@@ -38,16 +37,42 @@ type FileProcessor struct {
 	OutputFormat   string
 	OutputFile     string
 	ArchivePrefix  string
+	Budget         *BudgetOptions
+	Mode           string
+	Git            *GitOptions
+	Transforms     *Pipeline
+	Sink           func(EmittedFile) error
+	Writer         io.Writer
 	archiveWriter  io.Closer
 	fileWriter     io.WriteCloser
 	zipWriter      *zip.Writer
 	tarWriter      *tar.Writer
 	gzipWriter     *gzip.Writer
 	archiveCounter *countingWriter
+	bundle         []BundleFile
 }
 
 type FileProcessorOption func(*FileProcessor)
 
+// Content modes.
+const (
+	ModeFull    = "full"
+	ModeOutline = "outline"
+)
+
+// EmittedFile is a file as it is written out, after limits, modes and
+// budgets.
+type EmittedFile struct {
+	Path    string
+	Content string
+	Tokens  int
+	// Outline is set when Content is an outline of the file.
+	Outline bool
+	// SourceHash is the hex SHA-256 of the file as it was read, before
+	// transforms and limits.
+	SourceHash string
+}
+
 var (
 	ErrMaxTokensExceeded    = errors.New("maximum total tokens limit reached")
 	ErrMaxTotalSizeExceeded = errors.New("maximum total size limit reached")
@@ -141,17 +166,94 @@ func WithArchivePrefix(prefix string) FileProcessorOption {
 	}
 }
 
+// WithMode selects whether files are printed in full or as outlines.
+func WithMode(mode string) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		fp.Mode = mode
+	}
+}
+
+// WithTokenBudget packs the best files into opts.Tokens content tokens
+// instead of printing every file. See Pack.
+func WithTokenBudget(opts BudgetOptions) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		if opts.Tokens > 0 {
+			fp.Budget = &opts
+		}
+	}
+}
+
+// WithGit selects the files to print from git instead of walking the paths.
+// See GitFiles.
+func WithGit(opts GitOptions) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		if opts.Enabled() {
+			fp.Git = &opts
+		}
+	}
+}
+
+// WithTransforms runs each file through p before it is counted and printed.
+func WithTransforms(p *Pipeline) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		fp.Transforms = p
+	}
+}
+
+// WithWriter writes the text output, or an archive when no output file is
+// set, to w instead of stdout.
+func WithWriter(w io.Writer) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		fp.Writer = w
+	}
+}
+
+// WithSink hands each file to sink instead of writing it to the output.
+func WithSink(sink func(EmittedFile) error) FileProcessorOption {
+	return func(fp *FileProcessor) {
+		fp.Sink = sink
+	}
+}
+
 func (fp *FileProcessor) ProcessPaths(paths []string) error {
 	if fp.PrintFilters {
-		fp.printConfiguredFilters()
-		return nil
+		return fp.printConfiguredFilters(paths)
+	}
+	if !IsBundleFormat(fp.OutputFormat) || fp.ListOnly || fp.Sink != nil {
+		return fp.processPaths(paths)
 	}
 
+	// Bundles start with an index of their files, so they are written once
+	// every file is known.
+	fp.bundle = nil
+	if err := fp.processPaths(paths); err != nil {
+		return err
+	}
+	return WriteBundle(fp.out(), fp.OutputFormat, fp.bundle)
+}
+
+func (fp *FileProcessor) processPaths(paths []string) error {
 	var err error
 
+	var gitFiles []SourceFile
+	if fp.Git != nil {
+		gitFiles, err = GitFiles(paths, *fp.Git, fp.Filter)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error selecting files from git: %v\n", err)
+			return err
+		}
+	}
+
+	defer fp.Transforms.WriteReport(os.Stderr)
+
 	if fp.Processor != nil {
 		fp.Stats = NewStats()
-		err = fp.Stats.ComputeStats(paths, fp.Filter)
+		fp.Stats.Transforms = fp.Transforms
+		if fp.Git != nil {
+			err = fp.Stats.ComputeSourceStats(gitFiles)
+		} else {
+			err = fp.Stats.ComputeStats(paths, fp.Filter)
+		}
 		if err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Error computing stats: %v\n", err)
 			return err
@@ -160,15 +262,21 @@ func (fp *FileProcessor) ProcessPaths(paths []string) error {
 
 	isArchiveOutput := fp.OutputFormat == "zip" || fp.OutputFormat == "tar.gz"
 	if isArchiveOutput {
-		if fp.OutputFile == "" {
+		var archiveOut io.Writer
+		switch {
+		case fp.OutputFile != "":
+			outFile, err := os.Create(fp.OutputFile)
+			if err != nil {
+				return fmt.Errorf("failed to create output file %s: %w", fp.OutputFile, err)
+			}
+			fp.fileWriter = outFile
+			archiveOut = outFile
+		case fp.Writer != nil:
+			archiveOut = fp.Writer
+		default:
 			return fmt.Errorf("output file path is required for archive format")
 		}
-		outFile, err := os.Create(fp.OutputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file %s: %w", fp.OutputFile, err)
-		}
-		fp.fileWriter = outFile
-		fp.archiveCounter = newCountingWriter(outFile)
+		fp.archiveCounter = newCountingWriter(archiveOut)
 
 		switch fp.OutputFormat {
 		case "zip":
@@ -197,19 +305,41 @@ func (fp *FileProcessor) ProcessPaths(paths []string) error {
 		}()
 	}
 
+	if fp.Budget != nil {
+		files := gitFiles
+		if fp.Git == nil {
+			files, err = fp.collectFiles(paths)
+		}
+		if err == nil {
+			err = fp.processBudget(files)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error packing token budget: %v\n", err)
+			return err
+		}
+		paths = nil
+	} else if fp.Git != nil {
+		for _, f := range gitFiles {
+			err = fp.processSource(f)
+			if err != nil {
+				if fp.reportLimit(err) {
+					return nil
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "Error processing file %s: %v\n", f.Path, err)
+				return err
+			}
+		}
+		paths = nil
+	}
+
 	for _, path := range paths {
 		err = fp.processPath(path)
 		if err != nil {
-			if errors.Is(err, ErrMaxTokensExceeded) {
-				_, _ = fmt.Fprintf(os.Stderr, "Reached maximum total tokens limit of %d\n", fp.MaxTokens)
-				return nil
-			} else if errors.Is(err, ErrMaxTotalSizeExceeded) {
-				_, _ = fmt.Fprintf(os.Stderr, "Reached maximum total size limit of %d bytes\n", fp.MaxTotalSize)
+			if fp.reportLimit(err) {
 				return nil
-			} else {
-				_, _ = fmt.Fprintf(os.Stderr, "Error processing path %s: %v\n", path, err)
-				return err
 			}
+			_, _ = fmt.Fprintf(os.Stderr, "Error processing path %s: %v\n", path, err)
+			return err
 		}
 	}
 
@@ -237,13 +367,34 @@ func (fp *FileProcessor) ProcessPaths(paths []string) error {
 	return nil
 }
 
-func (fp *FileProcessor) processPath(path string) error {
+// reportLimit reports whether err is one of the total limits, printing which
+// one was reached.
+func (fp *FileProcessor) reportLimit(err error) bool {
+	switch {
+	case errors.Is(err, ErrMaxTokensExceeded):
+		_, _ = fmt.Fprintf(os.Stderr, "Reached maximum total tokens limit of %d\n", fp.MaxTokens)
+		return true
+	case errors.Is(err, ErrMaxTotalSizeExceeded):
+		_, _ = fmt.Fprintf(os.Stderr, "Reached maximum total size limit of %d bytes\n", fp.MaxTotalSize)
+		return true
+	}
+	return false
+}
+
+func (fp *FileProcessor) checkTotals() error {
 	if fp.MaxTokens > 0 && fp.TotalTokens >= fp.MaxTokens {
 		return ErrMaxTokensExceeded
 	}
 	if fp.MaxTotalSize > 0 && fp.TotalSize >= fp.MaxTotalSize {
 		return ErrMaxTotalSizeExceeded
 	}
+	return nil
+}
+
+func (fp *FileProcessor) processPath(path string) error {
+	if err := fp.checkTotals(); err != nil {
+		return err
+	}
 
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -288,10 +439,67 @@ func (fp *FileProcessor) processDirectory(dirPath string) error {
 
 func (fp *FileProcessor) processFileContent(filePath string, fileInfo os.FileInfo) error {
 	if fp.ListOnly {
-		fmt.Println(filePath)
+		_, _ = fmt.Fprintln(fp.out(), filePath)
 		return nil
 	}
 
+	contentBytes, sourceHash, ok := fp.readContent(SourceFile{Path: filePath, Info: fileInfo})
+	if !ok {
+		return nil
+	}
+
+	return fp.emitFile(filePath, fileInfo, sourceHash, fp.applyLimits(fp.modeContent(filePath, contentBytes)), fp.Mode == ModeOutline)
+}
+
+// processSource prints a file selected from git, whose content may come
+// from the object store rather than the disk.
+func (fp *FileProcessor) processSource(f SourceFile) error {
+	if err := fp.checkTotals(); err != nil {
+		return err
+	}
+	if fp.ListOnly {
+		_, _ = fmt.Fprintln(fp.out(), f.Path)
+		return nil
+	}
+
+	contentBytes, sourceHash, ok := fp.readContent(f)
+	if !ok {
+		return nil
+	}
+
+	return fp.emitFile(f.Path, f.Info, sourceHash, fp.applyLimits(fp.modeContent(f.Path, contentBytes)), fp.Mode == ModeOutline)
+}
+
+// readContent reads a file and runs the transforms over it, also returning
+// the hash of the file as read. Errors are reported on stderr and the file
+// is skipped.
+func (fp *FileProcessor) readContent(f SourceFile) ([]byte, string, bool) {
+	content, err := f.Read()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading file %s: %v\n", f.Path, err)
+		return nil, "", false
+	}
+	sourceHash := contentHash(content)
+	content, err = fp.Transforms.Apply(f.Path, content)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error transforming file %s: %v\n", f.Path, err)
+		return nil, "", false
+	}
+	return content, sourceHash, true
+}
+
+// modeContent returns the content printed for a file in the current mode.
+func (fp *FileProcessor) modeContent(filePath string, contentBytes []byte) []byte {
+	if fp.Mode == ModeOutline {
+		return []byte(Outline(filePath, contentBytes))
+	}
+	return contentBytes
+}
+
+// emitFile writes limitedContent as the content of filePath to the
+// configured output and updates the totals. sourceHash is the hash of the
+// file as read, and outline marks limitedContent as an outline of the file.
+func (fp *FileProcessor) emitFile(filePath string, fileInfo os.FileInfo, sourceHash string, limitedContent string, outline bool) error {
 	var fileStats FileStats
 	if fp.Processor != nil {
 		var ok bool
@@ -303,13 +511,6 @@ func (fp *FileProcessor) processFileContent(filePath string, fileInfo os.FileInf
 		}
 	}
 
-	contentBytes, err := os.ReadFile(filePath)
-	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Error reading file %s: %v\n", filePath, err)
-		return nil
-	}
-
-	limitedContent := fp.applyLimits(contentBytes)
 	actualSize := int64(len(limitedContent))
 	actualTokenCount := len(fp.TokenCounter.Encode(limitedContent, nil, nil))
 	actualLineCount := strings.Count(limitedContent, "\n")
@@ -332,6 +533,16 @@ func (fp *FileProcessor) processFileContent(filePath string, fileInfo os.FileInf
 	fp.TokenCounts[filePath] = actualTokenCount
 	fp.FileCount++
 
+	if fp.Sink != nil {
+		return fp.Sink(EmittedFile{
+			Path:       filePath,
+			Content:    limitedContent,
+			Tokens:     actualTokenCount,
+			Outline:    outline,
+			SourceHash: sourceHash,
+		})
+	}
+
 	contentBytesLimited := []byte(limitedContent)
 	compressedBefore := fp.currentArchiveSize()
 
@@ -401,17 +612,25 @@ func (fp *FileProcessor) processFileContent(filePath string, fileInfo os.FileInf
 		} else {
 			switch fp.DelimiterType {
 			case "xml":
-				fmt.Printf("<file name=\"%s\">\n<content>\n%s\n</content>\n</file>\n", filePath, limitedContent)
+				_, _ = fmt.Fprintf(fp.out(), "<file name=\"%s\">\n<content>\n%s\n</content>\n</file>\n", filePath, limitedContent)
 			case "markdown":
-				fmt.Printf("## File: %s\n\n```\n%s\n```\n\n", filePath, limitedContent)
+				_, _ = fmt.Fprintf(fp.out(), "## File: %s\n\n```\n%s\n```\n\n", filePath, limitedContent)
 			case "simple":
-				fmt.Printf("--- START FILE: %s ---\n%s\n--- END FILE: %s ---\n", filePath, limitedContent, filePath)
+				_, _ = fmt.Fprintf(fp.out(), "--- START FILE: %s ---\n%s\n--- END FILE: %s ---\n", filePath, limitedContent, filePath)
 			case "begin-end":
-				fmt.Printf("--- BEGIN FILE: %s ---\n%s\n--- END FILE: %s ---\n", filePath, limitedContent, filePath)
+				_, _ = fmt.Fprintf(fp.out(), "--- BEGIN FILE: %s ---\n%s\n--- END FILE: %s ---\n", filePath, limitedContent, filePath)
 			default:
-				fmt.Printf("File: %s\n%s\n", filePath, limitedContent)
+				_, _ = fmt.Fprintf(fp.out(), "File: %s\n%s\n", filePath, limitedContent)
 			}
 		}
+	case FormatJSONL, FormatRepoMap, FormatMarkdown:
+		fp.bundle = append(fp.bundle, NewBundleFile(EmittedFile{
+			Path:       filePath,
+			Content:    limitedContent,
+			Tokens:     actualTokenCount,
+			Outline:    outline,
+			SourceHash: sourceHash,
+		}))
 	default:
 		return fmt.Errorf("unknown output format: %s", fp.OutputFormat)
 	}
@@ -419,6 +638,112 @@ func (fp *FileProcessor) processFileContent(filePath string, fileInfo os.FileInf
 	return nil
 }
 
+// collectFiles walks paths like processPath does and returns the files that
+// pass the filter, in output order.
+func (fp *FileProcessor) collectFiles(paths []string) ([]SourceFile, error) {
+	var ret []SourceFile
+	var walk func(path string) error
+	walk = func(path string) error {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: Failed to stat path %s: %v\n", path, err)
+			return nil
+		}
+		if fp.Filter != nil && !fp.Filter.FilterPath(path) {
+			return nil
+		}
+		if !fileInfo.IsDir() {
+			ret = append(ret, SourceFile{Path: path, Info: fileInfo})
+			return nil
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return fmt.Errorf("error reading directory %s: %w", path, err)
+		}
+		for _, entry := range entries {
+			if err := walk(filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, path := range paths {
+		if err := walk(path); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// processBudget packs sources into the token budget, writes the selected
+// files and outlines, and reports the rest on stderr.
+func (fp *FileProcessor) processBudget(sources []SourceFile) error {
+	files := make([]BudgetFile, 0, len(sources))
+	infos := make([]os.FileInfo, 0, len(sources))
+	sourceHashes := make([]string, 0, len(sources))
+	for _, src := range sources {
+		contentBytes, sourceHash, ok := fp.readContent(src)
+		if !ok {
+			continue
+		}
+		content := fp.applyLimits(fp.modeContent(src.Path, contentBytes))
+		files = append(files, BudgetFile{
+			Path:    src.Path,
+			Content: content,
+			Tokens:  fp.countTokens(content),
+		})
+		infos = append(infos, src.Info)
+		sourceHashes = append(sourceHashes, sourceHash)
+	}
+
+	result, err := Pack(files, *fp.Budget, fp.countTokens)
+	if err != nil {
+		return err
+	}
+
+files:
+	for i, f := range result.Files {
+		switch f.Status {
+		case PackFull:
+			if fp.ListOnly {
+				_, _ = fmt.Fprintln(fp.out(), f.Path)
+				continue
+			}
+			err = fp.emitFile(f.Path, infos[i], sourceHashes[i], f.Content, fp.Mode == ModeOutline)
+		case PackOutline:
+			if fp.ListOnly {
+				_, _ = fmt.Fprintf(fp.out(), "%s (outline)\n", f.Path)
+				continue
+			}
+			err = fp.emitFile(f.Path, infos[i], sourceHashes[i], f.Outline, true)
+		case PackDropped:
+			continue
+		}
+		switch {
+		case errors.Is(err, ErrMaxTokensExceeded), errors.Is(err, ErrMaxTotalSizeExceeded):
+			_, _ = fmt.Fprintf(os.Stderr, "Stopped before filling the token budget: %v\n", err)
+			break files
+		case err != nil:
+			return err
+		}
+	}
+
+	result.WriteReport(os.Stderr)
+	return nil
+}
+
+// out is where text output goes.
+func (fp *FileProcessor) out() io.Writer {
+	if fp.Writer != nil {
+		return fp.Writer
+	}
+	return os.Stdout
+}
+
+func (fp *FileProcessor) countTokens(content string) int {
+	return len(fp.TokenCounter.Encode(content, nil, nil))
+}
+
 func getArchivePath(fullPath string) string {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -453,29 +778,8 @@ func (fp *FileProcessor) applyLimits(contentBytes []byte) string {
 	return content
 }
 
-func (fp *FileProcessor) printConfiguredFilters() {
-	fmt.Println("Configured Filters:")
-	fmt.Println("-------------------")
-
-	if fp.Filter == nil {
-		fmt.Println("No filters configured.")
-		return
-	}
-
-	fmt.Printf("Max File Size: %d bytes\n", fp.Filter.MaxFileSize)
-	fmt.Printf("Disable Default Filters: %v\n", fp.Filter.DisableDefaultFilters)
-	fmt.Printf("Disable GitIgnore: %v\n", fp.Filter.DisableGitIgnore)
-	fmt.Printf("Filter Binary Files: %v\n", fp.Filter.FilterBinaryFiles)
-	fmt.Printf("Verbose: %v\n", fp.Filter.Verbose)
-
-	printStringList("Include Extensions", fp.Filter.IncludeExts)
-	printStringList("Exclude Extensions", fp.Filter.ExcludeExts)
-	printStringList("Exclude Directories", fp.Filter.ExcludeDirs)
-
-	printRegexpList("Match Filenames", fp.Filter.MatchFilenames)
-	printRegexpList("Match Paths", fp.Filter.MatchPaths)
-	printRegexpList("Exclude Match Filenames", fp.Filter.ExcludeMatchFilenames)
-	printRegexpList("Exclude Match Paths", fp.Filter.ExcludeMatchPaths)
+func (fp *FileProcessor) printConfiguredFilters(paths []string) error {
+	PrintFilters(fp.Filter)
 
 	fmt.Println("\nFile Processor Settings:")
 	fmt.Printf("Max Total Size: %d bytes\n", fp.MaxTotalSize)
@@ -483,6 +787,8 @@ func (fp *FileProcessor) printConfiguredFilters() {
 	fmt.Printf("Max Tokens: %d\n", fp.MaxTokens)
 	fmt.Printf("List Only: %v\n", fp.ListOnly)
 	fmt.Printf("Delimiter Type: %s\n", fp.DelimiterType)
+
+	return PrintPathDecisions(os.Stdout, fp.Filter, paths)
 }
 
 func (fp *FileProcessor) currentArchiveSize() int64 {
@@ -502,6 +808,11 @@ func (fp *FileProcessor) bytesWrittenSince(previous int64) int64 {
 }
 
 func (fp *FileProcessor) reportArchiveInclusion(archivePath string, sizeBytes int64, lineCount, tokenCount int, compressedBytes int64) {
+	if fp.OutputFile == "" {
+		// The archive itself is going to the writer.
+		return
+	}
+
 	compressionInfo := ""
 	if compressedBytes > 0 {
 		compressionInfo = fmt.Sprintf(", compressed %d bytes", compressedBytes)
@@ -521,22 +832,6 @@ func (fp *FileProcessor) reportArchiveInclusion(archivePath string, sizeBytes in
 	)
 }
 
-func printStringList(name string, list []string) {
-	if len(list) > 0 {
-		fmt.Printf("%s: %s\n", name, strings.Join(list, ", "))
-	}
-}
-
-func printRegexpList(name string, list []*regexp.Regexp) {
-	if len(list) > 0 {
-		patterns := make([]string, len(list))
-		for i, re := range list {
-			patterns[i] = re.String()
-		}
-		fmt.Printf("%s: %s\n", name, strings.Join(patterns, ", "))
-	}
-}
-
 type multiCloser []io.Closer
 
 func (mc multiCloser) Close() error {
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+// PrintFilters prints the filter configuration, including the defaults that
+// apply unless --disable-default-filters is set.
+func PrintFilters(ff *filefilter.FileFilter) {
+	fmt.Println("Configured Filters:")
+	fmt.Println("-------------------")
+
+	if ff == nil {
+		fmt.Println("No filters configured.")
+		return
+	}
+
+	fmt.Printf("Max File Size: %d bytes\n", ff.MaxFileSize)
+	fmt.Printf("Disable Default Filters: %v\n", ff.DisableDefaultFilters)
+	fmt.Printf("Disable GitIgnore: %v\n", ff.DisableGitIgnore)
+	fmt.Printf("Filter Binary Files: %v\n", ff.FilterBinaryFiles)
+	fmt.Printf("Verbose: %v\n", ff.Verbose)
+
+	printStringList("Include Extensions", ff.IncludeExts)
+	printStringList("Exclude Extensions", ff.ExcludeExts)
+	printStringList("Exclude Directories", ff.ExcludeDirs)
+	printStringList("Match Globs", ff.MatchGlobs)
+	printStringList("Exclude Globs", ff.ExcludeGlobs)
+
+	printRegexpList("Match Filenames", ff.MatchFilenames)
+	printRegexpList("Match Paths", ff.MatchPaths)
+	printRegexpList("Exclude Match Filenames", ff.ExcludeMatchFilenames)
+	printRegexpList("Exclude Match Paths", ff.ExcludeMatchPaths)
+
+	if !ff.DisableDefaultFilters {
+		printStringList("Default Excluded Extensions", ff.DefaultExcludedExts)
+		printStringList("Default Excluded Directories", ff.DefaultExcludedDirs)
+		printRegexpList("Default Excluded Filenames", ff.DefaultExcludedMatchFilenames)
+	}
+
+	ignoreFiles := filefilter.CatterIgnoreFile
+	if !ff.DisableGitIgnore {
+		ignoreFiles = ".gitignore, " + ignoreFiles
+	}
+	fmt.Printf("Ignore Files: %s (in every directory)\n", ignoreFiles)
+}
+
+// PrintPathDecisions walks paths and prints whether each file and directory
+// is included, with the rule that excluded it.
+func PrintPathDecisions(w io.Writer, ff *filefilter.FileFilter, paths []string) error {
+	if ff == nil {
+		return nil
+	}
+	_, _ = fmt.Fprintln(w, "\nPath Decisions:")
+	return ff.ExplainPaths(paths, func(d filefilter.Decision) error {
+		name := d.Path
+		if d.IsDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		mark := "+"
+		if !d.Included {
+			mark = "-"
+		}
+		if d.Reason == "" {
+			_, err := fmt.Fprintf(w, "  %s %s\n", mark, name)
+			return err
+		}
+		_, err := fmt.Fprintf(w, "  %s %s (%s)\n", mark, name, d.Reason)
+		return err
+	})
+}
+
+func printStringList(name string, list []string) {
+	if len(list) > 0 {
+		fmt.Printf("%s: %s\n", name, strings.Join(list, ", "))
+	}
+}
+
+func printRegexpList(name string, list []*regexp.Regexp) {
+	if len(list) > 0 {
+		patterns := make([]string, len(list))
+		for i, re := range list {
+			patterns[i] = re.String()
+		}
+		fmt.Printf("%s: %s\n", name, strings.Join(patterns, ", "))
+	}
+}
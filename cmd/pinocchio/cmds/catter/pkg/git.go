@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitOutput runs git in dir and returns its standard output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// RecentFiles maps the absolute paths of files touched in the last n commits
+// of the repository containing dir to the index of the newest commit that
+// touched them, 0 being HEAD.
+func RecentFiles(dir string, n int) (map[string]int, error) {
+	root, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	out, err := gitOutput(root, "log", "-n", fmt.Sprint(n), "--format=%H")
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]int{}
+	for commit, hash := range strings.Fields(out) {
+		names, err := gitOutput(root, "diff-tree", "--root", "--no-commit-id", "-r", "--name-only", "-z", hash)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range splitNUL(names) {
+			abs := filepath.Join(root, filepath.FromSlash(name))
+			if _, ok := ret[abs]; !ok {
+				ret[abs] = commit
+			}
+		}
+	}
+	return ret, nil
+}
+
+// splitNUL splits the output of a git command run with -z into paths.
+// Unlike the newline-separated output, the paths are not quoted.
+func splitNUL(out string) []string {
+	var ret []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
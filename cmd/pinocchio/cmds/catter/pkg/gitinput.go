@@ -0,0 +1,295 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+// GitOptions select the files to print from git instead of walking the paths
+// on disk. The selections add up; paths then restrict them.
+type GitOptions struct {
+	// Diff selects the files changed between this revision and At, or the
+	// worktree if At is empty.
+	Diff string
+	// WithDiff adds the unified diff of each file selected by Diff or Staged
+	// as "<path>.diff", right after the file.
+	WithDiff bool
+	// Staged selects the files with staged changes.
+	Staged bool
+	// Log selects the files touched in the last Log commits.
+	Log int
+	// At reads contents from this revision instead of the worktree. On its
+	// own, it selects every file in the revision.
+	At string
+}
+
+func (o GitOptions) Enabled() bool {
+	return o.Diff != "" || o.Staged || o.Log > 0 || o.At != ""
+}
+
+// SourceFile is a file selected for output. Content is nil for files read
+// from disk.
+type SourceFile struct {
+	Path    string
+	Info    os.FileInfo
+	Content []byte
+}
+
+func (f SourceFile) Read() ([]byte, error) {
+	if f.Content != nil {
+		return f.Content, nil
+	}
+	return os.ReadFile(f.Path)
+}
+
+// gitFileInfo describes a file that only exists in the git object store.
+type gitFileInfo struct {
+	name string
+	size int64
+}
+
+func (i gitFileInfo) Name() string       { return i.name }
+func (i gitFileInfo) Size() int64        { return i.size }
+func (i gitFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i gitFileInfo) ModTime() time.Time { return time.Now() }
+func (i gitFileInfo) IsDir() bool        { return false }
+func (i gitFileInfo) Sys() any           { return nil }
+
+type gitSelection struct {
+	diff, staged, log bool
+}
+
+// GitFiles returns the files selected by opts below paths, sorted by path,
+// with the filter applied. Files read from a revision or the index carry
+// their content.
+func GitFiles(paths []string, opts GitOptions, ff *filefilter.FileFilter) ([]SourceFile, error) {
+	// Revisions are passed to git as arguments, where a leading dash would
+	// be read as an option.
+	for _, rev := range []string{opts.Diff, opts.At} {
+		if strings.HasPrefix(rev, "-") {
+			return nil, fmt.Errorf("invalid git revision %q", rev)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	root, err := gitOutput(wd, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = strings.TrimSpace(root)
+
+	selected := map[string]*gitSelection{}
+	addNames := func(names []string, mark func(*gitSelection)) {
+		for _, name := range names {
+			if selected[name] == nil {
+				selected[name] = &gitSelection{}
+			}
+			mark(selected[name])
+		}
+	}
+
+	if opts.Diff != "" {
+		args := []string{"diff", "--name-only", "-z", "--diff-filter=d", opts.Diff}
+		if opts.At != "" {
+			args = append(args, opts.At)
+		}
+		out, err := gitOutput(root, args...)
+		if err != nil {
+			return nil, err
+		}
+		addNames(splitNUL(out), func(s *gitSelection) { s.diff = true })
+	}
+	if opts.Staged {
+		out, err := gitOutput(root, "diff", "--cached", "--name-only", "-z", "--diff-filter=d")
+		if err != nil {
+			return nil, err
+		}
+		addNames(splitNUL(out), func(s *gitSelection) { s.staged = true })
+	}
+	if opts.Log > 0 {
+		recent, err := RecentFiles(root, opts.Log)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for abs := range recent {
+			if rel, err := filepath.Rel(root, abs); err == nil {
+				names = append(names, filepath.ToSlash(rel))
+			}
+		}
+		addNames(names, func(s *gitSelection) { s.log = true })
+	}
+	if opts.At != "" && opts.Diff == "" && !opts.Staged && opts.Log <= 0 {
+		out, err := gitOutput(root, "ls-tree", "-r", "--name-only", "-z", "--full-tree", opts.At)
+		if err != nil {
+			return nil, err
+		}
+		addNames(splitNUL(out), func(*gitSelection) {})
+	}
+
+	var scopes []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, abs)
+	}
+
+	var names []string
+	for name := range selected {
+		// git cat-file --batch reads one object name per line.
+		if strings.Contains(name, "\n") {
+			continue
+		}
+		if inScopes(filepath.Join(root, filepath.FromSlash(name)), scopes) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	// Contents come from At, from the index for files that are only staged,
+	// and from the worktree otherwise.
+	objects := map[string]string{}
+	var specs []string
+	for _, name := range names {
+		s := selected[name]
+		switch {
+		case opts.At != "":
+			objects[name] = opts.At + ":" + name
+		case s.staged && !s.diff && !s.log:
+			objects[name] = ":" + name
+		default:
+			continue
+		}
+		specs = append(specs, objects[name])
+	}
+	contents, err := catFiles(root, specs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []SourceFile
+	for _, name := range names {
+		abs := filepath.Join(root, filepath.FromSlash(name))
+		path := abs
+		if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+
+		var f SourceFile
+		if spec, ok := objects[name]; ok {
+			content, ok := contents[spec]
+			if !ok {
+				continue
+			}
+			if ff != nil && !ff.ExplainContent(path, content).Included {
+				continue
+			}
+			f = SourceFile{Path: path, Content: content, Info: gitFileInfo{name: filepath.Base(path), size: int64(len(content))}}
+		} else {
+			info, err := os.Stat(abs)
+			if err != nil || info.IsDir() {
+				// Files touched in the log may have been deleted since.
+				continue
+			}
+			if ff != nil && !ff.FilterPath(path) {
+				continue
+			}
+			f = SourceFile{Path: path, Info: info}
+		}
+		ret = append(ret, f)
+
+		s := selected[name]
+		if !opts.WithDiff || (!s.diff && !s.staged) {
+			continue
+		}
+		args := []string{"diff", "--cached"}
+		if s.diff {
+			args = []string{"diff", opts.Diff}
+			if opts.At != "" {
+				args = append(args, opts.At)
+			}
+		}
+		diff, err := gitOutput(root, append(args, "--", name)...)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, SourceFile{
+			Path:    path + ".diff",
+			Content: []byte(diff),
+			Info:    gitFileInfo{name: filepath.Base(path) + ".diff", size: int64(len(diff))},
+		})
+	}
+	return ret, nil
+}
+
+func inScopes(abs string, scopes []string) bool {
+	for _, scope := range scopes {
+		if abs == scope || strings.HasPrefix(abs, scope+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// catFiles reads the objects named by specs, such as "HEAD:main.go", from
+// the repository at root. Missing objects are left out of the result.
+func catFiles(root string, specs []string) (map[string][]byte, error) {
+	ret := map[string][]byte{}
+	if len(specs) == 0 {
+		return ret, nil
+	}
+
+	cmd := exec.Command("git", "-C", root, "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(strings.Join(specs, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	r := bufio.NewReader(bytes.NewReader(out))
+	for _, spec := range specs {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("reading git cat-file output for %s: %w", spec, err)
+		}
+		header = strings.TrimSuffix(header, "\n")
+		if strings.HasSuffix(header, " missing") || strings.HasSuffix(header, " ambiguous") {
+			continue
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected git cat-file header %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git cat-file header %q", header)
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, fmt.Errorf("reading %s from git: %w", spec, err)
+		}
+		if fields[1] == "blob" {
+			ret[spec] = content[:size]
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", "-A")
+	git(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := gitOutput(dir, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sourcePaths(files []SourceFile) string {
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestGitFilesDiffStagedAndAt(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		"main.go":     "package main\n",
+		"pkg/a.go":    "package pkg\n",
+		"pkg/b.go":    "package pkg\n",
+		"docs/x.md":   "# x\n",
+		"image.png":   "\x00png",
+		"pkg/old.txt": "old\n",
+	})
+	t.Chdir(dir)
+	ff := filefilter.NewFileFilter()
+
+	writeFiles(t, dir, map[string]string{"pkg/a.go": "package pkg\n\nfunc A() {}\n", "docs/x.md": "# y\n"})
+	git(t, dir, "rm", "-q", "pkg/old.txt")
+
+	files, err := GitFiles([]string{"."}, GitOptions{Diff: "HEAD", WithDiff: true}, ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sourcePaths(files); got != "docs/x.md,docs/x.md.diff,pkg/a.go,pkg/a.go.diff" {
+		t.Fatalf("diff files = %s", got)
+	}
+	if files[0].Content != nil || !strings.Contains(string(files[3].Content), "+func A() {}") {
+		t.Fatalf("unexpected contents: %q, %q", files[0].Content, files[3].Content)
+	}
+
+	files, err = GitFiles([]string{"pkg"}, GitOptions{Diff: "HEAD"}, ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sourcePaths(files); got != "pkg/a.go" {
+		t.Fatalf("diff files below pkg = %s", got)
+	}
+
+	git(t, dir, "add", "pkg/a.go")
+	writeFiles(t, dir, map[string]string{"pkg/a.go": "package pkg\n\nfunc A() { unstaged() }\n"})
+	files, err = GitFiles([]string{"."}, GitOptions{Staged: true}, ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sourcePaths(files); got != "pkg/a.go" || string(files[0].Content) != "package pkg\n\nfunc A() {}\n" {
+		t.Fatalf("staged files = %s, content %q", got, files[0].Content)
+	}
+
+	files, err = GitFiles([]string{"."}, GitOptions{At: "HEAD"}, ff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// image.png is binary.
+	if got := sourcePaths(files); got != "docs/x.md,main.go,pkg/a.go,pkg/b.go,pkg/old.txt" {
+		t.Fatalf("files at HEAD = %s", got)
+	}
+	if string(files[0].Content) != "# x\n" || files[0].Info.Size() != 4 {
+		t.Fatalf("docs/x.md at HEAD = %q", files[0].Content)
+	}
+}
+
+func TestGitFilesUnusualNamesAndRevisions(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		"café.go":        "package main\n",
+		"with space.txt": "a\n",
+	})
+	t.Chdir(dir)
+	ff := filefilter.NewFileFilter()
+
+	writeFiles(t, dir, map[string]string{"café.go": "package main\n\nfunc main() {}\n"})
+	git(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-am", "edit")
+
+	for _, opts := range []GitOptions{{At: "HEAD"}, {Diff: "HEAD~1"}, {Log: 1}} {
+		files, err := GitFiles([]string{"."}, opts, ff)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "café.go"
+		if opts.At != "" {
+			want = "café.go,with space.txt"
+		}
+		if got := sourcePaths(files); got != want {
+			t.Fatalf("%+v: files = %s", opts, got)
+		}
+	}
+
+	for _, opts := range []GitOptions{{Diff: "--output=/tmp/x"}, {At: "-p"}} {
+		if _, err := GitFiles([]string{"."}, opts, ff); err == nil || !strings.Contains(err.Error(), "invalid git revision") {
+			t.Fatalf("%+v: err = %v", opts, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// outlineFallbackLines is how many leading lines an outline keeps for files
+// no outliner handles.
+const outlineFallbackLines = 20
+
+// Outliner turns a file into its skeleton: the declarations a model needs to
+// use the code, without the implementation.
+type Outliner interface {
+	Outline(path string, content []byte) (string, error)
+}
+
+// OutlinerFunc adapts a function to Outliner.
+type OutlinerFunc func(path string, content []byte) (string, error)
+
+func (f OutlinerFunc) Outline(path string, content []byte) (string, error) {
+	return f(path, content)
+}
+
+// RegexOutliner keeps the lines matching Keep, except those matching Skip.
+// It returns an empty outline if no line matches.
+type RegexOutliner struct {
+	Keep *regexp.Regexp
+	Skip *regexp.Regexp
+}
+
+func (r *RegexOutliner) Outline(_ string, content []byte) (string, error) {
+	var b strings.Builder
+	for _, line := range strings.Split(string(content), "\n") {
+		if !r.Keep.MatchString(line) || (r.Skip != nil && r.Skip.MatchString(line)) {
+			continue
+		}
+		b.WriteString(strings.TrimRight(line, " \t{"))
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+var (
+	outlinersMu sync.RWMutex
+	outliners   = map[string]Outliner{}
+)
+
+// RegisterOutliner makes o the outliner for files with the given extensions,
+// such as ".go". It replaces earlier registrations.
+func RegisterOutliner(o Outliner, exts ...string) {
+	outlinersMu.Lock()
+	defer outlinersMu.Unlock()
+	for _, ext := range exts {
+		outliners[strings.ToLower(ext)] = o
+	}
+}
+
+// defaultOutliner handles extensions no outliner is registered for. It keeps
+// lines that look like declarations in most C-like and scripting languages.
+var defaultOutliner Outliner = &RegexOutliner{
+	Keep: regexp.MustCompile(`^\s*(export\s+)?(pub\s+)?(async\s+)?(func|function|def|class|interface|struct|enum|trait|type|module|impl)\s`),
+}
+
+func outlinerFor(path string) Outliner {
+	outlinersMu.RLock()
+	defer outlinersMu.RUnlock()
+	if o, ok := outliners[strings.ToLower(filepath.Ext(path))]; ok {
+		return o
+	}
+	return defaultOutliner
+}
+
+// Outline returns the outline of a file with the outliner registered for
+// its extension, or defaultOutliner. Files whose outliner fails or finds
+// nothing keep their first lines.
+func Outline(path string, content []byte) string {
+	if o := outlinerFor(path); o != nil {
+		if outline, err := o.Outline(path, content); err == nil && strings.TrimSpace(outline) != "" {
+			return outline
+		}
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) <= outlineFallbackLines {
+		return string(content)
+	}
+	return strings.Join(lines[:outlineFallbackLines], "\n") +
+		fmt.Sprintf("\n… %d more lines\n", len(lines)-outlineFallbackLines)
+}
+
+func init() {
+	RegisterOutliner(OutlinerFunc(outlineGo), ".go")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(@\w|(async\s+)?def\s|class\s)`),
+	}, ".py", ".pyi")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(declare\s+)?(abstract\s+)?((async\s+)?function\*?\s|class\s|interface\s|type\s+\w+|enum\s|namespace\s|(const|let)\s+\w+\s*(:[^=]+)?=\s*(async\s*)?(\([^)]*\)|\w+)\s*(:[^=]+)?=>)|^\s+(public\s+|private\s+|protected\s+|static\s+|readonly\s+|async\s+|get\s+|set\s+)*[A-Za-z_$][\w$]*\s*(<[^>]*>)?\([^)]*\)\s*(:\s*[^{;]+)?\{\s*$`),
+		Skip: regexp.MustCompile(`^\s*(if|for|while|switch|catch|return|else|do|with)\b`),
+	}, ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^#{1,6}\s`),
+	}, ".md", ".markdown")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(pub(\(\w+\))?\s+)?(async\s+)?(fn|struct|enum|trait|impl|mod|type)\s`),
+	}, ".rs")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(def|class|module)\s`),
+	}, ".rb")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(public|protected|private|static|abstract|final|\s)*(class|interface|enum|record|[\w<>\[\],\s]+\s+\w+\s*\([^;]*\)\s*(throws [\w.,\s]+)?\{?\s*$)`),
+		Skip: regexp.MustCompile(`^\s*(if|for|while|switch|catch|return|else|do|synchronized)\b`),
+	}, ".java", ".kt", ".cs")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(abstract\s+|final\s+)?(public\s+|protected\s+|private\s+)?(static\s+)?(function|class|interface|trait)\s`),
+	}, ".php")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(function\s+\w+|\w+\s*\(\)\s*\{?)`),
+	}, ".sh", ".bash")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`(?i)^\s*create\s+`),
+	}, ".sql")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^\s*(message|service|enum|rpc)\s`),
+	}, ".proto")
+	RegisterOutliner(&RegexOutliner{
+		Keep: regexp.MustCompile(`^[\w.-]+:`),
+	}, ".yaml", ".yml")
+}
+
+// outlineGo keeps the package clause, imports, type declarations, and
+// function signatures with their doc comments. Constants and variables are
+// reduced to their names.
+func outlineGo(_ string, content []byte) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	writeComments(&b, file.Doc)
+	fmt.Fprintf(&b, "package %s\n", file.Name.Name)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			d.Body = nil
+			b.WriteString("\n")
+			writeNode(&b, fset, d)
+			b.WriteString("\n")
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.IMPORT, token.TYPE:
+				b.WriteString("\n")
+				writeNode(&b, fset, d)
+				b.WriteString("\n")
+			case token.CONST, token.VAR:
+				var names []string
+				for _, spec := range d.Specs {
+					for _, name := range spec.(*ast.ValueSpec).Names {
+						if name.Name != "_" {
+							names = append(names, name.Name)
+						}
+					}
+				}
+				if len(names) > 0 {
+					b.WriteString("\n")
+					writeComments(&b, d.Doc)
+					fmt.Fprintf(&b, "%s %s\n", d.Tok, strings.Join(names, ", "))
+				}
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func writeComments(b *strings.Builder, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	for _, c := range doc.List {
+		b.WriteString(c.Text)
+		b.WriteString("\n")
+	}
+}
+
+// writeNode prints node with the doc comments attached to it and its fields.
+func writeNode(b *strings.Builder, fset *token.FileSet, node ast.Node) {
+	var buf bytes.Buffer
+	if err := (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(&buf, fset, node); err != nil {
+		return
+	}
+	b.Write(buf.Bytes())
+}
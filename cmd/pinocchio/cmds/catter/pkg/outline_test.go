@@ -0,0 +1,104 @@
+package pkg
+
+import "testing"
+
+func TestOutline(t *testing.T) {
+	goSrc := `package demo
+
+import "fmt"
+
+// Greeter greets people.
+// It is friendly.
+type Greeter struct {
+	// Name is who to greet.
+	Name string
+}
+
+const A, B = 1, 2
+
+// Greet prints a greeting.
+func (g *Greeter) Greet(times int) error {
+	fmt.Println("hi", g.Name)
+	return nil
+}
+`
+	want := `package demo
+
+import "fmt"
+
+// Greeter greets people.
+// It is friendly.
+type Greeter struct {
+	// Name is who to greet.
+	Name string
+}
+
+const A, B
+
+// Greet prints a greeting.
+func (g *Greeter) Greet(times int) error
+`
+	if got := Outline("demo.go", []byte(goSrc)); got != want {
+		t.Fatalf("Go outline:\n%s\nwant:\n%s", got, want)
+	}
+
+	py := "import os\n\nclass A:\n    @property\n    def run(self):\n        return 1\n\ndef main():\n    pass\n"
+	if got := Outline("a.py", []byte(py)); got != "class A:\n    @property\n    def run(self):\ndef main():\n" {
+		t.Fatalf("Python outline = %q", got)
+	}
+
+	ts := `export interface User {
+  name: string;
+}
+
+export class Store {
+  private cache = new Map();
+
+  async load(id: string): Promise<User> {
+    if (this.cache.has(id)) {
+      return this.cache.get(id);
+    }
+    for (const x of []) {
+    }
+  }
+}
+
+export const toUser = (raw: any): User => ({ name: raw.name });
+function helper(a: number) {
+}
+`
+	wantTS := "export interface User\nexport class Store\n  async load(id: string): Promise<User>\nexport const toUser = (raw: any): User => ({ name: raw.name });\nfunction helper(a: number)\n"
+	if got := Outline("store.ts", []byte(ts)); got != wantTS {
+		t.Fatalf("TypeScript outline = %q", got)
+	}
+
+	md := "# Title\ntext\n## Section\nmore\n"
+	if got := Outline("README.md", []byte(md)); got != "# Title\n## Section\n" {
+		t.Fatalf("markdown outline = %q", got)
+	}
+
+	others := []struct{ path, src, want string }{
+		{"lib.rs", "pub struct A {\n    x: i32,\n}\nimpl A {\n    pub fn new() -> Self {\n        A { x: 1 }\n    }\n}\n", "pub struct A\nimpl A\n    pub fn new() -> Self\n"},
+		{"app.rb", "module M\n  class C\n    def run\n      1\n    end\n  end\nend\n", "module M\n  class C\n    def run\n"},
+		{"Foo.java", "public class Foo {\n    private int x;\n    public int get(int a) {\n        if (a > 0) {\n            return x;\n        }\n        return a;\n    }\n}\n", "public class Foo\n    public int get(int a)\n"},
+		{"a.php", "<?php\nclass A {\n  public function run() {\n  }\n}\n", "class A\n  public function run()\n"},
+		{"deploy.sh", "set -e\nfunction deploy {\n  echo\n}\nbuild() {\n  make\n}\n", "function deploy\nbuild()\n"},
+		{"schema.sql", "CREATE TABLE users (\n  id int\n);\nSELECT 1;\n", "CREATE TABLE users (\n"},
+		{"api.proto", "syntax = \"proto3\";\nmessage User {\n  string name = 1;\n}\nservice Users {\n  rpc Get(Req) returns (User);\n}\n", "message User\nservice Users\n  rpc Get(Req) returns (User);\n"},
+		{"values.yml", "name: x\nspec:\n  replicas: 1\n", "name: x\nspec:\n"},
+		{"main.swift", "import Foundation\nclass A {\n  func run() {\n  }\n}\n", "class A\n  func run()\n"},
+	}
+	for _, tc := range others {
+		if got := Outline(tc.path, []byte(tc.src)); got != tc.want {
+			t.Fatalf("%s outline = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+
+	RegisterOutliner(OutlinerFunc(func(string, []byte) (string, error) { return "custom\n", nil }), ".custom")
+	if got := Outline("x.custom", []byte("anything")); got != "custom\n" {
+		t.Fatalf("registered outliner not used: %q", got)
+	}
+	if got := Outline("notes.txt", []byte("short file\n")); got != "short file\n" {
+		t.Fatalf("fallback outline = %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redaction is a secret replaced by a SecretRedactor.
+type Redaction struct {
+	Path string
+	Line int
+	Kind string
+}
+
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+	// group is the submatch holding the secret, 0 for the whole match.
+	group int
+}
+
+var secretPatterns = []secretPattern{
+	{kind: "private key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{kind: "aws access key", re: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github token", re: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{kind: "slack token", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: "google api key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{kind: "stripe key", re: regexp.MustCompile(`\b[rs]k_(live|test)_[0-9A-Za-z]{24,}\b`)},
+	{kind: "api key", re: regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{kind: "secret assignment", re: regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d)\w*["']?\s*[:=]\s*["']([^"'\s]{8,})["']`), group: 1},
+}
+
+// highEntropyToken matches the candidates for the entropy check.
+var highEntropyToken = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// minSecretEntropy is the share of the highest possible Shannon entropy for
+// its length above which a token is taken for a secret.
+const minSecretEntropy = 0.8
+
+// SecretRedactor replaces API keys, private keys and high-entropy strings
+// with "[REDACTED <kind>]" and records what it replaced in each file.
+type SecretRedactor struct {
+	patterns []secretPattern
+
+	mu         sync.Mutex
+	redactions map[string][]Redaction
+}
+
+// NewSecretRedactor returns a redactor for the built-in secret patterns and
+// the extra regular expressions, whose first group, or whole match, is the
+// secret.
+func NewSecretRedactor(extra []string) (*SecretRedactor, error) {
+	r := &SecretRedactor{patterns: append([]secretPattern{}, secretPatterns...), redactions: map[string][]Redaction{}}
+	for _, e := range extra {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern: %w", err)
+		}
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		r.patterns = append(r.patterns, secretPattern{kind: "custom pattern", re: re, group: group})
+	}
+	return r, nil
+}
+
+func (r *SecretRedactor) Transform(path string, content []byte) ([]byte, error) {
+	s := string(content)
+
+	type secret struct {
+		start, end int
+		kind       string
+	}
+	var secrets []secret
+	for _, p := range r.patterns {
+		for _, m := range p.re.FindAllStringSubmatchIndex(s, -1) {
+			if start, end := m[2*p.group], m[2*p.group+1]; start >= 0 {
+				secrets = append(secrets, secret{start, end, p.kind})
+			}
+		}
+	}
+	for _, m := range highEntropyToken.FindAllStringIndex(s, -1) {
+		if looksLikeSecret(s[m[0]:m[1]]) {
+			secrets = append(secrets, secret{m[0], m[1], "high-entropy string"})
+		}
+	}
+	if len(secrets) == 0 {
+		r.mu.Lock()
+		delete(r.redactions, path)
+		r.mu.Unlock()
+		return content, nil
+	}
+	sort.SliceStable(secrets, func(i, j int) bool { return secrets[i].start < secrets[j].start })
+
+	var b strings.Builder
+	var found []Redaction
+	last := 0
+	for _, sec := range secrets {
+		if sec.start < last {
+			continue
+		}
+		b.WriteString(s[last:sec.start])
+		b.WriteString("[REDACTED " + sec.kind + "]")
+		found = append(found, Redaction{Path: path, Line: strings.Count(s[:sec.start], "\n") + 1, Kind: sec.kind})
+		last = sec.end
+	}
+	b.WriteString(s[last:])
+
+	r.mu.Lock()
+	r.redactions[path] = found
+	r.mu.Unlock()
+	return []byte(b.String()), nil
+}
+
+// Redactions returns what the redactor replaced in the files it transformed,
+// as of their latest content, sorted by path and line.
+func (r *SecretRedactor) Redactions() []Redaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ret []Redaction
+	for _, found := range r.redactions {
+		ret = append(ret, found...)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Path != ret[j].Path {
+			return ret[i].Path < ret[j].Path
+		}
+		return ret[i].Line < ret[j].Line
+	})
+	return ret
+}
+
+// looksLikeSecret reports whether token mixes cases and digits and is
+// random enough to be a key rather than an identifier or a word. Identifiers
+// rarely have more than one digit per twelve characters.
+func looksLikeSecret(token string) bool {
+	var upper, lower bool
+	digits := 0
+	for _, c := range token {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digits++
+		}
+	}
+	return upper && lower && digits*12 >= len(token) &&
+		shannonEntropy(token) >= minSecretEntropy*math.Log2(math.Min(float64(len(token)), 64))
+}
+
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, c := range s {
+		counts[c]++
+	}
+	var entropy float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
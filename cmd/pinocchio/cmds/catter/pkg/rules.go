@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+// Rule actions of the serve API.
+const (
+	RuleInclude = "include"
+	RuleExclude = "exclude"
+	RuleReset   = "reset"
+)
+
+// ApplyRule toggles rel, a slash-separated path relative to the filter root,
+// by editing the anchored globs of ff. Including a path adds it to the match
+// globs, so the first include narrows the filter to the included paths.
+// Excluding a path adds it to the exclude globs. Reset drops the rules of rel.
+func ApplyRule(ff *filefilter.FileFilter, rel string, action string) error {
+	rel = path.Clean(strings.TrimPrefix(rel, "/"))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("invalid rule path %q", rel)
+	}
+	glob := "/" + escapeGlob(rel)
+
+	ff.MatchGlobs = removeGlob(ff.MatchGlobs, glob)
+	ff.ExcludeGlobs = removeGlob(ff.ExcludeGlobs, glob)
+	switch action {
+	case RuleInclude:
+		ff.MatchGlobs = append(ff.MatchGlobs, glob)
+		// Re-include rel if a parent directory is excluded.
+		if len(ff.ExcludeGlobs) > 0 {
+			ff.ExcludeGlobs = append(ff.ExcludeGlobs, "!"+glob)
+		}
+	case RuleExclude:
+		ff.ExcludeGlobs = append(ff.ExcludeGlobs, glob)
+	case RuleReset:
+	default:
+		return fmt.Errorf("unknown rule action %q (expected %s, %s or %s)", action, RuleInclude, RuleExclude, RuleReset)
+	}
+	return nil
+}
+
+// CloneFilter returns a copy of ff with its own compiled patterns, rooted at
+// root. Profiles are not copied.
+func CloneFilter(ff *filefilter.FileFilter, root string) (*filefilter.FileFilter, error) {
+	data, err := ff.ToYAML()
+	if err != nil {
+		return nil, err
+	}
+	clone, err := filefilter.FromYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	clone.Profiles = nil
+	clone.Verbose = false
+	clone.Root = root
+	return clone, nil
+}
+
+func removeGlob(globs []string, glob string) []string {
+	var ret []string
+	for _, g := range globs {
+		if g != glob && g != "!"+glob {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// escapeGlob quotes the glob metacharacters of a literal path.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+func TestApplyRule(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":         "package main\n",
+		"pkg/a.go":        "package pkg\n",
+		"pkg/b.go":        "package pkg\n",
+		"pkg/a_test.go":   "package pkg\n",
+		"pkg/gen/x.go":    "package gen\n",
+		"docs/[draft].md": "# draft\n",
+	})
+
+	base := filefilter.NewFileFilter(filefilter.WithExcludeMatchPaths([]string{`_test\.go$`}))
+	ff, err := CloneFilter(base, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ff.ExcludeMatchPaths) != 1 || ff.Root != dir {
+		t.Fatalf("clone lost settings: %+v", ff)
+	}
+
+	included := func(ff *filefilter.FileFilter) string {
+		var paths []string
+		err := ff.ExplainPaths([]string{dir}, func(d filefilter.Decision) error {
+			if !d.IsDir && d.Included {
+				rel, _ := filepath.Rel(dir, d.Path)
+				paths = append(paths, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(paths, ",")
+	}
+
+	apply := func(rel, action string) {
+		t.Helper()
+		if err := ApplyRule(ff, rel, action); err != nil {
+			t.Fatal(err)
+		}
+		// Rebuild the filter so the changed globs are compiled.
+		if ff, err = CloneFilter(ff, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	apply("pkg/gen", RuleExclude)
+	if got := included(ff); got != "docs/[draft].md,main.go,pkg/a.go,pkg/b.go" {
+		t.Fatalf("after excluding pkg/gen: %s", got)
+	}
+
+	apply("pkg", RuleInclude)
+	if got := included(ff); got != "pkg/a.go,pkg/b.go" {
+		t.Fatalf("after including pkg: %s", got)
+	}
+
+	apply("pkg/gen/x.go", RuleInclude)
+	if got := included(ff); got != "pkg/a.go,pkg/b.go,pkg/gen/x.go" {
+		t.Fatalf("after including pkg/gen/x.go: %s", got)
+	}
+
+	apply("docs/[draft].md", RuleInclude)
+	apply("pkg", RuleReset)
+	if got := included(ff); got != "docs/[draft].md,pkg/gen/x.go" {
+		t.Fatalf("after resetting pkg: %s", got)
+	}
+
+	for _, rel := range []string{"", ".", "../x", "/../x"} {
+		if err := ApplyRule(ff, rel, RuleInclude); err == nil {
+			t.Fatalf("expected an error for %q", rel)
+		}
+	}
+	if err := ApplyRule(ff, "main.go", "toggle"); err == nil {
+		t.Fatal("expected an error for an unknown action")
+	}
+}
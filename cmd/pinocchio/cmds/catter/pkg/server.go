@@ -0,0 +1,498 @@
+package pkg
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+	"github.com/weaviate/tiktoken-go"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed web/index.html
+var indexHTML []byte
+
+// Server serves the catter API and web UI used to pick the files of a bundle
+// below a root directory. The filter it edits lives in memory until it is
+// saved as a profile of FilterFile.
+type Server struct {
+	// Path is the root as given on the command line. Bundles are built from
+	// it, so their file names are relative to the working directory.
+	Path string
+	// FilterFile is the YAML file profiles are saved to and loaded from.
+	FilterFile string
+	// Transforms run over each file before it is counted and bundled.
+	Transforms *Pipeline
+	// Cache, if set, keeps the stats of unchanged files between tree
+	// requests. Its codec must match Transforms, see StatsCodec.
+	Cache *StatsCache
+	// ProfileTransforms, if set, builds the transforms of a profile of
+	// FilterFile. Loading a profile then replaces Transforms and Cache, so
+	// its transforms apply to the tree and bundles; without it loading a
+	// profile only replaces the filter.
+	ProfileTransforms func(profile string) (*Pipeline, error)
+	// Address, if set, is the host:port the server listens on. Requests for
+	// another host are refused, so a page that rebinds its own name to this
+	// address cannot reach the API.
+	Address string
+
+	root         string
+	tokenCounter *tiktoken.Tiktoken
+
+	// mu guards filter, and Transforms and Cache once the handler runs.
+	mu     sync.Mutex
+	filter *filefilter.FileFilter
+}
+
+// TreeNode is a file or directory below the server root. Directory stats are
+// the sums of the included files below them.
+type TreeNode struct {
+	Path     string `json:"path"`
+	Dir      bool   `json:"dir"`
+	Included bool   `json:"included"`
+	Reason   string `json:"reason,omitempty"`
+
+	Files         int   `json:"files"`
+	Tokens        int   `json:"tokens"`
+	OutlineTokens int   `json:"outlineTokens"`
+	Lines         int   `json:"lines"`
+	Size          int64 `json:"size"`
+}
+
+// TreeResponse is the body of GET /api/tree. Nodes are sorted by path; the
+// root itself is ".".
+type TreeResponse struct {
+	Root  string     `json:"root"`
+	Nodes []TreeNode `json:"nodes"`
+}
+
+type ruleRequest struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+func NewServer(path string, ff *filefilter.FileFilter, filterFile string) (*Server, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	tokenCounter, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return nil, fmt.Errorf("error initializing tiktoken: %v", err)
+	}
+	if ff == nil {
+		ff = filefilter.NewFileFilter()
+	}
+
+	s := &Server{Path: path, FilterFile: filterFile, root: root, tokenCounter: tokenCounter}
+	if err := s.setFilter(ff); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Handler returns the routes of the API and the web UI. The PUT and POST
+// routes only accept JSON from the server's own origin:
+//
+//	GET  /api/tree                  files and directories with their stats
+//	GET  /api/filter                the current filter
+//	PUT  /api/filter                replace the filter
+//	POST /api/filter/rules          include, exclude or reset a path
+//	GET  /api/profiles              profiles saved in the filter file
+//	POST /api/profiles/{name}       save the filter as a profile
+//	POST /api/profiles/{name}/load  load a profile
+//	GET  /api/bundle                the bundle as text, zip or tar.gz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tree", s.handleTree)
+	mux.HandleFunc("GET /api/filter", s.handleGetFilter)
+	mux.HandleFunc("PUT /api/filter", sameOriginJSON(s.handlePutFilter))
+	mux.HandleFunc("POST /api/filter/rules", sameOriginJSON(s.handleRule))
+	mux.HandleFunc("GET /api/profiles", s.handleProfiles)
+	mux.HandleFunc("POST /api/profiles/{name}", sameOriginJSON(s.handleSaveProfile))
+	mux.HandleFunc("POST /api/profiles/{name}/load", sameOriginJSON(s.handleLoadProfile))
+	mux.HandleFunc("GET /api/bundle", s.handleBundle)
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(indexHTML)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			http.Error(w, fmt.Sprintf("unexpected host %q", r.Host), http.StatusMisdirectedRequest)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether a request's Host header names the address the
+// server listens on. Any loopback name matches a loopback address, and any
+// host matches when the server listens on all interfaces.
+func (s *Server) allowedHost(host string) bool {
+	if s.Address == "" {
+		return true
+	}
+	bindHost, bindPort, err := net.SplitHostPort(s.Address)
+	if err != nil {
+		return strings.EqualFold(host, s.Address)
+	}
+	if ip := net.ParseIP(bindHost); bindHost == "" || (ip != nil && ip.IsUnspecified()) {
+		return true
+	}
+	reqHost, reqPort, err := net.SplitHostPort(host)
+	if err != nil {
+		reqHost, reqPort = host, "80"
+	}
+	if reqPort != bindPort {
+		return false
+	}
+	if isLoopbackHost(bindHost) {
+		return isLoopbackHost(reqHost)
+	}
+	return strings.EqualFold(reqHost, bindHost)
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sameOriginJSON guards a route that changes the filter or the filter file.
+// Browsers only send a JSON body to another origin after a CORS preflight,
+// which the server never answers, so forms and pages elsewhere cannot use it.
+func sameOriginJSON(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			http.Error(w, "expected Content-Type application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || !strings.EqualFold(u.Host, r.Host) {
+				http.Error(w, fmt.Sprintf("cross-origin request from %q refused", origin), http.StatusForbidden)
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+func (s *Server) currentFilter() *filefilter.FileFilter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filter
+}
+
+// currentTransforms returns the transforms and the stats cache that match
+// them.
+func (s *Server) currentTransforms() (*Pipeline, *StatsCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Transforms, s.Cache
+}
+
+// setFilter replaces the filter with a copy of ff rooted at the server root.
+// Filters are never modified once set, so requests can use them unlocked.
+func (s *Server) setFilter(ff *filefilter.FileFilter) error {
+	clone, err := CloneFilter(ff, s.root)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = clone
+	return nil
+}
+
+func (s *Server) handleTree(w http.ResponseWriter, _ *http.Request) {
+	transforms, cache := s.currentTransforms()
+	nodes := map[string]*TreeNode{}
+	err := s.currentFilter().ExplainPaths([]string{s.root}, func(d filefilter.Decision) error {
+		rel, err := filepath.Rel(s.root, d.Path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		node := &TreeNode{Path: rel, Dir: d.IsDir, Included: d.Included, Reason: d.Reason}
+		nodes[rel] = node
+		if d.IsDir || !d.Included {
+			return nil
+		}
+
+		stats, err := cache.Get(SourceFile{Path: d.Path}, func(content []byte) (FileStats, error) {
+			content, err := transforms.Apply(d.Path, content)
+			if err != nil {
+				return FileStats{}, err
+			}
+			return computeFileStats(s.tokenCounter, d.Path, content), nil
+		})
+		if err != nil {
+			node.Included = false
+			node.Reason = err.Error()
+			return nil
+		}
+		for p := rel; ; p = filepath.ToSlash(filepath.Dir(p)) {
+			if n := nodes[p]; n != nil {
+				n.Files += stats.FileCount
+				n.Tokens += stats.TokenCount
+				n.OutlineTokens += stats.OutlineTokenCount
+				n.Lines += stats.LineCount
+				n.Size += stats.Size
+			}
+			if p == "." {
+				break
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = cache.Flush()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := TreeResponse{Root: s.root, Nodes: make([]TreeNode, 0, len(nodes))}
+	for _, n := range nodes {
+		resp.Nodes = append(resp.Nodes, *n)
+	}
+	sort.Slice(resp.Nodes, func(i, j int) bool { return resp.Nodes[i].Path < resp.Nodes[j].Path })
+	writeJSON(w, resp)
+}
+
+// handleGetFilter returns the filter with the field names of its YAML form.
+func (s *Server) handleGetFilter(w http.ResponseWriter, _ *http.Request) {
+	data, err := s.currentFilter().ToYAML()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fields := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, fields)
+}
+
+// handlePutFilter replaces the filter with the JSON body, which uses the
+// same fields as the filter YAML.
+func (s *Server) handlePutFilter(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ff, err := filefilter.FromYAML(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid filter: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := s.setFilter(ff); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.handleGetFilter(w, r)
+}
+
+func (s *Server) handleRule(w http.ResponseWriter, r *http.Request) {
+	var req ruleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid rule: %v", err), http.StatusBadRequest)
+		return
+	}
+	ff, err := CloneFilter(s.currentFilter(), s.root)
+	if err == nil {
+		err = ApplyRule(ff, req.Path, req.Action)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.setFilter(ff); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.handleGetFilter(w, r)
+}
+
+func (s *Server) handleProfiles(w http.ResponseWriter, _ *http.Request) {
+	ff, err := s.loadFilterFile()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := make([]string, 0, len(ff.Profiles))
+	for name := range ff.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, map[string]interface{}{"file": s.FilterFile, "profiles": names})
+}
+
+// handleSaveProfile stores the current filter as a profile of the filter
+// file, keeping its other settings and profiles.
+func (s *Server) handleSaveProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	file, err := s.loadFilterFile()
+	if err == nil {
+		var profile *filefilter.FileFilter
+		profile, err = CloneFilter(s.currentFilter(), "")
+		if err == nil {
+			if file.Profiles == nil {
+				file.Profiles = map[string]*filefilter.FileFilter{}
+			}
+			file.Profiles[name] = profile
+			err = file.SaveToFile(s.FilterFile)
+		}
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.handleProfiles(w, r)
+}
+
+func (s *Server) handleLoadProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	ff, err := filefilter.LoadFromFile(s.FilterFile, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var transforms *Pipeline
+	if s.ProfileTransforms != nil {
+		if transforms, err = s.ProfileTransforms(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := s.setFilter(ff); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s.ProfileTransforms != nil {
+		s.mu.Lock()
+		s.Transforms = transforms
+		s.Cache = NewStatsCache(StatsCodec(transforms))
+		s.mu.Unlock()
+	}
+	s.handleGetFilter(w, r)
+}
+
+func (s *Server) loadFilterFile() (*filefilter.FileFilter, error) {
+	data, err := os.ReadFile(s.FilterFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return filefilter.NewFileFilter(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filefilter.FromYAML(data)
+}
+
+// handleBundle builds the bundle with the current filter. The query takes
+// format (text, jsonl, repomap, markdown, zip or tar.gz), delimiter, mode and token-budget like catter
+// print; download=1 serves it as an attachment.
+func (s *Server) handleBundle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "text"
+	}
+	var contentType, fileName string
+	switch format {
+	case "text":
+		contentType, fileName = "text/plain; charset=utf-8", "bundle.txt"
+	case FormatJSONL:
+		contentType, fileName = "application/x-ndjson", "bundle.jsonl"
+	case FormatRepoMap:
+		contentType, fileName = "application/xml; charset=utf-8", "bundle.xml"
+	case FormatMarkdown:
+		contentType, fileName = "text/markdown; charset=utf-8", "bundle.md"
+	case "zip":
+		contentType, fileName = "application/zip", "bundle.zip"
+	case "tar.gz":
+		contentType, fileName = "application/gzip", "bundle.tar.gz"
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = ModeFull
+	}
+
+	transforms, _ := s.currentTransforms()
+	var buf bytes.Buffer
+	options := []FileProcessorOption{
+		WithFileFilter(s.currentFilter()),
+		WithWriter(&buf),
+		WithOutputFormat(format),
+		WithDelimiterType(q.Get("delimiter")),
+		WithMode(mode),
+		WithTransforms(transforms),
+	}
+	if v := q.Get("token-budget"); v != "" {
+		budget, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid token-budget %q", v), http.StatusBadRequest)
+			return
+		}
+		options = append(options, WithTokenBudget(BudgetOptions{Tokens: budget}))
+	}
+	fp := NewFileProcessor(options...)
+	fp.TokenCounter = s.tokenCounter
+	if err := fp.ProcessPaths([]string{s.Path}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Catter-Files", strconv.Itoa(fp.FileCount))
+	w.Header().Set("X-Catter-Tokens", strconv.Itoa(fp.TotalTokens))
+	// The pipeline remembers the files of earlier bundles too.
+	redactions := 0
+	for _, r := range transforms.Redactions() {
+		if _, ok := fp.TokenCounts[r.Path]; ok {
+			redactions++
+		}
+	}
+	w.Header().Set("X-Catter-Redactions", strconv.Itoa(redactions))
+	if q.Get("download") != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	}
+	_, _ = w.Write(buf.Bytes())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
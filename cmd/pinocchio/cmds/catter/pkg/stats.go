@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -15,13 +16,17 @@ import (
 	"github.com/go-go-golems/glazed/pkg/middlewares"
 	"github.com/go-go-golems/glazed/pkg/types"
 	"github.com/weaviate/tiktoken-go"
+	"golang.org/x/sync/errgroup"
 )
 
 type FileStats struct {
 	TokenCount int
-	LineCount  int
-	Size       int64
-	FileCount  int // Add FileCount field
+	// OutlineTokenCount is the token count of the file's outline, as printed
+	// by --mode outline.
+	OutlineTokenCount int
+	LineCount         int
+	Size              int64
+	FileCount         int // Add FileCount field
 }
 
 type Stats struct {
@@ -30,9 +35,21 @@ type Stats struct {
 	Dirs      map[string]FileStats
 	DirFiles  map[string][]string // New field to store files per directory
 	Total     FileStats
-	mu        sync.Mutex
+	// Transforms run over each file before it is counted, so the stats
+	// match what is printed.
+	Transforms *Pipeline
+	// Cache, if set, keeps the stats of unchanged files between runs. Its
+	// codec must match Transforms, see StatsCodec.
+	Cache *StatsCache
+	// Workers is the number of files tokenized at once, the number of CPUs
+	// if 0.
+	Workers int
+	mu      sync.Mutex
 }
 
+// statsEncoding is the tokenizer stats are counted with.
+const statsEncoding = "cl100k_base"
+
 type OutputFlag int
 
 const (
@@ -67,6 +84,7 @@ func (s *Stats) AddFile(path string, stats FileStats) {
 	}
 	fileTypeStats := s.FileTypes[ext]
 	fileTypeStats.TokenCount += stats.TokenCount
+	fileTypeStats.OutlineTokenCount += stats.OutlineTokenCount
 	fileTypeStats.LineCount += stats.LineCount
 	fileTypeStats.Size += stats.Size
 	fileTypeStats.FileCount++ // Increment FileCount for file type
@@ -76,6 +94,7 @@ func (s *Stats) AddFile(path string, stats FileStats) {
 	dir := filepath.Dir(path)
 	dirStats := s.Dirs[dir]
 	dirStats.TokenCount += stats.TokenCount
+	dirStats.OutlineTokenCount += stats.OutlineTokenCount
 	dirStats.LineCount += stats.LineCount
 	dirStats.Size += stats.Size
 	dirStats.FileCount++ // Increment FileCount for directory
@@ -86,17 +105,13 @@ func (s *Stats) AddFile(path string, stats FileStats) {
 
 	// Update total stats
 	s.Total.TokenCount += stats.TokenCount
+	s.Total.OutlineTokenCount += stats.OutlineTokenCount
 	s.Total.LineCount += stats.LineCount
 	s.Total.Size += stats.Size
 	s.Total.FileCount++ // Increment FileCount for total
 }
 
 func (s *Stats) ComputeStats(paths []string, filter *filefilter.FileFilter) error {
-	tokenCounter, err := tiktoken.GetEncoding("cl100k_base")
-	if err != nil {
-		return fmt.Errorf("error initializing tiktoken: %v", err)
-	}
-
 	walker, err := filewalker.NewWalker(
 		filewalker.WithPaths(paths),
 		filewalker.WithFilter(filter.FilterNode),
@@ -105,26 +120,10 @@ func (s *Stats) ComputeStats(paths []string, filter *filefilter.FileFilter) erro
 		return fmt.Errorf("error creating filewalker: %v", err)
 	}
 
+	var files []SourceFile
 	preVisit := func(w *filewalker.Walker, node *filewalker.Node) error {
 		if node.Type == filewalker.FileNode {
-			content, err := os.ReadFile(node.Path)
-			if err != nil {
-				return fmt.Errorf("error reading file %s: %v", node.Path, err)
-			}
-
-			tokens := tokenCounter.Encode(string(content), nil, nil)
-			tokenCount := len(tokens)
-			lineCount := strings.Count(string(content), "\n") + 1
-			size := int64(len(content))
-
-			fileStats := FileStats{
-				TokenCount: tokenCount,
-				LineCount:  lineCount,
-				Size:       size,
-				FileCount:  1,
-			}
-
-			s.AddFile(node.Path, fileStats)
+			files = append(files, SourceFile{Path: node.Path})
 		}
 		return nil
 	}
@@ -133,9 +132,100 @@ func (s *Stats) ComputeStats(paths []string, filter *filefilter.FileFilter) erro
 		return fmt.Errorf("error walking files: %v", err)
 	}
 
+	return s.computeFiles(files, func(f SourceFile) string { return f.Path })
+}
+
+// ComputeSourceStats computes the statistics of files selected from git,
+// which may not exist on disk.
+func (s *Stats) ComputeSourceStats(files []SourceFile) error {
+	return s.computeFiles(files, func(f SourceFile) string {
+		absPath, err := filepath.Abs(f.Path)
+		if err != nil {
+			return f.Path
+		}
+		return absPath
+	})
+}
+
+// StatsCodec names what the token counts of a file depend on: the tokenizer
+// and the transforms. It keys the entries of a StatsCache.
+func StatsCodec(transforms *Pipeline) string {
+	if fingerprint := transforms.Fingerprint(); fingerprint != "" {
+		return statsEncoding + "+" + fingerprint
+	}
+	return statsEncoding
+}
+
+// computeFiles tokenizes files on Workers goroutines and adds them under
+// key(file), in order.
+func (s *Stats) computeFiles(files []SourceFile, key func(SourceFile) string) error {
+	tokenCounter, err := tiktoken.GetEncoding(statsEncoding)
+	if err != nil {
+		return fmt.Errorf("error initializing tiktoken: %v", err)
+	}
+
+	results := make([]FileStats, len(files))
+	computed := make([]bool, len(files))
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var g errgroup.Group
+	g.SetLimit(workers)
+	for i, f := range files {
+		g.Go(func() error {
+			var err error
+			results[i], computed[i], err = s.fileStats(tokenCounter, f)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	for i, f := range files {
+		if computed[i] {
+			s.AddFile(key(f), results[i])
+		}
+	}
+	if s.Cache != nil {
+		return s.Cache.Flush()
+	}
 	return nil
 }
 
+// fileStats returns the stats of f from the cache, or computes them after
+// the transforms. It returns false for files whose transform failed.
+func (s *Stats) fileStats(tokenCounter *tiktoken.Tiktoken, f SourceFile) (FileStats, bool, error) {
+	transformFailed := false
+	stats, err := s.Cache.Get(f, func(content []byte) (FileStats, error) {
+		transformed, err := s.Transforms.Apply(f.Path, content)
+		if err != nil {
+			transformFailed = true
+			return FileStats{}, err
+		}
+		return computeFileStats(tokenCounter, f.Path, transformed), nil
+	})
+	if transformFailed {
+		_, _ = fmt.Fprintf(os.Stderr, "Error transforming file %s: %v\n", f.Path, err)
+		return FileStats{}, false, nil
+	}
+	if err != nil {
+		return FileStats{}, false, err
+	}
+	return stats, true, nil
+}
+
+func computeFileStats(tokenCounter *tiktoken.Tiktoken, path string, content []byte) FileStats {
+	return FileStats{
+		TokenCount:        len(tokenCounter.Encode(string(content), nil, nil)),
+		OutlineTokenCount: len(tokenCounter.Encode(Outline(path, content), nil, nil)),
+		LineCount:         strings.Count(string(content), "\n") + 1,
+		Size:              int64(len(content)),
+		FileCount:         1,
+	}
+}
+
 func (s *Stats) PrintStats(config Config, processor middlewares.Processor) error {
 	ctx := context.Background()
 
@@ -179,6 +269,7 @@ func (s *Stats) printOverviewAndFileTypes(ctx context.Context, processor middlew
 		types.MRP("Name", ""),
 		types.MRP("FileCount", s.Total.FileCount),
 		types.MRP("TokenCount", s.Total.TokenCount),
+		types.MRP("OutlineTokenCount", s.Total.OutlineTokenCount),
 		types.MRP("LineCount", s.Total.LineCount),
 		types.MRP("Size", s.Total.Size),
 	)); err != nil {
@@ -192,6 +283,7 @@ func (s *Stats) printOverviewAndFileTypes(ctx context.Context, processor middlew
 			types.MRP("Name", ext),
 			types.MRP("FileCount", typeStats.FileCount),
 			types.MRP("TokenCount", typeStats.TokenCount),
+			types.MRP("OutlineTokenCount", typeStats.OutlineTokenCount),
 			types.MRP("LineCount", typeStats.LineCount),
 			types.MRP("Size", typeStats.Size),
 		)); err != nil {
@@ -220,6 +312,7 @@ func (s *Stats) printDirStructure(ctx context.Context, processor middlewares.Pro
 			types.MRP("Name", relDir),
 			types.MRP("FileCount", dirStats.FileCount),
 			types.MRP("TokenCount", dirStats.TokenCount),
+			types.MRP("OutlineTokenCount", dirStats.OutlineTokenCount),
 			types.MRP("LineCount", dirStats.LineCount),
 			types.MRP("Size", dirStats.Size),
 		)); err != nil {
@@ -248,6 +341,7 @@ func (s *Stats) printFullStructure(ctx context.Context, processor middlewares.Pr
 			types.MRP("Name", relDir),
 			types.MRP("FileCount", dirStats.FileCount),
 			types.MRP("TokenCount", dirStats.TokenCount),
+			types.MRP("OutlineTokenCount", dirStats.OutlineTokenCount),
 			types.MRP("LineCount", dirStats.LineCount),
 			types.MRP("Size", dirStats.Size),
 		)); err != nil {
@@ -267,6 +361,7 @@ func (s *Stats) printFullStructure(ctx context.Context, processor middlewares.Pr
 				types.MRP("Type", "File"),
 				types.MRP("Name", relFile),
 				types.MRP("TokenCount", fileStats.TokenCount),
+				types.MRP("OutlineTokenCount", fileStats.OutlineTokenCount),
 				types.MRP("LineCount", fileStats.LineCount),
 				types.MRP("Size", fileStats.Size),
 			)); err != nil {
@@ -283,13 +378,14 @@ func (s *Stats) PrintOverview() {
 	fmt.Printf("Total Files: %d\n", s.Total.FileCount)
 	fmt.Printf("Total Directories: %d\n", len(s.Dirs))
 	fmt.Printf("Total Tokens: %d\n", s.Total.TokenCount)
+	fmt.Printf("Total Outline Tokens: %d\n", s.Total.OutlineTokenCount)
 	fmt.Printf("Total Lines: %d\n", s.Total.LineCount)
 	fmt.Printf("Total Size: %d bytes\n", s.Total.Size)
 
 	fmt.Println("\nFile Type Statistics:")
 	for ext, typeStats := range s.FileTypes {
-		fmt.Printf("  %s:\n    Files: %d, Tokens: %d, Lines: %d, Size: %d bytes\n",
-			ext, typeStats.FileCount, typeStats.TokenCount, typeStats.LineCount, typeStats.Size)
+		fmt.Printf("  %s:\n    Files: %d, Tokens: %d, Outline Tokens: %d, Lines: %d, Size: %d bytes\n",
+			ext, typeStats.FileCount, typeStats.TokenCount, typeStats.OutlineTokenCount, typeStats.LineCount, typeStats.Size)
 	}
 	fmt.Println()
 }
@@ -309,8 +405,8 @@ func (s *Stats) PrintDirStructure() {
 		if err != nil {
 			relDir = dir // Fallback to absolute path if relative path can't be determined
 		}
-		fmt.Printf("%s:\n  Files: %d, Tokens: %d, Lines: %d, Size: %d bytes\n",
-			relDir, dirStats.FileCount, dirStats.TokenCount, dirStats.LineCount, dirStats.Size)
+		fmt.Printf("%s:\n  Files: %d, Tokens: %d, Outline Tokens: %d, Lines: %d, Size: %d bytes\n",
+			relDir, dirStats.FileCount, dirStats.TokenCount, dirStats.OutlineTokenCount, dirStats.LineCount, dirStats.Size)
 	}
 	fmt.Println()
 }
@@ -330,8 +426,8 @@ func (s *Stats) PrintFullStructure() {
 		if err != nil {
 			relDir = dir // Fallback to absolute path if relative path can't be determined
 		}
-		fmt.Printf("%s:\n  Tokens: %d, Lines: %d, Size: %d bytes\n",
-			relDir, dirStats.TokenCount, dirStats.LineCount, dirStats.Size)
+		fmt.Printf("%s:\n  Tokens: %d, Outline Tokens: %d, Lines: %d, Size: %d bytes\n",
+			relDir, dirStats.TokenCount, dirStats.OutlineTokenCount, dirStats.LineCount, dirStats.Size)
 
 		files := s.DirFiles[dir]
 		sort.Strings(files)
@@ -342,8 +438,8 @@ func (s *Stats) PrintFullStructure() {
 			if err != nil {
 				relFile = file // Fallback to absolute path if relative path can't be determined
 			}
-			fmt.Printf("  %s:\n    Tokens: %d, Lines: %d, Size: %d bytes\n",
-				relFile, fileStats.TokenCount, fileStats.LineCount, fileStats.Size)
+			fmt.Printf("  %s:\n    Tokens: %d, Outline Tokens: %d, Lines: %d, Size: %d bytes\n",
+				relFile, fileStats.TokenCount, fileStats.OutlineTokenCount, fileStats.LineCount, fileStats.Size)
 		}
 		fmt.Println()
 	}
@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultCacheDir is where catter keeps its stats cache.
+const DefaultCacheDir = ".catter-cache"
+
+type cacheEntry struct {
+	size  int64
+	mtime int64
+	hash  string
+	stats FileStats
+}
+
+// StatsCache remembers the stats of files between runs. Entries are keyed by
+// path and codec, the tokenizer and transforms the counts depend on. A file
+// whose size and modification time are unchanged is not read again; one
+// whose content hash is unchanged is not tokenized again.
+//
+// A cache opened with OpenStatsCache persists in a SQLite file; one created
+// with NewStatsCache lives in memory.
+type StatsCache struct {
+	codec string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dirty   map[string]bool
+	db      *sql.DB
+}
+
+func NewStatsCache(codec string) *StatsCache {
+	return &StatsCache{codec: codec, entries: map[string]cacheEntry{}, dirty: map[string]bool{}}
+}
+
+// OpenStatsCache opens, or creates, the cache in dir/stats.db and loads its
+// entries for codec.
+func OpenStatsCache(dir, codec string) (*StatsCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", filepath.Join(dir, "stats.db")))
+	if err != nil {
+		return nil, err
+	}
+	c := NewStatsCache(codec)
+	c.db = db
+	if err := c.load(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("error opening stats cache: %w", err)
+	}
+	return c, nil
+}
+
+func (c *StatsCache) load() error {
+	_, err := c.db.Exec(`CREATE TABLE IF NOT EXISTS file_stats (
+		path TEXT NOT NULL,
+		codec TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		mtime_ns INTEGER NOT NULL,
+		hash TEXT NOT NULL,
+		tokens INTEGER NOT NULL,
+		outline_tokens INTEGER NOT NULL,
+		lines INTEGER NOT NULL,
+		bytes INTEGER NOT NULL,
+		PRIMARY KEY (path, codec)
+	)`)
+	if err != nil {
+		return err
+	}
+
+	rows, err := c.db.Query(`SELECT path, size, mtime_ns, hash, tokens, outline_tokens, lines, bytes FROM file_stats WHERE codec = ?`, c.codec)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var path string
+		var e cacheEntry
+		if err := rows.Scan(&path, &e.size, &e.mtime, &e.hash, &e.stats.TokenCount, &e.stats.OutlineTokenCount, &e.stats.LineCount, &e.stats.Size); err != nil {
+			return err
+		}
+		e.stats.FileCount = 1
+		c.entries[path] = e
+	}
+	return rows.Err()
+}
+
+// Lookup returns the stats of path if its size and modification time did not
+// change.
+func (c *StatsCache) Lookup(path string, info os.FileInfo) (FileStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok || info == nil || e.size != info.Size() || e.mtime != info.ModTime().UnixNano() {
+		return FileStats{}, false
+	}
+	return e.stats, true
+}
+
+// LookupContent returns the stats of path if its content did not change.
+// The entry is refreshed with info, so the next Lookup hits.
+func (c *StatsCache) LookupContent(path string, info os.FileInfo, content []byte) (FileStats, bool) {
+	hash := contentHash(content)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	if !ok || e.hash != hash {
+		return FileStats{}, false
+	}
+	if info != nil && (e.size != info.Size() || e.mtime != info.ModTime().UnixNano()) {
+		e.size, e.mtime = info.Size(), info.ModTime().UnixNano()
+		c.entries[path] = e
+		c.dirty[path] = true
+	}
+	return e.stats, true
+}
+
+// Get returns the stats of f from the cache if the file did not change.
+// Otherwise it reads f and stores the stats compute returns for its content.
+// A nil cache always computes.
+func (c *StatsCache) Get(f SourceFile, compute func(content []byte) (FileStats, error)) (FileStats, error) {
+	// Files read from git have no meaningful modification time.
+	info := f.Info
+	if f.Content != nil {
+		info = nil
+	} else if info == nil {
+		info, _ = os.Stat(f.Path)
+	}
+
+	if c != nil && info != nil {
+		if stats, ok := c.Lookup(f.Path, info); ok {
+			return stats, nil
+		}
+	}
+	content, err := f.Read()
+	if err != nil {
+		return FileStats{}, fmt.Errorf("error reading file %s: %v", f.Path, err)
+	}
+	if c != nil {
+		if stats, ok := c.LookupContent(f.Path, info, content); ok {
+			return stats, nil
+		}
+	}
+	stats, err := compute(content)
+	if err != nil {
+		return FileStats{}, err
+	}
+	if c != nil {
+		c.Store(f.Path, info, content, stats)
+	}
+	return stats, nil
+}
+
+// Store records the stats of path. Persistent caches write them on Flush.
+func (c *StatsCache) Store(path string, info os.FileInfo, content []byte, stats FileStats) {
+	e := cacheEntry{hash: contentHash(content), stats: stats}
+	if info != nil {
+		e.size, e.mtime = info.Size(), info.ModTime().UnixNano()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[path] = e
+	c.dirty[path] = true
+}
+
+// Flush writes the entries stored since the last flush to the database.
+func (c *StatsCache) Flush() error {
+	if c == nil || c.db == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.dirty) == 0 {
+		return nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO file_stats
+		(path, codec, size, mtime_ns, hash, tokens, outline_tokens, lines, bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for path := range c.dirty {
+		e := c.entries[path]
+		if _, err := stmt.Exec(path, c.codec, e.size, e.mtime, e.hash, e.stats.TokenCount, e.stats.OutlineTokenCount, e.stats.LineCount, e.stats.Size); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	c.dirty = map[string]bool{}
+	return nil
+}
+
+// Close flushes the cache and closes its database.
+func (c *StatsCache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	err := c.Flush()
+	if cerr := c.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
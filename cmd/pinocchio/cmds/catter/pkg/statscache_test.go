@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatsCachePersistsAndRevalidates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n"})
+	path := filepath.Join(dir, "a.go")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("package a\n")
+	stats := FileStats{TokenCount: 3, OutlineTokenCount: 3, LineCount: 2, Size: 10, FileCount: 1}
+
+	cacheDir := filepath.Join(dir, DefaultCacheDir)
+	cache, err := OpenStatsCache(cacheDir, "cl100k_base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup(path, info); ok {
+		t.Fatal("empty cache hit")
+	}
+	cache.Store(path, info, content, stats)
+	if err := cache.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = OpenStatsCache(cacheDir, "cl100k_base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = cache.Close() }()
+	if got, ok := cache.Lookup(path, info); !ok || got != stats {
+		t.Fatalf("Lookup after reopening = %+v, %v", got, ok)
+	}
+
+	// Touching the file misses by modification time but hits by content.
+	later := info.ModTime().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	touched, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup(path, touched); ok {
+		t.Fatal("Lookup hit a touched file")
+	}
+	if got, ok := cache.LookupContent(path, touched, content); !ok || got != stats {
+		t.Fatalf("LookupContent = %+v, %v", got, ok)
+	}
+	if _, ok := cache.Lookup(path, touched); !ok {
+		t.Fatal("LookupContent did not refresh the entry")
+	}
+	if _, ok := cache.LookupContent(path, touched, []byte("package b\n")); ok {
+		t.Fatal("LookupContent hit changed content")
+	}
+
+	other, err := OpenStatsCache(cacheDir, "cl100k_base+transforms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = other.Close() }()
+	if _, ok := other.Lookup(path, info); ok {
+		t.Fatal("entries leaked across codecs")
+	}
+}
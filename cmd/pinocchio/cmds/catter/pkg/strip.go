@@ -0,0 +1,264 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// commentSyntax describes the comments of a language.
+type commentSyntax struct {
+	line       []string
+	blockStart string
+	blockEnd   string
+	// quotes are the string delimiters comments are not looked for in.
+	quotes string
+}
+
+var (
+	cComments      = commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/", quotes: "\"'`"}
+	hashComments   = commentSyntax{line: []string{"#"}, quotes: "\"'"}
+	dashComments   = commentSyntax{line: []string{"--"}, quotes: "\"'"}
+	cssComments    = commentSyntax{blockStart: "/*", blockEnd: "*/", quotes: "\"'"}
+	markupComments = commentSyntax{blockStart: "<!--", blockEnd: "-->"}
+)
+
+var commentSyntaxes = map[string]commentSyntax{
+	".go": cComments, ".c": cComments, ".h": cComments, ".cc": cComments, ".cpp": cComments, ".hpp": cComments,
+	".java": cComments, ".kt": cComments, ".scala": cComments, ".swift": cComments, ".rs": cComments, ".cs": cComments,
+	".js": cComments, ".jsx": cComments, ".mjs": cComments, ".cjs": cComments,
+	".ts": cComments, ".tsx": cComments, ".mts": cComments, ".cts": cComments, ".proto": cComments,
+	".py": hashComments, ".pyi": hashComments, ".sh": hashComments, ".bash": hashComments, ".zsh": hashComments,
+	".rb": hashComments, ".pl": hashComments, ".r": hashComments, ".yaml": hashComments, ".yml": hashComments,
+	".toml": hashComments, ".mk": hashComments, ".dockerfile": hashComments,
+	".sql": dashComments, ".lua": dashComments, ".hs": dashComments,
+	".css": cssComments, ".scss": cComments, ".less": cComments,
+	".html": markupComments, ".htm": markupComments, ".xml": markupComments, ".svg": markupComments,
+}
+
+func commentSyntaxFor(path string) (commentSyntax, bool) {
+	switch filepath.Base(path) {
+	case "Makefile", "Dockerfile":
+		return hashComments, true
+	}
+	s, ok := commentSyntaxes[strings.ToLower(filepath.Ext(path))]
+	return s, ok
+}
+
+// stripComments removes the comments of the languages in commentSyntaxes,
+// and the lines left empty by them. Shebang lines are kept. Other files are
+// returned unchanged.
+func stripComments(path string, content []byte) ([]byte, error) {
+	syntax, ok := commentSyntaxFor(path)
+	if !ok {
+		return content, nil
+	}
+
+	src := string(content)
+	var b strings.Builder
+	if strings.HasPrefix(src, "#!") {
+		end := strings.IndexByte(src, '\n')
+		if end < 0 {
+			return content, nil
+		}
+		b.WriteString(src[:end+1])
+		src = src[end+1:]
+	}
+
+	// stripped marks the lines of the output that lost a comment, so the
+	// ones left blank can be dropped.
+	var out strings.Builder
+	stripped := map[int]bool{}
+	line := 0
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			out.WriteByte(c)
+			switch {
+			case c == '\\' && quote != '`' && i+1 < len(src):
+				i++
+				out.WriteByte(src[i])
+				if src[i] == '\n' {
+					line++
+				}
+			case c == quote:
+				quote = 0
+			case c == '\n':
+				line++
+				if quote != '`' {
+					quote = 0
+				}
+			}
+			continue
+		}
+
+		if syntax.blockStart != "" && strings.HasPrefix(src[i:], syntax.blockStart) {
+			end := strings.Index(src[i+len(syntax.blockStart):], syntax.blockEnd)
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i + len(syntax.blockStart) + len(syntax.blockEnd)
+			}
+			stripped[line] = true
+			for _, nl := range src[i:end] {
+				if nl == '\n' {
+					out.WriteByte('\n')
+					line++
+					stripped[line] = true
+				}
+			}
+			i = end - 1
+			continue
+		}
+		if lineComment(syntax, src, i) {
+			stripped[line] = true
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i--
+			continue
+		}
+
+		out.WriteByte(c)
+		switch {
+		case c == '\n':
+			line++
+		case strings.IndexByte(syntax.quotes, c) >= 0:
+			quote = c
+		}
+	}
+
+	for n, l := range strings.SplitAfter(out.String(), "\n") {
+		if stripped[n] && strings.TrimSpace(l) == "" {
+			continue
+		}
+		if stripped[n] {
+			l = strings.TrimRight(l, " \t\n") + strings.Repeat("\n", strings.Count(l, "\n"))
+		}
+		b.WriteString(l)
+	}
+	return []byte(b.String()), nil
+}
+
+// lineComment reports whether a line comment starts at src[i]. Like in
+// shells and YAML, "#" only starts a comment after whitespace.
+func lineComment(syntax commentSyntax, src string, i int) bool {
+	for _, prefix := range syntax.line {
+		if !strings.HasPrefix(src[i:], prefix) {
+			continue
+		}
+		if prefix == "#" && i > 0 && !strings.ContainsRune(" \t\n", rune(src[i-1])) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// licenseHeader matches the words of a license header.
+var licenseHeader = regexp.MustCompile(`(?i)copyright|license|spdx-license-identifier|all rights reserved`)
+
+// stripLicense removes a leading comment block that mentions a copyright or
+// license, with the blank lines after it.
+func stripLicense(path string, content []byte) ([]byte, error) {
+	syntax, ok := commentSyntaxFor(path)
+	if !ok {
+		return content, nil
+	}
+
+	src := string(content)
+	prefix := ""
+	if strings.HasPrefix(src, "#!") {
+		end := strings.IndexByte(src, '\n')
+		if end < 0 {
+			return content, nil
+		}
+		prefix, src = src[:end+1], src[end+1:]
+	}
+	rest := strings.TrimLeft(src, " \t\r\n")
+
+	var header string
+	switch {
+	case syntax.blockStart != "" && strings.HasPrefix(rest, syntax.blockStart):
+		end := strings.Index(rest, syntax.blockEnd)
+		if end < 0 {
+			return content, nil
+		}
+		header = rest[:end+len(syntax.blockEnd)]
+	case len(syntax.line) > 0:
+		lines := strings.SplitAfter(rest, "\n")
+		n := 0
+		for _, l := range lines {
+			if !lineComment(syntax, strings.TrimSpace(l), 0) {
+				break
+			}
+			n += len(l)
+		}
+		header = rest[:n]
+	}
+	if header == "" || !licenseHeader.MatchString(header) {
+		return content, nil
+	}
+	return []byte(prefix + strings.TrimLeft(rest[len(header):], " \t\r\n")), nil
+}
+
+// stripBlankLines removes lines holding nothing but whitespace.
+func stripBlankLines(_ string, content []byte) ([]byte, error) {
+	var b bytes.Buffer
+	for _, l := range bytes.SplitAfter(content, []byte("\n")) {
+		if len(bytes.TrimSpace(l)) > 0 {
+			b.Write(l)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// minify compacts JSON files and rewrites YAML files in flow style, without
+// comments. Files that do not parse are returned unchanged.
+func minify(path string, content []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var b bytes.Buffer
+		if err := json.Compact(&b, content); err != nil {
+			return content, nil
+		}
+		return b.Bytes(), nil
+	case ".yaml", ".yml":
+		var docs []string
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var node yaml.Node
+			if err := dec.Decode(&node); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return content, nil
+			}
+			flowStyle(&node)
+			out, err := yaml.Marshal(&node)
+			if err != nil {
+				return content, nil
+			}
+			docs = append(docs, strings.TrimSpace(string(out)))
+		}
+		return []byte(strings.Join(docs, "\n---\n") + "\n"), nil
+	}
+	return content, nil
+}
+
+func flowStyle(node *yaml.Node) {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		flowStyle(child)
+	}
+}
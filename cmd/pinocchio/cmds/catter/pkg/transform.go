@@ -0,0 +1,342 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultCommandTimeout bounds command transforms without a timeout.
+const defaultCommandTimeout = 10 * time.Second
+
+// Transformer rewrites the content of a file before it is counted and
+// printed.
+type Transformer interface {
+	Transform(path string, content []byte) ([]byte, error)
+}
+
+// TransformerFunc adapts a function to Transformer.
+type TransformerFunc func(path string, content []byte) ([]byte, error)
+
+func (f TransformerFunc) Transform(path string, content []byte) ([]byte, error) {
+	return f(path, content)
+}
+
+// TransformConfig is one step of a transform chain, as listed under
+// `transforms` in the filter YAML file and its profiles.
+type TransformConfig struct {
+	// Type is the name the transform is registered under, such as
+	// "redact-secrets".
+	Type string `yaml:"type"`
+	// Globs restrict the step to the paths matching one of these
+	// gitignore-style globs. Empty means every file.
+	Globs []string `yaml:"globs,omitempty"`
+	// Patterns are extra regular expressions for redact-secrets.
+	Patterns []string `yaml:"patterns,omitempty"`
+	// Command is the shell command of a command transform. It reads the
+	// content on stdin and writes the new content to stdout; $CATTER_PATH
+	// holds the path of the file.
+	Command string `yaml:"command,omitempty"`
+	// Timeout bounds a command transform, 10s by default.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// TransformFactory builds a transform from its configuration.
+type TransformFactory func(config TransformConfig) (Transformer, error)
+
+// Built-in transform types.
+const (
+	TransformRedactSecrets   = "redact-secrets"
+	TransformStripLicense    = "strip-license"
+	TransformStripComments   = "strip-comments"
+	TransformStripBlankLines = "strip-blank-lines"
+	TransformMinify          = "minify"
+	TransformCommand         = "command"
+)
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFactory{}
+)
+
+// RegisterTransform makes factory build the transforms of the given type. It
+// replaces earlier registrations.
+func RegisterTransform(name string, factory TransformFactory) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = factory
+}
+
+// TransformNames returns the registered transform types, sorted.
+func TransformNames() []string {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterTransform(TransformRedactSecrets, func(config TransformConfig) (Transformer, error) {
+		return NewSecretRedactor(config.Patterns)
+	})
+	RegisterTransform(TransformStripLicense, func(TransformConfig) (Transformer, error) {
+		return TransformerFunc(stripLicense), nil
+	})
+	RegisterTransform(TransformStripComments, func(TransformConfig) (Transformer, error) {
+		return TransformerFunc(stripComments), nil
+	})
+	RegisterTransform(TransformStripBlankLines, func(TransformConfig) (Transformer, error) {
+		return TransformerFunc(stripBlankLines), nil
+	})
+	RegisterTransform(TransformMinify, func(TransformConfig) (Transformer, error) {
+		return TransformerFunc(minify), nil
+	})
+	RegisterTransform(TransformCommand, newCommandTransform)
+}
+
+type transformStep struct {
+	config      TransformConfig
+	globs       []*filefilter.Pattern
+	transformer Transformer
+}
+
+type transformResult struct {
+	sum     [sha256.Size]byte
+	content []byte
+	err     error
+}
+
+// Pipeline runs a chain of transforms over each file. Results are cached by
+// path and content, so stats and output transform a file once. A nil
+// Pipeline leaves contents unchanged.
+type Pipeline struct {
+	steps []transformStep
+
+	mu    sync.Mutex
+	cache map[string]transformResult
+}
+
+func NewPipeline(configs []TransformConfig) (*Pipeline, error) {
+	p := &Pipeline{cache: map[string]transformResult{}}
+	for _, config := range configs {
+		transformsMu.RLock()
+		factory, ok := transforms[config.Type]
+		transformsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown transform %q (available: %s)", config.Type, strings.Join(TransformNames(), ", "))
+		}
+		transformer, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("transform %s: %w", config.Type, err)
+		}
+		step := transformStep{config: config, transformer: transformer}
+		for _, g := range config.Globs {
+			pattern, err := filefilter.ParsePattern(g)
+			if err != nil {
+				return nil, fmt.Errorf("transform %s: %w", config.Type, err)
+			}
+			if pattern != nil {
+				step.globs = append(step.globs, pattern)
+			}
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+// Empty reports whether the pipeline has no steps.
+func (p *Pipeline) Empty() bool {
+	return p == nil || len(p.steps) == 0
+}
+
+// Fingerprint identifies the configuration of the steps, so results that
+// depend on them can be cached. It is empty for an empty pipeline.
+func (p *Pipeline) Fingerprint() string {
+	if p.Empty() {
+		return ""
+	}
+	var configs []TransformConfig
+	for _, step := range p.steps {
+		configs = append(configs, step.config)
+	}
+	data, err := yaml.Marshal(configs)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Apply runs the steps that select path over content.
+func (p *Pipeline) Apply(path string, content []byte) ([]byte, error) {
+	if p.Empty() {
+		return content, nil
+	}
+
+	sum := sha256.Sum256(content)
+	p.mu.Lock()
+	cached, ok := p.cache[path]
+	p.mu.Unlock()
+	if ok && cached.sum == sum {
+		return cached.content, cached.err
+	}
+
+	rel := filepath.ToSlash(path)
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			if r, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(r, "..") {
+				rel = filepath.ToSlash(r)
+			}
+		}
+	}
+
+	ret := content
+	var err error
+	for _, step := range p.steps {
+		if !step.selects(rel) {
+			continue
+		}
+		ret, err = step.transformer.Transform(path, ret)
+		if err != nil {
+			err = fmt.Errorf("transform %s: %w", step.config.Type, err)
+			ret = nil
+			break
+		}
+	}
+
+	p.mu.Lock()
+	p.cache[path] = transformResult{sum: sum, content: ret, err: err}
+	p.mu.Unlock()
+	return ret, err
+}
+
+func (s transformStep) selects(rel string) bool {
+	if len(s.globs) == 0 {
+		return true
+	}
+	selected := false
+	for _, g := range s.globs {
+		if matchesLevels(g, rel) {
+			selected = !g.Negated()
+		}
+	}
+	return selected
+}
+
+// Redactions returns the secrets the pipeline's redactors replaced, sorted
+// by path and line.
+func (p *Pipeline) Redactions() []Redaction {
+	if p == nil {
+		return nil
+	}
+	var ret []Redaction
+	for _, step := range p.steps {
+		if r, ok := step.transformer.(*SecretRedactor); ok {
+			ret = append(ret, r.Redactions()...)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Path != ret[j].Path {
+			return ret[i].Path < ret[j].Path
+		}
+		return ret[i].Line < ret[j].Line
+	})
+	return ret
+}
+
+// WriteReport lists the redacted secrets, if any.
+func (p *Pipeline) WriteReport(w io.Writer) {
+	redactions := p.Redactions()
+	if len(redactions) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Redacted %d secrets:\n", len(redactions))
+	for _, r := range redactions {
+		_, _ = fmt.Fprintf(w, "  %s:%d: %s\n", r.Path, r.Line, r.Kind)
+	}
+}
+
+type transformFile struct {
+	Transforms []TransformConfig `yaml:"transforms"`
+	Profiles   map[string]struct {
+		Transforms []TransformConfig `yaml:"transforms"`
+	} `yaml:"profiles"`
+}
+
+// LoadTransforms reads the transforms of the filter YAML file. A profile
+// that lists no transforms of its own uses the top-level ones. A missing
+// file has no transforms.
+func LoadTransforms(filename string, profile string) ([]TransformConfig, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f transformFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error parsing transforms in %s: %w", filename, err)
+	}
+	if p, ok := f.Profiles[profile]; ok && p.Transforms != nil {
+		return p.Transforms, nil
+	}
+	return f.Transforms, nil
+}
+
+type commandTransform struct {
+	command string
+	timeout time.Duration
+}
+
+func newCommandTransform(config TransformConfig) (Transformer, error) {
+	if strings.TrimSpace(config.Command) == "" {
+		return nil, errors.New("command is required")
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	return &commandTransform{command: config.Command, timeout: timeout}, nil
+}
+
+func (c *commandTransform) Transform(path string, content []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command)
+	// Children of the shell may hold stdout open after it is killed.
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(), "CATTER_PATH="+path)
+	cmd.Stdin = bytes.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%q timed out after %s", c.command, c.timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w: %s", c.command, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
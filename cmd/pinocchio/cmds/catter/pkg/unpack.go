@@ -0,0 +1,347 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ReadBundle parses a bundle written by WriteBundle, possibly edited since.
+// An empty format is detected from the content.
+func ReadBundle(r io.Reader, format string) ([]BundleFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = detectBundleFormat(data)
+	}
+	switch format {
+	case FormatJSONL:
+		return readJSONL(data)
+	case FormatRepoMap:
+		return readRepoMap(string(data))
+	case FormatMarkdown:
+		return readMarkdown(string(data)), nil
+	}
+	return nil, fmt.Errorf("unknown bundle format %q (available: %s)", format, strings.Join(BundleFormats, ", "))
+}
+
+func detectBundleFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return FormatJSONL
+	case bytes.HasPrefix(trimmed, []byte("<repository")), bytes.Contains(trimmed, []byte("<file path=")):
+		return FormatRepoMap
+	}
+	return FormatMarkdown
+}
+
+func readJSONL(data []byte) ([]BundleFile, error) {
+	var ret []BundleFile
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var f BundleFile
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		ret = append(ret, f)
+	}
+	return ret, scanner.Err()
+}
+
+var (
+	fileTagRe = regexp.MustCompile(`<file\s([^>]*)>`)
+	attrRe    = regexp.MustCompile(`([\w-]+)="([^"]*)"`)
+)
+
+// readRepoMap reads the <file> elements of a repomap bundle. Contents are
+// usually CDATA sections, but raw text up to </file> is accepted as models
+// do not always keep them.
+func readRepoMap(data string) ([]BundleFile, error) {
+	var ret []BundleFile
+	for {
+		loc := fileTagRe.FindStringSubmatchIndex(data)
+		if loc == nil {
+			return ret, nil
+		}
+		var f BundleFile
+		for _, m := range attrRe.FindAllStringSubmatch(data[loc[2]:loc[3]], -1) {
+			value := html.UnescapeString(m[2])
+			switch m[1] {
+			case "path":
+				f.Path = value
+			case "language":
+				f.Language = value
+			case "hash":
+				f.Hash = value
+			case "source-hash":
+				f.SourceHash = value
+			case "tokens":
+				_, _ = fmt.Sscanf(value, "%d", &f.Tokens)
+			case "outline":
+				f.Outline = value == "true"
+			}
+		}
+		if f.Path == "" {
+			return nil, errors.New("<file> without a path")
+		}
+
+		rest := strings.TrimPrefix(data[loc[1]:], "\n")
+		if strings.HasPrefix(rest, "<![CDATA[") {
+			var b strings.Builder
+			for strings.HasPrefix(rest, "<![CDATA[") {
+				rest = rest[len("<![CDATA["):]
+				end := strings.Index(rest, "]]>")
+				if end < 0 {
+					return nil, fmt.Errorf("%s: unterminated CDATA section", f.Path)
+				}
+				b.WriteString(rest[:end])
+				rest = rest[end+len("]]>"):]
+			}
+			f.Content = b.String()
+			end := strings.Index(rest, "</file>")
+			if end < 0 {
+				return nil, fmt.Errorf("%s: missing </file>", f.Path)
+			}
+			rest = rest[end+len("</file>"):]
+		} else {
+			end := strings.Index(rest, "</file>")
+			if end < 0 {
+				return nil, fmt.Errorf("%s: missing </file>", f.Path)
+			}
+			f.Content = strings.TrimSuffix(rest[:end], "\n") + "\n"
+			restoreMissingNewline(&f)
+			rest = rest[end+len("</file>"):]
+		}
+		ret = append(ret, f)
+		data = rest
+	}
+}
+
+var (
+	fenceRe      = regexp.MustCompile("^(`{3,})(\\S*)\\s*$")
+	anchorHashRe = regexp.MustCompile(`^<a id="[^"]*" data-hash="([0-9a-f]*)"(?: data-source-hash="([0-9a-f]*)")?></a>$`)
+)
+
+// readMarkdown reads the sections of a Markdown bundle: a "## path" heading,
+// after an optional anchor with the hash, followed by a fenced code block.
+func readMarkdown(data string) []BundleFile {
+	var ret []BundleFile
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		heading, ok := strings.CutPrefix(lines[i], "## ")
+		if !ok {
+			continue
+		}
+		j := i + 1
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		if j == len(lines) {
+			break
+		}
+		m := fenceRe.FindStringSubmatch(lines[j])
+		if m == nil {
+			continue
+		}
+
+		f := BundleFile{Path: strings.Trim(strings.TrimSpace(heading), "`"), Language: m[2]}
+		if p, ok := strings.CutSuffix(f.Path, markdownOutlineSuffix); ok {
+			f.Path, f.Outline = p, true
+		}
+		if i > 0 {
+			if m := anchorHashRe.FindStringSubmatch(strings.TrimSpace(lines[i-1])); m != nil {
+				f.Hash, f.SourceHash = m[1], m[2]
+			}
+		}
+		var b strings.Builder
+		k := j + 1
+		for ; k < len(lines) && strings.TrimSpace(lines[k]) != m[1]; k++ {
+			b.WriteString(lines[k])
+			b.WriteString("\n")
+		}
+		f.Content = b.String()
+		restoreMissingNewline(&f)
+		ret = append(ret, f)
+		i = k
+	}
+	return ret
+}
+
+// restoreMissingNewline drops the newline formats without exact contents
+// add to files that did not end with one, if the hash tells so.
+func restoreMissingNewline(f *BundleFile) {
+	if f.Hash == "" || contentHash([]byte(f.Content)) == f.Hash {
+		return
+	}
+	if trimmed := strings.TrimSuffix(f.Content, "\n"); contentHash([]byte(trimmed)) == f.Hash {
+		f.Content = trimmed
+	}
+}
+
+// Unpack statuses.
+const (
+	UnpackCreated   = "created"
+	UnpackUpdated   = "updated"
+	UnpackUnchanged = "unchanged"
+	// UnpackNotEdited marks files whose content is the one bundled while
+	// the file on disk differs, such as redacted or truncated files. They
+	// are not written back.
+	UnpackNotEdited = "not edited"
+	// UnpackOutline marks outlines, which are not written back.
+	UnpackOutline = "outline"
+	// UnpackTransformed marks files edited in the bundle whose bundled
+	// content was transformed or truncated. Writing them back would lose
+	// the rest of the original, so they are not written, even with force.
+	UnpackTransformed = "transformed"
+	// UnpackConflict marks files edited in the bundle that also changed on
+	// disk since it was written.
+	UnpackConflict = "conflict"
+)
+
+// UnpackedFile is what Unpack did, or would do, with a file of a bundle.
+type UnpackedFile struct {
+	Path   string
+	Status string
+}
+
+// UnpackOptions configure Unpack.
+type UnpackOptions struct {
+	// DryRun reports what would be written without writing.
+	DryRun bool
+	// Force overwrites conflicting files. Files that were not edited, and
+	// transformed or truncated files, are still left alone.
+	Force bool
+}
+
+// ErrUnpackConflict is returned by Unpack when files changed both in the
+// bundle and on disk. Nothing is written then.
+var ErrUnpackConflict = errors.New("files changed both in the bundle and on disk")
+
+// Unpack writes the files of a bundle below dir. Paths must stay inside dir,
+// also through symlinks. Each file is replaced atomically.
+func Unpack(dir string, files []BundleFile, opts UnpackOptions) ([]UnpackedFile, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	ret := make([]UnpackedFile, 0, len(files))
+	conflict := false
+	for _, f := range files {
+		name := filepath.FromSlash(f.Path)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("refusing to unpack %q outside of %s", f.Path, dir)
+		}
+		status, err := unpackStatus(root, name, f, opts.Force)
+		if err != nil {
+			return nil, fmt.Errorf("refusing to unpack %q: %w", f.Path, err)
+		}
+		conflict = conflict || status == UnpackConflict
+		ret = append(ret, UnpackedFile{Path: f.Path, Status: status})
+	}
+	if conflict {
+		return ret, ErrUnpackConflict
+	}
+	if opts.DryRun {
+		return ret, nil
+	}
+
+	for i, f := range files {
+		if s := ret[i].Status; s != UnpackCreated && s != UnpackUpdated {
+			continue
+		}
+		if err := writeFileAtomic(root, filepath.FromSlash(f.Path), []byte(f.Content)); err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+// writeFileAtomic replaces name below root with content through a temporary
+// file in the same directory, keeping the mode of an existing file.
+func writeFileAtomic(root *os.Root, name string, content []byte) error {
+	if err := root.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := root.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp := fmt.Sprintf("%s.catter-%d.tmp", name, time.Now().UnixNano())
+	f, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = root.Rename(tmp, name)
+	}
+	if err != nil {
+		_ = root.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func unpackStatus(root *os.Root, name string, f BundleFile, force bool) (string, error) {
+	if f.Outline {
+		return UnpackOutline, nil
+	}
+	if f.Transformed() {
+		if contentHash([]byte(f.Content)) == f.Hash {
+			return UnpackNotEdited, nil
+		}
+		return UnpackTransformed, nil
+	}
+	existing, err := root.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return UnpackCreated, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if string(existing) == f.Content {
+		return UnpackUnchanged, nil
+	}
+	if f.Hash == "" {
+		return UnpackUpdated, nil
+	}
+	// The bundled content of a redacted or truncated file differs from the
+	// file on disk; writing it back would lose the original, even with
+	// force. Bundles without source hashes only tell so for unedited files.
+	if contentHash([]byte(f.Content)) == f.Hash {
+		return UnpackNotEdited, nil
+	}
+	source := f.SourceHash
+	if source == "" {
+		source = f.Hash
+	}
+	if !force && contentHash(existing) != source {
+		return UnpackConflict, nil
+	}
+	return UnpackUpdated, nil
+}
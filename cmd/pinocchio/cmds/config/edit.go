@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+type EditCommand struct {
+	*cmds.CommandDescription
+}
+
+type EditSettings struct {
+	Layer string `glazed:"layer"`
+	File  string `glazed:"file"`
+}
+
+var _ cmds.BareCommand = (*EditCommand)(nil)
+
+func NewEditCommand() (*EditCommand, error) {
+	return &EditCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"edit",
+			cmds.WithShort("Open a config layer in $EDITOR and validate it"),
+			cmds.WithLong(`Open a copy of a config layer in $VISUAL or $EDITOR and validate it on save.
+
+The file is only replaced when the edited copy is valid. When it is not, the
+problems are printed and the copy is kept so nothing typed is lost.
+
+Examples:
+  pinocchio config edit
+  pinocchio config edit --layer repo-override
+  EDITOR=nano pinocchio config edit --file ./ci.yaml
+`),
+			cmds.WithFlags(
+				fields.New(
+					"layer",
+					fields.TypeString,
+					fields.WithDefault(layerUser),
+					fields.WithHelp("Config layer to edit: home, user, repo, repo-override, cwd, cwd-override"),
+				),
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config file to edit instead of a layer"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *EditCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &EditSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config edit settings: %w", err)
+	}
+	target, err := resolveTargetFile(ctx, s.File, s.Layer, nil)
+	if err != nil {
+		return err
+	}
+	original, err := readConfigFile(target)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "pinocchio-config-*"+filepath.Ext(target))
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runEditor(ctx, tmpPath); err != nil {
+		return err
+	}
+	edited, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return err
+	}
+	if string(edited) == string(original) {
+		_ = os.Remove(tmpPath)
+		fmt.Printf("%s unchanged\n", target)
+		return nil
+	}
+
+	findings := configdoc.ValidateDocument(target, edited)
+	for _, finding := range findings {
+		fmt.Println(finding.String())
+	}
+	if configdoc.HasErrors(findings) {
+		return fmt.Errorf("%s not updated; edited copy kept at %s", target, tmpPath)
+	}
+	if err := writeConfigFile(target, edited); err != nil {
+		return err
+	}
+	_ = os.Remove(tmpPath)
+	fmt.Printf("Wrote %s\n", target)
+	return nil
+}
+
+func runEditor(ctx context.Context, path string) error {
+	editor := strings.TrimSpace(os.Getenv("VISUAL"))
+	if editor == "" {
+		editor = strings.TrimSpace(os.Getenv("EDITOR"))
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	parts := strings.Fields(editor)
+	cmd := exec.CommandContext(ctx, parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+	return nil
+}
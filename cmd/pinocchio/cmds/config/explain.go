@@ -0,0 +1,168 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+type ExplainCommand struct {
+	*cmds.CommandDescription
+}
+
+type ExplainSettings struct {
+	Path string `glazed:"path"`
+}
+
+var _ cmds.GlazeCommand = (*ExplainCommand)(nil)
+
+func NewExplainCommand() (*ExplainCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExplainCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"explain",
+			cmds.WithShort("Show which config layer sets a value"),
+			cmds.WithLong(`Show the effective config value at a path and every config file that set it.
+
+Config files are resolved in the usual order (system, user, repo, cwd,
+--config-file). With a path, one row is printed per file that sets the path,
+lowest precedence first, followed by the effective value. Without a path,
+one row is printed per known config path with its winning file.
+
+Paths are dotted; quote keys that contain dots in brackets and index lists
+with [N]. API keys and OAuth secrets are redacted.
+
+Examples:
+  pinocchio config explain
+  pinocchio config explain profile.active
+  pinocchio config explain profiles.assistant.inference_settings.chat.engine
+  pinocchio config explain 'profiles.assistant.extensions["pinocchio.oauth@v1"]' --output yaml
+`),
+			cmds.WithArguments(
+				fields.New(
+					"path",
+					fields.TypeString,
+					fields.WithHelp("Config path to explain; omitted lists every path set by a config file"),
+					fields.WithDefault(""),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *ExplainCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &ExplainSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config explain settings: %w", err)
+	}
+	resolved, err := loadResolvedDocuments(parsedLayers)
+	if err != nil {
+		return err
+	}
+
+	if path := strings.TrimSpace(s.Path); path != "" {
+		explanation, err := resolved.ExplainPath(path)
+		if err != nil {
+			return err
+		}
+		for _, row := range explainRows(explanation) {
+			if err := gp.AddRow(ctx, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	paths := make([]string, 0, len(resolved.Explain.ByPath))
+	for path := range resolved.Explain.ByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		explanation, err := resolved.ExplainPath(path)
+		if err != nil {
+			log.Debug().Err(err).Str("path", path).Msg("skip unexplainable config path")
+			continue
+		}
+		winner := explanation.Winner()
+		if winner == nil {
+			continue
+		}
+		if err := gp.AddRow(ctx, types.NewRow(
+			types.MRP("path", explanation.Path),
+			types.MRP("value", explanation.Value),
+			types.MRP("layer", string(winner.File.Layer)),
+			types.MRP("file", winner.File.Path),
+			types.MRP("line", winner.Line),
+			types.MRP("operation", string(winner.Operation)),
+			types.MRP("files", len(explanation.Contributions)),
+		)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explainRows renders one row per contributing file followed by the
+// effective value.
+func explainRows(explanation *configdoc.PathExplanation) []types.Row {
+	rows := make([]types.Row, 0, len(explanation.Contributions)+1)
+	winner := explanation.Winner()
+	for i := range explanation.Contributions {
+		contribution := &explanation.Contributions[i]
+		rows = append(rows, types.NewRow(
+			types.MRP("stage", "layer"),
+			types.MRP("path", explanation.Path),
+			types.MRP("layer", string(contribution.File.Layer)),
+			types.MRP("source", contribution.File.SourceName),
+			types.MRP("file", contribution.File.Path),
+			types.MRP("line", contribution.Line),
+			types.MRP("operation", string(contribution.Operation)),
+			types.MRP("value", contribution.Value),
+			types.MRP("winner", contribution == winner),
+		))
+	}
+	effective := types.NewRow(
+		types.MRP("stage", "effective"),
+		types.MRP("path", explanation.Path),
+		types.MRP("layer", ""),
+		types.MRP("source", ""),
+		types.MRP("file", ""),
+		types.MRP("line", 0),
+		types.MRP("operation", ""),
+		types.MRP("value", explanation.Value),
+		types.MRP("winner", false),
+	)
+	if !explanation.Found {
+		effective.Set("value", "<unset>")
+	}
+	return append(rows, effective)
+}
+
+func loadResolvedDocuments(parsedLayers *values.Values) (*configdoc.ResolvedDocuments, error) {
+	configFiles, err := profilebootstrap.ResolveCLIConfigFilesResolved(parsedLayers)
+	if err != nil {
+		return nil, fmt.Errorf("resolve config files: %w", err)
+	}
+	resolved, err := configdoc.LoadResolvedDocuments(configFiles.Files)
+	if err != nil {
+		return nil, fmt.Errorf("load config files: %w", err)
+	}
+	return resolved, nil
+}
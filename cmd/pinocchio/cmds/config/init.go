@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/charmbracelet/huh"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	"github.com/mattn/go-isatty"
+)
+
+type InitCommand struct {
+	*cmds.CommandDescription
+}
+
+type InitSettings struct {
+	Layer          string   `glazed:"layer"`
+	File           string   `glazed:"file"`
+	ProfileSlug    string   `glazed:"profile-slug"`
+	APIType        string   `glazed:"api-type"`
+	Engine         string   `glazed:"engine"`
+	Registries     []string `glazed:"registry"`
+	NonInteractive bool     `glazed:"non-interactive"`
+	Force          bool     `glazed:"force"`
+	PrintOnly      bool     `glazed:"print"`
+}
+
+var _ cmds.BareCommand = (*InitCommand)(nil)
+
+var initAPITypes = []string{"openai", "openai-responses", "claude", "gemini", "ollama"}
+
+func NewInitCommand() (*InitCommand, error) {
+	return &InitCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"init",
+			cmds.WithShort("Write a commented starter config file"),
+			cmds.WithLong(`Write a commented starter config file for one config layer.
+
+On a terminal the profile slug, API type and model are asked for
+interactively; pass --non-interactive (or pipe stdin) to take them from the
+flags. The generated file is validated before it is written, and an existing
+file is only replaced with --force.
+
+Layers: home, user, repo, repo-override, cwd, cwd-override. The repo and cwd
+layers write .pinocchio.yml, which is meant to be committed; keep API keys in
+the matching -override layer (.pinocchio.override.yml) or the environment.
+
+Examples:
+  pinocchio config init
+  pinocchio config init --layer repo --profile-slug assistant --engine gpt-5-mini --non-interactive
+  pinocchio config init --layer cwd-override --api-type claude --engine claude-sonnet-4-5
+  pinocchio config init --print --non-interactive
+`),
+			cmds.WithFlags(
+				fields.New(
+					"layer",
+					fields.TypeString,
+					fields.WithDefault(layerUser),
+					fields.WithHelp("Config layer to create: home, user, repo, repo-override, cwd, cwd-override"),
+				),
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config file to create instead of a layer"),
+				),
+				fields.New(
+					"profile-slug",
+					fields.TypeString,
+					fields.WithDefault("assistant"),
+					fields.WithHelp("Slug of the inline profile to create and activate"),
+				),
+				fields.New(
+					"api-type",
+					fields.TypeString,
+					fields.WithDefault("openai"),
+					fields.WithHelp("Chat API type of the inline profile"),
+				),
+				fields.New(
+					"engine",
+					fields.TypeString,
+					fields.WithDefault("gpt-5-mini"),
+					fields.WithHelp("Chat model of the inline profile"),
+				),
+				fields.New(
+					"registry",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("Profile registry to import (repeatable)"),
+				),
+				fields.New(
+					"non-interactive",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Do not prompt; take every answer from the flags"),
+				),
+				fields.New(
+					"force",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Replace an existing config file"),
+				),
+				fields.New(
+					"print",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Print the generated config instead of writing it"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *InitCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &InitSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config init settings: %w", err)
+	}
+	target := strings.TrimSpace(s.File)
+	if target == "" {
+		var err error
+		target, err = layerFilePath(ctx, s.Layer)
+		if err != nil {
+			return err
+		}
+	}
+	if !s.PrintOnly && !s.Force {
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s already exists; use --force to replace it or pinocchio config set to edit it", target)
+		}
+	}
+
+	if !s.NonInteractive && isatty.IsTerminal(os.Stdin.Fd()) {
+		if err := s.prompt(); err != nil {
+			return err
+		}
+	}
+
+	data, err := renderInitTemplate(s, filepath.Base(target))
+	if err != nil {
+		return err
+	}
+	if s.PrintOnly {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := writeValidatedConfigFile(target, data); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", target)
+	return nil
+}
+
+func (s *InitSettings) prompt() error {
+	registries := strings.Join(s.Registries, ", ")
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Profile slug").
+				Description("Inline profile created in this file and set as profile.active").
+				Value(&s.ProfileSlug).
+				Validate(func(v string) error {
+					if strings.TrimSpace(v) == "" {
+						return fmt.Errorf("profile slug is required")
+					}
+					return nil
+				}),
+			huh.NewSelect[string]().
+				Title("API type").
+				Options(huh.NewOptions(initAPITypes...)...).
+				Value(&s.APIType),
+			huh.NewInput().
+				Title("Model").
+				Value(&s.Engine),
+			huh.NewInput().
+				Title("Profile registries").
+				Description("Comma-separated registry files to import; leave empty for none").
+				Value(&registries),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return fmt.Errorf("config init prompt: %w", err)
+	}
+	s.Registries = nil
+	for _, registry := range strings.Split(registries, ",") {
+		if registry = strings.TrimSpace(registry); registry != "" {
+			s.Registries = append(s.Registries, registry)
+		}
+	}
+	return nil
+}
+
+var initTemplate = template.Must(template.New("config").Parse(`# Pinocchio config ({{ .FileName }}).
+#
+# Layers, lowest precedence first: /etc/pinocchio/config.yaml,
+# ~/.pinocchio/config.yaml, ${XDG_CONFIG_HOME}/pinocchio/config.yaml,
+# .pinocchio.yml and .pinocchio.override.yml at the git root, then in the
+# current directory, then --config-file.
+#
+# Inspect the merged result with "pinocchio config explain" and check it
+# with "pinocchio config validate".
+
+profile:
+  # Profile used when --profile is not given.
+  active: {{ .ProfileSlug }}
+{{- if .Registries }}
+  # Engine profile registries (YAML or SQLite), searched in order.
+  registries:
+{{- range .Registries }}
+    - {{ . }}
+{{- end }}
+{{- else }}
+  # Engine profile registries (YAML or SQLite), searched in order.
+  # registries:
+  #   - ~/.config/pinocchio/profiles.yaml
+{{- end }}
+
+profiles:
+  {{ .ProfileSlug }}:
+    inference_settings:
+      chat:
+        api_type: {{ .APIType }}
+        engine: {{ .Engine }}
+{{- if .Committed }}
+      # This file is meant to be committed. Put API keys in
+      # .pinocchio.override.yml or the environment, not here.
+{{- else }}
+      # API keys can live here, but environment variables such as
+      # OPENAI_API_KEY keep them out of config files.
+      # api:
+      #   api_keys:
+      #     openai-api-key: sk-...
+{{- end }}
+
+# Extra prompt repositories loaded as commands.
+# app:
+#   repositories:
+#     - ~/prompts
+`))
+
+func renderInitTemplate(s *InitSettings, fileName string) ([]byte, error) {
+	slug := strings.TrimSpace(s.ProfileSlug)
+	if slug == "" {
+		return nil, fmt.Errorf("--profile-slug is required")
+	}
+	var buf bytes.Buffer
+	err := initTemplate.Execute(&buf, map[string]any{
+		"FileName":    fileName,
+		"ProfileSlug": slug,
+		"APIType":     strings.TrimSpace(s.APIType),
+		"Engine":      strings.TrimSpace(s.Engine),
+		"Registries":  s.Registries,
+		"Committed":   fileName == configdoc.LocalOverrideFileName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render config template: %w", err)
+	}
+	findings := configdoc.ValidateDocument(fileName, buf.Bytes())
+	if configdoc.HasErrors(findings) {
+		return nil, fmt.Errorf("generated config is invalid: %s", findings[0].String())
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+// Writable config layers, in precedence order. They name the files of the
+// standard config plan so set, unset, init and edit can target a layer even
+// when its file does not exist yet.
+const (
+	layerUser         = "user"
+	layerHome         = "home"
+	layerRepo         = "repo"
+	layerRepoOverride = "repo-override"
+	layerCWD          = "cwd"
+	layerCWDOverride  = "cwd-override"
+)
+
+var layerNames = []string{layerHome, layerUser, layerRepo, layerRepoOverride, layerCWD, layerCWDOverride}
+
+var (
+	userConfigDirFunc = os.UserConfigDir
+	userHomeDirFunc   = os.UserHomeDir
+	getwdFunc         = os.Getwd
+	gitRootFunc       = gitRoot
+)
+
+// layerFilePath returns the config file that backs layer.
+func layerFilePath(ctx context.Context, layer string) (string, error) {
+	switch strings.TrimSpace(layer) {
+	case layerUser, "":
+		dir, err := userConfigDirFunc()
+		if err != nil {
+			return "", fmt.Errorf("resolve user config dir: %w", err)
+		}
+		return filepath.Join(dir, "pinocchio", "config.yaml"), nil
+	case layerHome:
+		home, err := userHomeDirFunc()
+		if err != nil {
+			return "", fmt.Errorf("resolve home dir: %w", err)
+		}
+		return filepath.Join(home, ".pinocchio", "config.yaml"), nil
+	case layerRepo, layerRepoOverride:
+		root, err := gitRootFunc(ctx)
+		if err != nil {
+			return "", fmt.Errorf("layer %s requires a git repository: %w", layer, err)
+		}
+		return filepath.Join(root, layerFileName(layer)), nil
+	case layerCWD, layerCWDOverride:
+		cwd, err := getwdFunc()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(cwd, layerFileName(layer)), nil
+	default:
+		return "", fmt.Errorf("unknown config layer %q (expected one of %s)", layer, strings.Join(layerNames, ", "))
+	}
+}
+
+func layerFileName(layer string) string {
+	if strings.HasSuffix(layer, "-override") {
+		return configdoc.LocalProjectOverrideFileName
+	}
+	return configdoc.LocalOverrideFileName
+}
+
+// defaultTargetFile picks the file a config edit goes to: the file that
+// currently wins for the path, so the edit takes effect, or the user config
+// when no writable layer sets it.
+func defaultTargetFile(ctx context.Context, explanation *configdoc.PathExplanation) (string, error) {
+	if winner := explanation.Winner(); winner != nil && winner.File.Layer != glazedconfig.LayerSystem {
+		return winner.File.Path, nil
+	}
+	return layerFilePath(ctx, layerUser)
+}
+
+// resolveTargetFile applies --file and --layer before falling back to
+// defaultTargetFile.
+func resolveTargetFile(ctx context.Context, file, layer string, explanation *configdoc.PathExplanation) (string, error) {
+	if file = strings.TrimSpace(file); file != "" {
+		return filepath.Abs(file)
+	}
+	if layer = strings.TrimSpace(layer); layer != "" {
+		return layerFilePath(ctx, layer)
+	}
+	return defaultTargetFile(ctx, explanation)
+}
+
+func gitRoot(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	root := strings.TrimSpace(string(out))
+	if root == "" {
+		return "", fmt.Errorf("git rev-parse returned an empty worktree root")
+	}
+	return root, nil
+}
+
+// writeConfigFile writes data to path, keeping the mode of an existing file
+// and creating parent directories for a new one.
+func writeConfigFile(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+func readConfigFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	"github.com/stretchr/testify/require"
+)
+
+func stubLayerDirs(t *testing.T, gitRoot string) (string, string, string) {
+	t.Helper()
+	base := t.TempDir()
+	xdg, home, cwd := filepath.Join(base, "xdg"), filepath.Join(base, "home"), filepath.Join(base, "cwd")
+	prevConfig, prevHome, prevWd, prevGit := userConfigDirFunc, userHomeDirFunc, getwdFunc, gitRootFunc
+	t.Cleanup(func() {
+		userConfigDirFunc, userHomeDirFunc, getwdFunc, gitRootFunc = prevConfig, prevHome, prevWd, prevGit
+	})
+	userConfigDirFunc = func() (string, error) { return xdg, nil }
+	userHomeDirFunc = func() (string, error) { return home, nil }
+	getwdFunc = func() (string, error) { return cwd, nil }
+	gitRootFunc = func(context.Context) (string, error) {
+		if gitRoot == "" {
+			return "", errors.New("not a git repository")
+		}
+		return gitRoot, nil
+	}
+	return xdg, home, cwd
+}
+
+func TestLayerFilePath(t *testing.T) {
+	xdg, home, cwd := stubLayerDirs(t, "/src/project")
+	ctx := context.Background()
+
+	cases := map[string]string{
+		layerUser:         filepath.Join(xdg, "pinocchio", "config.yaml"),
+		layerHome:         filepath.Join(home, ".pinocchio", "config.yaml"),
+		layerRepo:         filepath.Join("/src/project", ".pinocchio.yml"),
+		layerRepoOverride: filepath.Join("/src/project", ".pinocchio.override.yml"),
+		layerCWD:          filepath.Join(cwd, ".pinocchio.yml"),
+		layerCWDOverride:  filepath.Join(cwd, ".pinocchio.override.yml"),
+	}
+	for layer, want := range cases {
+		got, err := layerFilePath(ctx, layer)
+		require.NoError(t, err, layer)
+		require.Equal(t, want, got, layer)
+	}
+
+	_, err := layerFilePath(ctx, "global")
+	require.ErrorContains(t, err, "unknown config layer")
+}
+
+func TestLayerFilePathRepoRequiresGit(t *testing.T) {
+	stubLayerDirs(t, "")
+	_, err := layerFilePath(context.Background(), layerRepo)
+	require.ErrorContains(t, err, "requires a git repository")
+}
+
+func TestDefaultTargetFileFollowsWinningLayer(t *testing.T) {
+	xdg, _, _ := stubLayerDirs(t, "")
+	ctx := context.Background()
+
+	got, err := defaultTargetFile(ctx, &configdoc.PathExplanation{})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(xdg, "pinocchio", "config.yaml"), got)
+
+	got, err = defaultTargetFile(ctx, &configdoc.PathExplanation{Contributions: []configdoc.PathContribution{
+		{File: glazedconfig.ResolvedConfigFile{Path: "/home/me/.pinocchio/config.yaml", Layer: glazedconfig.LayerUser}},
+		{File: glazedconfig.ResolvedConfigFile{Path: "/src/project/.pinocchio.yml", Layer: glazedconfig.LayerRepo}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, "/src/project/.pinocchio.yml", got)
+
+	got, err = defaultTargetFile(ctx, &configdoc.PathExplanation{Contributions: []configdoc.PathContribution{
+		{File: glazedconfig.ResolvedConfigFile{Path: "/etc/pinocchio/config.yaml", Layer: glazedconfig.LayerSystem}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(xdg, "pinocchio", "config.yaml"), got)
+}
+
+func TestWriteConfigFileKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	require.NoError(t, writeConfigFile(path, []byte("profile:\n  active: a\n")))
+	require.NoError(t, os.Chmod(path, 0o600))
+	require.NoError(t, writeConfigFile(path, []byte("profile:\n  active: b\n")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "profile:\n  active: b\n", string(data))
+}
+
+func TestRenderInitTemplateIsValid(t *testing.T) {
+	for _, fileName := range []string{"config.yaml", ".pinocchio.yml", ".pinocchio.override.yml"} {
+		data, err := renderInitTemplate(&InitSettings{
+			ProfileSlug: "assistant",
+			APIType:     "openai",
+			Engine:      "gpt-5-mini",
+			Registries:  []string{"~/.config/pinocchio/profiles.yaml"},
+		}, fileName)
+		require.NoError(t, err, fileName)
+
+		doc, err := configdoc.DecodeDocument(data)
+		require.NoError(t, err, fileName)
+		require.Equal(t, "assistant", doc.Profile.Active)
+		require.Equal(t, []string{"~/.config/pinocchio/profiles.yaml"}, doc.Profile.Registries)
+		require.Contains(t, doc.Profiles, "assistant")
+	}
+
+	_, err := renderInitTemplate(&InitSettings{}, "config.yaml")
+	require.ErrorContains(t, err, "--profile-slug")
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func NewConfigCommand() (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:   "config",
+		Short: "Explain, validate and edit the layered Pinocchio config files",
+	}
+
+	explainCmd, err := NewExplainCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraExplainCmd, err := cli.BuildCobraCommand(explainCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraExplainCmd)
+
+	validateCmd, err := NewValidateCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraValidateCmd, err := cli.BuildCobraCommand(validateCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraValidateCmd)
+
+	initCmd, err := NewInitCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraInitCmd, err := cli.BuildCobraCommand(initCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraInitCmd)
+
+	setCmd, err := NewSetCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraSetCmd, err := cli.BuildCobraCommand(setCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraSetCmd)
+
+	unsetCmd, err := NewUnsetCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraUnsetCmd, err := cli.BuildCobraCommand(unsetCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraUnsetCmd)
+
+	editCmd, err := NewEditCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraEditCmd, err := cli.BuildCobraCommand(editCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraEditCmd)
+
+	return root, nil
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+type SetCommand struct {
+	*cmds.CommandDescription
+}
+
+type SetSettings struct {
+	Path  string `glazed:"path"`
+	Value string `glazed:"value"`
+	Layer string `glazed:"layer"`
+	File  string `glazed:"file"`
+}
+
+var _ cmds.GlazeCommand = (*SetCommand)(nil)
+
+func NewSetCommand() (*SetCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"set",
+			cmds.WithShort("Set a config value in the layer that owns it"),
+			cmds.WithLong(`Set a config value, preserving the comments and layout of the file.
+
+By default the value is written to the file that currently wins for the path,
+so the change takes effect; when no file sets the path it goes to the user
+config (${XDG_CONFIG_HOME}/pinocchio/config.yaml). Use --layer or --file to
+pick the file explicitly. The edited file is validated before it is written.
+
+The value is parsed as YAML: true, 3 and "[a, b]" keep their types, anything
+else is stored as a string.
+
+Layers: home, user, repo, repo-override, cwd, cwd-override.
+
+Examples:
+  pinocchio config set profile.active assistant
+  pinocchio config set profiles.assistant.inference_settings.chat.engine gpt-5-mini --layer repo
+  pinocchio config set profile.registries '[~/.config/pinocchio/profiles.yaml]'
+  pinocchio config set app.repositories[0] ~/prompts --file ./ci.yaml
+`),
+			cmds.WithFlags(
+				fields.New(
+					"layer",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config layer to write: home, user, repo, repo-override, cwd, cwd-override"),
+				),
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config file to write instead of a layer"),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"path",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Config path, for example profiles.assistant.inference_settings.chat.engine"),
+				),
+				fields.New(
+					"value",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Value to set, parsed as YAML"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *SetCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &SetSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config set settings: %w", err)
+	}
+	if s.File != "" && s.Layer != "" {
+		return fmt.Errorf("--file and --layer are mutually exclusive")
+	}
+	resolved, err := loadResolvedDocuments(parsedLayers)
+	if err != nil {
+		return err
+	}
+	before, err := resolved.ExplainPath(s.Path)
+	if err != nil {
+		return err
+	}
+	target, err := resolveTargetFile(ctx, s.File, s.Layer, before)
+	if err != nil {
+		return err
+	}
+
+	data, err := readConfigFile(target)
+	if err != nil {
+		return err
+	}
+	edited, err := configdoc.SetPath(data, s.Path, s.Value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", target, err)
+	}
+	if err := writeValidatedConfigFile(target, edited); err != nil {
+		return err
+	}
+
+	row, err := editResultRow(parsedLayers, target, before)
+	if err != nil {
+		return err
+	}
+	return gp.AddRow(ctx, row)
+}
+
+// writeValidatedConfigFile refuses to write an edit that would leave the file
+// invalid.
+func writeValidatedConfigFile(path string, data []byte) error {
+	findings := configdoc.ValidateDocument(path, data)
+	if configdoc.HasErrors(findings) {
+		lines := make([]string, 0, len(findings))
+		for _, finding := range findings {
+			lines = append(lines, finding.String())
+		}
+		return fmt.Errorf("refusing to write invalid config:\n%s", strings.Join(lines, "\n"))
+	}
+	for _, finding := range findings {
+		log.Warn().Str("file", finding.File).Int("line", finding.Line).Str("path", finding.Path).Msg(finding.Message)
+	}
+	return writeConfigFile(path, data)
+}
+
+// editResultRow reloads the config stack after an edit of target and reports
+// the value before and after, plus what is now effective. effective_file
+// differs from file when a higher-precedence layer still shadows the edit.
+func editResultRow(parsedLayers *values.Values, target string, before *configdoc.PathExplanation) (types.Row, error) {
+	resolved, err := loadResolvedDocuments(parsedLayers)
+	if err != nil {
+		return nil, err
+	}
+	after, err := resolved.ExplainPath(before.Path)
+	if err != nil {
+		return nil, err
+	}
+	effective := any("<unset>")
+	if after.Found {
+		effective = after.Value
+	}
+	effectiveFile := ""
+	if winner := after.Winner(); winner != nil {
+		effectiveFile = winner.File.Path
+	}
+	return types.NewRow(
+		types.MRP("file", target),
+		types.MRP("path", before.Path),
+		types.MRP("previous", contributionValue(before, target)),
+		types.MRP("value", contributionValue(after, target)),
+		types.MRP("effective", effective),
+		types.MRP("effective_file", effectiveFile),
+	), nil
+}
+
+func contributionValue(explanation *configdoc.PathExplanation, file string) any {
+	for _, contribution := range explanation.Contributions {
+		if contribution.File.Path == file {
+			return contribution.Value
+		}
+	}
+	return "<unset>"
+}
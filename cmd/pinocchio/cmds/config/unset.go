@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+type UnsetCommand struct {
+	*cmds.CommandDescription
+}
+
+type UnsetSettings struct {
+	Path      string `glazed:"path"`
+	Layer     string `glazed:"layer"`
+	File      string `glazed:"file"`
+	AllLayers bool   `glazed:"all-layers"`
+}
+
+var _ cmds.GlazeCommand = (*UnsetCommand)(nil)
+
+func NewUnsetCommand() (*UnsetCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnsetCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"unset",
+			cmds.WithShort("Remove a config value from the layer that owns it"),
+			cmds.WithLong(`Remove a config value, preserving the comments and layout of the file.
+
+By default the value is removed from the file that currently wins for the
+path, which may reveal a value from a lower layer; the effective column shows
+what applies afterwards. --all-layers removes it from every file that sets
+it. Mappings left empty are removed as well.
+
+Examples:
+  pinocchio config unset profile.active
+  pinocchio config unset profiles.scratch --layer cwd-override
+  pinocchio config unset profiles.assistant.inference_settings.chat.engine --all-layers
+`),
+			cmds.WithFlags(
+				fields.New(
+					"layer",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config layer to edit: home, user, repo, repo-override, cwd, cwd-override"),
+				),
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Config file to edit instead of a layer"),
+				),
+				fields.New(
+					"all-layers",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Remove the path from every config file that sets it"),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"path",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Config path to remove"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *UnsetCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &UnsetSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config unset settings: %w", err)
+	}
+	if s.AllLayers && (s.File != "" || s.Layer != "") {
+		return fmt.Errorf("--all-layers cannot be combined with --file or --layer")
+	}
+	if s.File != "" && s.Layer != "" {
+		return fmt.Errorf("--file and --layer are mutually exclusive")
+	}
+	resolved, err := loadResolvedDocuments(parsedLayers)
+	if err != nil {
+		return err
+	}
+	before, err := resolved.ExplainPath(s.Path)
+	if err != nil {
+		return err
+	}
+
+	targets := []string{}
+	if s.AllLayers {
+		for _, contribution := range before.Contributions {
+			targets = append(targets, contribution.File.Path)
+		}
+	} else {
+		target, err := resolveTargetFile(ctx, s.File, s.Layer, before)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("%s is not set in any config file", before.Path)
+	}
+
+	for _, target := range targets {
+		data, err := readConfigFile(target)
+		if err != nil {
+			return err
+		}
+		edited, removed, err := configdoc.UnsetPath(data, s.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", target, err)
+		}
+		if !removed {
+			return fmt.Errorf("%s is not set in %s", before.Path, target)
+		}
+		if err := writeValidatedConfigFile(target, edited); err != nil {
+			return err
+		}
+	}
+
+	for _, target := range targets {
+		row, err := editResultRow(parsedLayers, target, before)
+		if err != nil {
+			return err
+		}
+		if err := gp.AddRow(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+)
+
+type ValidateCommand struct {
+	*cmds.CommandDescription
+}
+
+type ValidateSettings struct {
+	NoRegistries bool `glazed:"no-registries"`
+}
+
+var _ cmds.BareCommand = (*ValidateCommand)(nil)
+
+func NewValidateCommand() (*ValidateCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := geppettosections.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ValidateCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"validate",
+			cmds.WithShort("Check every resolved config file for errors"),
+			cmds.WithLong(`Check every resolved config file and report all problems with file:line.
+
+Each file is checked on its own for YAML syntax, unknown keys, legacy
+top-level keys and invalid profile blocks. When the files are valid, the
+winning profile.active and every inline profile stack reference are resolved
+against the inline profiles and the configured profile registries.
+
+API keys in a committed .pinocchio.yml are reported as warnings. The command
+exits non-zero when any error is found.
+
+Examples:
+  pinocchio config validate
+  pinocchio config validate --config-file ./ci.yaml
+  pinocchio config validate --no-registries
+`),
+			cmds.WithFlags(
+				fields.New(
+					"no-registries",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Do not open profile registries; only check references to inline profiles"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection, profileSettingsSection),
+		),
+	}, nil
+}
+
+func (c *ValidateCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &ValidateSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode config validate settings: %w", err)
+	}
+	configFiles, err := profilebootstrap.ResolveCLIConfigFilesResolved(parsedLayers)
+	if err != nil {
+		return fmt.Errorf("resolve config files: %w", err)
+	}
+
+	var lookup configdoc.ProfileLookup
+	var lookupErr error
+	if !s.NoRegistries {
+		runtime, err := profilebootstrap.ResolveCLIProfileRuntime(ctx, parsedLayers)
+		switch {
+		case err != nil:
+			lookupErr = err
+		case runtime != nil:
+			if runtime.Close != nil {
+				defer runtime.Close()
+			}
+			if reg := runtime.Registry(); reg != nil {
+				lookup = configdoc.NewRegistryProfileLookup(ctx, reg)
+			}
+		}
+	}
+
+	findings := configdoc.ValidateResolvedDocuments(configFiles.Files, lookup)
+	if lookupErr != nil && !configdoc.HasErrors(findings) {
+		findings = append(findings, configdoc.Finding{
+			Severity: configdoc.FindingSeverityError,
+			Message:  fmt.Sprintf("open profile registries: %v", lookupErr),
+		})
+	}
+	return reportFindings(os.Stdout, len(configFiles.Files), findings)
+}
+
+func reportFindings(w io.Writer, files int, findings []configdoc.Finding) error {
+	errorCount := 0
+	for _, finding := range findings {
+		if finding.Severity == configdoc.FindingSeverityError {
+			errorCount++
+		}
+		if _, err := fmt.Fprintln(w, finding.String()); err != nil {
+			return err
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("config validation failed: %d error(s) in %d file(s)", errorCount, files)
+	}
+	_, err := fmt.Fprintf(w, "%d config file(s) OK, %d warning(s)\n", files, len(findings))
+	return err
+}
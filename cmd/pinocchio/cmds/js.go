@@ -14,6 +14,7 @@ import (
 	"github.com/dop251/goja_nodejs/require"
 	geppettobootstrap "github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
 	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
 	geptools "github.com/go-go-golems/geppetto/pkg/inference/tools"
 	gp "github.com/go-go-golems/geppetto/pkg/js/modules/geppetto"
@@ -27,8 +28,13 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
 	gojengine "github.com/go-go-golems/go-go-goja/pkg/engine"
 	agenttools "github.com/go-go-golems/pinocchio/cmd/agents/simple-chat-agent/pkg/tools"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
 	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/js/jstools"
 	pjs "github.com/go-go-golems/pinocchio/pkg/js/modules/pinocchio"
+	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 	"github.com/spf13/cobra"
 )
 
@@ -47,13 +53,16 @@ func NewJSCommand() *cobra.Command {
 }
 
 type JSSettings struct {
-	ScriptPath   string `glazed:"script"`
-	ScriptArg    string `glazed:"script_path"`
-	PrintResult  bool   `glazed:"print-result"`
-	ListGoTools  bool   `glazed:"list-go-tools"`
-	TurnsBackend string `glazed:"turns-backend"`
-	TurnsDSN     string `glazed:"turns-dsn"`
-	TurnsDB      string `glazed:"turns-db"`
+	ScriptPath   string   `glazed:"script"`
+	ScriptArg    string   `glazed:"script_path"`
+	PrintResult  bool     `glazed:"print-result"`
+	ListGoTools  bool     `glazed:"list-go-tools"`
+	TurnsBackend string   `glazed:"turns-backend"`
+	TurnsDSN     string   `glazed:"turns-dsn"`
+	TurnsDB      string   `glazed:"turns-db"`
+	UsageDB      string   `glazed:"usage-db"`
+	ToolModules  []string `glazed:"tools-module"`
+	ToolsTimeout int      `glazed:"tools-timeout-ms"`
 }
 
 type JSCommand struct {
@@ -92,18 +101,29 @@ func newJSCommand() (*JSCommand, error) {
 				fields.WithDefault(false),
 				fields.WithHelp("List built-in Go tools exposed to JS and exit"),
 			),
+			fields.New(
+				"tools-module",
+				fields.TypeStringList,
+				fields.WithHelp("JavaScript module that registers tools and middlewares through require(\"pinocchio/tools\"); repeatable"),
+			),
+			fields.New(
+				"tools-timeout-ms",
+				fields.TypeInteger,
+				fields.WithDefault(0),
+				fields.WithHelp("Timeout for loading each tools module and for every JS tool or middleware call (0 = 10s)"),
+			),
 			fields.New(
 				"turns-backend",
 				fields.TypeChoice,
 				fields.WithDefault(""),
-				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql"),
+				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql", "postgres"),
 				fields.WithHelp("Turn persistence backend; required when turns-dsn is set"),
 			),
 			fields.New(
 				"turns-dsn",
 				fields.TypeString,
 				fields.WithDefault(""),
-				fields.WithHelp("SQLite or MySQL DSN for durable JS turn snapshots; interpreted only by turns-backend"),
+				fields.WithHelp("SQLite, MySQL or PostgreSQL DSN for durable JS turn snapshots; interpreted only by turns-backend"),
 			),
 			fields.New(
 				"turns-db",
@@ -111,6 +131,12 @@ func newJSCommand() (*JSCommand, error) {
 				fields.WithDefault(""),
 				fields.WithHelp("SQLite DB file path for durable Geppetto JS turn snapshots"),
 			),
+			fields.New(
+				"usage-db",
+				fields.TypeString,
+				fields.WithDefault(""),
+				fields.WithHelp("SQLite DB file path for the usage ledger of pinocchio.chat sessions"),
+			),
 		),
 		cmds.WithArguments(
 			fields.New(
@@ -157,7 +183,16 @@ func (c *JSCommand) RunIntoWriter(ctx context.Context, parsed *values.Values, w
 		return err
 	}
 
-	goRegistry, err := buildPinocchioJSToolRegistry()
+	jsTools, err := loadPinocchioJSToolModules(ctx, settings)
+	if err != nil {
+		return err
+	}
+	if jsTools != nil {
+		defer func() {
+			_ = jsTools.Close(context.Background())
+		}()
+	}
+	goRegistry, err := buildPinocchioJSToolRegistry(jsTools)
 	if err != nil {
 		return err
 	}
@@ -198,29 +233,39 @@ func (c *JSCommand) RunIntoWriter(ctx context.Context, parsed *values.Values, w
 			return err
 		}
 	}
-	middlewareDefs, buildDeps, err := buildPinocchioJSMiddlewareRegistry()
+	middlewareDefs, buildDeps, err := buildPinocchioJSMiddlewareRegistry(jsTools)
 	if err != nil {
 		return err
 	}
-	middlewareFactories := buildPinocchioJSMiddlewareFactories(buildDeps)
+	middlewareFactories := buildPinocchioJSMiddlewareFactories(buildDeps, jsTools)
 	turnStore, closeTurnStore, err := openPinocchioJSTurnStore(ctx, settings.TurnsBackend, settings.TurnsDSN, settings.TurnsDB)
 	if err != nil {
 		return err
 	}
 	defer closeTurnStore()
+	usageLedger, closeUsageLedger, err := serverkit.OpenUsageLedger(settings.UsageDB)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeUsageLedger() }()
 
 	scriptDir := filepath.Dir(scriptPath)
 	rt, err := newPinocchioJSRuntime(ctx, pinocchioJSRuntimeOptions{
 		ScriptDir:                scriptDir,
 		DefaultInferenceSettings: runtimeBootstrap.DefaultInferenceSettings,
+		BaseInferenceSettings:    runtimeBootstrap.BaseInferenceSettings,
 		GoToolRegistry:           goRegistry,
 		ProfileRegistry:          runtimeBootstrap.ProfileRegistry,
+		ChatProfileRegistry:      runtimeBootstrap.ChatProfileRegistry,
 		UseDefaultProfileResolve: runtimeBootstrap.UseDefaultProfileResolve,
 		DefaultProfileResolve:    runtimeBootstrap.DefaultProfileResolve,
 		GoMiddlewareFactories:    middlewareFactories,
 		MiddlewareDefinitions:    middlewareDefs,
 		BearerTokenSource:        runtimeBootstrap.BearerTokenSource,
 		TurnStore:                turnStore,
+		ChatTurnStore:            turnStore.ChatStore(),
+		TurnsDBPath:              strings.TrimSpace(settings.TurnsDB),
+		UsageLedger:              usageLedger,
 		Stdout:                   w,
 		Stderr:                   os.Stderr,
 	})
@@ -263,8 +308,12 @@ func resolveJSScriptPath(settings *JSSettings) (string, error) {
 type pinocchioJSRuntimeBootstrap struct {
 	DefaultInferenceSettings *aisettings.InferenceSettings
 	ResolvedEngineSettings   *profilebootstrap.ResolvedCLIEngineSettings
+	BaseInferenceSettings    *aisettings.InferenceSettings
 	BearerTokenSource        credentials.BearerTokenSource
 	ProfileRegistry          gepprofiles.RegistryReader
+	// ChatProfileRegistry is the full registry used by the pinocchio module
+	// to resolve chat session profiles and their runtime extensions.
+	ChatProfileRegistry      gepprofiles.Registry
 	UseDefaultProfileResolve bool
 	DefaultProfileResolve    gepprofiles.ResolveInput
 	Close                    func()
@@ -277,6 +326,7 @@ func resolvePinocchioJSRuntimeBootstrap(ctx context.Context, parsed *values.Valu
 	}
 
 	var profileRegistry gepprofiles.RegistryReader
+	var chatProfileRegistry gepprofiles.Registry
 	var useDefaultProfileResolve bool
 	var defaultProfileResolve gepprofiles.ResolveInput
 	var registryChain *geppettobootstrap.ResolvedProfileRegistryChain
@@ -285,6 +335,7 @@ func resolvePinocchioJSRuntimeBootstrap(ctx context.Context, parsed *values.Valu
 	}
 	if registryChain != nil {
 		profileRegistry = registryChain.Reader
+		chatProfileRegistry = registryChain.Registry
 		useDefaultProfileResolve = registryChain.DefaultProfileResolve.EngineProfileSlug != ""
 		defaultProfileResolve = registryChain.DefaultProfileResolve
 	}
@@ -293,12 +344,23 @@ func resolvePinocchioJSRuntimeBootstrap(ctx context.Context, parsed *values.Valu
 	if err != nil {
 		return nil, err
 	}
+	// The geppetto JS module builds engines from these defaults itself, so
+	// secret references are resolved before they are handed over.
+	defaults, err := secrets.ResolveInferenceSettings(ctx, profilebootstrap.CLISecretResolver(), resolved.FinalInferenceSettings)
+	if err != nil {
+		if resolved.Close != nil {
+			resolved.Close()
+		}
+		return nil, err
+	}
 
 	return &pinocchioJSRuntimeBootstrap{
-		DefaultInferenceSettings: resolved.FinalInferenceSettings,
+		DefaultInferenceSettings: defaults,
 		ResolvedEngineSettings:   resolved,
+		BaseInferenceSettings:    resolved.BaseInferenceSettings,
 		BearerTokenSource:        bearerTokenSource,
 		ProfileRegistry:          profileRegistry,
+		ChatProfileRegistry:      chatProfileRegistry,
 		UseDefaultProfileResolve: useDefaultProfileResolve,
 		DefaultProfileResolve:    defaultProfileResolve,
 		Close:                    resolved.Close,
@@ -308,8 +370,10 @@ func resolvePinocchioJSRuntimeBootstrap(ctx context.Context, parsed *values.Valu
 type pinocchioJSRuntimeOptions struct {
 	ScriptDir                string
 	DefaultInferenceSettings *aisettings.InferenceSettings
+	BaseInferenceSettings    *aisettings.InferenceSettings
 	GoToolRegistry           geptools.ToolRegistry
 	ProfileRegistry          gepprofiles.RegistryReader
+	ChatProfileRegistry      gepprofiles.Registry
 	UseDefaultProfileResolve bool
 	DefaultProfileResolve    gepprofiles.ResolveInput
 	GoMiddlewareFactories    map[string]gp.MiddlewareFactory
@@ -318,8 +382,13 @@ type pinocchioJSRuntimeOptions struct {
 	// native modules but never converted into a JavaScript value.
 	BearerTokenSource credentials.BearerTokenSource
 	TurnStore         gp.TurnStore
-	Stdout            io.Writer
-	Stderr            io.Writer
+	// ChatTurnStore backs chat sessions, turn queries and exports in the
+	// pinocchio module. TurnsDBPath enables minitrace exports.
+	ChatTurnStore chatstore.TurnStore
+	TurnsDBPath   string
+	UsageLedger   usagestore.Ledger
+	Stdout        io.Writer
+	Stderr        io.Writer
 }
 
 func newPinocchioJSRuntime(ctx context.Context, opts pinocchioJSRuntimeOptions) (*gojengine.Runtime, error) {
@@ -363,7 +432,14 @@ func newPinocchioJSRuntime(ctx context.Context, opts pinocchioJSRuntimeOptions)
 	gp.Register(reg, gpOptions)
 	pjs.Register(reg, pjs.Options{
 		DefaultInferenceSettings: opts.DefaultInferenceSettings,
+		BaseInferenceSettings:    opts.BaseInferenceSettings,
 		BearerTokenSource:        opts.BearerTokenSource,
+		SecretResolver:           profilebootstrap.CLISecretResolver(),
+		ProfileRegistry:          opts.ChatProfileRegistry,
+		DefaultProfileResolve:    opts.DefaultProfileResolve,
+		TurnStore:                opts.ChatTurnStore,
+		TurnsDBPath:              opts.TurnsDBPath,
+		UsageLedger:              opts.UsageLedger,
 	})
 	req := reg.Enable(rt.VM)
 	rt.Require = req
@@ -476,17 +552,37 @@ func joinArgs(args []goja.Value) string {
 	return strings.Join(out, " ")
 }
 
-func buildPinocchioJSToolRegistry() (*geptools.InMemoryToolRegistry, error) {
+// loadPinocchioJSToolModules loads the --tools-module scripts into their own
+// sandboxed runtime. It returns nil when no module is given.
+func loadPinocchioJSToolModules(ctx context.Context, settings *JSSettings) (*jstools.Runtime, error) {
+	if len(settings.ToolModules) == 0 {
+		return nil, nil
+	}
+	return jstools.Load(ctx, jstools.Options{
+		Scripts: settings.ToolModules,
+		Timeout: time.Duration(settings.ToolsTimeout) * time.Millisecond,
+	})
+}
+
+func buildPinocchioJSToolRegistry(jsTools *jstools.Runtime) (*geptools.InMemoryToolRegistry, error) {
 	reg := geptools.NewInMemoryToolRegistry()
 	if err := agenttools.RegisterCalculatorTool(reg); err != nil {
 		return nil, err
 	}
+	if jsTools != nil {
+		if err := jsTools.RegisterTools(reg); err != nil {
+			return nil, err
+		}
+	}
 	return reg, nil
 }
 
-func buildPinocchioJSMiddlewareRegistry() (*middlewarecfg.InMemoryDefinitionRegistry, middlewarecfg.BuildDeps, error) {
+func buildPinocchioJSMiddlewareRegistry(jsTools *jstools.Runtime) (*middlewarecfg.InMemoryDefinitionRegistry, middlewarecfg.BuildDeps, error) {
 	registry := middlewarecfg.NewInMemoryDefinitionRegistry()
 	defs := []middlewarecfg.Definition{}
+	if jsTools != nil {
+		defs = append(defs, jsTools.MiddlewareDefinitions()...)
+	}
 	for _, def := range defs {
 		if err := registry.RegisterDefinition(def); err != nil {
 			return nil, middlewarecfg.BuildDeps{}, err
@@ -497,6 +593,15 @@ func buildPinocchioJSMiddlewareRegistry() (*middlewarecfg.InMemoryDefinitionRegi
 	}, nil
 }
 
-func buildPinocchioJSMiddlewareFactories(deps middlewarecfg.BuildDeps) map[string]gp.MiddlewareFactory {
-	return map[string]gp.MiddlewareFactory{}
+func buildPinocchioJSMiddlewareFactories(deps middlewarecfg.BuildDeps, jsTools *jstools.Runtime) map[string]gp.MiddlewareFactory {
+	factories := map[string]gp.MiddlewareFactory{}
+	if jsTools == nil {
+		return factories
+	}
+	for _, name := range jsTools.MiddlewareNames() {
+		factories[name] = func(options map[string]any) (gepmiddleware.Middleware, error) {
+			return jsTools.BuildMiddleware(context.Background(), name, options)
+		}
+	}
+	return factories
 }
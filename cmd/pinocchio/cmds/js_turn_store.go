@@ -99,6 +99,15 @@ func (s *pinocchioJSTurnStore) LoadLatestTurn(ctx context.Context, q gp.TurnStor
 	return &snap, nil
 }
 
+// ChatStore returns the underlying chat turn store, or nil when turn
+// persistence is disabled.
+func (s *pinocchioJSTurnStore) ChatStore() chatstore.TurnStore {
+	if s == nil {
+		return nil
+	}
+	return s.store
+}
+
 func (s *pinocchioJSTurnStore) Close() error {
 	if s == nil || s.store == nil {
 		return nil
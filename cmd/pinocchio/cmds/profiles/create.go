@@ -0,0 +1,269 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/profileedit"
+)
+
+type CreateCommand struct {
+	*cmds.CommandDescription
+}
+
+type CreateSettings struct {
+	Profile     string   `glazed:"profile-slug"`
+	From        string   `glazed:"from"`
+	Registry    string   `glazed:"registry"`
+	File        string   `glazed:"file"`
+	DisplayName string   `glazed:"display-name"`
+	Description string   `glazed:"description"`
+	Stack       []string `glazed:"stack"`
+	Set         []string `glazed:"set"`
+	Force       bool     `glazed:"force"`
+	KeepAPIKeys bool     `glazed:"keep-api-keys"`
+}
+
+var _ cmds.GlazeCommand = (*CreateCommand)(nil)
+
+func NewCreateCommand() (*CreateCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := geppettosections.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"create",
+			cmds.WithShort("Create a Pinocchio engine profile"),
+			cmds.WithLong(`Create a profile in the file that owns its registry.
+
+Profiles in the inline registry (config-inline) are written to the config file
+that holds the winning inline profiles, or to the user config when there are
+none. Profiles in an imported registry are written to its YAML registry file
+under the registry lock; SQLite and other registry sources cannot be edited.
+Without --registry the default registry is used.
+
+--from copies the raw definition of another profile, not its resolved stack.
+OAuth tokens are never copied, and API keys only with --keep-api-keys.
+The new file is validated before it is written.
+
+Examples:
+  pinocchio profiles create scratch --stack default --set inference_settings.chat.engine=gpt-5-mini
+  pinocchio profiles create mini-fast --from workspace/mini --registry workspace
+  pinocchio profiles create review --from assistant --file .pinocchio.override.yml
+`),
+			cmds.WithFlags(
+				fields.New(
+					"from",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Profile slug or registry/profile to copy"),
+				),
+				fields.New(
+					"registry",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Registry to create the profile in; defaults to the default registry"),
+				),
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Registry or config file to write instead of looking up the registry"),
+				),
+				fields.New(
+					"display-name",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Display name of the new profile"),
+				),
+				fields.New(
+					"description",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Description of the new profile"),
+				),
+				fields.New(
+					"stack",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("Profiles to stack on, as profile or registry/profile; replaces the stack of --from"),
+				),
+				fields.New(
+					"set",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("path=value assignments relative to the profile, values parsed as YAML"),
+				),
+				fields.New(
+					"force",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Replace an existing profile with the same slug"),
+				),
+				fields.New(
+					"keep-api-keys",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Copy the API keys of --from instead of leaving them out"),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"profile-slug",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Slug of the new profile"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection, profileSettingsSection),
+		),
+	}, nil
+}
+
+func (c *CreateCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &CreateSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode profiles create settings: %w", err)
+	}
+	assignments, err := parseAssignments(s.Set)
+	if err != nil {
+		return err
+	}
+
+	runtime, err := profilebootstrap.ResolveCLIProfileRuntime(ctx, parsedLayers)
+	if err != nil {
+		return fmt.Errorf("resolve profile runtime: %w", err)
+	}
+	if runtime != nil && runtime.Close != nil {
+		defer runtime.Close()
+	}
+
+	registrySlug := strings.TrimSpace(s.Registry)
+	if registrySlug == "" && s.File == "" && runtime.ProfileRegistryChain != nil {
+		registrySlug = runtime.ProfileRegistryChain.DefaultRegistrySlug.String()
+	}
+	target, err := profileedit.CreateTarget(runtime.Documents, runtime.ProfileSettings.ProfileRegistries, registrySlug, s.File)
+	if err != nil {
+		return err
+	}
+
+	profile := &gepprofiles.EngineProfile{}
+	from := ""
+	if strings.TrimSpace(s.From) != "" {
+		source, ref, err := loadProfileRef(ctx, runtime, s.From)
+		if err != nil {
+			return err
+		}
+		copied := *source
+		profile, from = &copied, ref
+	}
+	if s.DisplayName != "" {
+		profile.DisplayName = s.DisplayName
+	}
+	if s.Description != "" {
+		profile.Description = s.Description
+	}
+	if len(s.Stack) > 0 {
+		profile.Stack, err = parseStackRefs(s.Stack)
+		if err != nil {
+			return err
+		}
+	}
+	definition, err := profileedit.Definition(profile)
+	if err != nil {
+		return err
+	}
+	if from != "" && !s.KeepAPIKeys {
+		profileedit.WithoutAPIKeys(definition)
+	}
+	if err := target.Create(s.Profile, definition, assignments, s.Force); err != nil {
+		return err
+	}
+
+	return gp.AddRow(ctx, types.NewRow(
+		types.MRP("registry", target.Registry),
+		types.MRP("profile", strings.ToLower(strings.TrimSpace(s.Profile))),
+		types.MRP("file", target.Path),
+		types.MRP("kind", string(target.Kind)),
+		types.MRP("from", from),
+	))
+}
+
+// loadProfileRef returns the raw profile a profile or registry/profile
+// reference points at, resolving unqualified references like profiles show.
+func loadProfileRef(ctx context.Context, runtime *profilebootstrap.ResolvedCLIProfileRuntime, ref string) (*gepprofiles.EngineProfile, string, error) {
+	registrySlug, profileSlug, err := resolveProfileRef(ctx, runtime, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	profile, err := runtime.Registry().GetEngineProfile(ctx, gepprofiles.RegistrySlug(registrySlug), gepprofiles.EngineProfileSlug(profileSlug))
+	if err != nil {
+		return nil, "", fmt.Errorf("load profile %s/%s: %w", registrySlug, profileSlug, err)
+	}
+	return profile, registrySlug + "/" + profileSlug, nil
+}
+
+func resolveProfileRef(ctx context.Context, runtime *profilebootstrap.ResolvedCLIProfileRuntime, ref string) (string, string, error) {
+	if runtime == nil || runtime.Registry() == nil {
+		return "", "", fmt.Errorf("no profile registry configured")
+	}
+	report, err := buildReportFromRuntime(ctx, runtime, VerbosityDefault)
+	if err != nil {
+		return "", "", err
+	}
+	return resolveShowTarget(report, "", ref)
+}
+
+func parseStackRefs(refs []string) ([]gepprofiles.EngineProfileRef, error) {
+	ret := make([]gepprofiles.EngineProfileRef, 0, len(refs))
+	for _, raw := range refs {
+		registryRaw, profileRaw, qualified := strings.Cut(strings.TrimSpace(raw), "/")
+		if !qualified {
+			registryRaw, profileRaw = "", registryRaw
+		}
+		ref := gepprofiles.EngineProfileRef{}
+		if registryRaw != "" {
+			registrySlug, err := gepprofiles.ParseRegistrySlug(registryRaw)
+			if err != nil {
+				return nil, fmt.Errorf("stack entry %q: %w", raw, err)
+			}
+			ref.RegistrySlug = registrySlug
+		}
+		profileSlug, err := gepprofiles.ParseEngineProfileSlug(profileRaw)
+		if err != nil {
+			return nil, fmt.Errorf("stack entry %q: %w", raw, err)
+		}
+		ref.EngineProfileSlug = profileSlug
+		ret = append(ret, ref)
+	}
+	return ret, nil
+}
+
+func parseAssignments(raw []string) ([]profileedit.Assignment, error) {
+	ret := make([]profileedit.Assignment, 0, len(raw))
+	for _, item := range raw {
+		assignment, err := profileedit.ParseAssignment(item)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, assignment)
+	}
+	return ret, nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/go-go-golems/glazed/pkg/types"
 	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
 	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 	"github.com/pkg/errors"
 )
 
@@ -285,11 +286,7 @@ func apiKeyStatus(keys map[string]string) []string {
 	sort.Strings(names)
 	statuses := make([]string, 0, len(names))
 	for _, name := range names {
-		status := "empty"
-		if strings.TrimSpace(keys[name]) != "" {
-			status = "present"
-		}
-		statuses = append(statuses, name+"="+status)
+		statuses = append(statuses, name+"="+secrets.Status(keys[name]))
 	}
 	return statuses
 }
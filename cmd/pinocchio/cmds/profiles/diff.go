@@ -0,0 +1,132 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/profileedit"
+)
+
+type DiffCommand struct {
+	*cmds.CommandDescription
+}
+
+type DiffSettings struct {
+	Left  string `glazed:"left"`
+	Right string `glazed:"right"`
+	All   bool   `glazed:"all"`
+}
+
+var _ cmds.GlazeCommand = (*DiffCommand)(nil)
+
+func NewDiffCommand() (*DiffCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := geppettosections.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiffCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"diff",
+			cmds.WithShort("Compare two fully resolved profiles"),
+			cmds.WithLong(`Compare the effective settings of two profiles after their stacks resolve.
+
+Both profiles are resolved like a chat runtime resolves them: the merged
+inference settings of the stack, the merged web-chat runtime (system prompt,
+middlewares, tools) and the merged usage pricing. One row is printed per
+differing leaf path; lists are compared as a whole. API keys are redacted.
+
+Examples:
+  pinocchio profiles diff default mini
+  pinocchio profiles diff workspace/mini other/mini --all --output json
+`),
+			cmds.WithFlags(
+				fields.New(
+					"all",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Include unchanged paths"),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"left",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Profile slug or registry/profile"),
+				),
+				fields.New(
+					"right",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Profile slug or registry/profile"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection, profileSettingsSection),
+		),
+	}, nil
+}
+
+func (c *DiffCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &DiffSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode profiles diff settings: %w", err)
+	}
+	runtime, err := profilebootstrap.ResolveCLIProfileRuntime(ctx, parsedLayers)
+	if err != nil {
+		return fmt.Errorf("resolve profile runtime: %w", err)
+	}
+	if runtime != nil && runtime.Close != nil {
+		defer runtime.Close()
+	}
+
+	left, err := effectiveProfileTree(ctx, runtime, s.Left)
+	if err != nil {
+		return err
+	}
+	right, err := effectiveProfileTree(ctx, runtime, s.Right)
+	if err != nil {
+		return err
+	}
+	for _, entry := range profileedit.Diff(left, right, s.All) {
+		row := types.NewRow(
+			types.MRP("path", entry.Path),
+			types.MRP("left", entry.Left),
+			types.MRP("right", entry.Right),
+			types.MRP("change", string(entry.Change)),
+		)
+		if err := gp.AddRow(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func effectiveProfileTree(ctx context.Context, runtime *profilebootstrap.ResolvedCLIProfileRuntime, ref string) (map[string]any, error) {
+	registrySlug, profileSlug, err := resolveProfileRef(ctx, runtime, ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveProfile(ctx, runtime.Registry(), registrySlug, profileSlug)
+	if err != nil {
+		return nil, fmt.Errorf("resolve profile %s/%s: %w", registrySlug, profileSlug, err)
+	}
+	tree, err := profileedit.EffectiveTree(ctx, runtime.Registry(), resolved)
+	if err != nil {
+		return nil, fmt.Errorf("resolve runtime of profile %s/%s: %w", registrySlug, profileSlug, err)
+	}
+	return tree, nil
+}
@@ -8,7 +8,7 @@ import (
 func NewProfilesCommand() (*cobra.Command, error) {
 	root := &cobra.Command{
 		Use:   "profiles",
-		Short: "Inspect Pinocchio engine profiles",
+		Short: "Inspect and edit Pinocchio engine profiles",
 	}
 
 	listCmd, err := NewListCommand()
@@ -41,5 +41,45 @@ func NewProfilesCommand() (*cobra.Command, error) {
 	}
 	root.AddCommand(cobraDebugCmd)
 
+	createCmd, err := NewCreateCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraCreateCmd, err := cli.BuildCobraCommand(createCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraCreateCmd)
+
+	setCmd, err := NewSetCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraSetCmd, err := cli.BuildCobraCommand(setCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraSetCmd)
+
+	validateCmd, err := NewValidateCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraValidateCmd, err := cli.BuildCobraCommand(validateCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraValidateCmd)
+
+	diffCmd, err := NewDiffCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraDiffCmd, err := cli.BuildCobraCommand(diffCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraDiffCmd)
+
 	return root, nil
 }
@@ -0,0 +1,138 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/profileedit"
+)
+
+type SetCommand struct {
+	*cmds.CommandDescription
+}
+
+type SetSettings struct {
+	Profile     string   `glazed:"profile-ref"`
+	Assignments []string `glazed:"assignments"`
+	Unset       []string `glazed:"unset"`
+}
+
+var _ cmds.GlazeCommand = (*SetCommand)(nil)
+
+func NewSetCommand() (*SetCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := geppettosections.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SetCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"set",
+			cmds.WithShort("Edit fields of a Pinocchio engine profile"),
+			cmds.WithLong(`Set or remove fields of a profile in the file that defines it.
+
+Paths are relative to the profile and values are parsed as YAML. Inline
+profiles are edited in the config file that wins for profiles.<slug>; imported
+profiles in their YAML registry file, under the registry lock. Comments are
+kept, and an edit that leaves the file or the profile's Pinocchio extensions
+invalid is refused.
+
+Examples:
+  pinocchio profiles set mini inference_settings.chat.engine=gpt-5-mini
+  pinocchio profiles set workspace/mini inference_settings.inference.reasoning_effort=low display_name="Mini"
+  pinocchio profiles set mini --unset inference_settings.chat.temperature
+`),
+			cmds.WithFlags(
+				fields.New(
+					"unset",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("Paths to remove from the profile"),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"profile-ref",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Profile slug or registry/profile"),
+				),
+				fields.New(
+					"assignments",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("path=value assignments relative to the profile"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection, profileSettingsSection),
+		),
+	}, nil
+}
+
+func (c *SetCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &SetSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode profiles set settings: %w", err)
+	}
+	assignments, err := parseAssignments(s.Assignments)
+	if err != nil {
+		return err
+	}
+	if len(assignments) == 0 && len(s.Unset) == 0 {
+		return fmt.Errorf("profiles set requires at least one path=value assignment or --unset path")
+	}
+
+	runtime, err := profilebootstrap.ResolveCLIProfileRuntime(ctx, parsedLayers)
+	if err != nil {
+		return fmt.Errorf("resolve profile runtime: %w", err)
+	}
+	if runtime != nil && runtime.Close != nil {
+		defer runtime.Close()
+	}
+	registrySlug, profileSlug, err := resolveProfileRef(ctx, runtime, s.Profile)
+	if err != nil {
+		return err
+	}
+	target, err := profileedit.Locate(runtime.Documents, runtime.ProfileSettings.ProfileRegistries, registrySlug, profileSlug)
+	if err != nil {
+		return err
+	}
+	if err := target.Update(profileSlug, assignments, s.Unset); err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		if err := gp.AddRow(ctx, setResultRow(target, profileSlug, assignment.Path, assignment.Value)); err != nil {
+			return err
+		}
+	}
+	for _, path := range s.Unset {
+		if err := gp.AddRow(ctx, setResultRow(target, profileSlug, path, "<unset>")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setResultRow(target profileedit.Target, profile, path, value string) types.Row {
+	return types.NewRow(
+		types.MRP("registry", target.Registry),
+		types.MRP("profile", profile),
+		types.MRP("file", target.Path),
+		types.MRP("path", path),
+		types.MRP("value", value),
+	)
+}
@@ -0,0 +1,94 @@
+package profiles
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	geppettosections "github.com/go-go-golems/geppetto/pkg/sections"
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	"github.com/go-go-golems/pinocchio/pkg/inference/middlewaredefs"
+	"github.com/go-go-golems/pinocchio/pkg/profileedit"
+)
+
+type ValidateCommand struct {
+	*cmds.CommandDescription
+}
+
+var _ cmds.BareCommand = (*ValidateCommand)(nil)
+
+func NewValidateCommand() (*ValidateCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+	profileSettingsSection, err := geppettosections.NewProfileSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ValidateCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"validate",
+			cmds.WithShort("Check every loaded profile for errors"),
+			cmds.WithLong(`Check every profile of the inline and imported registries.
+
+For each profile the stack is resolved, the Pinocchio extensions it sets
+(pinocchio.oauth@v1, pinocchio.webchat_runtime@v1, pinocchio.usage_pricing@v1)
+are decoded, and every middleware of the effective runtime is resolved against
+its middleware definition and config schema. Unknown pinocchio.* extensions
+are warnings. The command exits non-zero when any error is found.
+
+Examples:
+  pinocchio profiles validate
+  pinocchio profiles validate --profile-registries ./profiles.yaml
+`),
+			cmds.WithSections(commandSettingsSection, profileSettingsSection),
+		),
+	}, nil
+}
+
+func (c *ValidateCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	runtime, err := profilebootstrap.ResolveCLIProfileRuntime(ctx, parsedLayers)
+	if err != nil {
+		return fmt.Errorf("resolve profile runtime: %w", err)
+	}
+	if runtime != nil && runtime.Close != nil {
+		defer runtime.Close()
+	}
+	definitions, err := middlewaredefs.NewRegistry()
+	if err != nil {
+		return fmt.Errorf("create middleware definition registry: %w", err)
+	}
+
+	source := func(registry, profile string) string {
+		target, err := profileedit.Locate(runtime.Documents, runtime.ProfileSettings.ProfileRegistries, registry, profile)
+		if err != nil {
+			return registry
+		}
+		return target.Path
+	}
+	findings, err := profileedit.Validate(ctx, runtime.Registry(), definitions, source)
+	if err != nil {
+		return err
+	}
+
+	errorCount := 0
+	for _, finding := range findings {
+		if finding.Severity == configdoc.FindingSeverityError {
+			errorCount++
+		}
+		if _, err := fmt.Fprintln(os.Stdout, finding.String()); err != nil {
+			return err
+		}
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("profile validation failed: %d error(s)", errorCount)
+	}
+	_, err = fmt.Fprintf(os.Stdout, "profiles OK, %d warning(s)\n", len(findings))
+	return err
+}
@@ -0,0 +1,134 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	glazedcmds "github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	pinocchiocmds "github.com/go-go-golems/pinocchio/pkg/cmds"
+	"gopkg.in/yaml.v3"
+)
+
+type RenderCommand struct {
+	*glazedcmds.CommandDescription
+	commands []glazedcmds.Command
+}
+
+type RenderSettings struct {
+	Command  []string               `glazed:"command"`
+	Vars     map[string]interface{} `glazed:"vars"`
+	VarsFile []string               `glazed:"vars-file"`
+}
+
+var _ glazedcmds.WriterCommand = (*RenderCommand)(nil)
+
+// NewRenderCommand renders the prompts of the repository commands in
+// commands.
+func NewRenderCommand(commands []glazedcmds.Command) (*RenderCommand, error) {
+	return &RenderCommand{
+		CommandDescription: glazedcmds.NewCommandDescription(
+			"render",
+			glazedcmds.WithShort("Render a prompt command's final text without running it"),
+			glazedcmds.WithLong(`Render the system prompt, messages and prompt of a repository command
+with its flag defaults, repository partials and shared variables, and print
+the resulting turn. Nothing is sent to a provider.
+
+Variables are applied in this order, later ones winning: shared variables
+from the repositories' .templates directories, the command's flag and
+argument defaults, --vars-file files, then --vars.
+
+Examples:
+  pinocchio prompts render code review --vars lang:go
+  pinocchio prompts render examples/test --vars-file ./vars.yaml
+`),
+			glazedcmds.WithArguments(
+				fields.New(
+					"command",
+					fields.TypeStringList,
+					fields.WithRequired(true),
+					fields.WithHelp("Command path, as words (code review) or slash separated (code/review)"),
+				),
+			),
+			glazedcmds.WithFlags(
+				fields.New(
+					"vars",
+					fields.TypeKeyValue,
+					fields.WithHelp("Template variables as key:value pairs, or @file.yaml"),
+				),
+				fields.New(
+					"vars-file",
+					fields.TypeStringList,
+					fields.WithDefault([]string{}),
+					fields.WithHelp("YAML or JSON variable files, applied in order before --vars"),
+				),
+			),
+		),
+		commands: commands,
+	}, nil
+}
+
+func (c *RenderCommand) RunIntoWriter(ctx context.Context, parsedValues *values.Values, w io.Writer) error {
+	s := &RenderSettings{}
+	if err := parsedValues.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode prompts render settings: %w", err)
+	}
+	cmd, err := c.find(s.Command)
+	if err != nil {
+		return err
+	}
+
+	vars, err := cmd.GetDefaultsMap()
+	if err != nil {
+		return fmt.Errorf("defaults of %s: %w", cmd.FullPath(), err)
+	}
+	for _, file := range s.VarsFile {
+		fileVars, err := readVarsFile(file)
+		if err != nil {
+			return err
+		}
+		maps.Copy(vars, fileVars)
+	}
+	maps.Copy(vars, s.Vars)
+
+	t, err := cmd.RenderTurn(vars)
+	if err != nil {
+		return fmt.Errorf("render %s: %w", cmd.FullPath(), err)
+	}
+	turns.FprintTurn(w, t)
+	return nil
+}
+
+func (c *RenderCommand) find(words []string) (*pinocchiocmds.PinocchioCommand, error) {
+	want := strings.Trim(strings.Join(words, "/"), "/")
+	for _, cmd := range c.commands {
+		if cmd.Description().FullPath() != want {
+			continue
+		}
+		pc, ok := cmd.(*pinocchiocmds.PinocchioCommand)
+		if !ok {
+			return nil, fmt.Errorf("command %s is not a prompt command", want)
+		}
+		return pc, nil
+	}
+	return nil, fmt.Errorf("no prompt command %s; see pinocchio commands list", want)
+}
+
+func readVarsFile(file string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read vars file: %w", err)
+	}
+	vars := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &vars); err != nil {
+		return nil, fmt.Errorf("parse vars file %s: %w", file, err)
+	}
+	return vars, nil
+}
@@ -0,0 +1,28 @@
+package prompts
+
+import (
+	"github.com/go-go-golems/glazed/pkg/cli"
+	glazedcmds "github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/spf13/cobra"
+)
+
+// NewPromptsCommand groups commands that inspect the prompt commands loaded
+// from the repositories.
+func NewPromptsCommand(commands []glazedcmds.Command) (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:   "prompts",
+		Short: "Inspect prompt commands and their templates",
+	}
+
+	renderCmd, err := NewRenderCommand(commands)
+	if err != nil {
+		return nil, err
+	}
+	cobraRenderCmd, err := cli.BuildCobraCommand(renderCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraRenderCmd)
+
+	return root, nil
+}
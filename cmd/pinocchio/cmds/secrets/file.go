@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"golang.org/x/term"
+)
+
+const secretFileFlagHelp = "Encrypted secret file (default $PINOCCHIO_SECRETS_FILE or ${XDG_CONFIG_HOME}/pinocchio/secrets.enc)"
+
+func secretFilePath(flag string) (string, error) {
+	if path := strings.TrimSpace(flag); path != "" {
+		return path, nil
+	}
+	return secrets.DefaultSecretFilePath()
+}
+
+// openSecretFile decrypts path. A missing file is an error unless create is
+// set, in which case the passphrase is confirmed on the terminal so a typo
+// cannot lock the new file.
+func openSecretFile(path string, create bool) (map[string]string, []byte, error) {
+	_, statErr := os.Stat(path)
+	exists := statErr == nil
+	if !exists && !create {
+		return nil, nil, fmt.Errorf("secret file %s does not exist; add a secret with pinocchio secrets set", path)
+	}
+	passphrase, err := secrets.EnvPassphrase(secrets.TerminalPassphrase("Secret file passphrase: "))()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists && os.Getenv(secrets.PassphraseEnv) == "" && os.Getenv(secrets.PassphraseFileEnv) == "" {
+		confirm, err := secrets.TerminalPassphrase("Repeat passphrase: ")()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !bytes.Equal(passphrase, confirm) {
+			return nil, nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	entries, err := secrets.ReadSecretFile(path, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, passphrase, nil
+}
+
+// readSecretValue reads a secret from a hidden terminal prompt, or from stdin
+// when it is piped, so the value never appears in shell history.
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		value, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("read secret value: %w", err)
+		}
+		return string(value), nil
+	}
+	value, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("read secret value from stdin: %w", err)
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+)
+
+type ListCommand struct {
+	*cmds.CommandDescription
+}
+
+type ListSettings struct {
+	File string `glazed:"file"`
+}
+
+var _ cmds.GlazeCommand = (*ListCommand)(nil)
+
+func NewListCommand() (*ListCommand, error) {
+	return &ListCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"list",
+			cmds.WithShort("List secret names in the encrypted secret file"),
+			cmds.WithLong(`List the names stored in the encrypted secret file and the reference to
+use for each. Values are never printed.
+
+Examples:
+  pinocchio secrets list
+  pinocchio secrets list --output json
+`),
+			cmds.WithFlags(
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp(secretFileFlagHelp),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *ListCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &ListSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode secrets list settings: %w", err)
+	}
+	path, err := secretFilePath(s.File)
+	if err != nil {
+		return err
+	}
+	entries, _, err := openSecretFile(path, false)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := gp.AddRow(ctx, types.NewRow(
+			types.MRP("name", name),
+			types.MRP("reference", secrets.Ref{Scheme: secrets.SchemeEnc, Arg: name}.String()),
+			types.MRP("file", path),
+		)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+)
+
+type RmCommand struct {
+	*cmds.CommandDescription
+}
+
+type RmSettings struct {
+	Name string `glazed:"name"`
+	File string `glazed:"file"`
+}
+
+var _ cmds.BareCommand = (*RmCommand)(nil)
+
+func NewRmCommand() (*RmCommand, error) {
+	return &RmCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"rm",
+			cmds.WithShort("Remove a secret from the encrypted secret file"),
+			cmds.WithFlags(
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp(secretFileFlagHelp),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"name",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Secret name"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *RmCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &RmSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode secrets rm settings: %w", err)
+	}
+	path, err := secretFilePath(s.File)
+	if err != nil {
+		return err
+	}
+	entries, passphrase, err := openSecretFile(path, false)
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[s.Name]; !ok {
+		return fmt.Errorf("secret %q is not in %s", s.Name, path)
+	}
+	delete(entries, s.Name)
+	if err := secrets.WriteSecretFile(path, passphrase, entries); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s from %s\n", s.Name, path)
+	return nil
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func NewSecretsCommand() (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the passphrase-encrypted secret file used by ${enc:...} references",
+	}
+
+	setCmd, err := NewSetCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraSetCmd, err := cli.BuildCobraCommand(setCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraSetCmd)
+
+	listCmd, err := NewListCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraListCmd, err := cli.BuildCobraCommand(listCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraListCmd)
+
+	rmCmd, err := NewRmCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraRmCmd, err := cli.BuildCobraCommand(rmCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraRmCmd)
+
+	return root, nil
+}
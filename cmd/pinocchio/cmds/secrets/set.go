@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+)
+
+type SetCommand struct {
+	*cmds.CommandDescription
+}
+
+type SetSettings struct {
+	Name string `glazed:"name"`
+	File string `glazed:"file"`
+}
+
+var _ cmds.BareCommand = (*SetCommand)(nil)
+
+func NewSetCommand() (*SetCommand, error) {
+	return &SetCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"set",
+			cmds.WithShort("Store a secret in the encrypted secret file"),
+			cmds.WithLong(`Store a secret in the passphrase-encrypted secret file.
+
+The value is read from a hidden prompt, or from stdin when it is piped, so it
+never appears in shell history. Reference it from config or profiles as
+${enc:<name>}.
+
+The passphrase comes from PINOCCHIO_SECRETS_PASSPHRASE,
+PINOCCHIO_SECRETS_PASSPHRASE_FILE, or a terminal prompt.
+
+Examples:
+  pinocchio secrets set openai
+  pass show openai | pinocchio secrets set openai
+  pinocchio config set profiles.assistant.inference_settings.api.api_keys.openai-api-key '${enc:openai}'
+`),
+			cmds.WithFlags(
+				fields.New(
+					"file",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp(secretFileFlagHelp),
+				),
+			),
+			cmds.WithArguments(
+				fields.New(
+					"name",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Secret name"),
+				),
+			),
+		),
+	}, nil
+}
+
+func (c *SetCommand) Run(ctx context.Context, parsedLayers *values.Values) error {
+	s := &SetSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode secrets set settings: %w", err)
+	}
+	name := strings.TrimSpace(s.Name)
+	if name == "" || strings.ContainsAny(name, "{}") {
+		return fmt.Errorf("invalid secret name %q", s.Name)
+	}
+	path, err := secretFilePath(s.File)
+	if err != nil {
+		return err
+	}
+	entries, passphrase, err := openSecretFile(path, true)
+	if err != nil {
+		return err
+	}
+	value, err := readSecretValue(name)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret value is empty")
+	}
+	entries[name] = value
+	if err := secrets.WriteSecretFile(path, passphrase, entries); err != nil {
+		return err
+	}
+	fmt.Printf("Stored %s in %s; reference it as %s\n", name, path, secrets.Ref{Scheme: secrets.SchemeEnc, Arg: name})
+	return nil
+}
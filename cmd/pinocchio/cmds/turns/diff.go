@@ -0,0 +1,271 @@
+package turns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+)
+
+type DiffCommand struct {
+	*cmds.CommandDescription
+}
+
+type DiffSettings struct {
+	StoreSettings
+	SessionID string `glazed:"session"`
+	TurnA     string `glazed:"turn-a"`
+	TurnB     string `glazed:"turn-b"`
+	ConvID    string `glazed:"conv-id"`
+	FromPhase string `glazed:"from-phase"`
+	ToPhase   string `glazed:"to-phase"`
+}
+
+var _ cmds.GlazeCommand = (*DiffCommand)(nil)
+
+func NewDiffCommand() (*DiffCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := append(storeFlags(),
+		fields.New(
+			"conv-id",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Only consider snapshots of this conversation"),
+		),
+		fields.New(
+			"from-phase",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Phase of the snapshot to diff from (default: first snapshot of a single turn, latest snapshot of turn-a)"),
+		),
+		fields.New(
+			"to-phase",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Phase of the snapshot to diff to (default: latest snapshot)"),
+		),
+	)
+
+	return &DiffCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"diff",
+			cmds.WithShort("Show added, removed, modified and moved blocks between turn snapshots"),
+			cmds.WithLong(`Compare stored turn snapshots block by block.
+
+With only a session, every phase transition of the session's latest turn is
+shown. With one turn, every phase transition of that turn is shown unless
+--from-phase or --to-phase pick two snapshots. With two turns, the latest
+snapshot of each turn (or the given phases) are compared.
+
+Blocks are matched by block ID. Turn metadata and turn data changes are
+reported per key.
+
+Examples:
+  pinocchio turns diff --turns-db ./turns.db sess-1
+  pinocchio turns diff --turns-db ./turns.db sess-1 turn-3 --from-phase pre_inference --to-phase final
+  pinocchio turns diff --turns-db ./turns.db sess-1 turn-2 turn-3 --output json
+`),
+			cmds.WithFlags(flags...),
+			cmds.WithArguments(
+				fields.New(
+					"session",
+					fields.TypeString,
+					fields.WithHelp("Session ID whose snapshots are compared"),
+					fields.WithRequired(true),
+				),
+				fields.New(
+					"turn-a",
+					fields.TypeString,
+					fields.WithHelp("Turn to diff; omitted means the latest turn of the session"),
+					fields.WithDefault(""),
+				),
+				fields.New(
+					"turn-b",
+					fields.TypeString,
+					fields.WithHelp("Second turn; when set, turn-a is compared against it"),
+					fields.WithDefault(""),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *DiffCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &DiffSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode turns diff settings: %w", err)
+	}
+
+	store, closeStore, err := openStore(ctx, s.StoreSettings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeStore() }()
+
+	src, ok := store.(chatstore.SnapshotSource)
+	if !ok {
+		return fmt.Errorf("turn store %T does not support snapshot access", store)
+	}
+
+	keys, err := src.ListSnapshotKeys(ctx, chatstore.SnapshotKeyQuery{
+		ConvID:    strings.TrimSpace(s.ConvID),
+		SessionID: strings.TrimSpace(s.SessionID),
+	})
+	if err != nil {
+		return fmt.Errorf("list snapshots: %w", err)
+	}
+	pairs, err := s.snapshotPairs(keys)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		diff, err := chatstore.DiffSnapshotKeys(ctx, src, pair[0], pair[1])
+		if err != nil {
+			return err
+		}
+		for _, row := range diffRows(diff) {
+			if err := gp.AddRow(ctx, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotPairs resolves the positional turns and phase flags into the
+// snapshot pairs to compare. keys must be ordered by creation time.
+func (s *DiffSettings) snapshotPairs(keys []chatstore.SnapshotKey) ([][2]chatstore.SnapshotKey, error) {
+	sessionID := strings.TrimSpace(s.SessionID)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no snapshots found for session %q", sessionID)
+	}
+	turnA := strings.TrimSpace(s.TurnA)
+	turnB := strings.TrimSpace(s.TurnB)
+	fromPhase := strings.TrimSpace(s.FromPhase)
+	toPhase := strings.TrimSpace(s.ToPhase)
+	if turnA == "" {
+		if turnB != "" {
+			return nil, fmt.Errorf("turn-b requires turn-a")
+		}
+		turnA = keys[len(keys)-1].TurnID
+	}
+
+	snapsA := snapshotsOfTurn(keys, turnA)
+	if len(snapsA) == 0 {
+		return nil, fmt.Errorf("no snapshots found for turn %q in session %q", turnA, sessionID)
+	}
+
+	if turnB != "" {
+		snapsB := snapshotsOfTurn(keys, turnB)
+		if len(snapsB) == 0 {
+			return nil, fmt.Errorf("no snapshots found for turn %q in session %q", turnB, sessionID)
+		}
+		from, err := latestSnapshotInPhase(snapsA, fromPhase)
+		if err != nil {
+			return nil, err
+		}
+		to, err := latestSnapshotInPhase(snapsB, toPhase)
+		if err != nil {
+			return nil, err
+		}
+		return [][2]chatstore.SnapshotKey{{from, to}}, nil
+	}
+
+	if fromPhase == "" && toPhase == "" {
+		if len(snapsA) < 2 {
+			return nil, fmt.Errorf("turn %q has a single snapshot; nothing to diff", turnA)
+		}
+		pairs := make([][2]chatstore.SnapshotKey, 0, len(snapsA)-1)
+		for i := 1; i < len(snapsA); i++ {
+			pairs = append(pairs, [2]chatstore.SnapshotKey{snapsA[i-1], snapsA[i]})
+		}
+		return pairs, nil
+	}
+
+	from := snapsA[0]
+	if fromPhase != "" {
+		var err error
+		if from, err = latestSnapshotInPhase(snapsA, fromPhase); err != nil {
+			return nil, err
+		}
+	}
+	to, err := latestSnapshotInPhase(snapsA, toPhase)
+	if err != nil {
+		return nil, err
+	}
+	return [][2]chatstore.SnapshotKey{{from, to}}, nil
+}
+
+func snapshotsOfTurn(keys []chatstore.SnapshotKey, turnID string) []chatstore.SnapshotKey {
+	out := []chatstore.SnapshotKey{}
+	for _, k := range keys {
+		if k.TurnID == turnID {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// latestSnapshotInPhase returns the newest snapshot, restricted to phase when
+// one is given.
+func latestSnapshotInPhase(keys []chatstore.SnapshotKey, phase string) (chatstore.SnapshotKey, error) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if phase == "" || keys[i].Phase == phase {
+			return keys[i], nil
+		}
+	}
+	return chatstore.SnapshotKey{}, fmt.Errorf("turn %q has no %q snapshot", keys[0].TurnID, phase)
+}
+
+func diffRows(diff *chatstore.SnapshotDiff) []types.Row {
+	base := func(section string, change chatstore.DiffChange) []types.MapRowPair {
+		return []types.MapRowPair{
+			types.MRP("from", diff.From.String()),
+			types.MRP("to", diff.To.String()),
+			types.MRP("section", section),
+			types.MRP("change", string(change)),
+		}
+	}
+	if diff.Empty() {
+		return []types.Row{types.NewRow(base("snapshot", "unchanged")...)}
+	}
+
+	rows := []types.Row{}
+	for _, b := range diff.Blocks {
+		rows = append(rows, types.NewRow(append(base("block", b.Change),
+			types.MRP("block_id", b.BlockID),
+			types.MRP("kind", b.Kind),
+			types.MRP("role", b.Role),
+			types.MRP("from_index", b.FromIndex),
+			types.MRP("to_index", b.ToIndex),
+			types.MRP("fields", strings.Join(b.Fields, ", ")),
+		)...))
+	}
+	for _, section := range []struct {
+		name  string
+		diffs []chatstore.ValueDiff
+	}{{"metadata", diff.Metadata}, {"data", diff.Data}} {
+		for _, v := range section.diffs {
+			rows = append(rows, types.NewRow(append(base(section.name, v.Change),
+				types.MRP("key", v.Key),
+				types.MRP("from_value", v.From),
+				types.MRP("to_value", v.To),
+			)...))
+		}
+	}
+	return rows
+}
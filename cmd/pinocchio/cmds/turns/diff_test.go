@@ -0,0 +1,42 @@
+package turns
+
+import (
+	"testing"
+
+	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSettingsSnapshotPairs(t *testing.T) {
+	key := func(turn, phase string, at int64) chatstore.SnapshotKey {
+		return chatstore.SnapshotKey{ConvID: "conv-1", SessionID: "sess-1", TurnID: turn, Phase: phase, CreatedAtMs: at}
+	}
+	keys := []chatstore.SnapshotKey{
+		key("turn-1", "pre_inference", 100),
+		key("turn-1", "final", 200),
+		key("turn-2", "pre_inference", 300),
+		key("turn-2", "post_tools", 400),
+		key("turn-2", "final", 500),
+	}
+
+	pairs, err := (&DiffSettings{SessionID: "sess-1"}).snapshotPairs(keys)
+	require.NoError(t, err)
+	require.Equal(t, [][2]chatstore.SnapshotKey{{keys[2], keys[3]}, {keys[3], keys[4]}}, pairs)
+
+	pairs, err = (&DiffSettings{SessionID: "sess-1", TurnA: "turn-2", ToPhase: "post_tools"}).snapshotPairs(keys)
+	require.NoError(t, err)
+	require.Equal(t, [][2]chatstore.SnapshotKey{{keys[2], keys[3]}}, pairs)
+
+	pairs, err = (&DiffSettings{SessionID: "sess-1", TurnA: "turn-1", TurnB: "turn-2"}).snapshotPairs(keys)
+	require.NoError(t, err)
+	require.Equal(t, [][2]chatstore.SnapshotKey{{keys[1], keys[4]}}, pairs)
+
+	_, err = (&DiffSettings{SessionID: "sess-1", TurnA: "turn-1", FromPhase: "post_tools"}).snapshotPairs(keys)
+	require.Error(t, err)
+	_, err = (&DiffSettings{SessionID: "sess-1", TurnA: "turn-9"}).snapshotPairs(keys)
+	require.Error(t, err)
+	_, err = (&DiffSettings{SessionID: "sess-1", TurnA: "turn-1"}).snapshotPairs(keys[:1])
+	require.Error(t, err)
+	_, err = (&DiffSettings{SessionID: "sess-1"}).snapshotPairs(nil)
+	require.Error(t, err)
+}
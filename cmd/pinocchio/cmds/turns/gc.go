@@ -0,0 +1,200 @@
+package turns
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+)
+
+type GCCommand struct {
+	*cmds.CommandDescription
+}
+
+type GCSettings struct {
+	StoreSettings
+	ConvID              string `glazed:"conv-id"`
+	SessionID           string `glazed:"session-id"`
+	MaxAge              string `glazed:"max-age"`
+	FinalOnlyAfter      string `glazed:"final-only-after"`
+	MaxSnapshotsPerTurn int    `glazed:"max-snapshots-per-turn"`
+	FinalPhase          string `glazed:"final-phase"`
+	DryRun              bool   `glazed:"dry-run"`
+	Vacuum              bool   `glazed:"vacuum"`
+}
+
+var _ cmds.GlazeCommand = (*GCCommand)(nil)
+
+func NewGCCommand() (*GCCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := append(storeFlags(),
+		fields.New(
+			"conv-id",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Only apply the retention policy to this conversation"),
+		),
+		fields.New(
+			"session-id",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Only apply the retention policy to this session"),
+		),
+		fields.New(
+			"max-age",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Delete snapshots older than this age (e.g. 720h, 30d)"),
+		),
+		fields.New(
+			"final-only-after",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("Keep only final-phase snapshots older than this age (e.g. 7d)"),
+		),
+		fields.New(
+			"max-snapshots-per-turn",
+			fields.TypeInteger,
+			fields.WithDefault(0),
+			fields.WithHelp("Keep at most this many snapshots per turn; the newest final snapshot is always kept"),
+		),
+		fields.New(
+			"final-phase",
+			fields.TypeString,
+			fields.WithDefault(chatstore.DefaultRetentionFinalPhase),
+			fields.WithHelp("Snapshot phase treated as final by the retention rules"),
+		),
+		fields.New(
+			"dry-run",
+			fields.TypeBool,
+			fields.WithDefault(false),
+			fields.WithHelp("Report what would be deleted without deleting anything"),
+		),
+		fields.New(
+			"vacuum",
+			fields.TypeBool,
+			fields.WithDefault(false),
+			fields.WithHelp("Reclaim storage after collection (VACUUM on SQLite, OPTIMIZE TABLE on MySQL, VACUUM (ANALYZE) on PostgreSQL)"),
+		),
+	)
+
+	return &GCCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"gc",
+			cmds.WithShort("Apply retention policies and garbage-collect orphaned turn blocks"),
+			cmds.WithLong(`Delete expired turn snapshots and the turns/blocks rows they leave orphaned.
+
+Without any retention flag only orphaned rows are removed. The newest final
+snapshot of a turn is never removed by --max-snapshots-per-turn, so resumable
+history keeps working.
+
+Examples:
+  pinocchio turns gc --turns-db ./turns.db --dry-run
+  pinocchio turns gc --turns-db ./turns.db --max-age 90d --final-only-after 7d --vacuum
+  pinocchio turns gc --turns-backend mysql --turns-dsn "$DSN" --max-snapshots-per-turn 3
+`),
+			cmds.WithFlags(flags...),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *GCCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &GCSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode turns gc settings: %w", err)
+	}
+	policy, err := s.retentionPolicy()
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := openStore(ctx, s.StoreSettings)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeStore() }()
+
+	gcStore, ok := store.(chatstore.TurnStoreGC)
+	if !ok {
+		return fmt.Errorf("turn store %T does not support garbage collection", store)
+	}
+
+	started := time.Now()
+	res, err := gcStore.CollectGarbage(ctx, chatstore.GCOptions{Policy: policy, DryRun: s.DryRun})
+	if err != nil {
+		return err
+	}
+	vacuumed := false
+	if s.Vacuum && !s.DryRun {
+		if err := gcStore.Optimize(ctx); err != nil {
+			return err
+		}
+		vacuumed = true
+	}
+
+	return gp.AddRow(ctx, types.NewRow(
+		types.MRP("dry_run", res.DryRun),
+		types.MRP("snapshots_scanned", res.SnapshotsScanned),
+		types.MRP("snapshots_deleted", res.SnapshotsDeleted),
+		types.MRP("membership_rows_deleted", res.MembershipRowsDeleted),
+		types.MRP("turns_deleted", res.TurnsDeleted),
+		types.MRP("blocks_deleted", res.BlocksDeleted),
+		types.MRP("vacuumed", vacuumed),
+		types.MRP("duration_ms", time.Since(started).Milliseconds()),
+	))
+}
+
+func (s *GCSettings) retentionPolicy() (chatstore.RetentionPolicy, error) {
+	maxAge, err := parseRetentionAge(s.MaxAge)
+	if err != nil {
+		return chatstore.RetentionPolicy{}, fmt.Errorf("invalid --max-age: %w", err)
+	}
+	finalOnlyAfter, err := parseRetentionAge(s.FinalOnlyAfter)
+	if err != nil {
+		return chatstore.RetentionPolicy{}, fmt.Errorf("invalid --final-only-after: %w", err)
+	}
+	policy := chatstore.RetentionPolicy{
+		ConvID:              strings.TrimSpace(s.ConvID),
+		SessionID:           strings.TrimSpace(s.SessionID),
+		MaxAge:              maxAge,
+		FinalOnlyAfter:      finalOnlyAfter,
+		MaxSnapshotsPerTurn: s.MaxSnapshotsPerTurn,
+		FinalPhase:          strings.TrimSpace(s.FinalPhase),
+	}
+	if err := policy.Validate(); err != nil {
+		return chatstore.RetentionPolicy{}, err
+	}
+	return policy, nil
+}
+
+// parseRetentionAge accepts Go durations plus a whole-day "Nd" suffix, which is
+// how retention windows are usually expressed.
+func parseRetentionAge(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("parse days %q: %w", raw, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(raw)
+}
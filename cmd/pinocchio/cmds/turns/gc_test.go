@@ -0,0 +1,45 @@
+package turns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "36h", want: 36 * time.Hour},
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: " 7d ", want: 7 * 24 * time.Hour},
+		{in: "xd", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRetentionAge(tt.in)
+		if tt.wantErr {
+			require.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		require.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestGCSettingsRetentionPolicy(t *testing.T) {
+	s := &GCSettings{MaxAge: "90d", FinalOnlyAfter: "7d", MaxSnapshotsPerTurn: 3, FinalPhase: "final", ConvID: " conv-1 "}
+	policy, err := s.retentionPolicy()
+	require.NoError(t, err)
+	require.Equal(t, "conv-1", policy.ConvID)
+	require.Equal(t, 90*24*time.Hour, policy.MaxAge)
+	require.Equal(t, 7*24*time.Hour, policy.FinalOnlyAfter)
+	require.Equal(t, 3, policy.MaxSnapshotsPerTurn)
+
+	_, err = (&GCSettings{MaxSnapshotsPerTurn: -1}).retentionPolicy()
+	require.Error(t, err)
+}
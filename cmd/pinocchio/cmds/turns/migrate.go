@@ -0,0 +1,171 @@
+package turns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+)
+
+type MigrateCommand struct {
+	*cmds.CommandDescription
+}
+
+type MigrateSettings struct {
+	From      string `glazed:"from"`
+	To        string `glazed:"to"`
+	ConvID    string `glazed:"conv-id"`
+	SessionID string `glazed:"session-id"`
+	Resume    bool   `glazed:"resume"`
+	Verify    bool   `glazed:"verify"`
+}
+
+var _ cmds.GlazeCommand = (*MigrateCommand)(nil)
+
+func NewMigrateCommand() (*MigrateCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrateCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"migrate",
+			cmds.WithShort("Copy turn snapshots between SQLite, MySQL, PostgreSQL and JSONL stores"),
+			cmds.WithLong(`Copy every turn snapshot, with its normalized blocks, from one store to another.
+
+Endpoints are written as <backend>:<location>:
+  sqlite:<path>           SQLite file (a value starting with file: is used as DSN)
+  mysql:<dsn>             go-sql-driver/mysql DSN (parseTime=true)
+  postgres:<dsn>          libpq connection string or postgres:// URL
+  jsonl:<path>            one JSON snapshot record per line (dump/load)
+
+SQLite and JSONL sources are opened read-only and must exist; a mistyped
+--from path fails instead of migrating an empty store.
+
+Snapshot keys, runtime keys, inference ids and block ids are preserved. With
+--resume (the default) snapshots already present in the destination are
+skipped, so an interrupted migration can simply be re-run. With --verify (the
+default) snapshot counts and ordered block content hashes are compared at the
+end and the command fails on any mismatch.
+
+Examples:
+  pinocchio turns migrate --from sqlite:./turns.db --to "mysql:app:secret@tcp(db:3306)/chat?parseTime=true"
+  pinocchio turns migrate --from sqlite:./turns.db --to jsonl:./turns.jsonl
+  pinocchio turns migrate --from sqlite:./turns.db --to postgres:postgres://app:secret@db:5432/chat
+  pinocchio turns migrate --from jsonl:./turns.jsonl --to sqlite:./restored.db --conv-id conv-123
+`),
+			cmds.WithFlags(
+				fields.New(
+					"from",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Source store endpoint (sqlite:, mysql:, postgres: or jsonl:)"),
+				),
+				fields.New(
+					"to",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("Destination store endpoint (sqlite:, mysql:, postgres: or jsonl:)"),
+				),
+				fields.New(
+					"conv-id",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only copy snapshots of this conversation"),
+				),
+				fields.New(
+					"session-id",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only copy snapshots of this session"),
+				),
+				fields.New(
+					"resume",
+					fields.TypeBool,
+					fields.WithDefault(true),
+					fields.WithHelp("Skip snapshots that already exist in the destination"),
+				),
+				fields.New(
+					"verify",
+					fields.TypeBool,
+					fields.WithDefault(true),
+					fields.WithHelp("Compare snapshot counts and block content hashes after copying"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *MigrateCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &MigrateSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode turns migrate settings: %w", err)
+	}
+	if strings.TrimSpace(s.From) == strings.TrimSpace(s.To) {
+		return fmt.Errorf("source and destination are the same store endpoint")
+	}
+
+	src, closeSrc, err := openSnapshotEndpoint(ctx, s.From, true)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer func() { _ = closeSrc() }()
+	dst, closeDst, err := openSnapshotEndpoint(ctx, s.To, false)
+	if err != nil {
+		return fmt.Errorf("open destination: %w", err)
+	}
+	defer func() { _ = closeDst() }()
+
+	started := time.Now()
+	res, err := chatstore.MigrateSnapshots(ctx, src, dst, chatstore.MigrateOptions{
+		Query: chatstore.SnapshotKeyQuery{
+			ConvID:    strings.TrimSpace(s.ConvID),
+			SessionID: strings.TrimSpace(s.SessionID),
+		},
+		Resume: s.Resume,
+		Verify: s.Verify,
+		Progress: func(done, total int) {
+			if done%1000 == 0 || done == total {
+				log.Info().Int("done", done).Int("total", total).Msg("migrating turn snapshots")
+			}
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	row := types.NewRow(
+		types.MRP("from", s.From),
+		types.MRP("to", s.To),
+		types.MRP("source_snapshots", res.SourceSnapshots),
+		types.MRP("copied", res.Copied),
+		types.MRP("skipped", res.Skipped),
+		types.MRP("duration_ms", time.Since(started).Milliseconds()),
+	)
+	if s.Verify {
+		row.Set("destination_snapshots", res.DestinationSnapshots)
+		row.Set("verified", res.Verified)
+		row.Set("mismatches", len(res.Mismatches))
+	}
+	if err := gp.AddRow(ctx, row); err != nil {
+		return err
+	}
+	if len(res.Mismatches) > 0 {
+		for _, m := range res.Mismatches {
+			log.Error().Str("snapshot", m).Msg("turn snapshot verification mismatch")
+		}
+		return fmt.Errorf("verification failed for %d of %d snapshots", len(res.Mismatches), res.SourceSnapshots)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package turns
+
+import (
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func NewTurnsCommand() (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:   "turns",
+		Short: "Inspect and maintain durable turn snapshot stores",
+	}
+
+	gcCmd, err := NewGCCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraGCCmd, err := cli.BuildCobraCommand(gcCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraGCCmd)
+
+	migrateCmd, err := NewMigrateCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraMigrateCmd, err := cli.BuildCobraCommand(migrateCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraMigrateCmd)
+
+	diffCmd, err := NewDiffCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraDiffCmd, err := cli.BuildCobraCommand(diffCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraDiffCmd)
+
+	return root, nil
+}
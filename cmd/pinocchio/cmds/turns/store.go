@@ -0,0 +1,147 @@
+package turns
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+)
+
+// StoreSettings are the turn store selection flags shared by every turns
+// subcommand. They mirror the turns-* flags of web-chat and `pinocchio js`.
+type StoreSettings struct {
+	TurnsBackend string `glazed:"turns-backend"`
+	TurnsDSN     string `glazed:"turns-dsn"`
+	TurnsDB      string `glazed:"turns-db"`
+}
+
+func storeFlags() []*fields.Definition {
+	return []*fields.Definition{
+		fields.New(
+			"turns-backend",
+			fields.TypeChoice,
+			fields.WithDefault(""),
+			fields.WithChoices("", "sqlite", "mysql", "postgres"),
+			fields.WithHelp("Turn persistence backend; required when turns-dsn is set"),
+		),
+		fields.New(
+			"turns-dsn",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("SQLite, MySQL or PostgreSQL DSN of the turn store; interpreted only by turns-backend"),
+		),
+		fields.New(
+			"turns-db",
+			fields.TypeString,
+			fields.WithDefault(""),
+			fields.WithHelp("SQLite DB file path of the turn store; backend defaults to SQLite when set"),
+		),
+	}
+}
+
+func openStore(ctx context.Context, s StoreSettings) (chatstore.TurnStore, func() error, error) {
+	if strings.TrimSpace(s.TurnsBackend) == "" && strings.TrimSpace(s.TurnsDSN) == "" && strings.TrimSpace(s.TurnsDB) == "" {
+		return nil, nil, fmt.Errorf("no turn store configured; pass --turns-db or --turns-backend with --turns-dsn")
+	}
+	store, closeStore, err := serverkit.OpenTurnStore(ctx, serverkit.StoreOptions{Turns: serverkit.StoreSpec{
+		Backend: serverkit.StoreBackend(s.TurnsBackend),
+		DSN:     s.TurnsDSN,
+		Path:    s.TurnsDB,
+	}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open turn store: %w", err)
+	}
+	if store == nil {
+		_ = closeStore()
+		return nil, nil, fmt.Errorf("turn store is disabled")
+	}
+	return store, closeStore, nil
+}
+
+// openSnapshotEndpoint opens a migration endpoint written as
+// <backend>:<location>, e.g. sqlite:./turns.db, sqlite:file:x.db?_foreign_keys=on,
+// mysql:user:pass@tcp(host)/db?parseTime=true, postgres:postgres://host/db or
+// jsonl:./turns.jsonl. Sources are opened read-only, and SQLite and JSONL
+// sources must already exist instead of being created empty.
+func openSnapshotEndpoint(ctx context.Context, spec string, source bool) (chatstore.SnapshotSink, func() error, error) {
+	backend, location, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	location = strings.TrimSpace(location)
+	if !ok || location == "" {
+		return nil, nil, fmt.Errorf("invalid store endpoint %q: expected sqlite:<path|dsn>, mysql:<dsn>, postgres:<dsn> or jsonl:<path>", spec)
+	}
+
+	var settings StoreSettings
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "jsonl":
+		open := chatstore.OpenJSONLSnapshotStore
+		if source {
+			open = chatstore.OpenJSONLSnapshotSource
+		}
+		store, err := open(location)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, store.Close, nil
+	case "sqlite":
+		if source {
+			dsn, err := readOnlySQLiteDSN(location)
+			if err != nil {
+				return nil, nil, err
+			}
+			store, err := chatstore.NewReadOnlySQLiteTurnStore(dsn)
+			if err != nil {
+				return nil, nil, fmt.Errorf("open turn store: %w", err)
+			}
+			return store, store.Close, nil
+		}
+		settings.TurnsBackend = string(serverkit.StoreBackendSQLite)
+		if strings.HasPrefix(location, "file:") {
+			settings.TurnsDSN = location
+		} else {
+			settings.TurnsDB = location
+		}
+	case "mysql":
+		settings.TurnsBackend = string(serverkit.StoreBackendMySQL)
+		settings.TurnsDSN = location
+	case "postgres":
+		settings.TurnsBackend = string(serverkit.StoreBackendPostgres)
+		settings.TurnsDSN = location
+	default:
+		return nil, nil, fmt.Errorf("unsupported store endpoint backend %q", backend)
+	}
+
+	store, closeStore, err := openStore(ctx, settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	sink, ok := store.(chatstore.SnapshotSink)
+	if !ok {
+		_ = closeStore()
+		return nil, nil, fmt.Errorf("turn store %T does not support snapshot migration", store)
+	}
+	return sink, closeStore, nil
+}
+
+// readOnlySQLiteDSN turns a sqlite endpoint location into a mode=ro DSN for an
+// existing file. Explicit file: DSNs keep their options and get mode=ro added
+// unless they already choose a mode.
+func readOnlySQLiteDSN(location string) (string, error) {
+	if !strings.HasPrefix(location, "file:") {
+		return chatstore.SQLiteTurnReadOnlyDSNForFile(location)
+	}
+	path, query, _ := strings.Cut(strings.TrimPrefix(location, "file:"), "?")
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("open turn store: %w", err)
+	}
+	if strings.Contains("&"+query, "&mode=") {
+		return location, nil
+	}
+	if query == "" {
+		return location + "?mode=ro", nil
+	}
+	return location + "&mode=ro", nil
+}
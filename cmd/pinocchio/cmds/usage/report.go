@@ -0,0 +1,224 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/go-go-golems/glazed/pkg/cmds"
+	"github.com/go-go-golems/glazed/pkg/cmds/fields"
+	"github.com/go-go-golems/glazed/pkg/cmds/schema"
+	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/glazed/pkg/middlewares"
+	"github.com/go-go-golems/glazed/pkg/types"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+type ReportCommand struct {
+	*cmds.CommandDescription
+}
+
+type ReportSettings struct {
+	UsageDB    string `glazed:"usage-db"`
+	By         string `glazed:"by"`
+	SessionID  string `glazed:"session-id"`
+	RuntimeKey string `glazed:"runtime-key"`
+	Profile    string `glazed:"profile"`
+	Model      string `glazed:"model"`
+	Since      string `glazed:"since"`
+	Until      string `glazed:"until"`
+	Pricing    string `glazed:"pricing"`
+	Total      bool   `glazed:"total"`
+}
+
+var _ cmds.GlazeCommand = (*ReportCommand)(nil)
+
+func NewReportCommand() (*ReportCommand, error) {
+	commandSettingsSection, err := cli.NewCommandSettingsSection()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReportCommand{
+		CommandDescription: cmds.NewCommandDescription(
+			"report",
+			cmds.WithShort("Aggregate recorded provider calls by profile, model, day, session or runtime"),
+			cmds.WithLong(`Aggregate the usage ledger written by --usage-db on web-chat, pinocchio
+verbs and pinocchio js.
+
+Each row is one combination of the --by dimensions (session, runtime_key,
+profile, model, day) with call counts, token sums and cost in USD. Cost is
+taken from the price table that was active when the call was recorded;
+--pricing reprices every call from a YAML price table instead. Calls whose
+model had no price are counted in unpriced_calls.
+
+--since and --until accept RFC3339 timestamps, dates (2006-01-02) or ages
+counted back from now (36h, 7d). --until is exclusive.
+
+Examples:
+  pinocchio usage report --usage-db ./usage.db --by profile,model,day
+  pinocchio usage report --usage-db ./usage.db --by session --since 24h --total
+  pinocchio usage report --usage-db ./usage.db --by model --pricing ./prices.yaml
+`),
+			cmds.WithFlags(
+				fields.New(
+					"usage-db",
+					fields.TypeString,
+					fields.WithRequired(true),
+					fields.WithHelp("SQLite usage ledger file"),
+				),
+				fields.New(
+					"by",
+					fields.TypeString,
+					fields.WithDefault("profile,model,day"),
+					fields.WithHelp("Comma-separated grouping dimensions: session, runtime_key, profile, model, day"),
+				),
+				fields.New(
+					"session-id",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls from this session"),
+				),
+				fields.New(
+					"runtime-key",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls made with this runtime key"),
+				),
+				fields.New(
+					"profile",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls made with this profile"),
+				),
+				fields.New(
+					"model",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls to this model"),
+				),
+				fields.New(
+					"since",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls at or after this time (RFC3339, date, or age such as 7d)"),
+				),
+				fields.New(
+					"until",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("Only include calls before this time (RFC3339, date, or age such as 7d)"),
+				),
+				fields.New(
+					"pricing",
+					fields.TypeString,
+					fields.WithDefault(""),
+					fields.WithHelp("YAML price table used to reprice every call instead of the recorded cost"),
+				),
+				fields.New(
+					"total",
+					fields.TypeBool,
+					fields.WithDefault(false),
+					fields.WithHelp("Append a row summing all groups"),
+				),
+			),
+			cmds.WithSections(commandSettingsSection),
+		),
+	}, nil
+}
+
+func (c *ReportCommand) RunIntoGlazeProcessor(ctx context.Context, parsedLayers *values.Values, gp middlewares.Processor) error {
+	s := &ReportSettings{}
+	if err := parsedLayers.DecodeSectionInto(schema.DefaultSlug, s); err != nil {
+		return fmt.Errorf("decode usage report settings: %w", err)
+	}
+	groupBy, err := usagestore.ParseGroupBy(s.By)
+	if err != nil {
+		return err
+	}
+	query, err := s.query(time.Now())
+	if err != nil {
+		return err
+	}
+	var repricing *usagestore.PriceTable
+	if path := strings.TrimSpace(s.Pricing); path != "" {
+		repricing, err = usagestore.LoadPriceTableFile(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	dsn, err := usagestore.SQLiteDSNForFile(strings.TrimSpace(s.UsageDB))
+	if err != nil {
+		return err
+	}
+	ledger, err := usagestore.NewSQLiteLedger(dsn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := ledger.Close(); err != nil {
+			log.Warn().Err(err).Msg("close usage ledger")
+		}
+	}()
+
+	entries, err := ledger.Query(ctx, query)
+	if err != nil {
+		return err
+	}
+	summaries := usagestore.Summarize(entries, groupBy, repricing)
+	for _, summary := range summaries {
+		if err := gp.AddRow(ctx, summaryRow(groupBy, summary)); err != nil {
+			return err
+		}
+	}
+	if s.Total {
+		total := usagestore.Total(summaries)
+		total.Keys = map[string]string{}
+		for _, dim := range groupBy {
+			total.Keys[dim] = "TOTAL"
+		}
+		return gp.AddRow(ctx, summaryRow(groupBy, total))
+	}
+	return nil
+}
+
+func (s *ReportSettings) query(now time.Time) (usagestore.Query, error) {
+	since, err := usagestore.ParseTimeBound(s.Since, now)
+	if err != nil {
+		return usagestore.Query{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := usagestore.ParseTimeBound(s.Until, now)
+	if err != nil {
+		return usagestore.Query{}, fmt.Errorf("invalid --until: %w", err)
+	}
+	return usagestore.Query{
+		SessionID:  strings.TrimSpace(s.SessionID),
+		RuntimeKey: strings.TrimSpace(s.RuntimeKey),
+		Profile:    strings.TrimSpace(s.Profile),
+		Model:      strings.TrimSpace(s.Model),
+		SinceMs:    since,
+		UntilMs:    until,
+	}, nil
+}
+
+func summaryRow(groupBy []string, s usagestore.Summary) types.Row {
+	pairs := make([]types.MapRowPair, 0, len(groupBy)+9)
+	for _, dim := range groupBy {
+		pairs = append(pairs, types.MRP(dim, s.Keys[dim]))
+	}
+	pairs = append(pairs,
+		types.MRP("calls", s.Calls),
+		types.MRP("input_tokens", s.InputTokens),
+		types.MRP("output_tokens", s.OutputTokens),
+		types.MRP("cached_tokens", s.CachedTokens),
+		types.MRP("cache_creation_input_tokens", s.CacheCreationInputTokens),
+		types.MRP("cache_read_input_tokens", s.CacheReadInputTokens),
+		types.MRP("duration_ms", s.DurationMs),
+		types.MRP("cost_usd", s.CostUSD),
+		types.MRP("unpriced_calls", s.UnpricedCalls),
+	)
+	return types.NewRow(pairs...)
+}
@@ -0,0 +1,37 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSettingsQuery(t *testing.T) {
+	now := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	s := &ReportSettings{SessionID: " s1 ", Profile: "agent", Since: "7d", Until: "2026-10-09"}
+	q, err := s.query(now)
+	require.NoError(t, err)
+	require.Equal(t, "s1", q.SessionID)
+	require.Equal(t, "agent", q.Profile)
+	require.Equal(t, time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC).UnixMilli(), q.SinceMs)
+	require.Equal(t, time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC).UnixMilli(), q.UntilMs)
+
+	_, err = (&ReportSettings{Since: "yesterday"}).query(now)
+	require.ErrorContains(t, err, "--since")
+}
+
+func TestSummaryRowOrdersGroupKeysFirst(t *testing.T) {
+	row := summaryRow([]string{"profile", "model"}, usagestore.Summary{
+		Keys:  map[string]string{"profile": "default", "model": "gpt-4o"},
+		Calls: 2,
+	})
+	keys := []string{}
+	for pair := row.Oldest(); pair != nil; pair = pair.Next() {
+		keys = append(keys, pair.Key)
+	}
+	require.Equal(t, []string{"profile", "model", "calls"}, keys[:3])
+	v, _ := row.Get("calls")
+	require.Equal(t, int64(2), v)
+}
@@ -0,0 +1,25 @@
+package usage
+
+import (
+	"github.com/go-go-golems/glazed/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func NewUsageCommand() (*cobra.Command, error) {
+	root := &cobra.Command{
+		Use:   "usage",
+		Short: "Report provider-call token usage and cost from the usage ledger",
+	}
+
+	reportCmd, err := NewReportCommand()
+	if err != nil {
+		return nil, err
+	}
+	cobraReportCmd, err := cli.BuildCobraCommand(reportCmd)
+	if err != nil {
+		return nil, err
+	}
+	root.AddCommand(cobraReportCmd)
+
+	return root, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"os"
+	"path"
 	"path/filepath"
 
 	sections2 "github.com/go-go-golems/geppetto/pkg/sections"
@@ -20,13 +21,19 @@ import (
 	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/auth"
 	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter"
 	catter_doc "github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/catter/pkg/doc"
+	pinocchio_config "github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/config"
 	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/profiles"
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/prompts"
+	pinocchio_secrets "github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/secrets"
 	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/tokens"
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/turns"
+	"github.com/go-go-golems/pinocchio/cmd/pinocchio/cmds/usage"
 	pinocchio_docs "github.com/go-go-golems/pinocchio/cmd/pinocchio/doc"
 	"github.com/go-go-golems/pinocchio/pkg/cmds"
 	"github.com/go-go-golems/pinocchio/pkg/cmds/cmdlayers"
 	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
 	pkg_doc "github.com/go-go-golems/pinocchio/pkg/doc"
+	"github.com/go-go-golems/pinocchio/pkg/prompttemplates"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
@@ -205,8 +212,6 @@ func initAllCommands(helpSystem *help.HelpSystem) error {
 	defaultDirectory := "$HOME/.pinocchio/prompts"
 	repositoryPaths = append(repositoryPaths, defaultDirectory)
 
-	loader := &cmds.PinocchioCommandLoader{}
-
 	directories := []repositories.Directory{
 		{
 			FS:               promptsFS,
@@ -232,6 +237,19 @@ func initAllCommands(helpSystem *help.HelpSystem) error {
 		})
 	}
 
+	templates := prompttemplates.NewLibrary()
+	for _, directory := range directories {
+		if err := templates.AddFS(directory.FS, path.Join(directory.RootDirectory, prompttemplates.Dir), directory.Name); err != nil {
+			log.Warn().Err(err).Str("repository", directory.Name).Msg("Could not load prompt templates")
+		}
+	}
+	loader := &cmds.PinocchioCommandLoader{
+		Templates: templates,
+		ContextSources: map[string]cmds.ContextSource{
+			"catter": catter.ContextSource,
+		},
+	}
+
 	repositories_ := []*repositories.Repository{
 		repositories.NewRepository(
 			repositories.WithDirectories(directories...),
@@ -269,6 +287,36 @@ func initAllCommands(helpSystem *help.HelpSystem) error {
 	}
 	rootCmd.AddCommand(profilesCmd)
 
+	turnsCmd, err := turns.NewTurnsCommand()
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(turnsCmd)
+
+	promptsCmd, err := prompts.NewPromptsCommand(allCommands)
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(promptsCmd)
+
+	usageCmd, err := usage.NewUsageCommand()
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(usageCmd)
+
+	configCmd, err := pinocchio_config.NewConfigCommand()
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(configCmd)
+
+	secretsCmd, err := pinocchio_secrets.NewSecretsCommand()
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(secretsCmd)
+
 	authCmd, err := auth.NewAuthCommand()
 	if err != nil {
 		return err
@@ -289,5 +337,17 @@ func initAllCommands(helpSystem *help.HelpSystem) error {
 	}
 	rootCmd.AddCommand(cobraClipCommand)
 
+	applyCommand, err := pinocchio_cmds.NewApplyCommand()
+	if err != nil {
+		return err
+	}
+	cobraApplyCommand, err := cli.BuildCobraCommandFromCommand(applyCommand,
+		cli.WithCobraMiddlewaresFunc(cmds.GetPinocchioCommandMiddlewares),
+	)
+	if err != nil {
+		return err
+	}
+	rootCmd.AddCommand(cobraApplyCommand)
+
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/frontendtools"
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 )
 
@@ -78,6 +79,17 @@ func WithTurnsDBPath(path string) Option {
 	}
 }
 
+// WithUsageLedger records provider-call usage into ledger and serves it from
+// /api/chat/usage. A nil ledger disables both.
+func WithUsageLedger(ledger usagestore.Ledger) Option {
+	return func(s *Server) {
+		if s == nil {
+			return
+		}
+		s.usageLedger = ledger
+	}
+}
+
 func WithChatPlugins(features ...chatapp.ChatPlugin) Option {
 	return func(s *Server) {
 		if s == nil {
@@ -0,0 +1,67 @@
+package appserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+const defaultUsageGroupBy = "profile,model,day"
+
+type usageResponse struct {
+	GroupBy []string             `json:"group_by"`
+	Groups  []usagestore.Summary `json:"groups"`
+	Total   usagestore.Summary   `json:"total"`
+}
+
+// HandleUsage serves aggregated provider-call usage from the ledger.
+//
+// Query parameters: group_by (comma-separated session, runtime_key, profile,
+// model, day), session_id, runtime_key, profile, model, since and until.
+func (s *Server) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+	if s.usageLedger == nil {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "usage ledger disabled"})
+		return
+	}
+	query := r.URL.Query()
+	rawGroupBy := query.Get("group_by")
+	if rawGroupBy == "" {
+		rawGroupBy = defaultUsageGroupBy
+	}
+	groupBy, err := usagestore.ParseGroupBy(rawGroupBy)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	now := time.Now()
+	since, err := usagestore.ParseTimeBound(query.Get("since"), now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "since: " + err.Error()})
+		return
+	}
+	until, err := usagestore.ParseTimeBound(query.Get("until"), now)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "until: " + err.Error()})
+		return
+	}
+	entries, err := s.usageLedger.Query(r.Context(), usagestore.Query{
+		SessionID:  query.Get("session_id"),
+		RuntimeKey: query.Get("runtime_key"),
+		Profile:    query.Get("profile"),
+		Model:      query.Get("model"),
+		SinceMs:    since,
+		UntilMs:    until,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("query usage ledger")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "query usage ledger failed"})
+		return
+	}
+	groups := usagestore.Summarize(entries, groupBy, nil)
+	writeJSON(w, http.StatusOK, usageResponse{GroupBy: groupBy, Groups: groups, Total: usagestore.Total(groups)})
+}
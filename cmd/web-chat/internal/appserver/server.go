@@ -8,6 +8,7 @@ import (
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/frontendtools"
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	wstransport "github.com/go-go-golems/sessionstream/pkg/sessionstream/transport/ws"
 )
@@ -22,6 +23,7 @@ type Server struct {
 	runtimeResolver     RuntimeResolver
 	turnStore           chatstore.TurnStore
 	turnsDBPath         string
+	usageLedger         usagestore.Ledger
 	exportService       *chatexport.Service
 	chatPlugins         []chatapp.ChatPlugin
 	frontendToolManager *frontendtools.Manager
@@ -52,7 +54,7 @@ func NewServer(opts ...Option) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
-	engine := chatapp.NewEngine(chatapp.WithChunkDelay(s.chunkDelay), chatapp.WithPlugins(s.chatPlugins...), chatapp.WithTurnStore(s.turnStore))
+	engine := chatapp.NewEngine(chatapp.WithChunkDelay(s.chunkDelay), chatapp.WithPlugins(s.chatPlugins...), chatapp.WithTurnStore(s.turnStore), chatapp.WithUsageLedger(s.usageLedger))
 	hubOptions := []sessionstream.HubOption{
 		sessionstream.WithSchemaRegistry(reg),
 		sessionstream.WithHydrationStore(store),
@@ -23,6 +23,7 @@ import (
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
 	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstreamv1 "github.com/go-go-golems/sessionstream/pkg/sessionstream/pb/proto/sessionstream/v1"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/require"
@@ -82,6 +83,7 @@ func newTestMux(t *testing.T, opts ...Option) (*Server, *httptest.Server) {
 	mux.HandleFunc("/api/chat/sessions", srv.HandleCreateSession)
 	mux.HandleFunc("/api/chat/sessions/", srv.HandleSessionRoutes)
 	mux.HandleFunc("/api/chat/ws", srv.HandleWS)
+	mux.HandleFunc("/api/chat/usage", srv.HandleUsage)
 
 	httpSrv := httptest.NewServer(mux)
 	t.Cleanup(httpSrv.Close)
@@ -450,6 +452,47 @@ func TestFullExportOmitsTurnsWhenStoreUnavailable(t *testing.T) {
 	require.NotContains(t, payload, "turns")
 }
 
+func TestUsageEndpointAggregatesLedger(t *testing.T) {
+	ledger := usagestore.NewMemoryLedger()
+	ctx := context.Background()
+	createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	for _, e := range []usagestore.Entry{
+		{SessionID: "s1", Profile: "default", Model: "gpt-4o", InputTokens: 100, OutputTokens: 10, CostUSD: 0.5, Priced: true, CreatedAtMs: createdAt},
+		{SessionID: "s1", Profile: "default", Model: "gpt-4o", InputTokens: 200, OutputTokens: 20, CostUSD: 0.25, Priced: true, CreatedAtMs: createdAt},
+		{SessionID: "s2", Profile: "agent", Model: "gpt-4o", InputTokens: 50, OutputTokens: 5, CreatedAtMs: createdAt},
+	} {
+		require.NoError(t, ledger.Record(ctx, e))
+	}
+	_, httpSrv := newTestMux(t, WithUsageLedger(ledger))
+
+	resp, err := http.Get(httpSrv.URL + "/api/chat/usage?group_by=profile&model=gpt-4o")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var out usageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	require.Equal(t, []string{"profile"}, out.GroupBy)
+	require.Len(t, out.Groups, 2)
+	require.Equal(t, "default", out.Groups[1].Keys["profile"])
+	require.Equal(t, int64(300), out.Groups[1].InputTokens)
+	require.InDelta(t, 0.75, out.Total.CostUSD, 1e-9)
+	require.Equal(t, int64(1), out.Total.UnpricedCalls)
+
+	badResp, err := http.Get(httpSrv.URL + "/api/chat/usage?group_by=project")
+	require.NoError(t, err)
+	_ = badResp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, badResp.StatusCode)
+}
+
+func TestUsageEndpointDisabledWithoutLedger(t *testing.T) {
+	_, httpSrv := newTestMux(t)
+
+	resp, err := http.Get(httpSrv.URL + "/api/chat/usage")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
 func TestSQLiteSnapshotPersistsAcrossRestart(t *testing.T) {
 	dbPath := filepath.Join(t.TempDir(), "evtstream-web-chat.db")
 	serverA, httpSrvA := newTestMux(t, WithHydrationStoreSpec(serverkit.StoreSpec{Backend: serverkit.StoreBackendSQLite, Path: dbPath}))
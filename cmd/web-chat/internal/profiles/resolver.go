@@ -153,6 +153,8 @@ func (r *RequestResolver) BuildConversationPlan(ctx context.Context, convID, pro
 		rt.ProfileVersion = resolvedPlan.ProfileVersion
 		rt.InferenceSettings = CloneResolvedInferenceSettings(resolvedPlan.InferenceSettings)
 		rt.ProfileMetadata = CopyMetadataMap(resolvedPlan.ProfileMetadata)
+		rt.UsagePricing = resolvedPlan.Pricing
+		rt.JSTools = resolvedPlan.JSTools
 		if resolvedPlan.Runtime != nil {
 			rt.SystemPrompt = strings.TrimSpace(resolvedPlan.Runtime.SystemPrompt)
 			rt.Middlewares = append([]infruntime.MiddlewareUse(nil), resolvedPlan.Runtime.Middlewares...)
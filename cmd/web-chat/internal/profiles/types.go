@@ -7,6 +7,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 )
 
 // RequestResolutionError is a typed error allowing handlers to choose an HTTP status code
@@ -47,6 +48,8 @@ type ResolvedRuntime struct {
 	ProfileVersion     uint64
 	InferenceSettings  *aisettings.InferenceSettings
 	ProfileMetadata    map[string]any
+	UsagePricing       *usagestore.PriceTable
+	JSTools            *infruntime.JSTools
 }
 
 // ProfileListItem is the JSON shape for profile listing.
@@ -63,6 +63,8 @@ func (r *canonicalRuntimeResolver) Resolve(ctx context.Context, req *http.Reques
 		ResolvedInferenceSettings:  profiles.CloneResolvedInferenceSettings(plan.Runtime.InferenceSettings),
 		ResolvedProfileRuntime:     profiles.ToRuntimeTransport(plan.Runtime),
 		ResolvedProfileFingerprint: plan.Runtime.RuntimeFingerprint,
+		ResolvedUsagePricing:       plan.Runtime.UsagePricing,
+		ResolvedJSTools:            plan.Runtime.JSTools,
 	})
 	if err != nil {
 		return nil, err
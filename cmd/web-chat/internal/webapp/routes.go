@@ -33,6 +33,7 @@ func NewMux(opts MuxOptions) *http.ServeMux {
 		mux.HandleFunc("/api/chat/sessions", opts.ChatServer.HandleCreateSession)
 		mux.HandleFunc("/api/chat/sessions/", opts.ChatServer.HandleSessionRoutes)
 		mux.HandleFunc("/api/chat/ws", opts.ChatServer.HandleWS)
+		mux.HandleFunc("/api/chat/usage", opts.ChatServer.HandleUsage)
 	}
 	mux.HandleFunc("/app-config.js", buildAppConfigHandler(opts.AppConfigJS))
 	RegisterStaticUIHandlers(mux, opts.StaticFS)
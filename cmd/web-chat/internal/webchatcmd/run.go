@@ -10,7 +10,6 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
 	"github.com/go-go-golems/pinocchio/cmd/web-chat/internal/appserver"
-	"github.com/go-go-golems/pinocchio/cmd/web-chat/internal/middlewaredefs"
 	agentmodeplugin "github.com/go-go-golems/pinocchio/cmd/web-chat/internal/plugins/agentmode"
 	"github.com/go-go-golems/pinocchio/cmd/web-chat/internal/profiles"
 	webchatruntime "github.com/go-go-golems/pinocchio/cmd/web-chat/internal/runtime"
@@ -20,21 +19,26 @@ import (
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
 	"github.com/go-go-golems/pinocchio/pkg/chatapp/widgets"
 	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
-	agentmode "github.com/go-go-golems/pinocchio/pkg/middlewares/agentmode"
+	"github.com/go-go-golems/pinocchio/pkg/inference/middlewaredefs"
+	"github.com/go-go-golems/pinocchio/pkg/inference/profilecomposer"
+	"github.com/go-go-golems/pinocchio/pkg/js/jstools"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	"github.com/pkg/errors"
 	zlog "github.com/rs/zerolog/log"
 )
 
 type ServerSettings struct {
-	Addr            string `glazed:"addr"`
-	Root            string `glazed:"root"`
-	TimelineBackend string `glazed:"timeline-backend"`
-	TimelineDSN     string `glazed:"timeline-dsn"`
-	TimelineDB      string `glazed:"timeline-db"`
-	TurnsBackend    string `glazed:"turns-backend"`
-	TurnsDSN        string `glazed:"turns-dsn"`
-	TurnsDB         string `glazed:"turns-db"`
+	Addr                  string   `glazed:"addr"`
+	Root                  string   `glazed:"root"`
+	TimelineBackend       string   `glazed:"timeline-backend"`
+	TimelineDSN           string   `glazed:"timeline-dsn"`
+	TimelineDB            string   `glazed:"timeline-db"`
+	TurnsBackend          string   `glazed:"turns-backend"`
+	TurnsDSN              string   `glazed:"turns-dsn"`
+	TurnsDB               string   `glazed:"turns-db"`
+	UsageDB               string   `glazed:"usage-db"`
+	JSToolsRoot           string   `glazed:"js-tools-root"`
+	JSToolsAllowedModules []string `glazed:"js-tools-allowed-modules"`
 }
 
 func Run(ctx context.Context, parsed *values.Values, staticFS fs.FS) error {
@@ -59,11 +63,7 @@ func Run(ctx context.Context, parsed *values.Values, staticFS fs.FS) error {
 		return errors.Wrap(err, "build runtime config script")
 	}
 
-	amSvc := agentmode.NewStaticService([]*agentmode.AgentMode{
-		{Name: "financial_analyst", Prompt: "You are a financial transaction analyst. Analyze transactions and propose categories."},
-		{Name: "category_regexp_designer", Prompt: "Design regex patterns to categorize transactions. Verify with SQL counts before proposing changes."},
-		{Name: "category_regexp_reviewer", Prompt: "Review proposed regex patterns and assess over/under matching risks."},
-	})
+	amSvc := middlewaredefs.NewDefaultAgentModeService()
 
 	middlewareRegistry, err := middlewaredefs.NewRegistry()
 	if err != nil {
@@ -86,12 +86,26 @@ func Run(ctx context.Context, parsed *values.Values, staticFS fs.FS) error {
 		return err
 	}
 	defer func() { _ = closeTurnStore() }()
+	usageLedger, closeUsageLedger, err := serverkit.OpenUsageLedger(s.UsageDB)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeUsageLedger() }()
+
+	// Profiles can only load JS tool modules once an operator names the
+	// directory they are confined to, and only get the go-go-goja modules the
+	// operator allows.
+	var jsToolsCache *jstools.Cache
+	if s.JSToolsRoot != "" {
+		jsToolsCache = jstools.NewCache(s.JSToolsRoot, s.JSToolsAllowedModules)
+		defer func() { _ = jsToolsCache.Close(context.Background()) }()
+	}
 
-	runtimeComposer := webchatruntime.NewProfileRuntimeComposer(middlewareRegistry, middlewarecfg.BuildDeps{
+	runtimeComposer := profilecomposer.NewProfileRuntimeComposer(middlewareRegistry, middlewarecfg.BuildDeps{
 		Values: map[string]any{
 			middlewaredefs.DependencyAgentModeServiceKey: amSvc,
 		},
-	}, baseInferenceSettings).WithTurnStore(turnStore)
+	}, baseInferenceSettings).WithTurnStore(turnStore).WithJSTools(jsToolsCache)
 
 	var (
 		profileRegistry     gepprofiles.Registry
@@ -120,8 +134,9 @@ func Run(ctx context.Context, parsed *values.Values, staticFS fs.FS) error {
 		appserver.WithRuntimeResolver(canonicalRuntimeResolver),
 		appserver.WithTurnStore(turnStore),
 		appserver.WithTurnsDBPath(s.TurnsDB),
+		appserver.WithUsageLedger(usageLedger),
 		appserver.WithFrontendToolManager(frontendToolManager),
-		appserver.WithChatPlugins(agentmodeplugin.NewPlugin(), plugins.NewReasoningPlugin(), plugins.NewToolCallPlugin(), frontendtools.NewPlugin(), widgets.NewWidgetPlugin()),
+		appserver.WithChatPlugins(agentmodeplugin.NewPlugin(), plugins.NewReasoningPlugin(), plugins.NewToolCallPlugin(), plugins.NewContextBudgetPlugin(), frontendtools.NewPlugin(), widgets.NewWidgetPlugin()),
 	)
 	if err != nil {
 		return errors.Wrap(err, "build canonical evtstream-backed app")
@@ -54,12 +54,15 @@ func NewCommand(staticFS fs.FS) (*Command, error) {
 			fields.New("evict-idle-seconds", fields.TypeInteger, fields.WithDefault(300), fields.WithHelp("Evict conversations after N seconds idle (0=disabled)")),
 			fields.New("evict-interval-seconds", fields.TypeInteger, fields.WithDefault(60), fields.WithHelp("Sweep idle conversations every N seconds (0=disabled)")),
 			fields.New("root", fields.TypeString, fields.WithDefault("/"), fields.WithHelp("Serve the chat UI under a given URL root (e.g., /chat)")),
-			fields.New("timeline-backend", fields.TypeChoice, fields.WithDefault(""), fields.WithChoices("", "disabled", "memory", "sqlite", "mysql"), fields.WithHelp("Timeline persistence backend; required when timeline-dsn is set")),
-			fields.New("timeline-dsn", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite or MySQL DSN for durable timeline snapshots; interpreted only by timeline-backend")),
+			fields.New("timeline-backend", fields.TypeChoice, fields.WithDefault(""), fields.WithChoices("", "disabled", "memory", "sqlite", "mysql", "postgres"), fields.WithHelp("Timeline persistence backend; required when timeline-dsn is set")),
+			fields.New("timeline-dsn", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite, MySQL or PostgreSQL DSN for durable timeline snapshots; interpreted only by timeline-backend")),
 			fields.New("timeline-db", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite DB file path for durable timeline snapshots; backend defaults to SQLite when set")),
-			fields.New("turns-backend", fields.TypeChoice, fields.WithDefault(""), fields.WithChoices("", "disabled", "memory", "sqlite", "mysql"), fields.WithHelp("Turn persistence backend; required when turns-dsn is set")),
-			fields.New("turns-dsn", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite or MySQL DSN for durable turn snapshots; interpreted only by turns-backend")),
+			fields.New("turns-backend", fields.TypeChoice, fields.WithDefault(""), fields.WithChoices("", "disabled", "memory", "sqlite", "mysql", "postgres"), fields.WithHelp("Turn persistence backend; required when turns-dsn is set")),
+			fields.New("turns-dsn", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite, MySQL or PostgreSQL DSN for durable turn snapshots; interpreted only by turns-backend")),
 			fields.New("turns-db", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite DB file path for durable turn snapshots; backend defaults to SQLite when set")),
+			fields.New("usage-db", fields.TypeString, fields.WithDefault(""), fields.WithHelp("SQLite DB file path for the provider-call usage ledger; /api/chat/usage is disabled when empty")),
+			fields.New("js-tools-root", fields.TypeString, fields.WithDefault(""), fields.WithHelp("Directory that pinocchio.js_tools@v1 profile modules resolve against and may not leave; JS tools are disabled when empty")),
+			fields.New("js-tools-allowed-modules", fields.TypeStringList, fields.WithDefault([]string{}), fields.WithHelp("go-go-goja modules such as fs or exec that pinocchio.js_tools@v1 profiles may list in allowed_modules; profiles asking for others fail to compose")),
 		),
 		cmds.WithSections(profileSettingsSection, clientSection, redisLayer),
 	)
@@ -1,6 +1,7 @@
 export {
   pinocchioAgentModeAdapter,
   pinocchioBackendToolAdapter,
+  pinocchioContextBudgetAdapter,
   pinocchioReasoningAdapter,
   pinocchioWebChatTimelineAdapters,
 } from './pinocchioTimelineAdapters';
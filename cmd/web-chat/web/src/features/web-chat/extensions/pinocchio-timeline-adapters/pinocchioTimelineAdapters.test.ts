@@ -1,6 +1,6 @@
 import { createTimelineAdapterRegistry } from '@go-go-golems/chat-provider';
 import { describe, expect, it } from 'vitest';
-import { pinocchioAgentModeAdapter, pinocchioBackendToolAdapter } from './pinocchioTimelineAdapters';
+import { pinocchioAgentModeAdapter, pinocchioBackendToolAdapter, pinocchioContextBudgetAdapter } from './pinocchioTimelineAdapters';
 
 describe('pinocchio timeline adapters baseline parity', () => {
   it('projects live and hydrated AgentMode entities to agent_mode cards', () => {
@@ -81,4 +81,29 @@ describe('pinocchio timeline adapters baseline parity', () => {
     expect(call?.mutation.upsert?.props.toolName).toBe('mock.search');
     expect(result?.mutation.upsert?.props.toolName).toBe('mock.search');
   });
+
+  it('projects live and hydrated context compactions to log cards', () => {
+    const registry = createTimelineAdapterRegistry();
+    registry.register(pinocchioContextBudgetAdapter);
+
+    const payload = {
+      messageId: 'm-ctx',
+      strategy: 'summarize',
+      budgetTokens: 1000,
+      tokensBefore: 2400,
+      tokensAfter: 900,
+      blocksRemoved: 12,
+      summary: 'The user asked about BTC.',
+    };
+    const live = registry.projectLive({ name: 'ChatContextCompacted', payload }, { sessionId: 's1' });
+    const snapshot = registry.projectSnapshot({ id: 'm-ctx:context', kind: 'ContextCompaction', payload }, { sessionId: 's1' });
+
+    expect(live?.adapterName).toBe('pinocchio.context-budget');
+    expect(snapshot?.adapterName).toBe('pinocchio.context-budget');
+    expect(live?.mutation.upsert?.kind).toBe('log');
+    expect(snapshot?.mutation.upsert?.id).toBe('m-ctx:context');
+    expect(live?.mutation.upsert?.id).toBe('m-ctx:context');
+    expect(String(snapshot?.mutation.upsert?.props.message)).toContain('2400 → 900 tokens of 1000');
+    expect(live?.mutation.upsert?.props.level).toBe('info');
+  });
 });
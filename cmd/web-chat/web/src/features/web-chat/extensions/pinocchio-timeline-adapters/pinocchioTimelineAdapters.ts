@@ -28,6 +28,36 @@ function toolResultEntity(id: string, props: Record<string, unknown>) {
   return { id, kind: 'tool_result', createdAt: now(), updatedAt: now(), props };
 }
 
+function logEntity(id: string, props: Record<string, unknown>) {
+  return { id, kind: 'log', createdAt: now(), updatedAt: now(), props };
+}
+
+function contextCompactionEntity(id: string, payload: Record<string, unknown>) {
+  const strategy = asString(payload.strategy) || 'drop_oldest';
+  const before = Number(payload.tokensBefore ?? 0);
+  const after = Number(payload.tokensAfter ?? 0);
+  const budget = Number(payload.budgetTokens ?? 0);
+  const removed = Number(payload.blocksRemoved ?? 0);
+  const summary = asString(payload.summary);
+  const overBudget = payload.overBudget === true;
+  let message = `Context compacted (${strategy}): ${before} → ${after} tokens of ${budget}, ${removed} blocks removed.`;
+  if (overBudget) message += ' Still over budget.';
+  if (summary) message += `\nSummary: ${summary}`;
+  return logEntity(id, {
+    level: overBudget ? 'warn' : 'info',
+    message,
+    messageId: asString(payload.messageId),
+    strategy,
+    tokensBefore: before,
+    tokensAfter: after,
+    budgetTokens: budget,
+    blocksRemoved: removed,
+    summary,
+    summaryBlockId: asString(payload.summaryBlockId),
+    overBudget,
+  });
+}
+
 function agentModePreviewEntityId(messageId: string): string {
   return `agent-mode-preview:${messageId}`;
 }
@@ -285,7 +315,30 @@ export const pinocchioBackendToolAdapter = defineLiveAndHydrateAdapter({
   },
 });
 
+export const pinocchioContextBudgetAdapter = defineLiveAndHydrateAdapter({
+  name: 'pinocchio.context-budget',
+  priority: -10,
+  live: {
+    accepts: (frame) => asString(frame.name) === 'ChatContextCompacted',
+    project(frame): TimelineMutation | null {
+      const payload = payloadRecord(frame.payload);
+      const messageId = asString(payload.messageId);
+      if (!messageId) return null;
+      return { upsert: contextCompactionEntity(`${messageId}:context`, payload) };
+    },
+  },
+  hydrate: {
+    kind: 'supported',
+    project(entity) {
+      if (asString(entity.kind) !== 'ContextCompaction') return null;
+      const id = asString(entity.id);
+      if (!id) return null;
+      return contextCompactionEntity(id, payloadRecord(entity.payload));
+    },
+  },
+});
+
 export const pinocchioWebChatTimelineAdapters = defineChatExtensions({
   name: 'pinocchio.web-chat.timeline-adapters',
-  timelineAdapters: [pinocchioReasoningAdapter, pinocchioAgentModeAdapter, pinocchioBackendToolAdapter],
+  timelineAdapters: [pinocchioReasoningAdapter, pinocchioAgentModeAdapter, pinocchioBackendToolAdapter, pinocchioContextBudgetAdapter],
 });
@@ -10,7 +10,7 @@ import { enumDesc, fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv2";
  * Describes the file pinocchio/chatapp/v1/chat.proto.
  */
 export const file_pinocchio_chatapp_v1_chat: GenFile = /*@__PURE__*/
-  fileDesc("Ch9waW5vY2NoaW8vY2hhdGFwcC92MS9jaGF0LnByb3RvEhRwaW5vY2NoaW8uY2hhdGFwcC52MSKiAgoOQ2hhdEF0dGFjaG1lbnQSFQoNYXR0YWNobWVudF9pZBgBIAEoCRIMCgRraW5kGAIgASgJEhIKCm1lZGlhX3R5cGUYAyABKAkSCwoDdXJsGAQgASgJEhIKCnNpemVfYnl0ZXMYBSABKAQSDQoFd2lkdGgYBiABKA0SDgoGaGVpZ2h0GAcgASgNEhAKCGZpbGVuYW1lGAggASgJEg4KBmRldGFpbBgJIAEoCRJECghtZXRhZGF0YRgKIAMoCzIyLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRBdHRhY2htZW50Lk1ldGFkYXRhRW50cnkaLwoNTWV0YWRhdGFFbnRyeRILCgNrZXkYASABKAkSDQoFdmFsdWUYAiABKAk6AjgBIo8BChVTdGFydEluZmVyZW5jZUNvbW1hbmQSDgoGcHJvbXB0GAEgASgJEhcKD2lkZW1wb3RlbmN5X2tleRgCIAEoCRISCgpyZXF1ZXN0X2lkGAMgASgJEjkKC2F0dGFjaG1lbnRzGAQgAygLMiQucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdEF0dGFjaG1lbnQiFgoUU3RvcEluZmVyZW5jZUNvbW1hbmQilQEKCVVzYWdlSW5mbxIUCgxpbnB1dF90b2tlbnMYASABKAUSFQoNb3V0cHV0X3Rva2VucxgCIAEoBRIVCg1jYWNoZWRfdG9rZW5zGAMgASgFEiMKG2NhY2hlX2NyZWF0aW9uX2lucHV0X3Rva2VucxgEIAEoBRIfChdjYWNoZV9yZWFkX2lucHV0X3Rva2VucxgFIAEoBSKKAQoPQ29ycmVsYXRpb25JbmZvEhIKCnNlc3Npb25faWQYASABKAkSDgoGcnVuX2lkGAIgASgJEg8KB3R1cm5faWQYBCABKAkSGAoQcHJvdmlkZXJfY2FsbF9pZBgFIAEoCRISCgpzZWdtZW50X2lkGA8gASgJEhQKDHRvb2xfY2FsbF9pZBgTIAEoCSJwCg5DaGF0UnVuU3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEg4KBnByb21wdBgCIAEoCRI6Cgtjb3JyZWxhdGlvbhgDIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKqAQoPQ2hhdFJ1bkZpbmlzaGVkEhIKCm1lc3NhZ2VfaWQYASABKAkSDgoGc3RhdHVzGAIgASgJEg0KBWVycm9yGAMgASgJEhgKC2R1cmF0aW9uX21zGAQgASgDSACIAQESOgoLY29ycmVsYXRpb24YBSABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm9CDgoMX2R1cmF0aW9uX21zIn8KDkNoYXRSdW5TdG9wcGVkEhIKCm1lc3NhZ2VfaWQYASABKAkSDgoGc3RhdHVzGAIgASgJEg0KBWVycm9yGAMgASgJEjoKC2NvcnJlbGF0aW9uGAQgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIn4KDUNoYXRSdW5GYWlsZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIOCgZzdGF0dXMYAiABKAkSDQoFZXJyb3IYAyABKAkSOgoLY29ycmVsYXRpb24YBCABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iVQoXQ2hhdFByb3ZpZGVyQ2FsbFN0YXJ0ZWQSOgoLY29ycmVsYXRpb24YASABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iogEKH0NoYXRQcm92aWRlckNhbGxNZXRhZGF0YVVwZGF0ZWQSEwoLc3RvcF9yZWFzb24YASABKAkSLgoFdXNhZ2UYAiABKAsyHy5waW5vY2NoaW8uY2hhdGFwcC52MS5Vc2FnZUluZm8SOgoLY29ycmVsYXRpb24YAyABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8i8wEKGENoYXRQcm92aWRlckNhbGxGaW5pc2hlZBITCgtzdG9wX3JlYXNvbhgBIAEoCRIUCgxmaW5pc2hfY2xhc3MYAiABKAkSLgoFdXNhZ2UYAyABKAsyHy5waW5vY2NoaW8uY2hhdGFwcC52MS5Vc2FnZUluZm8SGAoLZHVyYXRpb25fbXMYBCABKANIAIgBARIWCg5oYXNfdG9vbF9jYWxscxgFIAEoCBI6Cgtjb3JyZWxhdGlvbhgGIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mb0IOCgxfZHVyYXRpb25fbXMiqQEKFkNoYXRUZXh0U2VnbWVudFN0YXJ0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgRyb2xlGAIgASgJEg4KBnByb21wdBgDIAEoCRIOCgZzdGF0dXMYBCABKAkSEQoJc3RyZWFtaW5nGAUgASgIEjoKC2NvcnJlbGF0aW9uGAYgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIq8CCg1DaGF0VGV4dFBhdGNoEhIKCm1lc3NhZ2VfaWQYASABKAkSDAoEcm9sZRgCIAEoCRIRCglzdHJlYW1faWQYAyABKAkSEAoIc2VxdWVuY2UYBCABKAQSDgoGb2Zmc2V0GAUgASgEEgwKBHRleHQYBiABKAkSNwoEbW9kZRgHIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDgoGc3RhdHVzGAggASgJEg0KBWZpbmFsGAkgASgIEhUKDWZpbmlzaF9yZWFzb24YCiABKAkSDgoGcHJvbXB0GAsgASgJEjoKC2NvcnJlbGF0aW9uGAwgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvInkKDUNoYXRUZXh0RGVsdGESEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgR0ZXh0GAIgASgJEjcKBG1vZGUYAyABKA4yKS5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0U3RyZWFtUGF0Y2hNb2RlEg0KBWZpbmFsGAQgASgIIu8BChdDaGF0VGV4dFNlZ21lbnRGaW5pc2hlZBISCgptZXNzYWdlX2lkGAEgASgJEgwKBHJvbGUYAiABKAkSDgoGcHJvbXB0GAMgASgJEgwKBHRleHQYBCABKAkSDwoHY29udGVudBgFIAEoCRIOCgZzdGF0dXMYBiABKAkSEQoJc3RyZWFtaW5nGAcgASgIEg0KBWZpbmFsGAggASgIEhUKDWZpbmlzaF9yZWFzb24YCSABKAkSOgoLY29ycmVsYXRpb24YCiABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8imQEKEkNoYXRSZWFzb25pbmdEZWx0YRISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHRleHQYAyABKAkSNwoEbW9kZRgEIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDQoFZmluYWwYBSABKAgiyQEKG0NoYXRSZWFzb25pbmdTZWdtZW50U3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSDgoGc3RhdHVzGAQgASgJEhEKCXN0cmVhbWluZxgFIAEoCBIOCgZzb3VyY2UYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8izwIKEkNoYXRSZWFzb25pbmdQYXRjaBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSEQoJc3RyZWFtX2lkGAQgASgJEhAKCHNlcXVlbmNlGAUgASgEEg4KBm9mZnNldBgGIAEoBBIMCgR0ZXh0GAcgASgJEjcKBG1vZGUYCCABKA4yKS5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0U3RyZWFtUGF0Y2hNb2RlEg4KBnN0YXR1cxgJIAEoCRINCgVmaW5hbBgKIAEoCBIOCgZzb3VyY2UYCyABKAkSFQoNZmluaXNoX3JlYXNvbhgMIAEoCRI6Cgtjb3JyZWxhdGlvbhgNIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKAAgocQ2hhdFJlYXNvbmluZ1NlZ21lbnRGaW5pc2hlZBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSDAoEdGV4dBgEIAEoCRIPCgdjb250ZW50GAUgASgJEg4KBnN0YXR1cxgGIAEoCRIRCglzdHJlYW1pbmcYByABKAgSDgoGc291cmNlGAggASgJEhUKDWZpbmlzaF9yZWFzb24YCSABKAkSOgoLY29ycmVsYXRpb24YCiABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iwAEKE0NoYXRUb29sQ2FsbFN0YXJ0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg0KBWlucHV0GAQgASgJEhEKCWV4ZWN1dGluZxgFIAEoCBIOCgZzdGF0dXMYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8isAEKFkNoYXRUb29sQXJndW1lbnRzRGVsdGESEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEhEKCWFyZ3VtZW50cxgEIAEoCRI3CgRtb2RlGAUgASgOMikucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdFN0cmVhbVBhdGNoTW9kZRINCgVmaW5hbBgGIAEoCCKxAgoWQ2hhdFRvb2xBcmd1bWVudHNQYXRjaBISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSEQoJc3RyZWFtX2lkGAQgASgJEhAKCHNlcXVlbmNlGAUgASgEEg4KBm9mZnNldBgGIAEoBBIRCglhcmd1bWVudHMYByABKAkSNwoEbW9kZRgIIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDgoGc3RhdHVzGAkgASgJEg0KBWZpbmFsGAogASgIEjoKC2NvcnJlbGF0aW9uGAsgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIsIBChVDaGF0VG9vbENhbGxSZXF1ZXN0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg0KBWlucHV0GAQgASgJEhEKCWV4ZWN1dGluZxgFIAEoCBIOCgZzdGF0dXMYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8ixQEKGENoYXRUb29sRXhlY3V0aW9uU3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSDQoFaW5wdXQYBCABKAkSEQoJZXhlY3V0aW5nGAUgASgIEg4KBnN0YXR1cxgGIAEoCRI6Cgtjb3JyZWxhdGlvbhgHIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKuAQoTQ2hhdFRvb2xSZXN1bHRSZWFkeRISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSDgoGcmVzdWx0GAQgASgJEg4KBnN0YXR1cxgFIAEoCRI6Cgtjb3JyZWxhdGlvbhgGIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKfAQoUQ2hhdFRvb2xDYWxsRmluaXNoZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg4KBnN0YXR1cxgEIAEoCRI6Cgtjb3JyZWxhdGlvbhgFIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyK1AQoXQ2hhdFVzZXJNZXNzYWdlQWNjZXB0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgRyb2xlGAIgASgJEg4KBnByb21wdBgDIAEoCRIMCgR0ZXh0GAQgASgJEg8KB2NvbnRlbnQYBSABKAkSDgoGc3RhdHVzGAYgASgJEjkKC2F0dGFjaG1lbnRzGAcgAygLMiQucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdEF0dGFjaG1lbnQi3gIKEUNoYXRNZXNzYWdlRW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSDAoEcm9sZRgCIAEoCRIOCgZwcm9tcHQYAyABKAkSDAoEdGV4dBgEIAEoCRIPCgdjb250ZW50GAUgASgJEg4KBnN0YXR1cxgGIAEoCRIRCglzdHJlYW1pbmcYByABKAgSDQoFZXJyb3IYCCABKAkSGQoRcGFyZW50X21lc3NhZ2VfaWQYCSABKAkSDwoHc2VnbWVudBgKIAEoBRIUCgxzZWdtZW50X3R5cGUYCyABKAkSDQoFZmluYWwYDCABKAgSOgoLY29ycmVsYXRpb24YDSABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8SOQoLYXR0YWNobWVudHMYDiADKAsyJC5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0QXR0YWNobWVudCJ8ChZBZ2VudE1vZGVQcmV2aWV3VXBkYXRlEhIKCm1lc3NhZ2VfaWQYASABKAkSFgoOY2FuZGlkYXRlX21vZGUYAiABKAkSEAoIYW5hbHlzaXMYAyABKAkSEwoLcGFyc2Vfc3RhdGUYBCABKAkSDwoHcHJldmlldxgFIAEoCCJ6ChhBZ2VudE1vZGVDb21taXR0ZWRVcGRhdGUSEgoKbWVzc2FnZV9pZBgBIAEoCRINCgV0aXRsZRgCIAEoCRIMCgRmcm9tGAMgASgJEgoKAnRvGAQgASgJEhAKCGFuYWx5c2lzGAUgASgJEg8KB3ByZXZpZXcYBiABKAgiLQoXQWdlbnRNb2RlUHJldmlld0NsZWFyZWQSEgoKbWVzc2FnZV9pZBgBIAEoCSJxCg9BZ2VudE1vZGVFbnRpdHkSEgoKbWVzc2FnZV9pZBgBIAEoCRINCgV0aXRsZRgCIAEoCRIMCgRmcm9tGAMgASgJEgoKAnRvGAQgASgJEhAKCGFuYWx5c2lzGAUgASgJEg8KB3ByZXZpZXcYBiABKAgiuwEKDlRvb2xDYWxsRW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSFAoMdG9vbF9jYWxsX2lkGAIgASgJEhEKCXRvb2xfbmFtZRgDIAEoCRINCgVpbnB1dBgEIAEoCRIRCglleGVjdXRpbmcYBSABKAgSDgoGc3RhdHVzGAYgASgJEjoKC2NvcnJlbGF0aW9uGAcgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIqsBChBUb29sUmVzdWx0RW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSFAoMdG9vbF9jYWxsX2lkGAIgASgJEhEKCXRvb2xfbmFtZRgDIAEoCRIOCgZyZXN1bHQYBCABKAkSDgoGc3RhdHVzGAUgASgJEjoKC2NvcnJlbGF0aW9uGAYgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvKqkBChNDaGF0U3RyZWFtUGF0Y2hNb2RlEiYKIkNIQVRfU1RSRUFNX1BBVENIX01PREVfVU5TUEVDSUZJRUQQABIhCh1DSEFUX1NUUkVBTV9QQVRDSF9NT0RFX0FQUEVORBABEiMKH0NIQVRfU1RSRUFNX1BBVENIX01PREVfU05BUFNIT1QQAhIiCh5DSEFUX1NUUkVBTV9QQVRDSF9NT0RFX1JFUExBQ0UQA0JXWlVnaXRodWIuY29tL2dvLWdvLWdvbGVtcy9waW5vY2NoaW8vcGtnL2NoYXRhcHAvcGIvcHJvdG8vcGlub2NjaGlvL2NoYXRhcHAvdjE7Y2hhdGFwcHYxYgZwcm90bzM");
+  fileDesc("Ch9waW5vY2NoaW8vY2hhdGFwcC92MS9jaGF0LnByb3RvEhRwaW5vY2NoaW8uY2hhdGFwcC52MSKiAgoOQ2hhdEF0dGFjaG1lbnQSFQoNYXR0YWNobWVudF9pZBgBIAEoCRIMCgRraW5kGAIgASgJEhIKCm1lZGlhX3R5cGUYAyABKAkSCwoDdXJsGAQgASgJEhIKCnNpemVfYnl0ZXMYBSABKAQSDQoFd2lkdGgYBiABKA0SDgoGaGVpZ2h0GAcgASgNEhAKCGZpbGVuYW1lGAggASgJEg4KBmRldGFpbBgJIAEoCRJECghtZXRhZGF0YRgKIAMoCzIyLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRBdHRhY2htZW50Lk1ldGFkYXRhRW50cnkaLwoNTWV0YWRhdGFFbnRyeRILCgNrZXkYASABKAkSDQoFdmFsdWUYAiABKAk6AjgBIo8BChVTdGFydEluZmVyZW5jZUNvbW1hbmQSDgoGcHJvbXB0GAEgASgJEhcKD2lkZW1wb3RlbmN5X2tleRgCIAEoCRISCgpyZXF1ZXN0X2lkGAMgASgJEjkKC2F0dGFjaG1lbnRzGAQgAygLMiQucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdEF0dGFjaG1lbnQiFgoUU3RvcEluZmVyZW5jZUNvbW1hbmQilQEKCVVzYWdlSW5mbxIUCgxpbnB1dF90b2tlbnMYASABKAUSFQoNb3V0cHV0X3Rva2VucxgCIAEoBRIVCg1jYWNoZWRfdG9rZW5zGAMgASgFEiMKG2NhY2hlX2NyZWF0aW9uX2lucHV0X3Rva2VucxgEIAEoBRIfChdjYWNoZV9yZWFkX2lucHV0X3Rva2VucxgFIAEoBSKKAQoPQ29ycmVsYXRpb25JbmZvEhIKCnNlc3Npb25faWQYASABKAkSDgoGcnVuX2lkGAIgASgJEg8KB3R1cm5faWQYBCABKAkSGAoQcHJvdmlkZXJfY2FsbF9pZBgFIAEoCRISCgpzZWdtZW50X2lkGA8gASgJEhQKDHRvb2xfY2FsbF9pZBgTIAEoCSJwCg5DaGF0UnVuU3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEg4KBnByb21wdBgCIAEoCRI6Cgtjb3JyZWxhdGlvbhgDIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKqAQoPQ2hhdFJ1bkZpbmlzaGVkEhIKCm1lc3NhZ2VfaWQYASABKAkSDgoGc3RhdHVzGAIgASgJEg0KBWVycm9yGAMgASgJEhgKC2R1cmF0aW9uX21zGAQgASgDSACIAQESOgoLY29ycmVsYXRpb24YBSABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm9CDgoMX2R1cmF0aW9uX21zIn8KDkNoYXRSdW5TdG9wcGVkEhIKCm1lc3NhZ2VfaWQYASABKAkSDgoGc3RhdHVzGAIgASgJEg0KBWVycm9yGAMgASgJEjoKC2NvcnJlbGF0aW9uGAQgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIn4KDUNoYXRSdW5GYWlsZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIOCgZzdGF0dXMYAiABKAkSDQoFZXJyb3IYAyABKAkSOgoLY29ycmVsYXRpb24YBCABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iVQoXQ2hhdFByb3ZpZGVyQ2FsbFN0YXJ0ZWQSOgoLY29ycmVsYXRpb24YASABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iogEKH0NoYXRQcm92aWRlckNhbGxNZXRhZGF0YVVwZGF0ZWQSEwoLc3RvcF9yZWFzb24YASABKAkSLgoFdXNhZ2UYAiABKAsyHy5waW5vY2NoaW8uY2hhdGFwcC52MS5Vc2FnZUluZm8SOgoLY29ycmVsYXRpb24YAyABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8i8wEKGENoYXRQcm92aWRlckNhbGxGaW5pc2hlZBITCgtzdG9wX3JlYXNvbhgBIAEoCRIUCgxmaW5pc2hfY2xhc3MYAiABKAkSLgoFdXNhZ2UYAyABKAsyHy5waW5vY2NoaW8uY2hhdGFwcC52MS5Vc2FnZUluZm8SGAoLZHVyYXRpb25fbXMYBCABKANIAIgBARIWCg5oYXNfdG9vbF9jYWxscxgFIAEoCBI6Cgtjb3JyZWxhdGlvbhgGIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mb0IOCgxfZHVyYXRpb25fbXMiqQEKFkNoYXRUZXh0U2VnbWVudFN0YXJ0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgRyb2xlGAIgASgJEg4KBnByb21wdBgDIAEoCRIOCgZzdGF0dXMYBCABKAkSEQoJc3RyZWFtaW5nGAUgASgIEjoKC2NvcnJlbGF0aW9uGAYgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIq8CCg1DaGF0VGV4dFBhdGNoEhIKCm1lc3NhZ2VfaWQYASABKAkSDAoEcm9sZRgCIAEoCRIRCglzdHJlYW1faWQYAyABKAkSEAoIc2VxdWVuY2UYBCABKAQSDgoGb2Zmc2V0GAUgASgEEgwKBHRleHQYBiABKAkSNwoEbW9kZRgHIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDgoGc3RhdHVzGAggASgJEg0KBWZpbmFsGAkgASgIEhUKDWZpbmlzaF9yZWFzb24YCiABKAkSDgoGcHJvbXB0GAsgASgJEjoKC2NvcnJlbGF0aW9uGAwgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvInkKDUNoYXRUZXh0RGVsdGESEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgR0ZXh0GAIgASgJEjcKBG1vZGUYAyABKA4yKS5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0U3RyZWFtUGF0Y2hNb2RlEg0KBWZpbmFsGAQgASgIIu8BChdDaGF0VGV4dFNlZ21lbnRGaW5pc2hlZBISCgptZXNzYWdlX2lkGAEgASgJEgwKBHJvbGUYAiABKAkSDgoGcHJvbXB0GAMgASgJEgwKBHRleHQYBCABKAkSDwoHY29udGVudBgFIAEoCRIOCgZzdGF0dXMYBiABKAkSEQoJc3RyZWFtaW5nGAcgASgIEg0KBWZpbmFsGAggASgIEhUKDWZpbmlzaF9yZWFzb24YCSABKAkSOgoLY29ycmVsYXRpb24YCiABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8imQEKEkNoYXRSZWFzb25pbmdEZWx0YRISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHRleHQYAyABKAkSNwoEbW9kZRgEIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDQoFZmluYWwYBSABKAgiyQEKG0NoYXRSZWFzb25pbmdTZWdtZW50U3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSDgoGc3RhdHVzGAQgASgJEhEKCXN0cmVhbWluZxgFIAEoCBIOCgZzb3VyY2UYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8izwIKEkNoYXRSZWFzb25pbmdQYXRjaBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSEQoJc3RyZWFtX2lkGAQgASgJEhAKCHNlcXVlbmNlGAUgASgEEg4KBm9mZnNldBgGIAEoBBIMCgR0ZXh0GAcgASgJEjcKBG1vZGUYCCABKA4yKS5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0U3RyZWFtUGF0Y2hNb2RlEg4KBnN0YXR1cxgJIAEoCRINCgVmaW5hbBgKIAEoCBIOCgZzb3VyY2UYCyABKAkSFQoNZmluaXNoX3JlYXNvbhgMIAEoCRI6Cgtjb3JyZWxhdGlvbhgNIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKAAgocQ2hhdFJlYXNvbmluZ1NlZ21lbnRGaW5pc2hlZBISCgptZXNzYWdlX2lkGAEgASgJEhkKEXBhcmVudF9tZXNzYWdlX2lkGAIgASgJEgwKBHJvbGUYAyABKAkSDAoEdGV4dBgEIAEoCRIPCgdjb250ZW50GAUgASgJEg4KBnN0YXR1cxgGIAEoCRIRCglzdHJlYW1pbmcYByABKAgSDgoGc291cmNlGAggASgJEhUKDWZpbmlzaF9yZWFzb24YCSABKAkSOgoLY29ycmVsYXRpb24YCiABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8iwAEKE0NoYXRUb29sQ2FsbFN0YXJ0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg0KBWlucHV0GAQgASgJEhEKCWV4ZWN1dGluZxgFIAEoCBIOCgZzdGF0dXMYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8isAEKFkNoYXRUb29sQXJndW1lbnRzRGVsdGESEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEhEKCWFyZ3VtZW50cxgEIAEoCRI3CgRtb2RlGAUgASgOMikucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdFN0cmVhbVBhdGNoTW9kZRINCgVmaW5hbBgGIAEoCCKxAgoWQ2hhdFRvb2xBcmd1bWVudHNQYXRjaBISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSEQoJc3RyZWFtX2lkGAQgASgJEhAKCHNlcXVlbmNlGAUgASgEEg4KBm9mZnNldBgGIAEoBBIRCglhcmd1bWVudHMYByABKAkSNwoEbW9kZRgIIAEoDjIpLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNoYXRTdHJlYW1QYXRjaE1vZGUSDgoGc3RhdHVzGAkgASgJEg0KBWZpbmFsGAogASgIEjoKC2NvcnJlbGF0aW9uGAsgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIsIBChVDaGF0VG9vbENhbGxSZXF1ZXN0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg0KBWlucHV0GAQgASgJEhEKCWV4ZWN1dGluZxgFIAEoCBIOCgZzdGF0dXMYBiABKAkSOgoLY29ycmVsYXRpb24YByABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8ixQEKGENoYXRUb29sRXhlY3V0aW9uU3RhcnRlZBISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSDQoFaW5wdXQYBCABKAkSEQoJZXhlY3V0aW5nGAUgASgIEg4KBnN0YXR1cxgGIAEoCRI6Cgtjb3JyZWxhdGlvbhgHIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKuAQoTQ2hhdFRvb2xSZXN1bHRSZWFkeRISCgptZXNzYWdlX2lkGAEgASgJEhQKDHRvb2xfY2FsbF9pZBgCIAEoCRIRCgl0b29sX25hbWUYAyABKAkSDgoGcmVzdWx0GAQgASgJEg4KBnN0YXR1cxgFIAEoCRI6Cgtjb3JyZWxhdGlvbhgGIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyKfAQoUQ2hhdFRvb2xDYWxsRmluaXNoZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIUCgx0b29sX2NhbGxfaWQYAiABKAkSEQoJdG9vbF9uYW1lGAMgASgJEg4KBnN0YXR1cxgEIAEoCRI6Cgtjb3JyZWxhdGlvbhgFIAEoCzIlLnBpbm9jY2hpby5jaGF0YXBwLnYxLkNvcnJlbGF0aW9uSW5mbyK1AQoXQ2hhdFVzZXJNZXNzYWdlQWNjZXB0ZWQSEgoKbWVzc2FnZV9pZBgBIAEoCRIMCgRyb2xlGAIgASgJEg4KBnByb21wdBgDIAEoCRIMCgR0ZXh0GAQgASgJEg8KB2NvbnRlbnQYBSABKAkSDgoGc3RhdHVzGAYgASgJEjkKC2F0dGFjaG1lbnRzGAcgAygLMiQucGlub2NjaGlvLmNoYXRhcHAudjEuQ2hhdEF0dGFjaG1lbnQi3gIKEUNoYXRNZXNzYWdlRW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSDAoEcm9sZRgCIAEoCRIOCgZwcm9tcHQYAyABKAkSDAoEdGV4dBgEIAEoCRIPCgdjb250ZW50GAUgASgJEg4KBnN0YXR1cxgGIAEoCRIRCglzdHJlYW1pbmcYByABKAgSDQoFZXJyb3IYCCABKAkSGQoRcGFyZW50X21lc3NhZ2VfaWQYCSABKAkSDwoHc2VnbWVudBgKIAEoBRIUCgxzZWdtZW50X3R5cGUYCyABKAkSDQoFZmluYWwYDCABKAgSOgoLY29ycmVsYXRpb24YDSABKAsyJS5waW5vY2NoaW8uY2hhdGFwcC52MS5Db3JyZWxhdGlvbkluZm8SOQoLYXR0YWNobWVudHMYDiADKAsyJC5waW5vY2NoaW8uY2hhdGFwcC52MS5DaGF0QXR0YWNobWVudCJ8ChZBZ2VudE1vZGVQcmV2aWV3VXBkYXRlEhIKCm1lc3NhZ2VfaWQYASABKAkSFgoOY2FuZGlkYXRlX21vZGUYAiABKAkSEAoIYW5hbHlzaXMYAyABKAkSEwoLcGFyc2Vfc3RhdGUYBCABKAkSDwoHcHJldmlldxgFIAEoCCJ6ChhBZ2VudE1vZGVDb21taXR0ZWRVcGRhdGUSEgoKbWVzc2FnZV9pZBgBIAEoCRINCgV0aXRsZRgCIAEoCRIMCgRmcm9tGAMgASgJEgoKAnRvGAQgASgJEhAKCGFuYWx5c2lzGAUgASgJEg8KB3ByZXZpZXcYBiABKAgiLQoXQWdlbnRNb2RlUHJldmlld0NsZWFyZWQSEgoKbWVzc2FnZV9pZBgBIAEoCSJxCg9BZ2VudE1vZGVFbnRpdHkSEgoKbWVzc2FnZV9pZBgBIAEoCRINCgV0aXRsZRgCIAEoCRIMCgRmcm9tGAMgASgJEgoKAnRvGAQgASgJEhAKCGFuYWx5c2lzGAUgASgJEg8KB3ByZXZpZXcYBiABKAgiuwEKDlRvb2xDYWxsRW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSFAoMdG9vbF9jYWxsX2lkGAIgASgJEhEKCXRvb2xfbmFtZRgDIAEoCRINCgVpbnB1dBgEIAEoCRIRCglleGVjdXRpbmcYBSABKAgSDgoGc3RhdHVzGAYgASgJEjoKC2NvcnJlbGF0aW9uGAcgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIqsBChBUb29sUmVzdWx0RW50aXR5EhIKCm1lc3NhZ2VfaWQYASABKAkSFAoMdG9vbF9jYWxsX2lkGAIgASgJEhEKCXRvb2xfbmFtZRgDIAEoCRIOCgZyZXN1bHQYBCABKAkSDgoGc3RhdHVzGAUgASgJEjoKC2NvcnJlbGF0aW9uGAYgASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIpQCChRDaGF0Q29udGV4dENvbXBhY3RlZBISCgptZXNzYWdlX2lkGAEgASgJEhAKCHN0cmF0ZWd5GAIgASgJEhUKDWJ1ZGdldF90b2tlbnMYAyABKAUSFQoNdG9rZW5zX2JlZm9yZRgEIAEoBRIUCgx0b2tlbnNfYWZ0ZXIYBSABKAUSFgoOYmxvY2tzX3JlbW92ZWQYBiABKAUSDwoHc3VtbWFyeRgHIAEoCRIYChBzdW1tYXJ5X2Jsb2NrX2lkGAggASgJEhMKC292ZXJfYnVkZ2V0GAkgASgIEjoKC2NvcnJlbGF0aW9uGAogASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvIpcCChdDb250ZXh0Q29tcGFjdGlvbkVudGl0eRISCgptZXNzYWdlX2lkGAEgASgJEhAKCHN0cmF0ZWd5GAIgASgJEhUKDWJ1ZGdldF90b2tlbnMYAyABKAUSFQoNdG9rZW5zX2JlZm9yZRgEIAEoBRIUCgx0b2tlbnNfYWZ0ZXIYBSABKAUSFgoOYmxvY2tzX3JlbW92ZWQYBiABKAUSDwoHc3VtbWFyeRgHIAEoCRIYChBzdW1tYXJ5X2Jsb2NrX2lkGAggASgJEhMKC292ZXJfYnVkZ2V0GAkgASgIEjoKC2NvcnJlbGF0aW9uGAogASgLMiUucGlub2NjaGlvLmNoYXRhcHAudjEuQ29ycmVsYXRpb25JbmZvKqkBChNDaGF0U3RyZWFtUGF0Y2hNb2RlEiYKIkNIQVRfU1RSRUFNX1BBVENIX01PREVfVU5TUEVDSUZJRUQQABIhCh1DSEFUX1NUUkVBTV9QQVRDSF9NT0RFX0FQUEVORBABEiMKH0NIQVRfU1RSRUFNX1BBVENIX01PREVfU05BUFNIT1QQAhIiCh5DSEFUX1NUUkVBTV9QQVRDSF9NT0RFX1JFUExBQ0UQA0JXWlVnaXRodWIuY29tL2dvLWdvLWdvbGVtcy9waW5vY2NoaW8vcGtnL2NoYXRhcHAvcGIvcHJvdG8vcGlub2NjaGlvL2NoYXRhcHAvdjE7Y2hhdGFwcHYxYgZwcm90bzM");
 
 /**
  * ChatAttachment describes a user-provided attachment (currently images) by
@@ -1541,6 +1541,130 @@ export type ToolResultEntity = Message<"pinocchio.chatapp.v1.ToolResultEntity">
 export const ToolResultEntitySchema: GenMessage<ToolResultEntity> = /*@__PURE__*/
   messageDesc(file_pinocchio_chatapp_v1_chat, 34);
 
+/**
+ * @generated from message pinocchio.chatapp.v1.ChatContextCompacted
+ */
+export type ChatContextCompacted = Message<"pinocchio.chatapp.v1.ChatContextCompacted"> & {
+  /**
+   * @generated from field: string message_id = 1;
+   */
+  messageId: string;
+
+  /**
+   * @generated from field: string strategy = 2;
+   */
+  strategy: string;
+
+  /**
+   * @generated from field: int32 budget_tokens = 3;
+   */
+  budgetTokens: number;
+
+  /**
+   * @generated from field: int32 tokens_before = 4;
+   */
+  tokensBefore: number;
+
+  /**
+   * @generated from field: int32 tokens_after = 5;
+   */
+  tokensAfter: number;
+
+  /**
+   * @generated from field: int32 blocks_removed = 6;
+   */
+  blocksRemoved: number;
+
+  /**
+   * @generated from field: string summary = 7;
+   */
+  summary: string;
+
+  /**
+   * @generated from field: string summary_block_id = 8;
+   */
+  summaryBlockId: string;
+
+  /**
+   * @generated from field: bool over_budget = 9;
+   */
+  overBudget: boolean;
+
+  /**
+   * @generated from field: pinocchio.chatapp.v1.CorrelationInfo correlation = 10;
+   */
+  correlation?: CorrelationInfo | undefined;
+};
+
+/**
+ * Describes the message pinocchio.chatapp.v1.ChatContextCompacted.
+ * Use `create(ChatContextCompactedSchema)` to create a new message.
+ */
+export const ChatContextCompactedSchema: GenMessage<ChatContextCompacted> = /*@__PURE__*/
+  messageDesc(file_pinocchio_chatapp_v1_chat, 35);
+
+/**
+ * @generated from message pinocchio.chatapp.v1.ContextCompactionEntity
+ */
+export type ContextCompactionEntity = Message<"pinocchio.chatapp.v1.ContextCompactionEntity"> & {
+  /**
+   * @generated from field: string message_id = 1;
+   */
+  messageId: string;
+
+  /**
+   * @generated from field: string strategy = 2;
+   */
+  strategy: string;
+
+  /**
+   * @generated from field: int32 budget_tokens = 3;
+   */
+  budgetTokens: number;
+
+  /**
+   * @generated from field: int32 tokens_before = 4;
+   */
+  tokensBefore: number;
+
+  /**
+   * @generated from field: int32 tokens_after = 5;
+   */
+  tokensAfter: number;
+
+  /**
+   * @generated from field: int32 blocks_removed = 6;
+   */
+  blocksRemoved: number;
+
+  /**
+   * @generated from field: string summary = 7;
+   */
+  summary: string;
+
+  /**
+   * @generated from field: string summary_block_id = 8;
+   */
+  summaryBlockId: string;
+
+  /**
+   * @generated from field: bool over_budget = 9;
+   */
+  overBudget: boolean;
+
+  /**
+   * @generated from field: pinocchio.chatapp.v1.CorrelationInfo correlation = 10;
+   */
+  correlation?: CorrelationInfo | undefined;
+};
+
+/**
+ * Describes the message pinocchio.chatapp.v1.ContextCompactionEntity.
+ * Use `create(ContextCompactionEntitySchema)` to create a new message.
+ */
+export const ContextCompactionEntitySchema: GenMessage<ContextCompactionEntity> = /*@__PURE__*/
+  messageDesc(file_pinocchio_chatapp_v1_chat, 36);
+
 /**
  * @generated from enum pinocchio.chatapp.v1.ChatStreamPatchMode
  */
@@ -0,0 +1,25 @@
+const pinocchio = require("pinocchio");
+
+const profile = pinocchio.profiles.resolve("assistant");
+console.log(`profile ${profile.registry}/${profile.profile} uses ${profile.model}`);
+
+const session = pinocchio.chat.session({
+  profile: "assistant",
+  systemPrompt: "Answer in one short sentence.",
+});
+
+const unsubscribe = session.subscribe((ev) => {
+  if (ev.name === "ChatRunStarted" || ev.name === "ChatRunFinished") {
+    console.error(`[${ev.ordinal}] ${ev.name}`);
+  }
+});
+
+const first = session.prompt("What is a pinocchio?");
+console.log(first.text);
+const second = session.prompt("And who wrote the story?");
+console.log(second.text);
+unsubscribe();
+
+const timeline = pinocchio.export.timeline(session.id, { view: "messages" });
+console.log(`${timeline.entities.length} timeline entities`);
+session.close();
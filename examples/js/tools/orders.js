@@ -0,0 +1,41 @@
+// Example JS tools module for `pinocchio js --tools-module` and the
+// pinocchio.js_tools@v1 profile extension.
+const tools = require("pinocchio/tools");
+
+const orders = {
+  "A-100": { status: "shipped", carrier: "UPS" },
+  "A-101": { status: "processing" },
+};
+
+tools.registerTool({
+  name: "lookup_order",
+  description: "Look up the status of an order by id.",
+  parameters: {
+    type: "object",
+    properties: {
+      id: { type: "string", description: "Order id, e.g. A-100" },
+    },
+    required: ["id"],
+  },
+  handler: (args) => {
+    const order = orders[args.id];
+    if (!order) {
+      throw new Error("unknown order " + args.id);
+    }
+    return Object.assign({ id: args.id }, order);
+  },
+});
+
+tools.registerMiddleware({
+  name: "tag_turns",
+  description: "Stamps a label on every outgoing turn.",
+  schema: {
+    type: "object",
+    properties: { label: { type: "string" } },
+  },
+  factory: (config) => (turn) => {
+    turn.metadata = turn.metadata || {};
+    turn.metadata["example.label@v1"] = config.label || "js";
+    return turn;
+  },
+});
@@ -8,6 +8,7 @@ import (
 
 	chatappv1 "github.com/go-go-golems/pinocchio/pkg/chatapp/pb/proto/pinocchio/chatapp/v1"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 )
 
@@ -64,6 +65,7 @@ type Engine struct {
 	hooks               Hooks
 	features            []ChatPlugin
 	turnStore           chatstore.TurnStore
+	usageLedger         usagestore.Ledger
 	streamPatchBatch    StreamPatchBatchConfig
 	uiEventTransformers []UIEventTransformer
 }
@@ -131,6 +133,14 @@ func WithTurnStore(ts chatstore.TurnStore) Option {
 	}
 }
 
+// WithUsageLedger records the usage of every finished provider call of a
+// runtime inference, attributed to the session, runtime key, profile and model.
+func WithUsageLedger(l usagestore.Ledger) Option {
+	return func(e *Engine) {
+		e.usageLedger = l
+	}
+}
+
 func NewEngine(opts ...Option) *Engine {
 	engine := &Engine{
 		active:             map[sessionstream.SessionId]*activeRun{},
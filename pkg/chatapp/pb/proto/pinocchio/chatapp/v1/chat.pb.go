@@ -3094,6 +3094,238 @@ func (x *ToolResultEntity) GetCorrelation() *CorrelationInfo {
 	return nil
 }
 
+type ChatContextCompacted struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MessageId      string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Strategy       string                 `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	BudgetTokens   int32                  `protobuf:"varint,3,opt,name=budget_tokens,json=budgetTokens,proto3" json:"budget_tokens,omitempty"`
+	TokensBefore   int32                  `protobuf:"varint,4,opt,name=tokens_before,json=tokensBefore,proto3" json:"tokens_before,omitempty"`
+	TokensAfter    int32                  `protobuf:"varint,5,opt,name=tokens_after,json=tokensAfter,proto3" json:"tokens_after,omitempty"`
+	BlocksRemoved  int32                  `protobuf:"varint,6,opt,name=blocks_removed,json=blocksRemoved,proto3" json:"blocks_removed,omitempty"`
+	Summary        string                 `protobuf:"bytes,7,opt,name=summary,proto3" json:"summary,omitempty"`
+	SummaryBlockId string                 `protobuf:"bytes,8,opt,name=summary_block_id,json=summaryBlockId,proto3" json:"summary_block_id,omitempty"`
+	OverBudget     bool                   `protobuf:"varint,9,opt,name=over_budget,json=overBudget,proto3" json:"over_budget,omitempty"`
+	Correlation    *CorrelationInfo       `protobuf:"bytes,10,opt,name=correlation,proto3" json:"correlation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChatContextCompacted) Reset() {
+	*x = ChatContextCompacted{}
+	mi := &file_pinocchio_chatapp_v1_chat_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatContextCompacted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatContextCompacted) ProtoMessage() {}
+
+func (x *ChatContextCompacted) ProtoReflect() protoreflect.Message {
+	mi := &file_pinocchio_chatapp_v1_chat_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatContextCompacted.ProtoReflect.Descriptor instead.
+func (*ChatContextCompacted) Descriptor() ([]byte, []int) {
+	return file_pinocchio_chatapp_v1_chat_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ChatContextCompacted) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ChatContextCompacted) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *ChatContextCompacted) GetBudgetTokens() int32 {
+	if x != nil {
+		return x.BudgetTokens
+	}
+	return 0
+}
+
+func (x *ChatContextCompacted) GetTokensBefore() int32 {
+	if x != nil {
+		return x.TokensBefore
+	}
+	return 0
+}
+
+func (x *ChatContextCompacted) GetTokensAfter() int32 {
+	if x != nil {
+		return x.TokensAfter
+	}
+	return 0
+}
+
+func (x *ChatContextCompacted) GetBlocksRemoved() int32 {
+	if x != nil {
+		return x.BlocksRemoved
+	}
+	return 0
+}
+
+func (x *ChatContextCompacted) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *ChatContextCompacted) GetSummaryBlockId() string {
+	if x != nil {
+		return x.SummaryBlockId
+	}
+	return ""
+}
+
+func (x *ChatContextCompacted) GetOverBudget() bool {
+	if x != nil {
+		return x.OverBudget
+	}
+	return false
+}
+
+func (x *ChatContextCompacted) GetCorrelation() *CorrelationInfo {
+	if x != nil {
+		return x.Correlation
+	}
+	return nil
+}
+
+type ContextCompactionEntity struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MessageId      string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Strategy       string                 `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	BudgetTokens   int32                  `protobuf:"varint,3,opt,name=budget_tokens,json=budgetTokens,proto3" json:"budget_tokens,omitempty"`
+	TokensBefore   int32                  `protobuf:"varint,4,opt,name=tokens_before,json=tokensBefore,proto3" json:"tokens_before,omitempty"`
+	TokensAfter    int32                  `protobuf:"varint,5,opt,name=tokens_after,json=tokensAfter,proto3" json:"tokens_after,omitempty"`
+	BlocksRemoved  int32                  `protobuf:"varint,6,opt,name=blocks_removed,json=blocksRemoved,proto3" json:"blocks_removed,omitempty"`
+	Summary        string                 `protobuf:"bytes,7,opt,name=summary,proto3" json:"summary,omitempty"`
+	SummaryBlockId string                 `protobuf:"bytes,8,opt,name=summary_block_id,json=summaryBlockId,proto3" json:"summary_block_id,omitempty"`
+	OverBudget     bool                   `protobuf:"varint,9,opt,name=over_budget,json=overBudget,proto3" json:"over_budget,omitempty"`
+	Correlation    *CorrelationInfo       `protobuf:"bytes,10,opt,name=correlation,proto3" json:"correlation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ContextCompactionEntity) Reset() {
+	*x = ContextCompactionEntity{}
+	mi := &file_pinocchio_chatapp_v1_chat_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContextCompactionEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContextCompactionEntity) ProtoMessage() {}
+
+func (x *ContextCompactionEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_pinocchio_chatapp_v1_chat_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContextCompactionEntity.ProtoReflect.Descriptor instead.
+func (*ContextCompactionEntity) Descriptor() ([]byte, []int) {
+	return file_pinocchio_chatapp_v1_chat_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ContextCompactionEntity) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *ContextCompactionEntity) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *ContextCompactionEntity) GetBudgetTokens() int32 {
+	if x != nil {
+		return x.BudgetTokens
+	}
+	return 0
+}
+
+func (x *ContextCompactionEntity) GetTokensBefore() int32 {
+	if x != nil {
+		return x.TokensBefore
+	}
+	return 0
+}
+
+func (x *ContextCompactionEntity) GetTokensAfter() int32 {
+	if x != nil {
+		return x.TokensAfter
+	}
+	return 0
+}
+
+func (x *ContextCompactionEntity) GetBlocksRemoved() int32 {
+	if x != nil {
+		return x.BlocksRemoved
+	}
+	return 0
+}
+
+func (x *ContextCompactionEntity) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *ContextCompactionEntity) GetSummaryBlockId() string {
+	if x != nil {
+		return x.SummaryBlockId
+	}
+	return ""
+}
+
+func (x *ContextCompactionEntity) GetOverBudget() bool {
+	if x != nil {
+		return x.OverBudget
+	}
+	return false
+}
+
+func (x *ContextCompactionEntity) GetCorrelation() *CorrelationInfo {
+	if x != nil {
+		return x.Correlation
+	}
+	return nil
+}
+
 var File_pinocchio_chatapp_v1_chat_proto protoreflect.FileDescriptor
 
 const file_pinocchio_chatapp_v1_chat_proto_rawDesc = "" +
@@ -3411,7 +3643,35 @@ const file_pinocchio_chatapp_v1_chat_proto_rawDesc = "" +
 	"\ttool_name\x18\x03 \x01(\tR\btoolName\x12\x16\n" +
 	"\x06result\x18\x04 \x01(\tR\x06result\x12\x16\n" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12G\n" +
-	"\vcorrelation\x18\x06 \x01(\v2%.pinocchio.chatapp.v1.CorrelationInfoR\vcorrelation*\xa9\x01\n" +
+	"\vcorrelation\x18\x06 \x01(\v2%.pinocchio.chatapp.v1.CorrelationInfoR\vcorrelation\"\x93\x03\n" +
+	"\x14ChatContextCompacted\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x1a\n" +
+	"\bstrategy\x18\x02 \x01(\tR\bstrategy\x12#\n" +
+	"\rbudget_tokens\x18\x03 \x01(\x05R\fbudgetTokens\x12#\n" +
+	"\rtokens_before\x18\x04 \x01(\x05R\ftokensBefore\x12!\n" +
+	"\ftokens_after\x18\x05 \x01(\x05R\vtokensAfter\x12%\n" +
+	"\x0eblocks_removed\x18\x06 \x01(\x05R\rblocksRemoved\x12\x18\n" +
+	"\asummary\x18\a \x01(\tR\asummary\x12(\n" +
+	"\x10summary_block_id\x18\b \x01(\tR\x0esummaryBlockId\x12\x1f\n" +
+	"\vover_budget\x18\t \x01(\bR\n" +
+	"overBudget\x12G\n" +
+	"\vcorrelation\x18\n" +
+	" \x01(\v2%.pinocchio.chatapp.v1.CorrelationInfoR\vcorrelation\"\x96\x03\n" +
+	"\x17ContextCompactionEntity\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x1a\n" +
+	"\bstrategy\x18\x02 \x01(\tR\bstrategy\x12#\n" +
+	"\rbudget_tokens\x18\x03 \x01(\x05R\fbudgetTokens\x12#\n" +
+	"\rtokens_before\x18\x04 \x01(\x05R\ftokensBefore\x12!\n" +
+	"\ftokens_after\x18\x05 \x01(\x05R\vtokensAfter\x12%\n" +
+	"\x0eblocks_removed\x18\x06 \x01(\x05R\rblocksRemoved\x12\x18\n" +
+	"\asummary\x18\a \x01(\tR\asummary\x12(\n" +
+	"\x10summary_block_id\x18\b \x01(\tR\x0esummaryBlockId\x12\x1f\n" +
+	"\vover_budget\x18\t \x01(\bR\n" +
+	"overBudget\x12G\n" +
+	"\vcorrelation\x18\n" +
+	" \x01(\v2%.pinocchio.chatapp.v1.CorrelationInfoR\vcorrelation*\xa9\x01\n" +
 	"\x13ChatStreamPatchMode\x12&\n" +
 	"\"CHAT_STREAM_PATCH_MODE_UNSPECIFIED\x10\x00\x12!\n" +
 	"\x1dCHAT_STREAM_PATCH_MODE_APPEND\x10\x01\x12#\n" +
@@ -3431,7 +3691,7 @@ func file_pinocchio_chatapp_v1_chat_proto_rawDescGZIP() []byte {
 }
 
 var file_pinocchio_chatapp_v1_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pinocchio_chatapp_v1_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
+var file_pinocchio_chatapp_v1_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_pinocchio_chatapp_v1_chat_proto_goTypes = []any{
 	(ChatStreamPatchMode)(0),                // 0: pinocchio.chatapp.v1.ChatStreamPatchMode
 	(*ChatAttachment)(nil),                  // 1: pinocchio.chatapp.v1.ChatAttachment
@@ -3469,10 +3729,12 @@ var file_pinocchio_chatapp_v1_chat_proto_goTypes = []any{
 	(*AgentModeEntity)(nil),                 // 33: pinocchio.chatapp.v1.AgentModeEntity
 	(*ToolCallEntity)(nil),                  // 34: pinocchio.chatapp.v1.ToolCallEntity
 	(*ToolResultEntity)(nil),                // 35: pinocchio.chatapp.v1.ToolResultEntity
-	nil,                                     // 36: pinocchio.chatapp.v1.ChatAttachment.MetadataEntry
+	(*ChatContextCompacted)(nil),            // 36: pinocchio.chatapp.v1.ChatContextCompacted
+	(*ContextCompactionEntity)(nil),         // 37: pinocchio.chatapp.v1.ContextCompactionEntity
+	nil,                                     // 38: pinocchio.chatapp.v1.ChatAttachment.MetadataEntry
 }
 var file_pinocchio_chatapp_v1_chat_proto_depIdxs = []int32{
-	36, // 0: pinocchio.chatapp.v1.ChatAttachment.metadata:type_name -> pinocchio.chatapp.v1.ChatAttachment.MetadataEntry
+	38, // 0: pinocchio.chatapp.v1.ChatAttachment.metadata:type_name -> pinocchio.chatapp.v1.ChatAttachment.MetadataEntry
 	1,  // 1: pinocchio.chatapp.v1.StartInferenceCommand.attachments:type_name -> pinocchio.chatapp.v1.ChatAttachment
 	5,  // 2: pinocchio.chatapp.v1.ChatRunStarted.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
 	5,  // 3: pinocchio.chatapp.v1.ChatRunFinished.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
@@ -3506,11 +3768,13 @@ var file_pinocchio_chatapp_v1_chat_proto_depIdxs = []int32{
 	1,  // 31: pinocchio.chatapp.v1.ChatMessageEntity.attachments:type_name -> pinocchio.chatapp.v1.ChatAttachment
 	5,  // 32: pinocchio.chatapp.v1.ToolCallEntity.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
 	5,  // 33: pinocchio.chatapp.v1.ToolResultEntity.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
-	34, // [34:34] is the sub-list for method output_type
-	34, // [34:34] is the sub-list for method input_type
-	34, // [34:34] is the sub-list for extension type_name
-	34, // [34:34] is the sub-list for extension extendee
-	0,  // [0:34] is the sub-list for field type_name
+	5,  // 34: pinocchio.chatapp.v1.ChatContextCompacted.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
+	5,  // 35: pinocchio.chatapp.v1.ContextCompactionEntity.correlation:type_name -> pinocchio.chatapp.v1.CorrelationInfo
+	36, // [36:36] is the sub-list for method output_type
+	36, // [36:36] is the sub-list for method input_type
+	36, // [36:36] is the sub-list for extension type_name
+	36, // [36:36] is the sub-list for extension extendee
+	0,  // [0:36] is the sub-list for field type_name
 }
 
 func init() { file_pinocchio_chatapp_v1_chat_proto_init() }
@@ -3526,7 +3790,7 @@ func file_pinocchio_chatapp_v1_chat_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pinocchio_chatapp_v1_chat_proto_rawDesc), len(file_pinocchio_chatapp_v1_chat_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   36,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	chatapp "github.com/go-go-golems/pinocchio/pkg/chatapp"
+	chatappv1 "github.com/go-go-golems/pinocchio/pkg/chatapp/pb/proto/pinocchio/chatapp/v1"
+	"github.com/go-go-golems/pinocchio/pkg/middlewares/contextbudget"
+	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// EventContextCompacted is published when the context_budget middleware
+	// shrank the turn before inference.
+	EventContextCompacted = "ChatContextCompacted"
+
+	// TimelineEntityContextCompaction is the timeline entity kind for compactions.
+	TimelineEntityContextCompaction = "ContextCompaction"
+)
+
+// ContextBudgetPlugin translates context_budget compaction events into
+// canonical Pinocchio events and timeline entities, so a chat shows where
+// older history was dropped or summarized.
+type ContextBudgetPlugin struct{}
+
+// NewContextBudgetPlugin creates a new ContextBudgetPlugin.
+func NewContextBudgetPlugin() chatapp.ChatPlugin { return &ContextBudgetPlugin{} }
+
+// RegisterSchemas registers the compaction event, UI event, and timeline entity schemas.
+func (p *ContextBudgetPlugin) RegisterSchemas(reg *sessionstream.SchemaRegistry) error {
+	for _, err := range []error{
+		reg.RegisterEvent(EventContextCompacted, &chatappv1.ChatContextCompacted{}),
+		reg.RegisterUIEvent(EventContextCompacted, &chatappv1.ChatContextCompacted{}),
+		reg.RegisterTimelineEntity(TimelineEntityContextCompaction, &chatappv1.ContextCompactionEntity{}),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleRuntimeEvent handles compaction events from the context_budget middleware.
+func (p *ContextBudgetPlugin) HandleRuntimeEvent(ctx context.Context, runtime chatapp.RuntimeEventContext, event gepevents.Event) (bool, error) {
+	ev, ok := event.(*contextbudget.EventContextCompacted)
+	if !ok {
+		return false, nil
+	}
+	meta := ev.Metadata()
+	return true, runtime.Publish(ctx, EventContextCompacted, &chatappv1.ChatContextCompacted{
+		MessageId:      runtime.MessageID,
+		Strategy:       ev.Strategy,
+		BudgetTokens:   chatapp.Int32FromInt(ev.BudgetTokens),
+		TokensBefore:   chatapp.Int32FromInt(ev.TokensBefore),
+		TokensAfter:    chatapp.Int32FromInt(ev.TokensAfter),
+		BlocksRemoved:  chatapp.Int32FromInt(ev.BlocksRemoved),
+		Summary:        ev.Summary,
+		SummaryBlockId: ev.SummaryBlockID,
+		OverBudget:     ev.OverBudget,
+		Correlation:    &chatappv1.CorrelationInfo{SessionId: meta.SessionID, TurnId: meta.TurnID},
+	})
+}
+
+// ProjectUI forwards compaction events as UI events.
+func (p *ContextBudgetPlugin) ProjectUI(_ context.Context, ev sessionstream.Event, _ *sessionstream.Session, _ sessionstream.TimelineView) ([]sessionstream.UIEvent, bool, error) {
+	if ev.Name != EventContextCompacted {
+		return nil, false, nil
+	}
+	payload, ok := ev.Payload.(*chatappv1.ChatContextCompacted)
+	if !ok || payload == nil {
+		return nil, true, fmt.Errorf("context compaction payload must be %T, got %T", &chatappv1.ChatContextCompacted{}, ev.Payload)
+	}
+	return []sessionstream.UIEvent{{Name: ev.Name, Payload: proto.Clone(payload)}}, true, nil
+}
+
+// ProjectTimeline projects compaction events into one entity per message. A
+// tool loop that compacts again within the same message replaces the entity.
+func (p *ContextBudgetPlugin) ProjectTimeline(_ context.Context, ev sessionstream.Event, _ *sessionstream.Session, _ sessionstream.TimelineView) ([]sessionstream.TimelineEntity, bool, error) {
+	if ev.Name != EventContextCompacted {
+		return nil, false, nil
+	}
+	payload, ok := ev.Payload.(*chatappv1.ChatContextCompacted)
+	if !ok || payload == nil {
+		return nil, true, fmt.Errorf("context compaction payload must be %T, got %T", &chatappv1.ChatContextCompacted{}, ev.Payload)
+	}
+	return []sessionstream.TimelineEntity{{
+		Kind: TimelineEntityContextCompaction,
+		Id:   payload.GetMessageId() + ":context",
+		Payload: &chatappv1.ContextCompactionEntity{
+			MessageId:      payload.GetMessageId(),
+			Strategy:       payload.GetStrategy(),
+			BudgetTokens:   payload.GetBudgetTokens(),
+			TokensBefore:   payload.GetTokensBefore(),
+			TokensAfter:    payload.GetTokensAfter(),
+			BlocksRemoved:  payload.GetBlocksRemoved(),
+			Summary:        payload.GetSummary(),
+			SummaryBlockId: payload.GetSummaryBlockId(),
+			OverBudget:     payload.GetOverBudget(),
+			Correlation:    chatapp.CloneCorrelationInfo(payload.GetCorrelation()),
+		},
+	}}, true, nil
+}
+
+// Ensure ContextBudgetPlugin implements ChatPlugin.
+var _ chatapp.ChatPlugin = (*ContextBudgetPlugin)(nil)
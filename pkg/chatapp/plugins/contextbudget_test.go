@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	chatapp "github.com/go-go-golems/pinocchio/pkg/chatapp"
+	chatappv1 "github.com/go-go-golems/pinocchio/pkg/chatapp/pb/proto/pinocchio/chatapp/v1"
+	"github.com/go-go-golems/pinocchio/pkg/middlewares/contextbudget"
+	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestContextBudgetPluginPublishesCompaction(t *testing.T) {
+	plugin := NewContextBudgetPlugin()
+	var published []sessionstream.Event
+	runtime := chatapp.RuntimeEventContext{
+		SessionID: "sid",
+		MessageID: "chat-msg-1",
+		Publish: func(_ context.Context, eventName string, payload proto.Message) error {
+			published = append(published, sessionstream.Event{Name: eventName, SessionId: "sid", Payload: payload})
+			return nil
+		},
+	}
+	event := contextbudget.NewContextCompactedEvent(gepevents.EventMetadata{SessionID: "sid", TurnID: "turn-1"}, contextbudget.Result{
+		Strategy:       contextbudget.StrategySummarize,
+		BudgetTokens:   1000,
+		TokensBefore:   2400,
+		TokensAfter:    900,
+		BlocksRemoved:  12,
+		Summary:        "The user asked about BTC.",
+		SummaryBlockID: "block-1",
+	})
+
+	handled, err := plugin.HandleRuntimeEvent(context.Background(), runtime, event)
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.Len(t, published, 1)
+	require.Equal(t, EventContextCompacted, published[0].Name)
+	payload := published[0].Payload.(*chatappv1.ChatContextCompacted)
+	require.Equal(t, "chat-msg-1", payload.GetMessageId())
+	require.Equal(t, int32(2400), payload.GetTokensBefore())
+	require.Equal(t, int32(12), payload.GetBlocksRemoved())
+	require.Equal(t, "turn-1", payload.GetCorrelation().GetTurnId())
+
+	handled, err = plugin.HandleRuntimeEvent(context.Background(), runtime, gepevents.NewToolCallFinishedEvent(gepevents.EventMetadata{}, gepevents.Correlation{}, "call-1", "lookup", "completed"))
+	require.NoError(t, err)
+	require.False(t, handled)
+}
+
+func TestContextBudgetPluginProjectsUIAndTimeline(t *testing.T) {
+	plugin := NewContextBudgetPlugin()
+	ev := sessionstream.Event{Name: EventContextCompacted, SessionId: "sid", Ordinal: 3, Payload: &chatappv1.ChatContextCompacted{
+		MessageId:     "chat-msg-1",
+		Strategy:      contextbudget.StrategyDropOldest,
+		BudgetTokens:  1000,
+		TokensBefore:  1500,
+		TokensAfter:   980,
+		BlocksRemoved: 4,
+	}}
+
+	uiEvents, handled, err := plugin.ProjectUI(context.Background(), ev, nil, toolCallStaticTimelineView{})
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.Len(t, uiEvents, 1)
+	require.Equal(t, EventContextCompacted, uiEvents[0].Name)
+
+	entities, handled, err := plugin.ProjectTimeline(context.Background(), ev, nil, toolCallStaticTimelineView{})
+	require.NoError(t, err)
+	require.True(t, handled)
+	require.Len(t, entities, 1)
+	require.Equal(t, TimelineEntityContextCompaction, entities[0].Kind)
+	require.Equal(t, "chat-msg-1:context", entities[0].Id)
+	entity := entities[0].Payload.(*chatappv1.ContextCompactionEntity)
+	require.Equal(t, contextbudget.StrategyDropOldest, entity.GetStrategy())
+	require.Equal(t, int32(980), entity.GetTokensAfter())
+
+	_, handled, err = plugin.ProjectTimeline(context.Background(), sessionstream.Event{Name: EventToolCallFinished}, nil, toolCallStaticTimelineView{})
+	require.NoError(t, err)
+	require.False(t, handled)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	storesqlite "github.com/go-go-golems/sessionstream/pkg/sessionstream/hydration/sqlite"
 )
@@ -15,6 +16,7 @@ type RunnerOptions struct {
 	HydrationStore sessionstream.HydrationStore
 	UIFanout       sessionstream.UIFanout
 	TurnStore      chatstore.TurnStore
+	UsageLedger    usagestore.Ledger
 	Plugins        []ChatPlugin
 	ChunkDelay     time.Duration
 }
@@ -59,6 +61,7 @@ func NewRunner(opts RunnerOptions) (*Runner, error) {
 	engineOptions := []Option{
 		WithPlugins(opts.Plugins...),
 		WithTurnStore(opts.TurnStore),
+		WithUsageLedger(opts.UsageLedger),
 	}
 	if opts.ChunkDelay > 0 {
 		engineOptions = append(engineOptions, WithChunkDelay(opts.ChunkDelay))
@@ -9,6 +9,7 @@ import (
 
 	gepevents "github.com/go-go-golems/geppetto/pkg/events"
 	chatappv1 "github.com/go-go-golems/pinocchio/pkg/chatapp/pb/proto/pinocchio/chatapp/v1"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	"google.golang.org/protobuf/proto"
 )
@@ -26,6 +27,9 @@ type runtimeEventSink struct {
 	sessionID           sessionstream.SessionId
 	messageID           string
 	prompt              string
+	runtimeKey          string
+	profile             string
+	usagePricing        *usagestore.PriceTable
 	pub                 sessionstream.EventPublisher
 	engine              *Engine
 	lastText            string
@@ -62,6 +66,7 @@ func (s *runtimeEventSink) PublishEvent(event gepevents.Event) error {
 	case *gepevents.EventProviderCallMetadataUpdated:
 		return s.engine.publish(s.publishContext(), s.sessionID, s.pub, EventChatProviderCallMetadataUpdated, &chatappv1.ChatProviderCallMetadataUpdated{StopReason: ev.StopReason, Usage: usageInfoFromGeppetto(ev.Usage), Correlation: correlationInfoFromEvent(ev)})
 	case *gepevents.EventProviderCallFinished:
+		s.recordUsage(ev)
 		return s.engine.publish(s.publishContext(), s.sessionID, s.pub, EventChatProviderCallFinished, &chatappv1.ChatProviderCallFinished{StopReason: ev.StopReason, FinishClass: ev.FinishClass, Usage: usageInfoFromGeppetto(ev.Usage), DurationMs: ev.DurationMs, HasToolCalls: ev.HasToolCalls, Correlation: correlationInfoFromEvent(ev)})
 	case *gepevents.EventTextSegmentStarted:
 		corr := ev.Correlation()
@@ -429,3 +434,7 @@ func Uint64FromInt(value int) uint64 {
 	// #nosec G115 -- value is non-negative and Go int fits in uint64 on supported architectures.
 	return uint64(value)
 }
+
+func Int32FromInt(value int) int32 {
+	return intToInt32Saturating(value)
+}
@@ -10,10 +10,13 @@ import (
 	"sync"
 
 	"github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/hydrationpg"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 	"github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	storemysql "github.com/go-go-golems/sessionstream/pkg/sessionstream/hydration/mysql"
 	storesqlite "github.com/go-go-golems/sessionstream/pkg/sessionstream/hydration/sqlite"
 	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
 )
 
 // StoreBackend identifies the persistence implementation selected by a
@@ -26,10 +29,11 @@ const (
 	StoreBackendMemory   StoreBackend = "memory"
 	StoreBackendSQLite   StoreBackend = "sqlite"
 	StoreBackendMySQL    StoreBackend = "mysql"
+	StoreBackendPostgres StoreBackend = "postgres"
 )
 
 // StoreSpec describes one persistence component. Path is the SQLite file-path
-// convenience; DSN is used by SQLite or MySQL according to Backend.
+// convenience; DSN is used by SQLite, MySQL or PostgreSQL according to Backend.
 type StoreSpec struct {
 	Backend StoreBackend
 	DSN     string
@@ -44,10 +48,12 @@ type StoreOptions struct {
 // StoreFactory owns persistence construction so every composition root can use
 // the same backend policy and tests can inject constructor spies.
 type StoreFactory struct {
-	OpenSQLiteTurn      func(string) (chatstore.TurnStore, error)
-	OpenMySQLTurn       func(context.Context, string) (chatstore.TurnStore, error)
-	OpenSQLiteHydration func(string, *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error)
-	OpenMySQLHydration  func(context.Context, string, *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error)
+	OpenSQLiteTurn        func(string) (chatstore.TurnStore, error)
+	OpenMySQLTurn         func(context.Context, string) (chatstore.TurnStore, error)
+	OpenPostgresTurn      func(context.Context, string) (chatstore.TurnStore, error)
+	OpenSQLiteHydration   func(string, *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error)
+	OpenMySQLHydration    func(context.Context, string, *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error)
+	OpenPostgresHydration func(context.Context, string, *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error)
 }
 
 type Stores struct {
@@ -129,6 +135,21 @@ func (f StoreFactory) OpenHydration(ctx context.Context, spec StoreSpec, reg *se
 			return nil, nil, fmt.Errorf("open mysql hydration store: %w", err)
 		}
 		return store, hydrationClose(store), nil
+	case StoreBackendPostgres:
+		if err := validatePostgresDSN(spec.DSN); err != nil {
+			return nil, nil, err
+		}
+		open := f.OpenPostgresHydration
+		if open == nil {
+			open = func(ctx context.Context, dsn string, reg *sessionstream.SchemaRegistry) (sessionstream.HydrationStore, error) {
+				return hydrationpg.Open(ctx, dsn, reg)
+			}
+		}
+		store, err := open(ctx, spec.DSN, reg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open postgres hydration store: %w", err)
+		}
+		return store, hydrationClose(store), nil
 	default:
 		return nil, nil, fmt.Errorf("unsupported persistence backend %q", backend)
 	}
@@ -181,6 +202,21 @@ func (f StoreFactory) OpenTurn(ctx context.Context, spec StoreSpec) (chatstore.T
 			return nil, nil, fmt.Errorf("open mysql turn store: %w", err)
 		}
 		return store, turnClose(store), nil
+	case StoreBackendPostgres:
+		if err := validatePostgresDSN(spec.DSN); err != nil {
+			return nil, nil, err
+		}
+		open := f.OpenPostgresTurn
+		if open == nil {
+			open = func(ctx context.Context, dsn string) (chatstore.TurnStore, error) {
+				return chatstore.NewPostgresTurnStore(ctx, dsn)
+			}
+		}
+		store, err := open(ctx, spec.DSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open postgres turn store: %w", err)
+		}
+		return store, turnClose(store), nil
 	default:
 		return nil, nil, fmt.Errorf("unsupported persistence backend %q", backend)
 	}
@@ -245,6 +281,13 @@ func resolveBackend(spec StoreSpec) (StoreBackend, error) {
 		if path != "" {
 			return "", fmt.Errorf("mysql persistence does not accept a path")
 		}
+	case StoreBackendPostgres:
+		if dsn == "" {
+			return "", fmt.Errorf("postgres persistence requires DSN")
+		}
+		if path != "" {
+			return "", fmt.Errorf("postgres persistence does not accept a path")
+		}
 	default:
 		return "", fmt.Errorf("unsupported persistence backend %q", backend)
 	}
@@ -286,6 +329,34 @@ func validateMySQLDSN(dsn string) error {
 	return nil
 }
 
+func validatePostgresDSN(dsn string) error {
+	if _, err := pgx.ParseConfig(strings.TrimSpace(dsn)); err != nil {
+		return fmt.Errorf("invalid postgres DSN: %w", err)
+	}
+	return nil
+}
+
+// OpenUsageLedger opens the SQLite usage ledger at path. An empty path
+// disables the ledger and returns a nil ledger with a no-op close function.
+func OpenUsageLedger(path string) (usagestore.Ledger, func() error, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, func() error { return nil }, nil
+	}
+	if err := ensureParentDir(path); err != nil {
+		return nil, nil, fmt.Errorf("create usage db dir: %w", err)
+	}
+	dsn, err := usagestore.SQLiteDSNForFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	ledger, err := usagestore.NewSQLiteLedger(dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	return ledger, ledger.Close, nil
+}
+
 func CloseAll(fns ...func() error) error {
 	var first error
 	for i := len(fns) - 1; i >= 0; i-- {
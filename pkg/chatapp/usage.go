@@ -0,0 +1,94 @@
+package chatapp
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+// UsageAttribution is what a recorded provider call is attributed to.
+type UsageAttribution struct {
+	SessionID  string
+	RunID      string
+	RuntimeKey string
+	Profile    string
+	// Pricing costs the call; nil records it unpriced.
+	Pricing *usagestore.PriceTable
+}
+
+// recordUsage appends a finished provider call to the engine's usage ledger.
+// Ledger failures are logged and never fail the run.
+func (s *runtimeEventSink) recordUsage(ev *gepevents.EventProviderCallFinished) {
+	if s.engine == nil {
+		return
+	}
+	recordUsage(s.publishContext(), s.engine.usageLedger, UsageAttribution{
+		SessionID:  string(s.sessionID),
+		RunID:      s.messageID,
+		RuntimeKey: s.runtimeKey,
+		Profile:    s.profile,
+		Pricing:    s.usagePricing,
+	}, ev)
+}
+
+// UsageSink is an event sink that records the usage of every finished
+// provider call in a ledger. It lets inference paths that do not run through
+// a chatapp Engine, such as blocking CLI runs, feed the same ledger.
+type UsageSink struct {
+	ctx         context.Context
+	ledger      usagestore.Ledger
+	attribution UsageAttribution
+}
+
+var _ gepevents.EventSink = &UsageSink{}
+
+// NewUsageSink returns a sink recording into ledger, or nil if ledger is nil.
+func NewUsageSink(ctx context.Context, ledger usagestore.Ledger, attribution UsageAttribution) *UsageSink {
+	if ledger == nil {
+		return nil
+	}
+	return &UsageSink{ctx: context.WithoutCancel(ctx), ledger: ledger, attribution: attribution}
+}
+
+func (s *UsageSink) PublishEvent(event gepevents.Event) error {
+	if ev, ok := event.(*gepevents.EventProviderCallFinished); ok && s != nil {
+		recordUsage(s.ctx, s.ledger, s.attribution, ev)
+	}
+	return nil
+}
+
+func recordUsage(ctx context.Context, ledger usagestore.Ledger, attribution UsageAttribution, ev *gepevents.EventProviderCallFinished) {
+	if ledger == nil || ev == nil || ev.Usage == nil {
+		return
+	}
+	entry := usageEntry(attribution, ev, time.Now())
+	if err := ledger.Record(ctx, entry); err != nil {
+		log.Warn().Err(err).Str("session_id", entry.SessionID).Str("provider_call_id", entry.ProviderCallID).Msg("record provider call usage")
+	}
+}
+
+func usageEntry(attribution UsageAttribution, ev *gepevents.EventProviderCallFinished, now time.Time) usagestore.Entry {
+	corr := ev.Correlation()
+	entry := usagestore.Entry{
+		SessionID:                firstNonEmpty(attribution.SessionID, ev.Metadata().SessionID),
+		RunID:                    firstNonEmpty(corr.RunID, attribution.RunID),
+		TurnID:                   corr.TurnID,
+		ProviderCallID:           corr.ProviderCallID,
+		RuntimeKey:               attribution.RuntimeKey,
+		Profile:                  attribution.Profile,
+		Model:                    strings.TrimSpace(ev.Metadata().Model),
+		InputTokens:              int64(ev.Usage.InputTokens),
+		OutputTokens:             int64(ev.Usage.OutputTokens),
+		CachedTokens:             int64(ev.Usage.CachedTokens),
+		CacheCreationInputTokens: int64(ev.Usage.CacheCreationInputTokens),
+		CacheReadInputTokens:     int64(ev.Usage.CacheReadInputTokens),
+		CreatedAtMs:              now.UnixMilli(),
+	}
+	if ev.DurationMs != nil {
+		entry.DurationMs = *ev.DurationMs
+	}
+	return attribution.Pricing.Apply(entry)
+}
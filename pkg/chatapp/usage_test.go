@@ -0,0 +1,69 @@
+package chatapp
+
+import (
+	"context"
+	"testing"
+
+	gepevents "github.com/go-go-golems/geppetto/pkg/events"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeEventSinkRecordsProviderCallUsage(t *testing.T) {
+	ledger := usagestore.NewMemoryLedger()
+	pub := &recordingEventPublisher{}
+	sink := newRuntimeSinkForProtocolTest(pub)
+	sink.engine = NewEngine(WithUsageLedger(ledger))
+	sink.runtimeKey = "planner"
+	sink.profile = "planner"
+	sink.usagePricing = &usagestore.PriceTable{Models: map[string]usagestore.ModelPrice{"gpt-4o*": {InputPerMTok: 2, OutputPerMTok: 8}}}
+
+	metadata := gepevents.EventMetadata{SessionID: "session-1", InferenceID: "inference-1", TurnID: "turn-1"}
+	metadata.Model = "gpt-4o-2024-08-06"
+	duration := int64(1200)
+	usage := &gepevents.Usage{InputTokens: 1000, OutputTokens: 250, CachedTokens: 0}
+	require.NoError(t, sink.PublishEvent(gepevents.NewProviderCallFinishedEvent(metadata, runtimeSinkProviderCorrelation(), "stop", "completed", usage, &duration, false)))
+	// Calls without usage are not recorded.
+	require.NoError(t, sink.PublishEvent(gepevents.NewProviderCallFinishedEvent(metadata, runtimeSinkProviderCorrelation(), "stop", "completed", nil, nil, false)))
+
+	entries, err := ledger.Query(context.Background(), usagestore.Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	e := entries[0]
+	require.Equal(t, "session-1", e.SessionID)
+	require.Equal(t, "message-1", e.RunID)
+	require.Equal(t, "turn-1", e.TurnID)
+	require.Equal(t, "provider-call-1", e.ProviderCallID)
+	require.Equal(t, "planner", e.RuntimeKey)
+	require.Equal(t, "planner", e.Profile)
+	require.Equal(t, "gpt-4o-2024-08-06", e.Model)
+	require.Equal(t, int64(1000), e.InputTokens)
+	require.Equal(t, int64(250), e.OutputTokens)
+	require.Equal(t, int64(1200), e.DurationMs)
+	require.True(t, e.Priced)
+	require.InDelta(t, (1000*2+250*8)/1e6, e.CostUSD, 1e-12)
+	require.Equal(t, []string{EventChatProviderCallFinished, EventChatProviderCallFinished}, runtimeSinkEventNames(pub.Events()))
+}
+
+func TestUsageSinkRecordsProviderCallsOutsideChatapp(t *testing.T) {
+	require.Nil(t, NewUsageSink(context.Background(), nil, UsageAttribution{}))
+
+	ledger := usagestore.NewMemoryLedger()
+	sink := NewUsageSink(context.Background(), ledger, UsageAttribution{
+		Profile: "assistant",
+		Pricing: &usagestore.PriceTable{Models: map[string]usagestore.ModelPrice{"claude-*": {InputPerMTok: 3, OutputPerMTok: 15}}},
+	})
+
+	metadata := gepevents.EventMetadata{SessionID: "cli-session", InferenceID: "inference-1", TurnID: "turn-1"}
+	metadata.Model = "claude-sonnet-4"
+	usage := &gepevents.Usage{InputTokens: 1000, OutputTokens: 100}
+	require.NoError(t, sink.PublishEvent(gepevents.NewProviderCallFinishedEvent(metadata, runtimeSinkProviderCorrelation(), "stop", "completed", usage, nil, false)))
+
+	entries, err := ledger.Query(context.Background(), usagestore.Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "cli-session", entries[0].SessionID)
+	require.Equal(t, "assistant", entries[0].Profile)
+	require.True(t, entries[0].Priced)
+	require.InDelta(t, (1000*3+100*15)/1e6, entries[0].CostUSD, 1e-12)
+}
@@ -0,0 +1,71 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/run"
+	"github.com/go-go-golems/pinocchio/pkg/edits"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// KeyTurnMetaAppliedEdits records what --apply-edits did with each file the
+// final answer edited.
+var KeyTurnMetaAppliedEdits = turns.TurnMetaK[[]edits.Record]("pinocchio", "applied_edits", 1)
+
+// applyTurnEdits applies the file edits of the last assistant answer of t to
+// the working tree, reviewing them first unless reviewing is off, and records
+// the outcome in the turn metadata. If a turn store is configured, the turn
+// is persisted with phase "apply" under its session, or a new one.
+func applyTurnEdits(ctx context.Context, w io.Writer, t *turns.Turn, review bool, sessionID string, persistence run.PersistenceSettings) error {
+	text := finalAssistantText(t)
+	if text == "" {
+		_, err := fmt.Fprintln(w, "no answer to apply edits from")
+		return err
+	}
+	records, applyErr := edits.ApplyText(w, text, edits.Options{Root: ".", Review: review})
+	if len(records) == 0 {
+		return applyErr
+	}
+	if err := KeyTurnMetaAppliedEdits.Set(&t.Metadata, records); err != nil {
+		return errors.Wrap(err, "record applied edits")
+	}
+
+	if sid, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok && strings.TrimSpace(sid) != "" {
+		sessionID = sid
+	}
+	store, closeStore, err := openCLITurnStore(ctx, persistence)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	if store != nil {
+		if strings.TrimSpace(sessionID) == "" {
+			sessionID = uuid.NewString()
+		}
+		if err := newCLITurnStorePersister(store, sessionID, sessionID, "apply").PersistTurn(ctx, t); err != nil {
+			return errors.Wrap(err, "persist applied edits")
+		}
+	}
+	return applyErr
+}
+
+func finalAssistantText(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		block := t.Blocks[i]
+		if block.Role != turns.RoleAssistant || block.Payload == nil {
+			continue
+		}
+		if text, ok := block.Payload[turns.PayloadKeyText].(string); ok && strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
 	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/toolloop/enginebuilder"
-	"github.com/go-go-golems/glazed/pkg/helpers/templating"
 
 	"github.com/go-go-golems/geppetto/pkg/events"
 
@@ -38,8 +37,9 @@ import (
 	"github.com/go-go-golems/pinocchio/pkg/cmds/cmdlayers"
 	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
 	"github.com/go-go-golems/pinocchio/pkg/cmds/run"
-	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/prompttemplates"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 	pinui "github.com/go-go-golems/pinocchio/pkg/ui"
 	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
 	"github.com/google/uuid"
@@ -50,19 +50,10 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
-func renderTemplateString(name, text string, vars map[string]interface{}) (string, error) {
-	if strings.TrimSpace(text) == "" {
-		return text, nil
-	}
-	tpl, err := templating.CreateTemplate(name).Parse(text)
-	if err != nil {
-		return "", err
-	}
-	var b strings.Builder
-	if err := tpl.Execute(&b, vars); err != nil {
-		return "", err
-	}
-	return b.String(), nil
+// renderTemplateString renders text with the partials, shared variables and
+// include helpers of templates, which may be nil.
+func renderTemplateString(templates *prompttemplates.Library, name, text string, vars map[string]interface{}) (string, error) {
+	return templates.Render(name, text, vars)
 }
 
 // SimpleMessage represents a minimal YAML message that will be converted to a user block
@@ -93,7 +84,7 @@ func buildInitialTurnFromBlocks(systemPrompt string, blocks []turns.Block, userP
 }
 
 // renderBlocks renders text payloads in blocks using vars
-func renderBlocks(blocks []turns.Block, vars map[string]interface{}) ([]turns.Block, error) {
+func renderBlocks(templates *prompttemplates.Library, blocks []turns.Block, vars map[string]interface{}) ([]turns.Block, error) {
 	if len(blocks) == 0 {
 		return blocks, nil
 	}
@@ -101,7 +92,7 @@ func renderBlocks(blocks []turns.Block, vars map[string]interface{}) ([]turns.Bl
 	for _, b := range blocks {
 		nb := b
 		if txt, ok := b.Payload[turns.PayloadKeyText].(string); ok {
-			rt, err := renderTemplateString("message", txt, vars)
+			rt, err := renderTemplateString(templates, "message", txt, vars)
 			if err != nil {
 				return nil, err
 			}
@@ -115,25 +106,37 @@ func renderBlocks(blocks []turns.Block, vars map[string]interface{}) ([]turns.Bl
 	return out, nil
 }
 
-func buildInitialTurnFromBlocksRendered(systemPrompt string, blocks []turns.Block, userPrompt string, vars map[string]interface{}, imagePaths []string) (*turns.Turn, error) {
-	sp, err := renderTemplateString("system-prompt", systemPrompt, vars)
+// buildInitialTurnFromBlocksRendered renders the system prompt, blocks and user
+// prompt with vars. contextBlocks follow the rendered blocks as they are.
+func buildInitialTurnFromBlocksRendered(templates *prompttemplates.Library, systemPrompt string, blocks []turns.Block, contextBlocks []turns.Block, userPrompt string, vars map[string]interface{}, imagePaths []string) (*turns.Turn, error) {
+	sp, err := renderTemplateString(templates, "system-prompt", systemPrompt, vars)
 	if err != nil {
 		return nil, err
 	}
-	rblocks, err := renderBlocks(blocks, vars)
+	rblocks, err := renderBlocks(templates, blocks, vars)
 	if err != nil {
 		return nil, err
 	}
-	up, err := renderTemplateString("prompt", userPrompt, vars)
+	up, err := renderTemplateString(templates, "prompt", userPrompt, vars)
 	if err != nil {
 		return nil, err
 	}
+	if len(contextBlocks) > 0 {
+		rblocks = append(append([]turns.Block{}, rblocks...), contextBlocks...)
+	}
 	return buildInitialTurnFromBlocks(sp, rblocks, up, imagePaths)
 }
 
-// buildInitialTurn constructs a seed Turn for the command from system + blocks + user prompt using vars.
-func (g *PinocchioCommand) buildInitialTurn(vars map[string]interface{}, imagePaths []string) (*turns.Turn, error) {
-	return buildInitialTurnFromBlocksRendered(g.SystemPrompt, g.Blocks, g.Prompt, vars, imagePaths)
+// buildInitialTurn constructs a seed Turn for the command from system + blocks + context blocks + user prompt using vars.
+func (g *PinocchioCommand) buildInitialTurn(vars map[string]interface{}, imagePaths []string, contextBlocks []turns.Block) (*turns.Turn, error) {
+	return buildInitialTurnFromBlocksRendered(g.Templates, g.SystemPrompt, g.Blocks, contextBlocks, g.Prompt, vars, imagePaths)
+}
+
+// RenderTurn renders the command's system prompt, messages and prompt with
+// vars into the seed turn a run would start from, without images or
+// middlewares.
+func (g *PinocchioCommand) RenderTurn(vars map[string]interface{}) (*turns.Turn, error) {
+	return g.buildInitialTurn(vars, nil, nil)
 }
 
 type PinocchioCommandDescription struct {
@@ -159,6 +162,11 @@ type PinocchioCommand struct {
 	SystemPrompt                   string        `yaml:"system-prompt,omitempty"`
 	EngineFactory                  factory.EngineFactory
 	BaseInferenceSettings          *settings.InferenceSettings
+	// Templates provides repository partials, shared variables and include
+	// helpers to the command's templates. Nil renders plain templates.
+	Templates *prompttemplates.Library `yaml:"-"`
+	// ContextSources are the sources --context-from can name, by name.
+	ContextSources map[string]ContextSource `yaml:"-"`
 }
 
 var _ glazedcmds.WriterCommand = &PinocchioCommand{}
@@ -183,6 +191,18 @@ func WithSystemPrompt(systemPrompt string) PinocchioCommandOption {
 	}
 }
 
+func WithTemplates(templates *prompttemplates.Library) PinocchioCommandOption {
+	return func(g *PinocchioCommand) {
+		g.Templates = templates
+	}
+}
+
+func WithContextSources(sources map[string]ContextSource) PinocchioCommandOption {
+	return func(g *PinocchioCommand) {
+		g.ContextSources = sources
+	}
+}
+
 func WithBaseInferenceSettings(base *settings.InferenceSettings) PinocchioCommandOption {
 	return func(g *PinocchioCommand) {
 		if base == nil {
@@ -288,6 +308,11 @@ func (g *PinocchioCommand) RunIntoWriter(
 		imagePaths[i] = img.Path
 	}
 
+	contextBlocks, err := g.resolveContextFrom(ctx, helpersSettings.ContextFrom)
+	if err != nil {
+		return err
+	}
+
 	// No conversation manager preview; print path handled by RunWithOptions
 
 	// Determine run mode based on helper settings
@@ -317,7 +342,7 @@ func (g *PinocchioCommand) RunIntoWriter(
 
 	// If we're just printing the prompt, render and print the seed Turn and return
 	if helpersSettings.PrintPrompt {
-		seed, err := g.buildInitialTurn(getDefaultTemplateVariables(parsedValues), imagePaths)
+		seed, err := g.buildInitialTurn(getDefaultTemplateVariables(parsedValues), imagePaths, contextBlocks)
 		if err != nil {
 			return err
 		}
@@ -349,6 +374,25 @@ func (g *PinocchioCommand) RunIntoWriter(
 		return err
 	}
 
+	if helpersSettings.ExplainTokens && !helpersSettings.DryRun {
+		return errors.New("--explain-tokens requires --dry-run")
+	}
+	runtimePlan, err := resolveCommandRuntimePlan(ctx, resolvedEngineSettings, baseSettings)
+	if err != nil {
+		return err
+	}
+	if helpersSettings.DryRun {
+		in := dryRunInput{
+			Helpers:           helpersSettings,
+			InferenceSettings: stepSettings,
+			Variables:         getDefaultTemplateVariables(parsedValues),
+			ImagePaths:        imagePaths,
+			ContextBlocks:     contextBlocks,
+			Plan:              runtimePlan,
+		}
+		return g.runDryRun(ctx, w, in)
+	}
+
 	engineFactory := g.EngineFactory
 	if engineFactory == nil && resolvedEngineSettings != nil {
 		engineFactory, err = profilebootstrap.NewEngineFactoryForResolvedSettings(ctx, resolvedEngineSettings)
@@ -357,8 +401,29 @@ func (g *PinocchioCommand) RunIntoWriter(
 		}
 	}
 
+	if helpersSettings.ApplyEdits && (runMode == run.RunModeRPCJSONL || runMode == run.RunModeRPCStdin) {
+		return errors.New("--apply-edits cannot be used with RPC output")
+	}
+	persistenceSettings := run.PersistenceSettings{
+		TimelineBackend: helpersSettings.TimelineBackend,
+		TimelineDSN:     helpersSettings.TimelineDSN,
+		TimelineDB:      helpersSettings.TimelineDB,
+		TurnsBackend:    helpersSettings.TurnsBackend,
+		TurnsDSN:        helpersSettings.TurnsDSN,
+		TurnsDB:         helpersSettings.TurnsDB,
+	}
+	engineFactory, err = withProfileMiddlewares(ctx, engineFactory, runtimePlan)
+	if err != nil {
+		return err
+	}
+	usageLedger, usagePricing, closeUsageLedger, err := openCommandUsageLedger(helpersSettings.UsageDB, runtimePlan)
+	if err != nil {
+		return err
+	}
+	defer closeUsageLedger()
+
 	// Run with options
-	_, err = g.RunWithOptions(ctx,
+	result, err := g.RunWithOptions(ctx,
 		run.WithInferenceSettings(stepSettings),
 		run.WithBaseSettings(baseSettings),
 		run.WithProfileSelection(profileSettings.Profile, strings.Join(profileSettings.ProfileRegistries, ",")),
@@ -367,22 +432,22 @@ func (g *PinocchioCommand) RunIntoWriter(
 		run.WithReader(os.Stdin),
 		run.WithRunMode(runMode),
 		run.WithUISettings(uiSettings),
-		run.WithPersistenceSettings(run.PersistenceSettings{
-			TimelineBackend: helpersSettings.TimelineBackend,
-			TimelineDSN:     helpersSettings.TimelineDSN,
-			TimelineDB:      helpersSettings.TimelineDB,
-			TurnsBackend:    helpersSettings.TurnsBackend,
-			TurnsDSN:        helpersSettings.TurnsDSN,
-			TurnsDB:         helpersSettings.TurnsDB,
-		}),
+		run.WithPersistenceSettings(persistenceSettings),
+		run.WithUsageLedger(usageLedger, usagePricing),
 		run.WithRouter(router),
 		run.WithVariables(getDefaultTemplateVariables(parsedValues)),
 		run.WithImagePaths(imagePaths),
+		run.WithContextBlocks(contextBlocks),
 	)
 	if err != nil {
 		return err
 	}
 
+	if helpersSettings.ApplyEdits {
+		// The diffs go to stderr so the answer on stdout stays clean.
+		return applyTurnEdits(ctx, os.Stderr, result, !helpersSettings.NonInteractive, strings.TrimSpace(helpersSettings.SessionID), persistenceSettings)
+	}
+
 	return nil
 }
 
@@ -432,7 +497,7 @@ func (g *PinocchioCommand) RunWithOptions(ctx context.Context, options ...run.Ru
 
 	if runCtx.UISettings != nil && runCtx.UISettings.PrintPrompt {
 		// Build a preview turn from initial blocks using rendered templates
-		t, err := g.buildInitialTurn(runCtx.Variables, runCtx.ImagePaths)
+		t, err := g.buildInitialTurn(runCtx.Variables, runCtx.ImagePaths, runCtx.ContextBlocks)
 		if err != nil {
 			return nil, err
 		}
@@ -441,7 +506,7 @@ func (g *PinocchioCommand) RunWithOptions(ctx context.Context, options ...run.Ru
 
 	// Create engine factory if not provided
 	if runCtx.EngineFactory == nil {
-		runCtx.EngineFactory = factory.NewStandardEngineFactory()
+		runCtx.EngineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
 	}
 
 	// Verify router for chat mode
@@ -529,16 +594,17 @@ func shouldAskForChatContinuation(rc *run.RunContext, force bool) bool {
 	return isatty.IsTerminal(os.Stdout.Fd())
 }
 
-func commandRunnerOptions(fanout sessionstream.UIFanout) chatapp.RunnerOptions {
-	return commandRunnerOptionsWithPersistence(fanout, nil, nil, nil)
+func commandRunnerOptions(rc *run.RunContext, fanout sessionstream.UIFanout) chatapp.RunnerOptions {
+	return commandRunnerOptionsWithPersistence(rc, fanout, nil, nil, nil)
 }
 
-func commandRunnerOptionsWithPersistence(fanout sessionstream.UIFanout, reg *sessionstream.SchemaRegistry, hydrationStore sessionstream.HydrationStore, turnStore chatstore.TurnStore) chatapp.RunnerOptions {
+func commandRunnerOptionsWithPersistence(rc *run.RunContext, fanout sessionstream.UIFanout, reg *sessionstream.SchemaRegistry, hydrationStore sessionstream.HydrationStore, turnStore chatstore.TurnStore) chatapp.RunnerOptions {
 	return chatapp.RunnerOptions{
 		Registry:       reg,
 		HydrationStore: hydrationStore,
 		UIFanout:       fanout,
 		TurnStore:      turnStore,
+		UsageLedger:    rc.UsageLedger,
 		Plugins: []chatapp.ChatPlugin{
 			plugins.NewReasoningPlugin(),
 			plugins.NewToolCallPlugin(),
@@ -581,10 +647,10 @@ func (g *PinocchioCommand) runBlockingWithDebugEvents(ctx context.Context, rc *r
 		rc.Writer = io.Discard
 	}
 	if rc.EngineFactory == nil {
-		rc.EngineFactory = factory.NewStandardEngineFactory()
+		rc.EngineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
 	}
 
-	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths)
+	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths, rc.ContextBlocks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render templates: %w", err)
 	}
@@ -607,7 +673,7 @@ func (g *PinocchioCommand) runBlockingWithDebugEvents(ctx context.Context, rc *r
 	}
 
 	statusFanout := newRunStatusFanout(debugFanout)
-	runner, err := chatapp.NewRunner(commandRunnerOptions(statusFanout))
+	runner, err := chatapp.NewRunner(commandRunnerOptions(rc, statusFanout))
 	if err != nil {
 		_ = writeTerminalErrorDoneAll(sid, "runner_init_failed", err, debugFanout)
 		return nil, err
@@ -628,7 +694,7 @@ func (g *PinocchioCommand) runBlockingWithDebugEvents(ctx context.Context, rc *r
 		_ = writeTerminalErrorDoneAll(sid, "engine_init_failed", err, debugFanout)
 		return nil, err
 	}
-	req := chatapp.PromptRequest{Prompt: prompt, InitialTurn: seed, Runtime: &infruntime.ComposedRuntime{Engine: engine}}
+	req := chatapp.PromptRequest{Prompt: prompt, InitialTurn: seed, Runtime: commandRuntime(rc, engine)}
 	if err := runner.Service.SubmitPromptRequest(ctx, sid, req); err != nil {
 		_ = writeTerminalErrorDoneAll(sid, "submit_failed", err, debugFanout)
 		return nil, err
@@ -664,18 +730,12 @@ func (g *PinocchioCommand) runBlockingWithDebugEvents(ctx context.Context, rc *r
 }
 
 func writeBlockingTextOutput(w io.Writer, t *turns.Turn) error {
-	if w == nil || t == nil {
+	if w == nil {
 		return nil
 	}
-	for i := len(t.Blocks) - 1; i >= 0; i-- {
-		block := t.Blocks[i]
-		if block.Role != turns.RoleAssistant || block.Payload == nil {
-			continue
-		}
-		if text, ok := block.Payload[turns.PayloadKeyText].(string); ok && strings.TrimSpace(text) != "" {
-			_, err := fmt.Fprintln(w, text)
-			return err
-		}
+	if text := finalAssistantText(t); text != "" {
+		_, err := fmt.Fprintln(w, text)
+		return err
 	}
 	return nil
 }
@@ -839,10 +899,10 @@ func (g *PinocchioCommand) runRPCJSONL(ctx context.Context, rc *run.RunContext)
 		rc.Writer = io.Discard
 	}
 	if rc.EngineFactory == nil {
-		rc.EngineFactory = factory.NewStandardEngineFactory()
+		rc.EngineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
 	}
 
-	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths)
+	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths, rc.ContextBlocks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render templates: %w", err)
 	}
@@ -873,7 +933,7 @@ func (g *PinocchioCommand) runRPCJSONL(ctx context.Context, rc *run.RunContext)
 	}
 
 	statusFanout := newRunStatusFanout(liveFanout)
-	runner, err := chatapp.NewRunner(commandRunnerOptions(statusFanout))
+	runner, err := chatapp.NewRunner(commandRunnerOptions(rc, statusFanout))
 	if err != nil {
 		_ = writeTerminalErrorDoneAll(sid, "runner_init_failed", err, fanout, debugFanout)
 		return nil, err
@@ -898,9 +958,7 @@ func (g *PinocchioCommand) runRPCJSONL(ctx context.Context, rc *run.RunContext)
 	req := chatapp.PromptRequest{
 		Prompt:      prompt,
 		InitialTurn: seed,
-		Runtime: &infruntime.ComposedRuntime{
-			Engine: engine,
-		},
+		Runtime:     commandRuntime(rc, engine),
 	}
 	if err := runner.Service.SubmitPromptRequest(ctx, sid, req); err != nil {
 		_ = writeTerminalErrorDoneAll(sid, "submit_failed", err, fanout, debugFanout)
@@ -938,10 +996,10 @@ func (g *PinocchioCommand) runStdinRPC(ctx context.Context, rc *run.RunContext)
 		rc.Reader = os.Stdin
 	}
 	if rc.EngineFactory == nil {
-		rc.EngineFactory = factory.NewStandardEngineFactory()
+		rc.EngineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
 	}
 
-	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths)
+	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths, rc.ContextBlocks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to render templates: %w", err)
 	}
@@ -968,7 +1026,7 @@ func (g *PinocchioCommand) runStdinRPC(ctx context.Context, rc *run.RunContext)
 	}
 
 	statusFanout := newRunStatusFanout(liveFanout)
-	runner, err := chatapp.NewRunner(commandRunnerOptions(statusFanout))
+	runner, err := chatapp.NewRunner(commandRunnerOptions(rc, statusFanout))
 	if err != nil {
 		_ = writeTerminalErrorDoneAll(defaultSID, "runner_init_failed", err, fanout, debugFanout)
 		return nil, err
@@ -1087,7 +1145,7 @@ func (g *PinocchioCommand) runStdinRPC(ctx context.Context, rc *run.RunContext)
 				err := runner.Service.SubmitPromptRequest(ctx, sid, chatapp.PromptRequest{
 					Prompt:      prompt,
 					InitialTurn: inputTurn,
-					Runtime:     &infruntime.ComposedRuntime{Engine: engine},
+					Runtime:     commandRuntime(rc, engine),
 					OnFinalTurn: func(t *turns.Turn) {
 						if t != nil {
 							finalTurn = t.Clone()
@@ -1374,11 +1432,14 @@ func (g *PinocchioCommand) runEngineAndCollectMessages(ctx context.Context, rc *
 	}
 
 	// Build seed Turn directly from system + messages + prompt (rendered)
-	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths)
+	seed, err := g.buildInitialTurn(rc.Variables, rc.ImagePaths, rc.ContextBlocks)
 	if err != nil {
 		return fmt.Errorf("failed to render templates: %w", err)
 	}
 
+	if usageSink := commandUsageSink(ctx, rc); usageSink != nil {
+		sinks = append(sinks, usageSink)
+	}
 	runner, err := (&enginebuilder.Builder{
 		Base:       engine,
 		EventSinks: sinks,
@@ -1406,7 +1467,7 @@ func (g *PinocchioCommand) runEngineAndCollectMessages(ctx context.Context, rc *
 // runChat handles chat execution mode
 func (g *PinocchioCommand) runChat(ctx context.Context, rc *run.RunContext) (*turns.Turn, error) {
 	if rc.EngineFactory == nil {
-		rc.EngineFactory = factory.NewStandardEngineFactory()
+		rc.EngineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
 	}
 	if rc.InferenceSettings == nil {
 		return nil, errors.New("inference settings are required")
@@ -1421,7 +1482,7 @@ func (g *PinocchioCommand) runChat(ctx context.Context, rc *run.RunContext) (*tu
 	seed := rc.ResultTurn
 	if seed == nil {
 		var err error
-		seed, err = buildInitialTurnFromBlocksRendered(g.SystemPrompt, g.Blocks, "", rc.Variables, rc.ImagePaths)
+		seed, err = buildInitialTurnFromBlocksRendered(g.Templates, g.SystemPrompt, g.Blocks, rc.ContextBlocks, "", rc.Variables, rc.ImagePaths)
 		if err != nil {
 			return nil, err
 		}
@@ -1472,7 +1533,7 @@ func (g *PinocchioCommand) runChat(ctx context.Context, rc *run.RunContext) (*tu
 	}
 
 	fanoutProxy := pinui.NewUIFanoutProxy()
-	runner, err := chatapp.NewRunner(commandRunnerOptionsWithPersistence(fanoutProxy, reg, hydrationStore, turnStore))
+	runner, err := chatapp.NewRunner(commandRunnerOptionsWithPersistence(rc, fanoutProxy, reg, hydrationStore, turnStore))
 	if err != nil {
 		_ = writeErrorAll(sid, "runner_init_failed", err, true, debugFanout)
 		return nil, err
@@ -1483,7 +1544,7 @@ func (g *PinocchioCommand) runChat(ctx context.Context, rc *run.RunContext) (*tu
 	if turnStore != nil {
 		turnPersister = newCLITurnStorePersister(turnStore, string(sid), string(sid), "final")
 	}
-	backend, err := pinui.NewChatAppBackend(runner.Service, sid, &infruntime.ComposedRuntime{Engine: eng}, seed, pinui.WithTurnPersister(turnPersister))
+	backend, err := pinui.NewChatAppBackend(runner.Service, sid, commandRuntime(rc, eng), seed, pinui.WithTurnPersister(turnPersister))
 	if err != nil {
 		return nil, err
 	}
@@ -1549,7 +1610,7 @@ func (g *PinocchioCommand) runChat(ctx context.Context, rc *run.RunContext) (*tu
 			}
 			promptText := strings.TrimSpace(g.Prompt)
 			if promptText != "" && rc.Variables != nil {
-				if rendered, err := renderTemplateString("prompt", promptText, rc.Variables); err == nil {
+				if rendered, err := renderTemplateString(g.Templates, "prompt", promptText, rc.Variables); err == nil {
 					promptText = rendered
 				}
 			}
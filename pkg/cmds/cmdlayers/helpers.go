@@ -17,6 +17,9 @@ type AutosaveSettings struct {
 type HelpersSettings struct {
 	PrintPrompt            bool               `glazed:"print-prompt"`
 	PrintInferenceSettings bool               `glazed:"print-inference-settings"`
+	DryRun                 bool               `glazed:"dry-run"`
+	ExplainTokens          bool               `glazed:"explain-tokens"`
+	ExplainPricing         string             `glazed:"explain-pricing"`
 	System                 string             `glazed:"system"`
 	AppendMessageFile      string             `glazed:"append-message-file"`
 	MessageFile            string             `glazed:"message-file"`
@@ -29,7 +32,10 @@ type HelpersSettings struct {
 	TurnsBackend           string             `glazed:"turns-backend"`
 	TurnsDSN               string             `glazed:"turns-dsn"`
 	TurnsDB                string             `glazed:"turns-db"`
+	UsageDB                string             `glazed:"usage-db"`
 	Images                 []*fields.FileData `glazed:"images"`
+	ContextFrom            []string           `glazed:"context-from"`
+	ApplyEdits             bool               `glazed:"apply-edits"`
 	Autosave               *AutosaveSettings  `glazed:"autosave,from_json"`
 	NonInteractive         bool               `glazed:"non-interactive"`
 	Output                 string             `glazed:"output"`
@@ -61,6 +67,24 @@ func NewHelpersParameterLayer() (schema.Section, error) {
 				fields.WithDefault(false),
 				fields.WithHelp("Print the final resolved inference settings together with source logs and exit"),
 			),
+			fields.New(
+				"dry-run",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Render the turn, apply the profile's turn-shaping middlewares and print it without calling the provider"),
+			),
+			fields.New(
+				"explain-tokens",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("With --dry-run, print a per-block token estimate, the context window headroom and the estimated cost instead of the turn"),
+			),
+			fields.New(
+				"explain-pricing",
+				fields.TypeString,
+				fields.WithDefault(""),
+				fields.WithHelp("YAML or JSON price table for --explain-tokens, merged over the profile's pinocchio.usage_pricing@v1"),
+			),
 			fields.New(
 				"system",
 				fields.TypeString,
@@ -98,14 +122,14 @@ func NewHelpersParameterLayer() (schema.Section, error) {
 				"timeline-backend",
 				fields.TypeChoice,
 				fields.WithDefault(""),
-				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql"),
+				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql", "postgres"),
 				fields.WithHelp("Persistence backend for durable timeline snapshots; required when timeline-dsn is set"),
 			),
 			fields.New(
 				"timeline-dsn",
 				fields.TypeString,
 				fields.WithDefault(""),
-				fields.WithHelp("SQLite or MySQL DSN for durable timeline snapshots; interpreted only by timeline-backend"),
+				fields.WithHelp("SQLite, MySQL or PostgreSQL DSN for durable timeline snapshots; interpreted only by timeline-backend"),
 			),
 			fields.New(
 				"timeline-db",
@@ -117,14 +141,14 @@ func NewHelpersParameterLayer() (schema.Section, error) {
 				"turns-backend",
 				fields.TypeChoice,
 				fields.WithDefault(""),
-				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql"),
+				fields.WithChoices("", "disabled", "memory", "sqlite", "mysql", "postgres"),
 				fields.WithHelp("Persistence backend for durable turn snapshots; required when turns-dsn is set"),
 			),
 			fields.New(
 				"turns-dsn",
 				fields.TypeString,
 				fields.WithDefault(""),
-				fields.WithHelp("SQLite or MySQL DSN for durable turn snapshots; interpreted only by turns-backend"),
+				fields.WithHelp("SQLite, MySQL or PostgreSQL DSN for durable turn snapshots; interpreted only by turns-backend"),
 			),
 			fields.New(
 				"turns-db",
@@ -132,11 +156,28 @@ func NewHelpersParameterLayer() (schema.Section, error) {
 				fields.WithDefault(""),
 				fields.WithHelp("SQLite DB file path for durable turn snapshots (DSN derived with WAL/busy_timeout)"),
 			),
+			fields.New(
+				"usage-db",
+				fields.TypeString,
+				fields.WithDefault(""),
+				fields.WithHelp("SQLite DB file path for the provider-call usage ledger read by pinocchio usage report"),
+			),
 			fields.New(
 				"images",
 				fields.TypeFileList,
 				fields.WithHelp("Images to display"),
 			),
+			fields.New(
+				"context-from",
+				fields.TypeStringList,
+				fields.WithHelp("Add each file from a context source as its own block before the prompt (e.g., 'catter:--token-budget 20000 pkg/')"),
+			),
+			fields.New(
+				"apply-edits",
+				fields.TypeBool,
+				fields.WithDefault(false),
+				fields.WithHelp("Review and write the file blocks and diffs of the final answer to the working tree"),
+			),
 			fields.New(
 				"autosave",
 				fields.TypeKeyValue,
@@ -0,0 +1,141 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/pkg/errors"
+)
+
+// Block metadata of the files injected with --context-from, so middlewares
+// and exports can tell them apart from the rest of the turn.
+var (
+	// KeyBlockMetaContextSource is the source that produced the block, such
+	// as "catter".
+	KeyBlockMetaContextSource = turns.BlockMetaK[string]("pinocchio", "context_source", 1)
+	// KeyBlockMetaContextPath is the path of the file in the block.
+	KeyBlockMetaContextPath = turns.BlockMetaK[string]("pinocchio", "context_path", 1)
+	// KeyBlockMetaContextTokens is the source's token count of the content.
+	KeyBlockMetaContextTokens = turns.BlockMetaK[int]("pinocchio", "context_tokens", 1)
+	// KeyBlockMetaContextOutline marks a block holding an outline of the
+	// file instead of its content.
+	KeyBlockMetaContextOutline = turns.BlockMetaK[bool]("pinocchio", "context_outline", 1)
+)
+
+// ContextFile is a file a context source adds to the seed turn.
+type ContextFile struct {
+	Path    string
+	Content string
+	Tokens  int
+	Outline bool
+}
+
+// ContextSource produces the files for --context-from <name>:<args>. args are
+// the rest of the flag value, split like a shell command line.
+type ContextSource func(ctx context.Context, args []string) ([]ContextFile, error)
+
+// resolveContextFrom runs the sources named by the --context-from specs and
+// returns one user block per file, in order.
+func (g *PinocchioCommand) resolveContextFrom(ctx context.Context, specs []string) ([]turns.Block, error) {
+	var blocks []turns.Block
+	for _, spec := range specs {
+		name, rest, _ := strings.Cut(spec, ":")
+		source, ok := g.ContextSources[name]
+		if !ok {
+			return nil, errors.Errorf("unknown --context-from source %q (available: %s)", name, strings.Join(g.contextSourceNames(), ", "))
+		}
+		args, err := splitArgs(rest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --context-from %q", spec)
+		}
+		files, err := source(ctx, args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "--context-from %s", name)
+		}
+		for _, f := range files {
+			block, err := contextFileBlock(name, f)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+func (g *PinocchioCommand) contextSourceNames() []string {
+	names := make([]string, 0, len(g.ContextSources))
+	for name := range g.ContextSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contextFileBlock wraps f in a user block, delimited like `catter print -d xml`.
+func contextFileBlock(source string, f ContextFile) (turns.Block, error) {
+	block := turns.NewUserTextBlock(fmt.Sprintf("<file name=\"%s\">\n<content>\n%s\n</content>\n</file>", f.Path, f.Content))
+	if err := KeyBlockMetaContextSource.Set(&block.Metadata, source); err != nil {
+		return block, err
+	}
+	if err := KeyBlockMetaContextPath.Set(&block.Metadata, f.Path); err != nil {
+		return block, err
+	}
+	if err := KeyBlockMetaContextTokens.Set(&block.Metadata, f.Tokens); err != nil {
+		return block, err
+	}
+	if f.Outline {
+		if err := KeyBlockMetaContextOutline.Set(&block.Metadata, true); err != nil {
+			return block, err
+		}
+	}
+	return block, nil
+}
+
+// splitArgs splits s on whitespace, honoring single quotes, double quotes
+// and backslash escapes.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
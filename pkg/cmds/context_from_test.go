@@ -0,0 +1,59 @@
+package cmds
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+	t.Parallel()
+
+	args, err := splitArgs(` --focus "auth refresh" --priority 'pkg/*.go' my\ dir `)
+	require.NoError(t, err)
+	require.Equal(t, []string{"--focus", "auth refresh", "--priority", "pkg/*.go", "my dir"}, args)
+
+	args, err = splitArgs("")
+	require.NoError(t, err)
+	require.Empty(t, args)
+
+	_, err = splitArgs(`--focus "auth`)
+	require.Error(t, err)
+}
+
+func TestResolveContextFromAddsOneBlockPerFile(t *testing.T) {
+	t.Parallel()
+
+	var gotArgs []string
+	g := &PinocchioCommand{ContextSources: map[string]ContextSource{
+		"catter": func(_ context.Context, args []string) ([]ContextFile, error) {
+			gotArgs = args
+			return []ContextFile{
+				{Path: "a.go", Content: "package a", Tokens: 3},
+				{Path: "b.go", Content: "func B()", Tokens: 2, Outline: true},
+			}, nil
+		},
+	}}
+
+	blocks, err := g.resolveContextFrom(context.Background(), []string{"catter:--token-budget 100 pkg/"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"--token-budget", "100", "pkg/"}, gotArgs)
+	require.Len(t, blocks, 2)
+
+	require.Equal(t, turns.BlockKindUser, blocks[0].Kind)
+	require.Equal(t, "<file name=\"a.go\">\n<content>\npackage a\n</content>\n</file>", blocks[0].Payload[turns.PayloadKeyText])
+	path, ok, err := KeyBlockMetaContextPath.Get(blocks[1].Metadata)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "b.go", path)
+	outline, ok, err := KeyBlockMetaContextOutline.Get(blocks[1].Metadata)
+	require.NoError(t, err)
+	require.True(t, ok && outline)
+	source, _, _ := KeyBlockMetaContextSource.Get(blocks[0].Metadata)
+	require.Equal(t, "catter", source)
+
+	_, err = g.resolveContextFrom(context.Background(), []string{"rg:foo"})
+	require.ErrorContains(t, err, `unknown --context-from source "rg" (available: catter)`)
+}
@@ -0,0 +1,174 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/cmdlayers"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/inference/tokenbudget"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// dryRunInput is what a dry run needs from the resolved command settings.
+type dryRunInput struct {
+	Helpers           *cmdlayers.HelpersSettings
+	InferenceSettings *settings.InferenceSettings
+	Plan              *infruntime.ResolvedRuntimePlan
+	Variables         map[string]interface{}
+	ImagePaths        []string
+	ContextBlocks     []turns.Block
+}
+
+// runDryRun renders the seed turn, applies the middlewares of the resolved
+// profile like a run does and prints the result or its token budget. It makes
+// no inference calls.
+func (g *PinocchioCommand) runDryRun(ctx context.Context, w io.Writer, in dryRunInput) error {
+	final, names, err := g.dryRunTurn(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	if !in.Helpers.ExplainTokens {
+		turns.FprintTurn(w, final)
+		return nil
+	}
+
+	pricing, err := dryRunPricing(in.Plan, in.Helpers.ExplainPricing)
+	if err != nil {
+		return err
+	}
+	opts := tokenBudgetOptions(in.InferenceSettings)
+	opts.Pricing = pricing
+	opts.Middlewares = names
+	report, err := tokenbudget.Explain(final, opts)
+	if err != nil {
+		return errors.Wrap(err, "explain tokens")
+	}
+	return writeTokenReport(w, in.Helpers.Output, report)
+}
+
+// dryRunTurn returns the turn a run would send to the provider and the names
+// of the middlewares applied to it. The middlewares are the chain
+// withProfileMiddlewares wraps CLI engines with, built over an engine factory
+// that refuses inference, so a summarizing context_budget drops the oldest
+// history instead.
+func (g *PinocchioCommand) dryRunTurn(ctx context.Context, in dryRunInput) (*turns.Turn, []string, error) {
+	seed, err := g.buildInitialTurn(in.Variables, in.ImagePaths, in.ContextBlocks)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to render templates")
+	}
+	if in.Plan == nil || in.Plan.Runtime == nil || len(in.Plan.Runtime.Middlewares) == 0 {
+		return seed, nil, nil
+	}
+	composer, err := newCommandProfileComposer(dryRunEngineFactory{})
+	if err != nil {
+		return nil, nil, err
+	}
+	middlewares, uses, err := composer.ProfileMiddlewares(ctx, in.Plan.Runtime, nil, in.InferenceSettings)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "build profile middlewares")
+	}
+	final, err := applyDryRunMiddlewares(ctx, seed, middlewares)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(uses))
+	for _, use := range uses {
+		names = append(names, use.Name)
+	}
+	return final, names, nil
+}
+
+// dryRunEngineFactory creates engines that fail every inference call.
+type dryRunEngineFactory struct{}
+
+func (dryRunEngineFactory) CreateEngine(*settings.InferenceSettings) (engine.Engine, error) {
+	return dryRunEngine{}, nil
+}
+
+func (dryRunEngineFactory) SupportedProviders() []string {
+	return nil
+}
+
+func (dryRunEngineFactory) DefaultProvider() string {
+	return ""
+}
+
+type dryRunEngine struct{}
+
+func (dryRunEngine) RunInference(context.Context, *turns.Turn) (*turns.Turn, error) {
+	return nil, errors.New("a dry run makes no inference calls")
+}
+
+func applyDryRunMiddlewares(ctx context.Context, seed *turns.Turn, middlewares []middleware.Middleware) (*turns.Turn, error) {
+	if len(middlewares) == 0 {
+		return seed, nil
+	}
+	var final *turns.Turn
+	handler := middleware.Chain(func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		final = t
+		return t, nil
+	}, middlewares...)
+	if _, err := handler(ctx, seed); err != nil {
+		return nil, errors.Wrap(err, "apply middlewares")
+	}
+	return final, nil
+}
+
+func dryRunPricing(plan *infruntime.ResolvedRuntimePlan, path string) (*usagestore.PriceTable, error) {
+	var pricing *usagestore.PriceTable
+	if plan != nil {
+		pricing = plan.Pricing
+	}
+	if path = strings.TrimSpace(path); path != "" {
+		table, err := usagestore.LoadPriceTableFile(path)
+		if err != nil {
+			return nil, err
+		}
+		pricing = pricing.Merge(table)
+	}
+	return pricing, nil
+}
+
+func tokenBudgetOptions(s *settings.InferenceSettings) tokenbudget.Options {
+	opts := tokenbudget.Options{}
+	if s == nil {
+		return opts
+	}
+	if s.Chat != nil {
+		if s.Chat.Engine != nil {
+			opts.Model = *s.Chat.Engine
+		}
+		if s.Chat.MaxResponseTokens != nil {
+			opts.MaxResponseTokens = int(*s.Chat.MaxResponseTokens)
+		}
+	}
+	if s.ModelInfo != nil && s.ModelInfo.ContextWindow != nil {
+		opts.ContextWindow = int(*s.ModelInfo.ContextWindow)
+	}
+	return opts
+}
+
+func writeTokenReport(w io.Writer, output string, report *tokenbudget.Report) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer func() { _ = enc.Close() }()
+		return enc.Encode(report)
+	default:
+		return report.WriteText(w)
+	}
+}
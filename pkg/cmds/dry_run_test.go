@@ -0,0 +1,106 @@
+package cmds
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/run"
+	"github.com/stretchr/testify/require"
+)
+
+func dryRunTestCommand(t *testing.T) *PinocchioCommand {
+	t.Helper()
+	loaded, err := LoadFromYAML([]byte(`name: dry-run-parity
+short: dry run parity
+system-prompt: You review code.
+prompt: |
+  review the files
+`))
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	cmd, ok := loaded[0].(*PinocchioCommand)
+	require.True(t, ok)
+	return cmd
+}
+
+func dryRunTestContextBlocks() []turns.Block {
+	blocks := []turns.Block{}
+	for i := 0; i < 5; i++ {
+		blocks = append(blocks, turns.NewUserTextBlock(strings.Repeat("package main // context file ", 60)))
+	}
+	return blocks
+}
+
+func blockTexts(t *turns.Turn) []string {
+	out := make([]string, 0, len(t.Blocks))
+	for _, block := range t.Blocks {
+		text, _ := block.Payload[turns.PayloadKeyText].(string)
+		out = append(out, block.Kind.String()+": "+text)
+	}
+	return out
+}
+
+func TestDryRunTurnMatchesTheTurnACommandRunSends(t *testing.T) {
+	ctx := context.Background()
+	cmd := dryRunTestCommand(t)
+	plan := contextBudgetPlan(map[string]any{
+		"max_tokens":         700,
+		"keep_recent_blocks": 2,
+	})
+	inferenceSettings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+	contextBlocks := dryRunTestContextBlocks()
+
+	dryRun, names, err := cmd.dryRunTurn(ctx, dryRunInput{
+		InferenceSettings: inferenceSettings,
+		Plan:              plan,
+		Variables:         map[string]interface{}{},
+		ContextBlocks:     contextBlocks,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"context_budget"}, names)
+
+	recorder := &turnRecordingEngineFactory{}
+	engineFactory, err := withProfileMiddlewares(ctx, recorder, plan)
+	require.NoError(t, err)
+	_, err = cmd.RunWithOptions(ctx,
+		run.WithInferenceSettings(inferenceSettings),
+		run.WithEngineFactory(engineFactory),
+		run.WithRunMode(run.RunModeBlocking),
+		run.WithVariables(map[string]interface{}{}),
+		run.WithContextBlocks(contextBlocks),
+	)
+	require.NoError(t, err)
+	require.Len(t, recorder.sent, 1)
+
+	seed, err := cmd.buildInitialTurn(map[string]interface{}{}, nil, contextBlocks)
+	require.NoError(t, err)
+	require.Less(t, len(dryRun.Blocks), len(seed.Blocks), "the budget should drop context blocks")
+	require.Equal(t, blockTexts(recorder.sent[0]), blockTexts(dryRun))
+}
+
+func TestDryRunSummarizingBudgetDropsInsteadOfCallingAModel(t *testing.T) {
+	cmd := dryRunTestCommand(t)
+	inferenceSettings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+
+	final, _, err := cmd.dryRunTurn(context.Background(), dryRunInput{
+		InferenceSettings: inferenceSettings,
+		Plan: contextBudgetPlan(map[string]any{
+			"max_tokens":         700,
+			"strategy":           "summarize",
+			"summary_tokens":     100,
+			"keep_recent_blocks": 2,
+		}),
+		Variables:     map[string]interface{}{},
+		ContextBlocks: dryRunTestContextBlocks(),
+	})
+	require.NoError(t, err)
+	for _, text := range blockTexts(final) {
+		require.NotContains(t, text, "Summary of the earlier conversation")
+	}
+	require.Equal(t, turns.BlockKindSystem, final.Blocks[0].Kind)
+}
@@ -14,11 +14,16 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/loaders"
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/pinocchio/pkg/cmds/cmdlayers"
+	"github.com/go-go-golems/pinocchio/pkg/prompttemplates"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 )
 
 type PinocchioCommandLoader struct {
+	// Templates is shared by every loaded command; see prompttemplates.
+	Templates *prompttemplates.Library
+	// ContextSources are the --context-from sources of every loaded command.
+	ContextSources map[string]ContextSource
 }
 
 func (g *PinocchioCommandLoader) IsFileSupported(f fs.FS, fileName string) bool {
@@ -111,6 +116,8 @@ func (g *PinocchioCommandLoader) loadPinocchioCommandFromReader(
 		WithBlocks(blocks),
 		WithSystemPrompt(scd.SystemPrompt),
 		WithBaseInferenceSettings(stepSettings),
+		WithTemplates(g.Templates),
+		WithContextSources(g.ContextSources),
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,103 @@
+package cmds
+
+import (
+	"context"
+
+	gepeengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
+	"github.com/go-go-golems/geppetto/pkg/inference/toolloop/enginebuilder"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	profilebootstrap "github.com/go-go-golems/pinocchio/pkg/cmds/profilebootstrap"
+	"github.com/go-go-golems/pinocchio/pkg/inference/middlewaredefs"
+	"github.com/go-go-golems/pinocchio/pkg/inference/profilecomposer"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"github.com/pkg/errors"
+)
+
+// resolveCommandRuntimePlan resolves the runtime of the profile a command
+// runs with, or returns nil when no profile was resolved.
+func resolveCommandRuntimePlan(
+	ctx context.Context,
+	resolved *profilebootstrap.ResolvedCLIEngineSettings,
+	base *settings.InferenceSettings,
+) (*infruntime.ResolvedRuntimePlan, error) {
+	if resolved == nil || resolved.ResolvedEngineProfile == nil {
+		return nil, nil
+	}
+	plan, err := infruntime.ResolveRuntimePlan(ctx, resolved.ProfileRuntime.Registry(), resolved.ResolvedEngineProfile, infruntime.ResolveRuntimePlanOptions{
+		BaseInferenceSettings: base,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve profile runtime")
+	}
+	return plan, nil
+}
+
+// newCommandProfileComposer returns the composer that builds profile
+// middlewares for the CLI. Middlewares that make their own inference calls,
+// such as a summarizing context_budget, create engines with engineFactory.
+// The CLI does not load pinocchio.js_tools@v1 modules.
+func newCommandProfileComposer(engineFactory factory.EngineFactory) (*profilecomposer.ProfileRuntimeComposer, error) {
+	registry, err := middlewaredefs.NewRegistry()
+	if err != nil {
+		return nil, errors.Wrap(err, "create middleware definition registry")
+	}
+	return profilecomposer.NewProfileRuntimeComposer(registry, middlewarecfg.BuildDeps{
+		Values: map[string]any{
+			middlewaredefs.DependencyAgentModeServiceKey: middlewaredefs.NewDefaultAgentModeService(),
+		},
+	}, nil).WithEngineFactory(engineFactory), nil
+}
+
+// profileMiddlewareEngineFactory wraps every engine it creates with the
+// middlewares of the selected profile, so CLI runs, including --resume
+// sessions, get the same context_budget and other middlewares as web-chat.
+type profileMiddlewareEngineFactory struct {
+	factory.EngineFactory
+	ctx      context.Context
+	composer *profilecomposer.ProfileRuntimeComposer
+	runtime  *infruntime.ProfileRuntime
+}
+
+// withProfileMiddlewares returns engineFactory wrapped with the middlewares of
+// plan, or engineFactory itself when the profile declares none.
+func withProfileMiddlewares(
+	ctx context.Context,
+	engineFactory factory.EngineFactory,
+	plan *infruntime.ResolvedRuntimePlan,
+) (factory.EngineFactory, error) {
+	if plan == nil || plan.Runtime == nil || len(plan.Runtime.Middlewares) == 0 {
+		return engineFactory, nil
+	}
+	if engineFactory == nil {
+		engineFactory = secrets.NewResolvingEngineFactory(nil, profilebootstrap.CLISecretResolver())
+	}
+	composer, err := newCommandProfileComposer(engineFactory)
+	if err != nil {
+		return nil, err
+	}
+	return &profileMiddlewareEngineFactory{
+		EngineFactory: engineFactory,
+		ctx:           ctx,
+		composer:      composer,
+		runtime:       plan.Runtime,
+	}, nil
+}
+
+func (f *profileMiddlewareEngineFactory) CreateEngine(s *settings.InferenceSettings) (gepeengine.Engine, error) {
+	base, err := f.EngineFactory.CreateEngine(s)
+	if err != nil {
+		return nil, err
+	}
+	middlewares, _, err := f.composer.ProfileMiddlewares(f.ctx, f.runtime, nil, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "build profile middlewares")
+	}
+	eng, err := (&enginebuilder.Builder{Base: base, Middlewares: middlewares}).Build(f.ctx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "build profile middleware engine")
+	}
+	return eng, nil
+}
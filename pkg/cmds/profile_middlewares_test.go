@@ -0,0 +1,106 @@
+package cmds
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/stretchr/testify/require"
+)
+
+// turnRecordingEngineFactory records the turns its engines are sent.
+type turnRecordingEngineFactory struct {
+	recordingEngineFactory
+	sent []*turns.Turn
+}
+
+func (f *turnRecordingEngineFactory) CreateEngine(*settings.InferenceSettings) (engine.Engine, error) {
+	return turnRecordingEngine{factory: f}, nil
+}
+
+type turnRecordingEngine struct {
+	factory *turnRecordingEngineFactory
+}
+
+func (e turnRecordingEngine) RunInference(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+	e.factory.sent = append(e.factory.sent, t.Clone())
+	out := t.Clone()
+	turns.AppendBlock(out, turns.NewAssistantTextBlock("ok"))
+	return out, nil
+}
+
+func contextBudgetPlan(config map[string]any) *infruntime.ResolvedRuntimePlan {
+	return &infruntime.ResolvedRuntimePlan{Runtime: &infruntime.ProfileRuntime{
+		Middlewares: []infruntime.MiddlewareUse{{Name: "context_budget", Config: config}},
+	}}
+}
+
+func TestWithProfileMiddlewaresWithoutMiddlewaresKeepsFactory(t *testing.T) {
+	recorder := &recordingEngineFactory{}
+	got, err := withProfileMiddlewares(context.Background(), recorder, &infruntime.ResolvedRuntimePlan{Runtime: &infruntime.ProfileRuntime{}})
+	require.NoError(t, err)
+	require.Same(t, recorder, got)
+
+	got, err = withProfileMiddlewares(context.Background(), recorder, nil)
+	require.NoError(t, err)
+	require.Same(t, recorder, got)
+}
+
+func TestResumedChatTurnIsCompactedByProfileContextBudget(t *testing.T) {
+	ctx := context.Background()
+	dsn, err := chatstore.SQLiteTurnDSNForFile(filepath.Join(t.TempDir(), "turns.db"))
+	require.NoError(t, err)
+	store, err := chatstore.NewSQLiteTurnStore(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	history := &turns.Turn{ID: "turn-resume"}
+	turns.AppendBlock(history, turns.NewSystemTextBlock("be brief"))
+	for i := 0; i < 6; i++ {
+		turns.AppendBlock(history, turns.NewUserTextBlock(strings.Repeat("an earlier question ", 100)))
+		turns.AppendBlock(history, turns.NewAssistantTextBlock(strings.Repeat("an earlier answer ", 100)))
+	}
+	require.NoError(t, newCLITurnStorePersister(store, "resume-session", "resume-session", "final").PersistTurn(ctx, history))
+
+	// This is what runChat does with --resume before the first submit.
+	resumed, err := loadLatestCLIFinalTurn(ctx, store, "resume-session")
+	require.NoError(t, err)
+	input := turnWithUserPrompt(resumed, "latest question")
+
+	recorder := &turnRecordingEngineFactory{}
+	engineFactory, err := withProfileMiddlewares(ctx, recorder, contextBudgetPlan(map[string]any{
+		"max_tokens":         600,
+		"keep_recent_blocks": 2,
+	}))
+	require.NoError(t, err)
+	inferenceSettings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+	eng, err := engineFactory.CreateEngine(inferenceSettings)
+	require.NoError(t, err)
+	_, err = eng.RunInference(ctx, input)
+	require.NoError(t, err)
+
+	require.Len(t, recorder.sent, 1)
+	sent := recorder.sent[0]
+	require.Less(t, len(sent.Blocks), len(input.Blocks))
+	require.Equal(t, turns.BlockKindSystem, sent.Blocks[0].Kind)
+	last := sent.Blocks[len(sent.Blocks)-1]
+	require.Equal(t, "latest question", last.Payload[turns.PayloadKeyText])
+}
+
+func TestWithProfileMiddlewaresRejectsInvalidMiddlewareConfig(t *testing.T) {
+	engineFactory, err := withProfileMiddlewares(context.Background(), &recordingEngineFactory{}, contextBudgetPlan(map[string]any{
+		"strategy": "drop_oldest",
+	}))
+	require.NoError(t, err)
+	inferenceSettings, err := settings.NewInferenceSettings()
+	require.NoError(t, err)
+	_, err = engineFactory.CreateEngine(inferenceSettings)
+	require.ErrorContains(t, err, "build profile middlewares")
+}
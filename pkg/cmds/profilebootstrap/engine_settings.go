@@ -2,6 +2,7 @@ package profilebootstrap
 
 import (
 	"context"
+	"sync"
 
 	"github.com/go-go-golems/geppetto/pkg/cli/bootstrap"
 	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
@@ -12,6 +13,7 @@ import (
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/glazed/pkg/cmds/sources"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 	"github.com/pkg/errors"
 )
 
@@ -174,8 +176,18 @@ func NewEngineFromResolvedCLIEngineSettingsWithFactory(
 	if resolved.FinalInferenceSettings == nil {
 		return nil, errors.New("resolved final inference settings cannot be nil")
 	}
-	if engineFactory == nil {
-		engineFactory = factory.NewStandardEngineFactory()
-	}
-	return engineFactory.CreateEngine(resolved.FinalInferenceSettings)
+	return secrets.NewResolvingEngineFactory(engineFactory, CLISecretResolver()).CreateEngine(resolved.FinalInferenceSettings)
+}
+
+var cliSecretResolver = sync.OnceValue(func() *secrets.Resolver {
+	return secrets.NewResolver(secrets.WithPassphrase(
+		secrets.EnvPassphrase(secrets.TerminalPassphrase("Pinocchio secrets passphrase: ")),
+	))
+})
+
+// CLISecretResolver is the secret resolver for interactive commands. Unlike
+// secrets.DefaultResolver it prompts on the terminal for the secret file
+// passphrase when the environment does not provide one.
+func CLISecretResolver() *secrets.Resolver {
+	return cliSecretResolver()
 }
@@ -15,21 +15,24 @@ import (
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/types"
 	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 )
 
 const runtimeOAuthRedirectURL = "http://127.0.0.1/oauth/callback"
 
-// ResolvedOAuthProfile binds one resolved profile to the sole direct YAML
-// registry file that owns its secret tuple and its exact outbound request.
+// ResolvedOAuthProfile binds one resolved profile to the credential store that
+// owns its secret tuple and its exact outbound request.
 type ResolvedOAuthProfile struct {
 	Profile *oauthprofiles.Profile
-	Store   *oauthprofiles.YAMLStore
+	Store   oauthprofiles.CredentialStore
 	Request credentials.Request
 }
 
-// ResolveOAuthProfile resolves the selected profile's OAuth extension. OAuth
-// profiles are deliberately supported only from one direct YAML registry file;
-// inline, composed, SQLite, and remote-like sources have no safe write target.
+// ResolveOAuthProfile resolves the selected profile's OAuth extension and its
+// credential store. The default registry store is deliberately supported only
+// from one direct YAML registry file; inline, composed, SQLite, and
+// remote-like sources have no safe write target. Profiles that select a
+// separate credential store may come from any source.
 func ResolveOAuthProfile(ctx context.Context, resolved *ResolvedCLIEngineSettings) (*ResolvedOAuthProfile, error) {
 	if resolved == nil || resolved.ResolvedEngineProfile == nil {
 		return nil, nil
@@ -60,17 +63,34 @@ func ResolveOAuthProfile(ctx context.Context, resolved *ResolvedCLIEngineSetting
 	if err := rejectStaticOAuthCredential(resolved.FinalInferenceSettings, request); err != nil {
 		return nil, err
 	}
-	path, err := directYAMLRegistryPath(resolved.ProfileRuntime.ProfileSettings.ProfileRegistries, resolved.ResolvedEngineProfile.RegistrySlug, resolved.ResolvedEngineProfile.EngineProfileSlug)
-	if err != nil {
-		return nil, err
-	}
-	store, err := oauthprofiles.NewYAMLStore(path, resolved.ResolvedEngineProfile.RegistrySlug, resolved.ResolvedEngineProfile.EngineProfileSlug, request)
+	store, err := NewOAuthCredentialStore(resolved, oauthProfile.CredentialStore, request)
 	if err != nil {
 		return nil, err
 	}
 	return &ResolvedOAuthProfile{Profile: oauthProfile, Store: store, Request: request}, nil
 }
 
+// NewOAuthCredentialStore creates the credential store config selects for the
+// selected profile. Only the registry store needs the profile's direct YAML
+// registry. The encrypted file store prompts for its passphrase on the
+// terminal when the environment does not provide one.
+func NewOAuthCredentialStore(resolved *ResolvedCLIEngineSettings, config oauthprofiles.StoreConfig, request credentials.Request) (oauthprofiles.CredentialStore, error) {
+	if resolved == nil || resolved.ResolvedEngineProfile == nil || resolved.ProfileRuntime == nil {
+		return nil, errors.New("OAuth credential store requires a resolved profile")
+	}
+	registrySlug, profileSlug := resolved.ResolvedEngineProfile.RegistrySlug, resolved.ResolvedEngineProfile.EngineProfileSlug
+	path := ""
+	if config.EffectiveKind() == oauthprofiles.StoreKindRegistry {
+		var err error
+		path, err = directYAMLRegistryPath(resolved.ProfileRuntime.ProfileSettings.ProfileRegistries, registrySlug, profileSlug)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return oauthprofiles.NewCredentialStore(config, path, registrySlug, profileSlug, request,
+		oauthprofiles.WithStorePassphrase(secrets.EnvPassphrase(secrets.TerminalPassphrase("Pinocchio OAuth credentials passphrase: "))))
+}
+
 // NewOAuthClient creates a profile-bound reusable protocol client for the
 // caller's exact callback URL. Runtime renewal uses a loopback placeholder
 // because refresh grants never use the redirect URL; browser login passes the
@@ -113,16 +133,17 @@ func NewBearerTokenSourceForResolvedSettings(ctx context.Context, resolved *Reso
 
 // NewEngineFactoryForResolvedSettings returns a standard factory with a
 // renewable bearer source only when the selected profile explicitly opts into
-// OAuth. Static-key profiles retain existing behavior.
+// OAuth. Static-key profiles retain existing behavior. Secret references in
+// the settings are resolved when the engine is created.
 func NewEngineFactoryForResolvedSettings(ctx context.Context, resolved *ResolvedCLIEngineSettings) (factory.EngineFactory, error) {
 	source, err := NewBearerTokenSourceForResolvedSettings(ctx, resolved)
 	if err != nil {
 		return nil, err
 	}
 	if source == nil {
-		return factory.NewStandardEngineFactory(), nil
+		return secrets.NewResolvingEngineFactory(factory.NewStandardEngineFactory(), CLISecretResolver()), nil
 	}
-	return factory.NewStandardEngineFactory(factory.WithBearerTokenSource(source)), nil
+	return secrets.NewResolvingEngineFactory(factory.NewStandardEngineFactory(factory.WithBearerTokenSource(source)), CLISecretResolver()), nil
 }
 
 func directYAMLRegistryPath(entries []string, registrySlug gepprofiles.RegistrySlug, profileSlug gepprofiles.EngineProfileSlug) (string, error) {
@@ -58,6 +58,41 @@ func TestResolveOAuthProfileRejectsNonDirectSource(t *testing.T) {
 	require.EqualError(t, err, "OAuth profiles require an explicit direct YAML profile registry source")
 }
 
+func TestProjectProfileExecCredentialStoreIsRejectedBeforeItRuns(t *testing.T) {
+	repoDir, _, restore := setupGitWorkspace(t)
+	defer restore()
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpHome, "home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpHome, "xdg"))
+
+	marker := filepath.Join(tmpHome, "helper-ran")
+	repoFile := filepath.Join(repoDir, ".pinocchio.yml")
+	require.NoError(t, os.WriteFile(repoFile, []byte(`profile:
+  active: assistant
+profiles:
+  assistant:
+    inference_settings:
+      chat:
+        api_type: openai
+    extensions:
+      pinocchio.oauth@v1:
+        kind: oauth_bearer
+        credential_store:
+          kind: exec
+          command: [sh, -c, "touch `+marker+`"]
+`), 0o644))
+
+	resolved, err := ResolveCLIEngineSettings(context.Background(), nil)
+	if err == nil {
+		if resolved.Close != nil {
+			defer resolved.Close()
+		}
+		_, err = NewEngineFactoryForResolvedSettings(context.Background(), resolved)
+	}
+	require.ErrorContains(t, err, "credential_store kind exec")
+	require.NoFileExists(t, marker)
+}
+
 func oauthResolvedFixture(t *testing.T, staticAPIKey string) *ResolvedCLIEngineSettings {
 	t.Helper()
 	registrySlug := gepprofiles.MustRegistrySlug("workspace")
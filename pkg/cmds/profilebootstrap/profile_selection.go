@@ -176,6 +176,12 @@ func resolveConfigRuntime(parsed *values.Values) (*resolvedConfigRuntime, error)
 	if err != nil {
 		return nil, err
 	}
+	if err := configdoc.CheckProjectSecretRefs(configFiles.Files); err != nil {
+		return nil, err
+	}
+	if err := configdoc.CheckProjectCredentialStores(configFiles.Files); err != nil {
+		return nil, err
+	}
 
 	documents, err := configdoc.LoadResolvedDocuments(configFiles.Files)
 	if err != nil {
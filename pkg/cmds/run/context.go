@@ -9,6 +9,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/events"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 )
 
 type RunMode int
@@ -65,6 +66,10 @@ type RunContext struct {
 	// ImagePaths are CLI-provided image paths to attach to the initial user message (if any).
 	ImagePaths []string
 
+	// ContextBlocks are added to the seed turn after the command's messages,
+	// one per file given with --context-from.
+	ContextBlocks []turns.Block
+
 	// ResultTurn stores the resulting Turn after engine execution when needed by callers
 	ResultTurn *turns.Turn
 
@@ -74,6 +79,11 @@ type RunContext struct {
 	Reader      io.Reader
 	Persistence PersistenceSettings
 
+	// UsageLedger records every provider call of the run when set, costed with
+	// UsagePricing if that is set too.
+	UsageLedger  usagestore.Ledger
+	UsagePricing *usagestore.PriceTable
+
 	// Run configuration
 	RunMode RunMode
 }
@@ -160,6 +170,16 @@ func WithPersistenceSettings(settings PersistenceSettings) RunOption {
 	}
 }
 
+// WithUsageLedger records the provider calls of the run in ledger, costed
+// with pricing. A nil pricing records them unpriced.
+func WithUsageLedger(ledger usagestore.Ledger, pricing *usagestore.PriceTable) RunOption {
+	return func(rc *RunContext) error {
+		rc.UsageLedger = ledger
+		rc.UsagePricing = pricing
+		return nil
+	}
+}
+
 // WithVariables passes a map of template variables used to render
 // system prompt, messages and user prompt before sending to the model.
 func WithVariables(vars map[string]interface{}) RunOption {
@@ -177,6 +197,14 @@ func WithImagePaths(imagePaths []string) RunOption {
 	}
 }
 
+// WithContextBlocks passes blocks added to the seed turn before the user prompt.
+func WithContextBlocks(blocks []turns.Block) RunOption {
+	return func(rc *RunContext) error {
+		rc.ContextBlocks = blocks
+		return nil
+	}
+}
+
 // NewRunContext creates a new RunContext with default values and a required manager
 func NewRunContext() *RunContext {
 	return &RunContext{
@@ -0,0 +1,45 @@
+package cmds
+
+import (
+	"context"
+
+	gepeengine "github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/serverkit"
+	"github.com/go-go-golems/pinocchio/pkg/cmds/run"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+// openCommandUsageLedger opens the --usage-db ledger of a command run,
+// together with the price table of the resolved profile runtime. An empty
+// path returns a nil ledger.
+func openCommandUsageLedger(path string, plan *infruntime.ResolvedRuntimePlan) (usagestore.Ledger, *usagestore.PriceTable, func(), error) {
+	ledger, closeLedger, err := serverkit.OpenUsageLedger(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	closeFn := func() { _ = closeLedger() }
+	if ledger == nil || plan == nil {
+		return ledger, nil, closeFn, nil
+	}
+	return ledger, plan.Pricing, closeFn, nil
+}
+
+// commandRuntime is the runtime the chatapp run paths submit engine with.
+func commandRuntime(rc *run.RunContext, engine gepeengine.Engine) *infruntime.ComposedRuntime {
+	return &infruntime.ComposedRuntime{
+		Engine:       engine,
+		Profile:      rc.Profile,
+		UsagePricing: rc.UsagePricing,
+	}
+}
+
+// commandUsageSink records the provider calls of a blocking run, which does
+// not go through chatapp, or returns nil without a ledger.
+func commandUsageSink(ctx context.Context, rc *run.RunContext) *chatapp.UsageSink {
+	return chatapp.NewUsageSink(ctx, rc.UsageLedger, chatapp.UsageAttribution{
+		Profile: rc.Profile,
+		Pricing: rc.UsagePricing,
+	})
+}
@@ -0,0 +1,182 @@
+package configdoc
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// SetPath sets path to value in the YAML document data and returns the
+// re-encoded document. The value is parsed as YAML, so "true", "3" and
+// "[a, b]" keep their types and are written in block style; anything else is stored as a string. Missing
+// parent mappings are created. A sequence index equal to the sequence length
+// appends. Comments on untouched nodes are preserved.
+func SetPath(data []byte, path string, value string) ([]byte, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseDocumentNode(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode config document")
+	}
+	valueNode := parseValueNode(value)
+
+	current := root.Content[0]
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		current = resolveAlias(current)
+		if current.Kind == yaml.ScalarNode && current.Tag == "!!null" {
+			replaceNode(current, newContainerFor(segments, i))
+		}
+		var next *yaml.Node
+		switch current.Kind {
+		case yaml.MappingNode:
+			foldCase := i == 1 && segments[0] == "profiles"
+			_, next = mappingEntry(current, segment, foldCase)
+			if next == nil {
+				next = newContainerFor(segments, i+1)
+				current.Content = append(current.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment}, next)
+			}
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx > len(current.Content) {
+				return nil, errors.Errorf("%s: index %q out of range for a sequence of length %d", FormatPath(segments[:i]), segment, len(current.Content))
+			}
+			if idx == len(current.Content) {
+				current.Content = append(current.Content, newContainerFor(segments, i+1))
+			}
+			next = current.Content[idx]
+		default:
+			return nil, errors.Errorf("%s is a scalar; cannot set %s below it", FormatPath(segments[:i]), FormatPath(segments))
+		}
+		if last {
+			replaceNode(next, valueNode)
+		}
+		current = next
+	}
+	return encodeDocumentNode(root)
+}
+
+// UnsetPath removes path from the YAML document data. It reports false when
+// the path was not present. Mappings left empty by the removal, including
+// top-level blocks, are removed as well.
+func UnsetPath(data []byte, path string) ([]byte, bool, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+	root, err := parseDocumentNode(data)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "decode config document")
+	}
+	if _, node := findNode(root, segments); node == nil {
+		return data, false, nil
+	}
+	for depth := len(segments); depth > 0; depth-- {
+		_, parent := findNode(root, segments[:depth-1])
+		parent = resolveAlias(parent)
+		if parent == nil {
+			break
+		}
+		removeChild(parent, segments, depth-1)
+		if depth == 1 || len(parent.Content) > 0 || parent.Kind != yaml.MappingNode {
+			break
+		}
+	}
+	out, err := encodeDocumentNode(root)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+func removeChild(parent *yaml.Node, segments []string, idx int) {
+	segment := segments[idx]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		foldCase := idx == 1 && segments[0] == "profiles"
+		key, _ := mappingEntry(parent, segment, foldCase)
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i] == key {
+				parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+				return
+			}
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(segment)
+		if err == nil && i >= 0 && i < len(parent.Content) {
+			parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+		}
+	}
+}
+
+// newContainerFor returns the empty node that should hold segments[idx]: a
+// sequence when that segment is an index, a mapping otherwise, and a null
+// placeholder past the end of the path. The first two levels are always
+// mappings because they hold block names and profile slugs.
+func newContainerFor(segments []string, idx int) *yaml.Node {
+	if idx >= len(segments) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	if _, err := strconv.Atoi(segments[idx]); err == nil && idx > 1 {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// replaceNode swaps the content of dst for src while keeping the comments
+// attached to dst.
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	if dst.HeadComment == "" {
+		dst.HeadComment = head
+	}
+	if dst.LineComment == "" {
+		dst.LineComment = line
+	}
+	if dst.FootComment == "" {
+		dst.FootComment = foot
+	}
+}
+
+func parseValueNode(value string) *yaml.Node {
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal([]byte(value), node); err != nil || len(node.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+	parsed := node.Content[0]
+	parsed.HeadComment, parsed.LineComment, parsed.FootComment = "", "", ""
+	blockStyle(parsed)
+	return parsed
+}
+
+// blockStyle drops flow style so values given as "[a, b]" are written like
+// the rest of the document.
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func encodeDocumentNode(root *yaml.Node) ([]byte, error) {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		if top := root.Content[0]; top.Kind == yaml.MappingNode && len(top.Content) == 0 && root.HeadComment == "" && top.HeadComment == "" {
+			return []byte{}, nil
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, errors.Wrap(err, "encode config document")
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Wrap(err, "encode config document")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package configdoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const editFixture = `# Personal pinocchio config.
+profile:
+  # Used when --profile is not given.
+  active: default # keep in sync with the team
+
+profiles:
+  default:
+    inference_settings:
+      chat:
+        engine: gpt-5-mini
+`
+
+func TestSetPathPreservesComments(t *testing.T) {
+	out, err := SetPath([]byte(editFixture), "profile.active", "assistant")
+	require.NoError(t, err)
+	require.Contains(t, string(out), "# Personal pinocchio config.")
+	require.Contains(t, string(out), "# Used when --profile is not given.")
+	require.Contains(t, string(out), "active: assistant # keep in sync with the team")
+
+	out, err = SetPath(out, "profiles.default.inference_settings.chat.temperature", "0.2")
+	require.NoError(t, err)
+	require.Contains(t, string(out), "temperature: 0.2")
+	require.Contains(t, string(out), "engine: gpt-5-mini")
+
+	out, err = SetPath(out, "app.repositories[0]", "~/prompts")
+	require.NoError(t, err)
+	require.Contains(t, string(out), "repositories:\n    - ~/prompts")
+
+	out, err = SetPath(out, `profiles.Default.extensions["pinocchio.usage_pricing@v1"].models`, "{gpt-5-mini: {input_per_mtok: 0.25}}")
+	require.NoError(t, err)
+	require.Contains(t, string(out), "pinocchio.usage_pricing@v1:")
+	require.NotContains(t, string(out), "Default:")
+
+	_, err = SetPath(out, "profile.active.slug", "x")
+	require.ErrorContains(t, err, "scalar")
+	_, err = SetPath(out, "app.repositories[5]", "x")
+	require.ErrorContains(t, err, "out of range")
+}
+
+func TestSetPathCreatesDocument(t *testing.T) {
+	out, err := SetPath(nil, "profile.registries", "[~/.config/pinocchio/profiles.yaml]")
+	require.NoError(t, err)
+	require.Equal(t, "profile:\n  registries:\n    - ~/.config/pinocchio/profiles.yaml\n", string(out))
+}
+
+func TestUnsetPath(t *testing.T) {
+	out, removed, err := UnsetPath([]byte(editFixture), "profiles.default.inference_settings.chat.engine")
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.NotContains(t, string(out), "profiles:")
+	require.Contains(t, string(out), "active: default # keep in sync with the team")
+
+	out, removed, err = UnsetPath(out, "profile.active")
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.Empty(t, string(out))
+
+	_, removed, err = UnsetPath([]byte(editFixture), "app.repositories")
+	require.NoError(t, err)
+	require.False(t, removed)
+}
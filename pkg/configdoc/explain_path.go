@@ -0,0 +1,204 @@
+package configdoc
+
+import (
+	"strings"
+
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"github.com/pkg/errors"
+)
+
+// PathContribution is what one config file set at an explained path.
+type PathContribution struct {
+	File      glazedconfig.ResolvedConfigFile
+	Line      int
+	Operation ProvenanceOperation
+	Value     any
+}
+
+// PathExplanation is the winning value at a config path together with every
+// config file that set it, lowest precedence first.
+type PathExplanation struct {
+	Path          string
+	Found         bool
+	Value         any
+	Contributions []PathContribution
+}
+
+// Winner returns the highest-precedence contribution, or nil when no file sets
+// the path.
+func (e *PathExplanation) Winner() *PathContribution {
+	if e == nil || len(e.Contributions) == 0 {
+		return nil
+	}
+	return &e.Contributions[len(e.Contributions)-1]
+}
+
+// ExplainPath reports the effective value at path and each file that set it.
+// Values are read from the files as written, merged with the same rules as
+// MergeDocuments, and have API keys and OAuth secrets redacted.
+func (r *ResolvedDocuments) ExplainPath(path string) (*PathExplanation, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 1 && segments[0] == "profiles" {
+		segments[1] = normalizeSlugKey(segments[1])
+	}
+	ret := &PathExplanation{Path: FormatPath(segments)}
+	if r == nil {
+		return ret, nil
+	}
+
+	var effective map[string]any
+	for _, file := range r.Files {
+		root, err := loadDocumentNode(file.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config document %s", file.Path)
+		}
+		raw, err := nodeToValue(root)
+		if err != nil {
+			return nil, errors.Wrapf(err, "config document %s", file.Path)
+		}
+		tree, _ := raw.(map[string]any)
+		tree, _ = redactTree(nil, normalizeProfileKeys(tree)).(map[string]any)
+		effective = mergeDocumentTrees(effective, tree)
+
+		value, ok := lookupValue(tree, segments)
+		if !ok {
+			continue
+		}
+		ret.Contributions = append(ret.Contributions, PathContribution{
+			File:      file,
+			Line:      nodeLine(root, segments),
+			Operation: r.operationFor(file, segments),
+			Value:     value,
+		})
+	}
+	ret.Value, ret.Found = lookupValue(effective, segments)
+	return ret, nil
+}
+
+// operationFor returns how file's value at segments combined with lower
+// layers, using the provenance recorded at load time.
+func (r *ResolvedDocuments) operationFor(file glazedconfig.ResolvedConfigFile, segments []string) ProvenanceOperation {
+	if r.Explain == nil {
+		return ProvenanceOperationReplace
+	}
+	best := -1
+	op := ProvenanceOperationReplace
+	descendant := false
+	for key, entries := range r.Explain.ByPath {
+		entryPath, err := ParsePath(key)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.File.Path != file.Path {
+				continue
+			}
+			switch {
+			case hasPathPrefix(segments, entryPath) && len(entryPath) > best:
+				best, op = len(entryPath), entry.Operation
+			case hasPathPrefix(entryPath, segments):
+				descendant = true
+			}
+		}
+	}
+	if best < 0 && descendant {
+		return ProvenanceOperationMerge
+	}
+	return op
+}
+
+// mergeDocumentTrees merges generic config documents like MergeDocuments:
+// app.repositories appends with dedupe, mappings merge, everything else is
+// replaced.
+func mergeDocumentTrees(low, high map[string]any) map[string]any {
+	ret := mergeStringAnyMaps(low, high)
+	highRepos, ok := lookupValue(high, []string{"app", "repositories"})
+	if !ok {
+		return ret
+	}
+	lowRepos, _ := lookupValue(low, []string{"app", "repositories"})
+	merged := mergeRepositories(stringList(lowRepos), stringList(highRepos))
+	repos := make([]any, 0, len(merged))
+	for _, repo := range merged {
+		repos = append(repos, repo)
+	}
+	if app, ok := ret["app"].(map[string]any); ok {
+		app["repositories"] = repos
+	}
+	return ret
+}
+
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	ret := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+			ret = append(ret, strings.TrimSpace(s))
+		}
+	}
+	return ret
+}
+
+func normalizeSlugKey(slug string) string {
+	return strings.ToLower(strings.TrimSpace(slug))
+}
+
+func normalizeProfileKeys(tree map[string]any) map[string]any {
+	profiles, ok := tree["profiles"].(map[string]any)
+	if !ok {
+		return tree
+	}
+	normalized := make(map[string]any, len(profiles))
+	for slug, profile := range profiles {
+		normalized[normalizeSlugKey(slug)] = profile
+	}
+	tree["profiles"] = normalized
+	return tree
+}
+
+// redactTree masks API keys and OAuth secrets in a generic config document.
+func redactTree(path []string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if len(path) == 3 && path[0] == "profiles" && path[2] == "extensions" {
+			v = oauthprofiles.RedactedExtensions(v)
+		}
+		ret := make(map[string]any, len(v))
+		for k, item := range v {
+			if k == "api_keys" {
+				ret[k] = redactStrings(item)
+				continue
+			}
+			ret[k] = redactTree(append(append([]string(nil), path...), k), item)
+		}
+		return ret
+	case []any:
+		ret := make([]any, 0, len(v))
+		for _, item := range v {
+			ret = append(ret, redactTree(path, item))
+		}
+		return ret
+	default:
+		return value
+	}
+}
+
+func redactStrings(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(v))
+		for k, item := range v {
+			ret[k] = redactStrings(item)
+		}
+		return ret
+	case string:
+		return secrets.RedactValue(v)
+	default:
+		return value
+	}
+}
@@ -36,3 +36,59 @@ profiles:
 	require.NotContains(t, entries[0].Value, "access-must-not-appear")
 	require.NotContains(t, entries[0].Value, "refresh-must-not-appear")
 }
+
+func TestResolvedDocumentsExplainPath(t *testing.T) {
+	dir := t.TempDir()
+	user := writeResolvedDocFixture(t, dir, "user.yaml", `app:
+  repositories:
+    - ~/prompts
+profiles:
+  Default:
+    inference_settings:
+      api:
+        api_keys:
+          openai-api-key: sk-user
+      chat:
+        engine: gpt-5
+`)
+	repo := writeResolvedDocFixture(t, dir, "repo.yaml", `app:
+  repositories:
+    - ~/prompts
+    - ./prompts
+profiles:
+  default:
+    inference_settings:
+      chat:
+        engine: gpt-5-mini
+`)
+	resolved, err := LoadResolvedDocuments([]glazedconfig.ResolvedConfigFile{
+		{Path: user, Layer: glazedconfig.LayerUser, Index: 0},
+		{Path: repo, Layer: glazedconfig.LayerRepo, Index: 1},
+	})
+	require.NoError(t, err)
+
+	explained, err := resolved.ExplainPath("profiles.default.inference_settings.chat.engine")
+	require.NoError(t, err)
+	require.True(t, explained.Found)
+	require.Equal(t, "gpt-5-mini", explained.Value)
+	require.Len(t, explained.Contributions, 2)
+	require.Equal(t, user, explained.Contributions[0].File.Path)
+	require.Equal(t, 11, explained.Contributions[0].Line)
+	require.Equal(t, ProvenanceOperationMerge, explained.Contributions[0].Operation)
+	require.Equal(t, repo, explained.Winner().File.Path)
+	require.Equal(t, "gpt-5-mini", explained.Winner().Value)
+
+	explained, err = resolved.ExplainPath("app.repositories")
+	require.NoError(t, err)
+	require.Equal(t, []any{"~/prompts", "./prompts"}, explained.Value)
+	require.Equal(t, ProvenanceOperationAppendDedupe, explained.Winner().Operation)
+
+	explained, err = resolved.ExplainPath("profiles.default.inference_settings.api")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"api_keys": map[string]any{"openai-api-key": "<redacted>"}}, explained.Value)
+
+	explained, err = resolved.ExplainPath("profile.active")
+	require.NoError(t, err)
+	require.False(t, explained.Found)
+	require.Empty(t, explained.Contributions)
+}
@@ -60,6 +60,13 @@ func wrapConfigDocumentError(source string, err error) error {
 	return errors.Wrapf(err, "config document %s", source)
 }
 
+type topLevelKeyFinding struct {
+	line   int
+	key    string
+	reason string
+	legacy bool
+}
+
 func validateTopLevelKeys(source string, root *yaml.Node) error {
 	if root == nil || len(root.Content) == 0 {
 		return nil
@@ -69,13 +76,27 @@ func validateTopLevelKeys(source string, root *yaml.Node) error {
 		return wrapConfigDocumentError(source, errors.New("top-level YAML document must be a mapping with supported keys app, profile, or profiles"))
 	}
 
-	type keyFinding struct {
-		line   int
-		key    string
-		reason string
-		legacy bool
+	findings := topLevelKeyFindings(mapping)
+	if len(findings) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("unsupported legacy top-level keys in unified config:\n")
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "  line %d: %s", finding.line, finding.key)
+		if finding.reason != "" {
+			fmt.Fprintf(&b, " -> %s", finding.reason)
+		}
+		b.WriteString("\n")
 	}
+	b.WriteString("supported top-level keys: app, profile, profiles\n")
+	b.WriteString("legacy keys are errors; they are not ignored\n")
+	b.WriteString("see: pinocchio help config-migration-guide")
+	return wrapConfigDocumentError(source, errors.New(strings.TrimSpace(b.String())))
+}
 
+func topLevelKeyFindings(mapping *yaml.Node) []topLevelKeyFinding {
 	supported := map[string]struct{}{
 		"app":      {},
 		"profile":  {},
@@ -91,7 +112,7 @@ func validateTopLevelKeys(source string, root *yaml.Node) error {
 		"ai-client":        "keep shared provider/client settings in environment variables or move runtime defaults into profiles.<slug>.inference_settings where appropriate",
 	}
 
-	findings := []keyFinding{}
+	findings := []topLevelKeyFinding{}
 	for i := 0; i+1 < len(mapping.Content); i += 2 {
 		keyNode := mapping.Content[i]
 		key := strings.TrimSpace(keyNode.Value)
@@ -102,13 +123,10 @@ func validateTopLevelKeys(source string, root *yaml.Node) error {
 			continue
 		}
 		if reason, ok := legacyReasons[key]; ok {
-			findings = append(findings, keyFinding{line: keyNode.Line, key: key, reason: reason, legacy: true})
+			findings = append(findings, topLevelKeyFinding{line: keyNode.Line, key: key, reason: reason, legacy: true})
 			continue
 		}
-		findings = append(findings, keyFinding{line: keyNode.Line, key: key, reason: "unsupported top-level key; supported keys are app, profile, and profiles"})
-	}
-	if len(findings) == 0 {
-		return nil
+		findings = append(findings, topLevelKeyFinding{line: keyNode.Line, key: key, reason: "unsupported top-level key; supported keys are app, profile, and profiles"})
 	}
 
 	sort.SliceStable(findings, func(i, j int) bool {
@@ -117,20 +135,7 @@ func validateTopLevelKeys(source string, root *yaml.Node) error {
 		}
 		return findings[i].line < findings[j].line
 	})
-
-	var b strings.Builder
-	b.WriteString("unsupported legacy top-level keys in unified config:\n")
-	for _, finding := range findings {
-		fmt.Fprintf(&b, "  line %d: %s", finding.line, finding.key)
-		if finding.reason != "" {
-			fmt.Fprintf(&b, " -> %s", finding.reason)
-		}
-		b.WriteString("\n")
-	}
-	b.WriteString("supported top-level keys: app, profile, profiles\n")
-	b.WriteString("legacy keys are errors; they are not ignored\n")
-	b.WriteString("see: pinocchio help config-migration-guide")
-	return wrapConfigDocumentError(source, errors.New(strings.TrimSpace(b.String())))
+	return findings
 }
 
 func annotatePresence(doc *Document, root *yaml.Node) {
@@ -0,0 +1,157 @@
+package configdoc
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// loadDocumentNode reads path as a YAML node tree. A missing or empty file
+// yields an empty mapping document so callers can create it.
+func loadDocumentNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "read config document")
+	}
+	return parseDocumentNode(data)
+}
+
+func parseDocumentNode(data []byte) (*yaml.Node, error) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, err
+	}
+	if root.Kind == 0 {
+		root.Kind = yaml.DocumentNode
+	}
+	if len(root.Content) == 0 {
+		root.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	return root, nil
+}
+
+// findNode returns the key and value nodes at segments below root. For
+// sequence elements the key node is nil. Profile slugs under "profiles" are
+// matched case-insensitively, the same way NormalizeAndValidate folds them.
+func findNode(root *yaml.Node, segments []string) (*yaml.Node, *yaml.Node) {
+	current := root
+	if current != nil && current.Kind == yaml.DocumentNode && len(current.Content) > 0 {
+		current = current.Content[0]
+	}
+	var key *yaml.Node
+	for i, segment := range segments {
+		if current == nil {
+			return nil, nil
+		}
+		current = resolveAlias(current)
+		switch current.Kind {
+		case yaml.MappingNode:
+			foldCase := i == 1 && segments[0] == "profiles"
+			k, v := mappingEntry(current, segment, foldCase)
+			if v == nil {
+				return nil, nil
+			}
+			key, current = k, v
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(current.Content) {
+				return nil, nil
+			}
+			key, current = nil, current.Content[idx]
+		default:
+			return nil, nil
+		}
+	}
+	return key, current
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func mappingEntry(mapping *yaml.Node, key string, foldCase bool) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	if foldCase {
+		want := strings.ToLower(strings.TrimSpace(key))
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if strings.ToLower(strings.TrimSpace(mapping.Content[i].Value)) == want {
+				return mapping.Content[i], mapping.Content[i+1]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// nodeLine returns the line of the key at segments, falling back to the value
+// line for sequence elements. Zero means the path is not in the document.
+func nodeLine(root *yaml.Node, segments []string) int {
+	key, value := findNode(root, segments)
+	switch {
+	case key != nil:
+		return key.Line
+	case value != nil:
+		return value.Line
+	default:
+		return 0
+	}
+}
+
+// pathAtLine returns the deepest key path declared on line.
+func pathAtLine(root *yaml.Node, line int) []string {
+	if root == nil || line <= 0 {
+		return nil
+	}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	var walk func(node *yaml.Node, prefix []string) []string
+	walk = func(node *yaml.Node, prefix []string) []string {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				k, v := node.Content[i], node.Content[i+1]
+				path := append(append([]string(nil), prefix...), k.Value)
+				if found := walk(v, path); found != nil {
+					return found
+				}
+				if k.Line == line {
+					return path
+				}
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				path := append(append([]string(nil), prefix...), strconv.Itoa(i))
+				if found := walk(item, path); found != nil {
+					return found
+				}
+				if item.Line == line && item.Kind == yaml.ScalarNode {
+					return path
+				}
+			}
+		}
+		return nil
+	}
+	return walk(root, nil)
+}
+
+// nodeToValue decodes a node into plain maps, slices and scalars.
+func nodeToValue(node *yaml.Node) (any, error) {
+	if node == nil {
+		return nil, nil
+	}
+	var out any
+	if err := node.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package configdoc
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParsePath splits a config document path into segments.
+//
+// Segments are separated by dots. Keys that contain dots, such as extension
+// keys, are written in brackets with double quotes, and sequence indexes in
+// plain brackets:
+//
+//	profiles.assistant.inference_settings.chat.engine
+//	profiles.assistant.extensions["pinocchio.oauth@v1"].client_id
+//	app.repositories[0]
+func ParsePath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("config path is empty")
+	}
+	segments := []string{}
+	current := strings.Builder{}
+	flush := func(pos int) error {
+		if current.Len() == 0 {
+			return errors.Errorf("config path %q has an empty segment at offset %d", path, pos)
+		}
+		segments = append(segments, current.String())
+		current.Reset()
+		return nil
+	}
+	// afterBracket is true right after a closing bracket, where only "." or
+	// another "[" may follow.
+	afterBracket := false
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '.':
+			if afterBracket {
+				afterBracket = false
+				continue
+			}
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+		case c == '[':
+			if current.Len() > 0 {
+				if err := flush(i); err != nil {
+					return nil, err
+				}
+			} else if !afterBracket && i > 0 {
+				return nil, errors.Errorf("config path %q has an empty segment at offset %d", path, i)
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, errors.Errorf("config path %q has an unterminated bracket at offset %d", path, i)
+			}
+			inner := path[i+1 : i+end]
+			if strings.HasPrefix(inner, `"`) {
+				unquoted, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, errors.Errorf("config path %q has an invalid quoted key %s", path, inner)
+				}
+				inner = unquoted
+			} else if _, err := strconv.Atoi(inner); err != nil {
+				return nil, errors.Errorf("config path %q: bracket segment %q must be an index or a quoted key", path, inner)
+			}
+			if inner == "" {
+				return nil, errors.Errorf("config path %q has an empty bracket segment at offset %d", path, i)
+			}
+			segments = append(segments, inner)
+			i += end
+			afterBracket = true
+		default:
+			if afterBracket {
+				return nil, errors.Errorf("config path %q: expected '.' or '[' at offset %d", path, i)
+			}
+			current.WriteByte(c)
+		}
+	}
+	if !afterBracket {
+		if err := flush(len(path)); err != nil {
+			return nil, err
+		}
+	}
+	return segments, nil
+}
+
+// FormatPath is the inverse of ParsePath. Keys that would not survive a dotted
+// round trip are quoted in brackets.
+func FormatPath(segments []string) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil && i > 0 {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		if segment == "" || strings.ContainsAny(segment, `.[]"`) {
+			b.WriteString("[" + strconv.Quote(segment) + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+// hasPathPrefix reports whether prefix is an ancestor of, or equal to, path.
+func hasPathPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupValue walks a generic YAML value (maps, slices, scalars).
+func lookupValue(value any, segments []string) (any, bool) {
+	current := value
+	for _, segment := range segments {
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			current = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
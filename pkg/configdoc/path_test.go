@@ -0,0 +1,36 @@
+package configdoc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "profile.active", want: []string{"profile", "active"}},
+		{in: "app.repositories[1]", want: []string{"app", "repositories", "1"}},
+		{in: `profiles.assistant.extensions["pinocchio.oauth@v1"].client_id`, want: []string{"profiles", "assistant", "extensions", "pinocchio.oauth@v1", "client_id"}},
+		{in: `profiles.a.stack[0].profile_slug`, want: []string{"profiles", "a", "stack", "0", "profile_slug"}},
+		{in: "", wantErr: true},
+		{in: "profile..active", wantErr: true},
+		{in: "profile.", wantErr: true},
+		{in: "app.repositories[x]", wantErr: true},
+		{in: "app.repositories[0", wantErr: true},
+		{in: "app.repositories[0]x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.in)
+		if tt.wantErr {
+			require.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		require.Equal(t, tt.want, got, tt.in)
+		require.Equal(t, tt.in, FormatPath(got), tt.in)
+	}
+}
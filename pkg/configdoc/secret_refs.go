@@ -0,0 +1,201 @@
+package configdoc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// IsProjectLayer reports whether files of layer come with the checkout, at
+// the git root or in the working directory, rather than from the user.
+func IsProjectLayer(layer glazedconfig.ConfigLayer) bool {
+	return layer == glazedconfig.LayerRepo || layer == glazedconfig.LayerCWD
+}
+
+// projectDocument is one parsed project layer file or one YAML profile
+// registry a project layer file imports.
+type projectDocument struct {
+	path string
+	root *yaml.Node
+}
+
+// projectDocuments parses the project layer files of files and the YAML
+// profile registries they import. Unreadable documents are skipped; the
+// regular validation reports them.
+func projectDocuments(files []glazedconfig.ResolvedConfigFile) []projectDocument {
+	ret := []projectDocument{}
+	for _, file := range files {
+		if !IsProjectLayer(file.Layer) {
+			continue
+		}
+		root, err := loadDocumentNode(file.Path)
+		if err != nil {
+			continue
+		}
+		ret = append(ret, projectDocument{path: file.Path, root: root})
+
+		doc, err := LoadDocument(file.Path)
+		if err != nil {
+			continue
+		}
+		specs, err := gepprofiles.ParseRegistrySourceSpecs(doc.Profile.Registries)
+		if err != nil {
+			continue
+		}
+		for _, spec := range specs {
+			if spec.Kind != gepprofiles.RegistrySourceKindYAML {
+				continue
+			}
+			if root, err := loadDocumentNode(spec.Path); err == nil {
+				ret = append(ret, projectDocument{path: spec.Path, root: root})
+			}
+		}
+	}
+	return ret
+}
+
+// ProjectSecretRefFindings reports the ${cmd:...} and ${file:...} references
+// of project layer files and of the YAML profile registries they import.
+// Resolving them would run commands or read files chosen by whoever wrote
+// the repository, so they are only honored in user and explicit config files.
+func ProjectSecretRefFindings(files []glazedconfig.ResolvedConfigFile) []Finding {
+	findings := []Finding{}
+	for _, doc := range projectDocuments(files) {
+		findings = append(findings, hostSecretRefFindings(doc.path, doc.root)...)
+	}
+	return sortFindings(findings)
+}
+
+// CheckProjectSecretRefs returns an error naming every finding of
+// ProjectSecretRefFindings, or nil when there are none.
+func CheckProjectSecretRefs(files []glazedconfig.ResolvedConfigFile) error {
+	return findingsError(ProjectSecretRefFindings(files))
+}
+
+// ProjectCredentialStoreFindings reports OAuth credential_store settings of
+// project layer files and of the YAML profile registries they import that
+// would run a command (kind exec) or write tokens to a path of the
+// repository's choosing (an explicit path). Like host secret references, they
+// are only honored in user and explicit config files.
+func ProjectCredentialStoreFindings(files []glazedconfig.ResolvedConfigFile) []Finding {
+	findings := []Finding{}
+	for _, doc := range projectDocuments(files) {
+		findings = append(findings, credentialStoreFindings(doc.path, doc.root)...)
+	}
+	return sortFindings(findings)
+}
+
+// CheckProjectCredentialStores returns an error naming every finding of
+// ProjectCredentialStoreFindings, or nil when there are none.
+func CheckProjectCredentialStores(files []glazedconfig.ResolvedConfigFile) error {
+	return findingsError(ProjectCredentialStoreFindings(files))
+}
+
+func findingsError(findings []Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(findings))
+	for _, f := range findings {
+		errs = append(errs, errors.New(f.String()))
+	}
+	return errors.Join(errs...)
+}
+
+func hostSecretRefFindings(source string, root *yaml.Node) []Finding {
+	ret := []Finding{}
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], append(append([]string(nil), path...), node.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, append(append([]string(nil), path...), strconv.Itoa(i)))
+			}
+		case yaml.ScalarNode:
+			ref, ok, err := secrets.ParseRef(node.Value)
+			if err != nil || !ok || !ref.ReadsHost() {
+				return
+			}
+			ret = append(ret, Finding{
+				File:     source,
+				Line:     node.Line,
+				Path:     FormatPath(path),
+				Severity: FindingSeverityError,
+				Message:  "${" + string(ref.Scheme) + ":...} references are only resolved from user config files; move it to your user config or pass the file with --config-file",
+			})
+		}
+	}
+	walk(root, nil)
+	return ret
+}
+
+func credentialStoreFindings(source string, root *yaml.Node) []Finding {
+	ret := []Finding{}
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i].Value, node.Content[i+1]
+				childPath := append(append([]string(nil), path...), key)
+				if key == "credential_store" && len(path) > 0 && path[len(path)-1] == oauthprofiles.ExtensionKey && value.Kind == yaml.MappingNode {
+					if message := projectCredentialStoreMessage(value); message != "" {
+						ret = append(ret, Finding{
+							File:     source,
+							Line:     node.Content[i].Line,
+							Path:     FormatPath(childPath),
+							Severity: FindingSeverityError,
+							Message:  message,
+						})
+					}
+					continue
+				}
+				walk(value, childPath)
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, append(append([]string(nil), path...), strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(root, nil)
+	return ret
+}
+
+func projectCredentialStoreMessage(node *yaml.Node) string {
+	kind, path := "", ""
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "kind":
+			kind = strings.TrimSpace(node.Content[i+1].Value)
+		case "path":
+			path = strings.TrimSpace(node.Content[i+1].Value)
+		}
+	}
+	switch {
+	case kind == oauthprofiles.StoreKindExec:
+		return "credential_store kind exec runs a command and is only honored in user config files; move the profile to your user config or pass the file with --config-file"
+	case path != "":
+		return "credential_store with an explicit path is only honored in user config files; drop the path to use the default store location or move the profile to your user config"
+	default:
+		return ""
+	}
+}
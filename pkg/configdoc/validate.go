@@ -0,0 +1,344 @@
+package configdoc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	glazedconfig "github.com/go-go-golems/glazed/pkg/config"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type FindingSeverity string
+
+const (
+	FindingSeverityError   FindingSeverity = "error"
+	FindingSeverityWarning FindingSeverity = "warning"
+)
+
+// Finding is one problem reported by ValidateDocument. Line is zero when the
+// problem cannot be attributed to a single key.
+type Finding struct {
+	File     string
+	Line     int
+	Path     string
+	Severity FindingSeverity
+	Message  string
+}
+
+func (f Finding) String() string {
+	var b strings.Builder
+	if f.File != "" {
+		b.WriteString(f.File)
+		if f.Line > 0 {
+			fmt.Fprintf(&b, ":%d", f.Line)
+		}
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "%s: ", f.Severity)
+	if f.Path != "" {
+		b.WriteString(f.Path + ": ")
+	}
+	b.WriteString(f.Message)
+	return b.String()
+}
+
+// HasErrors reports whether any finding has error severity.
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == FindingSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	yamlLinePattern     = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	unknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+)
+
+// ValidateDocument checks one config document and, unlike DecodeDocument,
+// reports every problem it finds instead of stopping at the first one.
+func ValidateDocument(source string, data []byte) []Finding {
+	findings := []Finding{}
+	add := func(line int, path []string, format string, args ...any) {
+		findings = append(findings, Finding{
+			File:     source,
+			Line:     line,
+			Path:     FormatPath(path),
+			Severity: FindingSeverityError,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if err := ValidateLocalOverrideFileName(source); err != nil {
+		add(0, nil, "%s", err.Error())
+		return findings
+	}
+	root, err := parseDocumentNode(data)
+	if err != nil {
+		line, msg := splitYAMLLine(err.Error())
+		add(line, nil, "invalid YAML: %s", msg)
+		return findings
+	}
+	mapping := root.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		add(mapping.Line, nil, "top-level YAML document must be a mapping with supported keys app, profile, or profiles")
+		return findings
+	}
+
+	reportedLines := map[int]bool{}
+	for _, f := range topLevelKeyFindings(mapping) {
+		reportedLines[f.line] = true
+		add(f.line, []string{f.key}, "%s", f.reason)
+	}
+
+	doc := &Document{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(doc); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			line, msg := splitYAMLLine(err.Error())
+			add(line, nil, "%s", msg)
+			return sortFindings(findings)
+		}
+		for _, raw := range typeErr.Errors {
+			line, msg := splitYAMLLine(raw)
+			if reportedLines[line] {
+				continue
+			}
+			reportedLines[line] = true
+			if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+				msg = fmt.Sprintf("unknown key %q", m[1])
+			}
+			add(line, pathAtLine(root, line), "%s", msg)
+		}
+		return sortFindings(findings)
+	}
+	if len(findings) > 0 {
+		return sortFindings(findings)
+	}
+
+	findings = append(findings, apiKeyFindings(source, root, filepath.Base(source) == LocalOverrideFileName)...)
+
+	annotatePresence(doc, root)
+	if err := doc.NormalizeAndValidate(); err != nil {
+		msg := err.Error()
+		var path []string
+		if token, _, ok := strings.Cut(msg, " "); ok {
+			if segments, perr := ParsePath(strings.TrimSuffix(token, ":")); perr == nil && nodeLine(root, segments) > 0 {
+				path = segments
+				msg = strings.TrimSpace(strings.TrimPrefix(msg, token))
+			}
+		}
+		add(nodeLine(root, path), path, "%s", msg)
+	}
+	return sortFindings(findings)
+}
+
+// apiKeyFindings reports malformed secret references in inline profile API
+// keys and, when committed is set, literal API keys in .pinocchio.yml, which
+// is meant to be committed; .pinocchio.override.yml is the uncommitted layer.
+func apiKeyFindings(source string, root *yaml.Node, committed bool) []Finding {
+	ret := []Finding{}
+	_, profiles := findNode(root, []string{"profiles"})
+	if profiles == nil || profiles.Kind != yaml.MappingNode {
+		return ret
+	}
+	for i := 0; i+1 < len(profiles.Content); i += 2 {
+		path := []string{"profiles", profiles.Content[i].Value, "inference_settings", "api", "api_keys"}
+		_, keys := findNode(root, path)
+		if keys == nil || keys.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(keys.Content); j += 2 {
+			value := keys.Content[j+1].Value
+			finding := Finding{
+				File: source,
+				Line: keys.Content[j].Line,
+				Path: FormatPath(append(append([]string(nil), path...), keys.Content[j].Value)),
+			}
+			_, isRef, err := secrets.ParseRef(value)
+			switch {
+			case err != nil:
+				finding.Severity, finding.Message = FindingSeverityError, err.Error()
+			case isRef || !committed || strings.TrimSpace(value) == "":
+				continue
+			default:
+				finding.Severity = FindingSeverityWarning
+				finding.Message = "API key stored in a committed project file; use a secret reference such as ${env:NAME}, or move it to " + LocalProjectOverrideFileName
+			}
+			ret = append(ret, finding)
+		}
+	}
+	return ret
+}
+
+// ProfileLookup reports whether a profile reference resolves outside the inline
+// profiles of the config documents, typically through the imported registries.
+// registry is empty for unqualified references.
+type ProfileLookup func(registry, profile string) (bool, error)
+
+// ValidateResolvedDocuments validates every file of a resolved config stack.
+// When all files are valid on their own it also checks that the winning
+// profile.active and every profile stack reference resolve, either to an
+// inline profile of the merged documents or through lookup. Without a lookup,
+// references are only checked when no registries are configured.
+func ValidateResolvedDocuments(files []glazedconfig.ResolvedConfigFile, lookup ProfileLookup) []Finding {
+	findings := []Finding{}
+	roots := make([]*yaml.Node, len(files))
+	for i, file := range files {
+		data, err := os.ReadFile(file.Path)
+		if err != nil {
+			findings = append(findings, Finding{File: file.Path, Severity: FindingSeverityError, Message: err.Error()})
+			continue
+		}
+		findings = append(findings, ValidateDocument(file.Path, data)...)
+		roots[i], _ = parseDocumentNode(data)
+	}
+	findings = append(findings, ProjectSecretRefFindings(files)...)
+	findings = append(findings, ProjectCredentialStoreFindings(files)...)
+	if HasErrors(findings) {
+		return findings
+	}
+
+	resolved, err := LoadResolvedDocuments(files)
+	if err != nil {
+		return append(findings, Finding{Severity: FindingSeverityError, Message: err.Error()})
+	}
+	effective := resolved.Effective
+	canCheckImported := lookup != nil || len(effective.Profile.Registries) == 0
+	check := func(file string, root *yaml.Node, path []string, registry, profile string) {
+		registry, profile = strings.TrimSpace(registry), strings.TrimSpace(profile)
+		if profile == "" {
+			return
+		}
+		if registry == "" || registry == DefaultInlineRegistrySlug {
+			if _, ok := effective.Profiles[profile]; ok {
+				return
+			}
+		}
+		if !canCheckImported {
+			return
+		}
+		found := false
+		if lookup != nil {
+			var err error
+			found, err = lookup(registry, profile)
+			if err != nil {
+				findings = append(findings, Finding{File: file, Line: nodeLine(root, path), Path: FormatPath(path), Severity: FindingSeverityError, Message: err.Error()})
+				return
+			}
+		}
+		if !found {
+			ref := profile
+			if registry != "" {
+				ref = registry + "/" + profile
+			}
+			findings = append(findings, Finding{
+				File:     file,
+				Line:     nodeLine(root, path),
+				Path:     FormatPath(path),
+				Severity: FindingSeverityError,
+				Message:  fmt.Sprintf("profile %q not found%s", ref, inlineProfilesHint(effective)),
+			})
+		}
+	}
+
+	if entries := resolved.Explain.Entries("profile.active"); len(entries) > 0 {
+		winner := entries[len(entries)-1]
+		check(winner.File.Path, rootForFile(files, roots, winner.File), []string{"profile", "active"}, "", effective.Profile.Active)
+	}
+	for i, doc := range resolved.Documents {
+		for _, slug := range sortedInlineProfileSlugs(doc.Profiles) {
+			rawSlug := slug
+			if key, _ := findNode(roots[i], []string{"profiles", slug}); key != nil {
+				rawSlug = key.Value
+			}
+			for j, ref := range doc.Profiles[slug].Stack {
+				check(files[i].Path, roots[i], []string{"profiles", rawSlug, "stack", strconv.Itoa(j)}, ref.RegistrySlug.String(), ref.EngineProfileSlug.String())
+			}
+		}
+	}
+	return findings
+}
+
+// NewRegistryProfileLookup adapts a profile registry to ProfileLookup.
+func NewRegistryProfileLookup(ctx context.Context, reg gepprofiles.Registry) ProfileLookup {
+	return func(registry, profile string) (bool, error) {
+		if reg == nil {
+			return false, nil
+		}
+		in := gepprofiles.ResolveInput{}
+		if registry != "" {
+			slug, err := gepprofiles.ParseRegistrySlug(registry)
+			if err != nil {
+				return false, err
+			}
+			in.RegistrySlug = slug
+		}
+		slug, err := gepprofiles.ParseEngineProfileSlug(profile)
+		if err != nil {
+			return false, err
+		}
+		in.EngineProfileSlug = slug
+		_, err = reg.ResolveEngineProfile(ctx, in)
+		switch {
+		case err == nil:
+			return true, nil
+		case errors.Is(err, gepprofiles.ErrProfileNotFound), errors.Is(err, gepprofiles.ErrRegistryNotFound):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+}
+
+func rootForFile(files []glazedconfig.ResolvedConfigFile, roots []*yaml.Node, file glazedconfig.ResolvedConfigFile) *yaml.Node {
+	for i := range files {
+		if files[i].Path == file.Path {
+			return roots[i]
+		}
+	}
+	return nil
+}
+
+func inlineProfilesHint(doc *Document) string {
+	slugs := sortedInlineProfileSlugs(doc.Profiles)
+	if len(slugs) == 0 {
+		return ""
+	}
+	return " (inline profiles: " + strings.Join(slugs, ", ") + ")"
+}
+
+func splitYAMLLine(msg string) (int, string) {
+	msg = strings.TrimPrefix(strings.TrimSpace(msg), "yaml: ")
+	m := yamlLinePattern.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, msg
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, m[2]
+}
+
+func sortFindings(findings []Finding) []Finding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
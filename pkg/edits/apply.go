@@ -0,0 +1,355 @@
+package edits
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Actions of a change.
+const (
+	ActionCreate = "create"
+	ActionModify = "modify"
+	ActionDelete = "delete"
+)
+
+// Change is the effect of the edits of one file on the working tree.
+type Change struct {
+	// Path is slash-separated and relative to the root.
+	Path string
+	// Old is the content on disk, empty if the file does not exist.
+	Old    string
+	New    string
+	Exists bool
+	Delete bool
+	// Err is why the edits could not be computed, such as a hunk that does
+	// not match. Changes with an error are never applied.
+	Err error
+}
+
+// Action returns ActionCreate, ActionModify or ActionDelete.
+func (c Change) Action() string {
+	switch {
+	case c.Delete:
+		return ActionDelete
+	case !c.Exists:
+		return ActionCreate
+	}
+	return ActionModify
+}
+
+// Plan computes the changes of edits below root, one per file, in the order
+// the files first appear. Later edits of a file apply on top of earlier ones.
+// Edits that change nothing are dropped. Paths outside of root are an
+// error.
+func Plan(root string, edits []Edit) ([]Change, error) {
+	var ret []Change
+	index := map[string]int{}
+	for _, e := range edits {
+		rel, err := relPath(root, e.Path)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[rel]
+		if !ok {
+			c := Change{Path: rel}
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+			switch {
+			case err == nil:
+				c.Old, c.New, c.Exists = string(data), string(data), true
+			case !errors.Is(err, fs.ErrNotExist):
+				return nil, err
+			}
+			i = len(ret)
+			index[rel] = i
+			ret = append(ret, c)
+		}
+
+		c := &ret[i]
+		if c.Err != nil {
+			continue
+		}
+		switch e.Kind {
+		case KindFile:
+			c.New, c.Delete = e.Content, false
+		case KindDelete:
+			if !c.Exists {
+				c.Err = errors.New("cannot delete a file that does not exist")
+			}
+			c.New, c.Delete = "", true
+		case KindPatch:
+			if c.Delete {
+				c.Err = errors.New("cannot patch a deleted file")
+				continue
+			}
+			patched, err := applyHunks(c.New, e.Hunks)
+			if err != nil {
+				c.Err = err
+				continue
+			}
+			c.New = patched
+		}
+	}
+
+	changed := ret[:0]
+	for _, c := range ret {
+		if c.Err != nil || c.Delete || !c.Exists || c.New != c.Old {
+			changed = append(changed, c)
+		}
+	}
+	return changed, nil
+}
+
+// relPath returns path relative to root, refusing paths that leave root,
+// directly or through a symlink, and paths inside a .git directory, whose
+// hooks and config would run code.
+func relPath(root, path string) (string, error) {
+	p := filepath.FromSlash(path)
+	if filepath.IsAbs(p) {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		if p, err = filepath.Rel(absRoot, p); err != nil {
+			return "", err
+		}
+	}
+	if !filepath.IsLocal(p) {
+		return "", fmt.Errorf("refusing to edit %s outside of the working tree", path)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(p), "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("refusing to edit %s inside a .git directory", path)
+		}
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	// The deepest existing ancestor must resolve inside the root.
+	existing := filepath.Join(root, p)
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	if r, err := filepath.Rel(realRoot, real); err != nil || !(r == "." || filepath.IsLocal(r)) {
+		return "", fmt.Errorf("refusing to edit %s: it resolves outside of the working tree", path)
+	}
+	return filepath.ToSlash(filepath.Clean(p)), nil
+}
+
+// Record is what happened to a change, as kept in the turn metadata.
+type Record struct {
+	Path   string `json:"path" yaml:"path"`
+	Action string `json:"action" yaml:"action"`
+	// Status is StatusApplied, StatusRejected or StatusFailed.
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+	// OldHash and NewHash are the SHA-256 of the content before and after,
+	// empty for missing files.
+	OldHash string `json:"old_hash,omitempty" yaml:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty" yaml:"new_hash,omitempty"`
+}
+
+// Statuses of a record.
+const (
+	StatusApplied  = "applied"
+	StatusRejected = "rejected"
+	StatusFailed   = "failed"
+)
+
+// Apply writes the accepted changes below root as one transaction: every new
+// content is first written to a temporary file next to its target, then the
+// temporary files are renamed over the targets. If the disk changed since
+// Plan, or a step fails, nothing is left changed: applied changes are rolled
+// back and the directories created for new files are removed. It returns a
+// record per change.
+func Apply(root string, changes []Change, accepted []bool) ([]Record, error) {
+	records := make([]Record, len(changes))
+	var todo []int
+	for i, c := range changes {
+		records[i] = Record{Path: c.Path, Action: c.Action(), Status: StatusRejected}
+		if c.Exists {
+			records[i].OldHash = hash(c.Old)
+		}
+		if !c.Delete && c.Err == nil {
+			records[i].NewHash = hash(c.New)
+		}
+		switch {
+		case c.Err != nil:
+			records[i].Status, records[i].Error = StatusFailed, c.Err.Error()
+		case i < len(accepted) && accepted[i]:
+			todo = append(todo, i)
+		}
+	}
+	fail := func(err error) ([]Record, error) {
+		for _, i := range todo {
+			records[i].Status, records[i].Error = StatusFailed, err.Error()
+		}
+		return records, err
+	}
+
+	// Refuse to overwrite changes made since the plan.
+	for _, i := range todo {
+		c := changes[i]
+		data, err := os.ReadFile(target(root, c.Path))
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fail(err)
+		}
+		if exists != c.Exists || string(data) != c.Old {
+			return fail(fmt.Errorf("%s changed on disk since the edits were computed", c.Path))
+		}
+	}
+
+	staged := map[int]string{}
+	var created []string
+	cleanup := func() {
+		for _, tmp := range staged {
+			_ = os.Remove(tmp)
+		}
+	}
+	for _, i := range todo {
+		c := changes[i]
+		if c.Delete {
+			continue
+		}
+		dirs, err := makeDirs(filepath.Dir(target(root, c.Path)))
+		created = append(created, dirs...)
+		var tmp string
+		if err == nil {
+			tmp, err = stage(target(root, c.Path), c.New)
+		}
+		if err != nil {
+			cleanup()
+			removeDirs(created)
+			return fail(err)
+		}
+		staged[i] = tmp
+	}
+
+	var done []int
+	for _, i := range todo {
+		c := changes[i]
+		var err error
+		if c.Delete {
+			err = os.Remove(target(root, c.Path))
+		} else {
+			err = os.Rename(staged[i], target(root, c.Path))
+			delete(staged, i)
+		}
+		if err != nil {
+			cleanup()
+			rollback(root, changes, done)
+			removeDirs(created)
+			return fail(err)
+		}
+		done = append(done, i)
+	}
+	for _, i := range todo {
+		records[i].Status = StatusApplied
+	}
+	return records, nil
+}
+
+func target(root, rel string) string {
+	return filepath.Join(root, filepath.FromSlash(rel))
+}
+
+// makeDirs creates dir and its missing parents. It returns the directories
+// it created, outermost first, even when it fails.
+func makeDirs(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Lstat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append([]string{d}, missing...)
+	}
+	return missing, os.MkdirAll(dir, 0o755)
+}
+
+// removeDirs removes the directories makeDirs created, innermost first.
+// Directories that are not empty are kept.
+func removeDirs(dirs []string) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+}
+
+// stage writes content to a temporary file in the directory of path, with
+// the mode of path if it exists. The directory must exist.
+func stage(path, content string) (string, error) {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".pinocchio-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), mode)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// rollback restores the files of the applied changes.
+func rollback(root string, changes []Change, applied []int) {
+	for _, i := range applied {
+		c := changes[i]
+		if !c.Exists {
+			_ = os.Remove(target(root, c.Path))
+			continue
+		}
+		if tmp, err := stage(target(root, c.Path), c.Old); err == nil {
+			_ = os.Rename(tmp, target(root, c.Path))
+		}
+	}
+}
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Summary counts records by status, such as "2 applied, 1 rejected".
+func Summary(records []Record) string {
+	counts := map[string]int{}
+	for _, r := range records {
+		counts[r.Status]++
+	}
+	var parts []string
+	for _, status := range []string{StatusApplied, StatusRejected, StatusFailed} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(parts) == 0 {
+		return "no edits"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,184 @@
+package edits
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readFile(t *testing.T, dir, path string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), true
+}
+
+func TestPlanAndApply(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":      "package main\n\nvar x = 1\n",
+		"old.go":       "package old\n",
+		"unchanged.go": "package u\n",
+		"rejected.go":  "package r\n",
+	})
+
+	changes, err := Plan(dir, []Edit{
+		{Path: "main.go", Kind: KindPatch, Hunks: []Hunk{{OldStart: 3, Lines: []string{"-var x = 1", "+var x = 2"}}}},
+		{Path: "./main.go", Kind: KindPatch, Hunks: []Hunk{{OldStart: 3, Lines: []string{" var x = 2", "+var y = 3"}}}},
+		{Path: "old.go", Kind: KindDelete},
+		{Path: "unchanged.go", Kind: KindFile, Content: "package u\n"},
+		{Path: "rejected.go", Kind: KindFile, Content: "package rejected\n"},
+		{Path: "new/file.go", Kind: KindFile, Content: "package new\n"},
+		{Path: "broken.go", Kind: KindPatch, Hunks: []Hunk{{OldStart: 1, Lines: []string{"-nope"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, c := range changes {
+		actions = append(actions, c.Action()+" "+c.Path)
+	}
+	want := "modify main.go,delete old.go,modify rejected.go,create new/file.go,create broken.go"
+	if got := strings.Join(actions, ","); got != want {
+		t.Fatalf("changes = %s", got)
+	}
+	if changes[4].Err == nil {
+		t.Fatal("expected the broken patch to fail")
+	}
+
+	records, err := Apply(dir, changes, []bool{true, true, false, true, true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Summary(records); got != "3 applied, 1 rejected, 1 failed" {
+		t.Fatalf("Summary() = %s", got)
+	}
+	for path, want := range map[string]string{
+		"main.go":     "package main\n\nvar x = 2\nvar y = 3\n",
+		"rejected.go": "package r\n",
+		"new/file.go": "package new\n",
+	} {
+		if got, _ := readFile(t, dir, path); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	for _, path := range []string{"old.go", "broken.go"} {
+		if _, ok := readFile(t, dir, path); ok {
+			t.Errorf("%s exists", path)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".pinocchio-") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestApplyConflict(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+	changes, err := Plan(dir, []Edit{
+		{Path: "a.go", Kind: KindFile, Content: "package a // edited\n"},
+		{Path: "b.go", Kind: KindFile, Content: "package b // edited\n"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"b.go": "package b // changed on disk\n"})
+
+	records, err := Apply(dir, changes, []bool{true, true})
+	if err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if records[0].Status != StatusFailed {
+		t.Fatalf("a.go = %s", records[0].Status)
+	}
+	if got, _ := readFile(t, dir, "a.go"); got != "package a\n" {
+		t.Fatalf("a.go was written: %q", got)
+	}
+}
+
+func TestPlanRefusesOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "root")
+	writeFiles(t, parent, map[string]string{"outside/x.go": "package x\n", "root/a.go": "package a\n"})
+	if err := os.Symlink(filepath.Join(parent, "outside"), filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+
+	for _, path := range []string{"../escape.go", filepath.Join(parent, "outside", "x.go"), "link/x.go", "link/new.go"} {
+		if _, err := Plan(dir, []Edit{{Path: path, Kind: KindFile, Content: "x"}}); err == nil {
+			t.Errorf("expected %s to be refused", path)
+		}
+	}
+	if _, err := Plan(dir, []Edit{{Path: filepath.Join(dir, "a.go"), Kind: KindFile, Content: "x"}}); err != nil {
+		t.Errorf("absolute path inside the root: %v", err)
+	}
+	for _, path := range []string{".git/hooks/pre-commit", ".git/config", "sub/.git/config", ".GIT/config"} {
+		if _, err := Plan(dir, []Edit{{Path: path, Kind: KindFile, Content: "x"}}); err == nil {
+			t.Errorf("expected %s to be refused", path)
+		}
+	}
+	if _, err := Plan(dir, []Edit{{Path: ".gitignore", Kind: KindFile, Content: "x"}}); err != nil {
+		t.Errorf(".gitignore: %v", err)
+	}
+}
+
+func TestRemoveDirsUndoesMakeDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"keep/a.go": "package keep\n"})
+
+	created, err := makeDirs(filepath.Join(dir, "keep", "new", "deeper"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	more, err := makeDirs(filepath.Join(dir, "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created = append(created, more...)
+	if len(created) != 3 {
+		t.Fatalf("created = %q", created)
+	}
+
+	removeDirs(created)
+	for _, name := range []string{"keep/new", "other"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s was left behind", name)
+		}
+	}
+	if got, _ := readFile(t, dir, "keep/a.go"); got != "package keep\n" {
+		t.Fatalf("keep/a.go = %q", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	diff := Diff(Change{Path: "a.go", Old: "a\nb\n", New: "a\nc\n", Exists: true})
+	want := "--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n"
+	if diff != want {
+		t.Fatalf("Diff() = %q", diff)
+	}
+	if !strings.HasPrefix(Diff(Change{Path: "n.go", New: "x\n"}), "--- /dev/null\n+++ b/n.go\n") {
+		t.Fatal("expected /dev/null for a created file")
+	}
+}
@@ -0,0 +1,68 @@
+package edits
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var (
+	diffHeaderStyle = lipgloss.NewStyle().Bold(true)
+	diffHunkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemoveStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// Diff returns the unified diff of a change, with /dev/null for a missing
+// side.
+func Diff(c Change) string {
+	from, to := "a/"+c.Path, "b/"+c.Path
+	if !c.Exists {
+		from = "/dev/null"
+	}
+	if c.Delete {
+		to = "/dev/null"
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(c.Old),
+		B:        splitLines(c.New),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	return diff
+}
+
+// splitLines splits s after its newlines. Unlike difflib.SplitLines, it adds
+// no empty line after a final newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// Colorize colors the lines of a unified diff for a terminal.
+func Colorize(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "--- "), strings.HasPrefix(l, "+++ "):
+			lines[i] = diffHeaderStyle.Render(l)
+		case strings.HasPrefix(l, "@@"):
+			lines[i] = diffHunkStyle.Render(l)
+		case strings.HasPrefix(l, "+"):
+			lines[i] = diffAddStyle.Render(l)
+		case strings.HasPrefix(l, "-"):
+			lines[i] = diffRemoveStyle.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
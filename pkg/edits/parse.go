@@ -0,0 +1,196 @@
+// Package edits finds the file edits a model proposes in its answer, whole
+// files in catter's delimiters or unified diffs, and applies them to a
+// working tree.
+package edits
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is how an edit describes the new file.
+type Kind string
+
+const (
+	// KindFile replaces the file with Content, creating it if needed.
+	KindFile Kind = "file"
+	// KindPatch applies the Hunks of a unified diff to the file.
+	KindPatch Kind = "patch"
+	// KindDelete removes the file.
+	KindDelete Kind = "delete"
+)
+
+// Edit is one file edit found in a text.
+type Edit struct {
+	// Path is the path as written in the text.
+	Path    string
+	Kind    Kind
+	Content string
+	Hunks   []Hunk
+}
+
+// Hunk is a hunk of a unified diff. Lines keep their ' ', '-' or '+' prefix.
+type Hunk struct {
+	// OldStart is the 1-based line the hunk starts at in the old file, a
+	// hint as models often get it wrong.
+	OldStart int
+	Lines    []string
+}
+
+var (
+	xmlFileStartRe   = regexp.MustCompile(`^\s*<file\s+(?:name|path)="([^"]+)"[^>]*>\s*$`)
+	delimFileStartRe = regexp.MustCompile(`^--- (?:BEGIN|START) FILE: (.+?) ---\s*$`)
+	hunkHeaderRe     = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+)
+
+// Parse returns the edits of text in order:
+//   - <file name="path"><content>…</content></file> blocks, as written by
+//     `catter print -d xml`, and <file path="path"> blocks of repomap bundles
+//   - --- BEGIN FILE: path --- … --- END FILE: path --- blocks, as written by
+//     `catter print -d begin-end`, or with START FILE as by `-d simple`
+//   - unified diffs, with or without git headers
+//
+// Markdown fences around them are ignored.
+func Parse(text string) []Edit {
+	var ret []Edit
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := xmlFileStartRe.FindStringSubmatch(line); m != nil {
+			if edit, next, ok := parseXMLFile(lines, i+1, m[1]); ok {
+				ret = append(ret, edit)
+				i = next
+			}
+			continue
+		}
+		if m := delimFileStartRe.FindStringSubmatch(line); m != nil {
+			if edit, next, ok := parseDelimitedFile(lines, i+1, m[1]); ok {
+				ret = append(ret, edit)
+				i = next
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") {
+			if edit, next, ok := parseDiff(lines, i); ok {
+				ret = append(ret, edit)
+				i = next
+			}
+		}
+	}
+	return ret
+}
+
+// parseXMLFile reads the content of a <file> block starting at line i, up to
+// </file>. It returns the index of the closing line.
+func parseXMLFile(lines []string, i int, path string) (Edit, int, bool) {
+	for j := i; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) != "</file>" {
+			continue
+		}
+		body := lines[i:j]
+		if len(body) > 0 && strings.TrimSpace(body[0]) == "<content>" {
+			body = body[1:]
+		}
+		if n := len(body); n > 0 && strings.TrimSpace(body[n-1]) == "</content>" {
+			body = body[:n-1]
+		}
+		content := strings.Join(body, "\n")
+		if c, ok := strings.CutPrefix(content, "<![CDATA["); ok {
+			if end := strings.LastIndex(c, "]]>"); end >= 0 {
+				content = strings.ReplaceAll(c[:end], "]]]]><![CDATA[>", "]]>")
+			}
+		}
+		return Edit{Path: path, Kind: KindFile, Content: withFinalNewline(content)}, j, true
+	}
+	return Edit{}, i, false
+}
+
+func parseDelimitedFile(lines []string, i int, path string) (Edit, int, bool) {
+	end := "--- END FILE: " + path + " ---"
+	for j := i; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == end {
+			return Edit{Path: path, Kind: KindFile, Content: withFinalNewline(strings.Join(lines[i:j], "\n"))}, j, true
+		}
+	}
+	return Edit{}, i, false
+}
+
+// withFinalNewline ends content with exactly the newline the delimiters
+// separate it from the closing line with.
+func withFinalNewline(content string) string {
+	if content == "" || strings.HasSuffix(content, "\n") {
+		return content
+	}
+	return content + "\n"
+}
+
+// parseDiff reads the diff of one file whose ---/+++ header starts at line
+// i. Hunk line counts are not trusted: a hunk runs until a line that cannot
+// belong to it. It returns the index of the last line read.
+func parseDiff(lines []string, i int) (Edit, int, bool) {
+	oldPath := diffPath(lines[i][len("--- "):])
+	newPath := diffPath(lines[i+1][len("+++ "):])
+	edit := Edit{Path: newPath, Kind: KindPatch}
+	if newPath == "" {
+		edit.Path, edit.Kind = oldPath, KindDelete
+	}
+	if edit.Path == "" {
+		return Edit{}, i, false
+	}
+
+	j := i + 2
+	for j < len(lines) {
+		m := hunkHeaderRe.FindStringSubmatch(lines[j])
+		if m == nil {
+			break
+		}
+		start, _ := strconv.Atoi(m[1])
+		hunk := Hunk{OldStart: start}
+		j++
+		for ; j < len(lines); j++ {
+			l := lines[j]
+			if l == "" {
+				// Editors and models drop the space of empty context lines.
+				hunk.Lines = append(hunk.Lines, " ")
+				continue
+			}
+			if strings.HasPrefix(l, `\`) {
+				// "\ No newline at end of file"
+				continue
+			}
+			if l[0] != ' ' && l[0] != '-' && l[0] != '+' {
+				break
+			}
+			if strings.HasPrefix(l, "--- ") && j+1 < len(lines) && strings.HasPrefix(lines[j+1], "+++ ") {
+				break
+			}
+			hunk.Lines = append(hunk.Lines, l)
+		}
+		// Trailing blank lines separate the diff from what follows.
+		for n := len(hunk.Lines); n > 0 && hunk.Lines[n-1] == " "; n-- {
+			hunk.Lines = hunk.Lines[:n-1]
+		}
+		edit.Hunks = append(edit.Hunks, hunk)
+	}
+	if edit.Kind == KindPatch && len(edit.Hunks) == 0 {
+		return Edit{}, i, false
+	}
+	return edit, j - 1, true
+}
+
+// diffPath returns the path of a ---/+++ header without its a/ or b/ prefix
+// and timestamp, or "" for /dev/null.
+func diffPath(s string) string {
+	if tab := strings.IndexByte(s, '\t'); tab >= 0 {
+		s = s[:tab]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package edits
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := "Here are the changes.\n\n" +
+		"```xml\n" +
+		"<file name=\"pkg/a.go\">\n<content>\npackage a\n\nvar A = 1\n</content>\n</file>\n" +
+		"```\n\n" +
+		"--- BEGIN FILE: README.md ---\n# Title\n--- END FILE: README.md ---\n\n" +
+		"```diff\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n+++ b/main.go\n" +
+		"@@ -1,3 +1,3 @@\n package main\n\n-var x = 1\n+var x = 2\n" +
+		"--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package old\n" +
+		"```\n"
+
+	want := []Edit{
+		{Path: "pkg/a.go", Kind: KindFile, Content: "package a\n\nvar A = 1\n"},
+		{Path: "README.md", Kind: KindFile, Content: "# Title\n"},
+		{Path: "main.go", Kind: KindPatch, Hunks: []Hunk{
+			{OldStart: 1, Lines: []string{" package main", " ", "-var x = 1", "+var x = 2"}},
+		}},
+		{Path: "old.go", Kind: KindDelete, Hunks: []Hunk{
+			{OldStart: 1, Lines: []string{"-package old"}},
+		}},
+	}
+	if got := Parse(text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseRepoMapCDATA(t *testing.T) {
+	text := "<file path=\"a.go\">\n<![CDATA[var s = \"]]]]><![CDATA[>\"\n]]>\n</file>\n"
+	want := []Edit{{Path: "a.go", Kind: KindFile, Content: "var s = \"]]>\"\n"}}
+	if got := Parse(text); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse() = %+v", got)
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	content := "a\nb\nc\nd\ne\nf\n"
+	tests := []struct {
+		name  string
+		hunks []Hunk
+		want  string
+	}{
+		{
+			name:  "exact",
+			hunks: []Hunk{{OldStart: 2, Lines: []string{" b", "-c", "+C"}}},
+			want:  "a\nb\nC\nd\ne\nf\n",
+		},
+		{
+			name:  "wrong line numbers",
+			hunks: []Hunk{{OldStart: 40, Lines: []string{" d", "-e", "+E"}}},
+			want:  "a\nb\nc\nd\nE\nf\n",
+		},
+		{
+			name: "several hunks",
+			hunks: []Hunk{
+				{OldStart: 1, Lines: []string{"+top", " a"}},
+				{OldStart: 5, Lines: []string{" e", "-f"}},
+			},
+			want: "top\na\nb\nc\nd\ne\n",
+		},
+		{
+			name:  "trailing whitespace",
+			hunks: []Hunk{{OldStart: 1, Lines: []string{" a  ", "-b", "+B"}}},
+			want:  "a\nB\nc\nd\ne\nf\n",
+		},
+		{
+			name:  "insertion at the top",
+			hunks: []Hunk{{OldStart: 0, Lines: []string{"+// header"}}},
+			want:  "// header\na\nb\nc\nd\ne\nf\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyHunks(content, tt.hunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("applyHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := applyHunks(content, []Hunk{{OldStart: 1, Lines: []string{"-z"}}}); err == nil {
+		t.Fatal("expected an error for a hunk that does not match")
+	}
+}
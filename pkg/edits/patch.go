@@ -0,0 +1,127 @@
+package edits
+
+import (
+	"fmt"
+	"strings"
+)
+
+// applyHunks applies hunks to content in order. Each hunk is looked for
+// near its OldStart first, then anywhere after the previous hunk, then with
+// trailing whitespace ignored, as diffs written by models are often off.
+func applyHunks(content string, hunks []Hunk) (string, error) {
+	finalNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	// from is where the next hunk may start; offset is how far the hunks
+	// found so far are from their stated positions.
+	from, offset := 0, 0
+	for i, h := range hunks {
+		var oldLines, newLines []string
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				oldLines = append(oldLines, l[1:])
+				newLines = append(newLines, l[1:])
+			case '-':
+				oldLines = append(oldLines, l[1:])
+			case '+':
+				newLines = append(newLines, l[1:])
+			}
+		}
+
+		hint := h.OldStart - 1 + offset
+		if len(oldLines) == 0 {
+			// Pure additions without context go where the header says.
+			hint = min(max(hint+1, from), len(lines))
+			if h.OldStart == 0 {
+				hint = 0
+			}
+			lines = splice(lines, hint, 0, newLines)
+			from = hint + len(newLines)
+			offset += len(newLines)
+			continue
+		}
+
+		at := findLines(lines, oldLines, from, hint, false)
+		if at < 0 {
+			at = findLines(lines, oldLines, from, hint, true)
+		}
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d (line %d) does not match the file", i+1, h.OldStart)
+		}
+		newLines = keepContext(h.Lines, lines[at:at+len(oldLines)])
+		lines = splice(lines, at, len(oldLines), newLines)
+		offset = at + len(newLines) - (h.OldStart - 1 + len(oldLines))
+		from = at + len(newLines)
+	}
+
+	ret := strings.Join(lines, "\n")
+	if finalNewline && len(lines) > 0 {
+		ret += "\n"
+	}
+	return ret, nil
+}
+
+// keepContext returns the new lines of a hunk, with context lines as found
+// in the file rather than as written in the hunk.
+func keepContext(hunk, found []string) []string {
+	var ret []string
+	k := 0
+	for _, l := range hunk {
+		switch l[0] {
+		case ' ':
+			ret = append(ret, found[k])
+			k++
+		case '-':
+			k++
+		case '+':
+			ret = append(ret, l[1:])
+		}
+	}
+	return ret
+}
+
+// findLines returns the index at or after from where want appears in lines,
+// the closest to hint, or -1.
+func findLines(lines, want []string, from, hint int, loose bool) int {
+	best := -1
+	for at := from; at+len(want) <= len(lines); at++ {
+		if !linesMatch(lines[at:at+len(want)], want, loose) {
+			continue
+		}
+		if best < 0 || abs(at-hint) < abs(best-hint) {
+			best = at
+		}
+	}
+	return best
+}
+
+func linesMatch(a, b []string, loose bool) bool {
+	for i := range b {
+		x, y := a[i], b[i]
+		if loose {
+			x, y = strings.TrimRight(x, " \t\r"), strings.TrimRight(y, " \t\r")
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+func splice(lines []string, at, remove int, insert []string) []string {
+	ret := make([]string, 0, len(lines)-remove+len(insert))
+	ret = append(ret, lines[:at]...)
+	ret = append(ret, insert...)
+	return append(ret, lines[at+remove:]...)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,164 @@
+package edits
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ErrReviewCanceled is returned by Review when the user quits without
+// confirming.
+var ErrReviewCanceled = errors.New("review canceled")
+
+var (
+	reviewTitleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+	reviewSelectedStyle = lipgloss.NewStyle().Bold(true).Reverse(true)
+	reviewAcceptedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	reviewRejectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	reviewHelpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+const reviewHelp = "↑/↓ file · space toggle · y accept · n reject · a/r all · pgup/pgdn scroll · enter apply · q cancel"
+
+// Review shows the diff of each change in a terminal UI on tty and lets the
+// user accept or reject each file. Changes are accepted by default, except
+// those with an error, which cannot be accepted.
+func Review(changes []Change, tty *os.File) ([]bool, error) {
+	m := newReviewModel(changes)
+	p := tea.NewProgram(m, tea.WithInput(tty), tea.WithOutput(tty), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	rm := final.(*reviewModel)
+	if !rm.confirmed {
+		return nil, ErrReviewCanceled
+	}
+	return rm.accepted, nil
+}
+
+type reviewModel struct {
+	changes   []Change
+	diffs     []string
+	accepted  []bool
+	selected  int
+	confirmed bool
+	width     int
+	height    int
+	viewport  viewport.Model
+}
+
+func newReviewModel(changes []Change) *reviewModel {
+	m := &reviewModel{
+		changes:  changes,
+		accepted: make([]bool, len(changes)),
+		viewport: viewport.New(80, 20),
+	}
+	for i, c := range changes {
+		if c.Err != nil {
+			m.diffs = append(m.diffs, reviewRejectedStyle.Render("cannot apply: "+c.Err.Error()))
+			continue
+		}
+		m.accepted[i] = true
+		m.diffs = append(m.diffs, Colorize(Diff(c)))
+	}
+	m.showSelected()
+	return m
+}
+
+func (m *reviewModel) Init() tea.Cmd { return nil }
+
+func (m *reviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "up", "k":
+			m.selectFile(m.selected - 1)
+		case "down", "j":
+			m.selectFile(m.selected + 1)
+		case " ", "tab":
+			m.setAccepted(m.selected, !m.accepted[m.selected])
+		case "y":
+			m.setAccepted(m.selected, true)
+			m.selectFile(m.selected + 1)
+		case "n":
+			m.setAccepted(m.selected, false)
+			m.selectFile(m.selected + 1)
+		case "a", "r":
+			for i := range m.changes {
+				m.setAccepted(i, msg.String() == "a")
+			}
+		default:
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m *reviewModel) selectFile(i int) {
+	if i < 0 || i >= len(m.changes) {
+		return
+	}
+	m.selected = i
+	m.showSelected()
+}
+
+func (m *reviewModel) setAccepted(i int, accepted bool) {
+	if m.changes[i].Err == nil {
+		m.accepted[i] = accepted
+	}
+}
+
+func (m *reviewModel) showSelected() {
+	if len(m.diffs) > 0 {
+		m.viewport.SetContent(m.diffs[m.selected])
+		m.viewport.GotoTop()
+	}
+}
+
+// layout sizes the diff to what the file list, title and help leave.
+func (m *reviewModel) layout() {
+	m.viewport.Width = m.width
+	m.viewport.Height = max(m.height-len(m.changes)-4, 3)
+}
+
+func (m *reviewModel) View() string {
+	var b strings.Builder
+	b.WriteString(reviewTitleStyle.Render(fmt.Sprintf("%d file(s) edited", len(m.changes))))
+	b.WriteString("\n")
+	for i, c := range m.changes {
+		mark := reviewAcceptedStyle.Render("[x]")
+		if !m.accepted[i] {
+			mark = reviewRejectedStyle.Render("[ ]")
+		}
+		line := fmt.Sprintf("%-6s %s", c.Action(), c.Path)
+		if c.Err != nil {
+			line += " (failed)"
+		}
+		if i == m.selected {
+			line = reviewSelectedStyle.Render(line)
+		}
+		b.WriteString(mark + " " + line + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+	b.WriteString(reviewHelpStyle.Render(reviewHelp))
+	return b.String()
+}
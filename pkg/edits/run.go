@@ -0,0 +1,66 @@
+package edits
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Options configure ApplyText.
+type Options struct {
+	// Root is the working tree the edits are confined to.
+	Root string
+	// Review lets the user accept or reject each file in a terminal UI on
+	// /dev/tty. Without it, every change that can be applied is.
+	Review bool
+	// DryRun prints the diffs without writing.
+	DryRun bool
+}
+
+// ApplyText finds the edits in text, prints their diffs to w and applies the
+// accepted ones. It returns a record per changed file, none for a dry run.
+func ApplyText(w io.Writer, text string, opts Options) ([]Record, error) {
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+	changes, err := Plan(root, Parse(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "no file edits found")
+		return nil, err
+	}
+
+	accepted := make([]bool, len(changes))
+	if opts.Review && !opts.DryRun {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot review edits without a terminal: %w", err)
+		}
+		accepted, err = Review(changes, tty)
+		_ = tty.Close()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for i, c := range changes {
+			if c.Err != nil {
+				_, _ = fmt.Fprintf(w, "cannot apply %s: %v\n", c.Path, c.Err)
+				continue
+			}
+			accepted[i] = true
+			_, _ = fmt.Fprint(w, Colorize(Diff(c)))
+		}
+	}
+	if opts.DryRun {
+		return nil, nil
+	}
+
+	records, err := Apply(root, changes, accepted)
+	for _, r := range records {
+		_, _ = fmt.Fprintf(w, "%-8s %-6s %s\n", r.Status, r.Action, r.Path)
+	}
+	return records, err
+}
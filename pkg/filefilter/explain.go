@@ -0,0 +1,32 @@
+package filefilter
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// ExplainPaths walks paths like catter does and calls fn with the decision
+// for every file and directory it reaches. Excluded directories are reported
+// once and not descended into.
+func (ff *FileFilter) ExplainPaths(paths []string, fn func(Decision) error) error {
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return fn(Decision{Path: path, Reason: err.Error()})
+			}
+			d := ff.Explain(path)
+			d.IsDir = entry.IsDir()
+			if err := fn(d); err != nil {
+				return err
+			}
+			if d.IsDir && !d.Included {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
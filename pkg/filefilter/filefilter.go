@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/denormal/go-gitignore"
 	"github.com/go-go-golems/clay/pkg/filewalker"
@@ -20,6 +21,8 @@ type FileFilter struct {
 	ExcludeExts           []string               `yaml:"exclude-exts,omitempty"`
 	MatchFilenames        []*regexp.Regexp       `yaml:"match-filenames,omitempty"`
 	MatchPaths            []*regexp.Regexp       `yaml:"match-paths,omitempty"`
+	MatchGlobs            []string               `yaml:"match-globs,omitempty"`
+	ExcludeGlobs          []string               `yaml:"exclude-globs,omitempty"`
 	ExcludeDirs           []string               `yaml:"exclude-dirs,omitempty"`
 	GitIgnoreFilter       gitignore.GitIgnore    `yaml:"-"`
 	DisableGitIgnore      bool                   `yaml:"disable-gitignore,omitempty"`
@@ -30,10 +33,49 @@ type FileFilter struct {
 	Profiles              map[string]*FileFilter `yaml:"profiles,omitempty"`
 	FilterBinaryFiles     bool                   `yaml:"filter-binary-files,omitempty"`
 
+	// Extra holds the keys of the YAML file the filter does not use, such as
+	// catter's transforms, so saving a loaded filter keeps them.
+	Extra map[string]interface{} `yaml:",inline"`
+
+	// Root is the directory anchored patterns ("/build") are relative to and
+	// below which directory exclusions are checked. Empty means the working
+	// directory, or the repository root for paths outside of it.
+	Root string `yaml:"-"`
+
 	// Default values (not serialized)
 	DefaultExcludedExts           []string         `yaml:"-"`
 	DefaultExcludedDirs           []string         `yaml:"-"`
 	DefaultExcludedMatchFilenames []*regexp.Regexp `yaml:"-"`
+
+	compileOnce sync.Once
+	compiled    *compiledPatterns
+}
+
+// compiledPatterns holds the parsed glob fields and the ignore file cache.
+// They are built on first use, so fields set after construction (or loaded
+// from YAML) are honored.
+type compiledPatterns struct {
+	excludeDirs  []*Pattern
+	matchGlobs   []*Pattern
+	excludeGlobs []*Pattern
+	ignores      *ignoreFiles
+	wd           string
+}
+
+// Decision tells whether a path passes the filter and why.
+type Decision struct {
+	Path     string
+	IsDir    bool
+	Included bool
+	Reason   string
+}
+
+func included(reason string) Decision {
+	return Decision{Included: true, Reason: reason}
+}
+
+func excluded(format string, args ...interface{}) Decision {
+	return Decision{Reason: fmt.Sprintf(format, args...)}
 }
 
 type FileFilterOption func(*FileFilter)
@@ -81,6 +123,24 @@ func WithMatchPaths(patterns []string) FileFilterOption {
 	}
 }
 
+func WithMatchGlobs(globs []string) FileFilterOption {
+	return func(ff *FileFilter) {
+		ff.MatchGlobs = globs
+	}
+}
+
+func WithExcludeGlobs(globs []string) FileFilterOption {
+	return func(ff *FileFilter) {
+		ff.ExcludeGlobs = globs
+	}
+}
+
+func WithRoot(root string) FileFilterOption {
+	return func(ff *FileFilter) {
+		ff.Root = root
+	}
+}
+
 func WithExcludeDirs(dirs []string) FileFilterOption {
 	return func(ff *FileFilter) {
 		ff.ExcludeDirs = dirs
@@ -144,7 +204,7 @@ var (
 	}
 
 	DefaultExcludedDirs = []string{
-		".git", ".svn", "node_modules", "vendor", ".history", ".idea", ".vscode", ".yardoc", "build", "dist", "sorbet",
+		".git", ".svn", "node_modules", "vendor", ".history", ".idea", ".vscode", ".yardoc", "build", "dist", "sorbet", ".catter-cache",
 	}
 
 	DefaultExcludedMatchFilenames = []*regexp.Regexp{
@@ -162,6 +222,8 @@ func (ff *FileFilter) PrintConfiguredFilters() {
 	fmt.Printf("  Exclude Extensions: %v\n", ff.ExcludeExts)
 	fmt.Printf("  Match Filenames: %v\n", ff.MatchFilenames)
 	fmt.Printf("  Match Paths: %v\n", ff.MatchPaths)
+	fmt.Printf("  Match Globs: %v\n", ff.MatchGlobs)
+	fmt.Printf("  Exclude Globs: %v\n", ff.ExcludeGlobs)
 	fmt.Printf("  Exclude Directories: %v\n", ff.ExcludeDirs)
 	fmt.Printf("  Exclude Match Filenames: %v\n", ff.ExcludeMatchFilenames)
 	fmt.Printf("  Exclude Match Paths: %v\n", ff.ExcludeMatchPaths)
@@ -174,60 +236,107 @@ func (ff *FileFilter) PrintConfiguredFilters() {
 }
 
 func (ff *FileFilter) FilterNode(node *filewalker.Node) bool {
-	result := false
-	if node.GetType() == filewalker.DirectoryNode {
-		result = !ff.isExcludedDir(node.GetPath())
-	} else {
-		result = ff.FilterPath(node.GetPath())
-	}
-
-	if ff.Verbose {
-		if result {
-			fmt.Printf("Including: %s\n", node.GetPath())
-		} else {
-			fmt.Printf("Excluding: %s\n", node.GetPath())
-		}
-	}
-
-	return result
+	return ff.FilterPath(node.GetPath())
 }
 
 func (ff *FileFilter) FilterPath(filePath string) bool {
-	result := ff.shouldProcessFile(filePath)
+	d := ff.Explain(filePath)
 
 	if ff.Verbose {
-		if result {
+		if d.Included {
 			fmt.Printf("Including: %s\n", filePath)
 		} else {
-			fmt.Printf("Excluding: %s\n", filePath)
+			fmt.Printf("Excluding: %s (%s)\n", filePath, d.Reason)
 		}
 	}
 
-	return result
+	return d.Included
 }
 
-func (ff *FileFilter) isExcludedDir(dirPath string) bool {
-	if !ff.DisableDefaultFilters {
-		for _, excludedDir := range ff.DefaultExcludedDirs {
-			if strings.Contains(dirPath, excludedDir) {
-				return true
+func (ff *FileFilter) patterns() *compiledPatterns {
+	ff.compileOnce.Do(func() {
+		c := &compiledPatterns{
+			ignores: newIgnoreFiles(!ff.DisableGitIgnore),
+		}
+		if !ff.DisableDefaultFilters {
+			c.excludeDirs = parsePatterns(ff.DefaultExcludedDirs, "default exclude-dirs")
+		}
+		c.excludeDirs = append(c.excludeDirs, parsePatterns(ff.ExcludeDirs, "exclude-dirs")...)
+		c.matchGlobs = parsePatterns(ff.MatchGlobs, "match-glob")
+		c.excludeGlobs = parsePatterns(ff.ExcludeGlobs, "exclude-glob")
+		c.wd, _ = os.Getwd()
+		ff.compiled = c
+	})
+	return ff.compiled
+}
+
+// root returns the directory patterns and directory exclusions of abs are
+// evaluated below, and the directory ignore files are read from.
+func (ff *FileFilter) root(abs string) (scope string, top string) {
+	c := ff.patterns()
+	repo := c.ignores.repoRoot(filepath.Dir(abs))
+
+	switch {
+	case ff.Root != "":
+		scope, _ = filepath.Abs(ff.Root)
+	default:
+		if c.wd != "" {
+			if _, ok := relativeTo(c.wd, abs); ok {
+				scope = c.wd
 			}
 		}
+		if scope == "" {
+			scope = repo
+		}
+		if scope == "" {
+			scope = filepath.Dir(abs)
+		}
 	}
-	for _, excludedDir := range ff.ExcludeDirs {
-		if strings.Contains(dirPath, excludedDir) {
-			return true
+
+	top = scope
+	if repo != "" {
+		if _, ok := relativeTo(repo, scope); ok {
+			top = repo
 		}
 	}
-	return false
+	return scope, top
+}
+
+// Explain applies the filter to filePath and returns the decision with the
+// rule that made it.
+//
+// Paths below the filter's root are checked level by level, like git does:
+// once a directory is excluded by an ignore file or by exclude-dirs,
+// nothing below it can be included again.
+func (ff *FileFilter) Explain(filePath string) Decision {
+	d := ff.explain(filePath)
+	d.Path = filePath
+	return d
+}
+
+// ExplainContent applies the filter to a file that need not exist on disk,
+// such as a file read from a git revision. content replaces the size and
+// binary checks on disk. Ignore files are still read from disk.
+func (ff *FileFilter) ExplainContent(filePath string, content []byte) Decision {
+	d := ff.explainWith(filePath, false, int64(len(content)), func() (bool, error) {
+		return isBinaryContent(content), nil
+	})
+	d.Path = filePath
+	return d
 }
 
-func (ff *FileFilter) shouldProcessFile(filePath string) bool {
+func (ff *FileFilter) explain(filePath string) Decision {
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		// If we can't get file info, we'll exclude the file
-		return false
+		return excluded("cannot stat: %v", err)
 	}
+	return ff.explainWith(filePath, fileInfo.IsDir(), fileInfo.Size(), func() (bool, error) {
+		return isBinaryFile(filePath)
+	})
+}
+
+func (ff *FileFilter) explainWith(filePath string, isDir bool, size int64, isBinary func() (bool, error)) Decision {
 
 	// Check GitIgnore filter first
 	if !ff.DisableGitIgnore && ff.GitIgnoreFilter != nil {
@@ -235,110 +344,183 @@ func (ff *FileFilter) shouldProcessFile(filePath string) bool {
 		if filePath != "." {
 			match := ff.GitIgnoreFilter.Match(filePath)
 			if match != nil && match.Ignore() {
-				return false
+				return excluded("ignored by gitignore filter: %s", match)
 			}
 		}
 	}
 
-	ext := strings.ToLower(filepath.Ext(filePath))
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return excluded("cannot resolve path: %v", err)
+	}
+	c := ff.patterns()
+	scope, top := ff.root(abs)
+
+	var levels []string
+	if rel, ok := relativeTo(scope, abs); ok && rel != "." {
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for i := range segments {
+			levels = append(levels, strings.Join(segments[:i+1], "/"))
+		}
+	}
+
+	var reincluded *Pattern
+	for i, rel := range levels {
+		last := i == len(levels)-1
+		levelIsDir := !last || isDir
+		levelAbs := filepath.Join(scope, filepath.FromSlash(rel))
+
+		if p := c.ignores.match(top, levelAbs, levelIsDir); p != nil {
+			if !p.Negated() {
+				if last {
+					return excluded("ignored by %s", p)
+				}
+				return excluded("directory %s ignored by %s", rel, p)
+			}
+			if last {
+				reincluded = p
+			}
+		}
+		if levelIsDir {
+			if p := lastMatch(c.excludeDirs, rel, true); p != nil && !p.Negated() {
+				if last {
+					return excluded("directory matches %s", p)
+				}
+				return excluded("directory %s matches %s", rel, p)
+			}
+		}
+	}
 
-	if fileInfo.IsDir() {
-		return !ff.isExcludedDir(filePath)
+	if isDir {
+		return included("")
 	}
 
+	ext := strings.ToLower(filepath.Ext(filePath))
+
 	// Check against default excluded extensions
 	if !ff.DisableDefaultFilters {
 		for _, excludedExt := range ff.DefaultExcludedExts {
 			if ext == excludedExt {
-				return false
+				return excluded("default excluded extension %s", ext)
 			}
 		}
 	}
 
-	if fileInfo.Size() > ff.MaxFileSize {
-		return false
+	if size > ff.MaxFileSize {
+		return excluded("size %d exceeds max-file-size %d", size, ff.MaxFileSize)
 	}
 
 	if len(ff.IncludeExts) > 0 {
-		included := false
+		found := false
 		for _, includedExt := range ff.IncludeExts {
 			if ext == strings.ToLower(includedExt) {
-				included = true
+				found = true
 				break
 			}
 		}
-		if !included {
-			return false
+		if !found {
+			return excluded("extension %q not in include", ext)
 		}
 	}
 
 	for _, excludedExt := range ff.ExcludeExts {
 		if ext == strings.ToLower(excludedExt) {
-			return false
+			return excluded("extension %s in exclude", ext)
 		}
 	}
 
-	if len(ff.MatchFilenames) > 0 || len(ff.MatchPaths) > 0 {
-		filenameMatch := false
-		pathMatch := false
+	reason := ""
+	if reincluded != nil {
+		reason = fmt.Sprintf("re-included by %s", reincluded)
+	}
+
+	if len(ff.MatchFilenames) > 0 || len(ff.MatchPaths) > 0 || len(c.matchGlobs) > 0 {
+		matched := ""
 
 		for _, re := range ff.MatchFilenames {
 			if re.MatchString(filepath.Base(filePath)) {
-				filenameMatch = true
+				matched = fmt.Sprintf("match-filename: %s", re)
 				break
 			}
 		}
 
-		for _, re := range ff.MatchPaths {
-			if re.MatchString(filePath) {
-				pathMatch = true
-				break
+		if matched == "" {
+			for _, re := range ff.MatchPaths {
+				if re.MatchString(filePath) {
+					matched = fmt.Sprintf("match-path: %s", re)
+					break
+				}
+			}
+		}
+
+		if matched == "" {
+			if p := matchGlob(c.matchGlobs, levels); p != nil {
+				matched = p.String()
 			}
 		}
 
-		if !filenameMatch && !pathMatch {
-			return false
+		if matched == "" {
+			return excluded("no match-filename, match-path or match-glob matched")
 		}
+		reason = "matches " + matched
 	}
 
 	// Check against default excluded match filenames
 	if !ff.DisableDefaultFilters {
 		for _, re := range ff.DefaultExcludedMatchFilenames {
 			if re.MatchString(filepath.Base(filePath)) {
-				return false
+				return excluded("default excluded filename %s", re)
 			}
 		}
 	}
 
 	for _, re := range ff.ExcludeMatchFilenames {
 		if re.MatchString(filepath.Base(filePath)) {
-			return false
+			return excluded("exclude-match-filename: %s", re)
 		}
 	}
 
 	for _, re := range ff.ExcludeMatchPaths {
 		if re.MatchString(filePath) {
-			return false
+			return excluded("exclude-match-path: %s", re)
 		}
 	}
 
+	if p := matchGlob(c.excludeGlobs, levels); p != nil {
+		return excluded("matches %s", p)
+	}
+
 	// TODO: fix upstream bug where "." / root panics
 	if filePath != "." && !ff.DisableGitIgnore && ff.GitIgnoreFilter != nil && ff.GitIgnoreFilter.Ignore(filePath) {
-		return false
+		return excluded("ignored by gitignore filter")
 	}
 
 	if ff.FilterBinaryFiles {
-		isBinary, err := isBinaryFile(filePath)
-		if err != nil {
-			// If there's an error checking, we'll assume it's not binary
-			return true
-		}
-		if isBinary {
-			return false
+		binary, err := isBinary()
+		// If there's an error checking, we'll assume it's not binary
+		if err == nil && binary {
+			return excluded("binary file")
 		}
 	}
 
-	return true
+	return included(reason)
+}
+
+// matchGlob returns the match-glob or exclude-glob pattern selecting the
+// file whose directory levels (ending with the file itself) are given. A
+// pattern that matches a directory selects everything below it, and a deeper
+// match, including a negated one, overrides a shallower one.
+func matchGlob(patterns []*Pattern, levels []string) *Pattern {
+	var ret *Pattern
+	for i, rel := range levels {
+		if p := lastMatch(patterns, rel, i < len(levels)-1); p != nil {
+			ret = p
+		}
+	}
+	if ret == nil || ret.Negated() {
+		return nil
+	}
+	return ret
 }
 
 // Add this helper function to detect binary files
@@ -358,8 +540,15 @@ func isBinaryFile(filePath string) (bool, error) {
 		return false, err
 	}
 
-	// Check for null bytes in the first 512 bytes
-	return bytes.IndexByte(buffer[:n], 0) != -1, nil
+	return isBinaryContent(buffer[:n]), nil
+}
+
+// isBinaryContent checks for null bytes in the first 512 bytes.
+func isBinaryContent(content []byte) bool {
+	if len(content) > 512 {
+		content = content[:512]
+	}
+	return bytes.IndexByte(content, 0) != -1
 }
 
 // ToYAML serializes the FileFilter to YAML format
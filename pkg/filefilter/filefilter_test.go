@@ -0,0 +1,223 @@
+package filefilter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"build", "build", true, true},
+		{"build", "src/build", true, true},
+		{"build", "src/rebuild_tools", true, false},
+		{"/build", "src/build", true, false},
+		{"build/", "build", false, false},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "docs/sub/a.md", false, false},
+		{"docs/*.md", "x/docs/a.md", false, false},
+		{"**/testdata", "a/b/testdata", true, true},
+		{"pkg/**", "pkg/a/b.go", false, true},
+		{"pkg/**", "pkg", true, false},
+		{"a/**/b", "a/b", true, true},
+		{"a/**/b", "a/x/y/b", true, true},
+		{"*.lo[gG]", "x/debug.loG", false, true},
+		{"file?.txt", "file1.txt", false, true},
+		{`\!important`, "!important", false, true},
+	}
+	for _, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if err != nil || p == nil {
+			t.Fatalf("ParsePattern(%q) = %v, %v", tt.pattern, p, err)
+		}
+		if got := p.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q.Match(%q, dir=%v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if p, err := ParsePattern(line); p != nil || err != nil {
+			t.Errorf("ParsePattern(%q) = %v, %v, want nil", line, p, err)
+		}
+	}
+	if p, _ := ParsePattern("!keep.txt"); p == nil || !p.Negated() {
+		t.Errorf("expected negated pattern")
+	}
+}
+
+func TestExcludeDirsMatchSegments(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"src/rebuild_tools/a.go": "package a\n",
+		"src/build/b.go":         "package b\n",
+		"tools/build/c.go":       "package c\n",
+		"dist/d.go":              "package d\n",
+		"src/dist/e.go":          "package e\n",
+	})
+	ff := NewFileFilter(WithRoot(dir), WithExcludeDirs([]string{"!tools/build", "/dist"}))
+
+	for name, want := range map[string]bool{
+		"src/rebuild_tools/a.go": true,
+		"src/build/b.go":         false,
+		"tools/build/c.go":       true,
+		"dist/d.go":              false,
+		"src/dist/e.go":          false,
+	} {
+		if got := ff.FilterPath(filepath.Join(dir, name)); got != want {
+			t.Errorf("FilterPath(%s) = %v, want %v (%s)", name, got, want, ff.Explain(filepath.Join(dir, name)).Reason)
+		}
+	}
+
+	// Negations only override the patterns before them.
+	ff = NewFileFilter(WithRoot(dir), WithDisableDefaultFilters(true), WithExcludeDirs([]string{"!tools/build", "build"}))
+	if ff.FilterPath(filepath.Join(dir, "tools/build/c.go")) {
+		t.Errorf("expected tools/build to stay excluded")
+	}
+}
+
+func TestNestedIgnoreFiles(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":            "*.log\n/generated/\n",
+		"keep.log":              "log\n",
+		"app.go":                "package app\n",
+		"generated/x.go":        "package x\n",
+		"pkg/.gitignore":        "!important.log\nlocal/\n",
+		"pkg/important.log":     "log\n",
+		"pkg/other.log":         "log\n",
+		"pkg/local/y.go":        "package y\n",
+		"pkg/generated/z.go":    "package z\n",
+		"pkg/.catterignore":     "*_test.go\n",
+		"pkg/a_test.go":         "package pkg\n",
+		"pkg/a.go":              "package pkg\n",
+		"docs/.catterignore":    "!*.log\n",
+		"docs/notes.log":        "log\n",
+		"docs/draft/.gitignore": "*\n",
+		"docs/draft/d.md":       "draft\n",
+	})
+	ff := NewFileFilter(WithRoot(dir))
+
+	cases := map[string]string{
+		"app.go":             "",
+		"keep.log":           "ignored by ",
+		"generated/x.go":     "directory generated ignored by ",
+		"pkg/important.log":  "",
+		"pkg/other.log":      ".gitignore:1: *.log",
+		"pkg/local/y.go":     "directory pkg/local ignored by ",
+		"pkg/generated/z.go": "",
+		"pkg/a_test.go":      ".catterignore:1: *_test.go",
+		"pkg/a.go":           "",
+		"docs/notes.log":     "",
+		"docs/draft/d.md":    "ignored by ",
+	}
+	for name, reason := range cases {
+		d := ff.Explain(filepath.Join(dir, name))
+		wantIncluded := reason == ""
+		if d.Included != wantIncluded || !strings.Contains(d.Reason, reason) {
+			t.Errorf("Explain(%s) = %v %q, want included=%v reason containing %q", name, d.Included, d.Reason, wantIncluded, reason)
+		}
+	}
+
+	if d := ff.Explain(filepath.Join(dir, "pkg/important.log")); !strings.HasPrefix(d.Reason, "re-included by ") {
+		t.Errorf("expected re-inclusion reason, got %q", d.Reason)
+	}
+
+	noGit := NewFileFilter(WithRoot(dir), WithDisableGitIgnore(true))
+	if !noGit.FilterPath(filepath.Join(dir, "keep.log")) {
+		t.Errorf("--disable-gitignore should skip .gitignore")
+	}
+	if noGit.FilterPath(filepath.Join(dir, "pkg/a_test.go")) {
+		t.Errorf(".catterignore should apply with --disable-gitignore")
+	}
+}
+
+func TestMatchGlobsAndExplainPaths(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"pkg/a.go":       "package a\n",
+		"pkg/a_test.go":  "package a\n",
+		"pkg/sub/b.go":   "package b\n",
+		"cmd/main.go":    "package main\n",
+		"vendor/v/v.go":  "package v\n",
+		"README.md":      "# readme\n",
+		"pkg/sub/c.txt":  "text\n",
+		"docs/index.md":  "# docs\n",
+		"docs/guide.txt": "guide\n",
+	})
+	ff := NewFileFilter(WithRoot(dir), WithMatchGlobs([]string{"pkg/**/*.go", "!*_test.go", "docs/"}))
+
+	var got []string
+	err := ff.ExplainPaths([]string{dir}, func(d Decision) error {
+		rel, _ := filepath.Rel(dir, d.Path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir && !d.Included {
+			got = append(got, "-"+rel+"/")
+		} else if !d.IsDir && d.Included {
+			got = append(got, "+"+rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExplainPaths: %v", err)
+	}
+	want := "+docs/guide.txt,+docs/index.md,+pkg/a.go,+pkg/sub/b.go,-vendor/"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("ExplainPaths = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	d := ff.Explain(filepath.Join(dir, "cmd/main.go"))
+	if d.Included || d.Reason != "no match-filename, match-path or match-glob matched" {
+		t.Fatalf("unexpected decision %#v", d)
+	}
+	if d := ff.Explain(filepath.Join(dir, "pkg/a.go")); d.Reason != "matches match-glob: pkg/**/*.go" {
+		t.Fatalf("unexpected reason %q", d.Reason)
+	}
+	ff = NewFileFilter(WithRoot(dir), WithExcludeGlobs([]string{"/pkg", "!/pkg/sub/b.go", "*.md"}))
+	if d := ff.Explain(filepath.Join(dir, "pkg/a.go")); d.Included || d.Reason != "matches exclude-glob: /pkg" {
+		t.Fatalf("unexpected decision %#v", d)
+	}
+	for _, rel := range []string{"pkg/sub/b.go", "cmd/main.go"} {
+		if d := ff.Explain(filepath.Join(dir, rel)); !d.Included {
+			t.Fatalf("%s: unexpected decision %#v", rel, d)
+		}
+	}
+	if d := ff.Explain(filepath.Join(dir, "docs/index.md")); d.Included {
+		t.Fatalf("docs/index.md: unexpected decision %#v", d)
+	}
+}
+
+func TestYAMLKeepsUnknownKeys(t *testing.T) {
+	ff, err := FromYAML([]byte("match-globs: [/pkg]\ntransforms:\n  - type: strip-comments\nprofiles:\n  docs:\n    match-globs: [/docs]\n    transforms: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ff.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.Contains(out, "transforms:\n    - type: strip-comments") || !strings.Contains(out, "transforms: []") {
+		t.Fatalf("unknown keys were dropped:\n%s", out)
+	}
+	if len(ff.MatchGlobs) != 1 || len(ff.Profiles["docs"].MatchGlobs) != 1 {
+		t.Fatalf("known keys were not decoded: %#v", ff)
+	}
+}
@@ -0,0 +1,160 @@
+package filefilter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CatterIgnoreFile holds gitignore-style patterns that only catter applies.
+// It is read next to every .gitignore and wins over it.
+const CatterIgnoreFile = ".catterignore"
+
+// ignoreFiles lazily reads the ignore files of every directory on the way
+// from the repository root to a path and caches them.
+type ignoreFiles struct {
+	withGit bool
+
+	mu    sync.Mutex
+	dirs  map[string][]*Pattern
+	roots map[string]string
+}
+
+func newIgnoreFiles(withGit bool) *ignoreFiles {
+	return &ignoreFiles{
+		withGit: withGit,
+		dirs:    map[string][]*Pattern{},
+		roots:   map[string]string{},
+	}
+}
+
+// repoRoot returns the closest ancestor of dir containing .git, or "".
+func (f *ignoreFiles) repoRoot(dir string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.repoRootLocked(dir)
+}
+
+func (f *ignoreFiles) repoRootLocked(dir string) string {
+	if root, ok := f.roots[dir]; ok {
+		return root
+	}
+	root := ""
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = f.repoRootLocked(parent)
+	}
+	f.roots[dir] = root
+	return root
+}
+
+// match returns the last ignore pattern matching the absolute path abs,
+// looking at the ignore files from top down to abs's directory.
+func (f *ignoreFiles) match(top string, abs string, isDir bool) *Pattern {
+	var chain []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		chain = append(chain, dir)
+		if dir == top || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	var ret *Pattern
+	for i := len(chain) - 1; i >= 0; i-- {
+		dir := chain[i]
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			continue
+		}
+		if p := lastMatch(f.patterns(dir, dir == top), filepath.ToSlash(rel), isDir); p != nil {
+			ret = p
+		}
+	}
+	return ret
+}
+
+func (f *ignoreFiles) patterns(dir string, isTop bool) []*Pattern {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if patterns, ok := f.dirs[dir]; ok {
+		return patterns
+	}
+
+	var files []string
+	if f.withGit {
+		if isTop {
+			files = append(files, filepath.Join(".git", "info", "exclude"))
+		}
+		files = append(files, ".gitignore")
+	}
+	files = append(files, CatterIgnoreFile)
+
+	var patterns []*Pattern
+	for _, name := range files {
+		ps, err := readIgnoreFile(dir, name)
+		if err != nil {
+			log.Warn().Err(err).Str("dir", dir).Str("file", name).Msg("Could not read ignore file")
+			continue
+		}
+		patterns = append(patterns, ps...)
+	}
+	f.dirs[dir] = patterns
+	return patterns
+}
+
+func readIgnoreFile(dir string, name string) ([]*Pattern, error) {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	source := displayPath(filepath.Join(dir, name))
+	var patterns []*Pattern
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		p, err := ParsePattern(scanner.Text())
+		if err != nil {
+			log.Warn().Err(err).Str("file", source).Int("line", line).Msg("Skipping invalid ignore pattern")
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		p.Source = fmt.Sprintf("%s:%d", source, line)
+		p.Base = dir
+		patterns = append(patterns, p)
+	}
+	return patterns, scanner.Err()
+}
+
+// displayPath shortens abs to a path relative to the working directory when
+// it lies below it.
+func displayPath(abs string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return abs
+	}
+	if rel, ok := relativeTo(wd, abs); ok {
+		return rel
+	}
+	return abs
+}
+
+// relativeTo returns abs relative to base, if abs is base or lies below it.
+func relativeTo(base string, abs string) (string, bool) {
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
@@ -0,0 +1,189 @@
+package filefilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single gitignore-style pattern:
+//
+//	build          any file or directory named build, at any depth
+//	/build         build directly below the base directory
+//	docs/*.md      anchored, because it contains a slash
+//	**/testdata/   a directory named testdata, at any depth
+//	pkg/**         everything below pkg
+//	!keep.txt      re-includes a path an earlier pattern matched
+type Pattern struct {
+	// Pattern is the pattern as written.
+	Pattern string
+	// Source tells where the pattern came from, e.g. "pkg/.gitignore:3" or
+	// "--exclude-dirs".
+	Source string
+	// Base is the absolute directory the pattern is relative to. Empty means
+	// the filter's root.
+	Base string
+
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// ParsePattern parses one line of an ignore file. It returns nil for blank
+// lines and comments.
+func ParsePattern(line string) (*Pattern, error) {
+	s := strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	if s == "" || strings.HasPrefix(s, "#") {
+		return nil, nil
+	}
+
+	p := &Pattern{Pattern: s}
+	switch {
+	case strings.HasPrefix(s, "!"):
+		p.negate = true
+		s = s[1:]
+	case strings.HasPrefix(s, `\!`), strings.HasPrefix(s, `\#`):
+		s = s[1:]
+	}
+	if strings.HasSuffix(s, "/") {
+		p.dirOnly = true
+		s = strings.TrimRight(s, "/")
+	}
+	anchored := strings.Contains(s, "/")
+	s = strings.TrimPrefix(s, "/")
+	if s == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(globToRegexp(s, anchored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", line, err)
+	}
+	p.re = re
+	return p, nil
+}
+
+// parsePatterns parses patterns given on the command line or in YAML,
+// logging and skipping invalid ones.
+func parsePatterns(lines []string, source string) []*Pattern {
+	var ret []*Pattern
+	for _, line := range lines {
+		p, err := ParsePattern(line)
+		if err != nil {
+			log.Warn().Err(err).Str("source", source).Msg("Skipping invalid pattern")
+			continue
+		}
+		if p != nil {
+			p.Source = source
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// Negated reports whether the pattern starts with "!".
+func (p *Pattern) Negated() bool {
+	return p.negate
+}
+
+// Match reports whether the slash separated path rel, relative to the
+// pattern's base, matches.
+func (p *Pattern) Match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
+
+func (p *Pattern) String() string {
+	if p.Source == "" {
+		return p.Pattern
+	}
+	return fmt.Sprintf("%s: %s", p.Source, p.Pattern)
+}
+
+// lastMatch returns the last of patterns matching rel, following gitignore's
+// "last match wins" rule.
+func lastMatch(patterns []*Pattern, rel string, isDir bool) *Pattern {
+	var ret *Pattern
+	for _, p := range patterns {
+		if p.Match(rel, isDir) {
+			ret = p
+		}
+	}
+	return ret
+}
+
+func globToRegexp(glob string, anchored bool) string {
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	segments := strings.Split(glob, "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment == "**" {
+			if last {
+				b.WriteString(".*")
+			} else {
+				b.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+		writeSegment(&b, segment)
+		if !last {
+			b.WriteString("/")
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func writeSegment(b *strings.Builder, segment string) {
+	runes := []rune(segment)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		case '[':
+			end := -1
+			for j := i + 1; j < len(runes); j++ {
+				if runes[j] == ']' && j > i+1 {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := runes[i+1 : end]
+			b.WriteString("[")
+			if class[0] == '!' || class[0] == '^' {
+				b.WriteString("^")
+				class = class[1:]
+			}
+			for _, r := range class {
+				if r == '\\' || r == '[' {
+					b.WriteString(`\`)
+				}
+				b.WriteRune(r)
+			}
+			b.WriteString("]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+}
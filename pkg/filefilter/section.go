@@ -1,10 +1,6 @@
 package filefilter
 
 import (
-	"fmt"
-	"os"
-
-	"github.com/denormal/go-gitignore"
 	"github.com/go-go-golems/glazed/pkg/cmds/fields"
 	"github.com/go-go-golems/glazed/pkg/cmds/schema"
 	"github.com/go-go-golems/glazed/pkg/cmds/values"
@@ -18,6 +14,8 @@ type FileFilterSettings struct {
 	Exclude               []string `glazed:"exclude"`
 	MatchFilename         []string `glazed:"match-filename"`
 	MatchPath             []string `glazed:"match-path"`
+	MatchGlob             []string `glazed:"match-glob"`
+	ExcludeGlob           []string `glazed:"exclude-glob"`
 	ExcludeDirs           []string `glazed:"exclude-dirs"`
 	ExcludeMatchFilename  []string `glazed:"exclude-match-filename"`
 	ExcludeMatchPath      []string `glazed:"exclude-match-path"`
@@ -41,7 +39,7 @@ func NewFileFilterParameterLayer() (schema.Section, error) {
 			fields.New(
 				"disable-gitignore",
 				fields.TypeBool,
-				fields.WithHelp("Disable .gitignore filter"),
+				fields.WithHelp("Disable .gitignore files (.catterignore files still apply)"),
 				fields.WithDefault(false),
 			),
 			fields.New(
@@ -74,10 +72,20 @@ func NewFileFilterParameterLayer() (schema.Section, error) {
 				fields.WithHelp("List of regular expressions to match full paths"),
 				fields.WithShortFlag("p"),
 			),
+			fields.New(
+				"match-glob",
+				fields.TypeStringList,
+				fields.WithHelp("List of gitignore-style globs to match paths (e.g., pkg/**/*.go, !*_test.go)"),
+			),
+			fields.New(
+				"exclude-glob",
+				fields.TypeStringList,
+				fields.WithHelp("List of gitignore-style globs of paths to exclude (e.g., **/*_test.go, /pkg/legacy)"),
+			),
 			fields.New(
 				"exclude-dirs",
 				fields.TypeStringList,
-				fields.WithHelp("List of directories to exclude"),
+				fields.WithHelp("List of gitignore-style directory patterns to exclude (e.g., build, /dist, **/testdata, !tools/build)"),
 				fields.WithShortFlag("x"),
 			),
 			fields.New(
@@ -123,6 +131,8 @@ func CreateFileFilterFromSettings(parsedSection *values.SectionValues) (*FileFil
 	ff.ExcludeExts = s.Exclude
 	ff.MatchFilenames = compileRegexps(s.MatchFilename)
 	ff.MatchPaths = compileRegexps(s.MatchPath)
+	ff.MatchGlobs = s.MatchGlob
+	ff.ExcludeGlobs = s.ExcludeGlob
 	ff.ExcludeDirs = s.ExcludeDirs
 	ff.ExcludeMatchFilenames = compileRegexps(s.ExcludeMatchFilename)
 	ff.ExcludeMatchPaths = compileRegexps(s.ExcludeMatchPath)
@@ -131,29 +141,5 @@ func CreateFileFilterFromSettings(parsedSection *values.SectionValues) (*FileFil
 	ff.Verbose = s.Verbose
 	ff.FilterBinaryFiles = s.FilterBinary
 
-	if !ff.DisableGitIgnore {
-		gitIgnoreFilter, err := initGitIgnoreFilter()
-		if err != nil {
-			return nil, fmt.Errorf("error initializing gitignore filter: %w", err)
-		}
-		ff.GitIgnoreFilter = gitIgnoreFilter
-	}
-
 	return ff, nil
 }
-
-func initGitIgnoreFilter() (gitignore.GitIgnore, error) {
-	if _, err := os.Stat(".gitignore"); err == nil {
-		gitIgnoreFilter, err := gitignore.NewFromFile(".gitignore")
-		if err != nil {
-			return nil, fmt.Errorf("error initializing gitignore filter from file: %w", err)
-		}
-		return gitIgnoreFilter, nil
-	}
-
-	gitIgnoreFilter, err := gitignore.NewRepository(".")
-	if err != nil {
-		return nil, fmt.Errorf("error initializing gitignore filter: %w", err)
-	}
-	return gitIgnoreFilter, nil
-}
@@ -0,0 +1,229 @@
+package middlewaredefs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
+	tokencountfactory "github.com/go-go-golems/geppetto/pkg/inference/tokencount/factory"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/middlewares/contextbudget"
+)
+
+const (
+	// DependencyInferenceSettingsKey holds the *settings.InferenceSettings of
+	// the runtime being composed.
+	DependencyInferenceSettingsKey = "inference.settings"
+	// DependencyEngineFactoryKey holds the factory.EngineFactory used for
+	// auxiliary inference calls such as context summaries.
+	DependencyEngineFactoryKey = "inference.engine_factory"
+)
+
+const (
+	contextBudgetCountModeEstimate = "estimate"
+	contextBudgetCountModeAPI      = "api"
+	contextBudgetCountModeAuto     = "auto"
+)
+
+func NewContextBudgetMiddlewareDefinition() middlewarecfg.Definition {
+	defaults := contextbudget.DefaultConfig(0)
+	schema := map[string]any{
+		"title":       "Context Budget Middleware",
+		"description": "Keeps the turn under a token budget by dropping or summarizing the oldest history.",
+		"type":        "object",
+		"properties": map[string]any{
+			"max_tokens": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Token budget for the whole turn sent to the provider.",
+			},
+			"strategy": map[string]any{
+				"type":    "string",
+				"enum":    []any{contextbudget.StrategyDropOldest, contextbudget.StrategySummarize},
+				"default": defaults.Strategy,
+			},
+			"keep_system": map[string]any{
+				"type":    "boolean",
+				"default": defaults.KeepSystem,
+			},
+			"keep_recent_blocks": map[string]any{
+				"type":    "integer",
+				"minimum": 0,
+				"default": defaults.KeepRecentBlocks,
+			},
+			"summary_tokens": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"default":     defaults.SummaryTokens,
+				"description": "Room reserved for the summary block when strategy is summarize.",
+			},
+			"summary_prompt": map[string]any{
+				"type":        "string",
+				"description": "System prompt of the summarization call.",
+			},
+			"count_mode": map[string]any{
+				"type":    "string",
+				"enum":    []any{contextBudgetCountModeEstimate, contextBudgetCountModeAPI, contextBudgetCountModeAuto},
+				"default": contextBudgetCountModeEstimate,
+			},
+			"encoding": map[string]any{
+				"type":        "string",
+				"description": "Local tokenizer encoding for estimates, e.g. cl100k_base or o200k_base.",
+			},
+		},
+		"required":             []any{"max_tokens"},
+		"additionalProperties": false,
+	}
+
+	type configInput struct {
+		MaxTokens        int    `json:"max_tokens"`
+		Strategy         string `json:"strategy,omitempty"`
+		KeepSystem       *bool  `json:"keep_system,omitempty"`
+		KeepRecentBlocks *int   `json:"keep_recent_blocks,omitempty"`
+		SummaryTokens    int    `json:"summary_tokens,omitempty"`
+		SummaryPrompt    string `json:"summary_prompt,omitempty"`
+		CountMode        string `json:"count_mode,omitempty"`
+		Encoding         string `json:"encoding,omitempty"`
+	}
+
+	return middlewareDefinition{
+		name:        "context_budget",
+		version:     1,
+		displayName: "Context Budget",
+		description: "Keeps the turn under a token budget by dropping or summarizing the oldest history.",
+		schema:      schema,
+		build: func(_ context.Context, deps middlewarecfg.BuildDeps, cfg any) (gepmiddleware.Middleware, error) {
+			input := configInput{}
+			if err := decodeResolvedMiddlewareConfig(cfg, &input); err != nil {
+				return nil, err
+			}
+
+			config := contextbudget.DefaultConfig(input.MaxTokens)
+			if strategy := strings.TrimSpace(input.Strategy); strategy != "" {
+				config.Strategy = strategy
+			}
+			if input.KeepSystem != nil {
+				config.KeepSystem = *input.KeepSystem
+			}
+			if input.KeepRecentBlocks != nil {
+				config.KeepRecentBlocks = *input.KeepRecentBlocks
+			}
+			if input.SummaryTokens > 0 {
+				config.SummaryTokens = input.SummaryTokens
+			}
+			if prompt := strings.TrimSpace(input.SummaryPrompt); prompt != "" {
+				config.SummaryPrompt = prompt
+			}
+			if err := config.Validate(); err != nil {
+				return nil, err
+			}
+
+			counter, err := newContextBudgetCounter(deps, input.CountMode, input.Encoding)
+			if err != nil {
+				return nil, err
+			}
+			var summarizer contextbudget.Summarizer
+			if config.Strategy == contextbudget.StrategySummarize {
+				summarizer, err = newContextBudgetSummarizer(deps, config.SummaryPrompt)
+				if err != nil {
+					return nil, err
+				}
+			}
+			compactor, err := contextbudget.NewCompactor(config, counter, summarizer)
+			if err != nil {
+				return nil, err
+			}
+			return contextbudget.NewMiddleware(compactor), nil
+		},
+	}
+}
+
+// newContextBudgetCounter mirrors `pinocchio tokens count`: estimate counts
+// locally, api asks the provider, and auto falls back to the local estimate
+// when the provider count is unavailable.
+func newContextBudgetCounter(deps middlewarecfg.BuildDeps, mode string, encoding string) (contextbudget.Counter, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = contextBudgetCountModeEstimate
+	}
+	switch mode {
+	case contextBudgetCountModeEstimate, contextBudgetCountModeAPI, contextBudgetCountModeAuto:
+	default:
+		return nil, fmt.Errorf("context budget count_mode must be %s, %s or %s, not %q",
+			contextBudgetCountModeEstimate, contextBudgetCountModeAPI, contextBudgetCountModeAuto, mode)
+	}
+
+	estimate, err := contextbudget.NewEstimateCounter(encoding)
+	if err != nil {
+		return nil, err
+	}
+	if mode == contextBudgetCountModeEstimate {
+		return estimate, nil
+	}
+
+	unavailable := func(err error) (contextbudget.Counter, error) {
+		if mode == contextBudgetCountModeAuto {
+			log.Warn().Err(err).Msg("context budget: provider token counting unavailable; using local estimate")
+			return estimate, nil
+		}
+		return nil, fmt.Errorf("context budget count_mode %s: %w", mode, err)
+	}
+	inferenceSettings, err := inferenceSettingsDependency(deps)
+	if err != nil {
+		return unavailable(err)
+	}
+	counter, err := tokencountfactory.NewFromSettings(inferenceSettings)
+	if err != nil {
+		return unavailable(err)
+	}
+	return contextbudget.CounterFunc(func(ctx context.Context, t *turns.Turn) (int, error) {
+		result, err := counter.CountTurn(ctx, t)
+		if err == nil && result != nil {
+			return int(result.InputTokens), nil
+		}
+		if err == nil {
+			err = fmt.Errorf("provider returned no token count")
+		}
+		if mode == contextBudgetCountModeAuto {
+			log.Debug().Err(err).Msg("context budget: provider token count failed; using local estimate")
+			return estimate.CountTurn(ctx, t)
+		}
+		return 0, err
+	}), nil
+}
+
+func newContextBudgetSummarizer(deps middlewarecfg.BuildDeps, prompt string) (contextbudget.Summarizer, error) {
+	inferenceSettings, err := inferenceSettingsDependency(deps)
+	if err != nil {
+		return nil, fmt.Errorf("context budget strategy summarize: %w", err)
+	}
+	raw, ok := deps.Get(DependencyEngineFactoryKey)
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("context budget strategy summarize: missing dependency %q", DependencyEngineFactoryKey)
+	}
+	engineFactory, ok := raw.(factory.EngineFactory)
+	if !ok {
+		return nil, fmt.Errorf("dependency %q has unexpected type %T", DependencyEngineFactoryKey, raw)
+	}
+	eng, err := engineFactory.CreateEngine(inferenceSettings)
+	if err != nil {
+		return nil, fmt.Errorf("context budget summary engine: %w", err)
+	}
+	return contextbudget.NewEngineSummarizer(eng, prompt), nil
+}
+
+func inferenceSettingsDependency(deps middlewarecfg.BuildDeps) (*settings.InferenceSettings, error) {
+	raw, ok := deps.Get(DependencyInferenceSettingsKey)
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("missing dependency %q", DependencyInferenceSettingsKey)
+	}
+	inferenceSettings, ok := raw.(*settings.InferenceSettings)
+	if !ok {
+		return nil, fmt.Errorf("dependency %q has unexpected type %T", DependencyInferenceSettingsKey, raw)
+	}
+	return inferenceSettings, nil
+}
@@ -4,4 +4,4 @@ package middlewaredefs
 
 import logcopter "github.com/go-go-golems/logcopter/pkg/logcopter"
 
-var log = logcopter.Package("go-go-golems.pinocchio.cmd.web-chat.internal.middlewaredefs")
+var log = logcopter.Package("go-go-golems.pinocchio.pkg.inference.middlewaredefs")
@@ -63,6 +63,7 @@ func NewRegistry() (*middlewarecfg.InMemoryDefinitionRegistry, error) {
 	registry := middlewarecfg.NewInMemoryDefinitionRegistry()
 	definitions := []middlewarecfg.Definition{
 		NewAgentModeMiddlewareDefinition(),
+		NewContextBudgetMiddlewareDefinition(),
 	}
 	for _, def := range definitions {
 		if err := registry.RegisterDefinition(def); err != nil {
@@ -72,6 +73,16 @@ func NewRegistry() (*middlewarecfg.InMemoryDefinitionRegistry, error) {
 	return registry, nil
 }
 
+// NewDefaultAgentModeService returns the agent modes web-chat and the
+// pinocchio CLI offer to profiles that use the agentmode middleware.
+func NewDefaultAgentModeService() *agentmode.StaticService {
+	return agentmode.NewStaticService([]*agentmode.AgentMode{
+		{Name: "financial_analyst", Prompt: "You are a financial transaction analyst. Analyze transactions and propose categories."},
+		{Name: "category_regexp_designer", Prompt: "Design regex patterns to categorize transactions. Verify with SQL counts before proposing changes."},
+		{Name: "category_regexp_reviewer", Prompt: "Review proposed regex patterns and assess over/under matching risks."},
+	})
+}
+
 func NewAgentModeMiddlewareDefinition() middlewarecfg.Definition {
 	schema := map[string]any{
 		"title":       "Agent Mode Middleware",
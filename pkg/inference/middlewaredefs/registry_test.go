@@ -73,3 +73,53 @@ func TestAgentModeMiddlewareDefinition_EmptyConfiguredDefaultModeFallsBackToWebC
 	require.True(t, ok)
 	require.Equal(t, DefaultWebChatAgentMode, modeName)
 }
+
+func TestContextBudgetMiddlewareDefinition_DropsOldestHistory(t *testing.T) {
+	def := NewContextBudgetMiddlewareDefinition()
+	mw, err := def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{
+		"max_tokens":         40,
+		"keep_recent_blocks": 1,
+	})
+	require.NoError(t, err)
+
+	var seen *turns.Turn
+	handler := mw(func(ctx context.Context, turn *turns.Turn) (*turns.Turn, error) {
+		seen = turn
+		return turn, nil
+	})
+
+	turn := &turns.Turn{ID: "turn-1", Blocks: []turns.Block{turns.NewSystemTextBlock("be brief")}}
+	for i := 0; i < 20; i++ {
+		turn.Blocks = append(turn.Blocks, turns.NewUserTextBlock("an old question that is no longer relevant"))
+	}
+	turn.Blocks = append(turn.Blocks, turns.NewUserTextBlock("latest"))
+
+	_, err = handler(context.Background(), turn)
+	require.NoError(t, err)
+	require.NotNil(t, seen)
+	require.Less(t, len(seen.Blocks), 22)
+	require.Equal(t, turns.BlockKindSystem, seen.Blocks[0].Kind)
+	require.Equal(t, "latest", seen.Blocks[len(seen.Blocks)-1].Payload[turns.PayloadKeyText])
+}
+
+func TestContextBudgetMiddlewareDefinition_RejectsInvalidConfig(t *testing.T) {
+	def := NewContextBudgetMiddlewareDefinition()
+
+	_, err := def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{"max_tokens": 0})
+	require.Error(t, err)
+
+	_, err = def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{"max_tokens": 1000, "count_mode": "exact"})
+	require.Error(t, err)
+
+	_, err = def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{"max_tokens": 1000, "count_mode": "api"})
+	require.ErrorContains(t, err, DependencyInferenceSettingsKey)
+
+	_, err = def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{"max_tokens": 1000, "strategy": "summarize"})
+	require.ErrorContains(t, err, DependencyInferenceSettingsKey)
+}
+
+func TestContextBudgetMiddlewareDefinition_AutoCountFallsBackToEstimate(t *testing.T) {
+	def := NewContextBudgetMiddlewareDefinition()
+	_, err := def.Build(context.Background(), middlewarecfg.BuildDeps{}, map[string]any{"max_tokens": 1000, "count_mode": "auto"})
+	require.NoError(t, err)
+}
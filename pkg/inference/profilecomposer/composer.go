@@ -1,18 +1,23 @@
-package runtime
+package profilecomposer
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
 	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
 	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
 	"github.com/go-go-golems/geppetto/pkg/inference/toolloop/enginebuilder"
+	geptools "github.com/go-go-golems/geppetto/pkg/inference/tools"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/pinocchio/pkg/inference/middlewaredefs"
 	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/js/jstools"
 	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 )
 
 type ProfileRuntimeComposer struct {
@@ -21,6 +26,7 @@ type ProfileRuntimeComposer struct {
 	base          *settings.InferenceSettings
 	turnStore     chatstore.TurnStore
 	engineFactory factory.EngineFactory
+	jsTools       *jstools.Cache
 }
 
 func NewProfileRuntimeComposer(
@@ -51,6 +57,17 @@ func (c *ProfileRuntimeComposer) WithEngineFactory(engineFactory factory.EngineF
 	return c
 }
 
+// WithJSTools enables the pinocchio.js_tools@v1 profile extension. Modules
+// are loaded through cache, which confines them to its root directory and
+// refuses allowed_modules the host did not allow.
+func (c *ProfileRuntimeComposer) WithJSTools(cache *jstools.Cache) *ProfileRuntimeComposer {
+	if c == nil {
+		return c
+	}
+	c.jsTools = cache
+	return c
+}
+
 func (c *ProfileRuntimeComposer) Compose(ctx context.Context, req infruntime.ConversationRuntimeRequest) (infruntime.ComposedRuntime, error) {
 	if c == nil {
 		return infruntime.ComposedRuntime{}, fmt.Errorf("runtime composer is not configured")
@@ -69,27 +86,16 @@ func (c *ProfileRuntimeComposer) Compose(ctx context.Context, req infruntime.Con
 		systemPrompt = strings.TrimSpace(req.ResolvedProfileRuntime.SystemPrompt)
 	}
 
-	profileMiddlewares, err := runtimeMiddlewaresFromProfile(req.ResolvedProfileRuntime)
-	if err != nil {
-		return infruntime.ComposedRuntime{}, err
-	}
 	tools := runtimeToolsFromProfile(req.ResolvedProfileRuntime)
 
 	if strings.TrimSpace(systemPrompt) == "" {
 		systemPrompt = "You are an assistant"
 	}
 
-	middlewareInputs, err := runtimeMiddlewareInputsFromProfile(profileMiddlewares)
-	if err != nil {
-		return infruntime.ComposedRuntime{}, err
-	}
-
-	resolvedMiddlewares, resolvedUses, err := c.resolveMiddlewares(ctx, middlewareInputs)
-	if err != nil {
-		return infruntime.ComposedRuntime{}, err
-	}
-
-	var effectiveInferenceSettings *settings.InferenceSettings
+	var (
+		effectiveInferenceSettings *settings.InferenceSettings
+		err                        error
+	)
 	if req.ResolvedInferenceSettings != nil {
 		effectiveInferenceSettings = req.ResolvedInferenceSettings.Clone()
 	} else if c.base != nil {
@@ -101,10 +107,17 @@ func (c *ProfileRuntimeComposer) Compose(ctx context.Context, req infruntime.Con
 		}
 	}
 
-	engineFactory := c.engineFactory
-	if engineFactory == nil {
-		engineFactory = factory.NewStandardEngineFactory()
+	jsRuntime, err := c.loadJSTools(ctx, req.ResolvedJSTools)
+	if err != nil {
+		return infruntime.ComposedRuntime{}, err
+	}
+
+	engineFactory := secrets.NewResolvingEngineFactory(c.engineFactory, nil)
+	resolvedMiddlewares, resolvedUses, err := c.profileMiddlewares(ctx, req.ResolvedProfileRuntime, effectiveInferenceSettings, engineFactory, jsRuntime)
+	if err != nil {
+		return infruntime.ComposedRuntime{}, err
 	}
+
 	baseEngine, err := engineFactory.CreateEngine(effectiveInferenceSettings)
 	if err != nil {
 		return infruntime.ComposedRuntime{}, fmt.Errorf("engine init failed: %w", err)
@@ -134,14 +147,64 @@ func (c *ProfileRuntimeComposer) Compose(ctx context.Context, req infruntime.Con
 		}, effectiveInferenceSettings)
 	}
 
+	var registry geptools.ToolRegistry
+	if jsRuntime != nil && len(jsRuntime.ToolNames()) > 0 {
+		toolRegistry := geptools.NewInMemoryToolRegistry()
+		if err := jsRuntime.RegisterTools(toolRegistry, tools...); err != nil {
+			return infruntime.ComposedRuntime{}, err
+		}
+		registry = toolRegistry
+	}
+
 	return infruntime.ComposedRuntime{
 		Engine:             eng,
+		Registry:           registry,
 		WrapSink:           runtimeSinkWrapperFromProfile(req.ResolvedProfileRuntime),
 		RuntimeKey:         runtimeKey,
 		RuntimeFingerprint: runtimeFingerprint,
+		Profile:            strings.TrimSpace(req.ProfileKey),
+		UsagePricing:       req.ResolvedUsagePricing,
 	}, nil
 }
 
+// ProfileMiddlewares builds the middlewares declared by a profile runtime for
+// an engine running with inferenceSettings, the way Compose builds them. The
+// tool result reordering and system prompt Compose adds around them are left
+// to the caller, so the pinocchio CLI can wrap its own engines with the chain.
+func (c *ProfileRuntimeComposer) ProfileMiddlewares(
+	ctx context.Context,
+	runtime *infruntime.ProfileRuntime,
+	jsTools *infruntime.JSTools,
+	inferenceSettings *settings.InferenceSettings,
+) ([]gepmiddleware.Middleware, []infruntime.MiddlewareUse, error) {
+	if c == nil {
+		return nil, nil, fmt.Errorf("runtime composer is not configured")
+	}
+	jsRuntime, err := c.loadJSTools(ctx, jsTools)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.profileMiddlewares(ctx, runtime, inferenceSettings, secrets.NewResolvingEngineFactory(c.engineFactory, nil), jsRuntime)
+}
+
+func (c *ProfileRuntimeComposer) profileMiddlewares(
+	ctx context.Context,
+	runtime *infruntime.ProfileRuntime,
+	inferenceSettings *settings.InferenceSettings,
+	engineFactory factory.EngineFactory,
+	jsRuntime *jstools.Runtime,
+) ([]gepmiddleware.Middleware, []infruntime.MiddlewareUse, error) {
+	profileMiddlewares, err := runtimeMiddlewaresFromProfile(runtime)
+	if err != nil {
+		return nil, nil, err
+	}
+	middlewareInputs, err := runtimeMiddlewareInputsFromProfile(profileMiddlewares)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.resolveMiddlewares(ctx, middlewareInputs, c.composeBuildDeps(inferenceSettings, engineFactory), jsRuntime)
+}
+
 type middlewareResolveInput struct {
 	Use           infruntime.MiddlewareUse
 	ProfileConfig map[string]any
@@ -150,11 +213,13 @@ type middlewareResolveInput struct {
 func (c *ProfileRuntimeComposer) resolveMiddlewares(
 	ctx context.Context,
 	inputs []middlewareResolveInput,
+	buildDeps middlewarecfg.BuildDeps,
+	jsRuntime *jstools.Runtime,
 ) ([]gepmiddleware.Middleware, []infruntime.MiddlewareUse, error) {
 	if len(inputs) == 0 {
 		return nil, nil, nil
 	}
-	if c == nil || c.definitions == nil {
+	if c == nil || (c.definitions == nil && jsRuntime == nil) {
 		return nil, nil, fmt.Errorf("middleware definitions are not configured")
 	}
 
@@ -167,7 +232,7 @@ func (c *ProfileRuntimeComposer) resolveMiddlewares(
 			Enabled: cloneBoolPtr(input.Use.Enabled),
 		}
 		instanceKey := middlewarecfg.MiddlewareInstanceKey(use, i)
-		def, ok := c.definitions.GetDefinition(input.Use.Name)
+		def, ok := c.lookupDefinition(input.Use.Name, jsRuntime)
 		if !ok {
 			return nil, nil, fmt.Errorf("resolve middleware %s: unknown middleware %q", instanceKey, input.Use.Name)
 		}
@@ -211,13 +276,65 @@ func (c *ProfileRuntimeComposer) resolveMiddlewares(
 		resolvedUses = append(resolvedUses, useForFingerprint)
 	}
 
-	chain, err := middlewarecfg.BuildChain(ctx, c.buildDeps, resolved)
+	chain, err := middlewarecfg.BuildChain(ctx, buildDeps, resolved)
 	if err != nil {
 		return nil, nil, err
 	}
 	return chain, resolvedUses, nil
 }
 
+// lookupDefinition prefers middlewares registered by the profile's JS tool
+// modules over the built-in definitions.
+func (c *ProfileRuntimeComposer) lookupDefinition(name string, jsRuntime *jstools.Runtime) (middlewarecfg.Definition, bool) {
+	if jsRuntime != nil {
+		if def, ok := jsRuntime.MiddlewareDefinition(name); ok {
+			return def, true
+		}
+	}
+	if c.definitions == nil {
+		return nil, false
+	}
+	return c.definitions.GetDefinition(name)
+}
+
+// loadJSTools returns the runtime of the profile's JS tool modules, nil when
+// the profile sets none.
+func (c *ProfileRuntimeComposer) loadJSTools(ctx context.Context, spec *infruntime.JSTools) (*jstools.Runtime, error) {
+	if spec == nil || len(spec.Scripts) == 0 {
+		return nil, nil
+	}
+	if c.jsTools == nil {
+		return nil, fmt.Errorf("profile sets pinocchio.js_tools@v1 but js tools are not enabled")
+	}
+	rt, err := c.jsTools.Get(ctx, jstools.Options{
+		Scripts:        spec.Scripts,
+		Timeout:        time.Duration(spec.TimeoutMs) * time.Millisecond,
+		AllowedModules: spec.AllowedModules,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load js tools: %w", err)
+	}
+	return rt, nil
+}
+
+// composeBuildDeps adds the settings and engine factory of the runtime being
+// composed to the shared build dependencies, for middlewares that count
+// tokens or make their own inference calls.
+func (c *ProfileRuntimeComposer) composeBuildDeps(
+	inferenceSettings *settings.InferenceSettings,
+	engineFactory factory.EngineFactory,
+) middlewarecfg.BuildDeps {
+	values := copyStringAnyMap(c.buildDeps.Values)
+	if values == nil {
+		values = map[string]any{}
+	}
+	values[middlewaredefs.DependencyInferenceSettingsKey] = inferenceSettings
+	values[middlewaredefs.DependencyEngineFactoryKey] = engineFactory
+	deps := c.buildDeps
+	deps.Values = values
+	return deps
+}
+
 type fixedPayloadSource struct {
 	name    string
 	layer   middlewarecfg.SourceLayer
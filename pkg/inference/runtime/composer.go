@@ -7,6 +7,7 @@ import (
 	"github.com/go-go-golems/geppetto/pkg/inference/engine"
 	geptools "github.com/go-go-golems/geppetto/pkg/inference/tools"
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 )
 
 // ConversationRuntimeRequest contains app-owned runtime policy inputs.
@@ -17,6 +18,8 @@ type ConversationRuntimeRequest struct {
 	ResolvedInferenceSettings  *aisettings.InferenceSettings
 	ResolvedProfileRuntime     *ProfileRuntime
 	ResolvedProfileFingerprint string
+	ResolvedUsagePricing       *usagestore.PriceTable
+	ResolvedJSTools            *JSTools
 }
 
 // EventSinkWrapper decorates a base event sink with runtime-owned behavior.
@@ -39,6 +42,10 @@ type ComposedRuntime struct {
 	WrapSink           EventSinkWrapper
 	RuntimeFingerprint string
 	RuntimeKey         string
+	// Profile and UsagePricing attribute and cost provider calls in the usage
+	// ledger. Both are optional.
+	Profile      string
+	UsagePricing *usagestore.PriceTable
 }
 
 // RuntimeBuilder composes an engine/sink runtime for a conversation request.
@@ -0,0 +1,71 @@
+package runtime
+
+import (
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+)
+
+// JSTools lists JavaScript modules that define tools and middlewares for a
+// profile's runtime through require("pinocchio/tools"). Relative script paths
+// resolve against the host's JS tools root.
+type JSTools struct {
+	Scripts []string `json:"scripts,omitempty" yaml:"scripts,omitempty"`
+	// TimeoutMs bounds loading each script and every handler call.
+	TimeoutMs int `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+	// AllowedModules opts into go-go-goja modules beyond the data-only set,
+	// for example "fs".
+	AllowedModules []string `json:"allowed_modules,omitempty" yaml:"allowed_modules,omitempty"`
+}
+
+// JSToolsProfileExtension carries a profile's JavaScript tool modules.
+// Stacked profiles append scripts and allowed modules; a later timeout
+// overrides an earlier one.
+var JSToolsProfileExtension = gepprofiles.MustProfileExtensionKey[JSTools]("pinocchio", "js_tools", 1)
+
+func JSToolsFromEngineProfile(profile *gepprofiles.EngineProfile) (*JSTools, bool, error) {
+	jsTools, ok, err := JSToolsProfileExtension.Get(profile)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return (&jsTools).Merge(nil), true, nil
+}
+
+// Merge returns a copy of j with overlay applied. Either side may be nil.
+func (j *JSTools) Merge(overlay *JSTools) *JSTools {
+	if j == nil && overlay == nil {
+		return nil
+	}
+	out := &JSTools{}
+	for _, src := range []*JSTools{j, overlay} {
+		if src == nil {
+			continue
+		}
+		out.Scripts = appendUniqueTrimmed(out.Scripts, src.Scripts)
+		out.AllowedModules = appendUniqueTrimmed(out.AllowedModules, src.AllowedModules)
+		if src.TimeoutMs > 0 {
+			out.TimeoutMs = src.TimeoutMs
+		}
+	}
+	return out
+}
+
+func appendUniqueTrimmed(dst []string, src []string) []string {
+	for _, s := range src {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		seen := false
+		for _, existing := range dst {
+			if existing == s {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
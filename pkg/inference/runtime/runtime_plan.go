@@ -8,6 +8,7 @@ import (
 
 	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
 )
 
 type ToolMergeMode string
@@ -39,6 +40,12 @@ type ResolvedRuntimePlan struct {
 	InferenceSettings *aisettings.InferenceSettings
 	Runtime           *ProfileRuntime
 	ProfileMetadata   map[string]any
+	// Pricing is the merged usage price table of the profile stack, nil when no
+	// profile in the stack configures pricing.
+	Pricing *usagestore.PriceTable
+	// JSTools is the merged JavaScript tool module list of the profile stack,
+	// nil when no profile in the stack sets one.
+	JSTools *JSTools
 }
 
 type RuntimeFingerprintInput struct {
@@ -102,6 +109,20 @@ func ResolveRuntimePlan(
 			return nil, err
 		}
 		plan.Runtime = MergeProfileRuntime(plan.Runtime, profileRuntime, mergeOpts)
+		pricing, ok, err := UsagePricingFromEngineProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			plan.Pricing = plan.Pricing.Merge(pricing)
+		}
+		jsTools, ok, err := JSToolsFromEngineProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			plan.JSTools = plan.JSTools.Merge(jsTools)
+		}
 	}
 
 	return plan, nil
@@ -95,6 +95,20 @@ func TestResolveRuntimePlan_MergesBaseRuntimeAndResolvedStackRuntimes(t *testing
 	require.Equal(t, "gpt-5-mini", *plan.InferenceSettings.Chat.Engine)
 }
 
+func TestJSToolsMerge_AppendsScriptsAndOverridesTimeout(t *testing.T) {
+	base := &JSTools{Scripts: []string{"tools/common.js"}, TimeoutMs: 5000}
+	overlay := &JSTools{Scripts: []string{" tools/finance.js ", "tools/common.js"}, AllowedModules: []string{"fs"}}
+
+	merged := base.Merge(overlay)
+	require.Equal(t, []string{"tools/common.js", "tools/finance.js"}, merged.Scripts)
+	require.Equal(t, []string{"fs"}, merged.AllowedModules)
+	require.Equal(t, 5000, merged.TimeoutMs)
+
+	merged = merged.Merge(&JSTools{TimeoutMs: 250})
+	require.Equal(t, 250, merged.TimeoutMs)
+	require.Nil(t, (*JSTools)(nil).Merge(nil))
+}
+
 func TestBuildRuntimeFingerprintFromSettings_UsesTypedPayload(t *testing.T) {
 	fp := BuildRuntimeFingerprintFromSettings("default", 7, &ProfileRuntime{
 		SystemPrompt: "hi",
@@ -0,0 +1,19 @@
+package runtime
+
+import (
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+// UsagePricingProfileExtension carries per-model prices used to cost provider
+// calls in the usage ledger. Stacked profiles merge their tables; later
+// profiles override earlier prices for the same model key.
+var UsagePricingProfileExtension = gepprofiles.MustProfileExtensionKey[usagestore.PriceTable]("pinocchio", "usage_pricing", 1)
+
+func UsagePricingFromEngineProfile(profile *gepprofiles.EngineProfile) (*usagestore.PriceTable, bool, error) {
+	table, ok, err := UsagePricingProfileExtension.Get(profile)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return (&table).Merge(nil), true, nil
+}
@@ -0,0 +1,147 @@
+// Package tokenbudget explains how much of a model's context a turn uses
+// before it is sent: per-block token estimates, the remaining headroom and
+// an estimated cost. Nothing in this package calls a provider.
+package tokenbudget
+
+import (
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/middlewares/contextbudget"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+const previewLength = 60
+
+// Options describes the model a turn would be sent to.
+type Options struct {
+	Model string
+	// Encoding overrides the tiktoken encoding derived from Model.
+	Encoding string
+	// ContextWindow is the model's context limit in tokens; 0 when unknown.
+	ContextWindow int
+	// MaxResponseTokens is reserved for the response when computing headroom
+	// and the maximum cost; 0 when unset.
+	MaxResponseTokens int
+	Pricing           *usagestore.PriceTable
+	// Middlewares names the middlewares applied to the turn, for the report.
+	Middlewares []string
+}
+
+// BlockTokens is the estimate for one block of the turn.
+type BlockTokens struct {
+	Index   int    `json:"index" yaml:"index"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Role    string `json:"role,omitempty" yaml:"role,omitempty"`
+	Tokens  int    `json:"tokens" yaml:"tokens"`
+	Images  int    `json:"images,omitempty" yaml:"images,omitempty"`
+	Preview string `json:"preview,omitempty" yaml:"preview,omitempty"`
+}
+
+// Report is the token budget of one turn.
+type Report struct {
+	Model             string        `json:"model,omitempty" yaml:"model,omitempty"`
+	Encoding          string        `json:"encoding" yaml:"encoding"`
+	Middlewares       []string      `json:"middlewares,omitempty" yaml:"middlewares,omitempty"`
+	Blocks            []BlockTokens `json:"blocks" yaml:"blocks"`
+	InputTokens       int           `json:"input_tokens" yaml:"input_tokens"`
+	Images            int           `json:"images,omitempty" yaml:"images,omitempty"`
+	ContextWindow     int           `json:"context_window,omitempty" yaml:"context_window,omitempty"`
+	MaxResponseTokens int           `json:"max_response_tokens,omitempty" yaml:"max_response_tokens,omitempty"`
+	// Headroom is ContextWindow minus the input and the reserved response
+	// tokens. It is negative when the turn would overflow and nil when the
+	// context window is unknown.
+	Headroom *int `json:"headroom,omitempty" yaml:"headroom,omitempty"`
+	// InputCostUSD prices the input only; MaxCostUSD adds a response of
+	// MaxResponseTokens. Both are nil when the model has no price.
+	InputCostUSD *float64 `json:"input_cost_usd,omitempty" yaml:"input_cost_usd,omitempty"`
+	MaxCostUSD   *float64 `json:"max_cost_usd,omitempty" yaml:"max_cost_usd,omitempty"`
+}
+
+// Explain estimates the tokens of every block of t.
+func Explain(t *turns.Turn, opts Options) (*Report, error) {
+	encoding := strings.TrimSpace(opts.Encoding)
+	if encoding == "" {
+		encoding = EncodingForModel(opts.Model)
+	}
+	counter, err := contextbudget.NewEstimateCounter(encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{
+		Model:             strings.TrimSpace(opts.Model),
+		Encoding:          encoding,
+		Middlewares:       opts.Middlewares,
+		Blocks:            []BlockTokens{},
+		ContextWindow:     max(opts.ContextWindow, 0),
+		MaxResponseTokens: max(opts.MaxResponseTokens, 0),
+	}
+	if t != nil {
+		for i, block := range t.Blocks {
+			n, err := counter.CountBlock(block)
+			if err != nil {
+				return nil, err
+			}
+			images := countImages(block)
+			r.Blocks = append(r.Blocks, BlockTokens{
+				Index:   i,
+				Kind:    block.Kind.String(),
+				Role:    block.Role,
+				Tokens:  n,
+				Images:  images,
+				Preview: preview(block),
+			})
+			r.InputTokens += n
+			r.Images += images
+		}
+	}
+
+	if r.ContextWindow > 0 {
+		headroom := r.ContextWindow - r.InputTokens - r.MaxResponseTokens
+		r.Headroom = &headroom
+	}
+	if cost, ok := opts.Pricing.Cost(usagestore.Entry{Model: r.Model, InputTokens: int64(r.InputTokens)}); ok {
+		r.InputCostUSD = &cost
+		maxCost, _ := opts.Pricing.Cost(usagestore.Entry{Model: r.Model, InputTokens: int64(r.InputTokens), OutputTokens: int64(r.MaxResponseTokens)})
+		r.MaxCostUSD = &maxCost
+	}
+	return r, nil
+}
+
+// Overflows reports whether the input and the reserved response exceed the
+// context window.
+func (r *Report) Overflows() bool {
+	return r != nil && r.Headroom != nil && *r.Headroom < 0
+}
+
+// EncodingForModel returns the tiktoken encoding of model, falling back to
+// cl100k_base for models tiktoken does not know, such as Claude or Gemini.
+func EncodingForModel(model string) string {
+	codec, err := tokenizer.ForModel(tokenizer.Model(strings.TrimSpace(model)))
+	if err != nil {
+		return string(tokenizer.Cl100kBase)
+	}
+	return codec.GetName()
+}
+
+func countImages(block turns.Block) int {
+	switch images := block.Payload[turns.PayloadKeyImages].(type) {
+	case []map[string]any:
+		return len(images)
+	case []any:
+		return len(images)
+	default:
+		return 0
+	}
+}
+
+func preview(block turns.Block) string {
+	text, _ := block.Payload[turns.PayloadKeyText].(string)
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > previewLength {
+		return string(r[:previewLength-1]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,95 @@
+package tokenbudget
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+)
+
+func testTurn() *turns.Turn {
+	return &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock("You are a terse assistant."),
+		turns.NewUserTextBlock(strings.Repeat("token ", 200)),
+	}}
+}
+
+func TestExplainCountsBlocksAndHeadroom(t *testing.T) {
+	r, err := Explain(testTurn(), Options{Model: "gpt-4o", ContextWindow: 1000, MaxResponseTokens: 100})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if r.Encoding != "o200k_base" {
+		t.Fatalf("encoding = %q", r.Encoding)
+	}
+	if len(r.Blocks) != 2 || r.Blocks[0].Kind != "system" || r.Blocks[1].Role != turns.RoleUser {
+		t.Fatalf("unexpected blocks %#v", r.Blocks)
+	}
+	sum := 0
+	for _, b := range r.Blocks {
+		if b.Tokens <= 0 {
+			t.Fatalf("block %d has no tokens", b.Index)
+		}
+		sum += b.Tokens
+	}
+	if r.InputTokens != sum || r.InputTokens < 200 {
+		t.Fatalf("input tokens = %d, blocks sum to %d", r.InputTokens, sum)
+	}
+	if r.Headroom == nil || *r.Headroom != 1000-r.InputTokens-100 {
+		t.Fatalf("headroom = %v", r.Headroom)
+	}
+	if r.Overflows() {
+		t.Fatalf("did not expect overflow")
+	}
+	if !strings.HasSuffix(r.Blocks[1].Preview, "…") {
+		t.Fatalf("long preview not truncated: %q", r.Blocks[1].Preview)
+	}
+}
+
+func TestExplainReportsOverflowAndCost(t *testing.T) {
+	pricing := &usagestore.PriceTable{Models: map[string]usagestore.ModelPrice{
+		"claude-*": {InputPerMTok: 3, OutputPerMTok: 15},
+	}}
+	r, err := Explain(testTurn(), Options{Model: "claude-sonnet-4", ContextWindow: 100, MaxResponseTokens: 1000, Pricing: pricing})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if r.Encoding != "cl100k_base" {
+		t.Fatalf("unknown models should fall back to cl100k_base, got %q", r.Encoding)
+	}
+	if !r.Overflows() {
+		t.Fatalf("expected overflow, headroom %v", *r.Headroom)
+	}
+	if r.InputCostUSD == nil || r.MaxCostUSD == nil {
+		t.Fatalf("expected costs")
+	}
+	if want := float64(r.InputTokens) * 3 / 1e6; *r.InputCostUSD != want {
+		t.Fatalf("input cost = %v, want %v", *r.InputCostUSD, want)
+	}
+	if want := *r.InputCostUSD + 1000*15/1e6; *r.MaxCostUSD-want > 1e-12 || want-*r.MaxCostUSD > 1e-12 {
+		t.Fatalf("max cost = %v, want %v", *r.MaxCostUSD, want)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Context window:   100", "WARNING: the turn exceeds the context window", "Estimated cost:   $"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("text report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExplainWithoutLimitsOrPrices(t *testing.T) {
+	r, err := Explain(testTurn(), Options{})
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	if r.Headroom != nil || r.InputCostUSD != nil || r.Overflows() {
+		t.Fatalf("expected no headroom or cost, got %#v", r)
+	}
+}
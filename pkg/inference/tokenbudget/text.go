@@ -0,0 +1,59 @@
+package tokenbudget
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText prints the report as a block table followed by totals.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tKIND\tROLE\tTOKENS\tPREVIEW")
+	for _, b := range r.Blocks {
+		p := b.Preview
+		if b.Images > 0 {
+			p = strings.TrimSpace(fmt.Sprintf("[%d image(s), not counted] %s", b.Images, p))
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", b.Index, b.Kind, b.Role, b.Tokens, p)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	model := r.Model
+	if model == "" {
+		model = "(unset)"
+	}
+	fmt.Fprintf(&sb, "Model:            %s (estimated with %s)\n", model, r.Encoding)
+	if len(r.Middlewares) > 0 {
+		fmt.Fprintf(&sb, "Middlewares:      %s\n", strings.Join(r.Middlewares, ", "))
+	}
+	fmt.Fprintf(&sb, "Input tokens:     %d\n", r.InputTokens)
+	if r.MaxResponseTokens > 0 {
+		fmt.Fprintf(&sb, "Response reserve: %d\n", r.MaxResponseTokens)
+	}
+	if r.ContextWindow > 0 {
+		fmt.Fprintf(&sb, "Context window:   %d\n", r.ContextWindow)
+		fmt.Fprintf(&sb, "Headroom:         %d\n", *r.Headroom)
+	} else {
+		sb.WriteString("Context window:   unknown (set model_info.context_window in the profile)\n")
+	}
+	if r.InputCostUSD != nil {
+		fmt.Fprintf(&sb, "Estimated cost:   $%.6f input", *r.InputCostUSD)
+		if r.MaxResponseTokens > 0 && r.MaxCostUSD != nil {
+			fmt.Fprintf(&sb, ", up to $%.6f with a full response", *r.MaxCostUSD)
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("Estimated cost:   unknown (no price for this model)\n")
+	}
+	if r.Overflows() {
+		fmt.Fprintf(&sb, "WARNING: the turn exceeds the context window by %d tokens\n", -*r.Headroom)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
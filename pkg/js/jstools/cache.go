@@ -0,0 +1,107 @@
+package jstools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache shares loaded runtimes between conversations that use the same
+// modules, keyed by their Options. BaseDir of the cache confines every
+// runtime it loads, and its allowed modules bound the AllowedModules a caller
+// may ask for.
+type Cache struct {
+	baseDir        string
+	allowedModules map[string]bool
+	loads          singleflight.Group
+
+	mu       sync.Mutex
+	runtimes map[string]*Runtime
+	closed   bool
+}
+
+// NewCache returns a cache confined to baseDir. allowedModules lists the
+// go-go-goja modules beyond the data-only set that runtimes may be given;
+// Get refuses options asking for any other.
+func NewCache(baseDir string, allowedModules []string) *Cache {
+	allowed := map[string]bool{}
+	for _, m := range allowedModules {
+		allowed[m] = true
+	}
+	return &Cache{baseDir: baseDir, allowedModules: allowed, runtimes: map[string]*Runtime{}}
+}
+
+// Get returns the runtime for opts, loading it on first use. opts.BaseDir is
+// replaced by the cache's root. Concurrent first uses of the same opts share
+// one load, and loads of other opts do not wait for it.
+func (c *Cache) Get(ctx context.Context, opts Options) (*Runtime, error) {
+	if c == nil {
+		return nil, errors.New("js tools cache is not configured")
+	}
+	for _, m := range opts.AllowedModules {
+		if !c.allowedModules[m] {
+			return nil, fmt.Errorf("js tools module %q is not allowed by the host", m)
+		}
+	}
+	opts.BaseDir = c.baseDir
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	key := string(b)
+
+	c.mu.Lock()
+	rt, ok := c.runtimes[key]
+	c.mu.Unlock()
+	if ok {
+		return rt, nil
+	}
+	v, err, _ := c.loads.Do(key, func() (any, error) {
+		c.mu.Lock()
+		rt, ok := c.runtimes[key]
+		closed := c.closed
+		c.mu.Unlock()
+		if closed {
+			return nil, errors.New("js tools cache is closed")
+		}
+		if ok {
+			return rt, nil
+		}
+		rt, err := Load(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.closed {
+			_ = rt.Close(context.Background())
+			return nil, errors.New("js tools cache is closed")
+		}
+		c.runtimes[key] = rt
+		return rt, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Runtime), nil
+}
+
+// Close stops every cached runtime. Get fails afterwards.
+func (c *Cache) Close(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	var errs []error
+	for key, rt := range c.runtimes {
+		errs = append(errs, rt.Close(ctx))
+		delete(c.runtimes, key)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,335 @@
+// Package jstools loads JavaScript modules that define tools and middlewares
+// for Pinocchio runtimes.
+//
+// Modules run in their own goja runtime and register definitions through
+// require("pinocchio/tools"). Every handler runs on that runtime's event loop
+// with a per-call timeout, so tool calls and middleware hooks coming from
+// concurrent inference goroutines are serialized and cannot hang a
+// conversation. The runtime only exposes go-go-goja's data-only modules unless
+// more are allowed explicitly, and module files can be confined to a root
+// directory.
+package jstools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/dop251/goja_nodejs/require"
+	gojengine "github.com/go-go-golems/go-go-goja/pkg/engine"
+)
+
+// ModuleName is the native module JavaScript tool modules require.
+const ModuleName = "pinocchio/tools"
+
+// DefaultTimeout bounds loading a module and every handler call when Options
+// does not set a timeout.
+const DefaultTimeout = 10 * time.Second
+
+type Options struct {
+	// Scripts are the module files to load, in order. Relative paths resolve
+	// against BaseDir, or the working directory when BaseDir is empty.
+	Scripts []string
+	// BaseDir confines module loading: scripts and everything they require
+	// must live below it. Empty means no confinement.
+	BaseDir string
+	// Timeout bounds loading each script and every handler call. Zero means
+	// DefaultTimeout; a tool or middleware may set its own timeoutMs.
+	Timeout time.Duration
+	// AllowedModules adds go-go-goja modules such as "fs" or "exec" to the
+	// data-only modules every runtime gets.
+	AllowedModules []string
+}
+
+// Runtime is a loaded set of JavaScript tool modules. It owns a goja runtime
+// and its event loop until Close.
+type Runtime struct {
+	rt      *gojengine.Runtime
+	timeout time.Duration
+
+	mu          sync.Mutex
+	tools       map[string]*tool
+	middlewares map[string]*middleware
+}
+
+// Load starts a sandboxed runtime and runs every script of opts in it.
+func Load(ctx context.Context, opts Options) (*Runtime, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	baseDir, err := resolveBaseDir(opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	scripts, err := resolveScripts(baseDir, opts.Scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Runtime{
+		timeout:     timeout,
+		tools:       map[string]*tool{},
+		middlewares: map[string]*middleware{},
+	}
+	requireOpts := []require.Option{}
+	if baseDir != "" {
+		requireOpts = append(requireOpts, require.WithLoader(sandboxedSourceLoader(baseDir)))
+	}
+	factory, err := gojengine.NewRuntimeFactoryBuilder(gojengine.WithRequireOptions(requireOpts...)).
+		UseModuleMiddleware(gojengine.MiddlewareOnly(opts.AllowedModules...)).
+		WithModules(gojengine.NativeModuleRegistrar{ModuleName: ModuleName, Loader: r.loader}).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	// The runtime outlives the load context; Close tears it down.
+	rt, err := factory.NewRuntime(gojengine.WithStartupContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	r.rt = rt
+
+	for _, script := range scripts {
+		_, err := r.call(ctx, "jstools.load", timeout, func(*goja.Runtime) (goja.Value, error) {
+			_, err := rt.Require.Require(script)
+			return nil, err
+		})
+		if err != nil {
+			_ = r.Close(context.Background())
+			return nil, fmt.Errorf("load js tools module %s: %w", script, err)
+		}
+	}
+	return r, nil
+}
+
+// Close stops the runtime. Registered tools and middlewares fail afterwards.
+func (r *Runtime) Close(ctx context.Context) error {
+	if r == nil || r.rt == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return r.rt.Close(ctx)
+}
+
+// ToolNames returns the names of the registered tools, sorted.
+func (r *Runtime) ToolNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedKeys(r.tools)
+}
+
+// MiddlewareNames returns the names of the registered middlewares, sorted.
+func (r *Runtime) MiddlewareNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedKeys(r.middlewares)
+}
+
+// call runs fn on the event loop and waits for its result, resolving
+// returned promises. fn is interrupted when timeout elapses or ctx ends, and
+// so is a promise continuation still running on the loop at that point.
+func (r *Runtime) call(ctx context.Context, op string, timeout time.Duration, fn func(*goja.Runtime) (goja.Value, error)) (any, error) {
+	if r == nil || r.rt == nil {
+		return nil, fmt.Errorf("js tools runtime is not loaded")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var loopVM *goja.Runtime
+	ret, err := r.rt.Owner.Call(ctx, op, func(callCtx context.Context, vm *goja.Runtime) (any, error) {
+		loopVM = vm
+		interrupted := make(chan struct{})
+		stop := context.AfterFunc(callCtx, func() {
+			vm.Interrupt(fmt.Errorf("%s: %w", op, callCtx.Err()))
+			close(interrupted)
+		})
+		defer func() {
+			if !stop() {
+				<-interrupted
+				vm.ClearInterrupt()
+			}
+		}()
+		v, err := fn(vm)
+		if err != nil {
+			return nil, err
+		}
+		return settle(vm, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	pending, ok := ret.(chan settled)
+	if !ok {
+		return ret, nil
+	}
+	select {
+	case res := <-pending:
+		return res.value, res.err
+	case <-ctx.Done():
+		// A continuation scheduled by a later loop task may be spinning, so
+		// interrupt it and clear the flag from the loop once it has yielded.
+		loopVM.Interrupt(fmt.Errorf("%s: %w", op, ctx.Err()))
+		_, _ = r.rt.Owner.Call(context.Background(), op+".clear", func(context.Context, *goja.Runtime) (any, error) {
+			loopVM.ClearInterrupt()
+			return nil, nil
+		})
+		return nil, fmt.Errorf("%s: promise not settled: %w", op, ctx.Err())
+	}
+}
+
+type settled struct {
+	value any
+	err   error
+}
+
+// settle exports v. Settled promises are unwrapped; a pending promise is
+// returned as a channel that receives its outcome.
+func settle(vm *goja.Runtime, v goja.Value) (any, error) {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, nil
+	}
+	promise, ok := v.Export().(*goja.Promise)
+	if !ok {
+		return v.Export(), nil
+	}
+	switch promise.State() {
+	case goja.PromiseStateFulfilled:
+		return settle(vm, promise.Result())
+	case goja.PromiseStateRejected:
+		return nil, rejection(promise.Result())
+	}
+	ch := make(chan settled, 1)
+	obj := v.ToObject(vm)
+	then, ok := goja.AssertFunction(obj.Get("then"))
+	if !ok {
+		return nil, fmt.Errorf("promise has no then method")
+	}
+	onFulfilled := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		value := call.Argument(0)
+		if goja.IsUndefined(value) || goja.IsNull(value) {
+			ch <- settled{}
+		} else {
+			ch <- settled{value: value.Export()}
+		}
+		return goja.Undefined()
+	})
+	onRejected := vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		ch <- settled{err: rejection(call.Argument(0))}
+		return goja.Undefined()
+	})
+	if _, err := then(obj, onFulfilled, onRejected); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func rejection(reason goja.Value) error {
+	if reason == nil || goja.IsUndefined(reason) || goja.IsNull(reason) {
+		return errors.New("promise rejected")
+	}
+	if obj, ok := reason.(*goja.Object); ok {
+		if msg := obj.Get("message"); msg != nil && !goja.IsUndefined(msg) {
+			return errors.New(msg.String())
+		}
+	}
+	return errors.New(reason.String())
+}
+
+func resolveBaseDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("js tools root %s: %w", dir, err)
+	}
+	return resolved, nil
+}
+
+func resolveScripts(baseDir string, scripts []string) ([]string, error) {
+	out := make([]string, 0, len(scripts))
+	for _, script := range scripts {
+		script = strings.TrimSpace(script)
+		if script == "" {
+			continue
+		}
+		if !filepath.IsAbs(script) && baseDir != "" {
+			script = filepath.Join(baseDir, script)
+		}
+		abs, err := filepath.Abs(script)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("js tools module: %w", err)
+		}
+		out = append(out, filepath.ToSlash(abs))
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no js tools modules to load")
+	}
+	return out, nil
+}
+
+// sandboxedSourceLoader refuses module files outside root, following
+// symlinks before the check.
+func sandboxedSourceLoader(root string) require.SourceLoader {
+	return func(p string) ([]byte, error) {
+		abs, err := filepath.Abs(filepath.FromSlash(p))
+		if err != nil {
+			return nil, err
+		}
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, require.ModuleFileDoesNotExistError
+			}
+			return nil, err
+		}
+		if !withinDir(root, resolved) {
+			return nil, fmt.Errorf("module %s is outside the js tools root %s", p, root)
+		}
+		return require.DefaultSourceLoader(resolved)
+	}
+}
+
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	out := make([]string, 0, len(m))
+	for name := range m {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,279 @@
+package jstools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+func writeModule(t *testing.T, dir, name, src string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func loadModule(t *testing.T, src string, opts Options) *Runtime {
+	t.Helper()
+	dir := t.TempDir()
+	writeModule(t, dir, "tools.js", src)
+	opts.BaseDir = dir
+	opts.Scripts = []string{"tools.js"}
+	rt, err := Load(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	t.Cleanup(func() { _ = rt.Close(context.Background()) })
+	return rt
+}
+
+func TestLoadRegistersToolsAndMiddlewares(t *testing.T) {
+	rt := loadModule(t, `
+const tools = require("pinocchio/tools");
+tools.registerTool({
+  name: "greet",
+  description: "Greets someone",
+  parameters: { type: "object", properties: { name: { type: "string" } } },
+  handler: (args) => ({ greeting: "hello " + args.name }),
+});
+tools.registerMiddleware({ name: "noop", factory: () => (turn) => turn });
+`, Options{})
+
+	if got := rt.ToolNames(); !reflect.DeepEqual(got, []string{"greet"}) {
+		t.Fatalf("ToolNames = %v", got)
+	}
+	if got := rt.MiddlewareNames(); !reflect.DeepEqual(got, []string{"noop"}) {
+		t.Fatalf("MiddlewareNames = %v", got)
+	}
+	out, err := rt.CallTool(context.Background(), "greet", map[string]any{"name": "ada"})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if got := out.(map[string]any)["greeting"]; got != "hello ada" {
+		t.Fatalf("greeting = %v", got)
+	}
+}
+
+func TestCallToolResolvesPromises(t *testing.T) {
+	rt := loadModule(t, `
+const tools = require("pinocchio/tools");
+const timer = require("timer");
+tools.registerTool({
+  name: "later",
+  handler: (args) => timer.sleep(5).then(() => args.n * 2),
+});
+tools.registerTool({
+  name: "fails",
+  handler: async () => { throw new Error("no luck"); },
+});
+`, Options{})
+
+	out, err := rt.CallTool(context.Background(), "later", map[string]any{"n": 21})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if out != int64(42) {
+		t.Fatalf("later = %#v", out)
+	}
+	if _, err := rt.CallTool(context.Background(), "fails", nil); err == nil || !strings.Contains(err.Error(), "no luck") {
+		t.Fatalf("expected rejection error, got %v", err)
+	}
+}
+
+func TestCallToolTimesOut(t *testing.T) {
+	rt := loadModule(t, `
+const tools = require("pinocchio/tools");
+tools.registerTool({ name: "spin", timeoutMs: 50, handler: () => { for (;;) {} } });
+tools.registerTool({ name: "ok", handler: () => "ok" });
+`, Options{})
+
+	start := time.Now()
+	if _, err := rt.CallTool(context.Background(), "spin", nil); err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout took %s", elapsed)
+	}
+	out, err := rt.CallTool(context.Background(), "ok", nil)
+	if err != nil || out != "ok" {
+		t.Fatalf("runtime not usable after interrupt: %v %v", out, err)
+	}
+}
+
+func TestCallToolTimesOutAfterAwait(t *testing.T) {
+	rt := loadModule(t, `
+const tools = require("pinocchio/tools");
+const { sleep } = require("timer");
+tools.registerTool({ name: "spin", timeoutMs: 50, handler: async () => { await sleep(1); for (;;) {} } });
+tools.registerTool({ name: "ok", handler: () => "ok" });
+`, Options{AllowedModules: []string{"timer"}})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := rt.CallTool(context.Background(), "spin", nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected timeout error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("call did not time out")
+	}
+
+	ok := make(chan error, 1)
+	go func() {
+		out, err := rt.CallTool(context.Background(), "ok", nil)
+		if err == nil && out != "ok" {
+			err = fmt.Errorf("unexpected result %v", out)
+		}
+		ok <- err
+	}()
+	select {
+	case err := <-ok:
+		if err != nil {
+			t.Fatalf("runtime not usable after interrupt: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("event loop still busy after the timeout")
+	}
+}
+
+func TestBuildMiddlewareRunsHooks(t *testing.T) {
+	rt := loadModule(t, `
+const tools = require("pinocchio/tools");
+tools.registerMiddleware({
+  name: "guard",
+  factory: (cfg) => ({
+    before: (turn) => { if (cfg.block) { throw new Error("blocked"); } },
+    after: (turn) => turn,
+  }),
+});
+`, Options{})
+
+	next := func(_ context.Context, t *turns.Turn) (*turns.Turn, error) { return t, nil }
+	mw, err := rt.BuildMiddleware(context.Background(), "guard", map[string]any{"block": false})
+	if err != nil {
+		t.Fatalf("BuildMiddleware: %v", err)
+	}
+	out, err := mw(next)(context.Background(), &turns.Turn{ID: "turn-1"})
+	if err != nil {
+		t.Fatalf("middleware: %v", err)
+	}
+	if out.ID != "turn-1" {
+		t.Fatalf("turn id = %q", out.ID)
+	}
+
+	mw, err = rt.BuildMiddleware(context.Background(), "guard", map[string]any{"block": true})
+	if err != nil {
+		t.Fatalf("BuildMiddleware: %v", err)
+	}
+	if _, err := mw(next)(context.Background(), &turns.Turn{ID: "turn-2"}); err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Fatalf("expected hook error, got %v", err)
+	}
+}
+
+func TestLoadConfinesModulesToBaseDir(t *testing.T) {
+	outside := t.TempDir()
+	writeModule(t, outside, "secret.js", `module.exports = "secret";`)
+	dir := t.TempDir()
+	rel, err := filepath.Rel(dir, filepath.Join(outside, "secret.js"))
+	if err != nil {
+		t.Fatalf("Rel: %v", err)
+	}
+	writeModule(t, dir, "tools.js", `require("`+filepath.ToSlash(rel)+`");`)
+
+	_, err = Load(context.Background(), Options{BaseDir: dir, Scripts: []string{"tools.js"}})
+	if err == nil || !strings.Contains(err.Error(), "outside the js tools root") {
+		t.Fatalf("expected sandbox error, got %v", err)
+	}
+}
+
+func TestLoadOnlyExposesAllowedModules(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "tools.js", `require("fs");`)
+	if _, err := Load(context.Background(), Options{BaseDir: dir, Scripts: []string{"tools.js"}}); err == nil {
+		t.Fatalf("expected fs to be unavailable")
+	}
+	rt, err := Load(context.Background(), Options{BaseDir: dir, Scripts: []string{"tools.js"}, AllowedModules: []string{"fs"}})
+	if err != nil {
+		t.Fatalf("Load with fs allowed: %v", err)
+	}
+	_ = rt.Close(context.Background())
+}
+
+func TestToolSchemaDefaultsToObject(t *testing.T) {
+	schema, err := toolSchema(nil)
+	if err != nil || schema.Type != "object" {
+		t.Fatalf("toolSchema(nil) = %#v, %v", schema, err)
+	}
+	schema, err = toolSchema(map[string]any{"properties": map[string]any{"q": map[string]any{"type": "string"}}})
+	if err != nil {
+		t.Fatalf("toolSchema: %v", err)
+	}
+	if schema.Type != "object" || schema.Properties == nil || schema.Properties.Len() != 1 {
+		t.Fatalf("unexpected schema %#v", schema)
+	}
+}
+
+func TestCacheSharesConcurrentLoads(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "tools.js", `
+const tools = require("pinocchio/tools");
+tools.registerTool({ name: "ok", handler: () => "ok" });
+`)
+	cache := NewCache(dir, nil)
+	opts := Options{Scripts: []string{"tools.js"}}
+
+	const n = 8
+	got := make(chan *Runtime, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			rt, err := cache.Get(context.Background(), opts)
+			if err != nil {
+				t.Errorf("Get: %v", err)
+			}
+			got <- rt
+		}()
+	}
+	first := <-got
+	for i := 1; i < n; i++ {
+		if rt := <-got; rt != first {
+			t.Fatalf("concurrent Gets loaded separate runtimes")
+		}
+	}
+
+	if err := cache.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := cache.Get(context.Background(), opts); err == nil {
+		t.Fatalf("expected Get to fail after Close")
+	}
+}
+
+func TestCacheRefusesModulesTheHostDoesNotAllow(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "tools.js", `require("fs");`)
+	cache := NewCache(dir, []string{"fs"})
+	defer func() { _ = cache.Close(context.Background()) }()
+
+	if _, err := cache.Get(context.Background(), Options{Scripts: []string{"tools.js"}, AllowedModules: []string{"exec"}}); err == nil {
+		t.Fatalf("expected exec to be refused")
+	}
+	if _, err := NewCache(dir, nil).Get(context.Background(), Options{Scripts: []string{"tools.js"}, AllowedModules: []string{"fs"}}); err == nil {
+		t.Fatalf("expected fs to be refused without a host allowlist")
+	}
+	if _, err := cache.Get(context.Background(), Options{Scripts: []string{"tools.js"}, AllowedModules: []string{"fs"}}); err != nil {
+		t.Fatalf("Get with fs allowed by the host: %v", err)
+	}
+}
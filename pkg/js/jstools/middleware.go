@@ -0,0 +1,238 @@
+package jstools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+	gepmiddleware "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"gopkg.in/yaml.v3"
+)
+
+type middleware struct {
+	name        string
+	description string
+	schema      map[string]any
+	timeout     time.Duration
+	factory     goja.Callable
+}
+
+// hooks are what a middleware factory returns: a function, used as before,
+// or an object with optional before and after functions. Both receive the
+// turn as a plain object and return the turn to continue with; returning
+// nothing keeps the (possibly mutated) argument.
+type hooks struct {
+	before goja.Callable
+	after  goja.Callable
+}
+
+// BuildMiddleware calls the factory of a registered middleware with cfg and
+// wraps the hooks it returns as a Geppetto middleware.
+func (r *Runtime) BuildMiddleware(ctx context.Context, name string, cfg any) (gepmiddleware.Middleware, error) {
+	r.mu.Lock()
+	mw, ok := r.middlewares[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("js middleware %q is not registered", name)
+	}
+	config, err := plainValue(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("js middleware %q config: %w", name, err)
+	}
+	if config == nil {
+		config = map[string]any{}
+	}
+
+	var h hooks
+	_, err = r.call(ctx, "jstools.middleware."+name, mw.timeout, func(vm *goja.Runtime) (goja.Value, error) {
+		ret, err := mw.factory(goja.Undefined(), vm.ToValue(config))
+		if err != nil {
+			return nil, err
+		}
+		h, err = parseHooks(vm, ret)
+		return nil, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build js middleware %q: %w", name, err)
+	}
+
+	return func(next gepmiddleware.HandlerFunc) gepmiddleware.HandlerFunc {
+		return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+			if h.before != nil {
+				updated, err := r.runHook(ctx, "jstools.middleware."+name+".before", mw.timeout, h.before, t)
+				if err != nil {
+					return nil, fmt.Errorf("js middleware %q: %w", name, err)
+				}
+				t = updated
+			}
+			res, err := next(ctx, t)
+			if err != nil || h.after == nil || res == nil {
+				return res, err
+			}
+			updated, err := r.runHook(ctx, "jstools.middleware."+name+".after", mw.timeout, h.after, res)
+			if err != nil {
+				return nil, fmt.Errorf("js middleware %q: %w", name, err)
+			}
+			return updated, nil
+		}
+	}, nil
+}
+
+func parseHooks(vm *goja.Runtime, v goja.Value) (hooks, error) {
+	if fn, ok := goja.AssertFunction(v); ok {
+		return hooks{before: fn}, nil
+	}
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return hooks{}, fmt.Errorf("factory must return a function or a {before, after} object")
+	}
+	obj := v.ToObject(vm)
+	before, _ := goja.AssertFunction(obj.Get("before"))
+	after, _ := goja.AssertFunction(obj.Get("after"))
+	if before == nil && after == nil {
+		return hooks{}, fmt.Errorf("factory must return a function or a {before, after} object")
+	}
+	return hooks{before: before, after: after}, nil
+}
+
+// runHook passes t to hook as a plain object and decodes the turn it returns.
+func (r *Runtime) runHook(ctx context.Context, op string, timeout time.Duration, hook goja.Callable, t *turns.Turn) (*turns.Turn, error) {
+	if t == nil {
+		return nil, nil
+	}
+	payload, err := turnToJSON(t)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := r.call(ctx, op, timeout, func(vm *goja.Runtime) (goja.Value, error) {
+		arg, err := vm.RunString("(" + payload + ")")
+		if err != nil {
+			return nil, err
+		}
+		ret, err := hook(goja.Undefined(), arg)
+		if err != nil {
+			return nil, err
+		}
+		if ret == nil || goja.IsUndefined(ret) || goja.IsNull(ret) {
+			return arg, nil
+		}
+		return ret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return t, nil
+	}
+	return turnFromValue(ret)
+}
+
+// turnToJSON renders t through the turn YAML codec, so JavaScript sees the
+// same shape as persisted turns.
+func turnToJSON(t *turns.Turn) (string, error) {
+	b, err := serde.ToYAML(t, serde.Options{})
+	if err != nil {
+		return "", fmt.Errorf("encode turn: %w", err)
+	}
+	var plain any
+	if err := yaml.Unmarshal(b, &plain); err != nil {
+		return "", fmt.Errorf("encode turn: %w", err)
+	}
+	out, err := json.Marshal(plain)
+	if err != nil {
+		return "", fmt.Errorf("encode turn: %w", err)
+	}
+	return string(out), nil
+}
+
+func turnFromValue(v any) (*turns.Turn, error) {
+	if _, ok := v.(map[string]any); !ok {
+		return nil, fmt.Errorf("hook must return a turn object, not %T", v)
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("decode turn: %w", err)
+	}
+	t, err := serde.FromYAML(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode turn: %w", err)
+	}
+	return t, nil
+}
+
+func plainValue(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MiddlewareDefinitions returns a middlewarecfg definition per registered
+// middleware, for profile middleware lists and schema listings.
+func (r *Runtime) MiddlewareDefinitions() []middlewarecfg.Definition {
+	names := r.MiddlewareNames()
+	out := make([]middlewarecfg.Definition, 0, len(names))
+	for _, name := range names {
+		if def, ok := r.MiddlewareDefinition(name); ok {
+			out = append(out, def)
+		}
+	}
+	return out
+}
+
+// MiddlewareDefinition returns the definition of one registered middleware.
+func (r *Runtime) MiddlewareDefinition(name string) (middlewarecfg.Definition, bool) {
+	r.mu.Lock()
+	mw, ok := r.middlewares[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return middlewareDefinition{runtime: r, mw: mw}, true
+}
+
+type middlewareDefinition struct {
+	runtime *Runtime
+	mw      *middleware
+}
+
+func (d middlewareDefinition) Name() string {
+	return d.mw.name
+}
+
+func (d middlewareDefinition) MiddlewareVersion() uint16 {
+	return 1
+}
+
+func (d middlewareDefinition) MiddlewareDisplayName() string {
+	return d.mw.name
+}
+
+func (d middlewareDefinition) MiddlewareDescription() string {
+	return d.mw.description
+}
+
+func (d middlewareDefinition) ConfigJSONSchema() map[string]any {
+	if len(d.mw.schema) == 0 {
+		return map[string]any{"type": "object"}
+	}
+	schema, _ := plainValue(d.mw.schema)
+	out, _ := schema.(map[string]any)
+	return out
+}
+
+func (d middlewareDefinition) Build(ctx context.Context, _ middlewarecfg.BuildDeps, cfg any) (gepmiddleware.Middleware, error) {
+	return d.runtime.BuildMiddleware(ctx, d.mw.name, cfg)
+}
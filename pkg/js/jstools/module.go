@@ -0,0 +1,176 @@
+package jstools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// loader exports the registration API of require("pinocchio/tools"):
+//
+//	registerTool({name, description, parameters, handler, timeoutMs, tags})
+//	registerMiddleware({name, description, schema, factory, timeoutMs})
+//	list()
+func (r *Runtime) loader(vm *goja.Runtime, moduleObj *goja.Object) {
+	exports := moduleObj.Get("exports").(*goja.Object)
+	setFunc(vm, exports, "registerTool", func(call goja.FunctionCall) (any, error) {
+		t, err := parseToolSpec(vm, call.Argument(0))
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.tools[t.name]; ok {
+			return nil, fmt.Errorf("tool %q is already registered", t.name)
+		}
+		r.tools[t.name] = t
+		return nil, nil
+	})
+	setFunc(vm, exports, "registerMiddleware", func(call goja.FunctionCall) (any, error) {
+		mw, err := parseMiddlewareSpec(vm, call.Argument(0))
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.middlewares[mw.name]; ok {
+			return nil, fmt.Errorf("middleware %q is already registered", mw.name)
+		}
+		r.middlewares[mw.name] = mw
+		return nil, nil
+	})
+	setFunc(vm, exports, "list", func(goja.FunctionCall) (any, error) {
+		return map[string]any{
+			"tools":       r.ToolNames(),
+			"middlewares": r.MiddlewareNames(),
+		}, nil
+	})
+}
+
+func parseToolSpec(vm *goja.Runtime, v goja.Value) (*tool, error) {
+	obj, err := specObject(vm, v, "registerTool")
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(stringField(obj, "name"))
+	if name == "" {
+		return nil, fmt.Errorf("registerTool requires a name")
+	}
+	handler, ok := goja.AssertFunction(obj.Get("handler"))
+	if !ok {
+		return nil, fmt.Errorf("tool %q requires a handler function", name)
+	}
+	parameters, err := objectField(obj, "parameters")
+	if err != nil {
+		return nil, fmt.Errorf("tool %q: %w", name, err)
+	}
+	tags := []string{}
+	if raw, ok := exportField(obj, "tags").([]any); ok {
+		for _, tag := range raw {
+			if s, ok := tag.(string); ok && strings.TrimSpace(s) != "" {
+				tags = append(tags, strings.TrimSpace(s))
+			}
+		}
+	}
+	return &tool{
+		name:        name,
+		description: strings.TrimSpace(stringField(obj, "description")),
+		parameters:  parameters,
+		tags:        tags,
+		timeout:     durationField(obj, "timeoutMs"),
+		handler:     handler,
+	}, nil
+}
+
+func parseMiddlewareSpec(vm *goja.Runtime, v goja.Value) (*middleware, error) {
+	obj, err := specObject(vm, v, "registerMiddleware")
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(stringField(obj, "name"))
+	if name == "" {
+		return nil, fmt.Errorf("registerMiddleware requires a name")
+	}
+	factory, ok := goja.AssertFunction(obj.Get("factory"))
+	if !ok {
+		return nil, fmt.Errorf("middleware %q requires a factory function", name)
+	}
+	schema, err := objectField(obj, "schema")
+	if err != nil {
+		return nil, fmt.Errorf("middleware %q: %w", name, err)
+	}
+	return &middleware{
+		name:        name,
+		description: strings.TrimSpace(stringField(obj, "description")),
+		schema:      schema,
+		timeout:     durationField(obj, "timeoutMs"),
+		factory:     factory,
+	}, nil
+}
+
+func specObject(vm *goja.Runtime, v goja.Value, fn string) (*goja.Object, error) {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, fmt.Errorf("%s expects an options object", fn)
+	}
+	obj := v.ToObject(vm)
+	if obj == nil {
+		return nil, fmt.Errorf("%s expects an options object", fn)
+	}
+	return obj, nil
+}
+
+func exportField(obj *goja.Object, name string) any {
+	v := obj.Get(name)
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil
+	}
+	return v.Export()
+}
+
+func stringField(obj *goja.Object, name string) string {
+	s, _ := exportField(obj, name).(string)
+	return s
+}
+
+func objectField(obj *goja.Object, name string) (map[string]any, error) {
+	raw := exportField(obj, name)
+	if raw == nil {
+		return nil, nil
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", name)
+	}
+	return m, nil
+}
+
+func durationField(obj *goja.Object, name string) time.Duration {
+	switch x := exportField(obj, name).(type) {
+	case int64:
+		if x > 0 {
+			return time.Duration(x) * time.Millisecond
+		}
+	case float64:
+		if x > 0 {
+			return time.Duration(x * float64(time.Millisecond))
+		}
+	}
+	return 0
+}
+
+func setFunc(vm *goja.Runtime, obj *goja.Object, name string, fn func(goja.FunctionCall) (any, error)) {
+	if err := obj.Set(name, func(call goja.FunctionCall) goja.Value {
+		ret, err := fn(call)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		if ret == nil {
+			return goja.Undefined()
+		}
+		return vm.ToValue(ret)
+	}); err != nil {
+		panic(vm.NewGoError(err))
+	}
+}
@@ -0,0 +1,103 @@
+package jstools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+	geptools "github.com/go-go-golems/geppetto/pkg/inference/tools"
+	"github.com/invopop/jsonschema"
+)
+
+type tool struct {
+	name        string
+	description string
+	parameters  map[string]any
+	tags        []string
+	timeout     time.Duration
+	handler     goja.Callable
+}
+
+// RegisterTools adds the JavaScript tools to registry as ordinary Go tool
+// definitions, so the default Geppetto executor can run them. With names,
+// only the listed tools are added; names the runtime does not define are
+// skipped, since a profile's tool list may also name tools of other sources.
+func (r *Runtime) RegisterTools(registry geptools.ToolRegistry, names ...string) error {
+	if registry == nil {
+		return fmt.Errorf("tool registry is nil")
+	}
+	if len(names) == 0 {
+		names = r.ToolNames()
+	}
+	for _, name := range names {
+		r.mu.Lock()
+		t, ok := r.tools[name]
+		r.mu.Unlock()
+		if !ok {
+			continue
+		}
+		def, err := r.toolDefinition(t)
+		if err != nil {
+			return err
+		}
+		if err := registry.RegisterTool(t.name, *def); err != nil {
+			return fmt.Errorf("register js tool %q: %w", t.name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Runtime) toolDefinition(t *tool) (*geptools.ToolDefinition, error) {
+	def, err := geptools.NewToolFromFunc(t.name, t.description, func(ctx context.Context, args map[string]any) (any, error) {
+		return r.CallTool(ctx, t.name, args)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("js tool %q: %w", t.name, err)
+	}
+	schema, err := toolSchema(t.parameters)
+	if err != nil {
+		return nil, fmt.Errorf("js tool %q parameters: %w", t.name, err)
+	}
+	def.Parameters = schema
+	def.Tags = append(append([]string(nil), t.tags...), "javascript")
+	return def, nil
+}
+
+// CallTool runs the handler of a registered tool with the decoded call
+// arguments and returns what it returns or resolves to.
+func (r *Runtime) CallTool(ctx context.Context, name string, args map[string]any) (any, error) {
+	r.mu.Lock()
+	t, ok := r.tools[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("js tool %q is not registered", name)
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	return r.call(ctx, "jstools.tool."+name, t.timeout, func(vm *goja.Runtime) (goja.Value, error) {
+		return t.handler(goja.Undefined(), vm.ToValue(args))
+	})
+}
+
+// toolSchema converts the JSON schema a module declares. Tools without one
+// take an arbitrary object.
+func toolSchema(parameters map[string]any) (*jsonschema.Schema, error) {
+	if len(parameters) == 0 {
+		return &jsonschema.Schema{Type: "object"}, nil
+	}
+	b, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, err
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, err
+	}
+	if schema.Type == "" && schema.Ref == "" {
+		schema.Type = "object"
+	}
+	return &schema, nil
+}
@@ -0,0 +1,460 @@
+package pinocchio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/export"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/plugins"
+	"github.com/go-go-golems/pinocchio/pkg/inference/middlewaredefs"
+	"github.com/go-go-golems/pinocchio/pkg/inference/profilecomposer"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	sessionstream "github.com/go-go-golems/sessionstream/pkg/sessionstream"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// chatSession is one chatapp.Runner session driven from JavaScript. It keeps
+// the conversation accumulator like the TUI backend does, so every prompt
+// sees the full history.
+type chatSession struct {
+	vm      *goja.Runtime
+	sid     sessionstream.SessionId
+	runner  *chatapp.Runner
+	runtime *infruntime.ComposedRuntime
+	fanout  *sessionFanout
+	store   chatstore.TurnStore
+	onClose func()
+
+	mu          sync.Mutex
+	current     *turns.Turn
+	closed      bool
+	subscribers map[int]goja.Callable
+	nextSubID   int
+}
+
+type chatSessionOptions struct {
+	Profile      profileRef
+	SessionID    string
+	SystemPrompt string
+}
+
+func parseChatSessionOptions(v any) (chatSessionOptions, error) {
+	out := chatSessionOptions{}
+	if v == nil {
+		return out, nil
+	}
+	opts, ok := v.(map[string]any)
+	if !ok {
+		return out, fmt.Errorf("pinocchio.chat.session expects an options object")
+	}
+	profile, err := parseProfileRef(opts["profile"])
+	if err != nil {
+		return out, err
+	}
+	out.Profile = profile
+	out.SessionID = strings.TrimSpace(asString(opts["sessionId"]))
+	out.SystemPrompt = asString(opts["systemPrompt"])
+	return out, nil
+}
+
+// newChatSession resolves the profile and builds a dedicated runner with the
+// same plugins as web-chat. The runtime is composed like web-chat's, so the
+// profile's middlewares and JS tools apply to the session.
+func (m *module) newChatSession(ctx context.Context, vm *goja.Runtime, opts chatSessionOptions) (*chatSession, error) {
+	plan, err := m.resolveProfilePlan(ctx, opts.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	sid := opts.SessionID
+	if sid == "" {
+		sid = uuid.NewString()
+	}
+	runtimeKey := "default"
+	profile := ""
+	if plan.ResolvedProfile != nil {
+		profile = plan.ResolvedProfile.EngineProfileSlug.String()
+		runtimeKey = profile
+	}
+	profileRuntime := plan.Runtime.Clone()
+	if profileRuntime == nil {
+		profileRuntime = &infruntime.ProfileRuntime{}
+	}
+	if strings.TrimSpace(opts.SystemPrompt) != "" {
+		profileRuntime.SystemPrompt = opts.SystemPrompt
+	}
+	composer, err := m.runtimeComposer(opts.Profile.IsDefault())
+	if err != nil {
+		return nil, err
+	}
+	composed, err := composer.Compose(ctx, infruntime.ConversationRuntimeRequest{
+		ProfileKey:                runtimeKey,
+		ProfileVersion:            plan.ProfileVersion,
+		ResolvedInferenceSettings: plan.InferenceSettings,
+		ResolvedProfileRuntime:    profileRuntime,
+		ResolvedUsagePricing:      plan.Pricing,
+		ResolvedJSTools:           plan.JSTools,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("compose chat runtime: %w", err)
+	}
+	composed.Profile = profile
+
+	fanout := newSessionFanout()
+	runner, err := chatapp.NewRunner(chatapp.RunnerOptions{
+		UIFanout:    fanout,
+		TurnStore:   m.opts.TurnStore,
+		UsageLedger: m.opts.UsageLedger,
+		Plugins: []chatapp.ChatPlugin{
+			plugins.NewReasoningPlugin(),
+			plugins.NewToolCallPlugin(),
+			plugins.NewContextBudgetPlugin(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The composed runtime adds the system prompt, like web-chat does.
+	seed := &turns.Turn{}
+	if err := turns.KeyTurnMetaSessionID.Set(&seed.Metadata, sid); err != nil {
+		_ = runner.Close()
+		return nil, err
+	}
+
+	return &chatSession{
+		vm:          vm,
+		sid:         sessionstream.SessionId(sid),
+		runner:      runner,
+		runtime:     &composed,
+		fanout:      fanout,
+		store:       m.opts.TurnStore,
+		current:     seed,
+		subscribers: map[int]goja.Callable{},
+	}, nil
+}
+
+// runtimeComposer returns the composer of a chat session. It offers the
+// built-in middlewares web-chat offers and creates engines with the
+// session's engine factory.
+func (m *module) runtimeComposer(defaultProfile bool) (*profilecomposer.ProfileRuntimeComposer, error) {
+	registry, err := middlewaredefs.NewRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("create middleware definition registry: %w", err)
+	}
+	return profilecomposer.NewProfileRuntimeComposer(registry, middlewarecfg.BuildDeps{
+		Values: map[string]any{
+			middlewaredefs.DependencyAgentModeServiceKey: middlewaredefs.NewDefaultAgentModeService(),
+		},
+	}, m.opts.BaseInferenceSettings).
+		WithEngineFactory(m.engineFactory(defaultProfile)).
+		WithJSTools(m.opts.JSTools), nil
+}
+
+// engineFactory resolves secret references right before engine creation.
+// The host bearer token belongs to the default profile, so it is only used
+// for sessions on that profile.
+func (m *module) engineFactory(defaultProfile bool) factory.EngineFactory {
+	if m.opts.BearerTokenSource == nil || !defaultProfile {
+		return secrets.NewResolvingEngineFactory(nil, m.opts.SecretResolver)
+	}
+	return secrets.NewResolvingEngineFactory(factory.NewStandardEngineFactory(
+		factory.WithBearerTokenSource(m.opts.BearerTokenSource),
+	), m.opts.SecretResolver)
+}
+
+// prompt submits one prompt and blocks until the session is idle. UI events
+// are delivered to subscribers on the JavaScript thread while it waits, so a
+// subscriber may call stop().
+func (s *chatSession) prompt(ctx context.Context, text string) (map[string]any, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("chat session %s is closed", s.sid)
+	}
+	initial := s.current.Clone()
+	s.mu.Unlock()
+	turns.AppendBlock(initial, turns.NewUserTextBlock(text))
+
+	var final *turns.Turn
+	var finalMu sync.Mutex
+	req := chatapp.PromptRequest{
+		Prompt:      text,
+		InitialTurn: initial,
+		Runtime:     s.runtime,
+		OnFinalTurn: func(t *turns.Turn) {
+			finalMu.Lock()
+			defer finalMu.Unlock()
+			final = t
+		},
+	}
+	if err := s.runner.Service.SubmitPromptRequest(ctx, s.sid, req); err != nil {
+		return nil, err
+	}
+	idle := make(chan error, 1)
+	go func() { idle <- s.runner.Service.WaitIdle(ctx, s.sid) }()
+
+	events := []map[string]any{}
+	var callbackErr error
+	deliver := func() {
+		batch := s.fanout.drain()
+		events = append(events, batch...)
+		if err := s.notify(batch); err != nil && callbackErr == nil {
+			callbackErr = err
+		}
+	}
+	var waitErr error
+wait:
+	for {
+		select {
+		case <-s.fanout.ready:
+			deliver()
+		case waitErr = <-idle:
+			deliver()
+			break wait
+		}
+	}
+	if waitErr != nil {
+		return nil, waitErr
+	}
+	if callbackErr != nil {
+		return nil, callbackErr
+	}
+	status, runErr := runStatus(events)
+	if runErr != nil {
+		return nil, runErr
+	}
+
+	finalMu.Lock()
+	out := final
+	finalMu.Unlock()
+	if out != nil {
+		s.mu.Lock()
+		s.current = out.Clone()
+		s.mu.Unlock()
+		if err := s.persistFinalTurn(ctx, out); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]any{
+		"sessionId": string(s.sid),
+		"status":    status,
+		"text":      lastAssistantText(out),
+		"events":    events,
+	}, nil
+}
+
+func (s *chatSession) notify(batch []map[string]any) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	callbacks := make([]goja.Callable, 0, len(s.subscribers))
+	for id := 0; id < s.nextSubID; id++ {
+		if fn, ok := s.subscribers[id]; ok {
+			callbacks = append(callbacks, fn)
+		}
+	}
+	s.mu.Unlock()
+	for _, ev := range batch {
+		for _, fn := range callbacks {
+			if _, err := fn(goja.Undefined(), s.vm.ToValue(ev)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *chatSession) subscribe(fn goja.Callable) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextSubID
+	s.nextSubID++
+	s.subscribers[id] = fn
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+// persistFinalTurn stores the final turn the way the CLI chat persister does.
+func (s *chatSession) persistFinalTurn(ctx context.Context, t *turns.Turn) error {
+	if s.store == nil || t == nil {
+		return nil
+	}
+	turnID := strings.TrimSpace(t.ID)
+	if turnID == "" {
+		turnID = "turn"
+	}
+	payload, err := serde.ToYAML(t, serde.Options{})
+	if err != nil {
+		return errors.Wrap(err, "pinocchio js session: serialize turn")
+	}
+	return s.store.Save(ctx, string(s.sid), string(s.sid), turnID, "final", time.Now().UnixMilli(), string(payload), chatstore.TurnSaveOptions{
+		RuntimeKey: s.runtime.RuntimeKey,
+	})
+}
+
+func (s *chatSession) snapshot(ctx context.Context) (map[string]any, error) {
+	timeline, err := export.NewService(s.runner.Service).ExportTimeline(ctx, string(s.sid), export.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return toPlainMap(timeline)
+}
+
+func (s *chatSession) stop(ctx context.Context) error {
+	return s.runner.Service.Stop(ctx, s.sid)
+}
+
+func (s *chatSession) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.subscribers = map[int]goja.Callable{}
+	s.mu.Unlock()
+	if s.onClose != nil {
+		s.onClose()
+	}
+	return s.runner.Close()
+}
+
+// sessionFanout buffers projected UI events until the JavaScript thread
+// drains them. PublishUI never calls into the VM.
+type sessionFanout struct {
+	mu      sync.Mutex
+	queue   []map[string]any
+	ready   chan struct{}
+	marshal protojson.MarshalOptions
+}
+
+var _ sessionstream.UIFanout = (*sessionFanout)(nil)
+
+func newSessionFanout() *sessionFanout {
+	return &sessionFanout{ready: make(chan struct{}, 1)}
+}
+
+func (f *sessionFanout) PublishUI(_ context.Context, sid sessionstream.SessionId, ord uint64, events []sessionstream.UIEvent) error {
+	batch := make([]map[string]any, 0, len(events))
+	for _, ev := range events {
+		payload, err := protoToValue(f.marshal, ev.Payload)
+		if err != nil {
+			return fmt.Errorf("ui event %q: %w", ev.Name, err)
+		}
+		batch = append(batch, map[string]any{
+			"sessionId": string(sid),
+			"ordinal":   ord,
+			"name":      strings.TrimSpace(ev.Name),
+			"payload":   payload,
+		})
+	}
+	f.mu.Lock()
+	f.queue = append(f.queue, batch...)
+	f.mu.Unlock()
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (f *sessionFanout) drain() []map[string]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	batch := f.queue
+	f.queue = nil
+	return batch
+}
+
+// runStatus reads the terminal run event of a prompt, mirroring the CLI's
+// run status fanout.
+func runStatus(events []map[string]any) (string, error) {
+	status := ""
+	var runErr error
+	for _, ev := range events {
+		payload, _ := ev["payload"].(map[string]any)
+		switch ev["name"] {
+		case chatapp.EventChatRunFinished:
+			status, runErr = firstNonEmpty(asString(payload["status"]), "ok"), nil
+		case chatapp.EventChatRunStopped:
+			status, runErr = firstNonEmpty(asString(payload["status"]), "stopped"), nil
+		case chatapp.EventChatRunFailed:
+			status = firstNonEmpty(asString(payload["status"]), "failed")
+			runErr = errors.New(firstNonEmpty(asString(payload["error"]), "chat run failed"))
+		}
+	}
+	return status, runErr
+}
+
+func lastAssistantText(t *turns.Turn) string {
+	if t == nil {
+		return ""
+	}
+	for i := len(t.Blocks) - 1; i >= 0; i-- {
+		block := t.Blocks[i]
+		if block.Role != turns.RoleAssistant || block.Payload == nil {
+			continue
+		}
+		if text, ok := block.Payload[turns.PayloadKeyText].(string); ok && strings.TrimSpace(text) != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+func protoToValue(marshal protojson.MarshalOptions, msg proto.Message) (any, error) {
+	if msg == nil {
+		return nil, nil
+	}
+	body, err := marshal.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// toPlainMap converts export structs to the JSON shape JavaScript sees.
+func toPlainMap(v any) (map[string]any, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]any{}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -1,36 +1,69 @@
 package pinocchio
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dop251/goja"
 	"github.com/dop251/goja_nodejs/require"
-	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	aitypes "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/pinocchio/pkg/js/jstools"
+	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+	"github.com/go-go-golems/pinocchio/pkg/persistence/usagestore"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 )
 
 const ModuleName = "pinocchio"
 
 type Options struct {
 	DefaultInferenceSettings *aisettings.InferenceSettings
+	// BaseInferenceSettings are the settings before any profile is applied.
+	// Profiles resolved from JavaScript are merged over them. When nil,
+	// DefaultInferenceSettings is used.
+	BaseInferenceSettings *aisettings.InferenceSettings
 	// BearerTokenSource is supplied by the embedding Go host. It remains an
 	// opaque Go capability and is never exported to JavaScript.
 	BearerTokenSource credentials.BearerTokenSource
+	// SecretResolver resolves secret references in profile settings. Nil
+	// means secrets.DefaultResolver.
+	SecretResolver *secrets.Resolver
+	// ProfileRegistry backs pinocchio.profiles and profile selection in
+	// pinocchio.chat.session. DefaultProfileResolve selects the host's
+	// default profile.
+	ProfileRegistry       gepprofiles.Registry
+	DefaultProfileResolve gepprofiles.ResolveInput
+	// TurnStore backs pinocchio.turns and export.turns. Chat sessions persist
+	// their final turns to it.
+	TurnStore chatstore.TurnStore
+	// TurnsDBPath is the file-backed SQLite turn database used by minitrace
+	// exports.
+	TurnsDBPath string
+	// UsageLedger records the provider calls of chat sessions, costed with
+	// the pricing of their profile. Nil records nothing.
+	UsageLedger usagestore.Ledger
+	// JSTools loads the pinocchio.js_tools@v1 modules of chat session
+	// profiles. Nil rejects sessions on profiles that set the extension.
+	JSTools *jstools.Cache
 }
 
 func Register(reg *require.Registry, opts Options) {
 	if reg == nil {
 		return
 	}
-	reg.RegisterNativeModule(ModuleName, (&module{opts: opts}).Loader)
+	reg.RegisterNativeModule(ModuleName, (&module{opts: opts, sessions: map[string]*chatSession{}}).Loader)
 }
 
 type module struct {
 	opts Options
+
+	mu       sync.Mutex
+	sessions map[string]*chatSession
 }
 
 func (m *module) Loader(vm *goja.Runtime, moduleObj *goja.Object) {
@@ -57,6 +90,167 @@ func (m *module) Loader(vm *goja.Runtime, moduleObj *goja.Object) {
 	if err := exports.Set("engines", enginesObj); err != nil {
 		panic(vm.NewGoError(err))
 	}
+
+	ctx := context.Background()
+	profilesObj := vm.NewObject()
+	setFunc(vm, profilesObj, "list", func(goja.FunctionCall) (any, error) {
+		return m.listProfiles(ctx)
+	})
+	setFunc(vm, profilesObj, "resolve", func(call goja.FunctionCall) (any, error) {
+		ref, err := parseProfileRef(exportArgument(call, 0))
+		if err != nil {
+			return nil, err
+		}
+		plan, err := m.resolveProfilePlan(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		return describeRuntimePlan(plan), nil
+	})
+	setObject(vm, exports, "profiles", profilesObj)
+
+	chatObj := vm.NewObject()
+	setFunc(vm, chatObj, "session", func(call goja.FunctionCall) (any, error) {
+		opts, err := parseChatSessionOptions(exportArgument(call, 0))
+		if err != nil {
+			return nil, err
+		}
+		session, err := m.openSession(ctx, vm, opts)
+		if err != nil {
+			return nil, err
+		}
+		return m.sessionObject(ctx, vm, session), nil
+	})
+	setObject(vm, exports, "chat", chatObj)
+
+	turnsObj := vm.NewObject()
+	setFunc(vm, turnsObj, "list", func(call goja.FunctionCall) (any, error) {
+		q, err := parseTurnQuery(exportArgument(call, 0))
+		if err != nil {
+			return nil, err
+		}
+		return m.listTurns(ctx, q)
+	})
+	setFunc(vm, turnsObj, "latest", func(call goja.FunctionCall) (any, error) {
+		q, err := parseTurnQuery(exportArgument(call, 0))
+		if err != nil {
+			return nil, err
+		}
+		turn, err := m.latestTurn(ctx, q)
+		if err != nil || turn == nil {
+			return nil, err
+		}
+		return turn, nil
+	})
+	setObject(vm, exports, "turns", turnsObj)
+
+	exportObj := vm.NewObject()
+	setFunc(vm, exportObj, "timeline", func(call goja.FunctionCall) (any, error) {
+		opts, err := parseExportOptions(exportArgument(call, 1))
+		if err != nil {
+			return nil, err
+		}
+		return m.exportTimeline(ctx, strings.TrimSpace(asString(exportArgument(call, 0))), opts)
+	})
+	setFunc(vm, exportObj, "turns", func(call goja.FunctionCall) (any, error) {
+		opts, err := parseExportOptions(exportArgument(call, 1))
+		if err != nil {
+			return nil, err
+		}
+		return m.exportTurns(ctx, strings.TrimSpace(asString(exportArgument(call, 0))), opts)
+	})
+	setObject(vm, exports, "export", exportObj)
+}
+
+// openSession creates a chat session and tracks it so export.timeline can
+// find it by id.
+func (m *module) openSession(ctx context.Context, vm *goja.Runtime, opts chatSessionOptions) (*chatSession, error) {
+	if opts.SessionID != "" {
+		if _, ok := m.lookupSession(opts.SessionID); ok {
+			return nil, fmt.Errorf("chat session %q is already open", opts.SessionID)
+		}
+	}
+	session, err := m.newChatSession(ctx, vm, opts)
+	if err != nil {
+		return nil, err
+	}
+	id := string(session.sid)
+	session.onClose = func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.sessions, id)
+	}
+	m.mu.Lock()
+	m.sessions[id] = session
+	m.mu.Unlock()
+	return session, nil
+}
+
+func (m *module) lookupSession(id string) (*chatSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[strings.TrimSpace(id)]
+	return session, ok
+}
+
+func (m *module) sessionObject(ctx context.Context, vm *goja.Runtime, session *chatSession) *goja.Object {
+	obj := vm.NewObject()
+	if err := obj.Set("id", string(session.sid)); err != nil {
+		panic(vm.NewGoError(err))
+	}
+	setFunc(vm, obj, "prompt", func(call goja.FunctionCall) (any, error) {
+		text := asString(exportArgument(call, 0))
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("session.prompt expects a non-empty prompt")
+		}
+		return session.prompt(ctx, text)
+	})
+	setFunc(vm, obj, "subscribe", func(call goja.FunctionCall) (any, error) {
+		fn, ok := goja.AssertFunction(call.Argument(0))
+		if !ok {
+			return nil, fmt.Errorf("session.subscribe expects a function")
+		}
+		return session.subscribe(fn), nil
+	})
+	setFunc(vm, obj, "snapshot", func(goja.FunctionCall) (any, error) {
+		return session.snapshot(ctx)
+	})
+	setFunc(vm, obj, "stop", func(goja.FunctionCall) (any, error) {
+		return nil, session.stop(ctx)
+	})
+	setFunc(vm, obj, "close", func(goja.FunctionCall) (any, error) {
+		return nil, session.close()
+	})
+	return obj
+}
+
+func setFunc(vm *goja.Runtime, obj *goja.Object, name string, fn func(goja.FunctionCall) (any, error)) {
+	if err := obj.Set(name, func(call goja.FunctionCall) goja.Value {
+		ret, err := fn(call)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		if ret == nil {
+			return goja.Undefined()
+		}
+		return vm.ToValue(ret)
+	}); err != nil {
+		panic(vm.NewGoError(err))
+	}
+}
+
+func setObject(vm *goja.Runtime, exports *goja.Object, name string, obj *goja.Object) {
+	if err := exports.Set(name, obj); err != nil {
+		panic(vm.NewGoError(err))
+	}
+}
+
+func exportArgument(call goja.FunctionCall, i int) any {
+	arg := call.Argument(i)
+	if goja.IsUndefined(arg) || goja.IsNull(arg) {
+		return nil
+	}
+	return arg.Export()
 }
 
 func (m *module) engineFromDefaults(call goja.FunctionCall) (any, error) {
@@ -64,12 +258,7 @@ func (m *module) engineFromDefaults(call goja.FunctionCall) (any, error) {
 	if err != nil {
 		return nil, err
 	}
-	if m.opts.BearerTokenSource == nil {
-		return factory.NewEngineFromSettings(ss)
-	}
-	return factory.NewStandardEngineFactory(
-		factory.WithBearerTokenSource(m.opts.BearerTokenSource),
-	).CreateEngine(ss)
+	return m.engineFactory(true).CreateEngine(ss)
 }
 
 func (m *module) inspectEngineDefaults(call goja.FunctionCall) (map[string]any, error) {
@@ -1,11 +1,13 @@
 package pinocchio
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
 	aitypes "github.com/go-go-golems/geppetto/pkg/steps/ai/types"
+	"github.com/go-go-golems/pinocchio/pkg/chatapp"
 )
 
 func TestApplyEngineOverridesIgnoresMissingKeys(t *testing.T) {
@@ -49,3 +51,78 @@ func TestAsStringReturnsEmptyStringForNil(t *testing.T) {
 		t.Fatalf("expected empty string for nil, got %q", got)
 	}
 }
+
+func TestParseProfileRef(t *testing.T) {
+	cases := []struct {
+		in   any
+		want profileRef
+	}{
+		{in: nil, want: profileRef{}},
+		{in: "assistant", want: profileRef{Profile: "assistant"}},
+		{in: "team/assistant", want: profileRef{Registry: "team", Profile: "assistant"}},
+		{in: map[string]any{"registry": "team", "profile": " coder "}, want: profileRef{Registry: "team", Profile: "coder"}},
+	}
+	for _, tc := range cases {
+		got, err := parseProfileRef(tc.in)
+		if err != nil {
+			t.Fatalf("parseProfileRef(%#v) failed: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("parseProfileRef(%#v) = %#v, want %#v", tc.in, got, tc.want)
+		}
+	}
+	if _, err := parseProfileRef(42); err == nil {
+		t.Fatalf("expected an error for a numeric profile reference")
+	}
+}
+
+func TestResolveProfilePlanWithoutRegistry(t *testing.T) {
+	base, err := aisettings.NewInferenceSettings()
+	if err != nil {
+		t.Fatalf("NewInferenceSettings failed: %v", err)
+	}
+	model := "base-model"
+	base.Chat.Engine = &model
+	m := &module{opts: Options{DefaultInferenceSettings: base}}
+
+	plan, err := m.resolveProfilePlan(context.Background(), profileRef{})
+	if err != nil {
+		t.Fatalf("default profile without a registry should resolve to the defaults: %v", err)
+	}
+	if plan.InferenceSettings == nil || plan.InferenceSettings.Chat.Engine == nil || *plan.InferenceSettings.Chat.Engine != "base-model" {
+		t.Fatalf("expected default inference settings, got %#v", plan.InferenceSettings)
+	}
+	if _, err := m.resolveProfilePlan(context.Background(), profileRef{Profile: "assistant"}); err == nil {
+		t.Fatalf("expected an error when selecting a profile without a registry")
+	}
+}
+
+func TestRunStatusReportsFailures(t *testing.T) {
+	status, err := runStatus([]map[string]any{
+		{"name": chatapp.EventChatRunStarted},
+		{"name": chatapp.EventChatRunFinished, "payload": map[string]any{}},
+	})
+	if err != nil || status != "ok" {
+		t.Fatalf("expected ok status, got %q, %v", status, err)
+	}
+	status, err = runStatus([]map[string]any{
+		{"name": chatapp.EventChatRunFailed, "payload": map[string]any{"error": "rate limited"}},
+	})
+	if err == nil || err.Error() != "rate limited" || status != "failed" {
+		t.Fatalf("expected failed status with provider error, got %q, %v", status, err)
+	}
+}
+
+func TestParseTurnQuery(t *testing.T) {
+	q, err := parseTurnQuery("session-1")
+	if err != nil || q.ConvID != "session-1" {
+		t.Fatalf("expected a session id query, got %#v, %v", q, err)
+	}
+	q, err = parseTurnQuery(map[string]any{"sessionId": "s", "phase": "final", "limit": int64(5)})
+	if err != nil {
+		t.Fatalf("parseTurnQuery failed: %v", err)
+	}
+	if q.SessionID != "s" || q.Phase != "final" || q.Limit != 5 {
+		t.Fatalf("unexpected query %#v", q)
+	}
+}
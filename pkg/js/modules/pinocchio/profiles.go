@@ -0,0 +1,185 @@
+package pinocchio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+)
+
+// profileRef selects a profile from JavaScript. Both slugs empty means the
+// host's default profile.
+type profileRef struct {
+	Registry string
+	Profile  string
+}
+
+func (r profileRef) IsDefault() bool {
+	return r.Registry == "" && r.Profile == ""
+}
+
+// parseProfileRef accepts "profile", "registry/profile" or
+// {registry, profile}.
+func parseProfileRef(v any) (profileRef, error) {
+	switch x := v.(type) {
+	case nil:
+		return profileRef{}, nil
+	case string:
+		ref := strings.TrimSpace(x)
+		if registry, profile, ok := strings.Cut(ref, "/"); ok {
+			return profileRef{Registry: strings.TrimSpace(registry), Profile: strings.TrimSpace(profile)}, nil
+		}
+		return profileRef{Profile: ref}, nil
+	case map[string]any:
+		return profileRef{
+			Registry: strings.TrimSpace(asString(x["registry"])),
+			Profile:  strings.TrimSpace(asString(x["profile"])),
+		}, nil
+	default:
+		return profileRef{}, fmt.Errorf("profile must be a slug string or {registry, profile} object, not %T", v)
+	}
+}
+
+func (m *module) listProfiles(ctx context.Context) ([]map[string]any, error) {
+	registry := m.opts.ProfileRegistry
+	if registry == nil {
+		return nil, fmt.Errorf("pinocchio profile registry is not configured")
+	}
+	registries, err := registry.ListRegistries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := []map[string]any{}
+	for _, summary := range registries {
+		reg, err := registry.GetRegistry(ctx, summary.Slug)
+		if err != nil {
+			return nil, err
+		}
+		profiles, err := registry.ListEngineProfiles(ctx, summary.Slug)
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range profiles {
+			if profile == nil {
+				continue
+			}
+			out = append(out, map[string]any{
+				"registry":    summary.Slug.String(),
+				"profile":     profile.Slug.String(),
+				"displayName": strings.TrimSpace(profile.DisplayName),
+				"description": strings.TrimSpace(profile.Description),
+				"isDefault":   reg != nil && reg.DefaultEngineProfileSlug == profile.Slug,
+				"isSelected":  m.isSelectedProfile(summary.Slug, profile.Slug),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (m *module) isSelectedProfile(registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug) bool {
+	selected := m.opts.DefaultProfileResolve
+	if selected.EngineProfileSlug.IsZero() || selected.EngineProfileSlug != profile {
+		return false
+	}
+	return selected.RegistrySlug.IsZero() || selected.RegistrySlug == registry
+}
+
+// resolveProfilePlan merges the selected profile stack over the host's base
+// inference settings, the same way web-chat resolves a conversation profile.
+// The default reference without a registry resolves to the host defaults.
+func (m *module) resolveProfilePlan(ctx context.Context, ref profileRef) (*infruntime.ResolvedRuntimePlan, error) {
+	registry := m.opts.ProfileRegistry
+	if registry == nil {
+		if !ref.IsDefault() {
+			return nil, fmt.Errorf("pinocchio profile registry is not configured")
+		}
+		defaults, err := m.defaultInferenceSettings()
+		if err != nil {
+			return nil, err
+		}
+		return &infruntime.ResolvedRuntimePlan{InferenceSettings: defaults}, nil
+	}
+
+	in := m.opts.DefaultProfileResolve
+	if !ref.IsDefault() {
+		in = gepprofiles.ResolveInput{}
+		if ref.Registry != "" {
+			in.RegistrySlug = gepprofiles.RegistrySlug(ref.Registry)
+		}
+		if ref.Profile != "" {
+			in.EngineProfileSlug = gepprofiles.EngineProfileSlug(ref.Profile)
+		}
+	}
+	resolved, err := registry.ResolveEngineProfile(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("resolve profile %q: %w", formatProfileRef(ref), err)
+	}
+	base := m.opts.BaseInferenceSettings
+	if base == nil {
+		base = m.opts.DefaultInferenceSettings
+	}
+	return infruntime.ResolveRuntimePlan(ctx, registry, resolved, infruntime.ResolveRuntimePlanOptions{
+		BaseInferenceSettings: base,
+	})
+}
+
+func (m *module) defaultInferenceSettings() (*aisettings.InferenceSettings, error) {
+	if m.opts.DefaultInferenceSettings == nil {
+		return nil, fmt.Errorf("pinocchio default inference settings are not configured")
+	}
+	ss := m.opts.DefaultInferenceSettings.Clone()
+	if ss == nil {
+		return nil, fmt.Errorf("pinocchio default inference settings are not available")
+	}
+	return ss, nil
+}
+
+// describeRuntimePlan is the JavaScript view of a resolved profile. API keys
+// are reported as present or absent only.
+func describeRuntimePlan(plan *infruntime.ResolvedRuntimePlan) map[string]any {
+	out := describeInferenceSettings(plan.InferenceSettings)
+	if plan.ResolvedProfile != nil {
+		out["registry"] = plan.ResolvedProfile.RegistrySlug.String()
+		out["profile"] = plan.ResolvedProfile.EngineProfileSlug.String()
+	}
+	out["profileVersion"] = plan.ProfileVersion
+	systemPrompt := ""
+	middlewares := []map[string]any{}
+	tools := []string{}
+	if plan.Runtime != nil {
+		systemPrompt = plan.Runtime.SystemPrompt
+		for _, use := range plan.Runtime.Middlewares {
+			mw := map[string]any{"name": use.Name}
+			if use.ID != "" {
+				mw["id"] = use.ID
+			}
+			if use.Enabled != nil {
+				mw["enabled"] = *use.Enabled
+			}
+			if len(use.Config) > 0 {
+				mw["config"] = use.Config
+			}
+			middlewares = append(middlewares, mw)
+		}
+		tools = append(tools, plan.Runtime.Tools...)
+	}
+	out["systemPrompt"] = systemPrompt
+	out["middlewares"] = middlewares
+	out["tools"] = tools
+	out["hasPricing"] = plan.Pricing != nil
+	return out
+}
+
+func formatProfileRef(ref profileRef) string {
+	switch {
+	case ref.IsDefault():
+		return "default"
+	case ref.Registry == "":
+		return ref.Profile
+	default:
+		return ref.Registry + "/" + ref.Profile
+	}
+}
@@ -0,0 +1,168 @@
+package pinocchio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/pinocchio/pkg/chatapp/export"
+	chatstore "github.com/go-go-golems/pinocchio/pkg/persistence/chatstore"
+)
+
+func (m *module) turnStore() (chatstore.TurnStore, error) {
+	if m.opts.TurnStore == nil {
+		return nil, fmt.Errorf("pinocchio turn store is not configured; pass --turns-backend or --turns-db")
+	}
+	return m.opts.TurnStore, nil
+}
+
+func parseTurnQuery(v any) (chatstore.TurnQuery, error) {
+	q := chatstore.TurnQuery{}
+	if v == nil {
+		return q, nil
+	}
+	if sid, ok := v.(string); ok {
+		q.ConvID = strings.TrimSpace(sid)
+		return q, nil
+	}
+	opts, ok := v.(map[string]any)
+	if !ok {
+		return q, fmt.Errorf("turn query must be a session id or an options object")
+	}
+	q.ConvID = strings.TrimSpace(asString(opts["convId"]))
+	q.SessionID = strings.TrimSpace(asString(opts["sessionId"]))
+	q.Phase = strings.TrimSpace(asString(opts["phase"]))
+	if sinceMs, ok := asPositiveInt(opts["sinceMs"]); ok {
+		q.SinceMs = int64(sinceMs)
+	}
+	if limit, ok := asPositiveInt(opts["limit"]); ok {
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+func (m *module) listTurns(ctx context.Context, q chatstore.TurnQuery) ([]map[string]any, error) {
+	store, err := m.turnStore()
+	if err != nil {
+		return nil, err
+	}
+	items, err := store.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		out = append(out, describeTurnSnapshot(item))
+	}
+	return out, nil
+}
+
+func (m *module) latestTurn(ctx context.Context, q chatstore.TurnQuery) (map[string]any, error) {
+	store, err := m.turnStore()
+	if err != nil {
+		return nil, err
+	}
+	convID := q.ConvID
+	if convID == "" {
+		convID = q.SessionID
+	}
+	if convID == "" {
+		return nil, fmt.Errorf("pinocchio.turns.latest requires convId or sessionId")
+	}
+	phase := q.Phase
+	if phase == "" {
+		phase = "final"
+	}
+	item, err := store.LoadLatestTurn(ctx, convID, phase)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return describeTurnSnapshot(*item), nil
+}
+
+func describeTurnSnapshot(item chatstore.TurnSnapshot) map[string]any {
+	return map[string]any{
+		"convId":      item.ConvID,
+		"sessionId":   item.SessionID,
+		"turnId":      item.TurnID,
+		"phase":       item.Phase,
+		"runtimeKey":  item.RuntimeKey,
+		"inferenceId": item.InferenceID,
+		"createdAtMs": item.CreatedAtMs,
+		"payload":     item.Payload,
+	}
+}
+
+func parseExportOptions(v any) (export.Options, error) {
+	out := export.Options{}
+	if v == nil {
+		return out, nil
+	}
+	opts, ok := v.(map[string]any)
+	if !ok {
+		return out, fmt.Errorf("export options must be an object")
+	}
+	out.Format = export.Format(asString(opts["format"]))
+	out.View = export.TimelineView(asString(opts["view"]))
+	out.TurnPhase = asString(opts["phase"])
+	if limit, ok := asPositiveInt(opts["limit"]); ok {
+		out.Limit = limit
+	}
+	if latestOnly, ok := opts["latestOnly"].(bool); ok {
+		out.LatestOnly = latestOnly
+	}
+	return out.Normalized()
+}
+
+// exportTimeline exports the timeline of a session opened by
+// pinocchio.chat.session in this runtime.
+func (m *module) exportTimeline(ctx context.Context, sessionID string, opts export.Options) (any, error) {
+	session, ok := m.lookupSession(sessionID)
+	if !ok {
+		return nil, fmt.Errorf("chat session %q is not open in this runtime", sessionID)
+	}
+	if opts.Format == export.FormatMinitrace {
+		return nil, fmt.Errorf("minitrace export is only available for turns")
+	}
+	timeline, err := export.NewService(session.runner.Service).ExportTimeline(ctx, sessionID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return renderExport(timeline, opts.Format)
+}
+
+func (m *module) exportTurns(ctx context.Context, sessionID string, opts export.Options) (any, error) {
+	if opts.Format == export.FormatMinitrace {
+		service := export.NewService(nil, export.WithTurnsDBPath(m.opts.TurnsDBPath))
+		trace, err := service.ExportTurnsMinitrace(ctx, sessionID, opts)
+		if err != nil {
+			return nil, err
+		}
+		return renderExport(trace, export.FormatJSON)
+	}
+	store, err := m.turnStore()
+	if err != nil {
+		return nil, err
+	}
+	turns, err := export.NewService(nil, export.WithTurnStore(store)).ExportTurns(ctx, sessionID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return renderExport(turns, opts.Format)
+}
+
+// renderExport returns JSON exports as plain objects and every other format
+// as its rendered text.
+func renderExport(value any, format export.Format) (any, error) {
+	if format == export.FormatJSON {
+		if m, ok := value.(map[string]any); ok {
+			return m, nil
+		}
+		return toPlainMap(value)
+	}
+	rendered, err := export.Render(value, format)
+	if err != nil {
+		return nil, err
+	}
+	return string(rendered.Body), nil
+}
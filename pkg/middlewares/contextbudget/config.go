@@ -0,0 +1,88 @@
+package contextbudget
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// StrategyDropOldest removes the oldest unprotected blocks until the turn
+	// fits the budget.
+	StrategyDropOldest = "drop_oldest"
+	// StrategySummarize replaces the oldest unprotected blocks with one
+	// LLM-generated summary block.
+	StrategySummarize = "summarize"
+)
+
+const (
+	DefaultKeepRecentBlocks = 4
+	DefaultSummaryTokens    = 512
+	DefaultSummaryPrompt    = `You compress chat history. Summarize the conversation excerpt below so it can replace the original messages in the model's context.
+Keep facts, decisions, constraints, names, numbers, file paths, open questions and tool results that later turns may rely on.
+Write compact plain prose in the language of the conversation. Do not add a preamble or address the user.`
+)
+
+// Config bounds the size of the turn sent to the provider.
+type Config struct {
+	// MaxTokens is the budget for the whole turn, as counted by the Counter.
+	MaxTokens int
+	// Strategy is StrategyDropOldest or StrategySummarize.
+	Strategy string
+	// KeepSystem keeps system blocks regardless of their position.
+	KeepSystem bool
+	// KeepRecentBlocks keeps the newest blocks, so the pending prompt and the
+	// last exchange always survive compaction.
+	KeepRecentBlocks int
+	// SummaryTokens is the room reserved for the summary block when choosing
+	// how much history to summarize.
+	SummaryTokens int
+	// SummaryPrompt is the system prompt of the summarization call.
+	SummaryPrompt string
+}
+
+// DefaultConfig returns a drop-oldest config for maxTokens.
+func DefaultConfig(maxTokens int) Config {
+	return Config{
+		MaxTokens:        maxTokens,
+		Strategy:         StrategyDropOldest,
+		KeepSystem:       true,
+		KeepRecentBlocks: DefaultKeepRecentBlocks,
+		SummaryTokens:    DefaultSummaryTokens,
+		SummaryPrompt:    DefaultSummaryPrompt,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	c.Strategy = strings.TrimSpace(c.Strategy)
+	if c.Strategy == "" {
+		c.Strategy = StrategyDropOldest
+	}
+	if c.KeepRecentBlocks < 0 {
+		c.KeepRecentBlocks = 0
+	}
+	if c.SummaryTokens <= 0 {
+		c.SummaryTokens = DefaultSummaryTokens
+	}
+	if strings.TrimSpace(c.SummaryPrompt) == "" {
+		c.SummaryPrompt = DefaultSummaryPrompt
+	}
+	return c
+}
+
+// Validate reports configuration errors before the middleware is built.
+func (c Config) Validate() error {
+	c = c.withDefaults()
+	if c.MaxTokens <= 0 {
+		return fmt.Errorf("context budget max_tokens must be positive")
+	}
+	switch c.Strategy {
+	case StrategyDropOldest:
+	case StrategySummarize:
+		if c.SummaryTokens >= c.MaxTokens {
+			return fmt.Errorf("context budget summary_tokens (%d) must be smaller than max_tokens (%d)", c.SummaryTokens, c.MaxTokens)
+		}
+	default:
+		return fmt.Errorf("context budget strategy must be %s or %s, not %q", StrategyDropOldest, StrategySummarize, c.Strategy)
+	}
+	return nil
+}
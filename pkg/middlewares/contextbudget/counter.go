@@ -0,0 +1,108 @@
+package contextbudget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Counter measures the input tokens of a turn.
+type Counter interface {
+	CountTurn(ctx context.Context, t *turns.Turn) (int, error)
+}
+
+// CounterFunc adapts a function to Counter, e.g. a provider token-count API.
+type CounterFunc func(ctx context.Context, t *turns.Turn) (int, error)
+
+func (f CounterFunc) CountTurn(ctx context.Context, t *turns.Turn) (int, error) {
+	return f(ctx, t)
+}
+
+// blockOverheadTokens approximates the per-message framing providers add.
+const blockOverheadTokens = 4
+
+// payloadKeyImages holds image attachments, which are not text tokens.
+const payloadKeyImages = "images"
+
+// EstimateCounter counts tokens locally with a tiktoken encoding. It does not
+// know provider-specific framing, so treat its numbers as estimates.
+type EstimateCounter struct {
+	codec tokenizer.Codec
+}
+
+// NewEstimateCounter creates a local counter for encoding, e.g. cl100k_base
+// or o200k_base. An empty encoding uses cl100k_base.
+func NewEstimateCounter(encoding string) (*EstimateCounter, error) {
+	encoding = strings.TrimSpace(encoding)
+	if encoding == "" {
+		encoding = string(tokenizer.Cl100kBase)
+	}
+	codec, err := tokenizer.Get(tokenizer.Encoding(encoding))
+	if err != nil {
+		return nil, fmt.Errorf("context budget encoding %q: %w", encoding, err)
+	}
+	return &EstimateCounter{codec: codec}, nil
+}
+
+func (c *EstimateCounter) CountTurn(_ context.Context, t *turns.Turn) (int, error) {
+	if t == nil {
+		return 0, nil
+	}
+	total := 0
+	for _, block := range t.Blocks {
+		n, err := c.CountBlock(block)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// CountBlock counts one block, including its framing overhead. Image
+// attachments are not counted.
+func (c *EstimateCounter) CountBlock(block turns.Block) (int, error) {
+	keys := make([]string, 0, len(block.Payload))
+	for key := range block.Payload {
+		if key != payloadKeyImages {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	total := blockOverheadTokens
+	for _, key := range keys {
+		text, err := payloadText(block.Payload[key])
+		if err != nil {
+			return 0, fmt.Errorf("count block %s payload %s: %w", block.ID, key, err)
+		}
+		if text == "" {
+			continue
+		}
+		n, err := c.codec.Count(text)
+		if err != nil {
+			return 0, fmt.Errorf("count block %s payload %s: %w", block.ID, key, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func payloadText(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
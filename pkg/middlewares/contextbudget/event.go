@@ -0,0 +1,35 @@
+package contextbudget
+
+import gepevents "github.com/go-go-golems/geppetto/pkg/events"
+
+const EventTypeContextCompacted gepevents.EventType = "context-compacted"
+
+// EventContextCompacted is published after the middleware shrank a turn. It
+// carries counts and the summary text, never the removed blocks.
+type EventContextCompacted struct {
+	gepevents.EventImpl
+	Strategy       string `json:"strategy"`
+	BudgetTokens   int    `json:"budget_tokens"`
+	TokensBefore   int    `json:"tokens_before"`
+	TokensAfter    int    `json:"tokens_after"`
+	BlocksRemoved  int    `json:"blocks_removed"`
+	Summary        string `json:"summary,omitempty"`
+	SummaryBlockID string `json:"summary_block_id,omitempty"`
+	OverBudget     bool   `json:"over_budget,omitempty"`
+}
+
+func NewContextCompactedEvent(metadata gepevents.EventMetadata, result Result) *EventContextCompacted {
+	return &EventContextCompacted{
+		EventImpl:      gepevents.EventImpl{Type_: EventTypeContextCompacted, Metadata_: metadata},
+		Strategy:       result.Strategy,
+		BudgetTokens:   result.BudgetTokens,
+		TokensBefore:   result.TokensBefore,
+		TokensAfter:    result.TokensAfter,
+		BlocksRemoved:  result.BlocksRemoved,
+		Summary:        result.Summary,
+		SummaryBlockID: result.SummaryBlockID,
+		OverBudget:     result.OverBudget,
+	}
+}
+
+var _ gepevents.Event = (*EventContextCompacted)(nil)
@@ -0,0 +1,272 @@
+// Package contextbudget keeps a turn under a token budget before it reaches
+// the provider, by dropping or summarizing the oldest history.
+package contextbudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	rootmw "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/google/uuid"
+)
+
+var (
+	// KeyBlockMetaPinned marks a block that compaction never removes.
+	KeyBlockMetaPinned = turns.BlockMetaK[bool]("pinocchio", "context_pinned", 1)
+	// KeyBlockMetaSummary marks a summary block inserted by compaction. A
+	// later compaction may fold it into a newer summary.
+	KeyBlockMetaSummary = turns.BlockMetaK[bool]("pinocchio", "context_summary", 1)
+)
+
+// Tool block payload keys, as written by the Geppetto tool loop.
+const (
+	payloadKeyID     = "id"
+	payloadKeyName   = "name"
+	payloadKeyArgs   = "args"
+	payloadKeyResult = "result"
+	payloadKeyError  = "error"
+)
+
+const summaryHeader = "Summary of the earlier conversation:\n\n"
+
+// Result describes one compaction.
+type Result struct {
+	Strategy       string
+	BudgetTokens   int
+	TokensBefore   int
+	TokensAfter    int
+	BlocksRemoved  int
+	Summary        string
+	SummaryBlockID string
+	// OverBudget is set when the protected blocks alone, or the summary,
+	// still exceed the budget.
+	OverBudget bool
+}
+
+// Compactor shrinks turns that exceed the configured budget.
+type Compactor struct {
+	cfg        Config
+	counter    Counter
+	summarizer Summarizer
+}
+
+// NewCompactor validates cfg. summarizer is only required for
+// StrategySummarize.
+func NewCompactor(cfg Config, counter Counter, summarizer Summarizer) (*Compactor, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	cfg = cfg.withDefaults()
+	if counter == nil {
+		return nil, errors.New("context budget requires a token counter")
+	}
+	if cfg.Strategy == StrategySummarize && summarizer == nil {
+		return nil, errors.New("context budget strategy summarize requires a summarizer")
+	}
+	return &Compactor{cfg: cfg, counter: counter, summarizer: summarizer}, nil
+}
+
+// Compact rewrites t.Blocks in place when the turn is over budget. It
+// reports whether anything was removed.
+func (c *Compactor) Compact(ctx context.Context, t *turns.Turn) (Result, bool, error) {
+	if t == nil || len(t.Blocks) == 0 {
+		return Result{}, false, nil
+	}
+	before, err := c.count(ctx, t, t.Blocks)
+	if err != nil {
+		return Result{}, false, err
+	}
+	if before <= c.cfg.MaxTokens {
+		return Result{}, false, nil
+	}
+	result := Result{Strategy: c.cfg.Strategy, BudgetTokens: c.cfg.MaxTokens, TokensBefore: before, TokensAfter: before}
+
+	target := c.cfg.MaxTokens
+	if c.cfg.Strategy == StrategySummarize {
+		target -= c.cfg.SummaryTokens
+	}
+	units := candidateUnits(t.Blocks, c.protected(t.Blocks))
+	removed := make([]bool, len(t.Blocks))
+	kept := t.Blocks
+	dropped := 0
+	for dropped < len(units) && result.TokensAfter > target {
+		for _, i := range units[dropped] {
+			removed[i] = true
+			result.BlocksRemoved++
+		}
+		dropped++
+		kept = keepBlocks(t.Blocks, removed)
+		if result.TokensAfter, err = c.count(ctx, t, kept); err != nil {
+			return Result{}, false, err
+		}
+	}
+	if dropped == 0 {
+		log.Warn().Str("turn_id", t.ID).Int("tokens", before).Int("budget", c.cfg.MaxTokens).Msg("context budget: only protected blocks remain; sending the turn over budget")
+		return Result{}, false, nil
+	}
+
+	if c.cfg.Strategy == StrategySummarize {
+		kept, err = c.summarize(ctx, t, removed, kept, &result)
+		if err != nil {
+			return Result{}, false, err
+		}
+	}
+	result.OverBudget = result.TokensAfter > c.cfg.MaxTokens
+	t.Blocks = kept
+	return result, true, nil
+}
+
+// summarize replaces the removed span with one summary block at the position
+// of its first block. When the summary call fails the span stays dropped.
+func (c *Compactor) summarize(ctx context.Context, t *turns.Turn, removed []bool, kept []turns.Block, result *Result) ([]turns.Block, error) {
+	span := make([]turns.Block, 0, result.BlocksRemoved)
+	insertAt := -1
+	keptBefore := 0
+	for i, block := range t.Blocks {
+		if !removed[i] {
+			if insertAt < 0 {
+				keptBefore++
+			}
+			continue
+		}
+		if insertAt < 0 {
+			insertAt = keptBefore
+		}
+		span = append(span, block)
+	}
+	summary, err := c.summarizer.Summarize(ctx, span)
+	if err != nil {
+		log.Warn().Err(err).Str("turn_id", t.ID).Msg("context budget: summary failed; dropping the span instead")
+		result.Strategy = StrategyDropOldest
+		return kept, nil
+	}
+	block := turns.NewUserTextBlock(summaryHeader + summary)
+	block.ID = uuid.NewString()
+	if err := KeyBlockMetaSummary.Set(&block.Metadata, true); err != nil {
+		return nil, fmt.Errorf("set context summary block metadata: %w", err)
+	}
+	withSummary := make([]turns.Block, 0, len(kept)+1)
+	withSummary = append(withSummary, kept[:insertAt]...)
+	withSummary = append(withSummary, block)
+	withSummary = append(withSummary, kept[insertAt:]...)
+	if result.TokensAfter, err = c.count(ctx, t, withSummary); err != nil {
+		return nil, err
+	}
+	result.Summary = summary
+	result.SummaryBlockID = block.ID
+	return withSummary, nil
+}
+
+func (c *Compactor) count(ctx context.Context, t *turns.Turn, blocks []turns.Block) (int, error) {
+	candidate := *t
+	candidate.Blocks = blocks
+	n, err := c.counter.CountTurn(ctx, &candidate)
+	if err != nil {
+		return 0, fmt.Errorf("count context tokens: %w", err)
+	}
+	return n, nil
+}
+
+// protected marks system, pinned and recent blocks, plus the other half of
+// any tool call/result pair one of them belongs to, so providers never see an
+// orphaned tool call or result.
+func (c *Compactor) protected(blocks []turns.Block) []bool {
+	protected := make([]bool, len(blocks))
+	toolIDs := map[string]bool{}
+	for i, block := range blocks {
+		pinned, ok, err := KeyBlockMetaPinned.Get(block.Metadata)
+		switch {
+		case c.cfg.KeepSystem && block.Kind == turns.BlockKindSystem,
+			err == nil && ok && pinned,
+			i >= len(blocks)-c.cfg.KeepRecentBlocks:
+			protected[i] = true
+			if id := toolBlockID(block); id != "" {
+				toolIDs[id] = true
+			}
+		}
+	}
+	for i, block := range blocks {
+		if id := toolBlockID(block); id != "" && toolIDs[id] {
+			protected[i] = true
+		}
+	}
+	return protected
+}
+
+// candidateUnits groups the unprotected blocks, oldest first, so that a tool
+// call and its result are always removed together.
+func candidateUnits(blocks []turns.Block, protected []bool) [][]int {
+	units := [][]int{}
+	unitByToolID := map[string]int{}
+	for i, block := range blocks {
+		if protected[i] {
+			continue
+		}
+		if id := toolBlockID(block); id != "" {
+			if unit, ok := unitByToolID[id]; ok {
+				units[unit] = append(units[unit], i)
+				continue
+			}
+			unitByToolID[id] = len(units)
+		}
+		units = append(units, []int{i})
+	}
+	return units
+}
+
+func toolBlockID(block turns.Block) string {
+	if block.Kind != turns.BlockKindToolCall && block.Kind != turns.BlockKindToolUse {
+		return ""
+	}
+	id, _ := block.Payload[payloadKeyID].(string)
+	return id
+}
+
+func keepBlocks(blocks []turns.Block, removed []bool) []turns.Block {
+	kept := make([]turns.Block, 0, len(blocks))
+	for i, block := range blocks {
+		if !removed[i] {
+			kept = append(kept, block)
+		}
+	}
+	return kept
+}
+
+// NewMiddleware compacts each turn before inference and publishes an
+// EventContextCompacted when it did. Because the compacted turn is what gets
+// persisted, resumed sessions start from the compacted history.
+func NewMiddleware(c *Compactor) rootmw.Middleware {
+	return func(next rootmw.HandlerFunc) rootmw.HandlerFunc {
+		return func(ctx context.Context, t *turns.Turn) (*turns.Turn, error) {
+			if c == nil || t == nil {
+				return next(ctx, t)
+			}
+			result, compacted, err := c.Compact(ctx, t)
+			if err != nil {
+				return nil, err
+			}
+			if compacted {
+				log.Debug().
+					Str("turn_id", t.ID).
+					Str("strategy", result.Strategy).
+					Int("tokens_before", result.TokensBefore).
+					Int("tokens_after", result.TokensAfter).
+					Int("blocks_removed", result.BlocksRemoved).
+					Msg("context budget: compacted turn")
+				meta := events.EventMetadata{ID: uuid.New(), SessionID: sessionIDFromTurn(t), TurnID: t.ID}
+				events.PublishEventToContext(ctx, NewContextCompactedEvent(meta, result))
+			}
+			return next(ctx, t)
+		}
+	}
+}
+
+func sessionIDFromTurn(t *turns.Turn) string {
+	if sid, ok, err := turns.KeyTurnMetaSessionID.Get(t.Metadata); err == nil && ok {
+		return sid
+	}
+	return ""
+}
@@ -0,0 +1,243 @@
+package contextbudget
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-go-golems/geppetto/pkg/events"
+	rootmw "github.com/go-go-golems/geppetto/pkg/inference/middleware"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/stretchr/testify/require"
+)
+
+// blockCounter charges 10 tokens per block so tests can reason about budgets
+// without depending on a tokenizer.
+var blockCounter = CounterFunc(func(_ context.Context, t *turns.Turn) (int, error) {
+	return 10 * len(t.Blocks), nil
+})
+
+func textBlock(kind turns.BlockKind, id, text string) turns.Block {
+	return turns.Block{ID: id, Kind: kind, Payload: map[string]any{turns.PayloadKeyText: text}}
+}
+
+func toolBlock(kind turns.BlockKind, id, toolID string) turns.Block {
+	return turns.Block{ID: id, Kind: kind, Payload: map[string]any{payloadKeyID: toolID, payloadKeyName: "search"}}
+}
+
+func blockIDs(blocks []turns.Block) []string {
+	ids := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		ids = append(ids, block.ID)
+	}
+	return ids
+}
+
+func history() *turns.Turn {
+	return &turns.Turn{ID: "turn-1", Blocks: []turns.Block{
+		textBlock(turns.BlockKindSystem, "sys", "be brief"),
+		textBlock(turns.BlockKindUser, "u1", "first question"),
+		textBlock(turns.BlockKindLLMText, "a1", "first answer"),
+		toolBlock(turns.BlockKindToolCall, "call", "tc-1"),
+		toolBlock(turns.BlockKindToolUse, "use", "tc-1"),
+		textBlock(turns.BlockKindLLMText, "a2", "second answer"),
+		textBlock(turns.BlockKindUser, "u2", "latest question"),
+	}}
+}
+
+func TestCompact_UnderBudgetIsUntouched(t *testing.T) {
+	c, err := NewCompactor(DefaultConfig(100), blockCounter, nil)
+	require.NoError(t, err)
+
+	turn := history()
+	_, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.False(t, compacted)
+	require.Len(t, turn.Blocks, 7)
+}
+
+func TestCompact_DropOldestKeepsSystemAndRecent(t *testing.T) {
+	cfg := DefaultConfig(40)
+	cfg.KeepRecentBlocks = 1
+	c, err := NewCompactor(cfg, blockCounter, nil)
+	require.NoError(t, err)
+
+	turn := history()
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.Equal(t, []string{"sys", "a2", "u2"}, blockIDs(turn.Blocks))
+	require.Equal(t, 70, result.TokensBefore)
+	require.Equal(t, 30, result.TokensAfter)
+	require.Equal(t, 4, result.BlocksRemoved)
+	require.False(t, result.OverBudget)
+}
+
+func TestCompact_NeverSplitsToolPairs(t *testing.T) {
+	cfg := DefaultConfig(50)
+	cfg.KeepRecentBlocks = 3
+	c, err := NewCompactor(cfg, blockCounter, nil)
+	require.NoError(t, err)
+
+	// The recent window starts at the tool result, so its call is kept too.
+	turn := history()
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.Equal(t, []string{"sys", "call", "use", "a2", "u2"}, blockIDs(turn.Blocks))
+	require.Equal(t, 2, result.BlocksRemoved)
+}
+
+func TestCompact_PinnedBlocksSurvive(t *testing.T) {
+	cfg := DefaultConfig(30)
+	cfg.KeepRecentBlocks = 1
+	c, err := NewCompactor(cfg, blockCounter, nil)
+	require.NoError(t, err)
+
+	turn := history()
+	require.NoError(t, KeyBlockMetaPinned.Set(&turn.Blocks[1].Metadata, true))
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.Equal(t, []string{"sys", "u1", "u2"}, blockIDs(turn.Blocks))
+	require.False(t, result.OverBudget)
+}
+
+func TestCompact_ReportsOverBudgetWhenOnlyProtectedBlocksRemain(t *testing.T) {
+	cfg := DefaultConfig(20)
+	cfg.KeepRecentBlocks = 3
+	c, err := NewCompactor(cfg, blockCounter, nil)
+	require.NoError(t, err)
+
+	turn := history()
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.True(t, result.OverBudget)
+	require.Equal(t, 50, result.TokensAfter)
+}
+
+func TestCompact_SummarizeReplacesSpanInPlace(t *testing.T) {
+	cfg := DefaultConfig(50)
+	cfg.Strategy = StrategySummarize
+	cfg.KeepRecentBlocks = 2
+	cfg.SummaryTokens = 10
+	var span []turns.Block
+	summarizer := SummarizerFunc(func(_ context.Context, blocks []turns.Block) (string, error) {
+		span = blocks
+		return "asked and answered", nil
+	})
+	c, err := NewCompactor(cfg, blockCounter, summarizer)
+	require.NoError(t, err)
+
+	turn := history()
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.Equal(t, []string{"u1", "a1", "call", "use"}, blockIDs(span))
+	require.Len(t, turn.Blocks, 4)
+	require.Equal(t, "sys", turn.Blocks[0].ID)
+	require.Equal(t, result.SummaryBlockID, turn.Blocks[1].ID)
+	isSummary, ok, err := KeyBlockMetaSummary.Get(turn.Blocks[1].Metadata)
+	require.NoError(t, err)
+	require.True(t, ok && isSummary)
+	require.Equal(t, summaryHeader+"asked and answered", turn.Blocks[1].Payload[turns.PayloadKeyText])
+	require.Equal(t, StrategySummarize, result.Strategy)
+	require.Equal(t, 40, result.TokensAfter)
+}
+
+func TestCompact_SummaryFailureFallsBackToDropping(t *testing.T) {
+	cfg := DefaultConfig(50)
+	cfg.Strategy = StrategySummarize
+	cfg.KeepRecentBlocks = 2
+	cfg.SummaryTokens = 10
+	summarizer := SummarizerFunc(func(context.Context, []turns.Block) (string, error) {
+		return "", errors.New("provider down")
+	})
+	c, err := NewCompactor(cfg, blockCounter, summarizer)
+	require.NoError(t, err)
+
+	turn := history()
+	result, compacted, err := c.Compact(context.Background(), turn)
+	require.NoError(t, err)
+	require.True(t, compacted)
+	require.Equal(t, StrategyDropOldest, result.Strategy)
+	require.Equal(t, []string{"sys", "a2", "u2"}, blockIDs(turn.Blocks))
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.Error(t, Config{}.Validate())
+	require.Error(t, Config{MaxTokens: 100, Strategy: "lru"}.Validate())
+	require.Error(t, Config{MaxTokens: 100, Strategy: StrategySummarize, SummaryTokens: 100}.Validate())
+	require.NoError(t, Config{MaxTokens: 100}.Validate())
+
+	_, err := NewCompactor(Config{MaxTokens: 100, Strategy: StrategySummarize, SummaryTokens: 10}, blockCounter, nil)
+	require.Error(t, err)
+}
+
+type recordingSink struct {
+	events []events.Event
+}
+
+func (s *recordingSink) PublishEvent(event events.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestNewMiddleware_PublishesCompactionEvent(t *testing.T) {
+	cfg := DefaultConfig(40)
+	cfg.KeepRecentBlocks = 1
+	c, err := NewCompactor(cfg, blockCounter, nil)
+	require.NoError(t, err)
+
+	sink := &recordingSink{}
+	ctx := events.WithEventSinks(context.Background(), sink)
+	var seen []string
+	next := rootmw.HandlerFunc(func(_ context.Context, t *turns.Turn) (*turns.Turn, error) {
+		seen = blockIDs(t.Blocks)
+		return t, nil
+	})
+
+	turn := history()
+	require.NoError(t, turns.KeyTurnMetaSessionID.Set(&turn.Metadata, "session-1"))
+	_, err = NewMiddleware(c)(next)(ctx, turn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sys", "a2", "u2"}, seen)
+	require.Len(t, sink.events, 1)
+	ev, ok := sink.events[0].(*EventContextCompacted)
+	require.True(t, ok)
+	require.Equal(t, "session-1", ev.Metadata().SessionID)
+	require.Equal(t, "turn-1", ev.Metadata().TurnID)
+	require.Equal(t, 4, ev.BlocksRemoved)
+}
+
+func TestEstimateCounter_CountsTextAndToolPayloads(t *testing.T) {
+	counter, err := NewEstimateCounter("")
+	require.NoError(t, err)
+
+	short, err := counter.CountTurn(context.Background(), &turns.Turn{Blocks: []turns.Block{textBlock(turns.BlockKindUser, "u", "hi")}})
+	require.NoError(t, err)
+	long, err := counter.CountTurn(context.Background(), &turns.Turn{Blocks: []turns.Block{textBlock(turns.BlockKindUser, "u", strings.Repeat("hello world ", 50))}})
+	require.NoError(t, err)
+	require.Greater(t, short, blockOverheadTokens)
+	require.Greater(t, long, short)
+
+	_, err = NewEstimateCounter("nope")
+	require.Error(t, err)
+}
+
+func TestTranscript_RendersRolesAndTools(t *testing.T) {
+	summary := turns.NewUserTextBlock(summaryHeader + "old stuff")
+	require.NoError(t, KeyBlockMetaSummary.Set(&summary.Metadata, true))
+	out := Transcript([]turns.Block{
+		summary,
+		textBlock(turns.BlockKindUser, "u1", "question"),
+		toolBlock(turns.BlockKindToolCall, "call", "tc-1"),
+		textBlock(turns.BlockKindLLMText, "a1", "answer"),
+	})
+	require.Contains(t, out, "Earlier summary: old stuff")
+	require.Contains(t, out, "User: question")
+	require.Contains(t, out, "Assistant called tool search")
+	require.Contains(t, out, "Assistant: answer")
+}
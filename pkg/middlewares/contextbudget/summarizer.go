@@ -0,0 +1,121 @@
+package contextbudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/turns"
+)
+
+// Summarizer condenses a span of history into text that replaces it.
+type Summarizer interface {
+	Summarize(ctx context.Context, blocks []turns.Block) (string, error)
+}
+
+// SummarizerFunc adapts a function to Summarizer.
+type SummarizerFunc func(ctx context.Context, blocks []turns.Block) (string, error)
+
+func (f SummarizerFunc) Summarize(ctx context.Context, blocks []turns.Block) (string, error) {
+	return f(ctx, blocks)
+}
+
+// EngineSummarizer asks an inference engine for the summary.
+type EngineSummarizer struct {
+	engine engine.Engine
+	prompt string
+}
+
+// NewEngineSummarizer creates a summarizer that sends the span as a
+// transcript to eng, with prompt as the system prompt.
+func NewEngineSummarizer(eng engine.Engine, prompt string) *EngineSummarizer {
+	if strings.TrimSpace(prompt) == "" {
+		prompt = DefaultSummaryPrompt
+	}
+	return &EngineSummarizer{engine: eng, prompt: prompt}
+}
+
+func (s *EngineSummarizer) Summarize(ctx context.Context, blocks []turns.Block) (string, error) {
+	if s == nil || s.engine == nil {
+		return "", errors.New("context budget summarizer has no engine")
+	}
+	// The summary call must not stream into the conversation's event sinks,
+	// which are carried on ctx, so it runs on a fresh context that only
+	// inherits cancellation.
+	summaryCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	request := &turns.Turn{Blocks: []turns.Block{
+		turns.NewSystemTextBlock(s.prompt),
+		turns.NewUserTextBlock(Transcript(blocks)),
+	}}
+	result, err := s.engine.RunInference(summaryCtx, request)
+	if err != nil {
+		return "", fmt.Errorf("summarize context: %w", err)
+	}
+	if result == nil {
+		return "", errors.New("summarize context: engine returned no turn")
+	}
+	for i := len(result.Blocks) - 1; i >= 0; i-- {
+		block := result.Blocks[i]
+		if block.Kind != turns.BlockKindLLMText {
+			continue
+		}
+		if text, _ := block.Payload[turns.PayloadKeyText].(string); strings.TrimSpace(text) != "" {
+			return strings.TrimSpace(text), nil
+		}
+	}
+	return "", errors.New("summarize context: engine returned no text")
+}
+
+// Transcript renders blocks as the plain-text conversation excerpt given to
+// the summarizer.
+func Transcript(blocks []turns.Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		line := transcriptLine(block)
+		if line == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func transcriptLine(block turns.Block) string {
+	text, _ := block.Payload[turns.PayloadKeyText].(string)
+	switch block.Kind {
+	case turns.BlockKindSystem:
+		return "System: " + text
+	case turns.BlockKindUser:
+		if summary, ok, _ := KeyBlockMetaSummary.Get(block.Metadata); ok && summary {
+			return "Earlier summary: " + strings.TrimPrefix(text, summaryHeader)
+		}
+		return "User: " + text
+	case turns.BlockKindLLMText:
+		return "Assistant: " + text
+	case turns.BlockKindToolCall:
+		args, _ := payloadText(block.Payload[payloadKeyArgs])
+		return fmt.Sprintf("Assistant called tool %v with %s", block.Payload[payloadKeyName], args)
+	case turns.BlockKindToolUse:
+		result, _ := payloadText(block.Payload[payloadKeyResult])
+		if errText, _ := block.Payload[payloadKeyError].(string); errText != "" {
+			return fmt.Sprintf("Tool %v failed: %s", block.Payload[payloadKeyID], errText)
+		}
+		return fmt.Sprintf("Tool %v returned: %s", block.Payload[payloadKeyID], result)
+	case turns.BlockKindReasoning:
+		return ""
+	default:
+		if text == "" {
+			return ""
+		}
+		return block.Kind.String() + ": " + text
+	}
+}
@@ -0,0 +1,320 @@
+package oauthprofiles
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+)
+
+// Credential store kinds accepted in credential_store.kind.
+const (
+	// StoreKindRegistry keeps the tuple in the profile's direct YAML registry.
+	StoreKindRegistry = "registry"
+	// StoreKindFile keeps tuples of many profiles in one owner-only YAML file
+	// keyed by registry/profile.
+	StoreKindFile = "file"
+	// StoreKindEncryptedFile keeps tuples in a passphrase-encrypted file.
+	StoreKindEncryptedFile = "encrypted_file"
+	// StoreKindExec delegates to an external credential helper.
+	StoreKindExec = "exec"
+)
+
+// CredentialStore persists one OAuth profile's credential tuple. Every
+// implementation is bound to one registry/profile and one provider/base URL
+// pair, and never includes token material in errors.
+type CredentialStore interface {
+	credentials.Store
+	credentials.Deleter
+	// Kind returns one of the StoreKind constants, for secret-free
+	// diagnostics.
+	Kind() string
+}
+
+var _ CredentialStore = (*YAMLStore)(nil)
+
+// StoreConfig is the credential_store mapping of the OAuth extension.
+type StoreConfig struct {
+	Kind    string
+	Path    string
+	Command []string
+}
+
+// EffectiveKind returns Kind, defaulting to StoreKindRegistry.
+func (c StoreConfig) EffectiveKind() string {
+	if c.Kind == "" {
+		return StoreKindRegistry
+	}
+	return c.Kind
+}
+
+// Map returns the extension form of the config, for writing it back to a
+// profile.
+func (c StoreConfig) Map() map[string]any {
+	ret := map[string]any{"kind": c.EffectiveKind()}
+	if c.Path != "" {
+		ret["path"] = c.Path
+	}
+	if len(c.Command) > 0 {
+		command := make([]any, len(c.Command))
+		for i := range c.Command {
+			command[i] = c.Command[i]
+		}
+		ret["command"] = command
+	}
+	return ret
+}
+
+// Validate checks the fields each kind needs.
+func (c StoreConfig) Validate() error {
+	switch c.EffectiveKind() {
+	case StoreKindRegistry:
+		if c.Path != "" || len(c.Command) > 0 {
+			return errors.New("OAuth credential_store kind registry takes no path or command")
+		}
+	case StoreKindFile, StoreKindEncryptedFile:
+		if len(c.Command) > 0 {
+			return fmt.Errorf("OAuth credential_store kind %s takes no command", c.Kind)
+		}
+	case StoreKindExec:
+		if len(c.Command) == 0 {
+			return errors.New("OAuth credential_store kind exec requires a command")
+		}
+		if c.Path != "" {
+			return errors.New("OAuth credential_store kind exec takes no path")
+		}
+	default:
+		return fmt.Errorf("OAuth credential_store kind must be registry, file, encrypted_file or exec, not %q", c.Kind)
+	}
+	return nil
+}
+
+func parseStoreConfig(value any) (StoreConfig, error) {
+	if value == nil {
+		return StoreConfig{}, nil
+	}
+	raw, ok := stringAnyMap(value)
+	if !ok {
+		return StoreConfig{}, errors.New("OAuth profile credential_store must be a mapping")
+	}
+	config := StoreConfig{}
+	var err error
+	if config.Kind, err = storeString(raw, "kind"); err != nil {
+		return StoreConfig{}, err
+	}
+	if config.Path, err = storeString(raw, "path"); err != nil {
+		return StoreConfig{}, err
+	}
+	if config.Command, err = optionalCommand(raw["command"]); err != nil {
+		return StoreConfig{}, err
+	}
+	if err := config.Validate(); err != nil {
+		return StoreConfig{}, err
+	}
+	return config, nil
+}
+
+func storeString(raw map[string]any, key string) (string, error) {
+	value, exists := raw[key]
+	if !exists || value == nil {
+		return "", nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("OAuth profile credential_store.%s must be a string", key)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+func optionalCommand(value any) ([]string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		fields := strings.Fields(typed)
+		if len(fields) == 0 {
+			return nil, nil
+		}
+		return fields, nil
+	case []any:
+		ret := make([]string, 0, len(typed))
+		for i, item := range typed {
+			text, ok := item.(string)
+			if !ok || strings.TrimSpace(text) == "" {
+				return nil, fmt.Errorf("OAuth profile credential_store.command[%d] must be a non-empty string", i)
+			}
+			ret = append(ret, text)
+		}
+		return ret, nil
+	case []string:
+		return append([]string(nil), typed...), nil
+	default:
+		return nil, errors.New("OAuth profile credential_store.command must be a string or a list of strings")
+	}
+}
+
+// StoreOption configures NewCredentialStore.
+type StoreOption func(*storeOptions)
+
+type storeOptions struct {
+	passphrase secrets.PassphraseFunc
+	timeout    time.Duration
+}
+
+// WithStorePassphrase sets where the encrypted file store gets its
+// passphrase. The default reads PINOCCHIO_SECRETS_PASSPHRASE or
+// PINOCCHIO_SECRETS_PASSPHRASE_FILE and never prompts.
+func WithStorePassphrase(passphrase secrets.PassphraseFunc) StoreOption {
+	return func(o *storeOptions) {
+		if passphrase != nil {
+			o.passphrase = passphrase
+		}
+	}
+}
+
+// WithHelperTimeout bounds each call of an exec credential helper.
+func WithHelperTimeout(timeout time.Duration) StoreOption {
+	return func(o *storeOptions) {
+		if timeout > 0 {
+			o.timeout = timeout
+		}
+	}
+}
+
+// NewCredentialStore creates the store config selects for one profile.
+// registryPath is the direct YAML registry of the profile and is only
+// required for StoreKindRegistry.
+func NewCredentialStore(config StoreConfig, registryPath string, registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request, options ...StoreOption) (CredentialStore, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	opts := storeOptions{passphrase: secrets.EnvPassphrase(nil), timeout: 30 * time.Second}
+	for _, option := range options {
+		option(&opts)
+	}
+	switch config.EffectiveKind() {
+	case StoreKindRegistry:
+		return NewYAMLStore(registryPath, registry, profile, expected)
+	case StoreKindFile:
+		path, err := storePath(config.Path, "oauth-credentials.yaml")
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStore(path, registry, profile, expected)
+	case StoreKindEncryptedFile:
+		path, err := storePath(config.Path, "oauth-credentials.enc")
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileStore(path, opts.passphrase, registry, profile, expected)
+	default:
+		return NewExecStore(config.Command, opts.timeout, registry, profile, expected)
+	}
+}
+
+// storePath expands ~ in configured paths and defaults to name in the
+// Pinocchio user config directory.
+func storePath(configured, name string) (string, error) {
+	if configured == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve OAuth credential store location: %w", err)
+		}
+		return filepath.Join(dir, "pinocchio", name), nil
+	}
+	if configured == "~" || strings.HasPrefix(configured, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve OAuth credential store location: %w", err)
+		}
+		configured = filepath.Join(home, strings.TrimPrefix(configured, "~"))
+	}
+	return filepath.Abs(configured)
+}
+
+// storeIdentity binds a store to one registry/profile and one request.
+type storeIdentity struct {
+	registry gepprofiles.RegistrySlug
+	profile  gepprofiles.EngineProfileSlug
+	expected credentials.Request
+}
+
+func newStoreIdentity(registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request) (storeIdentity, error) {
+	if registry.IsZero() || profile.IsZero() {
+		return storeIdentity{}, errors.New("OAuth profile registry and profile slugs are required")
+	}
+	if _, err := requestKey(expected); err != nil {
+		return storeIdentity{}, err
+	}
+	return storeIdentity{registry: registry, profile: profile, expected: normalizeRequest(expected)}, nil
+}
+
+// key is the registry/profile key of shared credential files and helpers.
+func (i storeIdentity) key() string {
+	return i.registry.String() + "/" + i.profile.String()
+}
+
+func (i storeIdentity) validateRequest(request credentials.Request) error {
+	if _, err := requestKey(request); err != nil {
+		return err
+	}
+	if normalizeRequest(request) != i.expected {
+		return errors.New("OAuth credential request does not match the selected profile")
+	}
+	return nil
+}
+
+// credentialRecord is one stored tuple with the request it was issued for.
+type credentialRecord struct {
+	Provider     string `json:"provider" yaml:"provider"`
+	BaseURL      string `json:"base_url" yaml:"base_url"`
+	AccessToken  string `json:"access_token" yaml:"access_token"`
+	RefreshToken string `json:"refresh_token" yaml:"refresh_token"`
+	ExpiresAt    string `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+func (i storeIdentity) record(credential credentials.Credential) credentialRecord {
+	record := credentialRecord{
+		Provider:     i.expected.Provider,
+		BaseURL:      i.expected.BaseURL,
+		AccessToken:  credential.AccessToken,
+		RefreshToken: credential.RefreshToken,
+	}
+	if !credential.ExpiresAt.IsZero() {
+		record.ExpiresAt = credential.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+// credential converts a stored record, refusing one issued for another
+// provider endpoint so a reused key cannot leak a token to the wrong host.
+func (i storeIdentity) credential(record credentialRecord) (credentials.Credential, error) {
+	if normalizeRequest(credentials.Request{Provider: record.Provider, BaseURL: record.BaseURL}) != i.expected {
+		return credentials.Credential{}, errors.New("stored OAuth credential belongs to a different provider endpoint")
+	}
+	credential := credentials.Credential{AccessToken: record.AccessToken, RefreshToken: record.RefreshToken}
+	if record.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, record.ExpiresAt)
+		if err != nil {
+			return credentials.Credential{}, errors.New("stored OAuth credential expires_at must be RFC3339")
+		}
+		credential.ExpiresAt = expiresAt.UTC()
+	}
+	return credential, nil
+}
+
+func validateCredentialTuple(credential credentials.Credential) error {
+	if strings.TrimSpace(credential.AccessToken) == "" {
+		return errors.New("OAuth credential access token is required")
+	}
+	if strings.TrimSpace(credential.RefreshToken) == "" {
+		return errors.New("OAuth credential refresh token is required")
+	}
+	return nil
+}
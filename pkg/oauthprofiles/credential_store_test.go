@@ -0,0 +1,170 @@
+//go:build !windows
+
+package oauthprofiles
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreKeysTuplesByRegistryAndProfile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o700))
+	path := filepath.Join(dir, "credentials", "oauth.yaml")
+	assistant := newKeyedTestStore(t, StoreConfig{Kind: StoreKindFile, Path: path}, "assistant")
+	other := newKeyedTestStore(t, StoreConfig{Kind: StoreKindFile, Path: path}, "other")
+	request := testRequest()
+
+	missing, err := assistant.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, credentials.Credential{}, missing)
+
+	tuple := credentials.Credential{AccessToken: "file-access", RefreshToken: "file-refresh", ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+	require.NoError(t, assistant.Save(context.Background(), request, tuple))
+	require.NoError(t, other.Save(context.Background(), request, credentials.Credential{AccessToken: "other-access", RefreshToken: "other-refresh"}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	loaded, err := assistant.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, tuple, loaded)
+
+	require.NoError(t, assistant.Delete(context.Background(), request))
+	require.NoError(t, assistant.Delete(context.Background(), request))
+	loaded, err = other.Load(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, "other-access", loaded.AccessToken)
+
+	require.NoError(t, os.Chmod(path, 0o644))
+	_, err = other.Load(context.Background(), request)
+	require.EqualError(t, err, "OAuth credential file must have mode 0600")
+}
+
+func TestFileStoreRejectsTupleForAnotherEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0o700))
+	path := filepath.Join(dir, "oauth.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`credentials:
+  workspace/assistant:
+    provider: openai
+    base_url: https://attacker.example.test/v1
+    access_token: must-not-appear
+    refresh_token: must-not-appear
+`), 0o600))
+	store := newKeyedTestStore(t, StoreConfig{Kind: StoreKindFile, Path: path}, "assistant")
+
+	_, err := store.Load(context.Background(), testRequest())
+	require.EqualError(t, err, "stored OAuth credential belongs to a different provider endpoint")
+	require.NotContains(t, err.Error(), "must-not-appear")
+}
+
+func TestEncryptedFileStoreRoundTripsAndAsksPassphraseOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oauth.enc")
+	asked := 0
+	passphrase := func() ([]byte, error) {
+		asked++
+		return []byte("correct horse"), nil
+	}
+	store, err := NewCredentialStore(StoreConfig{Kind: StoreKindEncryptedFile, Path: path}, "",
+		gepprofiles.MustRegistrySlug("workspace"), gepprofiles.MustEngineProfileSlug("assistant"), testRequest(),
+		WithStorePassphrase(passphrase))
+	require.NoError(t, err)
+	require.Equal(t, StoreKindEncryptedFile, store.Kind())
+
+	tuple := credentials.Credential{AccessToken: "encrypted-access", RefreshToken: "encrypted-refresh"}
+	require.NoError(t, store.Save(context.Background(), testRequest(), tuple))
+	loaded, err := store.Load(context.Background(), testRequest())
+	require.NoError(t, err)
+	require.Equal(t, tuple, loaded)
+	require.Equal(t, 1, asked)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "encrypted-access")
+}
+
+func TestExecStoreSpeaksHelperProtocol(t *testing.T) {
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper.sh")
+	state := filepath.Join(dir, "state")
+	require.NoError(t, os.WriteFile(helper, []byte(`#!/bin/sh
+state="$1"
+case "$2" in
+get)
+  if [ -f "$state.out" ]; then cat "$state.out"; fi
+  ;;
+store)
+  cat > "$state.in"
+  grep -E '^(access_token|refresh_token|expires_at)=' "$state.in" > "$state.out"
+  ;;
+erase)
+  cat > "$state.in"
+  rm -f "$state.out"
+  ;;
+esac
+`), 0o700))
+	store, err := NewCredentialStore(StoreConfig{Kind: StoreKindExec, Command: []string{helper, state}}, "",
+		gepprofiles.MustRegistrySlug("workspace"), gepprofiles.MustEngineProfileSlug("assistant"), testRequest())
+	require.NoError(t, err)
+
+	missing, err := store.Load(context.Background(), testRequest())
+	require.NoError(t, err)
+	require.Equal(t, credentials.Credential{}, missing)
+
+	tuple := credentials.Credential{AccessToken: "helper-access", RefreshToken: "helper-refresh", ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+	require.NoError(t, store.Save(context.Background(), testRequest(), tuple))
+	input, err := os.ReadFile(state + ".in")
+	require.NoError(t, err)
+	require.Contains(t, string(input), "registry=workspace\nprofile=assistant\nprovider=openai\nbase_url=https://provider.example.test/v1\n")
+	loaded, err := store.Load(context.Background(), testRequest())
+	require.NoError(t, err)
+	require.Equal(t, tuple, loaded)
+
+	require.NoError(t, store.Delete(context.Background(), testRequest()))
+	loaded, err = store.Load(context.Background(), testRequest())
+	require.NoError(t, err)
+	require.Equal(t, credentials.Credential{}, loaded)
+
+	failing, err := NewCredentialStore(StoreConfig{Kind: StoreKindExec, Command: []string{"false"}}, "",
+		gepprofiles.MustRegistrySlug("workspace"), gepprofiles.MustEngineProfileSlug("assistant"), testRequest())
+	require.NoError(t, err)
+	_, err = failing.Load(context.Background(), testRequest())
+	require.EqualError(t, err, "OAuth credential helper get failed: exit status 1")
+}
+
+func TestParseCredentialStoreConfig(t *testing.T) {
+	extensions := testExtensions("", "", time.Time{})
+	raw := extensions[ExtensionKey].(map[string]any)
+	raw["credential_store"] = map[string]any{"kind": "exec", "command": "pass-oauth-helper --vault work"}
+	profile, err := Parse(extensions)
+	require.NoError(t, err)
+	require.Equal(t, StoreConfig{Kind: StoreKindExec, Command: []string{"pass-oauth-helper", "--vault", "work"}}, profile.CredentialStore)
+
+	raw["credential_store"] = map[string]any{"kind": "keychain"}
+	_, err = Parse(extensions)
+	require.EqualError(t, err, `OAuth credential_store kind must be registry, file, encrypted_file or exec, not "keychain"`)
+
+	raw["credential_store"] = map[string]any{"kind": "exec"}
+	_, err = Parse(extensions)
+	require.EqualError(t, err, "OAuth credential_store kind exec requires a command")
+
+	delete(raw, "credential_store")
+	profile, err = Parse(extensions)
+	require.NoError(t, err)
+	require.Equal(t, StoreKindRegistry, profile.CredentialStore.EffectiveKind())
+}
+
+func newKeyedTestStore(t *testing.T, config StoreConfig, profile string) CredentialStore {
+	t.Helper()
+	store, err := NewCredentialStore(config, "", gepprofiles.MustRegistrySlug("workspace"), gepprofiles.MustEngineProfileSlug(profile), testRequest())
+	require.NoError(t, err)
+	return store
+}
@@ -0,0 +1,271 @@
+package oauthprofiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+)
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// defaultDevicePollInterval is the RFC 8628 default when the server omits
+	// interval; slow_down adds the same amount to the current interval.
+	defaultDevicePollInterval = 5 * time.Second
+	maxDeviceResponseBytes    = 1 << 20
+)
+
+// DeviceAuthorization is a pending RFC 8628 device authorization. DeviceCode
+// is a secret and must not be printed; UserCode and the verification URLs are
+// meant for the user.
+type DeviceAuthorization struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresAt               time.Time
+	Interval                time.Duration
+}
+
+// DeviceClient runs the device authorization grant for one OAuth profile.
+// Like the PKCE login it is a public client and never sends a client secret.
+type DeviceClient struct {
+	deviceAuthorizationURL string
+	tokenURL               string
+	clientID               string
+	scopes                 []string
+	httpClient             *http.Client
+	now                    func() time.Time
+	wait                   func(context.Context, time.Duration) error
+}
+
+type DeviceClientOption func(*DeviceClient)
+
+// WithDeviceHTTPClient replaces http.DefaultClient.
+func WithDeviceHTTPClient(client *http.Client) DeviceClientOption {
+	return func(c *DeviceClient) {
+		if client != nil {
+			c.httpClient = client
+		}
+	}
+}
+
+// WithDeviceWait replaces the wait between token polls, mainly so tests do
+// not sleep for the server-mandated interval.
+func WithDeviceWait(wait func(context.Context, time.Duration) error) DeviceClientOption {
+	return func(c *DeviceClient) {
+		if wait != nil {
+			c.wait = wait
+		}
+	}
+}
+
+// NewDeviceClient returns a device grant client for profiles that declare
+// device_authorization_url.
+func (p *Profile) NewDeviceClient(options ...DeviceClientOption) (*DeviceClient, error) {
+	if p == nil {
+		return nil, errors.New("OAuth profile is required")
+	}
+	if !p.SupportsDeviceLogin() {
+		return nil, errors.New("OAuth profile does not declare device_authorization_url")
+	}
+	client := &DeviceClient{
+		deviceAuthorizationURL: p.DeviceAuthorizationURL,
+		tokenURL:               p.TokenURL,
+		clientID:               p.ClientID,
+		scopes:                 append([]string(nil), p.Scopes...),
+		httpClient:             http.DefaultClient,
+		now:                    time.Now,
+		wait:                   waitContext,
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client, nil
+}
+
+// Authorize requests a device and user code.
+func (c *DeviceClient) Authorize(ctx context.Context) (*DeviceAuthorization, error) {
+	form := url.Values{"client_id": {c.clientID}}
+	if len(c.scopes) > 0 {
+		form.Set("scope", strings.Join(c.scopes, " "))
+	}
+	status, body, err := c.post(ctx, c.deviceAuthorizationURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("OAuth device authorization request failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OAuth device authorization request failed: %s", oauthErrorCode(status, body))
+	}
+
+	var response struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int64  `json:"expires_in"`
+		Interval                int64  `json:"interval"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, errors.New("OAuth device authorization response is not valid JSON")
+	}
+	if response.DeviceCode == "" || response.UserCode == "" || response.ExpiresIn <= 0 {
+		return nil, errors.New("OAuth device authorization response is missing device_code, user_code or expires_in")
+	}
+	if _, err := absoluteHTTPURL(response.VerificationURI, "verification_uri"); err != nil {
+		return nil, err
+	}
+	if response.VerificationURIComplete != "" {
+		if _, err := absoluteHTTPURL(response.VerificationURIComplete, "verification_uri_complete"); err != nil {
+			return nil, err
+		}
+	}
+	interval := defaultDevicePollInterval
+	if response.Interval > 0 {
+		interval = time.Duration(response.Interval) * time.Second
+	}
+	return &DeviceAuthorization{
+		DeviceCode:              response.DeviceCode,
+		UserCode:                response.UserCode,
+		VerificationURI:         response.VerificationURI,
+		VerificationURIComplete: response.VerificationURIComplete,
+		ExpiresAt:               c.now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		Interval:                interval,
+	}, nil
+}
+
+// Poll waits for the user to approve the authorization and returns the
+// issued credential. authorization_pending keeps polling, slow_down adds five
+// seconds to the interval, and access_denied, expired_token or the code
+// expiring end the login. Errors never include token material.
+func (c *DeviceClient) Poll(ctx context.Context, authorization *DeviceAuthorization) (credentials.Credential, error) {
+	if authorization == nil || authorization.DeviceCode == "" {
+		return credentials.Credential{}, errors.New("OAuth device authorization is required")
+	}
+	interval := authorization.Interval
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+	form := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {authorization.DeviceCode},
+		"client_id":   {c.clientID},
+	}
+	for {
+		if err := c.wait(ctx, interval); err != nil {
+			return credentials.Credential{}, errors.New("OAuth device login timed out or was cancelled")
+		}
+		if !authorization.ExpiresAt.IsZero() && !c.now().Before(authorization.ExpiresAt) {
+			return credentials.Credential{}, errors.New("OAuth device code expired before it was approved")
+		}
+		status, body, err := c.post(ctx, c.tokenURL, form)
+		if err != nil {
+			if ctx.Err() != nil {
+				return credentials.Credential{}, errors.New("OAuth device login timed out or was cancelled")
+			}
+			return credentials.Credential{}, fmt.Errorf("OAuth device token request failed: %w", err)
+		}
+		if status == http.StatusOK {
+			return c.credentialFromTokenResponse(body)
+		}
+		switch code := oauthErrorCode(status, body); code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += defaultDevicePollInterval
+		case "access_denied":
+			return credentials.Credential{}, errors.New("OAuth device authorization was denied")
+		case "expired_token":
+			return credentials.Credential{}, errors.New("OAuth device code expired before it was approved")
+		default:
+			return credentials.Credential{}, fmt.Errorf("OAuth device token request failed: %s", code)
+		}
+	}
+}
+
+func (c *DeviceClient) credentialFromTokenResponse(body []byte) (credentials.Credential, error) {
+	var response struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return credentials.Credential{}, errors.New("OAuth device token response is not valid JSON")
+	}
+	if strings.TrimSpace(response.AccessToken) == "" {
+		return credentials.Credential{}, errors.New("OAuth device token response did not include an access token")
+	}
+	if response.TokenType != "" && !strings.EqualFold(response.TokenType, "bearer") {
+		return credentials.Credential{}, fmt.Errorf("OAuth device token response has unsupported token_type %q", response.TokenType)
+	}
+	credential := credentials.Credential{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+	}
+	if response.ExpiresIn > 0 {
+		credential.ExpiresAt = c.now().Add(time.Duration(response.ExpiresIn) * time.Second).UTC()
+	}
+	return credential, nil
+}
+
+func (c *DeviceClient) post(ctx context.Context, endpoint string, form url.Values) (int, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, nil, errors.New("send request")
+	}
+	defer func() { _ = response.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxDeviceResponseBytes))
+	if err != nil {
+		return 0, nil, errors.New("read response")
+	}
+	return response.StatusCode, body, nil
+}
+
+// oauthErrorCode returns the RFC 6749 error code of a failed response, or the
+// HTTP status when the body has none. Descriptions are not returned because
+// servers may echo request values in them.
+func oauthErrorCode(status int, body []byte) string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err == nil && isOAuthErrorCode(response.Error) {
+		return response.Error
+	}
+	return fmt.Sprintf("HTTP %d", status)
+}
+
+func isOAuthErrorCode(code string) bool {
+	if code == "" || len(code) > 64 {
+		return false
+	}
+	for _, r := range code {
+		if (r < 'a' || r > 'z') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func waitContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package oauthprofiles
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceClientPollsUntilApprovedAndHonorsSlowDown(t *testing.T) {
+	tokenResponses := []string{
+		`{"error":"authorization_pending"}`,
+		`{"error":"slow_down"}`,
+		`{"error":"authorization_pending"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "public-client", r.Form.Get("client_id"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/device":
+			require.Equal(t, "inference offline_access", r.Form.Get("scope"))
+			_, _ = io.WriteString(w, `{"device_code":"device-secret","user_code":"ABCD-EFGH","verification_uri":"https://issuer.example.test/activate","verification_uri_complete":"https://issuer.example.test/activate?user_code=ABCD-EFGH","expires_in":600,"interval":2}`)
+		case "/token":
+			require.Equal(t, deviceCodeGrantType, r.Form.Get("grant_type"))
+			require.Equal(t, "device-secret", r.Form.Get("device_code"))
+			if len(tokenResponses) > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, tokenResponses[0])
+				tokenResponses = tokenResponses[1:]
+				return
+			}
+			_, _ = io.WriteString(w, `{"access_token":"new-access","refresh_token":"new-refresh","token_type":"Bearer","expires_in":3600}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client, err := deviceProfile(server.URL).NewDeviceClient(WithDeviceWait(func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	authorization, err := client.Authorize(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ABCD-EFGH", authorization.UserCode)
+	require.Equal(t, "https://issuer.example.test/activate", authorization.VerificationURI)
+	require.Equal(t, 2*time.Second, authorization.Interval)
+
+	credential, err := client.Poll(context.Background(), authorization)
+	require.NoError(t, err)
+	require.Equal(t, "new-access", credential.AccessToken)
+	require.Equal(t, "new-refresh", credential.RefreshToken)
+	require.False(t, credential.ExpiresAt.IsZero())
+	require.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second, 7 * time.Second, 7 * time.Second}, waits)
+}
+
+func TestDeviceClientStopsOnDenialExpiryAndCancellation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		response string
+		wait     func(context.Context, time.Duration) error
+		expected string
+	}{
+		"denied":    {response: `{"error":"access_denied","error_description":"device-secret"}`, expected: "OAuth device authorization was denied"},
+		"expired":   {response: `{"error":"expired_token"}`, expected: "OAuth device code expired before it was approved"},
+		"unknown":   {response: `{"error":"invalid_grant"}`, expected: "OAuth device token request failed: invalid_grant"},
+		"malformed": {response: `not json device-secret`, expected: "OAuth device token request failed: HTTP 400"},
+		"cancelled": {
+			response: `{"error":"authorization_pending"}`,
+			wait:     func(context.Context, time.Duration) error { return context.Canceled },
+			expected: "OAuth device login timed out or was cancelled",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, tc.response)
+			}))
+			defer server.Close()
+
+			wait := tc.wait
+			if wait == nil {
+				wait = func(context.Context, time.Duration) error { return nil }
+			}
+			client, err := deviceProfile(server.URL).NewDeviceClient(WithDeviceWait(wait))
+			require.NoError(t, err)
+			_, err = client.Poll(context.Background(), &DeviceAuthorization{
+				DeviceCode: "device-secret",
+				ExpiresAt:  time.Now().Add(time.Minute),
+				Interval:   time.Second,
+			})
+			require.EqualError(t, err, tc.expected)
+			require.NotContains(t, err.Error(), "device-secret")
+		})
+	}
+}
+
+func TestDeviceClientRequiresDeviceEndpoint(t *testing.T) {
+	profile := deviceProfile("https://issuer.example.test")
+	profile.DeviceAuthorizationURL = ""
+	_, err := profile.NewDeviceClient()
+	require.EqualError(t, err, "OAuth profile does not declare device_authorization_url")
+}
+
+func deviceProfile(issuerURL string) *Profile {
+	return &Profile{
+		DeviceAuthorizationURL: issuerURL + "/device",
+		TokenURL:               issuerURL + "/token",
+		ClientID:               "public-client",
+		Scopes:                 []string{"inference", "offline_access"},
+	}
+}
@@ -0,0 +1,170 @@
+package oauthprofiles
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+)
+
+// ExecStore delegates persistence to an external credential helper, in the
+// style of git credential helpers. The helper is run as
+//
+//	<command...> get|store|erase
+//
+// with key=value lines on stdin: registry, profile, provider and base_url,
+// plus access_token, refresh_token and expires_at (RFC3339) for store. For
+// get the helper prints access_token, refresh_token and expires_at lines, or
+// nothing when it has no credential. A non-zero exit fails the operation;
+// helper output is never included in errors.
+type ExecStore struct {
+	identity storeIdentity
+	command  []string
+	timeout  time.Duration
+}
+
+var _ CredentialStore = (*ExecStore)(nil)
+
+// NewExecStore creates a helper-backed store for one registry/profile.
+func NewExecStore(command []string, timeout time.Duration, registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request) (*ExecStore, error) {
+	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
+		return nil, errors.New("OAuth credential helper command is required")
+	}
+	identity, err := newStoreIdentity(registry, profile, expected)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &ExecStore{identity: identity, command: append([]string(nil), command...), timeout: timeout}, nil
+}
+
+func (s *ExecStore) Load(ctx context.Context, request credentials.Request) (credentials.Credential, error) {
+	if err := s.identity.validateRequest(request); err != nil {
+		return credentials.Credential{}, err
+	}
+	output, err := s.run(ctx, "get", nil)
+	if err != nil {
+		return credentials.Credential{}, err
+	}
+	values, err := parseHelperLines(output)
+	if err != nil {
+		return credentials.Credential{}, err
+	}
+	if values["access_token"] == "" && values["refresh_token"] == "" {
+		return credentials.Credential{}, nil
+	}
+	return s.identity.credential(credentialRecord{
+		Provider:     s.identity.expected.Provider,
+		BaseURL:      s.identity.expected.BaseURL,
+		AccessToken:  values["access_token"],
+		RefreshToken: values["refresh_token"],
+		ExpiresAt:    values["expires_at"],
+	})
+}
+
+func (s *ExecStore) Save(ctx context.Context, request credentials.Request, credential credentials.Credential) error {
+	if err := s.identity.validateRequest(request); err != nil {
+		return err
+	}
+	if err := validateCredentialTuple(credential); err != nil {
+		return err
+	}
+	record := s.identity.record(credential)
+	_, err := s.run(ctx, "store", [][2]string{
+		{"access_token", record.AccessToken},
+		{"refresh_token", record.RefreshToken},
+		{"expires_at", record.ExpiresAt},
+	})
+	return err
+}
+
+func (s *ExecStore) Delete(ctx context.Context, request credentials.Request) error {
+	if err := s.identity.validateRequest(request); err != nil {
+		return err
+	}
+	_, err := s.run(ctx, "erase", nil)
+	return err
+}
+
+func (s *ExecStore) Kind() string {
+	return StoreKindExec
+}
+
+func (s *ExecStore) run(ctx context.Context, operation string, extra [][2]string) ([]byte, error) {
+	if err := contextErr(ctx); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	lines := [][2]string{
+		{"registry", s.identity.registry.String()},
+		{"profile", s.identity.profile.String()},
+		{"provider", s.identity.expected.Provider},
+		{"base_url", s.identity.expected.BaseURL},
+	}
+	var stdin bytes.Buffer
+	for _, line := range append(lines, extra...) {
+		if line[1] == "" {
+			continue
+		}
+		if strings.ContainsAny(line[1], "\r\n\x00") {
+			return nil, fmt.Errorf("OAuth credential helper value %s contains a line break", line[0])
+		}
+		fmt.Fprintf(&stdin, "%s=%s\n", line[0], line[1])
+	}
+	stdin.WriteString("\n")
+
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	args := append(append([]string(nil), s.command[1:]...), operation)
+	command := exec.CommandContext(runCtx, s.command[0], args...)
+	command.Stdin = &stdin
+	var stdout bytes.Buffer
+	command.Stdout = &stdout
+	if err := command.Run(); err != nil {
+		if runCtx.Err() != nil {
+			return nil, fmt.Errorf("OAuth credential helper %s timed out or was cancelled", operation)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("OAuth credential helper %s failed: %s", operation, exitErr.ProcessState.String())
+		}
+		return nil, fmt.Errorf("run OAuth credential helper %s: %w", operation, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseHelperLines reads key=value lines up to the first blank line. Only the
+// keys a helper may return are kept.
+func parseHelperLines(output []byte) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errors.New("OAuth credential helper printed a line without key=value")
+		}
+		switch key {
+		case "access_token", "refresh_token", "expires_at":
+			values[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("read OAuth credential helper output")
+	}
+	return values, nil
+}
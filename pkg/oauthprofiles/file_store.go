@@ -0,0 +1,279 @@
+package oauthprofiles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+	"gopkg.in/yaml.v3"
+)
+
+// recordFile reads and writes every credential record of one shared file.
+type recordFile interface {
+	read() (map[string]credentialRecord, error)
+	write(map[string]credentialRecord) error
+}
+
+// keyedStore keeps one profile's tuple under its registry/profile key in a
+// file shared by many profiles, read and rewritten whole under the file lock.
+type keyedStore struct {
+	identity storeIdentity
+	path     string
+	kind     string
+	file     recordFile
+}
+
+// FileStore keeps credential tuples in one owner-only YAML file, outside the
+// profile registries people share and diff.
+type FileStore struct {
+	keyedStore
+}
+
+// EncryptedFileStore keeps credential tuples in a passphrase-encrypted file
+// using the same format as the Pinocchio secret file.
+type EncryptedFileStore struct {
+	keyedStore
+}
+
+var _ CredentialStore = (*FileStore)(nil)
+var _ CredentialStore = (*EncryptedFileStore)(nil)
+
+// NewFileStore creates a store for one registry/profile in the credentials
+// file at path. The file is created with mode 0600 on the first save.
+func NewFileStore(path string, registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request) (*FileStore, error) {
+	store, err := newKeyedStore(path, StoreKindFile, registry, profile, expected)
+	if err != nil {
+		return nil, err
+	}
+	store.file = yamlRecordFile{path: store.path}
+	return &FileStore{keyedStore: store}, nil
+}
+
+// NewEncryptedFileStore creates a store for one registry/profile in the
+// encrypted credentials file at path. passphrase is asked once, on first use.
+func NewEncryptedFileStore(path string, passphrase secrets.PassphraseFunc, registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request) (*EncryptedFileStore, error) {
+	if passphrase == nil {
+		return nil, errors.New("OAuth encrypted credential store requires a passphrase source")
+	}
+	store, err := newKeyedStore(path, StoreKindEncryptedFile, registry, profile, expected)
+	if err != nil {
+		return nil, err
+	}
+	store.file = &encryptedRecordFile{path: store.path, passphrase: passphrase}
+	return &EncryptedFileStore{keyedStore: store}, nil
+}
+
+func newKeyedStore(path, kind string, registry gepprofiles.RegistrySlug, profile gepprofiles.EngineProfileSlug, expected credentials.Request) (keyedStore, error) {
+	if err := validateYAMLPersistencePlatform(); err != nil {
+		return keyedStore{}, err
+	}
+	if strings.TrimSpace(path) == "" {
+		return keyedStore{}, errors.New("OAuth credential file path is required")
+	}
+	identity, err := newStoreIdentity(registry, profile, expected)
+	if err != nil {
+		return keyedStore{}, err
+	}
+	return keyedStore{identity: identity, path: filepath.Clean(path), kind: kind}, nil
+}
+
+// Load returns the stored tuple, or an empty credential when the file or the
+// profile's entry does not exist yet.
+func (s *keyedStore) Load(ctx context.Context, request credentials.Request) (credentials.Credential, error) {
+	if err := s.identity.validateRequest(request); err != nil {
+		return credentials.Credential{}, err
+	}
+	if err := contextErr(ctx); err != nil {
+		return credentials.Credential{}, err
+	}
+	if _, err := os.Stat(filepath.Dir(s.path)); os.IsNotExist(err) {
+		return credentials.Credential{}, nil
+	}
+	var credential credentials.Credential
+	err := WithRegistryLock(s.path, false, func() error {
+		records, err := s.file.read()
+		if err != nil {
+			return err
+		}
+		record, ok := records[s.identity.key()]
+		if !ok {
+			return nil
+		}
+		credential, err = s.identity.credential(record)
+		return err
+	})
+	if err != nil {
+		return credentials.Credential{}, err
+	}
+	return credential, nil
+}
+
+// Save replaces the profile's tuple and keeps every other entry.
+func (s *keyedStore) Save(ctx context.Context, request credentials.Request, credential credentials.Credential) error {
+	if err := s.identity.validateRequest(request); err != nil {
+		return err
+	}
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+	if err := validateCredentialTuple(credential); err != nil {
+		return err
+	}
+	return s.update(func(records map[string]credentialRecord) bool {
+		records[s.identity.key()] = s.identity.record(credential)
+		return true
+	})
+}
+
+// Delete removes the profile's tuple. It is idempotent.
+func (s *keyedStore) Delete(ctx context.Context, request credentials.Request) error {
+	if err := s.identity.validateRequest(request); err != nil {
+		return err
+	}
+	if err := contextErr(ctx); err != nil {
+		return err
+	}
+	return s.update(func(records map[string]credentialRecord) bool {
+		if _, ok := records[s.identity.key()]; !ok {
+			return false
+		}
+		delete(records, s.identity.key())
+		return true
+	})
+}
+
+func (s *keyedStore) Kind() string {
+	return s.kind
+}
+
+// Path returns the credentials file path, for diagnostics that must never
+// include credential values.
+func (s *keyedStore) Path() string {
+	return s.path
+}
+
+func (s *keyedStore) update(fn func(map[string]credentialRecord) bool) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("create OAuth credential file directory: %w", err)
+	}
+	return WithRegistryLock(s.path, true, func() error {
+		records, err := s.file.read()
+		if err != nil {
+			return err
+		}
+		if !fn(records) {
+			return nil
+		}
+		return s.file.write(records)
+	})
+}
+
+type yamlRecordFile struct {
+	path string
+}
+
+type yamlRecordDocument struct {
+	Credentials map[string]credentialRecord `yaml:"credentials"`
+}
+
+func (f yamlRecordFile) read() (map[string]credentialRecord, error) {
+	if _, err := os.Lstat(f.path); os.IsNotExist(err) {
+		return map[string]credentialRecord{}, nil
+	}
+	if err := ensureOwnerOnlyFile(f.path, "OAuth credential file"); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("read OAuth credential file: %w", err)
+	}
+	document := yamlRecordDocument{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, errors.New("decode OAuth credential file")
+	}
+	if document.Credentials == nil {
+		document.Credentials = map[string]credentialRecord{}
+	}
+	return document.Credentials, nil
+}
+
+func (f yamlRecordFile) write(records map[string]credentialRecord) error {
+	data, err := yaml.Marshal(yamlRecordDocument{Credentials: records})
+	if err != nil {
+		return errors.New("encode OAuth credential file")
+	}
+	return atomicWriteOwnerOnly(f.path, data)
+}
+
+type encryptedRecordFile struct {
+	path       string
+	passphrase secrets.PassphraseFunc
+
+	mu     sync.Mutex
+	cached []byte
+}
+
+func (f *encryptedRecordFile) read() (map[string]credentialRecord, error) {
+	passphrase, err := f.key()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := secrets.ReadSecretFile(f.path, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("read OAuth encrypted credential file: %w", err)
+	}
+	records := make(map[string]credentialRecord, len(entries))
+	for key, value := range entries {
+		record := credentialRecord{}
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, errors.New("decode OAuth encrypted credential file entry")
+		}
+		records[key] = record
+	}
+	return records, nil
+}
+
+func (f *encryptedRecordFile) write(records map[string]credentialRecord) error {
+	passphrase, err := f.key()
+	if err != nil {
+		return err
+	}
+	entries := make(map[string]string, len(records))
+	for key, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return errors.New("encode OAuth encrypted credential file entry")
+		}
+		entries[key] = string(data)
+	}
+	if err := secrets.WriteSecretFile(f.path, passphrase, entries); err != nil {
+		return fmt.Errorf("write OAuth encrypted credential file: %w", err)
+	}
+	return nil
+}
+
+// key asks for the passphrase once, so a refresh does not prompt again.
+func (f *encryptedRecordFile) key() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cached != nil {
+		return f.cached, nil
+	}
+	passphrase, err := f.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("OAuth encrypted credential file passphrase is empty")
+	}
+	f.cached = passphrase
+	return passphrase, nil
+}
@@ -4,8 +4,9 @@ package oauthprofiles
 
 import "errors"
 
-func withRegistryLock(_ string, _ bool, _ func() error) error {
-	return errors.New("OAuth profile YAML persistence requires an operating-system file lock")
+// WithRegistryLock is unsupported without an operating-system file lock.
+func WithRegistryLock(_ string, _ bool, _ func() error) error {
+	return errors.New("writing YAML profile registries requires an operating-system file lock")
 }
 
 func syncDirectory(_ string) error {
@@ -8,21 +8,25 @@ import (
 	"syscall"
 )
 
-func withRegistryLock(path string, exclusive bool, fn func() error) error {
+// WithRegistryLock holds a lock on the YAML profile registry at path while fn
+// runs, exclusive for writers and shared for readers. Every writer of a
+// registry file, including profile edits, takes this lock so they never
+// interleave with an OAuth token refresh.
+func WithRegistryLock(path string, exclusive bool, fn func() error) error {
 	lock, err := os.OpenFile(path+".oauth.lock", os.O_CREATE|os.O_RDWR, 0o600)
 	if err != nil {
-		return fmt.Errorf("open OAuth profile lock: %w", err)
+		return fmt.Errorf("open profile registry lock: %w", err)
 	}
 	defer func() { _ = lock.Close() }()
 	if err := lock.Chmod(0o600); err != nil {
-		return fmt.Errorf("set OAuth profile lock mode: %w", err)
+		return fmt.Errorf("set profile registry lock mode: %w", err)
 	}
 	mode := syscall.LOCK_SH
 	if exclusive {
 		mode = syscall.LOCK_EX
 	}
 	if err := syscall.Flock(int(lock.Fd()), mode); err != nil {
-		return fmt.Errorf("lock OAuth profile registry: %w", err)
+		return fmt.Errorf("lock profile registry: %w", err)
 	}
 	defer func() { _ = syscall.Flock(int(lock.Fd()), syscall.LOCK_UN) }()
 	return fn()
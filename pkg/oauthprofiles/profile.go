@@ -13,6 +13,7 @@ import (
 
 	"github.com/go-go-golems/geppetto/pkg/steps/ai/credentials"
 	geppettoauth "github.com/go-go-golems/geppetto/pkg/steps/ai/credentials/oauth"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
 )
 
 const (
@@ -28,12 +29,18 @@ const (
 // credential tuple. Do not log or serialize Credential outside the owner-only
 // registry file.
 type Profile struct {
-	AuthorizationURL   string
-	TokenURL           string
-	ClientID           string
-	Scopes             []string
-	RefreshTokenPolicy geppettoauth.RefreshTokenPolicy
-	Credential         credentials.Credential
+	AuthorizationURL string
+	// DeviceAuthorizationURL enables the RFC 8628 device authorization grant
+	// for logins without a local browser.
+	DeviceAuthorizationURL string
+	TokenURL               string
+	ClientID               string
+	Scopes                 []string
+	RefreshTokenPolicy     geppettoauth.RefreshTokenPolicy
+	// CredentialStore selects where the credential tuple is persisted. The
+	// zero value keeps it in the registry file next to the profile.
+	CredentialStore StoreConfig
+	Credential      credentials.Credential
 }
 
 // IsOAuthProfile reports whether extensions contains the versioned Pinocchio
@@ -66,9 +73,15 @@ func Parse(extensions map[string]any) (*Profile, error) {
 	}
 
 	profile := &Profile{RefreshTokenPolicy: geppettoauth.PreservePreviousRefreshToken}
-	if profile.AuthorizationURL, err = requiredURL(raw, "authorization_url"); err != nil {
+	if profile.AuthorizationURL, err = optionalURL(raw, "authorization_url"); err != nil {
 		return nil, err
 	}
+	if profile.DeviceAuthorizationURL, err = optionalURL(raw, "device_authorization_url"); err != nil {
+		return nil, err
+	}
+	if profile.AuthorizationURL == "" && profile.DeviceAuthorizationURL == "" {
+		return nil, fmt.Errorf("OAuth profile authorization_url or device_authorization_url is required")
+	}
 	if profile.TokenURL, err = requiredURL(raw, "token_url"); err != nil {
 		return nil, err
 	}
@@ -88,6 +101,10 @@ func Parse(extensions map[string]any) (*Profile, error) {
 		}
 	}
 
+	if profile.CredentialStore, err = parseStoreConfig(raw["credential_store"]); err != nil {
+		return nil, err
+	}
+
 	profile.Credential.AccessToken, err = optionalString(raw, "access_token")
 	if err != nil {
 		return nil, err
@@ -106,9 +123,23 @@ func Parse(extensions map[string]any) (*Profile, error) {
 	return profile, nil
 }
 
+// SupportsBrowserLogin reports whether the profile declares an authorization
+// endpoint for the loopback Authorization Code with PKCE flow.
+func (p *Profile) SupportsBrowserLogin() bool {
+	return p != nil && p.AuthorizationURL != ""
+}
+
+// SupportsDeviceLogin reports whether the profile declares a device
+// authorization endpoint for the RFC 8628 flow.
+func (p *Profile) SupportsDeviceLogin() bool {
+	return p != nil && p.DeviceAuthorizationURL != ""
+}
+
 // ProtocolConfig creates the reusable Geppetto OAuth client configuration for
 // a caller-owned callback URL. The profile format intentionally has no stored
 // redirect URL because each browser login binds an exact loopback listener.
+// Device-only profiles use the device endpoint as a placeholder authorization
+// URL because refresh grants never use it.
 func (p *Profile) ProtocolConfig(redirectURL string) (geppettoauth.Config, error) {
 	if p == nil {
 		return geppettoauth.Config{}, fmt.Errorf("OAuth profile is required")
@@ -116,8 +147,12 @@ func (p *Profile) ProtocolConfig(redirectURL string) (geppettoauth.Config, error
 	if _, err := absoluteHTTPURL(redirectURL, "redirect"); err != nil {
 		return geppettoauth.Config{}, err
 	}
+	authorizationURL := p.AuthorizationURL
+	if authorizationURL == "" {
+		authorizationURL = p.DeviceAuthorizationURL
+	}
 	return geppettoauth.Config{
-		AuthorizationURL: p.AuthorizationURL,
+		AuthorizationURL: authorizationURL,
 		TokenURL:         p.TokenURL,
 		ClientID:         p.ClientID,
 		RedirectURL:      redirectURL,
@@ -134,14 +169,28 @@ func RedactedExtensions(extensions map[string]any) map[string]any {
 		return ret
 	}
 	for _, key := range []string{"access_token", "refresh_token", "client_secret"} {
-		if _, exists := raw[key]; exists {
-			raw[key] = "<redacted>"
+		value, exists := raw[key]
+		if !exists {
+			continue
+		}
+		raw[key] = "<redacted>"
+		if text, ok := value.(string); ok && secrets.IsRef(text) {
+			raw[key] = secrets.RedactValue(text)
 		}
 	}
 	ret[ExtensionKey] = raw
 	return ret
 }
 
+// WithoutCredential clones extensions and drops the OAuth credential tuple
+// while keeping the protocol configuration, so a copied profile must log in
+// on its own. It never mutates the source.
+func WithoutCredential(extensions map[string]any) map[string]any {
+	ret := cloneMap(extensions)
+	clearCredential(ret)
+	return ret
+}
+
 func requiredString(raw map[string]any, key string) (string, error) {
 	value, err := optionalString(raw, key)
 	if err != nil {
@@ -210,6 +259,18 @@ func requiredURL(raw map[string]any, key string) (string, error) {
 	return parsed.String(), nil
 }
 
+func optionalURL(raw map[string]any, key string) (string, error) {
+	value, err := optionalString(raw, key)
+	if err != nil || value == "" {
+		return "", err
+	}
+	parsed, err := absoluteHTTPURL(value, key)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
 func absoluteHTTPURL(raw, key string) (*url.URL, error) {
 	parsed, err := url.Parse(strings.TrimSpace(raw))
 	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
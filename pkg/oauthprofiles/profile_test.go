@@ -57,6 +57,25 @@ func TestParseRejectsMalformedPolicyAndURL(t *testing.T) {
 	}
 }
 
+func TestParseDeviceOnlyProfile(t *testing.T) {
+	extensions := testExtensions("", "", time.Time{})
+	raw := extensions[ExtensionKey].(map[string]any)
+	delete(raw, "authorization_url")
+	raw["device_authorization_url"] = "https://issuer.example.test/device"
+
+	profile, err := Parse(extensions)
+	require.NoError(t, err)
+	require.False(t, profile.SupportsBrowserLogin())
+	require.True(t, profile.SupportsDeviceLogin())
+	config, err := profile.ProtocolConfig("http://127.0.0.1/oauth/callback")
+	require.NoError(t, err)
+	require.Equal(t, "https://issuer.example.test/device", config.AuthorizationURL)
+
+	delete(raw, "device_authorization_url")
+	_, err = Parse(extensions)
+	require.EqualError(t, err, "OAuth profile authorization_url or device_authorization_url is required")
+}
+
 func TestParseRejectsMalformedCredentialFieldsWithoutLeakingValues(t *testing.T) {
 	for name, mutate := range map[string]func(map[string]any){
 		"access token":  func(raw map[string]any) { raw["access_token"] = 42 },
@@ -62,7 +62,7 @@ func (s *YAMLStore) Load(ctx context.Context, request credentials.Request) (cred
 		return credentials.Credential{}, err
 	}
 	var credential credentials.Credential
-	err := withRegistryLock(s.path, false, func() error {
+	err := WithRegistryLock(s.path, false, func() error {
 		profile, err := s.loadProfile()
 		if err != nil {
 			return err
@@ -100,7 +100,7 @@ func (s *YAMLStore) Save(ctx context.Context, request credentials.Request, crede
 		return errors.New("OAuth credential refresh token is required")
 	}
 
-	return withRegistryLock(s.path, true, func() error {
+	return WithRegistryLock(s.path, true, func() error {
 		registry, profile, err := s.loadRegistryAndProfile()
 		if err != nil {
 			return err
@@ -133,7 +133,7 @@ func (s *YAMLStore) Delete(ctx context.Context, request credentials.Request) err
 		return err
 	}
 
-	return withRegistryLock(s.path, true, func() error {
+	return WithRegistryLock(s.path, true, func() error {
 		registry, profile, err := s.loadRegistryAndProfile()
 		if err != nil {
 			return err
@@ -155,6 +155,11 @@ func (s *YAMLStore) Delete(ctx context.Context, request credentials.Request) err
 	})
 }
 
+// Kind reports StoreKindRegistry.
+func (s *YAMLStore) Kind() string {
+	return StoreKindRegistry
+}
+
 // Path returns the direct YAML registry path, for diagnostics that must never
 // include credential values.
 func (s *YAMLStore) Path() string {
@@ -231,22 +236,28 @@ func setCredential(extensions map[string]any, credential credentials.Credential)
 }
 
 func ensureOwnerOnlyRegistry(path string) error {
+	return ensureOwnerOnlyFile(path, "OAuth profile registry")
+}
+
+// ensureOwnerOnlyFile checks that path is a regular 0600 file in a directory
+// nobody else can write to. what names the file in errors.
+func ensureOwnerOnlyFile(path, what string) error {
 	info, err := os.Lstat(path)
 	if err != nil {
-		return fmt.Errorf("stat OAuth profile registry: %w", err)
+		return fmt.Errorf("stat %s: %w", what, err)
 	}
 	if info.Mode()&os.ModeSymlink != 0 || !info.Mode().IsRegular() {
-		return errors.New("OAuth profile registry must be a regular file")
+		return fmt.Errorf("%s must be a regular file", what)
 	}
 	if info.Mode().Perm() != 0o600 {
-		return errors.New("OAuth profile registry must have mode 0600")
+		return fmt.Errorf("%s must have mode 0600", what)
 	}
 	dirInfo, err := os.Stat(filepath.Dir(path))
 	if err != nil {
-		return fmt.Errorf("stat OAuth profile registry directory: %w", err)
+		return fmt.Errorf("stat %s directory: %w", what, err)
 	}
 	if !dirInfo.IsDir() || dirInfo.Mode().Perm()&0o022 != 0 {
-		return errors.New("OAuth profile registry directory must not be group or world writable")
+		return fmt.Errorf("%s directory must not be group or world writable", what)
 	}
 	return nil
 }
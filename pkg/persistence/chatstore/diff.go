@@ -0,0 +1,326 @@
+package chatstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+)
+
+// DiffChange classifies one entry of a SnapshotDiff.
+type DiffChange string
+
+const (
+	DiffAdded    DiffChange = "added"
+	DiffRemoved  DiffChange = "removed"
+	DiffModified DiffChange = "modified"
+	// DiffMoved marks a block whose content is unchanged but whose position
+	// relative to the other surviving blocks changed.
+	DiffMoved DiffChange = "moved"
+)
+
+// BlockDiff describes how one block differs between two snapshots. Blocks are
+// matched by block ID; FromIndex/ToIndex are -1 when the block is absent on
+// that side.
+type BlockDiff struct {
+	Change    DiffChange `json:"change"`
+	BlockID   string     `json:"block_id"`
+	Kind      string     `json:"kind"`
+	Role      string     `json:"role,omitempty"`
+	FromIndex int        `json:"from_index"`
+	ToIndex   int        `json:"to_index"`
+	FromHash  string     `json:"from_hash,omitempty"`
+	ToHash    string     `json:"to_hash,omitempty"`
+	// Fields lists what changed on a modified block: "kind", "role",
+	// "payload.<key>" or "metadata.<key>".
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ValueDiff describes one changed key of turn metadata or turn data.
+type ValueDiff struct {
+	Key    string     `json:"key"`
+	Change DiffChange `json:"change"`
+	From   any        `json:"from,omitempty"`
+	To     any        `json:"to,omitempty"`
+}
+
+// SnapshotDiff is the block-level difference between two turn snapshots, e.g.
+// two phases of the same turn or the final snapshots of two turns.
+type SnapshotDiff struct {
+	From     SnapshotKey `json:"from"`
+	To       SnapshotKey `json:"to"`
+	Blocks   []BlockDiff `json:"blocks"`
+	Metadata []ValueDiff `json:"metadata"`
+	Data     []ValueDiff `json:"data"`
+}
+
+// Empty reports whether the two snapshots have identical blocks, metadata and
+// data.
+func (d *SnapshotDiff) Empty() bool {
+	return d == nil || (len(d.Blocks) == 0 && len(d.Metadata) == 0 && len(d.Data) == 0)
+}
+
+type diffBlock struct {
+	id       string
+	kind     string
+	role     string
+	hash     string
+	payload  map[string]any
+	metadata map[string]any
+}
+
+type diffTurn struct {
+	blocks   []diffBlock
+	metadata map[string]any
+	data     map[string]any
+}
+
+// DiffSnapshots compares two snapshot records. Both records must carry their
+// turn payload YAML, as returned by SnapshotSource.LoadSnapshot.
+func DiffSnapshots(from, to *SnapshotRecord) (*SnapshotDiff, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("turn diff: both snapshots are required")
+	}
+	a, err := decodeDiffTurn(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decodeDiffTurn(to)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotDiff{
+		From:     from.Key(),
+		To:       to.Key(),
+		Blocks:   diffBlocks(a.blocks, b.blocks),
+		Metadata: diffValues(a.metadata, b.metadata),
+		Data:     diffValues(a.data, b.data),
+	}, nil
+}
+
+// DiffSnapshotKeys loads two snapshots from src and diffs them.
+func DiffSnapshotKeys(ctx context.Context, src SnapshotSource, from, to SnapshotKey) (*SnapshotDiff, error) {
+	a, err := src.LoadSnapshot(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("turn diff: load %s: %w", from, err)
+	}
+	if a == nil {
+		return nil, fmt.Errorf("turn diff: snapshot %s not found", from)
+	}
+	b, err := src.LoadSnapshot(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("turn diff: load %s: %w", to, err)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("turn diff: snapshot %s not found", to)
+	}
+	return DiffSnapshots(a, b)
+}
+
+func decodeDiffTurn(rec *SnapshotRecord) (*diffTurn, error) {
+	t, err := serde.FromYAML([]byte(rec.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("turn diff: parse payload of %s: %w", rec.Key(), err)
+	}
+	if t == nil {
+		return nil, fmt.Errorf("turn diff: parse payload of %s: decoded nil turn", rec.Key())
+	}
+	turnID := strings.TrimSpace(t.ID)
+	if turnID == "" {
+		turnID = rec.TurnID
+	}
+	out := &diffTurn{
+		metadata: turnMetadataToMap(t.Metadata),
+		data:     turnDataToMap(t.Data),
+	}
+	for i, block := range t.Blocks {
+		payload := cloneStringAnyMap(block.Payload)
+		metadata := blockMetadataToMap(block.Metadata)
+		kind := strings.TrimSpace(block.Kind.String())
+		role := strings.TrimSpace(block.Role)
+		hash, err := ComputeBlockContentHash(kind, role, payload, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("turn diff: hash block %d of %s: %w", i, rec.Key(), err)
+		}
+		out.blocks = append(out.blocks, diffBlock{
+			id:       normalizeBlockID(block.ID, turnID, i),
+			kind:     kind,
+			role:     role,
+			hash:     hash,
+			payload:  payload,
+			metadata: metadata,
+		})
+	}
+	return out, nil
+}
+
+func diffBlocks(from, to []diffBlock) []BlockDiff {
+	fromIndex := make(map[string]int, len(from))
+	for i, b := range from {
+		if _, ok := fromIndex[b.id]; !ok {
+			fromIndex[b.id] = i
+		}
+	}
+	toIndex := make(map[string]int, len(to))
+	for i, b := range to {
+		if _, ok := toIndex[b.id]; !ok {
+			toIndex[b.id] = i
+		}
+	}
+
+	// Blocks present on both sides keep their position when they belong to the
+	// longest common subsequence of shared IDs; the rest were reordered.
+	var sharedFrom, sharedTo []string
+	for _, b := range from {
+		if _, ok := toIndex[b.id]; ok {
+			sharedFrom = append(sharedFrom, b.id)
+		}
+	}
+	for _, b := range to {
+		if _, ok := fromIndex[b.id]; ok {
+			sharedTo = append(sharedTo, b.id)
+		}
+	}
+	stable := longestCommonIDs(sharedFrom, sharedTo)
+
+	out := []BlockDiff{}
+	for i, b := range from {
+		if _, ok := toIndex[b.id]; ok || fromIndex[b.id] != i {
+			continue
+		}
+		out = append(out, BlockDiff{
+			Change: DiffRemoved, BlockID: b.id, Kind: b.kind, Role: b.role,
+			FromIndex: i, ToIndex: -1, FromHash: b.hash,
+		})
+	}
+	for j, b := range to {
+		if toIndex[b.id] != j {
+			continue
+		}
+		i, ok := fromIndex[b.id]
+		if !ok {
+			out = append(out, BlockDiff{
+				Change: DiffAdded, BlockID: b.id, Kind: b.kind, Role: b.role,
+				FromIndex: -1, ToIndex: j, ToHash: b.hash,
+			})
+			continue
+		}
+		prev := from[i]
+		d := BlockDiff{
+			BlockID: b.id, Kind: b.kind, Role: b.role,
+			FromIndex: i, ToIndex: j, FromHash: prev.hash, ToHash: b.hash,
+		}
+		switch {
+		case prev.hash != b.hash:
+			d.Change = DiffModified
+			d.Fields = changedBlockFields(prev, b)
+		case !stable[b.id]:
+			d.Change = DiffMoved
+		default:
+			continue
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return blockDiffPosition(out[i]) < blockDiffPosition(out[j])
+	})
+	return out
+}
+
+// blockDiffPosition orders removed blocks by their old position and every
+// other change by its new position.
+func blockDiffPosition(d BlockDiff) int {
+	if d.ToIndex >= 0 {
+		return d.ToIndex
+	}
+	return d.FromIndex
+}
+
+func longestCommonIDs(a, b []string) map[string]bool {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	out := map[string]bool{}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			out[a[i]] = true
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return out
+}
+
+func changedBlockFields(a, b diffBlock) []string {
+	fields := []string{}
+	if a.kind != b.kind {
+		fields = append(fields, "kind")
+	}
+	if a.role != b.role {
+		fields = append(fields, "role")
+	}
+	for _, d := range diffValues(a.payload, b.payload) {
+		fields = append(fields, "payload."+d.Key)
+	}
+	for _, d := range diffValues(a.metadata, b.metadata) {
+		fields = append(fields, "metadata."+d.Key)
+	}
+	return fields
+}
+
+func diffValues(from, to map[string]any) []ValueDiff {
+	keys := make([]string, 0, len(from)+len(to))
+	for k := range from {
+		keys = append(keys, k)
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := []ValueDiff{}
+	for _, k := range keys {
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		switch {
+		case !inTo:
+			out = append(out, ValueDiff{Key: k, Change: DiffRemoved, From: a})
+		case !inFrom:
+			out = append(out, ValueDiff{Key: k, Change: DiffAdded, To: b})
+		case !sameJSONValue(a, b):
+			out = append(out, ValueDiff{Key: k, Change: DiffModified, From: a, To: b})
+		}
+	}
+	return out
+}
+
+// sameJSONValue compares values by their canonical JSON form so that decoded
+// YAML and JSON numbers or map types compare equal.
+func sameJSONValue(a, b any) bool {
+	ja, errA := json.Marshal(normalizeJSONValue(a))
+	jb, errB := json.Marshal(normalizeJSONValue(b))
+	if errA != nil || errB != nil {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return string(ja) == string(jb)
+}
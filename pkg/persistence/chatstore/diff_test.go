@@ -0,0 +1,142 @@
+package chatstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func diffTestRecord(phase string, createdAtMs int64, payload string) *SnapshotRecord {
+	return &SnapshotRecord{TurnSnapshot: TurnSnapshot{
+		ConvID: "conv-1", SessionID: "sess-1", TurnID: "turn-1", Phase: phase, CreatedAtMs: createdAtMs, Payload: payload,
+	}}
+}
+
+func TestDiffSnapshots_AddedRemovedModifiedMoved(t *testing.T) {
+	from := diffTestRecord("pre_inference", 100, `id: turn-1
+blocks:
+  - id: sys
+    kind: system
+    role: system
+    payload:
+      text: be brief
+  - id: u1
+    kind: user
+    role: user
+    payload:
+      text: hello
+  - id: tool-a
+    kind: tool_call
+    payload:
+      id: a
+      name: search
+  - id: tool-b
+    kind: tool_call
+    payload:
+      id: b
+      name: fetch
+  - id: dropped
+    kind: user
+    role: user
+    payload:
+      text: bye
+`)
+	to := diffTestRecord("final", 200, `id: turn-1
+blocks:
+  - id: sys
+    kind: system
+    role: system
+    payload:
+      text: be brief and precise
+  - id: u1
+    kind: user
+    role: user
+    payload:
+      text: hello
+  - id: tool-b
+    kind: tool_call
+    payload:
+      id: b
+      name: fetch
+  - id: tool-a
+    kind: tool_call
+    payload:
+      id: a
+      name: search
+  - id: reply
+    kind: llm_text
+    role: assistant
+    payload:
+      text: hi
+`)
+
+	diff, err := DiffSnapshots(from, to)
+	require.NoError(t, err)
+	require.False(t, diff.Empty())
+	require.Equal(t, from.Key(), diff.From)
+	require.Equal(t, to.Key(), diff.To)
+
+	changes := map[string]BlockDiff{}
+	for _, d := range diff.Blocks {
+		changes[d.BlockID] = d
+	}
+	require.Len(t, changes, 4)
+	require.Equal(t, DiffModified, changes["sys"].Change)
+	require.Equal(t, []string{"payload.text"}, changes["sys"].Fields)
+	require.NotEqual(t, changes["sys"].FromHash, changes["sys"].ToHash)
+	require.Equal(t, DiffMoved, changes["tool-a"].Change)
+	require.Equal(t, 2, changes["tool-a"].FromIndex)
+	require.Equal(t, 3, changes["tool-a"].ToIndex)
+	require.Equal(t, DiffAdded, changes["reply"].Change)
+	require.Equal(t, -1, changes["reply"].FromIndex)
+	require.Equal(t, DiffRemoved, changes["dropped"].Change)
+	require.Equal(t, -1, changes["dropped"].ToIndex)
+	_, unchanged := changes["u1"]
+	require.False(t, unchanged)
+
+	// Changes are ordered by position in the resulting snapshot.
+	require.Equal(t, "sys", diff.Blocks[0].BlockID)
+}
+
+func TestDiffSnapshots_IdenticalSnapshotsAreEmpty(t *testing.T) {
+	payload := validTurnPayloadTwoBlocks("turn-1")
+	diff, err := DiffSnapshots(diffTestRecord("pre_inference", 100, payload), diffTestRecord("final", 200, payload))
+	require.NoError(t, err)
+	require.True(t, diff.Empty())
+}
+
+func TestDiffValues(t *testing.T) {
+	got := diffValues(
+		map[string]any{"keep": 1, "change": map[string]any{"a": 1}, "drop": "x"},
+		map[string]any{"keep": float64(1), "change": map[string]any{"a": 2}, "add": true},
+	)
+	require.Equal(t, []ValueDiff{
+		{Key: "add", Change: DiffAdded, To: true},
+		{Key: "change", Change: DiffModified, From: map[string]any{"a": 1}, To: map[string]any{"a": 2}},
+		{Key: "drop", Change: DiffRemoved, From: "x"},
+	}, got)
+}
+
+func TestDiffSnapshotKeys_LoadsFromStore(t *testing.T) {
+	ctx := context.Background()
+	s := newTestSQLiteTurnStore(t, "diff.db")
+	require.NoError(t, s.Save(ctx, "conv-1", "sess-1", "turn-1", "pre_inference", 100, validTurnPayload("turn-1", "draft"), TurnSaveOptions{}))
+	require.NoError(t, s.Save(ctx, "conv-1", "sess-1", "turn-1", "final", 200, validTurnPayloadTwoBlocks("turn-1"), TurnSaveOptions{}))
+
+	keys, err := s.ListSnapshotKeys(ctx, SnapshotKeyQuery{SessionID: "sess-1"})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	diff, err := DiffSnapshotKeys(ctx, s, keys[0], keys[1])
+	require.NoError(t, err)
+	require.Equal(t, []BlockDiff{
+		{Change: DiffModified, BlockID: "turn-1-b1", Kind: "llm_text", Role: "assistant", FromIndex: 0, ToIndex: 0,
+			FromHash: diff.Blocks[0].FromHash, ToHash: diff.Blocks[0].ToHash, Fields: []string{"payload.text"}},
+		{Change: DiffAdded, BlockID: "turn-1-b2", Kind: "llm_text", Role: "assistant", FromIndex: -1, ToIndex: 1,
+			ToHash: diff.Blocks[1].ToHash},
+	}, diff.Blocks)
+
+	_, err = DiffSnapshotKeys(ctx, s, keys[0], SnapshotKey{ConvID: "conv-1", SessionID: "sess-1", TurnID: "missing", Phase: "final", CreatedAtMs: 1})
+	require.ErrorContains(t, err, "not found")
+}
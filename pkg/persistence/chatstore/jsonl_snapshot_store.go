@@ -0,0 +1,213 @@
+package chatstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// JSONLSnapshotStore is a dump file with one SnapshotRecord JSON object per
+// line. It is a SnapshotSink so turn stores can be dumped to and loaded from
+// plain files with MigrateSnapshots.
+//
+// Records are appended; when a key appears more than once the last line wins.
+// Only keys and file offsets are kept in memory, so large dumps can be read
+// back without loading every payload.
+type JSONLSnapshotStore struct {
+	mu       sync.Mutex
+	f        *os.File
+	readOnly bool
+	index    map[SnapshotKey]int64
+	keys     []SnapshotKey
+	end      int64
+}
+
+var _ SnapshotSink = &JSONLSnapshotStore{}
+
+// OpenJSONLSnapshotStore opens (or creates) a JSONL dump and indexes the
+// existing records.
+func OpenJSONLSnapshotStore(path string) (*JSONLSnapshotStore, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("jsonl snapshot store: empty path")
+	}
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, errors.Wrap(err, "jsonl snapshot store: create parent directory")
+		}
+	}
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "jsonl snapshot store: open")
+	}
+	s := &JSONLSnapshotStore{f: f, index: map[SnapshotKey]int64{}}
+	if err := s.reindex(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenJSONLSnapshotSource opens an existing JSONL dump read-only, e.g. as a
+// migration source. A torn final line is skipped rather than truncated and
+// SaveSnapshot fails.
+func OpenJSONLSnapshotSource(path string) (*JSONLSnapshotStore, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("jsonl snapshot store: empty path")
+	}
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "jsonl snapshot store: open")
+	}
+	s := &JSONLSnapshotStore{f: f, readOnly: true, index: map[SnapshotKey]int64{}}
+	if err := s.reindex(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *JSONLSnapshotStore) reindex() error {
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "jsonl snapshot store: seek")
+	}
+	r := bufio.NewReader(s.f)
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && strings.TrimSpace(string(line)) != "" {
+			var rec SnapshotRecord
+			if uerr := json.Unmarshal(line, &rec); uerr != nil {
+				if errors.Is(err, io.EOF) {
+					// A torn final line from an interrupted dump: drop it so a
+					// resumed migration appends over it.
+					if s.readOnly {
+						break
+					}
+					if terr := s.f.Truncate(offset); terr != nil {
+						return errors.Wrap(terr, "jsonl snapshot store: truncate torn record")
+					}
+					break
+				}
+				return errors.Wrapf(uerr, "jsonl snapshot store: line %d", lineNo)
+			}
+			key := rec.Key()
+			if _, ok := s.index[key]; !ok {
+				s.keys = append(s.keys, key)
+			}
+			s.index[key] = offset
+		}
+		offset += int64(len(line))
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 && line[len(line)-1] != '\n' && !s.readOnly {
+				// A complete final record without its newline: terminate it so
+				// the next append starts on a line of its own.
+				if _, werr := s.f.WriteAt([]byte{'\n'}, offset); werr != nil {
+					return errors.Wrap(werr, "jsonl snapshot store: terminate final record")
+				}
+				offset++
+			}
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "jsonl snapshot store: read")
+		}
+	}
+	s.end = offset
+	return nil
+}
+
+func (s *JSONLSnapshotStore) ListSnapshotKeys(_ context.Context, q SnapshotKeyQuery) ([]SnapshotKey, error) {
+	if s == nil || s.f == nil {
+		return nil, errors.New("jsonl snapshot store: file is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	convID := strings.TrimSpace(q.ConvID)
+	sessionID := strings.TrimSpace(q.SessionID)
+	out := make([]SnapshotKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		if convID != "" && k.ConvID != convID {
+			continue
+		}
+		if sessionID != "" && k.SessionID != sessionID {
+			continue
+		}
+		out = append(out, k)
+	}
+	sortSnapshotKeys(out)
+	return out, nil
+}
+
+func (s *JSONLSnapshotStore) LoadSnapshot(_ context.Context, key SnapshotKey) (*SnapshotRecord, error) {
+	if s == nil || s.f == nil {
+		return nil, errors.New("jsonl snapshot store: file is nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.index[key]
+	if !ok {
+		return nil, nil
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(s.f, offset, s.end-offset)).ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(err, "jsonl snapshot store: read record")
+	}
+	var rec SnapshotRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, errors.Wrap(err, "jsonl snapshot store: decode record")
+	}
+	return &rec, nil
+}
+
+// SaveSnapshot appends the record. Block hashes are kept as provided by the
+// source store so dumps preserve the original content hashes.
+func (s *JSONLSnapshotStore) SaveSnapshot(_ context.Context, rec SnapshotRecord) error {
+	if s == nil || s.f == nil {
+		return errors.New("jsonl snapshot store: file is nil")
+	}
+	if s.readOnly {
+		return errors.New("jsonl snapshot store: opened read-only")
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "jsonl snapshot store: encode record")
+	}
+	b = append(b, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.WriteAt(b, s.end); err != nil {
+		return errors.Wrap(err, "jsonl snapshot store: append record")
+	}
+	key := rec.Key()
+	if _, ok := s.index[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.index[key] = s.end
+	s.end += int64(len(b))
+	return nil
+}
+
+func (s *JSONLSnapshotStore) Close() error {
+	if s == nil || s.f == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly {
+		return s.f.Close()
+	}
+	if err := s.f.Sync(); err != nil {
+		_ = s.f.Close()
+		return errors.Wrap(err, "jsonl snapshot store: sync")
+	}
+	return s.f.Close()
+}
@@ -0,0 +1,316 @@
+package chatstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SnapshotBlockRef is one ordered membership entry of a stored snapshot.
+type SnapshotBlockRef struct {
+	BlockID     string `json:"block_id"`
+	ContentHash string `json:"content_hash"`
+}
+
+// SnapshotRecord is a stored snapshot together with the block references
+// recorded in turn_block_membership. It is the unit copied by MigrateSnapshots
+// and the line format of JSONL turn dumps.
+type SnapshotRecord struct {
+	TurnSnapshot
+	Blocks []SnapshotBlockRef `json:"blocks"`
+}
+
+// Key returns the snapshot identity of the record.
+func (r SnapshotRecord) Key() SnapshotKey {
+	return SnapshotKey{
+		ConvID:      r.ConvID,
+		SessionID:   r.SessionID,
+		TurnID:      r.TurnID,
+		Phase:       r.Phase,
+		CreatedAtMs: r.CreatedAtMs,
+	}
+}
+
+// SnapshotKeyQuery scopes snapshot enumeration. Empty fields match everything.
+type SnapshotKeyQuery struct {
+	ConvID    string
+	SessionID string
+}
+
+// SnapshotSource enumerates and loads every stored snapshot, independent of the
+// per-conversation List API used by debug surfaces.
+type SnapshotSource interface {
+	// ListSnapshotKeys returns snapshot keys ordered by creation time, then key.
+	ListSnapshotKeys(ctx context.Context, q SnapshotKeyQuery) ([]SnapshotKey, error)
+	// LoadSnapshot returns (nil, nil) when the snapshot does not exist.
+	LoadSnapshot(ctx context.Context, key SnapshotKey) (*SnapshotRecord, error)
+}
+
+// SnapshotSink accepts copied snapshots. Sinks are also sources so migrations
+// can skip already-copied snapshots and verify the result.
+type SnapshotSink interface {
+	SnapshotSource
+	SaveSnapshot(ctx context.Context, rec SnapshotRecord) error
+}
+
+// MigrateOptions controls MigrateSnapshots.
+type MigrateOptions struct {
+	Query SnapshotKeyQuery
+	// Resume skips snapshots whose key already exists in the destination.
+	Resume bool
+	// Verify compares snapshot counts and ordered block hashes after copying.
+	Verify bool
+	// Progress, when set, is called after every processed snapshot.
+	Progress func(done, total int)
+}
+
+// MigrateResult reports a migration pass.
+type MigrateResult struct {
+	SourceSnapshots      int64    `json:"source_snapshots"`
+	Copied               int64    `json:"copied"`
+	Skipped              int64    `json:"skipped"`
+	DestinationSnapshots int64    `json:"destination_snapshots"`
+	Verified             int64    `json:"verified"`
+	Mismatches           []string `json:"mismatches,omitempty"`
+}
+
+// MigrateSnapshots copies every snapshot from src to dst, preserving snapshot
+// keys, runtime keys, inference ids and block identities. Destination stores
+// recompute content hashes from the canonical block material, so verification
+// catches any lossy round trip.
+func MigrateSnapshots(ctx context.Context, src SnapshotSource, dst SnapshotSink, opts MigrateOptions) (*MigrateResult, error) {
+	if src == nil || dst == nil {
+		return nil, errors.New("migrate snapshots: source and destination are required")
+	}
+	if ctx == nil {
+		return nil, errors.New("migrate snapshots: ctx is nil")
+	}
+
+	keys, err := src.ListSnapshotKeys(ctx, opts.Query)
+	if err != nil {
+		return nil, errors.Wrap(err, "migrate snapshots: list source snapshots")
+	}
+	res := &MigrateResult{SourceSnapshots: int64(len(keys))}
+
+	existing := map[SnapshotKey]struct{}{}
+	if opts.Resume {
+		dstKeys, err := dst.ListSnapshotKeys(ctx, opts.Query)
+		if err != nil {
+			return nil, errors.Wrap(err, "migrate snapshots: list destination snapshots")
+		}
+		for _, k := range dstKeys {
+			existing[k] = struct{}{}
+		}
+	}
+
+	for i, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if _, ok := existing[key]; ok {
+			res.Skipped++
+		} else {
+			rec, err := src.LoadSnapshot(ctx, key)
+			if err != nil {
+				return res, errors.Wrapf(err, "migrate snapshots: load %s", key)
+			}
+			if rec == nil {
+				return res, errors.Errorf("migrate snapshots: source snapshot %s disappeared", key)
+			}
+			if err := dst.SaveSnapshot(ctx, *rec); err != nil {
+				return res, errors.Wrapf(err, "migrate snapshots: save %s", key)
+			}
+			res.Copied++
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(keys))
+		}
+	}
+
+	if !opts.Verify {
+		return res, nil
+	}
+	dstKeys, err := dst.ListSnapshotKeys(ctx, opts.Query)
+	if err != nil {
+		return res, errors.Wrap(err, "migrate snapshots: list destination snapshots for verification")
+	}
+	res.DestinationSnapshots = int64(len(dstKeys))
+	for _, key := range keys {
+		want, err := src.LoadSnapshot(ctx, key)
+		if err != nil {
+			return res, errors.Wrapf(err, "migrate snapshots: reload source %s", key)
+		}
+		got, err := dst.LoadSnapshot(ctx, key)
+		if err != nil {
+			return res, errors.Wrapf(err, "migrate snapshots: load destination %s", key)
+		}
+		if msg := compareSnapshotRecords(want, got); msg != "" {
+			res.Mismatches = append(res.Mismatches, fmt.Sprintf("%s: %s", key, msg))
+			continue
+		}
+		res.Verified++
+	}
+	return res, nil
+}
+
+func compareSnapshotRecords(want, got *SnapshotRecord) string {
+	switch {
+	case want == nil:
+		return "missing in source"
+	case got == nil:
+		return "missing in destination"
+	case want.RuntimeKey != got.RuntimeKey:
+		return fmt.Sprintf("runtime_key %q != %q", got.RuntimeKey, want.RuntimeKey)
+	case want.InferenceID != got.InferenceID:
+		return fmt.Sprintf("inference_id %q != %q", got.InferenceID, want.InferenceID)
+	case len(want.Blocks) != len(got.Blocks):
+		return fmt.Sprintf("block count %d != %d", len(got.Blocks), len(want.Blocks))
+	}
+	for i := range want.Blocks {
+		if want.Blocks[i] != got.Blocks[i] {
+			return fmt.Sprintf("block %d %s@%s != %s@%s", i, got.Blocks[i].BlockID, got.Blocks[i].ContentHash, want.Blocks[i].BlockID, want.Blocks[i].ContentHash)
+		}
+	}
+	return ""
+}
+
+// String renders the key as conv/session/turn/phase@createdAtMs.
+func (k SnapshotKey) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s@%d", k.ConvID, k.SessionID, k.TurnID, k.Phase, k.CreatedAtMs)
+}
+
+func sortSnapshotKeys(keys []SnapshotKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.CreatedAtMs != b.CreatedAtMs {
+			return a.CreatedAtMs < b.CreatedAtMs
+		}
+		if a.ConvID != b.ConvID {
+			return a.ConvID < b.ConvID
+		}
+		if a.SessionID != b.SessionID {
+			return a.SessionID < b.SessionID
+		}
+		if a.TurnID != b.TurnID {
+			return a.TurnID < b.TurnID
+		}
+		return a.Phase < b.Phase
+	})
+}
+
+// queryer is the subset of *sql.DB and *sql.Tx used by the shared snapshot
+// helpers.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// rebindQueryer rewrites the ? placeholders of the shared statements for
+// drivers that use another bind syntax.
+type rebindQueryer struct {
+	q      queryer
+	rebind func(string) string
+}
+
+func (r rebindQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.q.QueryContext(ctx, r.rebind(query), args...)
+}
+
+func listNormalizedSnapshotKeys(ctx context.Context, db queryer, q SnapshotKeyQuery) ([]SnapshotKey, error) {
+	scope, args := retentionScopeClause(RetentionPolicy{ConvID: q.ConvID, SessionID: q.SessionID})
+	// #nosec G202 -- scope only appends constant clause fragments; values remain parameterized.
+	rows, err := db.QueryContext(ctx, `
+		SELECT DISTINCT conv_id, session_id, turn_id, phase, snapshot_created_at_ms
+		FROM turn_block_membership
+		WHERE 1 = 1`+scope, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	keys := []SnapshotKey{}
+	for rows.Next() {
+		var k SnapshotKey
+		if err := rows.Scan(&k.ConvID, &k.SessionID, &k.TurnID, &k.Phase, &k.CreatedAtMs); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sortSnapshotKeys(keys)
+	return keys, nil
+}
+
+// loadNormalizedSnapshotHeader loads the turns row columns and the ordered
+// block references of one snapshot. found is false when the snapshot has no
+// membership rows.
+func loadNormalizedSnapshotHeader(ctx context.Context, db queryer, key SnapshotKey) (rec SnapshotRecord, turnMetadataJSON string, turnDataJSON string, found bool, err error) {
+	rec.TurnSnapshot = TurnSnapshot{
+		ConvID:      key.ConvID,
+		SessionID:   key.SessionID,
+		TurnID:      key.TurnID,
+		Phase:       key.Phase,
+		CreatedAtMs: key.CreatedAtMs,
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT block_id, content_hash
+		FROM turn_block_membership
+		WHERE conv_id = ? AND session_id = ? AND turn_id = ? AND phase = ? AND snapshot_created_at_ms = ?
+		ORDER BY ordinal ASC
+	`, key.ConvID, key.SessionID, key.TurnID, key.Phase, key.CreatedAtMs)
+	if err != nil {
+		return rec, "", "", false, err
+	}
+	for rows.Next() {
+		var ref SnapshotBlockRef
+		if err := rows.Scan(&ref.BlockID, &ref.ContentHash); err != nil {
+			_ = rows.Close()
+			return rec, "", "", false, err
+		}
+		rec.Blocks = append(rec.Blocks, ref)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return rec, "", "", false, err
+	}
+	_ = rows.Close()
+	if len(rec.Blocks) == 0 {
+		return rec, "", "", false, nil
+	}
+
+	turnMetadataJSON, turnDataJSON = "{}", "{}"
+	rows, err = db.QueryContext(ctx, `
+		SELECT runtime_key, inference_id, turn_metadata_json, turn_data_json
+		FROM turns
+		WHERE conv_id = ? AND session_id = ? AND turn_id = ?
+	`, key.ConvID, key.SessionID, key.TurnID)
+	if err != nil {
+		return rec, "", "", false, err
+	}
+	defer func() { _ = rows.Close() }()
+	if rows.Next() {
+		if err := rows.Scan(&rec.RuntimeKey, &rec.InferenceID, &turnMetadataJSON, &turnDataJSON); err != nil {
+			return rec, "", "", false, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return rec, "", "", false, err
+	}
+	return rec, turnMetadataJSON, turnDataJSON, true, nil
+}
+
+func saveSnapshotRecord(ctx context.Context, store TurnStore, rec SnapshotRecord) error {
+	if strings.TrimSpace(rec.Payload) == "" {
+		return errors.Errorf("snapshot %s has an empty payload", rec.Key())
+	}
+	return store.Save(ctx, rec.ConvID, rec.SessionID, rec.TurnID, rec.Phase, rec.CreatedAtMs, rec.Payload, TurnSaveOptions{
+		RuntimeKey:  rec.RuntimeKey,
+		InferenceID: rec.InferenceID,
+	})
+}
@@ -0,0 +1,178 @@
+package chatstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteTurnStore(t *testing.T, name string) *SQLiteTurnStore {
+	t.Helper()
+	dsn, err := SQLiteTurnDSNForFile(filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	s, err := NewSQLiteTurnStore(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestMigrateSnapshots_SQLiteToJSONLToSQLite(t *testing.T) {
+	ctx := context.Background()
+	src := newTestSQLiteTurnStore(t, "src.db")
+	require.NoError(t, src.Save(ctx, "conv-1", "sess-1", "turn-1", "pre_inference", 100, validTurnPayload("turn-1", "hello"), TurnSaveOptions{RuntimeKey: "planner", InferenceID: "inf-1"}))
+	require.NoError(t, src.Save(ctx, "conv-1", "sess-1", "turn-1", "final", 200, validTurnPayload("turn-1", "done"), TurnSaveOptions{RuntimeKey: "planner", InferenceID: "inf-1"}))
+	require.NoError(t, src.Save(ctx, "conv-2", "sess-2", "turn-2", "final", 300, validTurnPayload("turn-2", "other"), TurnSaveOptions{}))
+
+	dumpPath := filepath.Join(t.TempDir(), "dump", "turns.jsonl")
+	dump, err := OpenJSONLSnapshotStore(dumpPath)
+	require.NoError(t, err)
+	res, err := MigrateSnapshots(ctx, src, dump, MigrateOptions{Resume: true, Verify: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.SourceSnapshots)
+	require.Equal(t, int64(3), res.Copied)
+	require.Equal(t, int64(3), res.Verified)
+	require.Empty(t, res.Mismatches)
+	require.NoError(t, dump.Close())
+
+	dump, err = OpenJSONLSnapshotStore(dumpPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dump.Close() })
+
+	dst := newTestSQLiteTurnStore(t, "dst.db")
+	res, err = MigrateSnapshots(ctx, dump, dst, MigrateOptions{Resume: true, Verify: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), res.Copied)
+	require.Equal(t, int64(3), res.DestinationSnapshots)
+	require.Empty(t, res.Mismatches)
+
+	snap, err := dst.LoadLatestTurn(ctx, "conv-1", "final")
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	require.Equal(t, "planner", snap.RuntimeKey)
+	require.Equal(t, "inf-1", snap.InferenceID)
+	require.Contains(t, snap.Payload, "done")
+
+	res, err = MigrateSnapshots(ctx, dump, dst, MigrateOptions{Resume: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), res.Copied)
+	require.Equal(t, int64(3), res.Skipped)
+}
+
+func TestMigrateSnapshots_ScopedByConversation(t *testing.T) {
+	ctx := context.Background()
+	src := newTestSQLiteTurnStore(t, "src.db")
+	require.NoError(t, src.Save(ctx, "conv-1", "sess-1", "turn-1", "final", 100, validTurnPayload("turn-1", "hello"), TurnSaveOptions{}))
+	require.NoError(t, src.Save(ctx, "conv-2", "sess-2", "turn-2", "final", 200, validTurnPayload("turn-2", "other"), TurnSaveOptions{}))
+
+	dst := newTestSQLiteTurnStore(t, "dst.db")
+	res, err := MigrateSnapshots(ctx, src, dst, MigrateOptions{Query: SnapshotKeyQuery{ConvID: "conv-2"}, Verify: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.Copied)
+	require.Equal(t, int64(1), res.Verified)
+
+	keys, err := dst.ListSnapshotKeys(ctx, SnapshotKeyQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotKey{{ConvID: "conv-2", SessionID: "sess-2", TurnID: "turn-2", Phase: "final", CreatedAtMs: 200}}, keys)
+}
+
+func TestJSONLSnapshotStore_DropsTornFinalLine(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "turns.jsonl")
+	s, err := OpenJSONLSnapshotStore(path)
+	require.NoError(t, err)
+	rec := SnapshotRecord{
+		TurnSnapshot: TurnSnapshot{ConvID: "c", SessionID: "s", TurnID: "t", Phase: "final", CreatedAtMs: 1, Payload: validTurnPayload("t", "x")},
+		Blocks:       []SnapshotBlockRef{{BlockID: "t-b1", ContentHash: "abc"}},
+	}
+	require.NoError(t, s.SaveSnapshot(ctx, rec))
+	require.NoError(t, s.Close())
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"conv_id":"c","session_id"`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = OpenJSONLSnapshotStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	keys, err := s.ListSnapshotKeys(ctx, SnapshotKeyQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotKey{rec.Key()}, keys)
+
+	got, err := s.LoadSnapshot(ctx, rec.Key())
+	require.NoError(t, err)
+	require.Equal(t, rec, *got)
+}
+
+func TestJSONLSnapshotStore_TerminatesFinalRecord(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "turns.jsonl")
+	first := SnapshotRecord{
+		TurnSnapshot: TurnSnapshot{ConvID: "c", SessionID: "s", TurnID: "t", Phase: "final", CreatedAtMs: 1, Payload: validTurnPayload("t", "x")},
+		Blocks:       []SnapshotBlockRef{{BlockID: "t-b1", ContentHash: "abc"}},
+	}
+	second := first
+	second.CreatedAtMs = 2
+
+	s, err := OpenJSONLSnapshotStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveSnapshot(ctx, first))
+	require.NoError(t, s.Close())
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, b[:len(b)-1], 0o600))
+
+	s, err = OpenJSONLSnapshotStore(path)
+	require.NoError(t, err)
+	require.NoError(t, s.SaveSnapshot(ctx, second))
+	require.NoError(t, s.Close())
+
+	s, err = OpenJSONLSnapshotStore(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	keys, err := s.ListSnapshotKeys(ctx, SnapshotKeyQuery{})
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotKey{first.Key(), second.Key()}, keys)
+}
+
+func TestSnapshotSourcesAreReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	_, err := OpenJSONLSnapshotSource(filepath.Join(dir, "missing.jsonl"))
+	require.Error(t, err)
+	_, err = SQLiteTurnReadOnlyDSNForFile(filepath.Join(dir, "missing.db"))
+	require.Error(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	path := filepath.Join(dir, "turns.db")
+	dsn, err := SQLiteTurnDSNForFile(path)
+	require.NoError(t, err)
+	rw, err := NewSQLiteTurnStore(dsn)
+	require.NoError(t, err)
+	require.NoError(t, rw.Save(ctx, "conv-1", "sess-1", "turn-1", "final", 100, validTurnPayload("turn-1", "hello"), TurnSaveOptions{}))
+	require.NoError(t, rw.Close())
+
+	dsn, err = SQLiteTurnReadOnlyDSNForFile(path)
+	require.NoError(t, err)
+	ro, err := NewReadOnlySQLiteTurnStore(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ro.Close() })
+	keys, err := ro.ListSnapshotKeys(ctx, SnapshotKeyQuery{})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Error(t, ro.Save(ctx, "conv-1", "sess-1", "turn-2", "final", 200, validTurnPayload("turn-2", "more"), TurnSaveOptions{}))
+
+	jsonlPath := filepath.Join(dir, "turns.jsonl")
+	require.NoError(t, os.WriteFile(jsonlPath, nil, 0o600))
+	src, err := OpenJSONLSnapshotSource(jsonlPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = src.Close() })
+	require.Error(t, src.SaveSnapshot(ctx, SnapshotRecord{TurnSnapshot: TurnSnapshot{ConvID: "c", SessionID: "s", TurnID: "t", Phase: "final", CreatedAtMs: 1}}))
+}
@@ -39,6 +39,8 @@ type MySQLTurnStore struct {
 }
 
 var _ TurnStore = &MySQLTurnStore{}
+var _ TurnStoreGC = &MySQLTurnStore{}
+var _ SnapshotSink = &MySQLTurnStore{}
 
 // NewMySQLTurnStore opens a bounded MySQL pool, migrates the three-table schema
 // if needed, and returns a TurnStore. The dsn must be a go-sql-driver/mysql DSN
@@ -675,6 +677,78 @@ func (s *MySQLTurnStore) loadSnapshotBlocks(ctx context.Context, convID string,
 	return blocks, nil
 }
 
+// CollectGarbage applies the retention policy and removes orphaned turns and
+// blocks in a single transaction. The shared statements are portable between
+// SQLite and MySQL; only the orphan candidate scans add row locks.
+func (s *MySQLTurnStore) CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("mysql turn store: db is nil")
+	}
+	return collectNormalizedGarbage(ctx, s.db, "mysql turn store", nil, serverGCLockClause, opts)
+}
+
+// Optimize runs OPTIMIZE TABLE over the managed turn tables so InnoDB can
+// reclaim space released by garbage collection.
+func (s *MySQLTurnStore) Optimize(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return errors.New("mysql turn store: db is nil")
+	}
+	if ctx == nil {
+		return errors.New("mysql turn store: ctx is nil")
+	}
+	if _, err := s.db.ExecContext(ctx, `OPTIMIZE TABLE turns, blocks, turn_block_membership`); err != nil {
+		return errors.Wrap(err, "mysql turn store: optimize tables")
+	}
+	return nil
+}
+
+// ListSnapshotKeys enumerates every stored snapshot in the optional scope.
+func (s *MySQLTurnStore) ListSnapshotKeys(ctx context.Context, q SnapshotKeyQuery) ([]SnapshotKey, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("mysql turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("mysql turn store: ctx is nil")
+	}
+	keys, err := listNormalizedSnapshotKeys(ctx, s.db, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "mysql turn store: list snapshot keys")
+	}
+	return keys, nil
+}
+
+// LoadSnapshot loads one snapshot with its payload and stored block hashes.
+func (s *MySQLTurnStore) LoadSnapshot(ctx context.Context, key SnapshotKey) (*SnapshotRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("mysql turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("mysql turn store: ctx is nil")
+	}
+	rec, turnMetadataJSON, turnDataJSON, found, err := loadNormalizedSnapshotHeader(ctx, s.db, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "mysql turn store: load snapshot")
+	}
+	if !found {
+		return nil, nil
+	}
+	blockRows, err := s.loadSnapshotBlocks(ctx, key.ConvID, key.SessionID, key.TurnID, key.Phase, key.CreatedAtMs)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := buildTurnPayloadYAML(key.TurnID, blockRows, turnMetadataJSON, turnDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = payload
+	return &rec, nil
+}
+
+// SaveSnapshot stores a copied snapshot under its original key.
+func (s *MySQLTurnStore) SaveSnapshot(ctx context.Context, rec SnapshotRecord) error {
+	return saveSnapshotRecord(ctx, s, rec)
+}
+
 func normalizeMySQLBlockID(blockID string, turnID string, ordinal int) string {
 	if blockID != "" {
 		return blockID
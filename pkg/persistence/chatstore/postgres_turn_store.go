@@ -0,0 +1,716 @@
+package chatstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/geppetto/pkg/turns"
+	"github.com/go-go-golems/geppetto/pkg/turns/serde"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+)
+
+// PostgresTurnStore keeps the chatstore.turns component version in the same
+// pinocchio_schema_version table layout as MySQLTurnStore.
+const postgresTurnSchemaVersion int64 = 1
+
+const postgresTurnSchemaComponent = "chatstore.turns"
+
+// postgresTurnMigrationLockKey serializes concurrent first-time migrations
+// through pg_advisory_xact_lock.
+const postgresTurnMigrationLockKey int64 = 0x70696e6f63636869
+
+// PostgresTurnStore is a PostgreSQL implementation of TurnStore. It is a
+// dialect translation of SQLiteTurnStore over the same three tables (turns,
+// blocks, turn_block_membership) and the same single-transaction Save. Turn
+// metadata, turn data, block payloads and block metadata are stored as JSONB so
+// they can be queried in place.
+//
+// Dialect differences from SQLite/MySQL:
+//
+//   - $n placeholders; shared statements are rebound with rebindPostgres
+//   - INSERT ... ON CONFLICT DO UPDATE with LEAST/GREATEST for first-seen and
+//     updated timestamps
+//   - JSONB columns are read back through ::text casts
+//   - schema creation and the version row are written in one transaction
+type PostgresTurnStore struct {
+	db *sql.DB
+}
+
+var _ TurnStore = &PostgresTurnStore{}
+var _ TurnStoreGC = &PostgresTurnStore{}
+var _ SnapshotSink = &PostgresTurnStore{}
+
+// NewPostgresTurnStore opens a bounded pgx pool, migrates the three-table schema
+// if needed, and returns a TurnStore. The dsn is a libpq connection string or
+// postgres:// URL.
+func NewPostgresTurnStore(ctx context.Context, dsn string) (*PostgresTurnStore, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, errors.New("postgres turn store: empty dsn")
+	}
+	db, err := openChatstorePostgresPool(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: open db")
+	}
+	s := &PostgresTurnStore{db: db}
+	if err := s.migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "postgres turn store: migrate")
+	}
+	return s, nil
+}
+
+func (s *PostgresTurnStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *PostgresTurnStore) migrate(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return errors.New("postgres turn store: db is nil")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin migration tx")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgresTurnMigrationLockKey); err != nil {
+		return errors.Wrap(err, "acquire migration lock")
+	}
+
+	versionTableExists, err := postgresTableExists(ctx, tx, "pinocchio_schema_version")
+	if err != nil {
+		return errors.Wrap(err, "inspect schema version table")
+	}
+	if !versionTableExists {
+		if _, err := tx.ExecContext(ctx, `
+			CREATE TABLE pinocchio_schema_version (
+				component TEXT NOT NULL PRIMARY KEY,
+				schema_version BIGINT NOT NULL
+			)
+		`); err != nil {
+			return errors.Wrap(err, "create schema version table")
+		}
+	}
+
+	var version int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT schema_version FROM pinocchio_schema_version WHERE component = $1
+	`, postgresTurnSchemaComponent).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		for _, table := range []string{"turns", "blocks", "turn_block_membership"} {
+			exists, err := postgresTableExists(ctx, tx, table)
+			if err != nil {
+				return errors.Wrapf(err, "inspect turn table %s", table)
+			}
+			if exists {
+				return errors.New("postgres turn store: unversioned turn tables detected; recreate the schema or migrate it explicitly")
+			}
+		}
+		for _, st := range postgresTurnCreateTableStatements {
+			if _, err := tx.ExecContext(ctx, st); err != nil {
+				return errors.Wrap(err, "create turn schema")
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pinocchio_schema_version(component, schema_version) VALUES($1, $2)
+		`, postgresTurnSchemaComponent, postgresTurnSchemaVersion); err != nil {
+			return errors.Wrap(err, "record turn schema version")
+		}
+	case err != nil:
+		return errors.Wrap(err, "read turn schema version")
+	case version != postgresTurnSchemaVersion:
+		return errors.Errorf("postgres turn store: unsupported chatstore.turns schema version %d (want %d)", version, postgresTurnSchemaVersion)
+	default:
+		for _, table := range []string{"turns", "blocks", "turn_block_membership"} {
+			exists, err := postgresTableExists(ctx, tx, table)
+			if err != nil {
+				return errors.Wrapf(err, "inspect managed table %s", table)
+			}
+			if !exists {
+				return errors.Errorf("postgres turn store: schema version %d is recorded but managed table %s is missing", version, table)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "commit migration tx")
+	}
+	committed = true
+	return nil
+}
+
+var postgresTurnCreateTableStatements = []string{
+	`CREATE TABLE turns (
+		conv_id TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		turn_id TEXT NOT NULL,
+		turn_created_at_ms BIGINT NOT NULL,
+		turn_metadata_json JSONB NOT NULL,
+		turn_data_json JSONB NOT NULL,
+		runtime_key TEXT NOT NULL DEFAULT '',
+		inference_id TEXT NOT NULL DEFAULT '',
+		updated_at_ms BIGINT NOT NULL,
+		PRIMARY KEY (conv_id, session_id, turn_id)
+	)`,
+	`CREATE INDEX turns_by_conv_session ON turns(conv_id, session_id, updated_at_ms)`,
+	`CREATE INDEX turns_by_session ON turns(session_id, updated_at_ms)`,
+	`CREATE INDEX turns_by_conv_runtime ON turns(conv_id, runtime_key, updated_at_ms)`,
+	`CREATE INDEX turns_by_conv_inference ON turns(conv_id, inference_id, updated_at_ms)`,
+	`CREATE TABLE blocks (
+		block_id TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		hash_algorithm TEXT NOT NULL DEFAULT 'sha256-canonical-json-v1',
+		kind TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT '',
+		payload_json JSONB NOT NULL,
+		block_metadata_json JSONB NOT NULL,
+		first_seen_at_ms BIGINT NOT NULL,
+		PRIMARY KEY (block_id, content_hash)
+	)`,
+	`CREATE INDEX blocks_by_kind_role ON blocks(kind, role)`,
+	`CREATE TABLE turn_block_membership (
+		conv_id TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		turn_id TEXT NOT NULL,
+		phase TEXT NOT NULL,
+		snapshot_created_at_ms BIGINT NOT NULL,
+		ordinal INTEGER NOT NULL,
+		block_id TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		PRIMARY KEY (conv_id, session_id, turn_id, phase, snapshot_created_at_ms, ordinal)
+	)`,
+	`CREATE INDEX tmem_by_block ON turn_block_membership(block_id, content_hash)`,
+}
+
+func postgresTableExists(ctx context.Context, tx *sql.Tx, table string) (bool, error) {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = $1
+		)
+	`, table).Scan(&exists)
+	return exists, err
+}
+
+func (s *PostgresTurnStore) Save(ctx context.Context, convID, sessionID, turnID, phase string, createdAtMs int64, payload string, opts TurnSaveOptions) error {
+	if s == nil || s.db == nil {
+		return errors.New("postgres turn store: db is nil")
+	}
+	if strings.TrimSpace(convID) == "" {
+		return errors.New("postgres turn store: convID is empty")
+	}
+	if strings.TrimSpace(sessionID) == "" {
+		return errors.New("postgres turn store: sessionID is empty")
+	}
+	if strings.TrimSpace(turnID) == "" {
+		return errors.New("postgres turn store: turnID is empty")
+	}
+	if strings.TrimSpace(phase) == "" {
+		return errors.New("postgres turn store: phase is empty")
+	}
+	if ctx == nil {
+		return errors.New("postgres turn store: ctx is nil")
+	}
+	if createdAtMs <= 0 {
+		createdAtMs = time.Now().UnixMilli()
+	}
+
+	t, err := serde.FromYAML([]byte(payload))
+	if err != nil {
+		return errors.Wrap(err, "postgres turn store: parse payload yaml")
+	}
+	if t == nil {
+		return errors.New("postgres turn store: parse payload yaml: decoded nil turn")
+	}
+
+	row := snapshotBackfillRow{
+		convID:      convID,
+		sessionID:   sessionID,
+		turnID:      turnID,
+		phase:       phase,
+		runtimeKey:  opts.RuntimeKey,
+		inferenceID: opts.InferenceID,
+		createdAtMs: createdAtMs,
+	}
+	if row.runtimeKey == "" {
+		row.runtimeKey = runtimeKeyFromTurnMetadata(t.Metadata)
+	}
+	if row.inferenceID == "" {
+		row.inferenceID = inferenceIDFromTurnMetadata(t.Metadata)
+	}
+	_, err = s.persistNormalizedSnapshot(ctx, row, t)
+	return err
+}
+
+// persistNormalizedSnapshot mirrors SQLiteTurnStore.persistNormalizedSnapshot
+// with PostgreSQL SQL: upsert the turn row, delete the prior membership rowset
+// for this (turn, phase, snapshot), then upsert blocks and insert membership
+// rows, all in one transaction.
+func (s *PostgresTurnStore) persistNormalizedSnapshot(ctx context.Context, row snapshotBackfillRow, t *turns.Turn) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: begin tx")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	turnID := row.turnID
+	if t.ID != "" {
+		turnID = t.ID
+	}
+	if turnID == "" {
+		turnID = "turn"
+	}
+
+	turnMetadataJSON, err := marshalJSONObject(turnMetadataToMap(t.Metadata))
+	if err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: marshal turn metadata")
+	}
+	turnDataJSON, err := marshalJSONObject(turnDataToMap(t.Data))
+	if err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: marshal turn data")
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO turns(
+			conv_id, session_id, turn_id, turn_created_at_ms, turn_metadata_json, turn_data_json, runtime_key, inference_id, updated_at_ms
+		)
+		VALUES($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7, $8, $9)
+		ON CONFLICT (conv_id, session_id, turn_id) DO UPDATE SET
+			turn_created_at_ms = LEAST(turns.turn_created_at_ms, excluded.turn_created_at_ms),
+			turn_metadata_json = excluded.turn_metadata_json,
+			turn_data_json = excluded.turn_data_json,
+			runtime_key = CASE
+				WHEN excluded.runtime_key <> '' THEN excluded.runtime_key
+				ELSE turns.runtime_key
+			END,
+			inference_id = CASE
+				WHEN excluded.inference_id <> '' THEN excluded.inference_id
+				ELSE turns.inference_id
+			END,
+			updated_at_ms = GREATEST(turns.updated_at_ms, excluded.updated_at_ms)
+	`, row.convID, row.sessionID, turnID, row.createdAtMs, turnMetadataJSON, turnDataJSON, row.runtimeKey, row.inferenceID, row.createdAtMs); err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: upsert turns row")
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM turn_block_membership
+		WHERE conv_id = $1 AND session_id = $2 AND turn_id = $3 AND phase = $4 AND snapshot_created_at_ms = $5
+	`, row.convID, row.sessionID, turnID, row.phase, row.createdAtMs); err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: clear existing membership rowset")
+	}
+
+	membershipInserted := 0
+	for i, block := range t.Blocks {
+		blockID := normalizeMySQLBlockID(block.ID, turnID, i)
+		payloadMap := cloneStringAnyMap(block.Payload)
+		blockMetadata := blockMetadataToMap(block.Metadata)
+
+		contentHash, err := ComputeBlockContentHash(block.Kind.String(), block.Role, payloadMap, blockMetadata)
+		if err != nil {
+			return 0, errors.Wrap(err, "postgres turn store: compute block content hash")
+		}
+		payloadJSON, err := marshalJSONObject(payloadMap)
+		if err != nil {
+			return 0, errors.Wrap(err, "postgres turn store: marshal block payload")
+		}
+		blockMetadataJSON, err := marshalJSONObject(blockMetadata)
+		if err != nil {
+			return 0, errors.Wrap(err, "postgres turn store: marshal block metadata")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO blocks(
+				block_id, content_hash, hash_algorithm, kind, role, payload_json, block_metadata_json, first_seen_at_ms
+			)
+			VALUES($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb, $8)
+			ON CONFLICT (block_id, content_hash) DO UPDATE SET
+				kind = excluded.kind,
+				role = excluded.role,
+				payload_json = excluded.payload_json,
+				block_metadata_json = excluded.block_metadata_json,
+				first_seen_at_ms = LEAST(blocks.first_seen_at_ms, excluded.first_seen_at_ms)
+		`, blockID, contentHash, BlockContentHashAlgorithmV1, block.Kind.String(), block.Role, payloadJSON, blockMetadataJSON, row.createdAtMs); err != nil {
+			return 0, errors.Wrap(err, "postgres turn store: upsert blocks row")
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO turn_block_membership(
+				conv_id, session_id, turn_id, phase, snapshot_created_at_ms, ordinal, block_id, content_hash
+			)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (conv_id, session_id, turn_id, phase, snapshot_created_at_ms, ordinal) DO UPDATE SET
+				block_id = excluded.block_id,
+				content_hash = excluded.content_hash
+		`, row.convID, row.sessionID, turnID, row.phase, row.createdAtMs, i, blockID, contentHash); err != nil {
+			return 0, errors.Wrap(err, "postgres turn store: insert turn_block_membership")
+		}
+		membershipInserted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "postgres turn store: commit tx")
+	}
+	committed = true
+	return membershipInserted, nil
+}
+
+// postgresSnapshotSelect selects distinct snapshots from turn_block_membership
+// in a subquery and joins the turns row afterwards; JSONB has no MAX aggregate,
+// so the SQLite/MySQL GROUP BY form does not translate.
+const postgresSnapshotSelect = `
+	SELECT
+		s.conv_id,
+		s.session_id,
+		s.turn_id,
+		s.phase,
+		s.snapshot_created_at_ms,
+		COALESCE(t.runtime_key, '') AS runtime_key,
+		COALESCE(t.inference_id, '') AS inference_id,
+		COALESCE(t.turn_metadata_json::text, '{}') AS turn_metadata_json,
+		COALESCE(t.turn_data_json::text, '{}') AS turn_data_json
+	FROM (
+		SELECT DISTINCT m.conv_id, m.session_id, m.turn_id, m.phase, m.snapshot_created_at_ms
+		FROM turn_block_membership m
+		%s
+	) s
+	LEFT JOIN turns t
+		ON t.conv_id = s.conv_id
+		AND t.session_id = s.session_id
+		AND t.turn_id = s.turn_id
+	ORDER BY s.snapshot_created_at_ms DESC
+	LIMIT %s
+`
+
+func (s *PostgresTurnStore) List(ctx context.Context, q TurnQuery) ([]TurnSnapshot, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("postgres turn store: db is nil")
+	}
+	if strings.TrimSpace(q.ConvID) == "" && strings.TrimSpace(q.SessionID) == "" {
+		return nil, errors.New("postgres turn store: convID or sessionID required")
+	}
+	if ctx == nil {
+		return nil, errors.New("postgres turn store: ctx is nil")
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 200
+	}
+
+	clauses := []string{}
+	args := []any{}
+	if v := q.ConvID; strings.TrimSpace(v) != "" {
+		args = append(args, v)
+		clauses = append(clauses, fmt.Sprintf("m.conv_id = $%d", len(args)))
+	}
+	if v := q.SessionID; strings.TrimSpace(v) != "" {
+		args = append(args, v)
+		clauses = append(clauses, fmt.Sprintf("m.session_id = $%d", len(args)))
+	}
+	if v := q.Phase; strings.TrimSpace(v) != "" {
+		args = append(args, v)
+		clauses = append(clauses, fmt.Sprintf("m.phase = $%d", len(args)))
+	}
+	if q.SinceMs > 0 {
+		args = append(args, q.SinceMs)
+		clauses = append(clauses, fmt.Sprintf("m.snapshot_created_at_ms >= $%d", len(args)))
+	}
+	where := ""
+	if len(clauses) > 0 {
+		where = "WHERE " + strings.Join(clauses, " AND ")
+	}
+	args = append(args, limit)
+
+	// #nosec G201 -- where and the limit placeholder are constant fragments; values remain parameterized in args.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(postgresSnapshotSelect, where, "$"+strconv.Itoa(len(args))), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: query")
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := []TurnSnapshot{}
+	for rows.Next() {
+		item, err := s.scanSnapshot(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *PostgresTurnStore) LoadLatestTurn(ctx context.Context, convID, phase string) (*TurnSnapshot, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("postgres turn store: db is nil")
+	}
+	if strings.TrimSpace(convID) == "" {
+		return nil, errors.New("postgres turn store: convID is empty")
+	}
+	if ctx == nil {
+		return nil, errors.New("postgres turn store: ctx is nil")
+	}
+	clauses := []string{"m.conv_id = $1"}
+	args := []any{convID}
+	if phase != "" {
+		clauses = append(clauses, "m.phase = $2")
+		args = append(args, phase)
+	}
+	where := "WHERE " + strings.Join(clauses, " AND ")
+
+	// #nosec G201 -- where only interpolates constant clause fragments; values remain parameterized in args.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(postgresSnapshotSelect, where, "1"), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: load latest turn")
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	item, err := s.scanSnapshot(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// scanSnapshot scans one postgresSnapshotSelect row and rebuilds its payload.
+// Snapshot blocks are loaded on the store pool, so callers must not hold the
+// only available connection.
+func (s *PostgresTurnStore) scanSnapshot(ctx context.Context, rows *sql.Rows) (TurnSnapshot, error) {
+	var (
+		item             TurnSnapshot
+		turnMetadataJSON string
+		turnDataJSON     string
+	)
+	if err := rows.Scan(
+		&item.ConvID,
+		&item.SessionID,
+		&item.TurnID,
+		&item.Phase,
+		&item.CreatedAtMs,
+		&item.RuntimeKey,
+		&item.InferenceID,
+		&turnMetadataJSON,
+		&turnDataJSON,
+	); err != nil {
+		return item, err
+	}
+	blockRows, err := s.loadSnapshotBlocks(ctx, item.ConvID, item.SessionID, item.TurnID, item.Phase, item.CreatedAtMs)
+	if err != nil {
+		return item, err
+	}
+	payload, err := buildTurnPayloadYAML(item.TurnID, blockRows, turnMetadataJSON, turnDataJSON)
+	if err != nil {
+		return item, err
+	}
+	item.Payload = payload
+	return item, nil
+}
+
+func (s *PostgresTurnStore) loadSnapshotBlocks(ctx context.Context, convID string, sessionID string, turnID string, phase string, snapshotCreatedAtMs int64) ([]map[string]any, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			m.ordinal,
+			b.block_id,
+			b.kind,
+			b.role,
+			COALESCE(b.payload_json::text, '{}') AS payload_json,
+			COALESCE(b.block_metadata_json::text, '{}') AS block_metadata_json
+		FROM turn_block_membership m
+		JOIN blocks b
+			ON b.block_id = m.block_id
+			AND b.content_hash = m.content_hash
+		WHERE
+			m.conv_id = $1
+			AND m.session_id = $2
+			AND m.turn_id = $3
+			AND m.phase = $4
+			AND m.snapshot_created_at_ms = $5
+		ORDER BY m.ordinal ASC
+	`, convID, sessionID, turnID, phase, snapshotCreatedAtMs)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: query snapshot blocks")
+	}
+	defer func() { _ = rows.Close() }()
+
+	blocks := make([]map[string]any, 0, 16)
+	for rows.Next() {
+		var (
+			ordinal           int
+			blockID           string
+			kind              string
+			role              string
+			payloadJSON       string
+			blockMetadataJSON string
+		)
+		if err := rows.Scan(&ordinal, &blockID, &kind, &role, &payloadJSON, &blockMetadataJSON); err != nil {
+			return nil, errors.Wrap(err, "postgres turn store: scan snapshot block")
+		}
+		_ = ordinal
+		payloadMap, err := parseJSONObject(payloadJSON)
+		if err != nil {
+			return nil, errors.Wrap(err, "postgres turn store: parse block payload json")
+		}
+		metadataMap, err := parseJSONObject(blockMetadataJSON)
+		if err != nil {
+			return nil, errors.Wrap(err, "postgres turn store: parse block metadata json")
+		}
+		block := map[string]any{
+			"id":   blockID,
+			"kind": kind,
+		}
+		if strings.TrimSpace(role) != "" {
+			block["role"] = role
+		}
+		if len(payloadMap) > 0 {
+			block["payload"] = payloadMap
+		}
+		if len(metadataMap) > 0 {
+			block["metadata"] = metadataMap
+		}
+		blocks = append(blocks, block)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: iterate snapshot blocks")
+	}
+	return blocks, nil
+}
+
+// CollectGarbage applies the retention policy and removes orphaned turns and
+// blocks in a single transaction, using the shared statements rebound to $n
+// placeholders. Orphan candidates are row-locked and re-checked, so a Save
+// running concurrently keeps the turn and blocks it references.
+func (s *PostgresTurnStore) CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("postgres turn store: db is nil")
+	}
+	return collectNormalizedGarbage(ctx, s.db, "postgres turn store", rebindPostgres, serverGCLockClause, opts)
+}
+
+// Optimize runs VACUUM (ANALYZE) over the managed turn tables. VACUUM does not
+// shrink files like VACUUM FULL, but makes freed space reusable without taking
+// exclusive locks.
+func (s *PostgresTurnStore) Optimize(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return errors.New("postgres turn store: db is nil")
+	}
+	if ctx == nil {
+		return errors.New("postgres turn store: ctx is nil")
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM (ANALYZE) turns, blocks, turn_block_membership`); err != nil {
+		return errors.Wrap(err, "postgres turn store: vacuum tables")
+	}
+	return nil
+}
+
+// ListSnapshotKeys enumerates every stored snapshot in the optional scope.
+func (s *PostgresTurnStore) ListSnapshotKeys(ctx context.Context, q SnapshotKeyQuery) ([]SnapshotKey, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("postgres turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("postgres turn store: ctx is nil")
+	}
+	keys, err := listNormalizedSnapshotKeys(ctx, rebindQueryer{q: s.db, rebind: rebindPostgres}, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: list snapshot keys")
+	}
+	return keys, nil
+}
+
+// LoadSnapshot loads one snapshot with its payload and stored block hashes.
+func (s *PostgresTurnStore) LoadSnapshot(ctx context.Context, key SnapshotKey) (*SnapshotRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("postgres turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("postgres turn store: ctx is nil")
+	}
+	rec, turnMetadataJSON, turnDataJSON, found, err := loadNormalizedSnapshotHeader(ctx, rebindQueryer{q: s.db, rebind: rebindPostgres}, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres turn store: load snapshot")
+	}
+	if !found {
+		return nil, nil
+	}
+	blockRows, err := s.loadSnapshotBlocks(ctx, key.ConvID, key.SessionID, key.TurnID, key.Phase, key.CreatedAtMs)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := buildTurnPayloadYAML(key.TurnID, blockRows, turnMetadataJSON, turnDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = payload
+	return &rec, nil
+}
+
+// SaveSnapshot stores a copied snapshot under its original key.
+func (s *PostgresTurnStore) SaveSnapshot(ctx context.Context, rec SnapshotRecord) error {
+	return saveSnapshotRecord(ctx, s, rec)
+}
+
+// rebindPostgres rewrites the ? placeholders of the shared portable statements
+// to PostgreSQL $n placeholders. The shared statements never contain ? inside
+// string literals.
+func rebindPostgres(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// openChatstorePostgresPool opens a bounded database/sql pool for the pgx
+// stdlib driver, sized like the MySQL pool.
+func openChatstorePostgresPool(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(20)
+	db.SetMaxIdleConns(10)
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetConnMaxIdleTime(time.Minute)
+	return db, nil
+}
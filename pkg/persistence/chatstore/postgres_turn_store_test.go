@@ -0,0 +1,175 @@
+package chatstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// postgresTurnTestDSN returns the PostgreSQL DSN for integration tests. Tests
+// skip when it is unset so `go test ./...` stays green without PostgreSQL. Set
+// PINOCCHIO_POSTGRES_TURNS_DSN, or PINOCCHIO_POSTGRES_TESTCONTAINERS=1 to start
+// a disposable container from TestMain.
+func postgresTurnTestDSN(t *testing.T) string {
+	t.Helper()
+	dsn := os.Getenv("PINOCCHIO_POSTGRES_TURNS_DSN")
+	if dsn == "" {
+		t.Skipf("PINOCCHIO_POSTGRES_TURNS_DSN not set; skipping PostgreSQL turn store integration test")
+	}
+	return dsn
+}
+
+func newTestPostgresTurnStore(t *testing.T) *PostgresTurnStore {
+	t.Helper()
+	s, err := NewPostgresTurnStore(context.Background(), postgresTurnTestDSN(t))
+	require.NoError(t, err, "NewPostgresTurnStore")
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func pgRowCount(t *testing.T, s *PostgresTurnStore, query string, args ...any) int64 {
+	t.Helper()
+	var n int64
+	require.NoError(t, s.db.QueryRowContext(context.Background(), query, args...).Scan(&n))
+	return n
+}
+
+func TestRebindPostgres(t *testing.T) {
+	require.Equal(t, "SELECT 1", rebindPostgres("SELECT 1"))
+	require.Equal(t,
+		"DELETE FROM m WHERE conv_id = $1 AND session_id = $2 AND snapshot_created_at_ms = $3",
+		rebindPostgres("DELETE FROM m WHERE conv_id = ? AND session_id = ? AND snapshot_created_at_ms = ?"),
+	)
+}
+
+func TestPostgresTurnStore_SaveAndList(t *testing.T) {
+	s := newTestPostgresTurnStore(t)
+	// Migrate is idempotent: re-open does not error and keeps the version row.
+	s2 := newTestPostgresTurnStore(t)
+	require.Equal(t, postgresTurnSchemaVersion, pgRowCount(t, s2, `SELECT schema_version FROM pinocchio_schema_version WHERE component = $1`, postgresTurnSchemaComponent))
+
+	ctx := context.Background()
+	conv := sanitizeTurnID(fmt.Sprintf("conv-pg-%s", t.Name()))
+
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-1", "final", 100, validTurnPayload("turn-1", "hello"), TurnSaveOptions{
+		RuntimeKey: "inventory", InferenceID: "inf-1",
+	}))
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-2", "draft", 200, validTurnPayload("turn-2", "draft"), TurnSaveOptions{
+		RuntimeKey: "planner", InferenceID: "inf-2",
+	}))
+	require.NoError(t, s.Save(ctx, conv+"-other", "sess-2", "turn-3", "final", 300, validTurnPayload("turn-3", "other"), TurnSaveOptions{}))
+
+	items, err := s.List(ctx, TurnQuery{ConvID: conv, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "turn-2", items[0].TurnID)
+	require.Equal(t, "turn-1", items[1].TurnID)
+	require.Equal(t, "planner", items[0].RuntimeKey)
+	require.Equal(t, "inf-1", items[1].InferenceID)
+	require.Contains(t, items[1].Payload, "text: hello")
+
+	byPhase, err := s.List(ctx, TurnQuery{ConvID: conv, Phase: "final", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, byPhase, 1)
+	require.Equal(t, "turn-1", byPhase[0].TurnID)
+
+	latest, err := s.LoadLatestTurn(ctx, conv, "final")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	require.Equal(t, "turn-1", latest.TurnID)
+	missing, err := s.LoadLatestTurn(ctx, conv+"-missing", "")
+	require.NoError(t, err)
+	require.Nil(t, missing)
+
+	// Payload columns are JSONB and queryable in place.
+	require.Equal(t, int64(1), pgRowCount(t, s, `
+		SELECT COUNT(1) FROM turn_block_membership m
+		JOIN blocks b ON b.block_id = m.block_id AND b.content_hash = m.content_hash
+		WHERE m.conv_id = $1 AND b.payload_json->>'text' = 'hello'
+	`, conv))
+}
+
+func TestPostgresTurnStore_ReSaveReplacesMembershipRowset(t *testing.T) {
+	s := newTestPostgresTurnStore(t)
+	ctx := context.Background()
+	conv := sanitizeTurnID(t.Name())
+
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-1", "final", 100, validTurnPayloadTwoBlocks("turn-1"), TurnSaveOptions{}))
+	require.Equal(t, int64(2), pgRowCount(t, s, `SELECT COUNT(1) FROM turn_block_membership WHERE conv_id = $1`, conv))
+
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-1", "final", 100, validTurnPayload("turn-1", "only"), TurnSaveOptions{}))
+	require.Equal(t, int64(1), pgRowCount(t, s, `SELECT COUNT(1) FROM turn_block_membership WHERE conv_id = $1`, conv))
+}
+
+func TestPostgresTurnStore_CollectGarbageAndMigrate(t *testing.T) {
+	s := newTestPostgresTurnStore(t)
+	ctx := context.Background()
+	conv := sanitizeTurnID(t.Name())
+
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-1", "pre_inference", 100, validTurnPayload("turn-1", "draft"), TurnSaveOptions{}))
+	require.NoError(t, s.Save(ctx, conv, "sess-1", "turn-1", "final", 200, validTurnPayload("turn-1", "done"), TurnSaveOptions{RuntimeKey: "rt"}))
+
+	dst := newTestSQLiteTurnStore(t, "from-postgres.db")
+	res, err := MigrateSnapshots(ctx, s, dst, MigrateOptions{Query: SnapshotKeyQuery{ConvID: conv}, Verify: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.Copied)
+	require.Empty(t, res.Mismatches)
+
+	gc, err := s.CollectGarbage(ctx, GCOptions{Policy: RetentionPolicy{ConvID: conv, MaxSnapshotsPerTurn: 1}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), gc.SnapshotsDeleted)
+	keys, err := s.ListSnapshotKeys(ctx, SnapshotKeyQuery{ConvID: conv})
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotKey{{ConvID: conv, SessionID: "sess-1", TurnID: "turn-1", Phase: "final", CreatedAtMs: 200}}, keys)
+	require.NoError(t, s.Optimize(ctx))
+}
+
+func TestPostgresTurnStore_CollectGarbageConcurrentWithSave(t *testing.T) {
+	s := newTestPostgresTurnStore(t)
+	ctx := context.Background()
+	conv := sanitizeTurnID(t.Name())
+	// Every save reuses the same block, and every GC pass expires every
+	// snapshot it sees, so the block is repeatedly orphaned while a save is
+	// about to reference it again.
+	policy := RetentionPolicy{ConvID: conv, MaxAge: time.Millisecond, NowMs: time.Now().Add(time.Hour).UnixMilli()}
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*rounds)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= rounds; i++ {
+			errs <- s.Save(ctx, conv, "sess-1", "turn-1", "final", int64(i), validTurnPayload("turn-1", "shared"), TurnSaveOptions{})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			_, err := s.CollectGarbage(ctx, GCOptions{Policy: policy})
+			errs <- err
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int64(0), pgRowCount(t, s, `
+		SELECT COUNT(1) FROM turn_block_membership m
+		WHERE m.conv_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM blocks b WHERE b.block_id = m.block_id AND b.content_hash = m.content_hash
+		)`, conv), "membership rows must not reference collected blocks")
+	require.Equal(t, int64(0), pgRowCount(t, s, `
+		SELECT COUNT(1) FROM turn_block_membership m
+		WHERE m.conv_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM turns t
+			WHERE t.conv_id = m.conv_id AND t.session_id = m.session_id AND t.turn_id = m.turn_id
+		)`, conv), "membership rows must not reference collected turns")
+}
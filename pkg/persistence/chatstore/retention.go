@@ -0,0 +1,378 @@
+package chatstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRetentionFinalPhase is the snapshot phase preserved by
+// RetentionPolicy.FinalOnlyAfter and MaxSnapshotsPerTurn when no explicit
+// FinalPhase is configured.
+const DefaultRetentionFinalPhase = "final"
+
+// RetentionPolicy selects stored snapshots for deletion.
+//
+// Every rule is optional; a zero policy deletes no snapshots and garbage
+// collection only removes orphaned rows. Rules are evaluated per snapshot and a
+// snapshot is deleted as soon as one rule matches:
+//
+//   - MaxAge drops every snapshot older than now-MaxAge.
+//   - FinalOnlyAfter drops non-final snapshots older than now-FinalOnlyAfter.
+//   - MaxSnapshotsPerTurn keeps the newest N snapshots of each turn across
+//     phases. The newest final snapshot of a turn is always kept by this rule so
+//     LoadLatestTurn keeps resolving resumable history.
+type RetentionPolicy struct {
+	// ConvID and SessionID scope the policy. Empty values match every
+	// conversation or session.
+	ConvID    string
+	SessionID string
+
+	MaxAge              time.Duration
+	FinalOnlyAfter      time.Duration
+	MaxSnapshotsPerTurn int
+
+	// FinalPhase defaults to DefaultRetentionFinalPhase.
+	FinalPhase string
+	// NowMs pins the evaluation time; defaults to time.Now().
+	NowMs int64
+}
+
+// IsZero reports whether the policy has no snapshot deletion rule.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAge <= 0 && p.FinalOnlyAfter <= 0 && p.MaxSnapshotsPerTurn <= 0
+}
+
+// Validate rejects negative durations and counts.
+func (p RetentionPolicy) Validate() error {
+	if p.MaxAge < 0 {
+		return errors.New("retention policy: max age must not be negative")
+	}
+	if p.FinalOnlyAfter < 0 {
+		return errors.New("retention policy: final-only-after must not be negative")
+	}
+	if p.MaxSnapshotsPerTurn < 0 {
+		return errors.New("retention policy: max snapshots per turn must not be negative")
+	}
+	return nil
+}
+
+func (p RetentionPolicy) finalPhase() string {
+	if v := strings.TrimSpace(p.FinalPhase); v != "" {
+		return v
+	}
+	return DefaultRetentionFinalPhase
+}
+
+func (p RetentionPolicy) nowMs() int64 {
+	if p.NowMs > 0 {
+		return p.NowMs
+	}
+	return time.Now().UnixMilli()
+}
+
+// GCOptions controls TurnStoreGC.CollectGarbage.
+type GCOptions struct {
+	Policy RetentionPolicy
+	// DryRun runs the deletion inside a transaction and rolls it back, so the
+	// reported counts are exact but nothing is removed.
+	DryRun bool
+}
+
+// GCResult reports what a garbage collection pass removed (or would remove
+// when DryRun is set).
+type GCResult struct {
+	DryRun                bool  `json:"dry_run"`
+	SnapshotsScanned      int64 `json:"snapshots_scanned"`
+	SnapshotsDeleted      int64 `json:"snapshots_deleted"`
+	MembershipRowsDeleted int64 `json:"membership_rows_deleted"`
+	TurnsDeleted          int64 `json:"turns_deleted"`
+	BlocksDeleted         int64 `json:"blocks_deleted"`
+}
+
+// TurnStoreGC is implemented by durable turn stores that support retention and
+// compaction. It is deliberately separate from TurnStore so in-memory and test
+// stores do not have to implement maintenance operations.
+type TurnStoreGC interface {
+	// CollectGarbage deletes snapshots selected by the retention policy, then
+	// removes turns rows without any remaining snapshot and blocks no longer
+	// referenced by turn_block_membership.
+	CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error)
+	// Optimize reclaims storage after deletions (VACUUM on SQLite, OPTIMIZE
+	// TABLE on MySQL, VACUUM (ANALYZE) on PostgreSQL).
+	Optimize(ctx context.Context) error
+}
+
+// SnapshotKey identifies one stored snapshot (one membership rowset).
+type SnapshotKey struct {
+	ConvID      string
+	SessionID   string
+	TurnID      string
+	Phase       string
+	CreatedAtMs int64
+}
+
+// SelectExpiredSnapshots applies the retention policy to the given snapshot keys
+// and returns the keys that should be deleted, ordered by turn and creation time.
+func SelectExpiredSnapshots(keys []SnapshotKey, policy RetentionPolicy) []SnapshotKey {
+	if policy.IsZero() || len(keys) == 0 {
+		return nil
+	}
+	nowMs := policy.nowMs()
+	finalPhase := policy.finalPhase()
+
+	type turnKey struct {
+		convID, sessionID, turnID string
+	}
+	byTurn := map[turnKey][]SnapshotKey{}
+	order := []turnKey{}
+	for _, k := range keys {
+		tk := turnKey{k.ConvID, k.SessionID, k.TurnID}
+		if _, ok := byTurn[tk]; !ok {
+			order = append(order, tk)
+		}
+		byTurn[tk] = append(byTurn[tk], k)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].convID != order[j].convID {
+			return order[i].convID < order[j].convID
+		}
+		if order[i].sessionID != order[j].sessionID {
+			return order[i].sessionID < order[j].sessionID
+		}
+		return order[i].turnID < order[j].turnID
+	})
+
+	out := []SnapshotKey{}
+	for _, tk := range order {
+		snaps := byTurn[tk]
+		// newest first so ranks line up with MaxSnapshotsPerTurn
+		sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].CreatedAtMs > snaps[j].CreatedAtMs })
+
+		latestFinal := -1
+		for i, s := range snaps {
+			if s.Phase == finalPhase {
+				latestFinal = i
+				break
+			}
+		}
+
+		expired := []SnapshotKey{}
+		for i, s := range snaps {
+			switch {
+			case policy.MaxAge > 0 && s.CreatedAtMs < nowMs-policy.MaxAge.Milliseconds():
+			case policy.FinalOnlyAfter > 0 && s.Phase != finalPhase && s.CreatedAtMs < nowMs-policy.FinalOnlyAfter.Milliseconds():
+			case policy.MaxSnapshotsPerTurn > 0 && i >= policy.MaxSnapshotsPerTurn && i != latestFinal:
+			default:
+				continue
+			}
+			expired = append(expired, s)
+		}
+		for i := len(expired) - 1; i >= 0; i-- {
+			out = append(out, expired[i])
+		}
+	}
+	return out
+}
+
+// serverGCLockClause locks orphan candidates on MySQL and PostgreSQL. SKIP
+// LOCKED leaves rows a concurrent Save holds to a later collection instead of
+// waiting on them.
+const serverGCLockClause = " FOR UPDATE SKIP LOCKED"
+
+// collectNormalizedGarbage implements CollectGarbage over the shared
+// turns/blocks/turn_block_membership schema. The statements only use portable
+// SQL, so SQLite, MySQL and PostgreSQL share it; label prefixes error messages,
+// rebind, when set, rewrites ? placeholders for the driver, and lockClause is
+// appended to the orphan candidate scans (" FOR UPDATE SKIP LOCKED" on servers
+// with concurrent writers, empty on SQLite, whose writers are serialized).
+func collectNormalizedGarbage(ctx context.Context, db *sql.DB, label string, rebind func(string) string, lockClause string, opts GCOptions) (*GCResult, error) {
+	if db == nil {
+		return nil, errors.Errorf("%s: db is nil", label)
+	}
+	if ctx == nil {
+		return nil, errors.Errorf("%s: ctx is nil", label)
+	}
+	if err := opts.Policy.Validate(); err != nil {
+		return nil, errors.Wrap(err, label)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: begin gc tx", label)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if rebind == nil {
+		rebind = func(query string) string { return query }
+	}
+	res := &GCResult{DryRun: opts.DryRun}
+	scope, scopeArgs := retentionScopeClause(opts.Policy)
+
+	if !opts.Policy.IsZero() {
+		keys, err := listNormalizedSnapshotKeys(ctx, rebindQueryer{q: tx, rebind: rebind}, SnapshotKeyQuery{ConvID: opts.Policy.ConvID, SessionID: opts.Policy.SessionID})
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: list snapshots", label)
+		}
+		res.SnapshotsScanned = int64(len(keys))
+
+		expired := SelectExpiredSnapshots(keys, opts.Policy)
+		if len(expired) > 0 {
+			stmt, err := tx.PrepareContext(ctx, rebind(`
+				DELETE FROM turn_block_membership
+				WHERE conv_id = ? AND session_id = ? AND turn_id = ? AND phase = ? AND snapshot_created_at_ms = ?
+			`))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: prepare snapshot delete", label)
+			}
+			for _, k := range expired {
+				r, err := stmt.ExecContext(ctx, k.ConvID, k.SessionID, k.TurnID, k.Phase, k.CreatedAtMs)
+				if err != nil {
+					_ = stmt.Close()
+					return nil, errors.Wrapf(err, "%s: delete snapshot", label)
+				}
+				n, _ := r.RowsAffected()
+				res.MembershipRowsDeleted += n
+				res.SnapshotsDeleted++
+			}
+			if err := stmt.Close(); err != nil {
+				return nil, errors.Wrapf(err, "%s: close snapshot delete", label)
+			}
+		}
+	}
+
+	// Orphans are collected in two steps: candidates are selected (and, where
+	// the dialect supports it, row-locked so rows a concurrent Save is upserting
+	// are skipped), then each candidate is deleted with the orphan check
+	// repeated. A Save that commits a membership row after the candidate scan
+	// therefore keeps its turn and blocks instead of losing them underneath it.
+	// #nosec G202 -- scope only appends constant clause fragments; values remain parameterized.
+	res.TurnsDeleted, err = deleteOrphans(ctx, tx, rebind(`
+		SELECT conv_id, session_id, turn_id FROM turns
+		WHERE NOT EXISTS (
+			SELECT 1 FROM turn_block_membership m
+			WHERE m.conv_id = turns.conv_id
+				AND m.session_id = turns.session_id
+				AND m.turn_id = turns.turn_id
+		)`+scope+lockClause), scopeArgs, rebind(`
+		DELETE FROM turns
+		WHERE conv_id = ? AND session_id = ? AND turn_id = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM turn_block_membership m
+				WHERE m.conv_id = turns.conv_id
+					AND m.session_id = turns.session_id
+					AND m.turn_id = turns.turn_id
+			)`), 3)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: delete orphaned turns", label)
+	}
+
+	res.BlocksDeleted, err = deleteOrphans(ctx, tx, `
+		SELECT block_id, content_hash FROM blocks
+		WHERE NOT EXISTS (
+			SELECT 1 FROM turn_block_membership m
+			WHERE m.block_id = blocks.block_id
+				AND m.content_hash = blocks.content_hash
+		)`+lockClause, nil, rebind(`
+		DELETE FROM blocks
+		WHERE block_id = ? AND content_hash = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM turn_block_membership m
+				WHERE m.block_id = blocks.block_id
+					AND m.content_hash = blocks.content_hash
+			)`), 2)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: delete orphaned blocks", label)
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "%s: commit gc tx", label)
+	}
+	committed = true
+	return res, nil
+}
+
+// deleteOrphans runs selectQuery to collect the keys of orphan candidates, then
+// deletes each one with deleteQuery, which takes the keyColumns key values as
+// its arguments and must repeat the orphan check. It returns the number of
+// rows deleted.
+func deleteOrphans(ctx context.Context, tx *sql.Tx, selectQuery string, selectArgs []any, deleteQuery string, keyColumns int) (int64, error) {
+	rows, err := tx.QueryContext(ctx, selectQuery, selectArgs...)
+	if err != nil {
+		return 0, errors.Wrap(err, "select candidates")
+	}
+	var keys [][]any
+	for rows.Next() {
+		key := make([]string, keyColumns)
+		dest := make([]any, keyColumns)
+		for i := range key {
+			dest[i] = &key[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return 0, errors.Wrap(err, "scan candidate")
+		}
+		args := make([]any, keyColumns)
+		for i, v := range key {
+			args[i] = v
+		}
+		keys = append(keys, args)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, errors.Wrap(err, "iterate candidates")
+	}
+	if err := rows.Close(); err != nil {
+		return 0, errors.Wrap(err, "close candidates")
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, deleteQuery)
+	if err != nil {
+		return 0, errors.Wrap(err, "prepare delete")
+	}
+	defer func() { _ = stmt.Close() }()
+	var deleted int64
+	for _, args := range keys {
+		r, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return deleted, errors.Wrap(err, "delete")
+		}
+		n, _ := r.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// retentionScopeClause returns an " AND ..." suffix restricting unqualified
+// conv_id/session_id columns to the policy scope.
+func retentionScopeClause(p RetentionPolicy) (string, []any) {
+	clauses := []string{}
+	args := []any{}
+	if v := strings.TrimSpace(p.ConvID); v != "" {
+		clauses = append(clauses, "conv_id = ?")
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(p.SessionID); v != "" {
+		clauses = append(clauses, "session_id = ?")
+		args = append(args, v)
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(clauses, " AND "), args
+}
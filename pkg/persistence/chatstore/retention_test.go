@@ -0,0 +1,117 @@
+package chatstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectExpiredSnapshots(t *testing.T) {
+	keys := []SnapshotKey{
+		{ConvID: "c", SessionID: "s", TurnID: "t1", Phase: "pre_inference", CreatedAtMs: 1_000},
+		{ConvID: "c", SessionID: "s", TurnID: "t1", Phase: "final", CreatedAtMs: 2_000},
+		{ConvID: "c", SessionID: "s", TurnID: "t1", Phase: "post_tools", CreatedAtMs: 3_000},
+		{ConvID: "c", SessionID: "s", TurnID: "t2", Phase: "final", CreatedAtMs: 9_000},
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []SnapshotKey
+	}{
+		{
+			name:   "zero policy keeps everything",
+			policy: RetentionPolicy{NowMs: 10_000},
+		},
+		{
+			name:   "max age drops old snapshots of every phase",
+			policy: RetentionPolicy{NowMs: 10_000, MaxAge: 7500 * time.Millisecond},
+			want:   []SnapshotKey{keys[0], keys[1]},
+		},
+		{
+			name:   "final only after keeps old final snapshots",
+			policy: RetentionPolicy{NowMs: 10_000, FinalOnlyAfter: 5 * time.Second},
+			want:   []SnapshotKey{keys[0], keys[2]},
+		},
+		{
+			name:   "max snapshots per turn keeps newest final",
+			policy: RetentionPolicy{NowMs: 10_000, MaxSnapshotsPerTurn: 1},
+			want:   []SnapshotKey{keys[0]},
+		},
+		{
+			name:   "custom final phase",
+			policy: RetentionPolicy{NowMs: 10_000, FinalOnlyAfter: 5 * time.Second, FinalPhase: "post_tools"},
+			want:   []SnapshotKey{keys[0], keys[1]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectExpiredSnapshots(keys, tt.policy)
+			if len(tt.want) == 0 {
+				require.Empty(t, got)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	require.NoError(t, RetentionPolicy{}.Validate())
+	require.Error(t, RetentionPolicy{MaxAge: -time.Second}.Validate())
+	require.Error(t, RetentionPolicy{FinalOnlyAfter: -time.Second}.Validate())
+	require.Error(t, RetentionPolicy{MaxSnapshotsPerTurn: -1}.Validate())
+}
+
+func TestSQLiteTurnStore_CollectGarbage(t *testing.T) {
+	dsn, err := SQLiteTurnDSNForFile(filepath.Join(t.TempDir(), "turns.db"))
+	require.NoError(t, err)
+	s, err := NewSQLiteTurnStore(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, s.Save(ctx, "conv-1", "sess-1", "turn-1", "pre_inference", 100, validTurnPayload("turn-1", "draft"), TurnSaveOptions{}))
+	require.NoError(t, s.Save(ctx, "conv-1", "sess-1", "turn-1", "final", 200, validTurnPayload("turn-1", "done"), TurnSaveOptions{}))
+	require.NoError(t, s.Save(ctx, "conv-2", "sess-2", "turn-2", "final", 150, validTurnPayload("turn-2", "other"), TurnSaveOptions{}))
+	require.Equal(t, int64(3), queryRowCount(t, s.db, "SELECT COUNT(1) FROM blocks"))
+
+	policy := RetentionPolicy{NowMs: 1_000, MaxAge: 500 * time.Millisecond, ConvID: "conv-2"}
+
+	dry, err := s.CollectGarbage(ctx, GCOptions{Policy: policy, DryRun: true})
+	require.NoError(t, err)
+	require.True(t, dry.DryRun)
+	require.Equal(t, int64(1), dry.SnapshotsScanned)
+	require.Equal(t, int64(1), dry.SnapshotsDeleted)
+	require.Equal(t, int64(1), dry.TurnsDeleted)
+	require.Equal(t, int64(1), dry.BlocksDeleted)
+	require.Equal(t, int64(3), queryRowCount(t, s.db, "SELECT COUNT(1) FROM blocks"))
+	require.Equal(t, int64(2), queryRowCount(t, s.db, "SELECT COUNT(1) FROM turns"))
+
+	res, err := s.CollectGarbage(ctx, GCOptions{Policy: policy})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.SnapshotsDeleted)
+	require.Equal(t, int64(1), res.MembershipRowsDeleted)
+	require.Equal(t, int64(2), queryRowCount(t, s.db, "SELECT COUNT(1) FROM blocks"))
+	require.Equal(t, int64(1), queryRowCount(t, s.db, "SELECT COUNT(1) FROM turns"))
+
+	res, err = s.CollectGarbage(ctx, GCOptions{Policy: RetentionPolicy{NowMs: 1_000, FinalOnlyAfter: 500 * time.Millisecond}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), res.SnapshotsDeleted)
+	require.Equal(t, int64(1), res.BlocksDeleted)
+	require.Equal(t, int64(0), res.TurnsDeleted)
+
+	snap, err := s.LoadLatestTurn(ctx, "conv-1", "final")
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	require.Contains(t, snap.Payload, "done")
+
+	items, err := s.List(ctx, TurnQuery{ConvID: "conv-1"})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	require.NoError(t, s.Optimize(ctx))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/go-go-golems/pinocchio/pkg/testsupport/mysqltest"
+	"github.com/go-go-golems/pinocchio/pkg/testsupport/pgtest"
 )
 
 func TestMain(m *testing.M) {
@@ -31,6 +32,25 @@ func TestMain(m *testing.M) {
 		}
 	}
 
+	var pgInstance *pgtest.Instance
+	if os.Getenv("PINOCCHIO_POSTGRES_TESTCONTAINERS") == "1" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		var err error
+		pgInstance, err = pgtest.Start(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "start disposable PostgreSQL: %v\n", err)
+			if instance != nil {
+				_ = instance.Close(context.Background())
+			}
+			os.Exit(1)
+		}
+		if err := os.Setenv("PINOCCHIO_POSTGRES_TURNS_DSN", pgInstance.AppDSN); err != nil {
+			fmt.Fprintf(os.Stderr, "set application PostgreSQL DSN: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	code := m.Run()
 	if instance != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -40,5 +60,13 @@ func TestMain(m *testing.M) {
 		}
 		cancel()
 	}
+	if pgInstance != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := pgInstance.Close(ctx); err != nil && code == 0 {
+			fmt.Fprintf(os.Stderr, "terminate disposable PostgreSQL: %v\n", err)
+			code = 1
+		}
+		cancel()
+	}
 	os.Exit(code)
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -20,6 +21,8 @@ type SQLiteTurnStore struct {
 }
 
 var _ TurnStore = &SQLiteTurnStore{}
+var _ TurnStoreGC = &SQLiteTurnStore{}
+var _ SnapshotSink = &SQLiteTurnStore{}
 
 var sqliteSchemaIntrospectionTables = map[string]struct{}{
 	"turns":                 {},
@@ -53,6 +56,32 @@ func NewSQLiteTurnStore(dsn string) (*SQLiteTurnStore, error) {
 	return s, nil
 }
 
+// NewReadOnlySQLiteTurnStore opens an existing SQLite turn store, e.g. as a
+// migration source. The schema is checked but not migrated, so the dsn may use
+// mode=ro and writes through the store fail.
+func NewReadOnlySQLiteTurnStore(dsn string) (*SQLiteTurnStore, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, errors.New("sqlite turn store: empty dsn")
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	s := &SQLiteTurnStore{db: db}
+	for _, table := range []string{"turns", "blocks", "turn_block_membership"} {
+		cols, err := s.tableColumns(table)
+		if err != nil {
+			_ = db.Close()
+			return nil, errors.Wrapf(err, "sqlite turn store: inspect table %s", table)
+		}
+		if len(cols) == 0 {
+			_ = db.Close()
+			return nil, errors.Errorf("sqlite turn store: managed table %s is missing", table)
+		}
+	}
+	return s, nil
+}
+
 func (s *SQLiteTurnStore) Close() error {
 	if s == nil || s.db == nil {
 		return nil
@@ -589,6 +618,80 @@ func (s *SQLiteTurnStore) LoadLatestTurn(ctx context.Context, convID, phase stri
 	return &item, nil
 }
 
+// CollectGarbage applies the retention policy and removes orphaned turns and
+// blocks in a single transaction.
+func (s *SQLiteTurnStore) CollectGarbage(ctx context.Context, opts GCOptions) (*GCResult, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("sqlite turn store: db is nil")
+	}
+	return collectNormalizedGarbage(ctx, s.db, "sqlite turn store", nil, "", opts)
+}
+
+// Optimize rebuilds the database file to release pages freed by deletions and
+// refreshes query planner statistics.
+func (s *SQLiteTurnStore) Optimize(ctx context.Context) error {
+	if s == nil || s.db == nil {
+		return errors.New("sqlite turn store: db is nil")
+	}
+	if ctx == nil {
+		return errors.New("sqlite turn store: ctx is nil")
+	}
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return errors.Wrap(err, "sqlite turn store: vacuum")
+	}
+	if _, err := s.db.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		return errors.Wrap(err, "sqlite turn store: optimize")
+	}
+	return nil
+}
+
+// ListSnapshotKeys enumerates every stored snapshot in the optional scope.
+func (s *SQLiteTurnStore) ListSnapshotKeys(ctx context.Context, q SnapshotKeyQuery) ([]SnapshotKey, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("sqlite turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("sqlite turn store: ctx is nil")
+	}
+	keys, err := listNormalizedSnapshotKeys(ctx, s.db, q)
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlite turn store: list snapshot keys")
+	}
+	return keys, nil
+}
+
+// LoadSnapshot loads one snapshot with its payload and stored block hashes.
+func (s *SQLiteTurnStore) LoadSnapshot(ctx context.Context, key SnapshotKey) (*SnapshotRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, errors.New("sqlite turn store: db is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("sqlite turn store: ctx is nil")
+	}
+	rec, turnMetadataJSON, turnDataJSON, found, err := loadNormalizedSnapshotHeader(ctx, s.db, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlite turn store: load snapshot")
+	}
+	if !found {
+		return nil, nil
+	}
+	blockRows, err := s.loadSnapshotBlocks(ctx, key.ConvID, key.SessionID, key.TurnID, key.Phase, key.CreatedAtMs)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := buildTurnPayloadYAML(key.TurnID, blockRows, turnMetadataJSON, turnDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = payload
+	return &rec, nil
+}
+
+// SaveSnapshot stores a copied snapshot under its original key.
+func (s *SQLiteTurnStore) SaveSnapshot(ctx context.Context, rec SnapshotRecord) error {
+	return saveSnapshotRecord(ctx, s, rec)
+}
+
 func SQLiteTurnDSNForFile(path string) (string, error) {
 	if strings.TrimSpace(path) == "" {
 		return "", errors.New("sqlite turn store: empty path")
@@ -596,6 +699,18 @@ func SQLiteTurnDSNForFile(path string) (string, error) {
 	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on", path), nil
 }
 
+// SQLiteTurnReadOnlyDSNForFile is SQLiteTurnDSNForFile for
+// NewReadOnlySQLiteTurnStore: the file is opened with mode=ro and must exist.
+func SQLiteTurnReadOnlyDSNForFile(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("sqlite turn store: empty path")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", errors.Wrap(err, "sqlite turn store")
+	}
+	return fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", path), nil
+}
+
 func (s *SQLiteTurnStore) loadSnapshotBlocks(ctx context.Context, convID string, sessionID string, turnID string, phase string, snapshotCreatedAtMs int64) ([]map[string]any, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT
@@ -0,0 +1,369 @@
+// Package hydrationpg is a PostgreSQL sessionstream.HydrationStore. It keeps
+// the latest projected state of every timeline entity per session plus the
+// last applied event ordinal, mirroring the sessionstream SQLite and MySQL
+// stores so web-chat and the CLI can hydrate from a shared Postgres database.
+package hydrationpg
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-go-golems/sessionstream/pkg/sessionstream"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const schemaVersion int64 = 1
+
+const schemaComponent = "sessionstream.hydration"
+
+// migrationLockKey is shared with chatstore.PostgresTurnStore so first-time
+// creation of pinocchio_schema_version is serialized across components.
+const migrationLockKey int64 = 0x70696e6f63636869
+
+// Store implements sessionstream.HydrationStore on PostgreSQL.
+//
+// Entity payloads are stored as protojson-encoded google.protobuf.Any values
+// in a JSONB column, so they are self-describing and are resolved through the
+// global protobuf registry when read back.
+type Store struct {
+	db *sql.DB
+}
+
+var _ sessionstream.HydrationStore = &Store{}
+
+// Open opens a bounded pgx pool and migrates the hydration schema. reg is
+// required for parity with the other sessionstream stores.
+func Open(ctx context.Context, dsn string, reg *sessionstream.SchemaRegistry) (*Store, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, errors.New("postgres hydration store: empty dsn")
+	}
+	if reg == nil {
+		return nil, errors.New("postgres hydration store: schema registry is nil")
+	}
+	if ctx == nil {
+		return nil, errors.New("postgres hydration store: ctx is nil")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "postgres hydration store: open db")
+	}
+	db.SetMaxOpenConns(20)
+	db.SetMaxIdleConns(10)
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetConnMaxIdleTime(time.Minute)
+	s := &Store{db: db}
+	if err := s.migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "postgres hydration store: migrate")
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin migration tx")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return errors.Wrap(err, "acquire migration lock")
+	}
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS pinocchio_schema_version (
+			component TEXT NOT NULL PRIMARY KEY,
+			schema_version BIGINT NOT NULL
+		)
+	`); err != nil {
+		return errors.Wrap(err, "create schema version table")
+	}
+
+	var version int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT schema_version FROM pinocchio_schema_version WHERE component = $1
+	`, schemaComponent).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		for _, st := range createTableStatements {
+			if _, err := tx.ExecContext(ctx, st); err != nil {
+				return errors.Wrap(err, "create hydration schema")
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO pinocchio_schema_version(component, schema_version) VALUES($1, $2)
+		`, schemaComponent, schemaVersion); err != nil {
+			return errors.Wrap(err, "record hydration schema version")
+		}
+	case err != nil:
+		return errors.Wrap(err, "read hydration schema version")
+	case version != schemaVersion:
+		return errors.Errorf("unsupported %s schema version %d (want %d)", schemaComponent, version, schemaVersion)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "commit migration tx")
+	}
+	committed = true
+	return nil
+}
+
+var createTableStatements = []string{
+	`CREATE TABLE sessionstream_hydration_cursors (
+		session_id TEXT NOT NULL PRIMARY KEY,
+		ordinal BIGINT NOT NULL,
+		updated_at_ms BIGINT NOT NULL
+	)`,
+	`CREATE TABLE sessionstream_hydration_entities (
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		entity_id TEXT NOT NULL,
+		created_ordinal BIGINT NOT NULL,
+		last_event_ordinal BIGINT NOT NULL,
+		tombstone BOOLEAN NOT NULL DEFAULT FALSE,
+		payload_json JSONB,
+		PRIMARY KEY (session_id, kind, entity_id)
+	)`,
+	`CREATE INDEX hydration_entities_by_session_ordinal ON sessionstream_hydration_entities(session_id, created_ordinal)`,
+}
+
+// Apply upserts the projected entities for one event ordinal and advances the
+// session cursor. Entities without a CreatedOrdinal keep the ordinal of their
+// first Apply; LastEventOrdinal defaults to ord.
+func (s *Store) Apply(ctx context.Context, sid sessionstream.SessionId, ord uint64, entities []sessionstream.TimelineEntity) error {
+	if s == nil || s.db == nil {
+		return errors.New("postgres hydration store: db is nil")
+	}
+	if ctx == nil {
+		return errors.New("postgres hydration store: ctx is nil")
+	}
+	if strings.TrimSpace(string(sid)) == "" {
+		return errors.New("postgres hydration store: session id is empty")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "postgres hydration store: begin tx")
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for i, entity := range entities {
+		kind := strings.TrimSpace(entity.Kind)
+		id := strings.TrimSpace(entity.Id)
+		if kind == "" || id == "" {
+			return errors.Errorf("postgres hydration store: entity %d has empty kind or id", i)
+		}
+		payload, err := encodePayload(entity)
+		if err != nil {
+			return errors.Wrapf(err, "postgres hydration store: encode %s/%s", kind, id)
+		}
+		created := entity.CreatedOrdinal
+		if created == 0 {
+			created = ord
+		}
+		last := entity.LastEventOrdinal
+		if last == 0 {
+			last = ord
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO sessionstream_hydration_entities(
+				session_id, kind, entity_id, created_ordinal, last_event_ordinal, tombstone, payload_json
+			)
+			VALUES($1, $2, $3, $4, $5, $6, $7::jsonb)
+			ON CONFLICT (session_id, kind, entity_id) DO UPDATE SET
+				created_ordinal = CASE
+					WHEN $8 THEN excluded.created_ordinal
+					ELSE sessionstream_hydration_entities.created_ordinal
+				END,
+				last_event_ordinal = excluded.last_event_ordinal,
+				tombstone = excluded.tombstone,
+				payload_json = excluded.payload_json
+		`, string(sid), kind, id, int64(created), int64(last), entity.Tombstone, payload, entity.CreatedOrdinal != 0); err != nil {
+			return errors.Wrapf(err, "postgres hydration store: upsert %s/%s", kind, id)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sessionstream_hydration_cursors(session_id, ordinal, updated_at_ms)
+		VALUES($1, $2, $3)
+		ON CONFLICT (session_id) DO UPDATE SET
+			ordinal = GREATEST(sessionstream_hydration_cursors.ordinal, excluded.ordinal),
+			updated_at_ms = excluded.updated_at_ms
+	`, string(sid), int64(ord), time.Now().UnixMilli()); err != nil {
+		return errors.Wrap(err, "postgres hydration store: advance cursor")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "postgres hydration store: commit tx")
+	}
+	committed = true
+	return nil
+}
+
+// Snapshot returns the current entities of the session ordered by creation
+// ordinal. Only the latest entity state is stored, so asOf does not rewind the
+// timeline; the snapshot ordinal is the session cursor.
+func (s *Store) Snapshot(ctx context.Context, sid sessionstream.SessionId, asOf uint64) (sessionstream.Snapshot, error) {
+	_ = asOf
+	cursor, entities, err := s.load(ctx, sid)
+	if err != nil {
+		return sessionstream.Snapshot{}, err
+	}
+	return sessionstream.Snapshot{
+		SessionId:       sid,
+		SnapshotOrdinal: cursor,
+		Entities:        entities,
+	}, nil
+}
+
+// View loads the session entities into an immutable in-memory view.
+func (s *Store) View(ctx context.Context, sid sessionstream.SessionId) (sessionstream.TimelineView, error) {
+	cursor, entities, err := s.load(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	v := &timelineView{ordinal: cursor, byKey: map[entityKey]sessionstream.TimelineEntity{}, entities: entities}
+	for _, e := range entities {
+		v.byKey[entityKey{kind: e.Kind, id: e.Id}] = e
+	}
+	return v, nil
+}
+
+// Cursor returns the last applied event ordinal, or 0 for unknown sessions.
+func (s *Store) Cursor(ctx context.Context, sid sessionstream.SessionId) (uint64, error) {
+	if s == nil || s.db == nil {
+		return 0, errors.New("postgres hydration store: db is nil")
+	}
+	if ctx == nil {
+		return 0, errors.New("postgres hydration store: ctx is nil")
+	}
+	var ord int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ordinal FROM sessionstream_hydration_cursors WHERE session_id = $1
+	`, string(sid)).Scan(&ord)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "postgres hydration store: read cursor")
+	}
+	return uint64(ord), nil
+}
+
+func (s *Store) load(ctx context.Context, sid sessionstream.SessionId) (uint64, []sessionstream.TimelineEntity, error) {
+	cursor, err := s.Cursor(ctx, sid)
+	if err != nil {
+		return 0, nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT kind, entity_id, created_ordinal, last_event_ordinal, tombstone, payload_json::text
+		FROM sessionstream_hydration_entities
+		WHERE session_id = $1
+		ORDER BY created_ordinal ASC, kind ASC, entity_id ASC
+	`, string(sid))
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "postgres hydration store: query entities")
+	}
+	defer func() { _ = rows.Close() }()
+
+	entities := []sessionstream.TimelineEntity{}
+	for rows.Next() {
+		var (
+			e                  sessionstream.TimelineEntity
+			created, lastEvent int64
+			payloadJSON        sql.NullString
+		)
+		if err := rows.Scan(&e.Kind, &e.Id, &created, &lastEvent, &e.Tombstone, &payloadJSON); err != nil {
+			return 0, nil, errors.Wrap(err, "postgres hydration store: scan entity")
+		}
+		e.CreatedOrdinal = uint64(created)
+		e.LastEventOrdinal = uint64(lastEvent)
+		if payloadJSON.Valid {
+			msg, err := decodePayload(payloadJSON.String)
+			if err != nil {
+				return 0, nil, errors.Wrapf(err, "postgres hydration store: decode %s/%s", e.Kind, e.Id)
+			}
+			e.Payload = msg
+		}
+		entities = append(entities, e)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, errors.Wrap(err, "postgres hydration store: iterate entities")
+	}
+	return cursor, entities, nil
+}
+
+func encodePayload(entity sessionstream.TimelineEntity) (any, error) {
+	if entity.Payload == nil {
+		return nil, nil
+	}
+	packed, err := anypb.New(entity.Payload)
+	if err != nil {
+		return nil, err
+	}
+	b, err := protojson.Marshal(packed)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func decodePayload(raw string) (proto.Message, error) {
+	var packed anypb.Any
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(raw), &packed); err != nil {
+		return nil, err
+	}
+	return packed.UnmarshalNew()
+}
+
+type entityKey struct {
+	kind string
+	id   string
+}
+
+// timelineView is a read-only snapshot of the stored entities.
+type timelineView struct {
+	ordinal  uint64
+	byKey    map[entityKey]sessionstream.TimelineEntity
+	entities []sessionstream.TimelineEntity
+}
+
+func (v *timelineView) Get(kind, id string) (sessionstream.TimelineEntity, bool) {
+	e, ok := v.byKey[entityKey{kind: kind, id: id}]
+	return e, ok
+}
+
+// List returns the entities of one kind (all entities for an empty kind) in
+// creation order; entities are loaded already sorted.
+func (v *timelineView) List(kind string) []sessionstream.TimelineEntity {
+	out := []sessionstream.TimelineEntity{}
+	for _, e := range v.entities {
+		if kind == "" || e.Kind == kind {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (v *timelineView) Ordinal() uint64 { return v.ordinal }
@@ -0,0 +1,119 @@
+package hydrationpg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	chatappv1 "github.com/go-go-golems/pinocchio/pkg/chatapp/pb/proto/pinocchio/chatapp/v1"
+	"github.com/go-go-golems/pinocchio/pkg/testsupport/pgtest"
+	"github.com/go-go-golems/sessionstream/pkg/sessionstream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	var instance *pgtest.Instance
+	if os.Getenv("PINOCCHIO_POSTGRES_TESTCONTAINERS") == "1" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		var err error
+		instance, err = pgtest.Start(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "start disposable PostgreSQL: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.Setenv("PINOCCHIO_POSTGRES_HYDRATION_DSN", instance.AppDSN); err != nil {
+			fmt.Fprintf(os.Stderr, "set application PostgreSQL DSN: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	code := m.Run()
+	if instance != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := instance.Close(ctx); err != nil && code == 0 {
+			fmt.Fprintf(os.Stderr, "terminate disposable PostgreSQL: %v\n", err)
+			code = 1
+		}
+		cancel()
+	}
+	os.Exit(code)
+}
+
+var sessionSeq atomic.Uint64
+
+func newTestStore(t *testing.T) (*Store, sessionstream.SessionId) {
+	t.Helper()
+	dsn := os.Getenv("PINOCCHIO_POSTGRES_HYDRATION_DSN")
+	if dsn == "" {
+		t.Skip("PINOCCHIO_POSTGRES_HYDRATION_DSN not set; skipping PostgreSQL hydration store integration test")
+	}
+	s, err := Open(context.Background(), dsn, sessionstream.NewSchemaRegistry())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	sid := sessionstream.SessionId(fmt.Sprintf("%s-%d-%d", t.Name(), time.Now().UnixNano(), sessionSeq.Add(1)))
+	return s, sid
+}
+
+func TestStore_ApplySnapshotViewCursor(t *testing.T) {
+	ctx := context.Background()
+	s, sid := newTestStore(t)
+
+	cursor, err := s.Cursor(ctx, sid)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), cursor)
+
+	require.NoError(t, s.Apply(ctx, sid, 1, []sessionstream.TimelineEntity{{
+		Kind:    "ChatMessage",
+		Id:      "user-1",
+		Payload: &chatappv1.ChatMessageEntity{MessageId: "user-1", Role: "user", Content: "hello"},
+	}}))
+	require.NoError(t, s.Apply(ctx, sid, 4, []sessionstream.TimelineEntity{{
+		Kind:    "ChatMessage",
+		Id:      "assistant-1",
+		Payload: &chatappv1.ChatMessageEntity{MessageId: "assistant-1", Role: "assistant", Content: "hi", Status: "streaming"},
+	}}))
+	require.NoError(t, s.Apply(ctx, sid, 7, []sessionstream.TimelineEntity{{
+		Kind:    "ChatMessage",
+		Id:      "assistant-1",
+		Payload: &chatappv1.ChatMessageEntity{MessageId: "assistant-1", Role: "assistant", Content: "hi there", Status: "finished"},
+	}}))
+
+	snap, err := s.Snapshot(ctx, sid, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), snap.SnapshotOrdinal)
+	require.Len(t, snap.Entities, 2)
+	require.Equal(t, "user-1", snap.Entities[0].Id)
+	require.Equal(t, uint64(1), snap.Entities[0].CreatedOrdinal)
+	assistant := snap.Entities[1]
+	require.Equal(t, uint64(4), assistant.CreatedOrdinal)
+	require.Equal(t, uint64(7), assistant.LastEventOrdinal)
+	msg, ok := assistant.Payload.(*chatappv1.ChatMessageEntity)
+	require.True(t, ok, "payload type %T", assistant.Payload)
+	require.Equal(t, "hi there", msg.GetContent())
+	require.Equal(t, "finished", msg.GetStatus())
+
+	view, err := s.View(ctx, sid)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), view.Ordinal())
+	got, ok := view.Get("ChatMessage", "user-1")
+	require.True(t, ok)
+	require.Equal(t, "user-1", got.Id)
+	require.Len(t, view.List("ChatMessage"), 2)
+
+	// Stale ordinals do not move the cursor backwards.
+	require.NoError(t, s.Apply(ctx, sid, 3, nil))
+	cursor, err = s.Cursor(ctx, sid)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), cursor)
+}
+
+func TestOpenRequiresDSNAndRegistry(t *testing.T) {
+	_, err := Open(context.Background(), "", sessionstream.NewSchemaRegistry())
+	require.Error(t, err)
+	_, err = Open(context.Background(), "postgres://localhost/db", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package usagestore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Entry is one provider call recorded in the usage ledger.
+type Entry struct {
+	SessionID      string `json:"session_id"`
+	RunID          string `json:"run_id,omitempty"`
+	TurnID         string `json:"turn_id,omitempty"`
+	ProviderCallID string `json:"provider_call_id,omitempty"`
+	RuntimeKey     string `json:"runtime_key,omitempty"`
+	Profile        string `json:"profile,omitempty"`
+	Model          string `json:"model,omitempty"`
+
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CachedTokens             int64 `json:"cached_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+
+	DurationMs int64 `json:"duration_ms,omitempty"`
+	// CostUSD is the cost computed from the price table active when the call
+	// was recorded. Priced is false when no price was known for the model.
+	CostUSD     float64 `json:"cost_usd"`
+	Priced      bool    `json:"priced"`
+	CreatedAtMs int64   `json:"created_at_ms"`
+}
+
+// Query filters ledger entries. Zero values do not filter.
+type Query struct {
+	SessionID  string
+	RuntimeKey string
+	Profile    string
+	Model      string
+	SinceMs    int64
+	// UntilMs is exclusive.
+	UntilMs int64
+}
+
+func (q Query) matches(e Entry) bool {
+	switch {
+	case q.SessionID != "" && e.SessionID != q.SessionID:
+		return false
+	case q.RuntimeKey != "" && e.RuntimeKey != q.RuntimeKey:
+		return false
+	case q.Profile != "" && e.Profile != q.Profile:
+		return false
+	case q.Model != "" && e.Model != q.Model:
+		return false
+	case q.SinceMs > 0 && e.CreatedAtMs < q.SinceMs:
+		return false
+	case q.UntilMs > 0 && e.CreatedAtMs >= q.UntilMs:
+		return false
+	}
+	return true
+}
+
+// Ledger persists provider call usage across sessions.
+type Ledger interface {
+	Record(ctx context.Context, e Entry) error
+	// Query returns matching entries ordered by creation time.
+	Query(ctx context.Context, q Query) ([]Entry, error)
+	Close() error
+}
+
+// MemoryLedger is a process-local Ledger for tests and ephemeral servers.
+type MemoryLedger struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+var _ Ledger = &MemoryLedger{}
+
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{}
+}
+
+func (l *MemoryLedger) Record(_ context.Context, e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, normalizeEntry(e))
+	return nil
+}
+
+func (l *MemoryLedger) Query(_ context.Context, q Query) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := []Entry{}
+	for _, e := range l.entries {
+		if q.matches(e) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAtMs < out[j].CreatedAtMs })
+	return out, nil
+}
+
+func (l *MemoryLedger) Close() error {
+	return nil
+}
+
+func normalizeEntry(e Entry) Entry {
+	e.SessionID = strings.TrimSpace(e.SessionID)
+	e.RunID = strings.TrimSpace(e.RunID)
+	e.TurnID = strings.TrimSpace(e.TurnID)
+	e.ProviderCallID = strings.TrimSpace(e.ProviderCallID)
+	e.RuntimeKey = strings.TrimSpace(e.RuntimeKey)
+	e.Profile = strings.TrimSpace(e.Profile)
+	e.Model = strings.TrimSpace(e.Model)
+	return e
+}
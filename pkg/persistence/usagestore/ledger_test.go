@@ -0,0 +1,78 @@
+package usagestore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteLedger(t *testing.T) *SQLiteLedger {
+	t.Helper()
+	dsn, err := SQLiteDSNForFile(filepath.Join(t.TempDir(), "usage.db"))
+	require.NoError(t, err)
+	l, err := NewSQLiteLedger(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+func day(t *testing.T, s string) int64 {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, s)
+	require.NoError(t, err)
+	return ts.UnixMilli()
+}
+
+func seedLedger(t *testing.T, l Ledger) {
+	t.Helper()
+	ctx := context.Background()
+	for _, e := range []Entry{
+		{SessionID: "s1", Profile: "default", RuntimeKey: "default", Model: "gpt-4o", InputTokens: 1000, OutputTokens: 100, CostUSD: 0.01, Priced: true, CreatedAtMs: day(t, "2026-10-01T10:00:00Z")},
+		{SessionID: "s1", Profile: "default", RuntimeKey: "default", Model: "gpt-4o", InputTokens: 2000, OutputTokens: 200, CostUSD: 0.02, Priced: true, CreatedAtMs: day(t, "2026-10-01T11:00:00Z")},
+		{SessionID: "s2", Profile: "agent", RuntimeKey: "agent", Model: "claude-sonnet", InputTokens: 500, OutputTokens: 50, CreatedAtMs: day(t, "2026-10-02T09:00:00Z")},
+	} {
+		require.NoError(t, l.Record(ctx, e))
+	}
+}
+
+func TestLedgers_RecordAndQuery(t *testing.T) {
+	for name, l := range map[string]Ledger{
+		"memory": NewMemoryLedger(),
+		"sqlite": newTestSQLiteLedger(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seedLedger(t, l)
+
+			all, err := l.Query(ctx, Query{})
+			require.NoError(t, err)
+			require.Len(t, all, 3)
+			require.Equal(t, "s1", all[0].SessionID)
+			require.True(t, all[0].Priced)
+			require.InDelta(t, 0.01, all[0].CostUSD, 1e-9)
+
+			bySession, err := l.Query(ctx, Query{SessionID: "s2"})
+			require.NoError(t, err)
+			require.Len(t, bySession, 1)
+			require.Equal(t, "claude-sonnet", bySession[0].Model)
+			require.False(t, bySession[0].Priced)
+
+			window, err := l.Query(ctx, Query{SinceMs: day(t, "2026-10-01T10:30:00Z"), UntilMs: day(t, "2026-10-02T09:00:00Z")})
+			require.NoError(t, err)
+			require.Len(t, window, 1)
+			require.Equal(t, int64(2000), window[0].InputTokens)
+
+			byModel, err := l.Query(ctx, Query{Profile: "default", Model: "gpt-4o"})
+			require.NoError(t, err)
+			require.Len(t, byModel, 2)
+		})
+	}
+}
+
+func TestSQLiteLedger_RejectsEmptySession(t *testing.T) {
+	l := newTestSQLiteLedger(t)
+	require.Error(t, l.Record(context.Background(), Entry{Model: "gpt-4o"}))
+}
@@ -0,0 +1,121 @@
+package usagestore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelPrice is the price of one model in USD per million tokens.
+//
+// Cached and cache-write prices fall back to the input price when zero, so a
+// table only needs the fields a provider actually bills differently.
+type ModelPrice struct {
+	InputPerMTok       float64 `json:"input_per_mtok" yaml:"input_per_mtok"`
+	OutputPerMTok      float64 `json:"output_per_mtok" yaml:"output_per_mtok"`
+	CachedInputPerMTok float64 `json:"cached_input_per_mtok,omitempty" yaml:"cached_input_per_mtok,omitempty"`
+	CacheWritePerMTok  float64 `json:"cache_write_per_mtok,omitempty" yaml:"cache_write_per_mtok,omitempty"`
+}
+
+// PriceTable maps model names to prices. A key ending in "*" matches every
+// model with that prefix; the longest matching prefix wins over shorter ones
+// and exact names win over prefixes.
+type PriceTable struct {
+	Models map[string]ModelPrice `json:"models,omitempty" yaml:"models,omitempty"`
+}
+
+// Lookup returns the price for model.
+func (t *PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if t == nil || len(t.Models) == 0 {
+		return ModelPrice{}, false
+	}
+	model = strings.TrimSpace(model)
+	if p, ok := t.Models[model]; ok {
+		return p, true
+	}
+	best := ""
+	var price ModelPrice
+	found := false
+	for key, p := range t.Models {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if !ok || !strings.HasPrefix(model, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(best) || (len(prefix) == len(best) && prefix < best) {
+			best, price, found = prefix, p, true
+		}
+	}
+	return price, found
+}
+
+// Cost prices one ledger entry. Providers report cached input two ways:
+// OpenAI counts CachedTokens as part of InputTokens, while Anthropic reports
+// cache reads and cache writes next to an InputTokens that excludes them. When
+// an entry has Anthropic's cache fields, InputTokens is billed in full and
+// CachedTokens is ignored so cache reads are not counted twice.
+func (t *PriceTable) Cost(e Entry) (float64, bool) {
+	p, ok := t.Lookup(e.Model)
+	if !ok {
+		return 0, false
+	}
+	cachedPrice := p.CachedInputPerMTok
+	if cachedPrice == 0 {
+		cachedPrice = p.InputPerMTok
+	}
+	writePrice := p.CacheWritePerMTok
+	if writePrice == 0 {
+		writePrice = p.InputPerMTok
+	}
+	uncached, cached := e.InputTokens, e.CacheReadInputTokens
+	if e.CacheReadInputTokens == 0 && e.CacheCreationInputTokens == 0 {
+		cached = min(e.CachedTokens, e.InputTokens)
+		uncached -= cached
+	}
+	cost := float64(uncached)*p.InputPerMTok +
+		float64(cached)*cachedPrice +
+		float64(e.CacheCreationInputTokens)*writePrice +
+		float64(e.OutputTokens)*p.OutputPerMTok
+	return cost / 1e6, true
+}
+
+// Merge returns a copy of t with the models of overlay added or replaced.
+func (t *PriceTable) Merge(overlay *PriceTable) *PriceTable {
+	if t == nil && overlay == nil {
+		return nil
+	}
+	out := &PriceTable{Models: map[string]ModelPrice{}}
+	for _, src := range []*PriceTable{t, overlay} {
+		if src == nil {
+			continue
+		}
+		for k, v := range src.Models {
+			out.Models[k] = v
+		}
+	}
+	return out
+}
+
+// Apply sets CostUSD and Priced on e from the table.
+func (t *PriceTable) Apply(e Entry) Entry {
+	e.CostUSD, e.Priced = t.Cost(e)
+	return e
+}
+
+// LoadPriceTableFile reads a YAML or JSON price table of the form
+//
+//	models:
+//	  gpt-4o: {input_per_mtok: 2.5, output_per_mtok: 10}
+//	  claude-*: {input_per_mtok: 3, output_per_mtok: 15}
+func LoadPriceTableFile(path string) (*PriceTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read price table: %w", err)
+	}
+	table := &PriceTable{}
+	if err := yaml.Unmarshal(b, table); err != nil {
+		return nil, fmt.Errorf("parse price table %s: %w", path, err)
+	}
+	return table, nil
+}
@@ -0,0 +1,169 @@
+package usagestore
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report dimensions accepted by Summarize.
+const (
+	GroupBySession    = "session"
+	GroupByRuntimeKey = "runtime_key"
+	GroupByProfile    = "profile"
+	GroupByModel      = "model"
+	GroupByDay        = "day"
+)
+
+var groupByDimensions = []string{GroupBySession, GroupByRuntimeKey, GroupByProfile, GroupByModel, GroupByDay}
+
+// Summary aggregates the entries sharing one combination of group keys.
+type Summary struct {
+	Keys                     map[string]string `json:"keys"`
+	Calls                    int64             `json:"calls"`
+	InputTokens              int64             `json:"input_tokens"`
+	OutputTokens             int64             `json:"output_tokens"`
+	CachedTokens             int64             `json:"cached_tokens"`
+	CacheCreationInputTokens int64             `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64             `json:"cache_read_input_tokens"`
+	DurationMs               int64             `json:"duration_ms"`
+	CostUSD                  float64           `json:"cost_usd"`
+	// UnpricedCalls counts calls whose model had no known price.
+	UnpricedCalls int64 `json:"unpriced_calls"`
+}
+
+func (s *Summary) add(e Entry) {
+	s.Calls++
+	s.InputTokens += e.InputTokens
+	s.OutputTokens += e.OutputTokens
+	s.CachedTokens += e.CachedTokens
+	s.CacheCreationInputTokens += e.CacheCreationInputTokens
+	s.CacheReadInputTokens += e.CacheReadInputTokens
+	s.DurationMs += e.DurationMs
+	if e.Priced {
+		s.CostUSD += e.CostUSD
+	} else {
+		s.UnpricedCalls++
+	}
+}
+
+// ParseGroupBy splits a comma-separated dimension list and rejects unknown
+// dimensions.
+func ParseGroupBy(raw string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		dim := strings.ToLower(strings.TrimSpace(part))
+		if dim == "" || seen[dim] {
+			continue
+		}
+		valid := false
+		for _, known := range groupByDimensions {
+			if dim == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown usage group-by dimension %q (expected one of %s)", dim, strings.Join(groupByDimensions, ", "))
+		}
+		seen[dim] = true
+		out = append(out, dim)
+	}
+	return out, nil
+}
+
+// Summarize groups entries by the given dimensions. Days are UTC calendar
+// days. When repricing is non-nil, every entry is priced with it instead of
+// using the cost recorded at call time. Summaries are ordered by their keys.
+func Summarize(entries []Entry, groupBy []string, repricing *PriceTable) []Summary {
+	byKey := map[string]*Summary{}
+	order := []string{}
+	for _, e := range entries {
+		if repricing != nil {
+			e = repricing.Apply(e)
+		}
+		keys := make(map[string]string, len(groupBy))
+		parts := make([]string, 0, len(groupBy))
+		for _, dim := range groupBy {
+			v := entryDimension(e, dim)
+			keys[dim] = v
+			parts = append(parts, v)
+		}
+		id := strings.Join(parts, "\x00")
+		s, ok := byKey[id]
+		if !ok {
+			s = &Summary{Keys: keys}
+			byKey[id] = s
+			order = append(order, id)
+		}
+		s.add(e)
+	}
+	sort.Strings(order)
+	out := make([]Summary, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byKey[id])
+	}
+	return out
+}
+
+// Total sums all summaries into one without group keys.
+func Total(summaries []Summary) Summary {
+	total := Summary{Keys: map[string]string{}}
+	for _, s := range summaries {
+		total.Calls += s.Calls
+		total.InputTokens += s.InputTokens
+		total.OutputTokens += s.OutputTokens
+		total.CachedTokens += s.CachedTokens
+		total.CacheCreationInputTokens += s.CacheCreationInputTokens
+		total.CacheReadInputTokens += s.CacheReadInputTokens
+		total.DurationMs += s.DurationMs
+		total.CostUSD += s.CostUSD
+		total.UnpricedCalls += s.UnpricedCalls
+	}
+	return total
+}
+
+// ParseTimeBound parses a report window bound into Unix milliseconds. It
+// accepts RFC3339 timestamps, UTC dates (2006-01-02), and durations counted
+// back from now ("36h", "7d"). An empty value returns 0, meaning unbounded.
+func ParseTimeBound(raw string, now time.Time) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts.UnixMilli(), nil
+	}
+	if ts, err := time.Parse(time.DateOnly, raw); err == nil {
+		return ts.UnixMilli(), nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n).UnixMilli(), nil
+		}
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return now.Add(-d).UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("invalid time %q (expected RFC3339, YYYY-MM-DD, or a duration such as 24h or 7d)", raw)
+}
+
+func entryDimension(e Entry, dim string) string {
+	switch dim {
+	case GroupBySession:
+		return e.SessionID
+	case GroupByRuntimeKey:
+		return e.RuntimeKey
+	case GroupByProfile:
+		return e.Profile
+	case GroupByModel:
+		return e.Model
+	case GroupByDay:
+		return time.UnixMilli(e.CreatedAtMs).UTC().Format(time.DateOnly)
+	default:
+		return ""
+	}
+}
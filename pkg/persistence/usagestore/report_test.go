@@ -0,0 +1,118 @@
+package usagestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	dims, err := ParseGroupBy(" profile, model ,day,profile")
+	require.NoError(t, err)
+	require.Equal(t, []string{"profile", "model", "day"}, dims)
+
+	dims, err = ParseGroupBy("")
+	require.NoError(t, err)
+	require.Empty(t, dims)
+
+	_, err = ParseGroupBy("profile,project")
+	require.ErrorContains(t, err, "project")
+}
+
+func TestSummarize(t *testing.T) {
+	l := NewMemoryLedger()
+	seedLedger(t, l)
+	entries, err := l.Query(context.Background(), Query{})
+	require.NoError(t, err)
+
+	summaries := Summarize(entries, []string{GroupByProfile, GroupByModel, GroupByDay}, nil)
+	require.Len(t, summaries, 2)
+	require.Equal(t, map[string]string{"profile": "agent", "model": "claude-sonnet", "day": "2026-10-02"}, summaries[0].Keys)
+	require.Equal(t, int64(1), summaries[0].UnpricedCalls)
+	require.Equal(t, map[string]string{"profile": "default", "model": "gpt-4o", "day": "2026-10-01"}, summaries[1].Keys)
+	require.Equal(t, int64(2), summaries[1].Calls)
+	require.Equal(t, int64(3000), summaries[1].InputTokens)
+	require.InDelta(t, 0.03, summaries[1].CostUSD, 1e-9)
+
+	total := Total(summaries)
+	require.Equal(t, int64(3), total.Calls)
+	require.Equal(t, int64(350), total.OutputTokens)
+
+	repriced := Summarize(entries, nil, &PriceTable{Models: map[string]ModelPrice{"claude-*": {InputPerMTok: 3, OutputPerMTok: 15}}})
+	require.Len(t, repriced, 1)
+	require.Equal(t, int64(2), repriced[0].UnpricedCalls)
+	require.InDelta(t, (500*3+50*15)/1e6, repriced[0].CostUSD, 1e-12)
+}
+
+func TestPriceTable(t *testing.T) {
+	table := &PriceTable{Models: map[string]ModelPrice{
+		"gpt-4o":      {InputPerMTok: 2.5, OutputPerMTok: 10, CachedInputPerMTok: 1.25},
+		"gpt-4o-mini": {InputPerMTok: 0.15, OutputPerMTok: 0.6},
+		"claude-*":    {InputPerMTok: 3, OutputPerMTok: 15, CachedInputPerMTok: 0.3, CacheWritePerMTok: 3.75},
+		"claude-o*":   {InputPerMTok: 15, OutputPerMTok: 75},
+	}}
+
+	p, ok := table.Lookup("gpt-4o-mini")
+	require.True(t, ok)
+	require.Equal(t, 0.15, p.InputPerMTok)
+	p, ok = table.Lookup("claude-opus-4")
+	require.True(t, ok)
+	require.Equal(t, 15.0, p.InputPerMTok)
+	_, ok = table.Lookup("llama")
+	require.False(t, ok)
+
+	cost, ok := table.Cost(Entry{Model: "gpt-4o", InputTokens: 1_000_000, CachedTokens: 400_000, OutputTokens: 100_000})
+	require.True(t, ok)
+	require.InDelta(t, 0.6*2.5+0.4*1.25+0.1*10, cost, 1e-9)
+
+	// Anthropic's input_tokens excludes cache reads and writes.
+	cost, ok = table.Cost(Entry{Model: "claude-sonnet", InputTokens: 1_000_000, CachedTokens: 500_000, CacheReadInputTokens: 500_000, CacheCreationInputTokens: 200_000})
+	require.True(t, ok)
+	require.InDelta(t, 1.0*3+0.5*0.3+0.2*3.75, cost, 1e-9)
+
+	merged := table.Merge(&PriceTable{Models: map[string]ModelPrice{"gpt-4o": {InputPerMTok: 5}}})
+	require.Equal(t, 5.0, merged.Models["gpt-4o"].InputPerMTok)
+	require.Equal(t, 2.5, table.Models["gpt-4o"].InputPerMTok)
+}
+
+func TestLoadPriceTableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("models:\n  gpt-4o:\n    input_per_mtok: 2.5\n    output_per_mtok: 10\n"), 0o600))
+	table, err := LoadPriceTableFile(path)
+	require.NoError(t, err)
+	require.Equal(t, ModelPrice{InputPerMTok: 2.5, OutputPerMTok: 10}, table.Models["gpt-4o"])
+
+	_, err = LoadPriceTableFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+
+	ms, err := ParseTimeBound("", now)
+	require.NoError(t, err)
+	require.Zero(t, ms)
+
+	ms, err = ParseTimeBound("2026-10-01", now)
+	require.NoError(t, err)
+	require.Equal(t, day(t, "2026-10-01T00:00:00Z"), ms)
+
+	ms, err = ParseTimeBound("2026-10-01T10:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, day(t, "2026-10-01T10:00:00Z"), ms)
+
+	ms, err = ParseTimeBound("7d", now)
+	require.NoError(t, err)
+	require.Equal(t, day(t, "2026-10-03T12:00:00Z"), ms)
+
+	ms, err = ParseTimeBound("36h", now)
+	require.NoError(t, err)
+	require.Equal(t, day(t, "2026-10-09T00:00:00Z"), ms)
+
+	_, err = ParseTimeBound("last week", now)
+	require.Error(t, err)
+}
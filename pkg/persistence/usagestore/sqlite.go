@@ -0,0 +1,162 @@
+package usagestore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// SQLiteLedger stores usage entries in a single append-only SQLite table. It
+// can share a database file with the turn store.
+type SQLiteLedger struct {
+	db *sql.DB
+}
+
+var _ Ledger = &SQLiteLedger{}
+
+func NewSQLiteLedger(dsn string) (*SQLiteLedger, error) {
+	if strings.TrimSpace(dsn) == "" {
+		return nil, errors.New("sqlite usage ledger: empty dsn")
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	l := &SQLiteLedger{db: db}
+	if err := l.migrate(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// SQLiteDSNForFile returns the DSN used for file-backed ledgers.
+func SQLiteDSNForFile(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errors.New("sqlite usage ledger: empty path")
+	}
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", path), nil
+}
+
+func (l *SQLiteLedger) Close() error {
+	if l == nil || l.db == nil {
+		return nil
+	}
+	return l.db.Close()
+}
+
+func (l *SQLiteLedger) migrate() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS usage_ledger (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT NOT NULL,
+			run_id TEXT NOT NULL DEFAULT '',
+			turn_id TEXT NOT NULL DEFAULT '',
+			provider_call_id TEXT NOT NULL DEFAULT '',
+			runtime_key TEXT NOT NULL DEFAULT '',
+			profile TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			cached_tokens INTEGER NOT NULL DEFAULT 0,
+			cache_creation_input_tokens INTEGER NOT NULL DEFAULT 0,
+			cache_read_input_tokens INTEGER NOT NULL DEFAULT 0,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			cost_usd REAL NOT NULL DEFAULT 0,
+			priced INTEGER NOT NULL DEFAULT 0,
+			created_at_ms INTEGER NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS usage_ledger_by_created ON usage_ledger(created_at_ms);`,
+		`CREATE INDEX IF NOT EXISTS usage_ledger_by_session ON usage_ledger(session_id, created_at_ms);`,
+		`CREATE INDEX IF NOT EXISTS usage_ledger_by_profile_model ON usage_ledger(profile, model, created_at_ms);`,
+	}
+	for _, st := range stmts {
+		if _, err := l.db.Exec(st); err != nil {
+			return errors.Wrap(err, "sqlite usage ledger: migrate")
+		}
+	}
+	return nil
+}
+
+func (l *SQLiteLedger) Record(ctx context.Context, e Entry) error {
+	e = normalizeEntry(e)
+	if e.SessionID == "" {
+		return errors.New("sqlite usage ledger: empty session id")
+	}
+	if _, err := l.db.ExecContext(ctx, `
+		INSERT INTO usage_ledger(
+			session_id, run_id, turn_id, provider_call_id, runtime_key, profile, model,
+			input_tokens, output_tokens, cached_tokens, cache_creation_input_tokens, cache_read_input_tokens,
+			duration_ms, cost_usd, priced, created_at_ms
+		) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.SessionID, e.RunID, e.TurnID, e.ProviderCallID, e.RuntimeKey, e.Profile, e.Model,
+		e.InputTokens, e.OutputTokens, e.CachedTokens, e.CacheCreationInputTokens, e.CacheReadInputTokens,
+		e.DurationMs, e.CostUSD, e.Priced, e.CreatedAtMs); err != nil {
+		return errors.Wrap(err, "sqlite usage ledger: insert entry")
+	}
+	return nil
+}
+
+func (l *SQLiteLedger) Query(ctx context.Context, q Query) ([]Entry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"session_id", q.SessionID},
+		{"runtime_key", q.RuntimeKey},
+		{"profile", q.Profile},
+		{"model", q.Model},
+	} {
+		if v := strings.TrimSpace(f.value); v != "" {
+			where = append(where, f.column+" = ?")
+			args = append(args, v)
+		}
+	}
+	if q.SinceMs > 0 {
+		where = append(where, "created_at_ms >= ?")
+		args = append(args, q.SinceMs)
+	}
+	if q.UntilMs > 0 {
+		where = append(where, "created_at_ms < ?")
+		args = append(args, q.UntilMs)
+	}
+	query := `
+		SELECT session_id, run_id, turn_id, provider_call_id, runtime_key, profile, model,
+			input_tokens, output_tokens, cached_tokens, cache_creation_input_tokens, cache_read_input_tokens,
+			duration_ms, cost_usd, priced, created_at_ms
+		FROM usage_ledger`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at_ms ASC, id ASC"
+
+	// #nosec G202 -- where only joins constant column predicates; values remain parameterized.
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlite usage ledger: query")
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.SessionID, &e.RunID, &e.TurnID, &e.ProviderCallID, &e.RuntimeKey, &e.Profile, &e.Model,
+			&e.InputTokens, &e.OutputTokens, &e.CachedTokens, &e.CacheCreationInputTokens, &e.CacheReadInputTokens,
+			&e.DurationMs, &e.CostUSD, &e.Priced, &e.CreatedAtMs); err != nil {
+			return nil, errors.Wrap(err, "sqlite usage ledger: scan entry")
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "sqlite usage ledger: iterate entries")
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+package profileedit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/secrets"
+)
+
+type Change string
+
+const (
+	ChangeAdded     Change = "added"
+	ChangeRemoved   Change = "removed"
+	ChangeChanged   Change = "changed"
+	ChangeUnchanged Change = "unchanged"
+)
+
+// DiffEntry is one leaf path of two effective profile trees. Left or Right is
+// nil when the path is missing on that side.
+type DiffEntry struct {
+	Path   string
+	Left   any
+	Right  any
+	Change Change
+}
+
+// EffectiveTree returns a fully resolved profile as a plain tree: the merged
+// inference settings of its stack under inference_settings, and the merged
+// web-chat runtime, usage pricing and JS tools extensions under runtime,
+// pricing and js_tools.
+// API keys are redacted.
+func EffectiveTree(ctx context.Context, registry gepprofiles.Registry, resolved *gepprofiles.ResolvedEngineProfile) (map[string]any, error) {
+	plan, err := infruntime.ResolveRuntimePlan(ctx, registry, resolved, infruntime.ResolveRuntimePlanOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]any{}
+	if plan.InferenceSettings != nil {
+		if err := setPlain(ret, "inference_settings", plan.InferenceSettings); err != nil {
+			return nil, err
+		}
+	}
+	if plan.Runtime != nil {
+		if err := setPlain(ret, "runtime", plan.Runtime); err != nil {
+			return nil, err
+		}
+	}
+	if plan.Pricing != nil {
+		if err := setPlain(ret, "pricing", plan.Pricing); err != nil {
+			return nil, err
+		}
+	}
+	if plan.JSTools != nil {
+		if err := setPlain(ret, "js_tools", plan.JSTools); err != nil {
+			return nil, err
+		}
+	}
+	redactAPIKeys(ret)
+	return ret, nil
+}
+
+// Diff compares two trees leaf by leaf, sorted by path. Lists are compared as
+// whole values. Unchanged leaves are included only when includeUnchanged is
+// set.
+func Diff(left, right map[string]any, includeUnchanged bool) []DiffEntry {
+	leftLeaves, rightLeaves := map[string]any{}, map[string]any{}
+	flattenLeaves(nil, left, leftLeaves)
+	flattenLeaves(nil, right, rightLeaves)
+
+	paths := make([]string, 0, len(leftLeaves)+len(rightLeaves))
+	for path := range leftLeaves {
+		paths = append(paths, path)
+	}
+	for path := range rightLeaves {
+		if _, ok := leftLeaves[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	ret := []DiffEntry{}
+	for _, path := range paths {
+		l, inLeft := leftLeaves[path]
+		r, inRight := rightLeaves[path]
+		entry := DiffEntry{Path: path, Left: l, Right: r}
+		switch {
+		case !inLeft:
+			entry.Change = ChangeAdded
+		case !inRight:
+			entry.Change = ChangeRemoved
+		case reflect.DeepEqual(l, r):
+			if !includeUnchanged {
+				continue
+			}
+			entry.Change = ChangeUnchanged
+		default:
+			entry.Change = ChangeChanged
+		}
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+func setPlain(tree map[string]any, key string, value any) error {
+	plain, err := plainValue(value)
+	if err != nil {
+		return fmt.Errorf("encode effective %s: %w", key, err)
+	}
+	if m, ok := plain.(map[string]any); ok && !pruneEmpty(m) {
+		tree[key] = m
+	}
+	return nil
+}
+
+func flattenLeaves(prefix []string, value any, out map[string]any) {
+	m, ok := value.(map[string]any)
+	if !ok || len(m) == 0 {
+		if len(prefix) > 0 {
+			out[configdoc.FormatPath(prefix)] = value
+		}
+		return
+	}
+	for key, item := range m {
+		flattenLeaves(append(append([]string(nil), prefix...), key), item, out)
+	}
+}
+
+func redactAPIKeys(tree map[string]any) {
+	settings, _ := tree["inference_settings"].(map[string]any)
+	api, _ := settings["api"].(map[string]any)
+	keys, _ := api["api_keys"].(map[string]any)
+	for name, value := range keys {
+		if text, ok := value.(string); ok {
+			keys[name] = secrets.RedactValue(text)
+		}
+	}
+}
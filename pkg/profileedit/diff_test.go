@@ -0,0 +1,48 @@
+package profileedit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffComparesLeaves(t *testing.T) {
+	left := map[string]any{
+		"inference_settings": map[string]any{
+			"chat": map[string]any{"engine": "gpt-5", "api_type": "openai-responses"},
+		},
+		"runtime": map[string]any{"tools": []any{"calculator"}},
+	}
+	right := map[string]any{
+		"inference_settings": map[string]any{
+			"chat":      map[string]any{"engine": "gpt-5-mini", "api_type": "openai-responses"},
+			"inference": map[string]any{"reasoning_effort": "low"},
+		},
+	}
+
+	entries := Diff(left, right, false)
+	require.Equal(t, []DiffEntry{
+		{Path: "inference_settings.chat.engine", Left: "gpt-5", Right: "gpt-5-mini", Change: ChangeChanged},
+		{Path: "inference_settings.inference.reasoning_effort", Right: "low", Change: ChangeAdded},
+		{Path: "runtime.tools", Left: []any{"calculator"}, Change: ChangeRemoved},
+	}, entries)
+
+	all := Diff(left, right, true)
+	require.Len(t, all, 4)
+	require.Equal(t, ChangeUnchanged, all[0].Change)
+}
+
+func TestRedactAPIKeys(t *testing.T) {
+	tree := map[string]any{
+		"inference_settings": map[string]any{
+			"api": map[string]any{"api_keys": map[string]any{
+				"openai-api-key": "sk-123",
+				"claude-api-key": "${env:ANTHROPIC_API_KEY}",
+			}},
+		},
+	}
+	redactAPIKeys(tree)
+	keys := tree["inference_settings"].(map[string]any)["api"].(map[string]any)["api_keys"].(map[string]any)
+	require.Equal(t, "<redacted>", keys["openai-api-key"])
+	require.Equal(t, "<redacted:env-ref>", keys["claude-api-key"])
+}
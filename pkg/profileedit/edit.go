@@ -0,0 +1,320 @@
+package profileedit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"gopkg.in/yaml.v3"
+)
+
+// Assignment sets Path, relative to the profile, to Value. The value is
+// parsed as YAML, like pinocchio config set.
+type Assignment struct {
+	Path  string
+	Value string
+}
+
+// ParseAssignment parses "path=value", for example
+// "inference_settings.chat.engine=gpt-5-mini".
+func ParseAssignment(raw string) (Assignment, error) {
+	path, value, ok := strings.Cut(raw, "=")
+	path = strings.TrimSpace(path)
+	if !ok || path == "" {
+		return Assignment{}, fmt.Errorf("invalid assignment %q: expected path=value", raw)
+	}
+	if _, err := configdoc.ParsePath(path); err != nil {
+		return Assignment{}, fmt.Errorf("invalid assignment %q: %w", raw, err)
+	}
+	return Assignment{Path: path, Value: value}, nil
+}
+
+// Definition converts a stored profile into the body Create writes. The OAuth
+// credential tuple is dropped so a copy never shares tokens with its source.
+func Definition(profile *gepprofiles.EngineProfile) (map[string]any, error) {
+	ret := map[string]any{}
+	if profile == nil {
+		return ret, nil
+	}
+	if displayName := strings.TrimSpace(profile.DisplayName); displayName != "" {
+		ret["display_name"] = displayName
+	}
+	if description := strings.TrimSpace(profile.Description); description != "" {
+		ret["description"] = description
+	}
+	if len(profile.Stack) > 0 {
+		stack, err := plainValue(profile.Stack)
+		if err != nil {
+			return nil, fmt.Errorf("encode profile stack: %w", err)
+		}
+		ret["stack"] = stack
+	}
+	if profile.InferenceSettings != nil {
+		settings, err := plainValue(profile.InferenceSettings)
+		if err != nil {
+			return nil, fmt.Errorf("encode profile inference settings: %w", err)
+		}
+		if m, ok := settings.(map[string]any); ok && !pruneEmpty(m) {
+			ret["inference_settings"] = m
+		}
+	}
+	if len(profile.Extensions) > 0 {
+		ret["extensions"] = oauthprofiles.WithoutCredential(profile.Extensions)
+	}
+	return ret, nil
+}
+
+// WithoutAPIKeys removes the API keys from a profile definition and prunes
+// the maps left empty, so a copied profile does not duplicate credentials.
+func WithoutAPIKeys(definition map[string]any) {
+	settings, _ := definition["inference_settings"].(map[string]any)
+	api, _ := settings["api"].(map[string]any)
+	if api == nil {
+		return
+	}
+	delete(api, "api_keys")
+	if pruneEmpty(settings) {
+		delete(definition, "inference_settings")
+	}
+}
+
+// Create writes profile slug with definition to the target and then applies
+// assignments, in one validated write. An existing profile is replaced only
+// when force is set.
+func (t Target) Create(slug string, definition map[string]any, assignments []Assignment, force bool) error {
+	parsed, err := gepprofiles.ParseEngineProfileSlug(slug)
+	if err != nil {
+		return err
+	}
+	slug = parsed.String()
+	body := map[string]any{}
+	for key, value := range definition {
+		body[key] = value
+	}
+	if t.Kind == TargetKindRegistry {
+		body["slug"] = slug
+	}
+	value, err := yaml.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode profile %s: %w", slug, err)
+	}
+	return t.edit(slug, func(data []byte) ([]byte, error) {
+		if hasProfile(data, slug) && !force {
+			return nil, fmt.Errorf("profile %q already exists in %s; use --force to replace it", slug, t.Path)
+		}
+		data, err := configdoc.SetPath(data, profilePath(slug, nil), string(value))
+		if err != nil {
+			return nil, err
+		}
+		return applyEdits(data, slug, assignments, nil)
+	})
+}
+
+// Update applies assignments and then removes the unset paths of profile
+// slug. Paths are relative to the profile.
+func (t Target) Update(slug string, assignments []Assignment, unset []string) error {
+	parsed, err := gepprofiles.ParseEngineProfileSlug(slug)
+	if err != nil {
+		return err
+	}
+	slug = parsed.String()
+	return t.edit(slug, func(data []byte) ([]byte, error) {
+		if !hasProfile(data, slug) {
+			return nil, fmt.Errorf("profile %q is not defined in %s", slug, t.Path)
+		}
+		return applyEdits(data, slug, assignments, unset)
+	})
+}
+
+func applyEdits(data []byte, slug string, assignments []Assignment, unset []string) ([]byte, error) {
+	for _, assignment := range assignments {
+		segments, err := configdoc.ParsePath(assignment.Path)
+		if err != nil {
+			return nil, err
+		}
+		data, err = configdoc.SetPath(data, profilePath(slug, segments), assignment.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range unset {
+		segments, err := configdoc.ParsePath(path)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err = configdoc.UnsetPath(data, profilePath(slug, segments))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// edit reads the target, applies fn and writes the result once it validates.
+// Registry files are rewritten under the registry lock. Config documents are
+// written like pinocchio config set does.
+func (t Target) edit(slug string, fn func([]byte) ([]byte, error)) error {
+	apply := func() error {
+		data, err := os.ReadFile(t.Path)
+		if err != nil && !(os.IsNotExist(err) && t.Kind == TargetKindConfig) {
+			return fmt.Errorf("read %s: %w", t.Path, err)
+		}
+		edited, err := fn(data)
+		if err != nil {
+			return err
+		}
+		if err := t.validate(slug, edited); err != nil {
+			return err
+		}
+		return writeFile(t.Path, edited)
+	}
+	if t.Kind == TargetKindRegistry {
+		return oauthprofiles.WithRegistryLock(t.Path, true, apply)
+	}
+	return apply()
+}
+
+// validate refuses edits that leave the file undecodable or the edited
+// profile with an invalid Pinocchio extension.
+func (t Target) validate(slug string, data []byte) error {
+	var profile *gepprofiles.EngineProfile
+	switch t.Kind {
+	case TargetKindRegistry:
+		registry, err := gepprofiles.DecodeEngineProfileYAMLSingleRegistry(data)
+		if err != nil {
+			return fmt.Errorf("refusing to write invalid profile registry %s: %w", t.Path, err)
+		}
+		if registry == nil || registry.Slug.String() != t.Registry {
+			return fmt.Errorf("refusing to write %s: the registry slug must stay %q", t.Path, t.Registry)
+		}
+		profile = registry.Profiles[gepprofiles.EngineProfileSlug(slug)]
+	default:
+		findings := configdoc.ValidateDocument(t.Path, data)
+		if configdoc.HasErrors(findings) {
+			return fmt.Errorf("refusing to write invalid config:\n%s", findingLines(findings))
+		}
+		doc, err := configdoc.DecodeDocumentWithSource(t.Path, data)
+		if err != nil {
+			return err
+		}
+		registry, err := configdoc.InlineProfilesToRegistry(doc, "")
+		if err != nil {
+			return err
+		}
+		profile = registry.Profiles[gepprofiles.EngineProfileSlug(slug)]
+	}
+	if profile == nil {
+		return fmt.Errorf("refusing to write %s: profile %q would no longer be defined", t.Path, slug)
+	}
+	findings := extensionFindings(t.Path, slug, profile)
+	if configdoc.HasErrors(findings) {
+		return fmt.Errorf("refusing to write invalid profile:\n%s", findingLines(findings))
+	}
+	for _, finding := range findings {
+		log.Warn().Str("file", finding.File).Str("path", finding.Path).Msg(finding.Message)
+	}
+	return nil
+}
+
+func hasProfile(data []byte, slug string) bool {
+	var doc struct {
+		Profiles map[string]any `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false
+	}
+	for key := range doc.Profiles {
+		if strings.EqualFold(strings.TrimSpace(key), slug) {
+			return true
+		}
+	}
+	return false
+}
+
+func profilePath(slug string, segments []string) string {
+	return configdoc.FormatPath(append([]string{"profiles", slug}, segments...))
+}
+
+// writeFile replaces path atomically, keeping the mode of an existing file.
+func writeFile(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".edit-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func findingLines(findings []configdoc.Finding) string {
+	lines := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		lines = append(lines, finding.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func plainValue(value any) (any, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var ret any
+	if err := yaml.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// pruneEmpty drops empty strings, nulls, and empty maps and lists, and
+// reports whether m is empty afterwards.
+func pruneEmpty(m map[string]any) bool {
+	for k, v := range m {
+		switch vv := v.(type) {
+		case map[string]any:
+			if pruneEmpty(vv) {
+				delete(m, k)
+			}
+		case []any:
+			if len(vv) == 0 {
+				delete(m, k)
+			}
+		case string:
+			if vv == "" {
+				delete(m, k)
+			}
+		case nil:
+			delete(m, k)
+		}
+	}
+	return len(m) == 0
+}
@@ -0,0 +1,145 @@
+package profileedit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+	"github.com/stretchr/testify/require"
+)
+
+const workspaceRegistry = `slug: workspace
+profiles:
+  default:
+    slug: default
+    # the shared baseline
+    inference_settings:
+      chat:
+        api_type: openai-responses
+        engine: gpt-5
+`
+
+func writeFixture(t *testing.T, name, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), mode))
+	return path
+}
+
+func TestLocateFindsProfileInYAMLRegistry(t *testing.T) {
+	path := writeFixture(t, "profiles.yaml", workspaceRegistry, 0o644)
+
+	target, err := Locate(nil, []string{path}, "", "default")
+	require.NoError(t, err)
+	require.Equal(t, TargetKindRegistry, target.Kind)
+	require.Equal(t, "workspace", target.Registry)
+
+	_, err = Locate(nil, []string{path}, "other", "default")
+	require.Error(t, err)
+}
+
+func TestCreateCopiesProfileIntoRegistryAndKeepsComments(t *testing.T) {
+	path := writeFixture(t, "profiles.yaml", workspaceRegistry, 0o600)
+	target := Target{Kind: TargetKindRegistry, Path: path, Registry: "workspace"}
+
+	definition, err := Definition(&gepprofiles.EngineProfile{
+		Stack: []gepprofiles.EngineProfileRef{{EngineProfileSlug: gepprofiles.MustEngineProfileSlug("default")}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, target.Create("mini", definition, nil, false))
+	require.NoError(t, target.Update("mini", []Assignment{{Path: "inference_settings.chat.engine", Value: "gpt-5-mini"}}, nil))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "# the shared baseline")
+	registry, err := gepprofiles.DecodeEngineProfileYAMLSingleRegistry(data)
+	require.NoError(t, err)
+	mini := registry.Profiles[gepprofiles.MustEngineProfileSlug("mini")]
+	require.NotNil(t, mini)
+	require.Equal(t, "gpt-5-mini", *mini.InferenceSettings.Chat.Engine)
+	require.Len(t, mini.Stack, 1)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	err = target.Create("mini", definition, nil, false)
+	require.ErrorContains(t, err, "already exists")
+}
+
+func TestUpdateRefusesInvalidEdits(t *testing.T) {
+	path := writeFixture(t, "profiles.yaml", workspaceRegistry, 0o644)
+	target := Target{Kind: TargetKindRegistry, Path: path, Registry: "workspace"}
+
+	err := target.Update("default", []Assignment{{Path: "stack", Value: "not-a-list"}}, nil)
+	require.Error(t, err)
+	err = target.Update("missing", []Assignment{{Path: "display_name", Value: "x"}}, nil)
+	require.ErrorContains(t, err, "not defined")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, workspaceRegistry, string(data))
+}
+
+func TestCreateWritesInlineConfigProfile(t *testing.T) {
+	path := writeFixture(t, ".pinocchio.yml", "profile:\n  active: default\n", 0o644)
+	target, err := CreateTarget(nil, nil, "", path)
+	require.NoError(t, err)
+	require.Equal(t, TargetKindConfig, target.Kind)
+
+	require.NoError(t, target.Create("assistant", map[string]any{"display_name": "Assistant"}, nil, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "active: default")
+	require.Contains(t, string(data), "display_name: Assistant")
+	require.False(t, strings.Contains(string(data), "slug:"))
+}
+
+func TestDefinitionDropsOAuthCredential(t *testing.T) {
+	definition, err := Definition(&gepprofiles.EngineProfile{
+		Extensions: map[string]any{
+			oauthprofiles.ExtensionKey: map[string]any{
+				"client_id":     "pinocchio",
+				"access_token":  "secret-access",
+				"refresh_token": "secret-refresh",
+			},
+		},
+	})
+	require.NoError(t, err)
+	oauth := definition["extensions"].(map[string]any)[oauthprofiles.ExtensionKey].(map[string]any)
+	require.Equal(t, "pinocchio", oauth["client_id"])
+	require.NotContains(t, oauth, "access_token")
+	require.NotContains(t, oauth, "refresh_token")
+}
+
+func TestWithoutAPIKeysPrunesEmptySettings(t *testing.T) {
+	definition := map[string]any{
+		"inference_settings": map[string]any{
+			"api": map[string]any{"api_keys": map[string]any{"openai-api-key": "sk-live"}},
+		},
+	}
+	WithoutAPIKeys(definition)
+	require.Empty(t, definition)
+
+	definition = map[string]any{
+		"inference_settings": map[string]any{
+			"api":  map[string]any{"api_keys": map[string]any{"openai-api-key": "sk-live"}},
+			"chat": map[string]any{"engine": "gpt-5-mini"},
+		},
+	}
+	WithoutAPIKeys(definition)
+	require.Equal(t, map[string]any{"inference_settings": map[string]any{"chat": map[string]any{"engine": "gpt-5-mini"}}}, definition)
+}
+
+func TestParseAssignment(t *testing.T) {
+	assignment, err := ParseAssignment("inference_settings.chat.engine=gpt-5=mini")
+	require.NoError(t, err)
+	require.Equal(t, Assignment{Path: "inference_settings.chat.engine", Value: "gpt-5=mini"}, assignment)
+
+	_, err = ParseAssignment("no-value")
+	require.Error(t, err)
+}
@@ -0,0 +1,212 @@
+// Package profileedit creates and edits engine profiles in the files that
+// define them: direct YAML profile registries and the inline profiles of
+// Pinocchio config documents. Edits keep comments and layout, are validated
+// before they are written, and hold a file lock while the file is rewritten.
+package profileedit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	"gopkg.in/yaml.v3"
+)
+
+type TargetKind string
+
+const (
+	// TargetKindRegistry is a direct YAML profile registry file.
+	TargetKindRegistry TargetKind = "registry"
+	// TargetKindConfig is a Pinocchio config document with inline profiles.
+	TargetKindConfig TargetKind = "config"
+)
+
+// Target is one file that profiles are written to. Registry is the registry
+// slug the file provides; config documents provide the inline registry.
+type Target struct {
+	Kind     TargetKind
+	Path     string
+	Registry string
+}
+
+// Locate returns the file that defines registry/profile. Inline profiles are
+// found in the config file that wins for profiles.<slug>; imported profiles in
+// the direct YAML registry source with a matching slug. An empty registry
+// searches inline profiles first, like the composed profile registry, and
+// then every YAML registry source; a profile found in several sources must be
+// qualified.
+func Locate(docs *configdoc.ResolvedDocuments, registryEntries []string, registry, profile string) (Target, error) {
+	registry, profile = strings.TrimSpace(registry), strings.TrimSpace(profile)
+	if profile == "" {
+		return Target{}, errors.New("profile slug is required")
+	}
+	if registry == "" || registry == configdoc.DefaultInlineRegistrySlug {
+		target, ok, err := locateInline(docs, profile)
+		if err != nil || ok {
+			return target, err
+		}
+		if registry != "" {
+			return Target{}, fmt.Errorf("profile %q is not defined inline in any config file", profile)
+		}
+	}
+
+	sources, err := yamlRegistrySources(registryEntries)
+	if err != nil {
+		return Target{}, err
+	}
+	matches := []Target{}
+	for _, source := range sources {
+		if registry != "" && source.Registry != registry {
+			continue
+		}
+		if _, ok := source.profiles[profile]; ok {
+			matches = append(matches, source.Target)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		ref := profile
+		if registry != "" {
+			ref = registry + "/" + profile
+		}
+		return Target{}, fmt.Errorf("profile %q is not defined inline or in a direct YAML registry; only those can be edited", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return Target{}, fmt.Errorf("profile %q is defined in registries %s; qualify it as registry/profile", profile, joinRegistries(matches))
+	}
+}
+
+// CreateTarget returns the file a new profile in registry is written to. An
+// empty registry or the inline registry slug selects inline config profiles:
+// the config file that already holds the winning inline profiles, or the user
+// config file when there are none. file, when set, overrides the lookup; it is
+// treated as a registry when it has a top-level slug.
+func CreateTarget(docs *configdoc.ResolvedDocuments, registryEntries []string, registry, file string) (Target, error) {
+	registry = strings.TrimSpace(registry)
+	if file = strings.TrimSpace(file); file != "" {
+		return targetForFile(file)
+	}
+	if registry == "" || registry == configdoc.DefaultInlineRegistrySlug {
+		if docs != nil {
+			explanation, err := docs.ExplainPath("profiles")
+			if err != nil {
+				return Target{}, err
+			}
+			if winner := explanation.Winner(); winner != nil {
+				return configTarget(winner.File.Path), nil
+			}
+		}
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return Target{}, fmt.Errorf("resolve user config directory: %w", err)
+		}
+		return configTarget(filepath.Join(dir, "pinocchio", "config.yaml")), nil
+	}
+
+	sources, err := yamlRegistrySources(registryEntries)
+	if err != nil {
+		return Target{}, err
+	}
+	for _, source := range sources {
+		if source.Registry == registry {
+			return source.Target, nil
+		}
+	}
+	return Target{}, fmt.Errorf("registry %q is not a direct YAML registry source; only those can be edited", registry)
+}
+
+func locateInline(docs *configdoc.ResolvedDocuments, profile string) (Target, bool, error) {
+	if docs == nil {
+		return Target{}, false, nil
+	}
+	explanation, err := docs.ExplainPath(configdoc.FormatPath([]string{"profiles", profile}))
+	if err != nil {
+		return Target{}, false, err
+	}
+	winner := explanation.Winner()
+	if winner == nil {
+		return Target{}, false, nil
+	}
+	return configTarget(winner.File.Path), true, nil
+}
+
+func configTarget(path string) Target {
+	return Target{Kind: TargetKindConfig, Path: path, Registry: configdoc.DefaultInlineRegistrySlug}
+}
+
+func targetForFile(path string) (Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return Target{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	var head struct {
+		Slug string `yaml:"slug"`
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &head); err != nil {
+			return Target{}, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+	if strings.TrimSpace(head.Slug) == "" {
+		return configTarget(path), nil
+	}
+	return Target{Kind: TargetKindRegistry, Path: path, Registry: strings.TrimSpace(head.Slug)}, nil
+}
+
+type registrySource struct {
+	Target
+	profiles map[string]struct{}
+}
+
+// yamlRegistrySources decodes every direct YAML registry among the profile
+// registry entries. Other source kinds, such as SQLite, cannot be edited and
+// are skipped.
+func yamlRegistrySources(entries []string) ([]registrySource, error) {
+	specs, err := gepprofiles.ParseRegistrySourceSpecs(entries)
+	if err != nil {
+		return nil, err
+	}
+	ret := []registrySource{}
+	for _, spec := range specs {
+		if spec.Kind != gepprofiles.RegistrySourceKindYAML {
+			continue
+		}
+		data, err := os.ReadFile(spec.Path)
+		if err != nil {
+			return nil, fmt.Errorf("read profile registry %s: %w", spec.Path, err)
+		}
+		registry, err := gepprofiles.DecodeEngineProfileYAMLSingleRegistry(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode profile registry %s: %w", spec.Path, err)
+		}
+		if registry == nil {
+			continue
+		}
+		path, err := filepath.Abs(spec.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve profile registry path: %w", err)
+		}
+		source := registrySource{
+			Target:   Target{Kind: TargetKindRegistry, Path: path, Registry: registry.Slug.String()},
+			profiles: map[string]struct{}{},
+		}
+		for slug := range registry.Profiles {
+			source.profiles[slug.String()] = struct{}{}
+		}
+		ret = append(ret, source)
+	}
+	return ret, nil
+}
+
+func joinRegistries(targets []Target) string {
+	ret := make([]string, 0, len(targets))
+	for _, target := range targets {
+		ret = append(ret, target.Registry)
+	}
+	return strings.Join(ret, ", ")
+}
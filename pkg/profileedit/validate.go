@@ -0,0 +1,203 @@
+package profileedit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	gepprofiles "github.com/go-go-golems/geppetto/pkg/engineprofiles"
+	"github.com/go-go-golems/geppetto/pkg/inference/middlewarecfg"
+	"github.com/go-go-golems/pinocchio/pkg/configdoc"
+	infruntime "github.com/go-go-golems/pinocchio/pkg/inference/runtime"
+	"github.com/go-go-golems/pinocchio/pkg/oauthprofiles"
+)
+
+const (
+	webChatRuntimeExtensionKey = "pinocchio.webchat_runtime@v1"
+	usagePricingExtensionKey   = "pinocchio.usage_pricing@v1"
+	jsToolsExtensionKey        = "pinocchio.js_tools@v1"
+)
+
+// extensionDecoders decode the profile extensions Pinocchio owns. Extensions
+// of other namespaces belong to embedding applications and are only checked
+// for a well-formed key.
+var extensionDecoders = map[string]func(*gepprofiles.EngineProfile) error{
+	oauthprofiles.ExtensionKey: func(profile *gepprofiles.EngineProfile) error {
+		_, err := oauthprofiles.Parse(profile.Extensions)
+		return err
+	},
+	webChatRuntimeExtensionKey: func(profile *gepprofiles.EngineProfile) error {
+		_, _, err := infruntime.ProfileRuntimeFromEngineProfile(profile)
+		return err
+	},
+	usagePricingExtensionKey: func(profile *gepprofiles.EngineProfile) error {
+		_, _, err := infruntime.UsagePricingFromEngineProfile(profile)
+		return err
+	},
+	jsToolsExtensionKey: func(profile *gepprofiles.EngineProfile) error {
+		_, _, err := infruntime.JSToolsFromEngineProfile(profile)
+		return err
+	},
+}
+
+// SourceFunc names the file or registry source that defines a profile, for
+// findings.
+type SourceFunc func(registry, profile string) string
+
+// Validate checks every profile of registry: that its stack resolves, that
+// the Pinocchio extensions it sets decode, and that every middleware of its
+// effective runtime is defined in definitions with a config that resolves
+// against the definition schema. A nil definitions registry skips the
+// middleware checks.
+func Validate(ctx context.Context, registry gepprofiles.Registry, definitions middlewarecfg.DefinitionRegistry, source SourceFunc) ([]configdoc.Finding, error) {
+	findings := []configdoc.Finding{}
+	if registry == nil {
+		return findings, nil
+	}
+	if source == nil {
+		source = func(registry, _ string) string { return registry }
+	}
+	summaries, err := registry.ListRegistries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list profile registries: %w", err)
+	}
+	for _, summary := range summaries {
+		profiles, err := registry.ListEngineProfiles(ctx, summary.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("list profiles of registry %s: %w", summary.Slug, err)
+		}
+		sort.Slice(profiles, func(i, j int) bool { return profiles[i].Slug < profiles[j].Slug })
+		for _, profile := range profiles {
+			if profile == nil {
+				continue
+			}
+			slug := profile.Slug.String()
+			file := source(summary.Slug.String(), slug)
+			findings = append(findings, extensionFindings(file, slug, profile)...)
+
+			resolved, err := registry.ResolveEngineProfile(ctx, gepprofiles.ResolveInput{
+				RegistrySlug:      summary.Slug,
+				EngineProfileSlug: profile.Slug,
+			})
+			if err != nil {
+				findings = append(findings, errorFinding(file, []string{"profiles", slug, "stack"}, "%s", err.Error()))
+				continue
+			}
+			plan, err := infruntime.ResolveRuntimePlan(ctx, registry, resolved, infruntime.ResolveRuntimePlanOptions{})
+			if err != nil {
+				findings = append(findings, errorFinding(file, []string{"profiles", slug}, "resolve runtime: %s", err.Error()))
+				continue
+			}
+			if definitions != nil && plan.Runtime != nil {
+				hasJSTools := plan.JSTools != nil && len(plan.JSTools.Scripts) > 0
+				findings = append(findings, middlewareFindings(file, slug, plan.Runtime.Middlewares, definitions, hasJSTools)...)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// extensionFindings checks the extension keys of one profile and decodes the
+// extensions Pinocchio owns.
+func extensionFindings(file, slug string, profile *gepprofiles.EngineProfile) []configdoc.Finding {
+	ret := []configdoc.Finding{}
+	keys := make([]string, 0, len(profile.Extensions))
+	for key := range profile.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := []string{"profiles", slug, "extensions", key}
+		if _, err := gepprofiles.ParseExtensionKey(key); err != nil {
+			ret = append(ret, errorFinding(file, path, "invalid extension key: %s", err.Error()))
+			continue
+		}
+		decode, ok := extensionDecoders[key]
+		if !ok {
+			if strings.HasPrefix(key, "pinocchio.") {
+				ret = append(ret, configdoc.Finding{
+					File:     file,
+					Path:     configdoc.FormatPath(path),
+					Severity: configdoc.FindingSeverityWarning,
+					Message:  "unknown Pinocchio extension; it is ignored",
+				})
+			}
+			continue
+		}
+		if err := decode(profile); err != nil {
+			ret = append(ret, errorFinding(file, path, "%s", err.Error()))
+		}
+	}
+	return ret
+}
+
+// middlewareFindings resolves each effective runtime middleware against its
+// definition, the way the web-chat runtime composer does before it builds
+// the chain. Profiles with JS tool modules may use middlewares those modules
+// register, so unknown names are only warnings for them.
+func middlewareFindings(file, slug string, uses []infruntime.MiddlewareUse, definitions middlewarecfg.DefinitionRegistry, hasJSTools bool) []configdoc.Finding {
+	ret := []configdoc.Finding{}
+	for i, use := range uses {
+		name := strings.TrimSpace(use.Name)
+		if name == "" {
+			continue
+		}
+		cfgUse := middlewarecfg.Use{Name: name, ID: strings.TrimSpace(use.ID), Enabled: use.Enabled}
+		path := []string{"profiles", slug, "extensions", webChatRuntimeExtensionKey, "middlewares", middlewarecfg.MiddlewareInstanceKey(cfgUse, i)}
+		def, ok := definitions.GetDefinition(name)
+		if !ok && hasJSTools {
+			ret = append(ret, configdoc.Finding{
+				File:     file,
+				Path:     configdoc.FormatPath(path),
+				Severity: configdoc.FindingSeverityWarning,
+				Message:  fmt.Sprintf("middleware %q is not built in; a js_tools module must register it", name),
+			})
+			continue
+		}
+		if !ok {
+			ret = append(ret, errorFinding(file, path, "unknown middleware %q", name))
+			continue
+		}
+		sources := []middlewarecfg.Source{}
+		if len(use.Config) > 0 {
+			sources = append(sources, profileConfigSource{payload: use.Config})
+		}
+		if _, err := middlewarecfg.NewResolver(sources...).Resolve(def, cfgUse); err != nil {
+			ret = append(ret, errorFinding(file, path, "%s", err.Error()))
+		}
+	}
+	return ret
+}
+
+type profileConfigSource struct {
+	payload map[string]any
+}
+
+func (s profileConfigSource) Name() string {
+	return "profile"
+}
+
+func (s profileConfigSource) Layer() middlewarecfg.SourceLayer {
+	return middlewarecfg.SourceLayerProfile
+}
+
+func (s profileConfigSource) Payload(middlewarecfg.Definition, middlewarecfg.Use) (map[string]any, bool, error) {
+	if len(s.payload) == 0 {
+		return nil, false, nil
+	}
+	ret := make(map[string]any, len(s.payload))
+	for k, v := range s.payload {
+		ret[k] = v
+	}
+	return ret, true, nil
+}
+
+func errorFinding(file string, path []string, format string, args ...any) configdoc.Finding {
+	return configdoc.Finding{
+		File:     file,
+		Path:     configdoc.FormatPath(path),
+		Severity: configdoc.FindingSeverityError,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
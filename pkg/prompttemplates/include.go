@@ -0,0 +1,68 @@
+package prompttemplates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-go-golems/pinocchio/pkg/filefilter"
+)
+
+// includeFuncs returns the file helpers available to every prompt template.
+// Template function names cannot contain dashes, hence the camel case.
+//
+//	{{ includeFile "docs/style.md" }}
+//	{{ includeGlob "pkg/**/*.go" }}
+//
+// includeGlob supports ** and skips what catter skips by default: binary
+// files, files over 1MB, vendored or generated directories, and whatever the
+// .gitignore and .catterignore files below baseDir exclude.
+func includeFuncs(baseDir string) template.FuncMap {
+	resolve := func(file string) string {
+		if baseDir == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(baseDir, file)
+	}
+	return template.FuncMap{
+		"includeFile": func(file string) (string, error) {
+			b, err := os.ReadFile(resolve(file))
+			if err != nil {
+				return "", fmt.Errorf("includeFile: %w", err)
+			}
+			return string(b), nil
+		},
+		"includeGlob": func(pattern string) (string, error) {
+			matches, err := doublestar.FilepathGlob(resolve(pattern), doublestar.WithFilesOnly())
+			if err != nil {
+				return "", fmt.Errorf("includeGlob %q: %w", pattern, err)
+			}
+			ff := filefilter.NewFileFilter(
+				filefilter.WithFilterBinaryFiles(true),
+				filefilter.WithRoot(baseDir),
+			)
+			var b strings.Builder
+			for _, match := range matches {
+				name := match
+				if baseDir != "" {
+					if rel, err := filepath.Rel(baseDir, match); err == nil {
+						name = rel
+					}
+				}
+				name = filepath.ToSlash(name)
+				if !ff.FilterPath(match) {
+					continue
+				}
+				content, err := os.ReadFile(match)
+				if err != nil {
+					return "", fmt.Errorf("includeGlob %q: %w", pattern, err)
+				}
+				fmt.Fprintf(&b, "--- START FILE: %s ---\n%s\n--- END FILE: %s ---\n", name, strings.TrimRight(string(content), "\n"), name)
+			}
+			return b.String(), nil
+		},
+	}
+}
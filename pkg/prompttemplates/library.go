@@ -0,0 +1,188 @@
+// Package prompttemplates renders Pinocchio prompt templates with partials
+// and shared variables collected from command repositories.
+//
+// Every repository may contain a hidden .templates directory (hidden so the
+// command loader skips it):
+//
+//	.templates/persona.tmpl     partial, used as {{ template "persona" . }}
+//	.templates/format.tmpl      may also {{ define }} further partials
+//	.templates/vars.yaml        shared variables, merged below command flags
+//
+// Repositories added later override partials and variables of earlier ones.
+package prompttemplates
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/go-go-golems/glazed/pkg/helpers/templating"
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is the directory of a repository that holds partials and variables.
+const Dir = ".templates"
+
+const partialExt = ".tmpl"
+
+type partial struct {
+	name   string
+	source string
+	text   string
+}
+
+// Library holds partials and shared variables. The zero value and a nil
+// *Library render templates with the include helpers only.
+type Library struct {
+	partials []partial
+	vars     map[string]any
+	// BaseDir resolves relative includeFile and includeGlob paths. Empty means
+	// the working directory.
+	BaseDir string
+}
+
+func NewLibrary() *Library {
+	return &Library{vars: map[string]any{}}
+}
+
+// AddDir adds the .templates directory of the repository at dir. A
+// repository without one is skipped.
+func (l *Library) AddDir(dir string) error {
+	return l.AddFS(os.DirFS(dir), Dir, dir)
+}
+
+// AddFS adds the partials and variable files found directly in dir of fsys.
+// source names the repository in error messages.
+func (l *Library) AddFS(fsys fs.FS, dir string, source string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read templates of %s: %w", source, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		file := path.Join(dir, name)
+		switch ext := path.Ext(name); ext {
+		case partialExt:
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return fmt.Errorf("read partial %s/%s: %w", source, file, err)
+			}
+			if err := l.AddPartial(strings.TrimSuffix(name, ext), string(b), source+"/"+file); err != nil {
+				return err
+			}
+		case ".yaml", ".yml":
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return fmt.Errorf("read variables %s/%s: %w", source, file, err)
+			}
+			if err := l.addVars(b, source+"/"+file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddPartial registers text as the template name. It is parsed once here so
+// syntax errors point at their file.
+func (l *Library) AddPartial(name string, text string, source string) error {
+	if _, err := l.newTemplate(name).Parse(text); err != nil {
+		return fmt.Errorf("parse partial %s: %w", source, err)
+	}
+	l.partials = append(l.partials, partial{name: name, source: source, text: text})
+	return nil
+}
+
+// AddVarsFile merges a YAML or JSON variables file over the current shared
+// variables.
+func (l *Library) AddVarsFile(file string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read variables %s: %w", file, err)
+	}
+	return l.addVars(b, file)
+}
+
+func (l *Library) addVars(b []byte, source string) error {
+	vars := map[string]any{}
+	if err := yaml.Unmarshal(b, &vars); err != nil {
+		return fmt.Errorf("parse variables %s: %w", source, err)
+	}
+	if l.vars == nil {
+		l.vars = map[string]any{}
+	}
+	maps.Copy(l.vars, vars)
+	return nil
+}
+
+// Partials returns the names of the registered partial files, without the
+// templates they define internally.
+func (l *Library) Partials() []string {
+	if l == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var ret []string
+	for _, p := range l.partials {
+		if !seen[p.name] {
+			seen[p.name] = true
+			ret = append(ret, p.name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Vars returns a copy of the shared variables.
+func (l *Library) Vars() map[string]any {
+	ret := map[string]any{}
+	if l != nil {
+		maps.Copy(ret, l.vars)
+	}
+	return ret
+}
+
+// Render executes text with the shared variables overlaid by vars.
+func (l *Library) Render(name string, text string, vars map[string]any) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+	tpl := l.newTemplate(name)
+	if l != nil {
+		for _, p := range l.partials {
+			if _, err := tpl.New(p.name).Parse(p.text); err != nil {
+				return "", fmt.Errorf("parse partial %s: %w", p.source, err)
+			}
+		}
+	}
+	if _, err := tpl.Parse(text); err != nil {
+		return "", err
+	}
+	data := l.Vars()
+	maps.Copy(data, vars)
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (l *Library) newTemplate(name string) *template.Template {
+	baseDir := ""
+	if l != nil {
+		baseDir = l.BaseDir
+	}
+	return templating.CreateTemplate(name).Funcs(includeFuncs(baseDir))
+}
@@ -0,0 +1,94 @@
+package prompttemplates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestRenderUsesPartialsAndSharedVars(t *testing.T) {
+	lib := NewLibrary()
+	base := fstest.MapFS{
+		".templates/persona.tmpl": {Data: []byte(`You are {{ .persona }}.`)},
+		".templates/rules.tmpl":   {Data: []byte(`{{ define "bullet" }}- {{ . }}{{ end }}Rules: {{ range .rules }}{{ template "bullet" . }} {{ end }}`)},
+		".templates/vars.yaml":    {Data: []byte("persona: a careful reviewer\nrules: [be brief]\nlang: go\n")},
+	}
+	override := fstest.MapFS{
+		".templates/persona.tmpl": {Data: []byte(`You are {{ .persona }}, writing {{ .lang }}.`)},
+		".templates/vars.yml":     {Data: []byte("lang: rust\n")},
+	}
+	if err := lib.AddFS(base, Dir, "base"); err != nil {
+		t.Fatalf("AddFS base: %v", err)
+	}
+	if err := lib.AddFS(override, Dir, "override"); err != nil {
+		t.Fatalf("AddFS override: %v", err)
+	}
+	if err := lib.AddFS(fstest.MapFS{}, Dir, "empty"); err != nil {
+		t.Fatalf("repositories without templates should be skipped: %v", err)
+	}
+
+	out, err := lib.Render("prompt", `{{ template "persona" . }} {{ template "rules" . }}`, map[string]any{"persona": "a pirate"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "You are a pirate, writing rust. Rules: - be brief "; out != want {
+		t.Fatalf("Render = %q, want %q", out, want)
+	}
+	if got := strings.Join(lib.Partials(), ","); got != "persona,rules" {
+		t.Fatalf("Partials = %s", got)
+	}
+}
+
+func TestAddFSReportsPartialSyntaxErrors(t *testing.T) {
+	err := NewLibrary().AddFS(fstest.MapFS{".templates/broken.tmpl": {Data: []byte(`{{ if }}`)}}, Dir, "repo")
+	if err == nil || !strings.Contains(err.Error(), "repo/.templates/broken.tmpl") {
+		t.Fatalf("expected error naming the partial, got %v", err)
+	}
+}
+
+func TestIncludeHelpers(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "style.md", "Use short sentences.\n")
+	writeFile(t, dir, "src/a.go", "package a\n")
+	writeFile(t, dir, "src/sub/b.go", "package b\n")
+	writeFile(t, dir, "src/vendor/c.go", "package c\n")
+	writeFile(t, dir, "src/go.sum", "sum\n")
+
+	lib := NewLibrary()
+	lib.BaseDir = dir
+	out, err := lib.Render("prompt", `{{ includeFile "style.md" }}{{ includeGlob "src/**/*" }}`, nil)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "Use short sentences.\n" +
+		"--- START FILE: src/a.go ---\npackage a\n--- END FILE: src/a.go ---\n" +
+		"--- START FILE: src/sub/b.go ---\npackage b\n--- END FILE: src/sub/b.go ---\n"
+	if out != want {
+		t.Fatalf("Render = %q, want %q", out, want)
+	}
+
+	if _, err := lib.Render("prompt", `{{ includeFile "missing.md" }}`, nil); err == nil {
+		t.Fatalf("expected missing include to fail")
+	}
+}
+
+func TestNilLibraryRendersPlainTemplates(t *testing.T) {
+	var lib *Library
+	out, err := lib.Render("prompt", `Hello {{ .name | upper }}`, map[string]any{"name": "ada"})
+	if err != nil || out != "Hello ADA" {
+		t.Fatalf("Render = %q, %v", out, err)
+	}
+}
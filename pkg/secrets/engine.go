@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-go-golems/geppetto/pkg/inference/engine"
+	"github.com/go-go-golems/geppetto/pkg/inference/engine/factory"
+	aisettings "github.com/go-go-golems/geppetto/pkg/steps/ai/settings"
+)
+
+// ResolveInferenceSettings returns a clone of settings with secret references
+// in the API keys resolved. settings itself keeps the references so it stays
+// safe to print. Base URLs are never expanded: a reference there is an error,
+// so a config file cannot send a resolved key to a host it chose.
+func ResolveInferenceSettings(ctx context.Context, resolver *Resolver, settings *aisettings.InferenceSettings) (*aisettings.InferenceSettings, error) {
+	if settings == nil || settings.API == nil {
+		return settings, nil
+	}
+	if resolver == nil {
+		resolver = DefaultResolver()
+	}
+	for key, value := range settings.API.BaseUrls {
+		if _, isRef, _ := ParseRef(value); isRef {
+			return nil, fmt.Errorf("%s: secret references are not allowed in base URLs", key)
+		}
+	}
+	apiKeys, err := resolver.ResolveMap(ctx, settings.API.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	ret := settings.Clone()
+	ret.API.APIKeys = apiKeys
+	return ret, nil
+}
+
+type resolvingEngineFactory struct {
+	factory.EngineFactory
+	resolver *Resolver
+}
+
+// NewResolvingEngineFactory wraps inner so secret references are resolved
+// right before each engine is created. A nil inner is the standard factory and
+// a nil resolver is DefaultResolver. Wrapping an already wrapping factory is a
+// no-op.
+func NewResolvingEngineFactory(inner factory.EngineFactory, resolver *Resolver) factory.EngineFactory {
+	if inner == nil {
+		inner = factory.NewStandardEngineFactory()
+	}
+	if already, ok := inner.(*resolvingEngineFactory); ok {
+		return already
+	}
+	return &resolvingEngineFactory{EngineFactory: inner, resolver: resolver}
+}
+
+func (f *resolvingEngineFactory) CreateEngine(settings *aisettings.InferenceSettings) (engine.Engine, error) {
+	resolved, err := ResolveInferenceSettings(context.Background(), f.resolver, settings)
+	if err != nil {
+		return nil, err
+	}
+	return f.EngineFactory.CreateEngine(resolved)
+}
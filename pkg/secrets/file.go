@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	secretFileVersion = 1
+	secretFileKDF     = "scrypt"
+	secretFileCipher  = "xchacha20poly1305"
+
+	// scrypt work factor; about 100ms on current hardware.
+	scryptLogN = 15
+	scryptR    = 8
+	scryptP    = 1
+)
+
+// ErrWrongPassphrase is returned when a secret file cannot be decrypted.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted secret file")
+
+// secretFileEnvelope is the on-disk form of a secret file. The entries are a
+// JSON object encrypted with XChaCha20-Poly1305 under a key derived from the
+// passphrase with scrypt, the same construction age uses for passphrase
+// recipients.
+type secretFileEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	LogN       int    `json:"log_n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ReadSecretFile decrypts the secret file at path. A missing file yields no
+// entries.
+func ReadSecretFile(path string, passphrase []byte) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secret file: %w", err)
+	}
+	envelope := secretFileEnvelope{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("secret file %s is not a pinocchio secret file: %w", path, err)
+	}
+	if envelope.Version != secretFileVersion || envelope.KDF != secretFileKDF || envelope.Cipher != secretFileCipher {
+		return nil, fmt.Errorf("secret file %s has unsupported format version=%d kdf=%q cipher=%q", path, envelope.Version, envelope.KDF, envelope.Cipher)
+	}
+	if envelope.LogN < 10 || envelope.LogN > 22 {
+		return nil, fmt.Errorf("secret file %s has invalid scrypt work factor %d", path, envelope.LogN)
+	}
+	aead, err := secretFileAEAD(passphrase, envelope.Salt, envelope.LogN, envelope.R, envelope.P)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("secret file %s has an invalid nonce", path)
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Ciphertext, secretFileAAD(envelope))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	entries := map[string]string{}
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, fmt.Errorf("decode secret file entries: %w", err)
+	}
+	return entries, nil
+}
+
+// WriteSecretFile encrypts entries into path with a fresh salt and nonce. The
+// file is written owner-only through a temporary file and a rename.
+func WriteSecretFile(path string, passphrase []byte, entries map[string]string) error {
+	if len(passphrase) == 0 {
+		return fmt.Errorf("secret file passphrase is empty")
+	}
+	envelope := secretFileEnvelope{
+		Version: secretFileVersion,
+		KDF:     secretFileKDF,
+		LogN:    scryptLogN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, 16),
+		Cipher:  secretFileCipher,
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return err
+	}
+	aead, err := secretFileAEAD(passphrase, envelope.Salt, envelope.LogN, envelope.R, envelope.P)
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = map[string]string{}
+	}
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	envelope.Ciphertext = aead.Seal(nil, envelope.Nonce, plaintext, secretFileAAD(envelope))
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create secret file dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func secretFileAEAD(passphrase, salt []byte, logN, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<logN, r, p, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive secret file key: %w", err)
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// secretFileAAD binds the KDF parameters to the ciphertext so they cannot be
+// downgraded without failing authentication.
+func secretFileAAD(envelope secretFileEnvelope) []byte {
+	return []byte(fmt.Sprintf("pinocchio-secrets/v%d/%s/%d/%d/%d/%s", envelope.Version, envelope.KDF, envelope.LogN, envelope.R, envelope.P, envelope.Cipher))
+}
@@ -0,0 +1,109 @@
+// Package secrets resolves secret references in config and profile values.
+//
+// A config value that is exactly ${scheme:argument} is a reference instead of
+// a literal secret:
+//
+//	${env:OPENAI_API_KEY}        environment variable
+//	${file:~/.secrets/openai}    file contents, trailing newline removed
+//	${cmd:pass show openai}      first line of a shell command's output
+//	${enc:openai}                entry of the passphrase-encrypted secret file
+//
+// References stay unresolved in config documents, profile registries and
+// inspection output. They are resolved when an engine is constructed, so
+// config files holding only references can be committed.
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Scheme string
+
+const (
+	SchemeEnv  Scheme = "env"
+	SchemeFile Scheme = "file"
+	SchemeCmd  Scheme = "cmd"
+	SchemeEnc  Scheme = "enc"
+)
+
+var schemes = []Scheme{SchemeEnv, SchemeFile, SchemeCmd, SchemeEnc}
+
+// Ref is a parsed secret reference.
+type Ref struct {
+	Scheme Scheme
+	Arg    string
+}
+
+func (r Ref) String() string {
+	return "${" + string(r.Scheme) + ":" + r.Arg + "}"
+}
+
+// ReadsHost reports whether resolving r runs a command or reads a file,
+// which is only safe for references the user wrote.
+func (r Ref) ReadsHost() bool {
+	return r.Scheme == SchemeCmd || r.Scheme == SchemeFile
+}
+
+// ParseRef parses value as a secret reference. It reports false for values
+// that are not shaped like ${...}, and an error for ones that are but use an
+// unknown scheme or an empty argument.
+func ParseRef(value string) (Ref, bool, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+		return Ref{}, false, nil
+	}
+	inner := value[2 : len(value)-1]
+	scheme, arg, ok := strings.Cut(inner, ":")
+	if !ok {
+		return Ref{}, true, fmt.Errorf("secret reference %q must be ${scheme:argument}", value)
+	}
+	ref := Ref{Scheme: Scheme(strings.TrimSpace(scheme)), Arg: strings.TrimSpace(arg)}
+	if !knownScheme(ref.Scheme) {
+		return Ref{}, true, fmt.Errorf("secret reference %q has unknown scheme %q (expected env, file, cmd or enc)", value, scheme)
+	}
+	if ref.Arg == "" {
+		return Ref{}, true, fmt.Errorf("secret reference %q has an empty argument", value)
+	}
+	return ref, true, nil
+}
+
+// IsRef reports whether value is a valid secret reference.
+func IsRef(value string) bool {
+	_, ok, err := ParseRef(value)
+	return ok && err == nil
+}
+
+func knownScheme(scheme Scheme) bool {
+	for _, s := range schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactValue returns a marker for value that is safe to print. Secret
+// references are reduced to their scheme because command lines and paths can
+// themselves be sensitive.
+func RedactValue(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return value
+	}
+	if ref, ok, err := ParseRef(value); ok && err == nil {
+		return "<redacted:" + string(ref.Scheme) + "-ref>"
+	}
+	return "<redacted>"
+}
+
+// Status describes value without revealing it: "empty", "present" for a
+// literal secret, or "<scheme>-ref" for a reference.
+func Status(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "empty"
+	}
+	if ref, ok, err := ParseRef(value); ok && err == nil {
+		return string(ref.Scheme) + "-ref"
+	}
+	return "present"
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRef(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    Ref
+		isRef   bool
+		wantErr string
+	}{
+		{value: "sk-live"},
+		{value: "${env:OPENAI_API_KEY}", want: Ref{Scheme: SchemeEnv, Arg: "OPENAI_API_KEY"}, isRef: true},
+		{value: " ${cmd:pass show openai} ", want: Ref{Scheme: SchemeCmd, Arg: "pass show openai"}, isRef: true},
+		{value: "${file:~/.secrets/x}", want: Ref{Scheme: SchemeFile, Arg: "~/.secrets/x"}, isRef: true},
+		{value: "${enc:openai}", want: Ref{Scheme: SchemeEnc, Arg: "openai"}, isRef: true},
+		{value: "${vault:x}", isRef: true, wantErr: "unknown scheme"},
+		{value: "${env:}", isRef: true, wantErr: "empty argument"},
+		{value: "${OPENAI_API_KEY}", isRef: true, wantErr: "${scheme:argument}"},
+	}
+	for _, tc := range cases {
+		ref, isRef, err := ParseRef(tc.value)
+		require.Equal(t, tc.isRef, isRef, tc.value)
+		if tc.wantErr != "" {
+			require.ErrorContains(t, err, tc.wantErr, tc.value)
+			continue
+		}
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.want, ref, tc.value)
+	}
+}
+
+func TestRedactValueAndStatus(t *testing.T) {
+	require.Equal(t, "", RedactValue(""))
+	require.Equal(t, "<redacted>", RedactValue("sk-live"))
+	require.Equal(t, "<redacted:cmd-ref>", RedactValue("${cmd:pass show openai}"))
+
+	require.Equal(t, "empty", Status(" "))
+	require.Equal(t, "present", Status("sk-live"))
+	require.Equal(t, "env-ref", Status("${env:OPENAI_API_KEY}"))
+}
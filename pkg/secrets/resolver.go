@@ -0,0 +1,315 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SecretFileEnv overrides the location of the encrypted secret file.
+	SecretFileEnv = "PINOCCHIO_SECRETS_FILE"
+	// PassphraseEnv holds the secret file passphrase for non-interactive use.
+	PassphraseEnv = "PINOCCHIO_SECRETS_PASSPHRASE"
+	// PassphraseFileEnv names a file holding the secret file passphrase.
+	PassphraseFileEnv = "PINOCCHIO_SECRETS_PASSPHRASE_FILE"
+
+	defaultCommandTimeout = 30 * time.Second
+	defaultCacheTTL       = 5 * time.Minute
+)
+
+// PassphraseFunc supplies the passphrase of the encrypted secret file. It is
+// only called when an ${enc:...} reference is resolved.
+type PassphraseFunc func() ([]byte, error)
+
+// Resolver resolves secret references. Command output and the decrypted
+// secret file are cached for a short time so repeated engine construction
+// does not re-run password managers or re-derive the file key; environment
+// variables and plain files are read on every call.
+type Resolver struct {
+	lookupEnv      func(string) (string, bool)
+	secretFile     string
+	passphrase     PassphraseFunc
+	commandTimeout time.Duration
+	cacheTTL       time.Duration
+	now            func() time.Time
+
+	mu            sync.Mutex
+	commands      map[string]cachedValue
+	entries       map[string]string
+	entriesLoaded time.Time
+}
+
+type cachedValue struct {
+	value    string
+	loadedAt time.Time
+}
+
+type Option func(*Resolver)
+
+func WithLookupEnv(lookup func(string) (string, bool)) Option {
+	return func(r *Resolver) {
+		r.lookupEnv = lookup
+	}
+}
+
+// WithSecretFile sets the encrypted secret file used by ${enc:...}.
+func WithSecretFile(path string) Option {
+	return func(r *Resolver) {
+		r.secretFile = path
+	}
+}
+
+func WithPassphrase(passphrase PassphraseFunc) Option {
+	return func(r *Resolver) {
+		r.passphrase = passphrase
+	}
+}
+
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(r *Resolver) {
+		r.commandTimeout = timeout
+	}
+}
+
+// WithCacheTTL sets how long command output and secret file entries are
+// reused. Zero disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Resolver) {
+		r.cacheTTL = ttl
+	}
+}
+
+func NewResolver(opts ...Option) *Resolver {
+	r := &Resolver{
+		lookupEnv:      os.LookupEnv,
+		passphrase:     EnvPassphrase(nil),
+		commandTimeout: defaultCommandTimeout,
+		cacheTTL:       defaultCacheTTL,
+		now:            time.Now,
+		commands:       map[string]cachedValue{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+var (
+	defaultResolverOnce sync.Once
+	defaultResolver     *Resolver
+)
+
+// DefaultResolver is the process-wide resolver used by servers and scripts.
+// It reads the secret file passphrase from the environment only and never
+// prompts.
+func DefaultResolver() *Resolver {
+	defaultResolverOnce.Do(func() {
+		defaultResolver = NewResolver()
+	})
+	return defaultResolver
+}
+
+// DefaultSecretFilePath returns $PINOCCHIO_SECRETS_FILE or
+// ${XDG_CONFIG_HOME}/pinocchio/secrets.enc.
+func DefaultSecretFilePath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(SecretFileEnv)); path != "" {
+		return expandHome(path)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve secret file location: %w", err)
+	}
+	return filepath.Join(dir, "pinocchio", "secrets.enc"), nil
+}
+
+// EnvPassphrase reads the passphrase from PINOCCHIO_SECRETS_PASSPHRASE or the
+// file named by PINOCCHIO_SECRETS_PASSPHRASE_FILE, and otherwise defers to
+// fallback.
+func EnvPassphrase(fallback PassphraseFunc) PassphraseFunc {
+	return func() ([]byte, error) {
+		if value := os.Getenv(PassphraseEnv); value != "" {
+			return []byte(value), nil
+		}
+		if path := strings.TrimSpace(os.Getenv(PassphraseFileEnv)); path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read %s: %w", PassphraseFileEnv, err)
+			}
+			return bytes.TrimRight(data, "\r\n"), nil
+		}
+		if fallback != nil {
+			return fallback()
+		}
+		return nil, fmt.Errorf("secret file passphrase not available; set %s or %s", PassphraseEnv, PassphraseFileEnv)
+	}
+}
+
+// Resolve returns value with a secret reference replaced by the secret.
+// Values that are not references are returned unchanged. Errors name the
+// reference but never include the secret.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok, err := ParseRef(value)
+	if err != nil || !ok {
+		return value, err
+	}
+	var secret string
+	switch ref.Scheme {
+	case SchemeEnv:
+		secret, err = r.resolveEnv(ref)
+	case SchemeFile:
+		secret, err = resolveFile(ref)
+	case SchemeCmd:
+		secret, err = r.resolveCommand(ctx, ref)
+	case SchemeEnc:
+		secret, err = r.resolveEncrypted(ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %s: %w", ref, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("resolve secret %s: value is empty", ref)
+	}
+	return secret, nil
+}
+
+// ResolveMap returns a copy of values with every reference resolved.
+func (r *Resolver) ResolveMap(ctx context.Context, values map[string]string) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	ret := make(map[string]string, len(values))
+	for key, value := range values {
+		resolved, err := r.Resolve(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		ret[key] = resolved
+	}
+	return ret, nil
+}
+
+func (r *Resolver) resolveEnv(ref Ref) (string, error) {
+	value, ok := r.lookupEnv(ref.Arg)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref.Arg)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func resolveFile(ref Ref) (string, error) {
+	path, err := expandHome(ref.Arg)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func (r *Resolver) resolveCommand(ctx context.Context, ref Ref) (string, error) {
+	if value, ok := r.cached(ref.Arg); ok {
+		return value, nil
+	}
+	if r.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.commandTimeout)
+		defer cancel()
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", ref.Arg)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", ref.Arg)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := firstLine(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+	// Password managers print the secret on the first line and metadata
+	// after it.
+	value := firstLine(string(out))
+
+	r.mu.Lock()
+	r.commands[ref.Arg] = cachedValue{value: value, loadedAt: r.now()}
+	r.mu.Unlock()
+	return value, nil
+}
+
+func (r *Resolver) cached(command string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.commands[command]
+	if !ok || r.cacheTTL <= 0 || r.now().Sub(entry.loadedAt) > r.cacheTTL {
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (r *Resolver) resolveEncrypted(ref Ref) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries == nil || r.cacheTTL <= 0 || r.now().Sub(r.entriesLoaded) > r.cacheTTL {
+		path := r.secretFile
+		if path == "" {
+			var err error
+			if path, err = DefaultSecretFilePath(); err != nil {
+				return "", err
+			}
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("secret file %s: %w", path, err)
+		}
+		if r.passphrase == nil {
+			return "", fmt.Errorf("no passphrase source for secret file %s", path)
+		}
+		passphrase, err := r.passphrase()
+		if err != nil {
+			return "", err
+		}
+		entries, err := ReadSecretFile(path, passphrase)
+		if err != nil {
+			return "", err
+		}
+		r.entries, r.entriesLoaded = entries, r.now()
+	}
+	value, ok := r.entries[ref.Arg]
+	if !ok {
+		return "", fmt.Errorf("secret file has no entry %q", ref.Arg)
+	}
+	return value, nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimLeft(s, "\r\n")
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func expandHome(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
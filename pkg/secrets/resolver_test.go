@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverResolvesEachScheme(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-secret\n"), 0o600))
+	secretFile := filepath.Join(dir, "secrets.enc")
+	require.NoError(t, WriteSecretFile(secretFile, []byte("pw"), map[string]string{"openai": "enc-secret"}))
+
+	r := NewResolver(
+		WithLookupEnv(func(name string) (string, bool) {
+			if name == "OPENAI_API_KEY" {
+				return "env-secret", true
+			}
+			return "", false
+		}),
+		WithSecretFile(secretFile),
+		WithPassphrase(func() ([]byte, error) { return []byte("pw"), nil }),
+	)
+	ctx := context.Background()
+
+	cases := map[string]string{
+		"literal":                 "literal",
+		"${env:OPENAI_API_KEY}":   "env-secret",
+		"${file:" + keyFile + "}": "file-secret",
+		"${enc:openai}":           "enc-secret",
+	}
+	if runtime.GOOS != "windows" {
+		cases["${cmd:printf 'cmd-secret\\nuser: me\\n'}"] = "cmd-secret"
+	}
+	for value, want := range cases {
+		got, err := r.Resolve(ctx, value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+
+	_, err := r.Resolve(ctx, "${env:MISSING}")
+	require.ErrorContains(t, err, "MISSING is not set")
+	_, err = r.Resolve(ctx, "${enc:missing}")
+	require.ErrorContains(t, err, `no entry "missing"`)
+}
+
+func TestResolverErrorsDoNotLeakSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	r := NewResolver()
+	_, err := r.Resolve(context.Background(), "${cmd:echo sk-leaked; exit 3}")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "sk-leaked\n")
+	require.Contains(t, err.Error(), "exit status 3")
+}
+
+func TestResolveMapCopies(t *testing.T) {
+	r := NewResolver(WithLookupEnv(func(string) (string, bool) { return "resolved", true }))
+	in := map[string]string{"openai-api-key": "${env:X}", "claude-api-key": "literal"}
+	out, err := r.ResolveMap(context.Background(), in)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"openai-api-key": "resolved", "claude-api-key": "literal"}, out)
+	require.Equal(t, "${env:X}", in["openai-api-key"])
+}
+
+func TestSecretFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "secrets.enc")
+	entries, err := ReadSecretFile(path, []byte("pw"))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, WriteSecretFile(path, []byte("pw"), map[string]string{"a": "1", "b": "2"}))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `"a"`)
+
+	entries, err = ReadSecretFile(path, []byte("pw"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1", "b": "2"}, entries)
+
+	_, err = ReadSecretFile(path, []byte("wrong"))
+	require.ErrorIs(t, err, ErrWrongPassphrase)
+}
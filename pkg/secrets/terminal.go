@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// TerminalPassphrase prompts for the secret file passphrase on the terminal.
+// It fails instead of blocking when stdin is not a terminal.
+func TerminalPassphrase(prompt string) PassphraseFunc {
+	return func() ([]byte, error) {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("secret file passphrase not available; set %s or run from a terminal", PassphraseEnv)
+		}
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase: %w", err)
+		}
+		return passphrase, nil
+	}
+}
@@ -0,0 +1,177 @@
+// Package pgtest provides a disposable PostgreSQL integration-test
+// environment. It is intended for _test.go consumers and must not be used by
+// production runtime code.
+package pgtest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	image             = "postgres:17.6-alpine"
+	bootstrapDatabase = "pinocchio_bootstrap"
+	adminUser         = "postgres"
+	adminPassword     = "test-admin-password"
+	appUser           = "pinocchio_app"
+)
+
+// Instance is a disposable PostgreSQL server and the two credentials used by
+// the integration tests. AppDSN is the only DSN that should be passed to the
+// adapter under test. AdminDSN is reserved for test setup and fault injection.
+type Instance struct {
+	Container testcontainers.Container
+	AppDSN    string
+	AdminDSN  string
+	Database  string
+}
+
+// Start starts a fresh PostgreSQL container, creates a random database owned
+// by an application role and returns URL-style DSNs for both roles. The
+// container has no volume or fixed host port and is safe to terminate after
+// the package test completes.
+func Start(ctx context.Context) (*Instance, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("pgtest: context is nil")
+	}
+	container, err := testcontainers.Run(
+		ctx,
+		image,
+		testcontainers.WithEnv(map[string]string{
+			"POSTGRES_USER":     adminUser,
+			"POSTGRES_PASSWORD": adminPassword,
+			"POSTGRES_DB":       bootstrapDatabase,
+		}),
+		testcontainers.WithExposedPorts("5432/tcp"),
+		testcontainers.WithWaitStrategy(
+			// The entrypoint starts a temporary server for initdb first, so
+			// readiness is logged twice.
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(90*time.Second),
+			wait.ForListeningPort("5432/tcp"),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("pgtest: start %s: %w", image, err)
+	}
+	cleanupOnError := true
+	defer func() {
+		if cleanupOnError {
+			_ = container.Terminate(context.Background())
+		}
+	}()
+
+	endpoint, err := container.PortEndpoint(ctx, "5432/tcp", "")
+	if err != nil {
+		return nil, fmt.Errorf("pgtest: get endpoint: %w", err)
+	}
+
+	database, err := randomIdentifier("pinocchio_test_")
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomSecret(24)
+	if err != nil {
+		return nil, err
+	}
+
+	adminDB, err := sql.Open("pgx", formatDSN(endpoint, adminUser, adminPassword, bootstrapDatabase))
+	if err != nil {
+		return nil, fmt.Errorf("pgtest: open admin connection: %w", err)
+	}
+	defer func() { _ = adminDB.Close() }()
+	if err := adminDB.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("pgtest: ping admin connection: %w", err)
+	}
+
+	if _, err := adminDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE ROLE %s LOGIN PASSWORD '%s'",
+		quoteIdentifier(appUser),
+		escapeSQLString(password),
+	)); err != nil {
+		return nil, fmt.Errorf("pgtest: create application role: %w", err)
+	}
+	// Owning the database gives the application role CREATE on its public
+	// schema, which PostgreSQL 15+ no longer grants to PUBLIC.
+	if _, err := adminDB.ExecContext(ctx, fmt.Sprintf(
+		"CREATE DATABASE %s OWNER %s",
+		quoteIdentifier(database),
+		quoteIdentifier(appUser),
+	)); err != nil {
+		return nil, fmt.Errorf("pgtest: create database %q: %w", database, err)
+	}
+
+	instance := &Instance{
+		Container: container,
+		AdminDSN:  formatDSN(endpoint, adminUser, adminPassword, database),
+		AppDSN:    formatDSN(endpoint, appUser, password, database),
+		Database:  database,
+	}
+	cleanupOnError = false
+	return instance, nil
+}
+
+// Close terminates the disposable container. It is safe to call more than
+// once; callers should pass a bounded cleanup context.
+func (i *Instance) Close(ctx context.Context) error {
+	if i == nil || i.Container == nil {
+		return nil
+	}
+	if ctx == nil {
+		return fmt.Errorf("pgtest: cleanup context is nil")
+	}
+	return i.Container.Terminate(ctx)
+}
+
+func formatDSN(endpoint, user, password, database string) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     endpoint,
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+func randomIdentifier(prefix string) (string, error) {
+	secret, err := randomSecret(8)
+	if err != nil {
+		return "", fmt.Errorf("pgtest: generate identifier: %w", err)
+	}
+	return prefix + strings.ToLower(secret), nil
+}
+
+func randomSecret(length int) (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	if length <= 0 {
+		return "", fmt.Errorf("pgtest: invalid secret length %d", length)
+	}
+	buf := make([]byte, length)
+	raw := make([]byte, length)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	for i, b := range raw {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf), nil
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func escapeSQLString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
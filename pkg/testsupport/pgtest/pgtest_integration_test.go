@@ -0,0 +1,51 @@
+package pgtest
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestStartDisposablePostgres(t *testing.T) {
+	if os.Getenv("PINOCCHIO_POSTGRES_TESTCONTAINERS") != "1" {
+		t.Skip("set PINOCCHIO_POSTGRES_TESTCONTAINERS=1 to run disposable PostgreSQL integration test")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	instance, err := Start(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cleanupCancel()
+		if err := instance.Close(cleanupCtx); err != nil {
+			t.Errorf("close disposable PostgreSQL: %v", err)
+		}
+	})
+	if instance.AppDSN == instance.AdminDSN {
+		t.Fatal("application and admin DSNs must be distinct")
+	}
+	appDB, err := sql.Open("pgx", instance.AppDSN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = appDB.Close() }()
+	if err := appDB.PingContext(ctx); err != nil {
+		t.Fatalf("ping application DSN: %v", err)
+	}
+	var currentUser string
+	if err := appDB.QueryRowContext(ctx, "SELECT current_user").Scan(&currentUser); err != nil {
+		t.Fatal(err)
+	}
+	if currentUser != appUser {
+		t.Fatalf("application DSN connected as %s, want %s", currentUser, appUser)
+	}
+	if _, err := appDB.ExecContext(ctx, "CREATE TABLE pgtest_probe (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("application role cannot create tables: %v", err)
+	}
+}